@@ -19,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
@@ -42,6 +43,7 @@ const (
 	ADUserFlag                 = "azuredevops-user"
 	AllowForkPRsFlag           = "allow-fork-prs"
 	AllowRepoConfigFlag        = "allow-repo-config"
+	APISecretFlag              = "api-secret" // nolint: gosec
 	AtlantisURLFlag            = "atlantis-url"
 	AutomergeFlag              = "automerge"
 	BitbucketBaseURLFlag       = "bitbucket-base-url"
@@ -53,6 +55,16 @@ const (
 	DataDirFlag                = "data-dir"
 	DefaultTFVersionFlag       = "default-tf-version"
 	DisableApplyAllFlag        = "disable-apply-all"
+	DrainTimeoutFlag           = "drain-timeout"
+	DriftDetectionIntervalFlag = "drift-detection-interval"
+	EventWorkersFlag           = "event-workers"
+	GiteaBaseURLFlag           = "gitea-base-url"
+	GiteaTokenFlag             = "gitea-token" // nolint: gosec
+	GiteaUserFlag              = "gitea-user"
+	GiteaWebhookSecretFlag     = "gitea-webhook-secret" // nolint: gosec
+	GHAppIDFlag                = "gh-app-id"
+	GHAppKeyFileFlag           = "gh-app-key-file"
+	GHChecksFlag               = "gh-checks"
 	GHHostnameFlag             = "gh-hostname"
 	GHTokenFlag                = "gh-token"
 	GHUserFlag                 = "gh-user"
@@ -61,8 +73,21 @@ const (
 	GitlabTokenFlag            = "gitlab-token"
 	GitlabUserFlag             = "gitlab-user"
 	GitlabWebhookSecretFlag    = "gitlab-webhook-secret" // nolint: gosec
+	HistoryRetentionFlag       = "history-retention"
+	LockReaperIntervalFlag     = "lock-reaper-interval"
+	LockTTLFlag                = "lock-ttl"
+	LockingDBType              = "locking-db-type"
+	LogFormatFlag              = "log-format"
 	LogLevelFlag               = "log-level"
+	MaxPlanAgeFlag             = "max-plan-age"
+	ParallelPoolSizeFlag       = "parallel-pool-size"
 	PortFlag                   = "port"
+	RedisDB                    = "redis-db"
+	RedisHost                  = "redis-host"
+	RedisInsecureSkipVerify    = "redis-insecure-skip-verify"
+	RedisPassword              = "redis-password"
+	RedisPort                  = "redis-port"
+	RedisTLSEnabled            = "redis-tls-enabled"
 	RepoConfigFlag             = "repo-config"
 	RepoConfigJSONFlag         = "repo-config-json"
 	RepoWhitelistFlag          = "repo-whitelist"
@@ -77,6 +102,14 @@ const (
 	VCSStatusName              = "vcs-status-name"
 	TFEHostnameFlag            = "tfe-hostname"
 	TFETokenFlag               = "tfe-token"
+	WebAdminsFlag              = "web-admins"
+	WebAuthFlag                = "web-auth"
+	WebBasicAuthUsersFlag      = "web-basic-auth-users"
+	WebOIDCClientIDFlag        = "web-oidc-client-id"
+	WebOIDCClientSecretFlag    = "web-oidc-client-secret" // nolint: gosec
+	WebOIDCGroupsClaimFlag     = "web-oidc-groups-claim"
+	WebOIDCIssuerURLFlag       = "web-oidc-issuer-url"
+	WebViewersFlag             = "web-viewers"
 	WriteGitCredsFlag          = "write-git-creds"
 
 	// NOTE: Must manually set these as defaults in the setDefaults function.
@@ -85,13 +118,24 @@ const (
 	DefaultCheckoutStrategy = "branch"
 	DefaultBitbucketBaseURL = bitbucketcloud.BaseURL
 	DefaultDataDir          = "~/.atlantis"
+	DefaultDrainTimeout     = "5m"
+	DefaultEventWorkers     = 10
 	DefaultGHHostname       = "github.com"
+	DefaultGiteaBaseURL     = "https://gitea.com"
 	DefaultGitlabHostname   = "gitlab.com"
+	DefaultHistoryRetention = "720h"
+	DefaultLockingDBType    = "boltdb"
+	DefaultLogFormat        = "text"
 	DefaultLogLevel         = "info"
+	DefaultParallelPoolSize = 15
 	DefaultPort             = 4141
+	DefaultRedisDB          = 0
+	DefaultRedisPort        = 6379
 	DefaultTFDownloadURL    = "https://releases.hashicorp.com"
 	DefaultTFEHostname      = "app.terraform.io"
 	DefaultVCSStatusName    = "atlantis"
+	DefaultWebAuth          = "none"
+	DefaultWebOIDCGroups    = "groups"
 )
 
 var stringFlags = map[string]stringFlag{
@@ -113,6 +157,10 @@ var stringFlags = map[string]stringFlag{
 		description:  "Azure DevOps basic HTTP authentication username for inbound webhooks.",
 		defaultValue: "",
 	},
+	APISecretFlag: {
+		description: "Secret used to authenticate requests to the API via the X-Atlantis-Token header. If not specified, the API is disabled." +
+			" Can also be specified via the ATLANTIS_API_SECRET environment variable.",
+	},
 	AtlantisURLFlag: {
 		description: "URL that Atlantis can be reached at. Defaults to http://$(hostname):$port where $port is from --" + PortFlag + ". Supports a base path ex. https://example.com/basepath.",
 	},
@@ -150,10 +198,41 @@ var stringFlags = map[string]stringFlag{
 		description:  "Path to directory to store Atlantis data.",
 		defaultValue: DefaultDataDir,
 	},
+	DrainTimeoutFlag: {
+		description: "How long to wait for running plans and applies to finish when Atlantis is shut down, ex. 5m." +
+			" Webhooks received while waiting are rejected so that the VCS host retries them." +
+			" Commands still running after the timeout are interrupted. Set to 0 to interrupt them straight away.",
+		defaultValue: DefaultDrainTimeout,
+	},
+	DriftDetectionIntervalFlag: {
+		description: "How often to plan the default branch of each repo with drift_detection enabled in the server-side repo config" +
+			" to detect drift, ex. 1h or 30m. If not set, drift detection is disabled.",
+	},
+	GiteaBaseURLFlag: {
+		description: "Base URL of your Gitea or Forgejo installation." +
+			" Must include 'http://' or 'https://'." +
+			" If using gitea.com, no need to set.",
+		defaultValue: DefaultGiteaBaseURL,
+	},
+	GiteaUserFlag: {
+		description: "Gitea username of API user.",
+	},
+	GiteaTokenFlag: {
+		description: "Gitea access token of API user. Can also be specified via the ATLANTIS_GITEA_TOKEN environment variable.",
+	},
+	GiteaWebhookSecretFlag: {
+		description: "Secret used to validate Gitea webhooks." +
+			" SECURITY WARNING: If not specified, Atlantis won't be able to validate that the incoming webhook call came from Gitea. " +
+			"This means that an attacker could spoof calls to Atlantis and cause it to perform malicious actions. " +
+			"Should be specified via the ATLANTIS_GITEA_WEBHOOK_SECRET environment variable.",
+	},
 	GHHostnameFlag: {
 		description:  "Hostname of your Github Enterprise installation. If using github.com, no need to set.",
 		defaultValue: DefaultGHHostname,
 	},
+	GHAppKeyFileFlag: {
+		description: "Path to the private key of the GitHub App to authenticate as. Must be set with --" + GHAppIDFlag + ".",
+	},
 	GHUserFlag: {
 		description: "GitHub username of API user.",
 	},
@@ -182,10 +261,44 @@ var stringFlags = map[string]stringFlag{
 			"This means that an attacker could spoof calls to Atlantis and cause it to perform malicious actions. " +
 			"Should be specified via the ATLANTIS_GITLAB_WEBHOOK_SECRET environment variable.",
 	},
+	HistoryRetentionFlag: {
+		description:  "How long to keep the output of plans and applies that's shown on each pull request's history page, ex. 720h. Set to 0 to keep it forever.",
+		defaultValue: DefaultHistoryRetention,
+	},
+	LockReaperIntervalFlag: {
+		description: "How often to check for locks held by pull requests that have been closed or merged, ex. 1h." +
+			" Their locks are released and their plans discarded. Working dirs of pull requests without any locks are deleted too." +
+			" If not set, locks are only released when the pull request's closed webhook is received.",
+	},
+	LockTTLFlag: {
+		description: "How long a lock can be held before it expires, ex. 168h. Expired locks are released by the stale lock reaper" +
+			" so --" + LockReaperIntervalFlag + " must be set. If not set, locks don't expire.",
+	},
+	LockingDBType: {
+		description: "The locking database type to use for storing plan and apply locks. Either boltdb or redis." +
+			" Use redis to run multiple Atlantis instances against the same locks.",
+		defaultValue: DefaultLockingDBType,
+	},
+	LogFormatFlag: {
+		description: "Log format. Either text or json. With json, each log line is a JSON object that includes" +
+			" fields like the repo, pull request number and VCS request ID.",
+		defaultValue: DefaultLogFormat,
+	},
+	MaxPlanAgeFlag: {
+		description: "How old a plan can be before it expires and has to be generated again before it can be applied," +
+			" ex. 24h. If not set, plans don't expire.",
+	},
 	LogLevelFlag: {
 		description:  "Log level. Either debug, info, warn, or error.",
 		defaultValue: DefaultLogLevel,
 	},
+	RedisHost: {
+		description: fmt.Sprintf("The Redis hostname for when using a --%s of redis.", LockingDBType),
+	},
+	RedisPassword: {
+		description: "The Redis password for when using a --" + LockingDBType + " of redis." +
+			" Should be specified via the ATLANTIS_REDIS_PASSWORD environment variable.",
+	},
 	RepoConfigFlag: {
 		description: "Path to a repo config file, used to customize how Atlantis runs on each repo. See runatlantis.io/docs for more details.",
 	},
@@ -228,6 +341,40 @@ var stringFlags = map[string]stringFlag{
 		description:  "Name used to identify Atlantis for pull request statuses.",
 		defaultValue: DefaultVCSStatusName,
 	},
+	WebAdminsFlag: {
+		description: "Comma-separated list of the users and groups that can view the web UI and delete locks." +
+			" If not set, everyone that can view the web UI can delete locks." +
+			" This or --" + WebViewersFlag + " must be set when using a --" + WebAuthFlag + " of oidc.",
+	},
+	WebAuthFlag: {
+		description: "How users log in to the web UI. Either none, basic or oidc." +
+			" Webhooks, the API, /healthz and /metrics aren't affected.",
+		defaultValue: DefaultWebAuth,
+	},
+	WebBasicAuthUsersFlag: {
+		description: "Comma-separated list of username:password pairs that can log in when using a --" + WebAuthFlag + " of basic." +
+			" Should be specified via the ATLANTIS_WEB_BASIC_AUTH_USERS environment variable for security.",
+	},
+	WebOIDCClientIDFlag: {
+		description: "The OIDC client ID for when using a --" + WebAuthFlag + " of oidc.",
+	},
+	WebOIDCClientSecretFlag: {
+		description: "The OIDC client secret for when using a --" + WebAuthFlag + " of oidc." +
+			" Should be specified via the ATLANTIS_WEB_OIDC_CLIENT_SECRET environment variable for security.",
+	},
+	WebOIDCGroupsClaimFlag: {
+		description:  "The name of the OIDC user info claim that lists the user's groups.",
+		defaultValue: DefaultWebOIDCGroups,
+	},
+	WebOIDCIssuerURLFlag: {
+		description: "The URL of the OIDC provider for when using a --" + WebAuthFlag + " of oidc, ex. https://accounts.google.com." +
+			" The provider's redirect URL must be set to {atlantis url}/auth/callback.",
+	},
+	WebViewersFlag: {
+		description: "Comma-separated list of the users and groups that can view the web UI." +
+			" Admins from --" + WebAdminsFlag + " can always view it. If not set, everyone that can log in can view it." +
+			" This or --" + WebAdminsFlag + " must be set when using a --" + WebAuthFlag + " of oidc.",
+	},
 }
 
 var boolFlags = map[string]boolFlag{
@@ -250,6 +397,18 @@ var boolFlags = map[string]boolFlag{
 		description:  "Disable \"atlantis apply\" command so a specific project/workspace/directory has to be specified for applies.",
 		defaultValue: false,
 	},
+	GHChecksFlag: {
+		description:  "Publish the results of plans and applies in GitHub repos as check runs instead of commit statuses. Requires Atlantis to be authenticated as a GitHub App.",
+		defaultValue: false,
+	},
+	RedisTLSEnabled: {
+		description:  "Enable TLS on the connection to Redis.",
+		defaultValue: false,
+	},
+	RedisInsecureSkipVerify: {
+		description:  "Controls whether the Redis client verifies the Redis server's certificate chain and host name. If true, accepts any certificate presented by the server and any host name in that certificate.",
+		defaultValue: false,
+	},
 	RequireApprovalFlag: {
 		description:  "Require pull requests to be \"Approved\" before allowing the apply command to be run.",
 		defaultValue: false,
@@ -275,10 +434,29 @@ var boolFlags = map[string]boolFlag{
 	},
 }
 var intFlags = map[string]intFlag{
+	EventWorkersFlag: {
+		description:  "How many webhook events, ex. plans triggered by comments, can run at once. Other events wait in a queue that's stored in the database.",
+		defaultValue: DefaultEventWorkers,
+	},
+	GHAppIDFlag: {
+		description: "ID of the GitHub App to authenticate as instead of a GitHub user. Must be set with --" + GHAppKeyFileFlag + ".",
+	},
+	ParallelPoolSizeFlag: {
+		description:  "Max size of the goroutine pool used to run plans and applies in parallel for repos that have enabled parallel_plan or parallel_apply.",
+		defaultValue: DefaultParallelPoolSize,
+	},
 	PortFlag: {
 		description:  "Port to bind to.",
 		defaultValue: DefaultPort,
 	},
+	RedisDB: {
+		description:  "The Redis database number for when using a --" + LockingDBType + " of redis.",
+		defaultValue: DefaultRedisDB,
+	},
+	RedisPort: {
+		description:  "The Redis port for when using a --" + LockingDBType + " of redis.",
+		defaultValue: DefaultRedisPort,
+	},
 }
 
 type stringFlag struct {
@@ -419,6 +597,7 @@ func (s *ServerCmd) run() error {
 	// Now that we've parsed the config we can set our local logger to the
 	// right level.
 	s.Logger.SetLevel(userConfig.ToLogLevel())
+	s.Logger.SetJSON(userConfig.LogFormat == "json")
 
 	if err := s.validate(userConfig); err != nil {
 		return err
@@ -457,6 +636,12 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.DataDir == "" {
 		c.DataDir = DefaultDataDir
 	}
+	if c.DrainTimeout == "" {
+		c.DrainTimeout = DefaultDrainTimeout
+	}
+	if c.EventWorkers == 0 {
+		c.EventWorkers = DefaultEventWorkers
+	}
 	if c.GithubHostname == "" {
 		c.GithubHostname = DefaultGHHostname
 	}
@@ -466,12 +651,30 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.BitbucketBaseURL == "" {
 		c.BitbucketBaseURL = DefaultBitbucketBaseURL
 	}
+	if c.GiteaBaseURL == "" {
+		c.GiteaBaseURL = DefaultGiteaBaseURL
+	}
+	if c.HistoryRetention == "" {
+		c.HistoryRetention = DefaultHistoryRetention
+	}
+	if c.LockingDBType == "" {
+		c.LockingDBType = DefaultLockingDBType
+	}
+	if c.LogFormat == "" {
+		c.LogFormat = DefaultLogFormat
+	}
 	if c.LogLevel == "" {
 		c.LogLevel = DefaultLogLevel
 	}
+	if c.ParallelPoolSize == 0 {
+		c.ParallelPoolSize = DefaultParallelPoolSize
+	}
 	if c.Port == 0 {
 		c.Port = DefaultPort
 	}
+	if c.RedisPort == 0 {
+		c.RedisPort = DefaultRedisPort
+	}
 	if c.TFDownloadURL == "" {
 		c.TFDownloadURL = DefaultTFDownloadURL
 	}
@@ -481,6 +684,12 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.TFEHostname == "" {
 		c.TFEHostname = DefaultTFEHostname
 	}
+	if c.WebAuth == "" {
+		c.WebAuth = DefaultWebAuth
+	}
+	if c.WebOIDCGroupsClaim == "" {
+		c.WebOIDCGroupsClaim = DefaultWebOIDCGroups
+	}
 }
 
 func (s *ServerCmd) validate(userConfig server.UserConfig) error {
@@ -488,11 +697,86 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	if logLevel != "debug" && logLevel != "info" && logLevel != "warn" && logLevel != "error" {
 		return errors.New("invalid log level: not one of debug, info, warn, error")
 	}
+	logFormat := userConfig.LogFormat
+	if logFormat != "text" && logFormat != "json" {
+		return errors.New("invalid log format: not one of text or json")
+	}
 	checkoutStrategy := userConfig.CheckoutStrategy
 	if checkoutStrategy != "branch" && checkoutStrategy != "merge" {
 		return errors.New("invalid checkout strategy: not one of branch or merge")
 	}
 
+	lockingDBType := userConfig.LockingDBType
+	if lockingDBType != "boltdb" && lockingDBType != "redis" {
+		return fmt.Errorf("invalid --%s: not one of boltdb or redis", LockingDBType)
+	}
+	if lockingDBType == "redis" && userConfig.RedisHost == "" {
+		return fmt.Errorf("--%s must be set when using a --%s of redis", RedisHost, LockingDBType)
+	}
+
+	drainTimeout, err := time.ParseDuration(userConfig.DrainTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %s", DrainTimeoutFlag, err)
+	}
+	if drainTimeout < 0 {
+		return fmt.Errorf("--%s can't be negative", DrainTimeoutFlag)
+	}
+
+	if userConfig.DriftDetectionInterval != "" {
+		interval, err := time.ParseDuration(userConfig.DriftDetectionInterval)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", DriftDetectionIntervalFlag, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("--%s must be greater than 0", DriftDetectionIntervalFlag)
+		}
+	}
+
+	if userConfig.EventWorkers < 0 {
+		return fmt.Errorf("--%s must be greater than 0", EventWorkersFlag)
+	}
+
+	if userConfig.LockReaperInterval != "" {
+		interval, err := time.ParseDuration(userConfig.LockReaperInterval)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", LockReaperIntervalFlag, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("--%s must be greater than 0", LockReaperIntervalFlag)
+		}
+	}
+
+	if userConfig.LockTTL != "" {
+		ttl, err := time.ParseDuration(userConfig.LockTTL)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", LockTTLFlag, err)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("--%s must be greater than 0", LockTTLFlag)
+		}
+		if userConfig.LockReaperInterval == "" {
+			return fmt.Errorf("--%s must be set when using --%s since expired locks are released by the stale lock reaper", LockReaperIntervalFlag, LockTTLFlag)
+		}
+	}
+
+	if userConfig.MaxPlanAge != "" {
+		maxPlanAge, err := time.ParseDuration(userConfig.MaxPlanAge)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", MaxPlanAgeFlag, err)
+		}
+		if maxPlanAge <= 0 {
+			return fmt.Errorf("--%s must be greater than 0", MaxPlanAgeFlag)
+		}
+	}
+
+	historyRetention, err := time.ParseDuration(userConfig.HistoryRetention)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %s", HistoryRetentionFlag, err)
+	}
+	if historyRetention < 0 {
+		return fmt.Errorf("--%s can't be negative", HistoryRetentionFlag)
+	}
+
 	if (userConfig.SSLKeyFile == "") != (userConfig.SSLCertFile == "") {
 		return fmt.Errorf("--%s and --%s are both required for ssl", SSLKeyFileFlag, SSLCertFileFlag)
 	}
@@ -502,16 +786,26 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	// 2. gitlab user and token set
 	// 3. bitbucket user and token set
 	// 4. azuredevops user and token set
-	// 5. any combination of the above
-	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag, ADUserFlag, ADTokenFlag)
-	if ((userConfig.GithubUser == "") != (userConfig.GithubToken == "")) || ((userConfig.GitlabUser == "") != (userConfig.GitlabToken == "")) || ((userConfig.BitbucketUser == "") != (userConfig.BitbucketToken == "")) || ((userConfig.AzureDevopsUser == "") != (userConfig.AzureDevopsToken == "")) {
+	// 5. gitea user and token set
+	// 6. github app id and key file set
+	// 7. any combination of the above except github user and github app
+	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GHAppIDFlag, GHAppKeyFileFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag, ADUserFlag, ADTokenFlag, GiteaUserFlag, GiteaTokenFlag)
+	if ((userConfig.GithubUser == "") != (userConfig.GithubToken == "")) || ((userConfig.GithubAppID == 0) != (userConfig.GithubAppKeyFile == "")) || ((userConfig.GitlabUser == "") != (userConfig.GitlabToken == "")) || ((userConfig.BitbucketUser == "") != (userConfig.BitbucketToken == "")) || ((userConfig.AzureDevopsUser == "") != (userConfig.AzureDevopsToken == "")) || ((userConfig.GiteaUser == "") != (userConfig.GiteaToken == "")) {
 		return vcsErr
 	}
 	// At this point, we know that there can't be a single user/token without
 	// its partner, but we haven't checked if any user/token is set at all.
-	if userConfig.GithubUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" {
+	if userConfig.GithubUser == "" && userConfig.GithubAppID == 0 && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" && userConfig.GiteaUser == "" {
 		return vcsErr
 	}
+	if userConfig.GithubUser != "" && userConfig.GithubAppID != 0 {
+		return fmt.Errorf("--%s/--%s and --%s/--%s can't be used at the same time", GHUserFlag, GHTokenFlag, GHAppIDFlag, GHAppKeyFileFlag)
+	}
+
+	// Check runs can only be created by GitHub Apps.
+	if userConfig.GithubChecks && userConfig.GithubAppID == 0 {
+		return fmt.Errorf("--%s requires --%s and --%s to be set", GHChecksFlag, GHAppIDFlag, GHAppKeyFileFlag)
+	}
 
 	if userConfig.RepoWhitelist == "" {
 		return fmt.Errorf("--%s must be set for security purposes", RepoWhitelistFlag)
@@ -532,6 +826,14 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("--%s must have http:// or https://, got %q", BitbucketBaseURLFlag, userConfig.BitbucketBaseURL)
 	}
 
+	parsed, err = url.Parse(userConfig.GiteaBaseURL)
+	if err != nil {
+		return fmt.Errorf("error parsing --%s flag value %q: %s", GiteaBaseURLFlag, userConfig.GiteaBaseURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("--%s must have http:// or https://, got %q", GiteaBaseURLFlag, userConfig.GiteaBaseURL)
+	}
+
 	if userConfig.RepoConfig != "" && userConfig.RepoConfigJSON != "" {
 		return fmt.Errorf("cannot use --%s and --%s at the same time", RepoConfigFlag, RepoConfigJSONFlag)
 	}
@@ -544,6 +846,8 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		GitlabWebhookSecretFlag:    userConfig.GitlabWebhookSecret,
 		BitbucketTokenFlag:         userConfig.BitbucketToken,
 		BitbucketWebhookSecretFlag: userConfig.BitbucketWebhookSecret,
+		GiteaTokenFlag:             userConfig.GiteaToken,
+		GiteaWebhookSecretFlag:     userConfig.GiteaWebhookSecret,
 	} {
 		if strings.Contains(token, "\n") {
 			s.Logger.Warn("--%s contains a newline which is usually unintentional", name)
@@ -554,6 +858,25 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("if setting --%s, must set --%s", TFEHostnameFlag, TFETokenFlag)
 	}
 
+	switch userConfig.WebAuth {
+	case "none":
+	case "basic":
+		if userConfig.WebBasicAuthUsers == "" {
+			return fmt.Errorf("--%s must be set when using a --%s of basic", WebBasicAuthUsersFlag, WebAuthFlag)
+		}
+	case "oidc":
+		if userConfig.WebOIDCIssuerURL == "" || userConfig.WebOIDCClientID == "" || userConfig.WebOIDCClientSecret == "" {
+			return fmt.Errorf("--%s, --%s and --%s must be set when using a --%s of oidc", WebOIDCIssuerURLFlag, WebOIDCClientIDFlag, WebOIDCClientSecretFlag, WebAuthFlag)
+		}
+		// Anyone can log in to public providers like Google so without a
+		// list of users, anyone could be an admin.
+		if userConfig.WebViewers == "" && userConfig.WebAdmins == "" {
+			return fmt.Errorf("--%s or --%s must be set when using a --%s of oidc", WebViewersFlag, WebAdminsFlag, WebAuthFlag)
+		}
+	default:
+		return fmt.Errorf("invalid --%s: not one of none, basic or oidc", WebAuthFlag)
+	}
+
 	return nil
 }
 
@@ -599,10 +922,11 @@ func (s *ServerCmd) trimAtSymbolFromUsers(userConfig *server.UserConfig) {
 	userConfig.GitlabUser = strings.TrimPrefix(userConfig.GitlabUser, "@")
 	userConfig.BitbucketUser = strings.TrimPrefix(userConfig.BitbucketUser, "@")
 	userConfig.AzureDevopsUser = strings.TrimPrefix(userConfig.AzureDevopsUser, "@")
+	userConfig.GiteaUser = strings.TrimPrefix(userConfig.GiteaUser, "@")
 }
 
 func (s *ServerCmd) securityWarnings(userConfig *server.UserConfig) {
-	if userConfig.GithubUser != "" && userConfig.GithubWebhookSecret == "" && !s.SilenceOutput {
+	if (userConfig.GithubUser != "" || userConfig.GithubAppID != 0) && userConfig.GithubWebhookSecret == "" && !s.SilenceOutput {
 		s.Logger.Warn("no GitHub webhook secret set. This could allow attackers to spoof requests from GitHub")
 	}
 	if userConfig.GitlabUser != "" && userConfig.GitlabWebhookSecret == "" && !s.SilenceOutput {
@@ -617,6 +941,12 @@ func (s *ServerCmd) securityWarnings(userConfig *server.UserConfig) {
 	if userConfig.AzureDevopsWebhookUser != "" && userConfig.AzureDevopsWebhookPassword == "" && !s.SilenceOutput {
 		s.Logger.Warn("no Azure DevOps webhook user and password set. This could allow attackers to spoof requests from Azure DevOps.")
 	}
+	if userConfig.GiteaUser != "" && userConfig.GiteaWebhookSecret == "" && !s.SilenceOutput {
+		s.Logger.Warn("no Gitea webhook secret set. This could allow attackers to spoof requests from Gitea")
+	}
+	if userConfig.WebAuth == "basic" && strings.HasPrefix(userConfig.AtlantisURL, "http://") && !s.SilenceOutput {
+		s.Logger.Warn("web UI basic auth is enabled but the Atlantis URL isn't https. Passwords will be sent unencrypted")
+	}
 }
 
 // deprecationWarnings prints a warning if flags that are deprecated are
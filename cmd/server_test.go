@@ -127,6 +127,14 @@ func TestExecute_ValidateLogLevel(t *testing.T) {
 	Equals(t, "invalid log level: not one of debug, info, warn, error", err.Error())
 }
 
+func TestExecute_ValidateLogFormat(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.LogFormatFlag: "invalid",
+	})
+	err := c.Execute()
+	ErrEquals(t, "invalid log format: not one of text or json", err)
+}
+
 func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	c := setupWithDefaults(map[string]interface{}{
 		cmd.CheckoutStrategyFlag: "invalid",
@@ -135,6 +143,182 @@ func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	ErrEquals(t, "invalid checkout strategy: not one of branch or merge", err)
 }
 
+func TestExecute_ValidateDrainTimeout(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.DrainTimeoutFlag: "5 minutes",
+	})
+	err := c.Execute()
+	ErrContains(t, "invalid --drain-timeout", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.DrainTimeoutFlag: "-1m",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--drain-timeout can't be negative", err)
+}
+
+func TestExecute_ValidateDriftDetectionInterval(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.DriftDetectionIntervalFlag: "1 hour",
+	})
+	err := c.Execute()
+	ErrContains(t, "invalid --drift-detection-interval", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.DriftDetectionIntervalFlag: "0s",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--drift-detection-interval must be greater than 0", err)
+}
+
+func TestExecute_ValidateEventWorkers(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.EventWorkersFlag: -1,
+	})
+	err := c.Execute()
+	ErrEquals(t, "--event-workers must be greater than 0", err)
+}
+
+func TestExecute_ValidateGithubChecks(t *testing.T) {
+	c := setup(map[string]interface{}{
+		cmd.GHChecksFlag:      true,
+		cmd.GitlabUserFlag:    "user",
+		cmd.GitlabTokenFlag:   "token",
+		cmd.RepoWhitelistFlag: "*",
+	})
+	err := c.Execute()
+	ErrEquals(t, "--gh-checks requires --gh-app-id and --gh-app-key-file to be set", err)
+
+	// A GitHub user can't create check runs.
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.GHChecksFlag: true,
+	})
+	err = c.Execute()
+	ErrEquals(t, "--gh-checks requires --gh-app-id and --gh-app-key-file to be set", err)
+}
+
+func TestExecute_ValidateGithubApp(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.GHAppIDFlag:      1,
+		cmd.GHAppKeyFileFlag: "key.pem",
+	})
+	err := c.Execute()
+	ErrEquals(t, "--gh-user/--gh-token and --gh-app-id/--gh-app-key-file can't be used at the same time", err)
+
+	// --gh-checks can be used with a GitHub App.
+	c = setup(map[string]interface{}{
+		cmd.GHAppIDFlag:       1,
+		cmd.GHAppKeyFileFlag:  "key.pem",
+		cmd.GHChecksFlag:      true,
+		cmd.RepoWhitelistFlag: "*",
+	})
+	Ok(t, c.Execute())
+	Equals(t, 1, passedConfig.GithubAppID)
+	Equals(t, "key.pem", passedConfig.GithubAppKeyFile)
+	Equals(t, true, passedConfig.GithubChecks)
+}
+
+func TestExecute_ValidateLockReaper(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.LockReaperIntervalFlag: "1 hour",
+	})
+	err := c.Execute()
+	ErrContains(t, "invalid --lock-reaper-interval", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.LockReaperIntervalFlag: "0s",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--lock-reaper-interval must be greater than 0", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.LockReaperIntervalFlag: "1h",
+		cmd.LockTTLFlag:            "-1h",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--lock-ttl must be greater than 0", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.LockTTLFlag: "168h",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--lock-reaper-interval must be set when using --lock-ttl since expired locks are released by the stale lock reaper", err)
+}
+
+func TestExecute_ValidateWebAuth(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.WebAuthFlag: "saml",
+	})
+	err := c.Execute()
+	ErrEquals(t, "invalid --web-auth: not one of none, basic or oidc", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.WebAuthFlag: "basic",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--web-basic-auth-users must be set when using a --web-auth of basic", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.WebAuthFlag:          "oidc",
+		cmd.WebOIDCIssuerURLFlag: "https://accounts.example.com",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--web-oidc-issuer-url, --web-oidc-client-id and --web-oidc-client-secret must be set when using a --web-auth of oidc", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.WebAuthFlag:             "oidc",
+		cmd.WebOIDCIssuerURLFlag:    "https://accounts.example.com",
+		cmd.WebOIDCClientIDFlag:     "client-id",
+		cmd.WebOIDCClientSecretFlag: "client-secret",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--web-viewers or --web-admins must be set when using a --web-auth of oidc", err)
+}
+
+func TestExecute_ValidateMaxPlanAge(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.MaxPlanAgeFlag: "1 day",
+	})
+	err := c.Execute()
+	ErrContains(t, "invalid --max-plan-age", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.MaxPlanAgeFlag: "0s",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--max-plan-age must be greater than 0", err)
+}
+
+func TestExecute_ValidateHistoryRetention(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.HistoryRetentionFlag: "30 days",
+	})
+	err := c.Execute()
+	ErrContains(t, "invalid --history-retention", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		cmd.HistoryRetentionFlag: "-1h",
+	})
+	err = c.Execute()
+	ErrEquals(t, "--history-retention can't be negative", err)
+}
+
+func TestExecute_ValidateLockingDBType(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.LockingDBType: "invalid",
+	})
+	err := c.Execute()
+	ErrEquals(t, "invalid --locking-db-type: not one of boltdb or redis", err)
+}
+
+func TestExecute_ValidateRedisHost(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.LockingDBType: "redis",
+	})
+	err := c.Execute()
+	ErrEquals(t, "--redis-host must be set when using a --locking-db-type of redis", err)
+}
+
 func TestExecute_ValidateSSLConfig(t *testing.T) {
 	expErr := "--ssl-key-file and --ssl-cert-file are both required for ssl"
 	cases := []struct {
@@ -184,7 +368,7 @@ func TestExecute_ValidateSSLConfig(t *testing.T) {
 }
 
 func TestExecute_ValidateVCSConfig(t *testing.T) {
-	expErr := "--gh-user/--gh-token or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token must be set"
+	expErr := "--gh-user/--gh-token or --gh-app-id/--gh-app-key-file or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token or --gitea-user/--gitea-token must be set"
 	cases := []struct {
 		description string
 		flags       map[string]interface{}
@@ -202,6 +386,20 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just github app id set",
+			map[string]interface{}{
+				cmd.GHAppIDFlag: 1,
+			},
+			true,
+		},
+		{
+			"just github app key file set",
+			map[string]interface{}{
+				cmd.GHAppKeyFileFlag: "key.pem",
+			},
+			true,
+		},
 		{
 			"just gitlab token set",
 			map[string]interface{}{
@@ -223,6 +421,13 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just gitea token set",
+			map[string]interface{}{
+				cmd.GiteaTokenFlag: "token",
+			},
+			true,
+		},
 		{
 			"just github user set",
 			map[string]interface{}{
@@ -251,6 +456,13 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just gitea user set",
+			map[string]interface{}{
+				cmd.GiteaUserFlag: "user",
+			},
+			true,
+		},
 		{
 			"github user and gitlab token set",
 			map[string]interface{}{
@@ -283,6 +495,14 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"github app id and github app key file set and should be successful",
+			map[string]interface{}{
+				cmd.GHAppIDFlag:      1,
+				cmd.GHAppKeyFileFlag: "key.pem",
+			},
+			false,
+		},
 		{
 			"gitlab user and gitlab token set and should be successful",
 			map[string]interface{}{
@@ -307,6 +527,14 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"gitea user and gitea token set and should be successful",
+			map[string]interface{}{
+				cmd.GiteaUserFlag:  "user",
+				cmd.GiteaTokenFlag: "token",
+			},
+			false,
+		},
 		{
 			"all set should be successful",
 			map[string]interface{}{
@@ -318,6 +546,8 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 				cmd.BitbucketTokenFlag: "token",
 				cmd.ADUserFlag:         "user",
 				cmd.ADTokenFlag:        "token",
+				cmd.GiteaUserFlag:      "user",
+				cmd.GiteaTokenFlag:     "token",
 			},
 			false,
 		},
@@ -368,7 +598,17 @@ func TestExecute_Defaults(t *testing.T) {
 
 	Equals(t, "branch", passedConfig.CheckoutStrategy)
 	Equals(t, false, passedConfig.DisableApplyAll)
+	Equals(t, "5m", passedConfig.DrainTimeout)
+	Equals(t, "", passedConfig.DriftDetectionInterval)
+	Equals(t, 10, passedConfig.EventWorkers)
 	Equals(t, "", passedConfig.DefaultTFVersion)
+	Equals(t, "https://gitea.com", passedConfig.GiteaBaseURL)
+	Equals(t, "", passedConfig.GiteaToken)
+	Equals(t, "", passedConfig.GiteaUser)
+	Equals(t, "", passedConfig.GiteaWebhookSecret)
+	Equals(t, 0, passedConfig.GithubAppID)
+	Equals(t, "", passedConfig.GithubAppKeyFile)
+	Equals(t, false, passedConfig.GithubChecks)
 	Equals(t, "github.com", passedConfig.GithubHostname)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "user", passedConfig.GithubUser)
@@ -377,6 +617,7 @@ func TestExecute_Defaults(t *testing.T) {
 	Equals(t, "gitlab-token", passedConfig.GitlabToken)
 	Equals(t, "gitlab-user", passedConfig.GitlabUser)
 	Equals(t, "", passedConfig.GitlabWebhookSecret)
+	Equals(t, "720h", passedConfig.HistoryRetention)
 	Equals(t, "https://api.bitbucket.org", passedConfig.BitbucketBaseURL)
 	Equals(t, "bitbucket-token", passedConfig.BitbucketToken)
 	Equals(t, "bitbucket-user", passedConfig.BitbucketUser)
@@ -385,8 +626,21 @@ func TestExecute_Defaults(t *testing.T) {
 	Equals(t, "", passedConfig.AzureDevopsWebhookPassword)
 	Equals(t, "", passedConfig.AzureDevopsWebhookUser)
 	Equals(t, "", passedConfig.BitbucketWebhookSecret)
+	Equals(t, "", passedConfig.APISecret)
+	Equals(t, "", passedConfig.LockReaperInterval)
+	Equals(t, "", passedConfig.LockTTL)
+	Equals(t, "boltdb", passedConfig.LockingDBType)
+	Equals(t, "text", passedConfig.LogFormat)
 	Equals(t, "info", passedConfig.LogLevel)
+	Equals(t, "", passedConfig.MaxPlanAge)
+	Equals(t, 15, passedConfig.ParallelPoolSize)
 	Equals(t, 4141, passedConfig.Port)
+	Equals(t, 0, passedConfig.RedisDB)
+	Equals(t, "", passedConfig.RedisHost)
+	Equals(t, false, passedConfig.RedisInsecureSkipVerify)
+	Equals(t, "", passedConfig.RedisPassword)
+	Equals(t, 6379, passedConfig.RedisPort)
+	Equals(t, false, passedConfig.RedisTLSEnabled)
 	Equals(t, false, passedConfig.RequireApproval)
 	Equals(t, false, passedConfig.RequireMergeable)
 	Equals(t, false, passedConfig.SilenceForkPRErrors)
@@ -398,6 +652,14 @@ func TestExecute_Defaults(t *testing.T) {
 	Equals(t, "app.terraform.io", passedConfig.TFEHostname)
 	Equals(t, "", passedConfig.TFEToken)
 	Equals(t, "atlantis", passedConfig.VCSStatusName)
+	Equals(t, "", passedConfig.WebAdmins)
+	Equals(t, "none", passedConfig.WebAuth)
+	Equals(t, "", passedConfig.WebBasicAuthUsers)
+	Equals(t, "", passedConfig.WebOIDCClientID)
+	Equals(t, "", passedConfig.WebOIDCClientSecret)
+	Equals(t, "groups", passedConfig.WebOIDCGroupsClaim)
+	Equals(t, "", passedConfig.WebOIDCIssuerURL)
+	Equals(t, "", passedConfig.WebViewers)
 	Equals(t, false, passedConfig.WriteGitCreds)
 }
 
@@ -484,6 +746,19 @@ func TestExecute_ADUser(t *testing.T) {
 	Equals(t, "user", passedConfig.AzureDevopsUser)
 }
 
+func TestExecute_GiteaUser(t *testing.T) {
+	t.Log("Should remove the @ from the gitea username if it's passed.")
+	c := setup(map[string]interface{}{
+		cmd.GiteaUserFlag:     "@user",
+		cmd.GiteaTokenFlag:    "token",
+		cmd.RepoWhitelistFlag: "*",
+	})
+	err := c.Execute()
+	Ok(t, err)
+
+	Equals(t, "user", passedConfig.GiteaUser)
+}
+
 func TestExecute_Flags(t *testing.T) {
 	t.Log("Should use all flags that are set.")
 	c := setup(map[string]interface{}{
@@ -493,6 +768,7 @@ func TestExecute_Flags(t *testing.T) {
 		cmd.ADWebhookUserFlag:          "ad-wh-user",
 		cmd.AtlantisURLFlag:            "url",
 		cmd.AllowForkPRsFlag:           true,
+		cmd.APISecretFlag:              "api-secret",
 		cmd.AllowRepoConfigFlag:        true,
 		cmd.AutomergeFlag:              true,
 		cmd.BitbucketBaseURLFlag:       "https://bitbucket-base-url.com",
@@ -503,16 +779,36 @@ func TestExecute_Flags(t *testing.T) {
 		cmd.DataDirFlag:                "/path",
 		cmd.DefaultTFVersionFlag:       "v0.11.0",
 		cmd.DisableApplyAllFlag:        true,
+		cmd.DrainTimeoutFlag:           "10m",
+		cmd.DriftDetectionIntervalFlag: "1h",
+		cmd.EventWorkersFlag:           4,
 		cmd.GHHostnameFlag:             "ghhostname",
 		cmd.GHTokenFlag:                "token",
 		cmd.GHUserFlag:                 "user",
 		cmd.GHWebhookSecretFlag:        "secret",
+		cmd.GiteaBaseURLFlag:           "https://gitea-base-url.com",
+		cmd.GiteaTokenFlag:             "gitea-token",
+		cmd.GiteaUserFlag:              "gitea-user",
+		cmd.GiteaWebhookSecretFlag:     "gitea-secret",
 		cmd.GitlabHostnameFlag:         "gitlab-hostname",
 		cmd.GitlabTokenFlag:            "gitlab-token",
 		cmd.GitlabUserFlag:             "gitlab-user",
 		cmd.GitlabWebhookSecretFlag:    "gitlab-secret",
+		cmd.HistoryRetentionFlag:       "24h",
+		cmd.LockReaperIntervalFlag:     "1h",
+		cmd.LockTTLFlag:                "168h",
+		cmd.LockingDBType:              "redis",
+		cmd.LogFormatFlag:              "json",
 		cmd.LogLevelFlag:               "debug",
+		cmd.MaxPlanAgeFlag:             "24h",
+		cmd.ParallelPoolSizeFlag:       5,
 		cmd.PortFlag:                   8181,
+		cmd.RedisDB:                    3,
+		cmd.RedisHost:                  "redis-host",
+		cmd.RedisInsecureSkipVerify:    true,
+		cmd.RedisPassword:              "redis-password",
+		cmd.RedisPort:                  6380,
+		cmd.RedisTLSEnabled:            true,
 		cmd.RepoWhitelistFlag:          "github.com/runatlantis/atlantis",
 		cmd.RequireApprovalFlag:        true,
 		cmd.RequireMergeableFlag:       true,
@@ -525,6 +821,14 @@ func TestExecute_Flags(t *testing.T) {
 		cmd.TFEHostnameFlag:            "my-hostname",
 		cmd.TFETokenFlag:               "my-token",
 		cmd.VCSStatusName:              "my-status",
+		cmd.WebAdminsFlag:              "ops",
+		cmd.WebAuthFlag:                "oidc",
+		cmd.WebBasicAuthUsersFlag:      "alice:pass",
+		cmd.WebOIDCClientIDFlag:        "client-id",
+		cmd.WebOIDCClientSecretFlag:    "client-secret",
+		cmd.WebOIDCGroupsClaimFlag:     "roles",
+		cmd.WebOIDCIssuerURLFlag:       "https://accounts.example.com",
+		cmd.WebViewersFlag:             "dev",
 		cmd.WriteGitCredsFlag:          true,
 	})
 	err := c.Execute()
@@ -536,6 +840,7 @@ func TestExecute_Flags(t *testing.T) {
 	Equals(t, "ad-wh-user", passedConfig.AzureDevopsWebhookUser)
 	Equals(t, "url", passedConfig.AtlantisURL)
 	Equals(t, true, passedConfig.AllowForkPRs)
+	Equals(t, "api-secret", passedConfig.APISecret)
 	Equals(t, true, passedConfig.AllowRepoConfig)
 	Equals(t, true, passedConfig.Automerge)
 	Equals(t, "https://bitbucket-base-url.com", passedConfig.BitbucketBaseURL)
@@ -546,16 +851,36 @@ func TestExecute_Flags(t *testing.T) {
 	Equals(t, "/path", passedConfig.DataDir)
 	Equals(t, "v0.11.0", passedConfig.DefaultTFVersion)
 	Equals(t, true, passedConfig.DisableApplyAll)
+	Equals(t, "10m", passedConfig.DrainTimeout)
+	Equals(t, "1h", passedConfig.DriftDetectionInterval)
+	Equals(t, 4, passedConfig.EventWorkers)
 	Equals(t, "ghhostname", passedConfig.GithubHostname)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "user", passedConfig.GithubUser)
 	Equals(t, "secret", passedConfig.GithubWebhookSecret)
+	Equals(t, "https://gitea-base-url.com", passedConfig.GiteaBaseURL)
+	Equals(t, "gitea-token", passedConfig.GiteaToken)
+	Equals(t, "gitea-user", passedConfig.GiteaUser)
+	Equals(t, "gitea-secret", passedConfig.GiteaWebhookSecret)
 	Equals(t, "gitlab-hostname", passedConfig.GitlabHostname)
 	Equals(t, "gitlab-token", passedConfig.GitlabToken)
 	Equals(t, "gitlab-user", passedConfig.GitlabUser)
 	Equals(t, "gitlab-secret", passedConfig.GitlabWebhookSecret)
+	Equals(t, "24h", passedConfig.HistoryRetention)
+	Equals(t, "1h", passedConfig.LockReaperInterval)
+	Equals(t, "168h", passedConfig.LockTTL)
+	Equals(t, "redis", passedConfig.LockingDBType)
+	Equals(t, "json", passedConfig.LogFormat)
 	Equals(t, "debug", passedConfig.LogLevel)
+	Equals(t, "24h", passedConfig.MaxPlanAge)
+	Equals(t, 5, passedConfig.ParallelPoolSize)
 	Equals(t, 8181, passedConfig.Port)
+	Equals(t, 3, passedConfig.RedisDB)
+	Equals(t, "redis-host", passedConfig.RedisHost)
+	Equals(t, true, passedConfig.RedisInsecureSkipVerify)
+	Equals(t, "redis-password", passedConfig.RedisPassword)
+	Equals(t, 6380, passedConfig.RedisPort)
+	Equals(t, true, passedConfig.RedisTLSEnabled)
 	Equals(t, "github.com/runatlantis/atlantis", passedConfig.RepoWhitelist)
 	Equals(t, true, passedConfig.RequireApproval)
 	Equals(t, true, passedConfig.RequireMergeable)
@@ -568,6 +893,14 @@ func TestExecute_Flags(t *testing.T) {
 	Equals(t, "my-hostname", passedConfig.TFEHostname)
 	Equals(t, "my-token", passedConfig.TFEToken)
 	Equals(t, "my-status", passedConfig.VCSStatusName)
+	Equals(t, "ops", passedConfig.WebAdmins)
+	Equals(t, "oidc", passedConfig.WebAuth)
+	Equals(t, "alice:pass", passedConfig.WebBasicAuthUsers)
+	Equals(t, "client-id", passedConfig.WebOIDCClientID)
+	Equals(t, "client-secret", passedConfig.WebOIDCClientSecret)
+	Equals(t, "roles", passedConfig.WebOIDCGroupsClaim)
+	Equals(t, "https://accounts.example.com", passedConfig.WebOIDCIssuerURL)
+	Equals(t, "dev", passedConfig.WebViewers)
 	Equals(t, true, passedConfig.WriteGitCreds)
 }
 
@@ -590,15 +923,27 @@ checkout-strategy: "merge"
 data-dir: "/path"
 default-tf-version: "v0.11.0"
 disable-apply-all: true
+drain-timeout: "10m"
+drift-detection-interval: "1h"
+event-workers: 4
 gh-hostname: "ghhostname"
 gh-token: "token"
 gh-user: "user"
 gh-webhook-secret: "secret"
+gitea-base-url: "https://gitea-base-url.com"
+gitea-token: "gitea-token"
+gitea-user: "gitea-user"
+gitea-webhook-secret: "gitea-secret"
 gitlab-hostname: "gitlab-hostname"
 gitlab-token: "gitlab-token"
 gitlab-user: "gitlab-user"
 gitlab-webhook-secret: "gitlab-secret"
+history-retention: "24h"
+lock-reaper-interval: "1h"
+lock-ttl: "168h"
+log-format: "json"
 log-level: "debug"
+max-plan-age: "24h"
 port: 8181
 repo-whitelist: "github.com/runatlantis/atlantis"
 require-approval: true
@@ -612,6 +957,14 @@ tf-download-url: https://my-hostname.com
 tfe-hostname: my-hostname
 tfe-token: my-token
 vcs-status-name: my-status
+web-admins: ops
+web-auth: oidc
+web-basic-auth-users: "alice:pass"
+web-oidc-client-id: client-id
+web-oidc-client-secret: client-secret
+web-oidc-groups-claim: roles
+web-oidc-issuer-url: "https://accounts.example.com"
+web-viewers: dev
 write-git-creds: true
 `)
 	defer os.Remove(tmpFile) // nolint: errcheck
@@ -637,15 +990,27 @@ write-git-creds: true
 	Equals(t, "/path", passedConfig.DataDir)
 	Equals(t, "v0.11.0", passedConfig.DefaultTFVersion)
 	Equals(t, true, passedConfig.DisableApplyAll)
+	Equals(t, "10m", passedConfig.DrainTimeout)
+	Equals(t, "1h", passedConfig.DriftDetectionInterval)
+	Equals(t, 4, passedConfig.EventWorkers)
 	Equals(t, "ghhostname", passedConfig.GithubHostname)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "user", passedConfig.GithubUser)
 	Equals(t, "secret", passedConfig.GithubWebhookSecret)
+	Equals(t, "https://gitea-base-url.com", passedConfig.GiteaBaseURL)
+	Equals(t, "gitea-token", passedConfig.GiteaToken)
+	Equals(t, "gitea-user", passedConfig.GiteaUser)
+	Equals(t, "gitea-secret", passedConfig.GiteaWebhookSecret)
 	Equals(t, "gitlab-hostname", passedConfig.GitlabHostname)
 	Equals(t, "gitlab-token", passedConfig.GitlabToken)
 	Equals(t, "gitlab-user", passedConfig.GitlabUser)
 	Equals(t, "gitlab-secret", passedConfig.GitlabWebhookSecret)
+	Equals(t, "24h", passedConfig.HistoryRetention)
+	Equals(t, "1h", passedConfig.LockReaperInterval)
+	Equals(t, "168h", passedConfig.LockTTL)
+	Equals(t, "json", passedConfig.LogFormat)
 	Equals(t, "debug", passedConfig.LogLevel)
+	Equals(t, "24h", passedConfig.MaxPlanAge)
 	Equals(t, 8181, passedConfig.Port)
 	Equals(t, "github.com/runatlantis/atlantis", passedConfig.RepoWhitelist)
 	Equals(t, true, passedConfig.RequireApproval)
@@ -659,6 +1024,14 @@ write-git-creds: true
 	Equals(t, "my-hostname", passedConfig.TFEHostname)
 	Equals(t, "my-token", passedConfig.TFEToken)
 	Equals(t, "my-status", passedConfig.VCSStatusName)
+	Equals(t, "ops", passedConfig.WebAdmins)
+	Equals(t, "oidc", passedConfig.WebAuth)
+	Equals(t, "alice:pass", passedConfig.WebBasicAuthUsers)
+	Equals(t, "client-id", passedConfig.WebOIDCClientID)
+	Equals(t, "client-secret", passedConfig.WebOIDCClientSecret)
+	Equals(t, "roles", passedConfig.WebOIDCGroupsClaim)
+	Equals(t, "https://accounts.example.com", passedConfig.WebOIDCIssuerURL)
+	Equals(t, "dev", passedConfig.WebViewers)
 	Equals(t, true, passedConfig.WriteGitCreds)
 }
 
@@ -1109,6 +1482,17 @@ func TestExecute_BitbucketServerBaseURLPort(t *testing.T) {
 	Equals(t, "http://mydomain.com:7990", passedConfig.BitbucketBaseURL)
 }
 
+// Gitea base URL must have a scheme.
+func TestExecute_GiteaBaseURLScheme(t *testing.T) {
+	c := setup(map[string]interface{}{
+		cmd.GiteaUserFlag:     "user",
+		cmd.GiteaTokenFlag:    "token",
+		cmd.RepoWhitelistFlag: "*",
+		cmd.GiteaBaseURLFlag:  "gitea.mydomain.com",
+	})
+	ErrEquals(t, "--gitea-base-url must have http:// or https://, got \"gitea.mydomain.com\"", c.Execute())
+}
+
 // Can't use both --repo-config and --repo-config-json.
 func TestExecute_RepoCfgFlags(t *testing.T) {
 	c := setup(map[string]interface{}{
@@ -50,7 +50,8 @@ module.exports = {
                         'webhook-secrets',
                         'deployment',
                         'configuring-webhooks',
-                        'provider-credentials'
+                        'provider-credentials',
+                        'metrics'
                     ]
                 },
                 {
@@ -64,16 +65,21 @@ module.exports = {
                         'repo-level-atlantis-yaml',
                         'upgrading-atlantis-yaml',
                         'apply-requirements',
+                        'policy-checking',
+                        'drift-detection',
                         'checkout-strategy',
                         'terraform-versions',
-                        'terraform-cloud'
+                        'terraform-cloud',
+                        'github-checks',
+                        'web-ui-authentication'
                     ]
                 },
                 {
                     title: 'Using Atlantis',
                     collapsable: true,
                     children: [
-                        ['using-atlantis', 'Overview']
+                        ['using-atlantis', 'Overview'],
+                        'api-endpoints'
                     ]
                 },
                 {
@@ -82,6 +88,7 @@ module.exports = {
                     children: [
                         ['how-atlantis-works', 'Overview'],
                         'locking',
+                        'event-queue',
                         'autoplanning',
                         'automerging',
                         'security'
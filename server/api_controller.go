@@ -0,0 +1,342 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// atlantisTokenHeader is the header that API requests must set to the API
+// secret.
+const atlantisTokenHeader = "X-Atlantis-Token"
+
+// APIController handles requests to the Atlantis API. The API lets users
+// run plan and apply without commenting on a pull request, ex. from a
+// deployment pipeline.
+type APIController struct {
+	// APISecret is the secret that must be set in the X-Atlantis-Token header
+	// of every request. If empty, the API is disabled.
+	APISecret             []byte
+	Locker                locking.Locker
+	Logger                *logging.SimpleLogger
+	Parser                events.EventParsing
+	ProjectCommandBuilder events.ProjectCommandBuilder
+	// CommandRunner runs the project commands so that they're run in
+	// parallel and in depends_on order like they are for comments.
+	CommandRunner *events.DefaultCommandRunner
+	// WorkingDir is used to resolve the requested ref to a commit and to
+	// delete the working dirs of requests that aren't for a pull request.
+	WorkingDir events.WorkingDir
+	// Drainer tracks the requests in progress so that shutdown waits for
+	// them. Once it's draining, requests are rejected.
+	Drainer *events.Drainer
+}
+
+// APIRequest is the body of a request to the API.
+type APIRequest struct {
+	// Repository is the full name of the repo, ex. runatlantis/atlantis.
+	Repository string `validate:"required"`
+	// Ref is the branch or tag to run the command on. If PR is set, it must
+	// point to the pull request's head commit.
+	Ref string `validate:"required"`
+	// Type is the VCS host type, ex. Github or Gitlab.
+	Type string `validate:"required"`
+	// PR is the number of the pull request this request is for. If 0, the
+	// request isn't associated with a pull request. It's then given its own
+	// working dir and locks, which are deleted and released once the request
+	// is done.
+	PR int
+	// Projects are the names of the projects defined in atlantis.yaml to run
+	// the command on.
+	Projects []string
+	// Paths are the dirs and workspaces to run the command on.
+	Paths []struct {
+		Directory string
+		Workspace string
+	}
+}
+
+// Plan is the POST /api/plan route. It runs plan for the projects in the
+// request and responds with the project results.
+func (a *APIController) Plan(w http.ResponseWriter, r *http.Request) {
+	if !a.Drainer.StartOp() {
+		a.apiReportError(w, http.StatusServiceUnavailable, errors.New("Atlantis is shutting down, try again later"))
+		return
+	}
+	defer a.Drainer.OpDone()
+	request, ctx, code, err := a.apiParseAndValidate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	defer a.cleanup(request, ctx)
+
+	result, err := a.apiPlan(request, ctx)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, result)
+}
+
+// Apply is the POST /api/apply route. It runs plan and then apply for the
+// projects in the request and responds with the project results. If any plan
+// fails then nothing is applied and the plan results are returned.
+func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
+	if !a.Drainer.StartOp() {
+		a.apiReportError(w, http.StatusServiceUnavailable, errors.New("Atlantis is shutting down, try again later"))
+		return
+	}
+	defer a.Drainer.OpDone()
+	request, ctx, code, err := a.apiParseAndValidate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	defer a.cleanup(request, ctx)
+
+	// We always plan first so that the plans being applied are for the
+	// requested ref and so that we hold the project locks.
+	planResult, err := a.apiPlan(request, ctx)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if planResult.HasErrors() {
+		a.respond(w, planResult)
+		return
+	}
+
+	applyResult, err := a.apiApply(request, ctx)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, applyResult)
+}
+
+// cleanup releases the locks and deletes the working dir of a request that
+// isn't for a pull request. No other request can use them since each one has
+// its own pull number. Locks for pull requests are released when the pull
+// request is closed.
+func (a *APIController) cleanup(request *APIRequest, ctx *events.CommandContext) {
+	if request.PR != 0 {
+		return
+	}
+	if _, err := a.Locker.UnlockByPull(ctx.BaseRepo.FullName, ctx.Pull.Num); err != nil {
+		ctx.Log.Err("unable to release locks: %s", err)
+	}
+	if err := a.WorkingDir.Delete(ctx.BaseRepo, ctx.Pull); err != nil {
+		ctx.Log.Err("unable to delete working dir: %s", err)
+	}
+}
+
+func (a *APIController) apiPlan(request *APIRequest, ctx *events.CommandContext) (events.CommandResult, error) {
+	return a.apiRun(request, ctx, models.PlanCommand, a.ProjectCommandBuilder.BuildPlanCommands)
+}
+
+func (a *APIController) apiApply(request *APIRequest, ctx *events.CommandContext) (events.CommandResult, error) {
+	return a.apiRun(request, ctx, models.ApplyCommand, a.ProjectCommandBuilder.BuildApplyCommands)
+}
+
+// apiRun builds the project commands for request with build and runs them.
+func (a *APIController) apiRun(request *APIRequest, ctx *events.CommandContext, name models.CommandName,
+	build func(*events.CommandContext, *events.CommentCommand) ([]models.ProjectCommandContext, error)) (events.CommandResult, error) {
+
+	cmds, err := a.buildCommands(request, ctx, name, build)
+	if err != nil {
+		return events.CommandResult{}, err
+	}
+	result := a.CommandRunner.RunProjectCmds(ctx, name, cmds)
+	for i := range result.ProjectResults {
+		result.ProjectResults[i].Command = name
+	}
+	return result, nil
+}
+
+// buildCommands builds the project commands for every project and path in
+// request using build.
+func (a *APIController) buildCommands(request *APIRequest, ctx *events.CommandContext, name models.CommandName,
+	build func(*events.CommandContext, *events.CommentCommand) ([]models.ProjectCommandContext, error)) ([]models.ProjectCommandContext, error) {
+
+	var comments []*events.CommentCommand
+	for _, project := range request.Projects {
+		comments = append(comments, events.NewCommentCommand("", nil, name, false, "", project))
+	}
+	for _, path := range request.Paths {
+		comments = append(comments, events.NewCommentCommand(path.Directory, nil, name, false, path.Workspace, ""))
+	}
+
+	var cmds []models.ProjectCommandContext
+	for _, comment := range comments {
+		projectCmds, err := build(ctx, comment)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s command", name.String())
+		}
+		cmds = append(cmds, projectCmds...)
+	}
+	return cmds, nil
+}
+
+// apiParseAndValidate authenticates r and parses its body. If there's an
+// error, it also returns the HTTP response code to use.
+func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *events.CommandContext, int, error) {
+	if len(a.APISecret) == 0 {
+		return nil, nil, http.StatusBadRequest, errors.New("ignoring request since API is disabled")
+	}
+
+	// Use a constant time comparison so the secret can't be guessed by
+	// timing our responses.
+	secret := r.Header.Get(atlantisTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), a.APISecret) != 1 {
+		return nil, nil, http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+	}
+
+	var request APIRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(err, "failed to parse request")
+	}
+	if err := validator.New().Struct(request); err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(err, "request is missing fields")
+	}
+	if len(request.Projects) == 0 && len(request.Paths) == 0 {
+		return nil, nil, http.StatusBadRequest, errors.New("request must specify at least one of Projects or Paths")
+	}
+	for _, path := range request.Paths {
+		if strings.HasPrefix(filepath.Clean(filepath.Join(".", path.Directory)), "..") {
+			return nil, nil, http.StatusBadRequest, fmt.Errorf("using a relative path %q is not allowed", path.Directory)
+		}
+	}
+
+	vcsHostType, err := a.parseVCSHostType(request.Type)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, err
+	}
+	baseRepo, err := a.Parser.ParseAPIPlanRequest(vcsHostType, request.Repository)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrap(err, "failed to parse repository")
+	}
+
+	if request.PR != 0 {
+		ctx, code, err := a.apiPullContext(baseRepo, request)
+		return &request, ctx, code, err
+	}
+
+	pullNum, err := newAPIPullNum()
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+	log := a.Logger.WithFields(map[string]interface{}{
+		"repo": baseRepo.FullName,
+		"pull": pullNum,
+	})
+	headCommit, err := a.WorkingDir.ResolveRef(log, baseRepo, request.Ref)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrapf(err, "failed to resolve ref %q", request.Ref)
+	}
+
+	return &request, &events.CommandContext{
+		BaseRepo: baseRepo,
+		HeadRepo: baseRepo,
+		Pull: models.PullRequest{
+			Num:        pullNum,
+			BaseBranch: request.Ref,
+			HeadBranch: request.Ref,
+			HeadCommit: headCommit,
+			BaseRepo:   baseRepo,
+		},
+		Log: log,
+	}, http.StatusOK, nil
+}
+
+// apiPullContext returns the context for a request for pull request
+// request.PR. The pull request is fetched from the VCS host so that apply
+// requirements are checked against it and request.Ref must be its head so
+// that the code that's run is what was approved.
+func (a *APIController) apiPullContext(baseRepo models.Repo, request APIRequest) (*events.CommandContext, int, error) {
+	log := a.Logger.WithFields(map[string]interface{}{
+		"repo": baseRepo.FullName,
+		"pull": request.PR,
+	})
+	pull, headRepo, err := a.CommandRunner.GetPull(baseRepo, request.PR)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrapf(err, "failed to get pull request %d", request.PR)
+	}
+	commit, err := a.WorkingDir.ResolveRef(log, headRepo, request.Ref)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.Wrapf(err, "failed to resolve ref %q", request.Ref)
+	}
+	if commit != pull.HeadCommit {
+		return nil, http.StatusBadRequest, fmt.Errorf("ref %q is at commit %s but the head of pull request %d is %s", request.Ref, commit, request.PR, pull.HeadCommit)
+	}
+
+	return &events.CommandContext{
+		BaseRepo: baseRepo,
+		HeadRepo: headRepo,
+		Pull:     pull,
+		Log:      log,
+	}, http.StatusOK, nil
+}
+
+// newAPIPullNum returns a random negative pull number for a request that
+// isn't for a pull request so that concurrent requests don't share a working
+// dir or locks. Pull requests always have positive numbers so they can't
+// clash.
+func newAPIPullNum() (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt32))
+	if err != nil {
+		return 0, errors.Wrap(err, "generating pull number")
+	}
+	return -int(n.Int64()) - 1, nil
+}
+
+// parseVCSHostType converts the Type field of an API request into a
+// VCSHostType.
+func (a *APIController) parseVCSHostType(vcsType string) (models.VCSHostType, error) {
+	for _, h := range []models.VCSHostType{models.Github, models.Gitlab, models.BitbucketCloud, models.BitbucketServer, models.AzureDevops, models.Gitea} {
+		if strings.EqualFold(vcsType, h.String()) {
+			return h, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown VCS type %q", vcsType)
+}
+
+// respond writes result as JSON. If any project failed, the response code is
+// 500.
+func (a *APIController) respond(w http.ResponseWriter, result events.CommandResult) {
+	code := http.StatusOK
+	if result.HasErrors() {
+		code = http.StatusInternalServerError
+	}
+
+	response, err := json.Marshal(result.ProjectResults)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to marshal response"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response) // nolint: errcheck
+}
+
+// apiReportError logs err and responds with code and err as a JSON error.
+func (a *APIController) apiReportError(w http.ResponseWriter, code int, err error) {
+	a.Logger.Warn("API request failed: %s", err)
+	response, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response) // nolint: errcheck
+}
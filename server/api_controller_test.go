@@ -0,0 +1,316 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events"
+	lockmocks "github.com/runatlantis/atlantis/server/events/locking/mocks"
+	emocks "github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const apiSecret = "api-secret"
+
+func TestAPIController_Disabled(t *testing.T) {
+	ac, _, _, _, _ := setupAPI(t)
+	ac.APISecret = nil
+	w := httptest.NewRecorder()
+	ac.Plan(w, apiRequest(t, apiSecret, server.APIRequest{}))
+	responseContains(t, w, http.StatusBadRequest, "ignoring request since API is disabled")
+}
+
+func TestAPIController_InvalidSecret(t *testing.T) {
+	ac, _, _, _, _ := setupAPI(t)
+	w := httptest.NewRecorder()
+	ac.Plan(w, apiRequest(t, "wrong", server.APIRequest{}))
+	responseContains(t, w, http.StatusUnauthorized, "header X-Atlantis-Token did not match expected secret")
+}
+
+func TestAPIController_ShuttingDown(t *testing.T) {
+	ac, _, _, _, _ := setupAPI(t)
+	ac.Drainer = &events.Drainer{}
+	Equals(t, 0, ac.Drainer.Drain(context.Background()))
+	w := httptest.NewRecorder()
+	ac.Apply(w, apiRequest(t, apiSecret, server.APIRequest{}))
+	responseContains(t, w, http.StatusServiceUnavailable, "Atlantis is shutting down")
+}
+
+func TestAPIController_Validation(t *testing.T) {
+	cases := []struct {
+		description string
+		request     server.APIRequest
+		expErr      string
+	}{
+		{
+			"missing repository",
+			server.APIRequest{Ref: "main", Type: "Github", Projects: []string{"proj"}},
+			"request is missing fields",
+		},
+		{
+			"no projects or paths",
+			server.APIRequest{Repository: "owner/repo", Ref: "main", Type: "Github"},
+			"request must specify at least one of Projects or Paths",
+		},
+		{
+			"relative path",
+			server.APIRequest{Repository: "owner/repo", Ref: "main", Type: "Github", Paths: []struct {
+				Directory string
+				Workspace string
+			}{{Directory: "../other"}}},
+			"using a relative path \\\"../other\\\" is not allowed",
+		},
+		{
+			"unknown type",
+			server.APIRequest{Repository: "owner/repo", Ref: "main", Type: "svn", Projects: []string{"proj"}},
+			"unknown VCS type \\\"svn\\\"",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ac, _, _, _, _ := setupAPI(t)
+			w := httptest.NewRecorder()
+			ac.Plan(w, apiRequest(t, apiSecret, c.request))
+			responseContains(t, w, http.StatusBadRequest, c.expErr)
+		})
+	}
+}
+
+func TestAPIController_Plan(t *testing.T) {
+	ac, builder, runner, locker, workingDir := setupAPI(t)
+	projCtx := models.ProjectCommandContext{RepoRelDir: "dir", Workspace: "default"}
+	When(builder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{projCtx}, nil)
+	When(runner.Plan(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{RepoRelDir: "dir", Workspace: "default", PlanSuccess: &models.PlanSuccess{TerraformOutput: "tf-output"}})
+
+	w := httptest.NewRecorder()
+	ac.Plan(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "feature",
+		Type:       "Github",
+		PR:         2,
+		Projects:   []string{"proj"},
+	}))
+	responseContains(t, w, http.StatusOK, `"TerraformOutput":"tf-output"`)
+
+	ctx, comment := builder.VerifyWasCalledOnce().BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand()).GetCapturedArguments()
+	Equals(t, "owner/repo", ctx.BaseRepo.FullName)
+	Equals(t, "fork/repo", ctx.HeadRepo.FullName)
+	Equals(t, 2, ctx.Pull.Num)
+	Equals(t, "feature", ctx.Pull.HeadBranch)
+	Equals(t, "main", ctx.Pull.BaseBranch)
+	Equals(t, "abc123", ctx.Pull.HeadCommit)
+	Equals(t, "proj", comment.ProjectName)
+	Equals(t, models.PlanCommand, comment.Name)
+	// Locks for pull requests are kept until the pull request is closed.
+	locker.VerifyWasCalled(Never()).UnlockByPull(AnyString(), AnyInt())
+	workingDir.VerifyWasCalled(Never()).Delete(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())
+}
+
+// Test that requests that aren't for a pull request don't share a working dir
+// or locks and that they're cleaned up once each request is done.
+func TestAPIController_PlanWithoutPR(t *testing.T) {
+	ac, builder, runner, locker, workingDir := setupAPI(t)
+	When(builder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{{}}, nil)
+	When(runner.Plan(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		ac.Plan(w, apiRequest(t, apiSecret, server.APIRequest{
+			Repository: "owner/repo",
+			Ref:        "main",
+			Type:       "Github",
+			Projects:   []string{"proj"},
+		}))
+		responseContains(t, w, http.StatusOK, `"PlanSuccess"`)
+	}
+
+	ctxs, _ := builder.VerifyWasCalled(Times(2)).BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand()).GetAllCapturedArguments()
+	first, second := ctxs[0].Pull, ctxs[1].Pull
+	Assert(t, first.Num < 0 && second.Num < 0, "exp negative pull nums, got %d and %d", first.Num, second.Num)
+	Assert(t, first.Num != second.Num, "exp requests to have different pull nums")
+	for _, pull := range []models.PullRequest{first, second} {
+		locker.VerifyWasCalledOnce().UnlockByPull("owner/repo", pull.Num)
+		workingDir.VerifyWasCalledOnce().Delete(ctxs[0].BaseRepo, pull)
+	}
+}
+
+func TestAPIController_UnknownRef(t *testing.T) {
+	ac, _, _, _, workingDir := setupAPI(t)
+	When(workingDir.ResolveRef(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), EqString("nope"))).
+		ThenReturn("", errors.New(`no branch or tag named "nope" in owner/repo`))
+
+	w := httptest.NewRecorder()
+	ac.Plan(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "nope",
+		Type:       "Github",
+		Projects:   []string{"proj"},
+	}))
+	responseContains(t, w, http.StatusBadRequest, `failed to resolve ref \"nope\"`)
+}
+
+// Test that a request for a pull request is rejected if its ref isn't the pull
+// request's head so that the pull request's approvals can't be used to apply
+// other code.
+func TestAPIController_RefNotPullHead(t *testing.T) {
+	ac, builder, _, _, workingDir := setupAPI(t)
+	When(workingDir.ResolveRef(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), EqString("other"))).
+		ThenReturn("def456", nil)
+
+	w := httptest.NewRecorder()
+	ac.Apply(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "other",
+		Type:       "Github",
+		PR:         2,
+		Projects:   []string{"proj"},
+	}))
+	responseContains(t, w, http.StatusBadRequest, `ref \"other\" is at commit def456 but the head of pull request 2 is abc123`)
+	builder.VerifyWasCalled(Never()).BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())
+}
+
+func TestAPIController_PlanError(t *testing.T) {
+	ac, builder, runner, _, _ := setupAPI(t)
+	When(builder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{{}}, nil)
+	When(runner.Plan(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{Error: errors.New("plan failed")})
+
+	w := httptest.NewRecorder()
+	ac.Plan(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "main",
+		Type:       "Github",
+		Paths: []struct {
+			Directory string
+			Workspace string
+		}{{Directory: "dir", Workspace: "default"}},
+	}))
+	responseContains(t, w, http.StatusInternalServerError, `"Error":"plan failed"`)
+}
+
+func TestAPIController_Apply(t *testing.T) {
+	ac, builder, runner, locker, workingDir := setupAPI(t)
+	When(builder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{{}}, nil)
+	When(builder.BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{{}}, nil)
+	When(runner.Plan(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+	When(runner.Apply(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{ApplySuccess: "applied"})
+
+	w := httptest.NewRecorder()
+	ac.Apply(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "main",
+		Type:       "Github",
+		Projects:   []string{"proj"},
+	}))
+	responseContains(t, w, http.StatusOK, `"ApplySuccess":"applied"`)
+	runner.VerifyWasCalledOnce().Plan(matchers.AnyModelsProjectCommandContext())
+	ctx, _ := builder.VerifyWasCalledOnce().BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand()).GetCapturedArguments()
+	locker.VerifyWasCalledOnce().UnlockByPull("owner/repo", ctx.Pull.Num)
+	workingDir.VerifyWasCalledOnce().Delete(ctx.BaseRepo, ctx.Pull)
+}
+
+// Test that projects are applied in depends_on order and that a project isn't
+// applied if its dependency fails.
+func TestAPIController_ApplyDependsOn(t *testing.T) {
+	ac, builder, runner, _, _ := setupAPI(t)
+	network := models.ProjectCommandContext{ProjectName: "network"}
+	eks := models.ProjectCommandContext{ProjectName: "eks", DependsOn: []string{"network"}}
+	When(builder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{eks, network}, nil)
+	When(builder.BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{eks, network}, nil)
+	When(runner.Plan(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+	When(runner.Apply(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{Error: errors.New("apply failed")})
+
+	w := httptest.NewRecorder()
+	ac.Apply(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "main",
+		Type:       "Github",
+		Projects:   []string{"eks"},
+	}))
+	responseContains(t, w, http.StatusInternalServerError, `depends on project \"network\"`)
+	runner.VerifyWasCalledOnce().Apply(network)
+	runner.VerifyWasCalled(Never()).Apply(eks)
+}
+
+// Should not apply if the plan fails. The plan's locks are still released
+// since no other request can use them.
+func TestAPIController_ApplyPlanFails(t *testing.T) {
+	ac, builder, runner, locker, _ := setupAPI(t)
+	When(builder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{{}}, nil)
+	When(runner.Plan(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{Failure: "locked"})
+
+	w := httptest.NewRecorder()
+	ac.Apply(w, apiRequest(t, apiSecret, server.APIRequest{
+		Repository: "owner/repo",
+		Ref:        "main",
+		Type:       "Github",
+		Projects:   []string{"proj"},
+	}))
+	responseContains(t, w, http.StatusInternalServerError, `"Failure":"locked"`)
+	runner.VerifyWasCalled(Never()).Apply(matchers.AnyModelsProjectCommandContext())
+	locker.VerifyWasCalledOnce().UnlockByPull(EqString("owner/repo"), AnyInt())
+}
+
+func setupAPI(t *testing.T) (server.APIController, *emocks.MockProjectCommandBuilder, *emocks.MockProjectCommandRunner, *lockmocks.MockLocker, *emocks.MockWorkingDir) {
+	RegisterMockTestingT(t)
+	parser := emocks.NewMockEventParsing()
+	builder := emocks.NewMockProjectCommandBuilder()
+	runner := emocks.NewMockProjectCommandRunner()
+	locker := lockmocks.NewMockLocker()
+	workingDir := emocks.NewMockWorkingDir()
+	When(workingDir.ResolveRef(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), AnyString())).
+		ThenReturn("abc123", nil)
+	When(parser.ParseAPIPlanRequest(matchers.AnyModelsVCSHostType(), AnyString())).
+		Then(func(params []Param) ReturnValues {
+			return ReturnValues{models.Repo{FullName: params[1].(string), VCSHost: models.VCSHost{Type: params[0].(models.VCSHostType)}}, nil}
+		})
+	pullGetter := emocks.NewMockGithubPullGetter()
+	When(pullGetter.GetPullRequest(matchers.AnyModelsRepo(), AnyInt())).ThenReturn(&github.PullRequest{}, nil)
+	When(parser.ParseGithubPull(matchers.AnyPtrToGithubPullRequest())).
+		ThenReturn(models.PullRequest{Num: 2, HeadCommit: "abc123", HeadBranch: "feature", BaseBranch: "main"}, models.Repo{FullName: "owner/repo"}, models.Repo{FullName: "fork/repo"}, nil)
+	ac := server.APIController{
+		APISecret:             []byte(apiSecret),
+		Locker:                locker,
+		Logger:                logging.NewNoopLogger(),
+		Parser:                parser,
+		ProjectCommandBuilder: builder,
+		CommandRunner:         &events.DefaultCommandRunner{ProjectCommandRunner: runner, DB: lockmocks.NewMockBackend(), GithubPullGetter: pullGetter, EventParser: parser},
+		WorkingDir:            workingDir,
+	}
+	return ac, builder, runner, locker, workingDir
+}
+
+func apiRequest(t *testing.T, secret string, body server.APIRequest) *http.Request {
+	bodyBytes, err := json.Marshal(body)
+	Ok(t, err)
+	req, err := http.NewRequest("POST", "/api/plan", bytes.NewBuffer(bodyBytes))
+	Ok(t, err)
+	req.Header.Set("X-Atlantis-Token", secret)
+	return req
+}
@@ -0,0 +1,163 @@
+// Package auth authenticates and authorizes users of the Atlantis web UI.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Identity is a user that has logged in to the web UI.
+type Identity struct {
+	// Username is the user's name, ex. their login or email.
+	Username string
+	// Groups are the groups the user is a member of, if the authenticator
+	// supports them.
+	Groups []string
+}
+
+// Authenticator identifies the users making requests to the web UI.
+type Authenticator interface {
+	// Identify returns the identity of the user that sent r or nil if they
+	// haven't logged in.
+	Identify(r *http.Request) *Identity
+	// Challenge responds to a request from a user that hasn't logged in,
+	// ex. by asking for credentials or redirecting to the login page.
+	Challenge(w http.ResponseWriter, r *http.Request)
+	// Routes returns the extra routes the authenticator needs, keyed by
+	// path, ex. the OIDC callback.
+	Routes() map[string]http.HandlerFunc
+}
+
+// Permission is something a user can do in the web UI.
+type Permission int
+
+const (
+	// ViewPermission allows viewing the index, locks and pull request
+	// history.
+	ViewPermission Permission = iota
+	// DeleteLocksPermission allows deleting locks, which discards their
+	// plans.
+	DeleteLocksPermission
+	// CancelPermission allows cancelling running plans and applies.
+	CancelPermission
+	// EventQueuePermission allows viewing the event queue and retrying or
+	// discarding failed events.
+	EventQueuePermission
+)
+
+// Roles determines which users have which permissions. Entries are matched
+// against the user's username and their groups.
+type Roles struct {
+	// Viewers have ViewPermission. If empty, every user that has logged in
+	// is a viewer.
+	Viewers []string
+	// Admins have every permission. If empty, every viewer is an admin.
+	Admins []string
+}
+
+// NewRoles returns the roles for viewers and admins, which are
+// comma-separated lists of users and groups.
+func NewRoles(viewers string, admins string) Roles {
+	return Roles{
+		Viewers: splitList(viewers),
+		Admins:  splitList(admins),
+	}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Allowed returns true if id has permission p.
+func (r Roles) Allowed(id Identity, p Permission) bool {
+	isAdmin := r.matches(r.Admins, id)
+	isViewer := len(r.Viewers) == 0 || r.matches(r.Viewers, id) || isAdmin
+	switch p {
+	case ViewPermission:
+		return isViewer
+	case DeleteLocksPermission, CancelPermission, EventQueuePermission:
+		if len(r.Admins) == 0 {
+			return isViewer
+		}
+		return isAdmin
+	}
+	return false
+}
+
+func (r Roles) matches(entries []string, id Identity) bool {
+	for _, e := range entries {
+		if e == id.Username {
+			return true
+		}
+		for _, g := range id.Groups {
+			if e == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Middleware requires users to log in and have the right permissions before
+// their requests are handled.
+type Middleware struct {
+	// Authenticator identifies users. If it's nil, or the Middleware itself
+	// is nil, auth is disabled and every request is allowed.
+	Authenticator Authenticator
+	Roles         Roles
+	Logger        *logging.SimpleLogger
+}
+
+// Require wraps next so that it's only called for requests from users that
+// have logged in and have permission p. The user's identity is available to
+// next via FromContext.
+func (m *Middleware) Require(p Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m == nil || m.Authenticator == nil {
+			next(w, r)
+			return
+		}
+		id := m.Authenticator.Identify(r)
+		if id == nil {
+			m.Authenticator.Challenge(w, r)
+			return
+		}
+		if !m.Roles.Allowed(*id, p) {
+			m.Logger.Warn("user %q isn't allowed to %s %s", id.Username, r.Method, r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r.WithContext(NewContext(r.Context(), *id)))
+	}
+}
+
+// Routes returns the extra routes the authenticator needs, if any.
+func (m *Middleware) Routes() map[string]http.HandlerFunc {
+	if m == nil || m.Authenticator == nil {
+		return nil
+	}
+	return m.Authenticator.Routes()
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries id.
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity of the user that made the request with
+// ctx. It returns false if auth is disabled.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
@@ -0,0 +1,147 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRoles_Allowed(t *testing.T) {
+	alice := auth.Identity{Username: "alice"}
+	bob := auth.Identity{Username: "bob", Groups: []string{"ops"}}
+	carol := auth.Identity{Username: "carol", Groups: []string{"dev"}}
+
+	cases := []struct {
+		description string
+		roles       auth.Roles
+		id          auth.Identity
+		expView     bool
+		expDelete   bool
+	}{
+		{
+			"no roles allows everyone",
+			auth.Roles{},
+			alice,
+			true,
+			true,
+		},
+		{
+			"viewers only: viewers can delete",
+			auth.Roles{Viewers: []string{"alice"}},
+			alice,
+			true,
+			true,
+		},
+		{
+			"viewers only: others can't view or delete",
+			auth.Roles{Viewers: []string{"alice"}},
+			carol,
+			false,
+			false,
+		},
+		{
+			"admins by group",
+			auth.Roles{Viewers: []string{"dev"}, Admins: []string{"ops"}},
+			bob,
+			true,
+			true,
+		},
+		{
+			"viewer by group can't delete",
+			auth.Roles{Viewers: []string{"dev"}, Admins: []string{"ops"}},
+			carol,
+			true,
+			false,
+		},
+		{
+			"admins only: everyone can view",
+			auth.Roles{Admins: []string{"ops"}},
+			alice,
+			true,
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.expView, c.roles.Allowed(c.id, auth.ViewPermission))
+			Equals(t, c.expDelete, c.roles.Allowed(c.id, auth.DeleteLocksPermission))
+			Equals(t, c.expDelete, c.roles.Allowed(c.id, auth.CancelPermission))
+			Equals(t, c.expDelete, c.roles.Allowed(c.id, auth.EventQueuePermission))
+		})
+	}
+}
+
+func TestNewRoles(t *testing.T) {
+	Equals(t, auth.Roles{Admins: []string{"ops", "alice"}}, auth.NewRoles("", "ops, alice,"))
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	m := auth.Middleware{Logger: logging.NewNoopLogger()}
+	called := false
+	handler := m.Require(auth.DeleteLocksPermission, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, ok := auth.FromContext(r.Context())
+		Assert(t, !ok, "expected no identity when auth is disabled")
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/locks?id=abc", nil))
+	Assert(t, called, "expected handler to be called")
+	Equals(t, 0, len(m.Routes()))
+}
+
+func TestMiddleware_BasicAuth(t *testing.T) {
+	authenticator, err := auth.NewBasicAuthenticator("alice:alicepass, bob:bobpass")
+	Ok(t, err)
+	m := auth.Middleware{
+		Authenticator: authenticator,
+		Roles:         auth.Roles{Admins: []string{"alice"}},
+		Logger:        logging.NewNoopLogger(),
+	}
+	var gotUser string
+	handler := m.Require(auth.DeleteLocksPermission, func(w http.ResponseWriter, r *http.Request) {
+		id, ok := auth.FromContext(r.Context())
+		Assert(t, ok, "expected identity in context")
+		gotUser = id.Username
+	})
+
+	cases := []struct {
+		description string
+		user        string
+		password    string
+		expCode     int
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized},
+		{"wrong password", "alice", "bobpass", http.StatusUnauthorized},
+		{"unknown user", "carol", "alicepass", http.StatusUnauthorized},
+		{"not an admin", "bob", "bobpass", http.StatusForbidden},
+		{"admin", "alice", "alicepass", http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			gotUser = ""
+			req := httptest.NewRequest("DELETE", "/locks?id=abc", nil)
+			if c.user != "" {
+				req.SetBasicAuth(c.user, c.password)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+			Equals(t, c.expCode, w.Code)
+			if c.expCode == http.StatusUnauthorized {
+				Equals(t, `Basic realm="Atlantis", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+			}
+			if c.expCode == http.StatusOK {
+				Equals(t, c.user, gotUser)
+			}
+		})
+	}
+}
+
+func TestNewBasicAuthenticator_Invalid(t *testing.T) {
+	_, err := auth.NewBasicAuthenticator("alice")
+	ErrEquals(t, `invalid user "alice": must be of the form username:password`, err)
+	_, err = auth.NewBasicAuthenticator(" , ")
+	ErrEquals(t, "no users configured", err)
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BasicAuthenticator authenticates users with HTTP basic auth.
+type BasicAuthenticator struct {
+	// passwords maps each username to its password.
+	passwords map[string]string
+}
+
+// NewBasicAuthenticator returns an authenticator for users, a comma-separated
+// list of username:password pairs.
+func NewBasicAuthenticator(users string) (*BasicAuthenticator, error) {
+	passwords := make(map[string]string)
+	for _, user := range strings.Split(users, ",") {
+		user = strings.TrimSpace(user)
+		if user == "" {
+			continue
+		}
+		parts := strings.SplitN(user, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid user %q: must be of the form username:password", parts[0])
+		}
+		passwords[parts[0]] = parts[1]
+	}
+	if len(passwords) == 0 {
+		return nil, fmt.Errorf("no users configured")
+	}
+	return &BasicAuthenticator{passwords: passwords}, nil
+}
+
+// Identify returns the user if the request has valid credentials.
+func (b *BasicAuthenticator) Identify(r *http.Request) *Identity {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+	expected, ok := b.passwords[username]
+	if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+		return nil
+	}
+	return &Identity{Username: username}
+}
+
+// Challenge asks the browser for credentials.
+func (b *BasicAuthenticator) Challenge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="Atlantis", charset="UTF-8"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// Routes returns nil since basic auth doesn't need any extra routes.
+func (b *BasicAuthenticator) Routes() map[string]http.HandlerFunc {
+	return nil
+}
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OIDCCallbackPath is the path that the OIDC provider redirects users
+	// to after they log in.
+	OIDCCallbackPath = "/auth/callback"
+	// sessionCookie holds the user's signed session once they've logged in.
+	sessionCookie = "atlantis_session"
+	// stateCookie holds the signed state of a login that's in progress.
+	stateCookie = "atlantis_oidc_state"
+	// sessionDuration is how long users stay logged in.
+	sessionDuration = 12 * time.Hour
+	// loginTimeout is how long users have to log in with the provider.
+	loginTimeout = 10 * time.Minute
+)
+
+// OIDCConfig configures an OIDCAuthenticator.
+type OIDCConfig struct {
+	// IssuerURL is the URL of the OIDC provider, ex.
+	// https://accounts.google.com. Its discovery document must be served at
+	// {IssuerURL}/.well-known/openid-configuration.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// AtlantisURL is the URL users reach Atlantis at. It's used to build the
+	// callback URL.
+	AtlantisURL string
+	// GroupsClaim is the name of the user info claim that lists the user's
+	// groups.
+	GroupsClaim string
+	// HTTPClient is used to make requests to the provider. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// OIDCAuthenticator authenticates users by logging them in with an OpenID
+// Connect provider using the authorization code flow. Once they've logged in,
+// their identity is stored in a signed session cookie.
+type OIDCAuthenticator struct {
+	oauth       oauth2.Config
+	userInfoURL string
+	groupsClaim string
+	// sessionKey signs the session and state cookies.
+	sessionKey   []byte
+	secureCookie bool
+	httpClient   *http.Client
+	logger       *logging.SimpleLogger
+}
+
+// oidcDiscovery is the part of the provider's discovery document that we use.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// oidcSession is stored in the session cookie.
+type oidcSession struct {
+	Username string    `json:"username"`
+	Groups   []string  `json:"groups,omitempty"`
+	Expiry   time.Time `json:"expiry"`
+}
+
+// oidcState is stored in the state cookie while the user logs in.
+type oidcState struct {
+	State string `json:"state"`
+	// Redirect is the path the user was trying to view before logging in.
+	Redirect string    `json:"redirect"`
+	Expiry   time.Time `json:"expiry"`
+}
+
+// NewOIDCAuthenticator looks up the provider's endpoints using its discovery
+// document and returns an authenticator that uses them.
+func NewOIDCAuthenticator(cfg OIDCConfig, logger *logging.SimpleLogger) (*OIDCAuthenticator, error) {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	issuer := strings.TrimSuffix(cfg.IssuerURL, "/")
+	resp, err := httpClient.Get(issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting OIDC discovery document")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting OIDC discovery document: unexpected status %s", resp.Status)
+	}
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, errors.Wrap(err, "parsing OIDC discovery document")
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC discovery document is for issuer %q, expected %q", discovery.Issuer, cfg.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserInfoEndpoint == "" {
+		return nil, fmt.Errorf("OIDC discovery document must include the authorization, token and userinfo endpoints")
+	}
+
+	// We derive the key from the client secret so that sessions are valid
+	// across restarts and across Atlantis instances.
+	sessionKey := sha256.Sum256([]byte("atlantis-web-session:" + cfg.ClientSecret))
+	return &OIDCAuthenticator{
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  discovery.AuthorizationEndpoint,
+				TokenURL: discovery.TokenEndpoint,
+			},
+			RedirectURL: strings.TrimSuffix(cfg.AtlantisURL, "/") + OIDCCallbackPath,
+			Scopes:      []string{"openid", "profile", "email"},
+		},
+		userInfoURL:  discovery.UserInfoEndpoint,
+		groupsClaim:  cfg.GroupsClaim,
+		sessionKey:   sessionKey[:],
+		secureCookie: strings.HasPrefix(cfg.AtlantisURL, "https://"),
+		httpClient:   httpClient,
+		logger:       logger,
+	}, nil
+}
+
+// Identify returns the user from the session cookie if it's valid and hasn't
+// expired.
+func (o *OIDCAuthenticator) Identify(r *http.Request) *Identity {
+	var session oidcSession
+	if !o.readCookie(r, sessionCookie, &session) || time.Now().After(session.Expiry) || session.Username == "" {
+		return nil
+	}
+	return &Identity{Username: session.Username, Groups: session.Groups}
+}
+
+// Challenge redirects the user to the provider to log in. Since requests
+// that aren't GETs are made by scripts that can't follow the redirect, they
+// get a 401 instead.
+func (o *OIDCAuthenticator) Challenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		o.logger.Err("generating OIDC state: %s", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	state := oidcState{
+		State:    hex.EncodeToString(stateBytes),
+		Redirect: r.URL.RequestURI(),
+		Expiry:   time.Now().Add(loginTimeout),
+	}
+	if err := o.setCookie(w, stateCookie, state, state.Expiry); err != nil {
+		o.logger.Err("setting OIDC state cookie: %s", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, o.oauth.AuthCodeURL(state.State), http.StatusFound)
+}
+
+// Routes returns the callback route.
+func (o *OIDCAuthenticator) Routes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		OIDCCallbackPath: o.Callback,
+	}
+}
+
+// Callback is the route the provider redirects users to after they log in.
+// It exchanges the authorization code for a token, looks up the user's info
+// and stores it in the session cookie.
+func (o *OIDCAuthenticator) Callback(w http.ResponseWriter, r *http.Request) {
+	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+		o.logger.Warn("OIDC login failed: %s: %s", errMsg, r.URL.Query().Get("error_description"))
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+	var state oidcState
+	if !o.readCookie(r, stateCookie, &state) || time.Now().After(state.Expiry) ||
+		!hmac.Equal([]byte(state.State), []byte(r.URL.Query().Get("state"))) {
+		http.Error(w, "Invalid login state, try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/", MaxAge: -1})
+
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, o.httpClient)
+	token, err := o.oauth.Exchange(ctx, r.URL.Query().Get("code"))
+	if err != nil {
+		o.logger.Warn("exchanging OIDC authorization code: %s", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+	id, err := o.userInfo(token.AccessToken)
+	if err != nil {
+		o.logger.Warn("getting OIDC user info: %s", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+	session := oidcSession{
+		Username: id.Username,
+		Groups:   id.Groups,
+		Expiry:   time.Now().Add(sessionDuration),
+	}
+	if err := o.setCookie(w, sessionCookie, session, session.Expiry); err != nil {
+		o.logger.Err("setting session cookie: %s", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	o.logger.Info("user %q logged in", id.Username)
+
+	// Only redirect to paths on this server.
+	redirect := state.Redirect
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		redirect = "/"
+	}
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// userInfo returns the identity of the user that accessToken was issued for
+// using the provider's userinfo endpoint.
+func (o *OIDCAuthenticator) userInfo(accessToken string) (Identity, error) {
+	req, err := http.NewRequest(http.MethodGet, o.userInfoURL, nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return Identity{}, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Identity{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Identity{}, fmt.Errorf("unexpected status %s: %s", resp.Status, string(body))
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return Identity{}, errors.Wrapf(err, "parsing response %q", string(body))
+	}
+
+	var id Identity
+	for _, claim := range []string{"preferred_username", "email", "sub"} {
+		if s, ok := claims[claim].(string); ok && s != "" {
+			id.Username = s
+			break
+		}
+	}
+	if id.Username == "" {
+		return Identity{}, fmt.Errorf("user info has no preferred_username, email or sub claim")
+	}
+	switch groups := claims[o.groupsClaim].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	case string:
+		id.Groups = []string{groups}
+	}
+	return id, nil
+}
+
+// setCookie stores v in the cookie called name, signed so that it can't be
+// modified.
+func (o *OIDCAuthenticator) setCookie(w http.ResponseWriter, name string, v interface{}, expires time.Time) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(o.sign(name, payload)),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   o.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// readCookie reads the cookie called name into v. It returns false if the
+// cookie doesn't exist or its signature is invalid.
+func (o *OIDCAuthenticator) readCookie(r *http.Request, name string, v interface{}) bool {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return false
+	}
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, o.sign(name, payload)) {
+		return false
+	}
+	return json.Unmarshal(payload, v) == nil
+}
+
+// sign returns the signature of payload in the cookie called name. The name is
+// signed too so that one cookie's value can't be used as another's, ex. the
+// state cookie, which anyone can get, as a session.
+func (o *OIDCAuthenticator) sign(name string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, o.sessionKey)
+	mac.Write([]byte(name + ":")) // nolint: errcheck
+	mac.Write(payload)            // nolint: errcheck
+	return mac.Sum(nil)
+}
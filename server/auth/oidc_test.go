@@ -0,0 +1,211 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// oidcStub is a minimal OIDC provider. It issues the access token
+// "token-for-{code}" for any code and returns userInfo for it.
+type oidcStub struct {
+	server   *httptest.Server
+	userInfo map[string]interface{}
+}
+
+func newOIDCStub(t *testing.T) *oidcStub {
+	stub := &oidcStub{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{ // nolint: errcheck
+			"issuer":                 stub.server.URL,
+			"authorization_endpoint": stub.server.URL + "/authorize",
+			"token_endpoint":         stub.server.URL + "/token",
+			"userinfo_endpoint":      stub.server.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, r.ParseForm())
+		Equals(t, "authorization_code", r.PostForm.Get("grant_type"))
+		Equals(t, "https://atlantis.example.com/auth/callback", r.PostForm.Get("redirect_uri"))
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			user, password = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if user != "client-id" || password != "client-secret" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ // nolint: errcheck
+			"access_token": "token-for-" + r.PostForm.Get("code"),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-for-good-code" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(stub.userInfo) // nolint: errcheck
+	})
+	stub.server = httptest.NewServer(mux)
+	return stub
+}
+
+func newOIDCAuthenticator(t *testing.T, stub *oidcStub) *auth.OIDCAuthenticator {
+	authenticator, err := auth.NewOIDCAuthenticator(auth.OIDCConfig{
+		IssuerURL:    stub.server.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		AtlantisURL:  "https://atlantis.example.com",
+		GroupsClaim:  "groups",
+	}, logging.NewNoopLogger())
+	Ok(t, err)
+	return authenticator
+}
+
+func TestOIDCAuthenticator_Login(t *testing.T) {
+	stub := newOIDCStub(t)
+	defer stub.server.Close()
+	stub.userInfo = map[string]interface{}{
+		"sub":                "1234",
+		"preferred_username": "alice",
+		"groups":             []string{"ops", "dev"},
+	}
+	authenticator := newOIDCAuthenticator(t, stub)
+	m := auth.Middleware{
+		Authenticator: authenticator,
+		Roles:         auth.Roles{Admins: []string{"ops"}},
+		Logger:        logging.NewNoopLogger(),
+	}
+	var gotID auth.Identity
+	handler := m.Require(auth.DeleteLocksPermission, func(w http.ResponseWriter, r *http.Request) {
+		gotID, _ = auth.FromContext(r.Context())
+	})
+
+	t.Log("requests that aren't GETs aren't redirected")
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("DELETE", "/locks?id=abc", nil))
+	Equals(t, http.StatusUnauthorized, w.Code)
+
+	t.Log("users that haven't logged in are redirected to the provider")
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/lock?id=abc", nil))
+	Equals(t, http.StatusFound, w.Code)
+	loginURL, err := url.Parse(w.Header().Get("Location"))
+	Ok(t, err)
+	Equals(t, stub.server.URL+"/authorize", loginURL.Scheme+"://"+loginURL.Host+loginURL.Path)
+	Equals(t, "client-id", loginURL.Query().Get("client_id"))
+	Equals(t, "https://atlantis.example.com/auth/callback", loginURL.Query().Get("redirect_uri"))
+	state := loginURL.Query().Get("state")
+	stateCookies := w.Result().Cookies()
+
+	t.Log("the callback fails if the state doesn't match")
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/auth/callback?code=good-code&state=wrong", nil)
+	for _, c := range stateCookies {
+		req.AddCookie(c)
+	}
+	authenticator.Callback(w, req)
+	Equals(t, http.StatusBadRequest, w.Code)
+
+	t.Log("the callback logs the user in and redirects back")
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/auth/callback?code=good-code&state="+state, nil)
+	for _, c := range stateCookies {
+		req.AddCookie(c)
+	}
+	authenticator.Routes()[auth.OIDCCallbackPath](w, req)
+	Equals(t, http.StatusFound, w.Code)
+	Equals(t, "/lock?id=abc", w.Header().Get("Location"))
+
+	t.Log("the session cookie identifies the user")
+	req = httptest.NewRequest("DELETE", "/locks?id=abc", nil)
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge >= 0 {
+			req.AddCookie(c)
+		}
+	}
+	w = httptest.NewRecorder()
+	handler(w, req)
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, auth.Identity{Username: "alice", Groups: []string{"ops", "dev"}}, gotID)
+}
+
+func TestOIDCAuthenticator_BadCode(t *testing.T) {
+	stub := newOIDCStub(t)
+	defer stub.server.Close()
+	stub.userInfo = map[string]interface{}{"sub": "1234"}
+	authenticator := newOIDCAuthenticator(t, stub)
+
+	w := httptest.NewRecorder()
+	authenticator.Challenge(w, httptest.NewRequest("GET", "/", nil))
+	loginURL, err := url.Parse(w.Header().Get("Location"))
+	Ok(t, err)
+	req := httptest.NewRequest("GET", "/auth/callback?code=bad-code&state="+loginURL.Query().Get("state"), nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	authenticator.Callback(w, req)
+	Equals(t, http.StatusUnauthorized, w.Code)
+	for _, c := range w.Result().Cookies() {
+		Assert(t, c.Name != "atlantis_session", "expected no session cookie")
+	}
+}
+
+func TestOIDCAuthenticator_TamperedSession(t *testing.T) {
+	stub := newOIDCStub(t)
+	defer stub.server.Close()
+	authenticator := newOIDCAuthenticator(t, stub)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "atlantis_session", Value: "eyJ1c2VybmFtZSI6ImFsaWNlIn0.c2lnbmF0dXJl"})
+	Assert(t, authenticator.Identify(req) == nil, "expected tampered session to be rejected")
+}
+
+// Test that the state cookie, which anyone can get by visiting Atlantis,
+// can't be used as a session.
+func TestOIDCAuthenticator_StateCookieAsSession(t *testing.T) {
+	stub := newOIDCStub(t)
+	defer stub.server.Close()
+	authenticator := newOIDCAuthenticator(t, stub)
+
+	w := httptest.NewRecorder()
+	authenticator.Challenge(w, httptest.NewRequest("GET", "/", nil))
+	var state *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "atlantis_oidc_state" {
+			state = c
+		}
+	}
+	Assert(t, state != nil, "expected state cookie")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "atlantis_session", Value: state.Value})
+	Assert(t, authenticator.Identify(req) == nil, "expected state cookie to be rejected as a session")
+}
+
+func TestNewOIDCAuthenticator_WrongIssuer(t *testing.T) {
+	stub := newOIDCStub(t)
+	defer stub.server.Close()
+	_, err := auth.NewOIDCAuthenticator(auth.OIDCConfig{
+		IssuerURL: stub.server.URL + "/",
+	}, logging.NewNoopLogger())
+	Ok(t, err)
+
+	other := httptest.NewServer(stub.server.Config.Handler)
+	defer other.Close()
+	_, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{
+		IssuerURL: other.URL,
+	}, logging.NewNoopLogger())
+	ErrContains(t, "OIDC discovery document is for issuer", err)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// EventQueueController handles requests for the queue of webhook events.
+type EventQueueController struct {
+	AtlantisVersion    string
+	AtlantisURL        *url.URL
+	EventQueue         *events.EventQueue
+	Logger             *logging.SimpleLogger
+	EventQueueTemplate TemplateWriter
+}
+
+// GetEventQueue is the GET /event-queue route. It renders the events that
+// haven't been processed yet, oldest first.
+func (e *EventQueueController) GetEventQueue(w http.ResponseWriter, r *http.Request) {
+	queued, err := e.EventQueue.DB.GetEvents()
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting events: %s", err)
+		return
+	}
+
+	var eventData []QueuedEventData
+	for _, event := range queued {
+		if event.Status == models.ProcessedEventStatus {
+			continue
+		}
+		command := "autoplan"
+		if event.Comment != nil {
+			command = event.Comment.Name.String()
+		}
+		eventData = append(eventData, QueuedEventData{
+			ID:                event.ID,
+			Status:            event.Status.String(),
+			Failed:            event.Status == models.FailedEventStatus,
+			Command:           command,
+			RepoFullName:      event.BaseRepo.FullName,
+			PullNum:           event.PullNum,
+			User:              event.User.Username,
+			Attempts:          event.Attempts,
+			Error:             event.Error,
+			ReceivedFormatted: event.ReceivedAt.Format(historyTimeFormat),
+		})
+	}
+
+	err = e.EventQueueTemplate.Execute(w, EventQueueData{
+		Events:          eventData,
+		AtlantisVersion: e.AtlantisVersion,
+		CleanedBasePath: e.AtlantisURL.Path,
+	})
+	if err != nil {
+		e.Logger.Err(err.Error())
+	}
+}
+
+// RetryEvent is the POST /event-queue/{id}/retry route. It queues a failed
+// event to be run again.
+func (e *EventQueueController) RetryEvent(w http.ResponseWriter, r *http.Request) {
+	id, ok := e.getID(w, r)
+	if !ok {
+		return
+	}
+	if err := e.EventQueue.Retry(id); err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Failed retrying event: %s", err)
+		return
+	}
+	e.respond(w, logging.Info, http.StatusOK, "Retrying event %q", id)
+}
+
+// DiscardEvent is the DELETE /event-queue/{id} route. It deletes a failed
+// event without running it.
+func (e *EventQueueController) DiscardEvent(w http.ResponseWriter, r *http.Request) {
+	id, ok := e.getID(w, r)
+	if !ok {
+		return
+	}
+	if err := e.EventQueue.Discard(id); err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Failed discarding event: %s", err)
+		return
+	}
+	e.respond(w, logging.Info, http.StatusOK, "Discarded event %q", id)
+}
+
+func (e *EventQueueController) getID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok || id == "" {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "No event id in request")
+		return "", false
+	}
+	return id, true
+}
+
+func (e *EventQueueController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	e.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
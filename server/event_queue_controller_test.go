@@ -0,0 +1,125 @@
+package server_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	sMocks "github.com/runatlantis/atlantis/server/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func setupEventQueueController(t *testing.T) (server.EventQueueController, *sMocks.MockTemplateWriter, time.Time, func()) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+
+	received := time.Now()
+	for _, e := range []models.QueuedEvent{
+		{ID: "pending", Status: models.PendingEventStatus, Autoplan: true, BaseRepo: models.Repo{FullName: "owner/repo"}, PullNum: 1, User: models.User{Username: "lkysow"}, ReceivedAt: received},
+		{ID: "failed", Status: models.FailedEventStatus, Comment: &models.QueuedComment{Name: models.ApplyCommand}, BaseRepo: models.Repo{FullName: "owner/repo"}, PullNum: 2, Attempts: 3, Error: "PANIC: boom", ReceivedAt: received.Add(time.Second)},
+		{ID: "processed", Status: models.ProcessedEventStatus, Autoplan: true, ReceivedAt: received.Add(2 * time.Second)},
+	} {
+		_, err := boltdb.EnqueueEvent(e)
+		Ok(t, err)
+	}
+
+	tmpl := sMocks.NewMockTemplateWriter()
+	atlantisURL, err := url.Parse("https://example.com/basepath")
+	Ok(t, err)
+	ec := server.EventQueueController{
+		AtlantisVersion:    "1300135",
+		AtlantisURL:        atlantisURL,
+		EventQueue:         &events.EventQueue{DB: boltdb, Logger: logging.NewNoopLogger(), Workers: 1},
+		Logger:             logging.NewNoopLogger(),
+		EventQueueTemplate: tmpl,
+	}
+	return ec, tmpl, received, cleanup
+}
+
+func TestGetEventQueue(t *testing.T) {
+	ec, tmpl, received, cleanup := setupEventQueueController(t)
+	defer cleanup()
+
+	req, _ := http.NewRequest("GET", "/event-queue", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	ec.GetEventQueue(w, req)
+
+	// Processed events shouldn't be shown.
+	tmpl.VerifyWasCalledOnce().Execute(w, server.EventQueueData{
+		Events: []server.QueuedEventData{
+			{
+				ID:                "pending",
+				Status:            "pending",
+				Command:           "autoplan",
+				RepoFullName:      "owner/repo",
+				PullNum:           1,
+				User:              "lkysow",
+				ReceivedFormatted: received.Format("02-01-2006 15:04:05"),
+			},
+			{
+				ID:                "failed",
+				Status:            "failed",
+				Failed:            true,
+				Command:           "apply",
+				RepoFullName:      "owner/repo",
+				PullNum:           2,
+				Attempts:          3,
+				Error:             "PANIC: boom",
+				ReceivedFormatted: received.Add(time.Second).Format("02-01-2006 15:04:05"),
+			},
+		},
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "/basepath",
+	})
+}
+
+func TestRetryEvent(t *testing.T) {
+	ec, _, _, cleanup := setupEventQueueController(t)
+	defer cleanup()
+
+	req, _ := http.NewRequest("POST", "/event-queue/pending/retry", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "pending"})
+	w := httptest.NewRecorder()
+	ec.RetryEvent(w, req)
+	responseContains(t, w, http.StatusBadRequest, "Failed retrying event: event \"pending\" is pending, only failed events can be retried or discarded")
+
+	req = mux.SetURLVars(req, map[string]string{"id": "failed"})
+	w = httptest.NewRecorder()
+	ec.RetryEvent(w, req)
+	responseContains(t, w, http.StatusOK, "Retrying event \"failed\"")
+	queued, err := ec.EventQueue.DB.GetEvents()
+	Ok(t, err)
+	Equals(t, models.PendingEventStatus, queued[1].Status)
+	Equals(t, 0, queued[1].Attempts)
+}
+
+func TestDiscardEvent(t *testing.T) {
+	ec, _, _, cleanup := setupEventQueueController(t)
+	defer cleanup()
+
+	req, _ := http.NewRequest("DELETE", "/event-queue/missing", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	w := httptest.NewRecorder()
+	ec.DiscardEvent(w, req)
+	responseContains(t, w, http.StatusBadRequest, "Failed discarding event: no event found with id \"missing\"")
+
+	req = mux.SetURLVars(req, map[string]string{"id": "failed"})
+	w = httptest.NewRecorder()
+	ec.DiscardEvent(w, req)
+	responseContains(t, w, http.StatusOK, "Discarded event \"failed\"")
+	queued, err := ec.EventQueue.DB.GetEvents()
+	Ok(t, err)
+	Equals(t, 2, len(queued))
+}
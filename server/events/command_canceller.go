@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// CommandCanceller tracks the commands running for each pull request so that
+// they can be cancelled, ex. with atlantis cancel. Its zero value is ready to
+// use.
+type CommandCanceller struct {
+	mu     sync.Mutex
+	nextID int
+	// running holds the cancel functions of the commands running for each
+	// pull request by id.
+	running map[string]map[int]context.CancelFunc
+}
+
+// Track returns a context that's cancelled when Cancel is called for the pull
+// request. Callers must call done when their command has finished. If c is
+// nil, the context is nil, which means the command can't be cancelled.
+func (c *CommandCanceller) Track(repoFullName string, pullNum int) (ctx context.Context, done func()) {
+	if c == nil {
+		return nil, func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	key := c.pullKey(repoFullName, pullNum)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running == nil {
+		c.running = make(map[string]map[int]context.CancelFunc)
+	}
+	if c.running[key] == nil {
+		c.running[key] = make(map[int]context.CancelFunc)
+	}
+	id := c.nextID
+	c.nextID++
+	c.running[key][id] = cancel
+	return ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.running[key], id)
+		if len(c.running[key]) == 0 {
+			delete(c.running, key)
+		}
+		cancel()
+	}
+}
+
+// Cancel cancels the commands running for the pull request. It returns how
+// many there were.
+func (c *CommandCanceller) Cancel(repoFullName string, pullNum int) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	running := c.running[c.pullKey(repoFullName, pullNum)]
+	for _, cancel := range running {
+		cancel()
+	}
+	return len(running)
+}
+
+// CancelAll cancels every running command, ex. when Atlantis is shutting down
+// and can't wait for them any longer. It returns how many there were.
+func (c *CommandCanceller) CancelAll() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancelled := 0
+	for _, running := range c.running {
+		for _, cancel := range running {
+			cancel()
+			cancelled++
+		}
+	}
+	return cancelled
+}
+
+func (c *CommandCanceller) pullKey(repoFullName string, pullNum int) string {
+	return fmt.Sprintf("%s#%d", repoFullName, pullNum)
+}
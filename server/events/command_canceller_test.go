@@ -0,0 +1,35 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCommandCanceller_Cancel(t *testing.T) {
+	c := &events.CommandCanceller{}
+	ctx1, done1 := c.Track("owner/repo", 1)
+	defer done1()
+	ctx2, done2 := c.Track("owner/repo", 2)
+	defer done2()
+
+	Equals(t, 1, c.Cancel("owner/repo", 1))
+	Assert(t, ctx1.Err() != nil, "exp pull 1's command to be cancelled")
+	Ok(t, ctx2.Err())
+}
+
+func TestCommandCanceller_CancelAll(t *testing.T) {
+	c := &events.CommandCanceller{}
+	ctx1, done1 := c.Track("owner/repo", 1)
+	defer done1()
+	ctx2, done2 := c.Track("owner/other", 1)
+	defer done2()
+	_, done3 := c.Track("owner/repo", 2)
+	// Finished commands aren't counted.
+	done3()
+
+	Equals(t, 2, c.CancelAll())
+	Assert(t, ctx1.Err() != nil, "exp owner/repo's command to be cancelled")
+	Assert(t, ctx2.Err() != nil, "exp owner/other's command to be cancelled")
+}
@@ -15,13 +15,19 @@ package events
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/mcdafydd/go-azuredevops/azuredevops"
 	"github.com/pkg/errors"
-	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/recovery"
 	gitlab "github.com/xanzy/go-gitlab"
@@ -34,8 +40,10 @@ type CommandRunner interface {
 	// RunCommentCommand is the first step after a command request has been parsed.
 	// It handles gathering additional information needed to execute the command
 	// and then calling the appropriate services to finish executing the command.
-	RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand)
-	RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User)
+	// requestID is the ID the VCS host gave the webhook request, if any, and
+	// is only used for logging.
+	RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand, requestID string)
+	RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, requestID string)
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_github_pull_getter.go GithubPullGetter
@@ -54,6 +62,14 @@ type AzureDevopsPullGetter interface {
 	GetPullRequest(repo models.Repo, pullNum int) (*azuredevops.GitPullRequest, error)
 }
 
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_gitea_pull_getter.go GiteaPullGetter
+
+// GiteaPullGetter makes API calls to get pull requests.
+type GiteaPullGetter interface {
+	// GetPullRequest gets the pull request with id pullNum for the repo.
+	GetPullRequest(repo models.Repo, pullNum int) (*gitea.PullRequest, error)
+}
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_gitlab_merge_request_getter.go GitlabMergeRequestGetter
 
 // GitlabMergeRequestGetter makes API calls to get merge requests.
@@ -67,6 +83,7 @@ type DefaultCommandRunner struct {
 	VCSClient                vcs.Client
 	GithubPullGetter         GithubPullGetter
 	AzureDevopsPullGetter    AzureDevopsPullGetter
+	GiteaPullGetter          GiteaPullGetter
 	GitlabMergeRequestGetter GitlabMergeRequestGetter
 	CommitStatusUpdater      CommitStatusUpdater
 	DisableApplyAll          bool
@@ -92,13 +109,38 @@ type DefaultCommandRunner struct {
 	GlobalAutomerge   bool
 	PendingPlanFinder PendingPlanFinder
 	WorkingDir        WorkingDir
-	DB                *db.BoltDB
+	WorkingDirLocker  WorkingDirLocker
+	Locker            locking.Locker
+	DB                locking.Backend
+	// ParallelPoolSize is the maximum number of projects that will be
+	// planned or applied at the same time when a repo has enabled parallel
+	// plans or applies. This is set via a CLI flag.
+	ParallelPoolSize int
+	// HistoryRetention is how long the output of plans and applies is kept.
+	// If 0, it's kept forever.
+	HistoryRetention time.Duration
+	// Jobs holds the output of plans and applies while they run so it can be
+	// followed in the web UI. If nil, the output isn't streamed.
+	Jobs *jobs.Registry
+	// Canceller tracks the running commands so that they can be cancelled
+	// with atlantis cancel. If nil, commands can't be cancelled.
+	Canceller *CommandCanceller
+	// GithubChecks is true if the results of projects in GitHub repos are
+	// published as check runs. Each project then gets its own check run even
+	// if Jobs is nil.
+	GithubChecks bool
+	// DriftDetector, if set, is given the repo of each pull request event so
+	// that it can check repos on any VCS host for drift.
+	DriftDetector *DriftDetector
 }
 
 // RunAutoplanCommand runs plan when a pull request is opened or updated.
-func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
-	log := c.buildLogger(baseRepo.FullName, pull.Num)
+func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, requestID string) {
+	log := c.buildLogger(baseRepo.FullName, pull.Num, models.PlanCommand, requestID)
 	defer c.logPanics(baseRepo, pull.Num, log)
+	if c.DriftDetector != nil {
+		c.DriftDetector.RecordRepo(log, baseRepo)
+	}
 	ctx := &CommandContext{
 		User:     user,
 		Log:      log,
@@ -134,6 +176,11 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		return
 	}
 
+	defer c.trackCancellation(ctx, projectCmds)()
+	c.startJobs(ctx, models.PlanCommand, projectCmds)
+	defer finishJobs(projectCmds)
+
+	startedAt := time.Now()
 	result := c.runProjectCmds(projectCmds, models.PlanCommand)
 	if c.automergeEnabled(ctx, projectCmds) && result.HasErrors() {
 		ctx.Log.Info("deleting plans because there were errors and automerge requires all plans succeed")
@@ -141,7 +188,9 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		result.PlansDeleted = true
 	}
 	c.updatePull(ctx, AutoplanCommand{}, result)
-	pullStatus, err := c.updateDB(ctx, ctx.Pull, result.ProjectResults)
+	c.recordHistory(ctx, models.PlanCommand, startedAt, result.ProjectResults)
+	dbResults := c.runPolicyChecks(ctx, PolicyCheckCommand{Autoplan: true}, projectCmds, result)
+	pullStatus, err := c.updateDB(ctx, ctx.Pull, dbResults)
 	if err != nil {
 		c.Logger.Err("writing results: %s", err)
 	}
@@ -154,9 +203,12 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 // enough data to construct the Repo model and callers might want to wait until
 // the event is further validated before making an additional (potentially
 // wasteful) call to get the necessary data.
-func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand) {
-	log := c.buildLogger(baseRepo.FullName, pullNum)
+func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand, requestID string) {
+	log := c.buildLogger(baseRepo.FullName, pullNum, cmd.Name, requestID)
 	defer c.logPanics(baseRepo, pullNum, log)
+	if c.DriftDetector != nil {
+		c.DriftDetector.RecordRepo(log, baseRepo)
+	}
 
 	if c.DisableApplyAll && cmd.Name == models.ApplyCommand && !cmd.IsForSpecificProject() {
 		log.Info("ignoring apply command without flags since apply all is disabled")
@@ -185,6 +237,8 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		pull = *maybePull
 	case models.AzureDevops:
 		pull, headRepo, err = c.getAzureDevopsData(baseRepo, pullNum)
+	case models.Gitea:
+		pull, headRepo, err = c.getGiteaData(baseRepo, pullNum)
 	default:
 		err = errors.New("Unknown VCS type–this is a bug")
 	}
@@ -206,7 +260,21 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		return
 	}
 
-	if cmd.CommandName() == models.ApplyCommand {
+	if cmd.Name == models.UnlockCommand {
+		c.unlock(ctx, cmd)
+		return
+	}
+	if cmd.Name == models.CancelCommand {
+		c.cancel(ctx)
+		return
+	}
+
+	// import and state rm modify state directly so they don't have their own
+	// commit statuses and their results aren't saved. They're gated by the
+	// same apply requirements as apply.
+	isStateCmd := cmd.Name == models.ImportCommand || cmd.Name == models.StateRmCommand
+
+	if cmd.CommandName() == models.ApplyCommand || isStateCmd {
 		// Get the mergeable status before we set any build statuses of our own.
 		// We do this here because when we set a "Pending" status, if users have
 		// required the Atlantis status checks to pass, then we've now changed
@@ -222,8 +290,10 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		ctx.Log.Info("pull request mergeable status: %t", ctx.PullMergeable)
 	}
 
-	if err = c.CommitStatusUpdater.UpdateCombined(baseRepo, pull, models.PendingCommitStatus, cmd.CommandName()); err != nil {
-		ctx.Log.Warn("unable to update commit status: %s", err)
+	if !isStateCmd {
+		if err = c.CommitStatusUpdater.UpdateCombined(baseRepo, pull, models.PendingCommitStatus, cmd.CommandName()); err != nil {
+			ctx.Log.Warn("unable to update commit status: %s", err)
+		}
 	}
 
 	var projectCmds []models.ProjectCommandContext
@@ -232,19 +302,40 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		projectCmds, err = c.ProjectCommandBuilder.BuildPlanCommands(ctx, cmd)
 	case models.ApplyCommand:
 		projectCmds, err = c.ProjectCommandBuilder.BuildApplyCommands(ctx, cmd)
+	case models.ImportCommand:
+		projectCmds, err = c.ProjectCommandBuilder.BuildImportCommands(ctx, cmd)
+	case models.StateRmCommand:
+		projectCmds, err = c.ProjectCommandBuilder.BuildStateRmCommands(ctx, cmd)
 	default:
-		ctx.Log.Err("failed to determine desired command, neither plan nor apply")
+		ctx.Log.Err("failed to determine desired command, neither plan, apply, import nor state rm")
 		return
 	}
 	if err != nil {
-		if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmd.CommandName()); statusErr != nil {
-			ctx.Log.Warn("unable to update commit status: %s", statusErr)
+		if !isStateCmd {
+			if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmd.CommandName()); statusErr != nil {
+				ctx.Log.Warn("unable to update commit status: %s", statusErr)
+			}
 		}
 		c.updatePull(ctx, cmd, CommandResult{Error: err})
 		return
 	}
 
-	result := c.runProjectCmds(projectCmds, cmd.Name)
+	if cmd.Name == models.ApplyCommand {
+		c.setProjectPlanStatuses(ctx, projectCmds)
+	}
+	defer c.trackCancellation(ctx, projectCmds)()
+	if cmd.Name == models.PlanCommand || cmd.Name == models.ApplyCommand {
+		c.startJobs(ctx, cmd.Name, projectCmds)
+		defer finishJobs(projectCmds)
+	}
+
+	startedAt := time.Now()
+	var result CommandResult
+	if cmd.Name == models.ApplyCommand {
+		result = c.runApplyCmds(ctx, projectCmds)
+	} else {
+		result = c.runProjectCmds(projectCmds, cmd.Name)
+	}
 	if cmd.Name == models.PlanCommand && c.automergeEnabled(ctx, projectCmds) && result.HasErrors() {
 		ctx.Log.Info("deleting plans because there were errors and automerge requires all plans succeed")
 		c.deletePlans(ctx)
@@ -255,7 +346,16 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		cmd,
 		result)
 
-	pullStatus, err := c.updateDB(ctx, pull, result.ProjectResults)
+	if isStateCmd {
+		return
+	}
+	c.recordHistory(ctx, cmd.Name, startedAt, result.ProjectResults)
+
+	dbResults := result.ProjectResults
+	if cmd.Name == models.PlanCommand {
+		dbResults = c.runPolicyChecks(ctx, PolicyCheckCommand{Verbose: cmd.Verbose}, projectCmds, result)
+	}
+	pullStatus, err := c.updateDB(ctx, pull, dbResults)
 	if err != nil {
 		c.Logger.Err("writing results: %s", err)
 		return
@@ -329,21 +429,413 @@ func (c *DefaultCommandRunner) automerge(ctx *CommandContext, pullStatus models.
 	}
 }
 
+// runPolicyChecks checks the projects that were planned successfully in
+// planResult against their policies and comments the results back to the pull
+// request. It returns planResult's project results with the policy check
+// results substituted in so that the database reflects them.
+func (c *DefaultCommandRunner) runPolicyChecks(ctx *CommandContext, command PolicyCheckCommand, planCmds []models.ProjectCommandContext, planResult CommandResult) []models.ProjectResult {
+	// If the plans were deleted there's nothing to check.
+	if planResult.PlansDeleted {
+		return planResult.ProjectResults
+	}
+
+	var policyCmds []models.ProjectCommandContext
+	var resultIdxs []int
+	for i, pCmd := range planCmds {
+		if len(pCmd.PolicySets) == 0 || planResult.ProjectResults[i].PlanSuccess == nil {
+			continue
+		}
+		pCmd.Steps = pCmd.PolicyCheckSteps
+		// The plan's job has finished so the policy check's output isn't
+		// streamed.
+		pCmd.Job = nil
+		policyCmds = append(policyCmds, pCmd)
+		resultIdxs = append(resultIdxs, i)
+	}
+	if len(policyCmds) == 0 {
+		return planResult.ProjectResults
+	}
+
+	if err := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.PendingCommitStatus, models.PolicyCheckCommand); err != nil {
+		ctx.Log.Warn("unable to update commit status: %s", err)
+	}
+
+	policyResult := c.runProjectCmds(policyCmds, models.PolicyCheckCommand)
+	c.updatePull(ctx, command, policyResult)
+
+	results := make([]models.ProjectResult, len(planResult.ProjectResults))
+	copy(results, planResult.ProjectResults)
+	numPassed := 0
+	for i, r := range policyResult.ProjectResults {
+		results[resultIdxs[i]] = r
+		if r.PlanStatus() == models.PassedPolicyCheckStatus {
+			numPassed++
+		}
+	}
+
+	status := models.SuccessCommitStatus
+	if numPassed != len(policyCmds) {
+		status = models.FailedCommitStatus
+	}
+	if err := c.CommitStatusUpdater.UpdateCombinedCount(ctx.BaseRepo, ctx.Pull, status, models.PolicyCheckCommand, numPassed, len(policyCmds)); err != nil {
+		ctx.Log.Warn("unable to update commit status: %s", err)
+	}
+	return results
+}
+
+// setProjectPlanStatuses sets the ProjectPlanStatus of each of cmds to the
+// status stored in the database so that apply requirements that depend on it
+// can be checked. If there's no status for the pull's current commit, the
+// statuses are left unset.
+func (c *DefaultCommandRunner) setProjectPlanStatuses(ctx *CommandContext, cmds []models.ProjectCommandContext) {
+	pullStatus, err := c.DB.GetPullStatus(ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get pull status: %s", err)
+		return
+	}
+	if pullStatus == nil || pullStatus.Pull.HeadCommit != ctx.Pull.HeadCommit {
+		return
+	}
+	for i := range cmds {
+		for _, p := range pullStatus.Projects {
+			if p.Workspace == cmds[i].Workspace && p.RepoRelDir == cmds[i].RepoRelDir && p.ProjectName == cmds[i].ProjectName {
+				cmds[i].ProjectPlanStatus = p.Status
+			}
+		}
+	}
+}
+
+// RunProjectCmds runs cmds the same way they're run for a comment: in
+// parallel if it's enabled and, for apply, in the order set by depends_on.
+// It's used to run commands that don't come from a pull request, ex. from the
+// API.
+func (c *DefaultCommandRunner) RunProjectCmds(ctx *CommandContext, cmdName models.CommandName, cmds []models.ProjectCommandContext) CommandResult {
+	if cmdName == models.ApplyCommand {
+		return c.runApplyCmds(ctx, cmds)
+	}
+	return c.runProjectCmds(cmds, cmdName)
+}
+
 func (c *DefaultCommandRunner) runProjectCmds(cmds []models.ProjectCommandContext, cmdName models.CommandName) CommandResult {
+	if c.parallelEnabled(cmds, cmdName) {
+		return c.runProjectCmdsParallel(cmds, cmdName)
+	}
 	var results []models.ProjectResult
 	for _, pCmd := range cmds {
-		var res models.ProjectResult
-		switch cmdName {
-		case models.PlanCommand:
-			res = c.ProjectCommandRunner.Plan(pCmd)
-		case models.ApplyCommand:
-			res = c.ProjectCommandRunner.Apply(pCmd)
+		results = append(results, c.runProjectCmd(pCmd, cmdName))
+	}
+	return CommandResult{ProjectResults: results}
+}
+
+// runApplyCmds applies cmds in the order set by their depends_on config.
+// Projects that don't depend on each other are applied in the same wave so
+// they can still be applied in parallel. If a project isn't applied
+// successfully, the projects that depend on it are skipped. Projects that
+// depend on a project with an unapplied plan that isn't part of cmds are
+// skipped too so that they're never applied before it.
+func (c *DefaultCommandRunner) runApplyCmds(ctx *CommandContext, cmds []models.ProjectCommandContext) CommandResult {
+	unapplied := c.unappliedProjects(ctx)
+	for _, cmd := range cmds {
+		delete(unapplied, cmd.ProjectName)
+	}
+	// failed holds the names of the projects that failed or were skipped.
+	failed := make(map[string]bool)
+	var results []models.ProjectResult
+	for _, wave := range applyWaves(cmds) {
+		var toRun []models.ProjectCommandContext
+		for _, cmd := range wave {
+			var depErr DependencyFailedErr
+			if dep := failedDependency(cmd, unapplied); dep != "" {
+				ctx.Log.Info("skipping apply of project %q because project %q has a plan that isn't being applied", cmd.ProjectName, dep)
+				depErr = DependencyFailedErr{Dependency: dep, Unapplied: true}
+			} else if dep := failedDependency(cmd, failed); dep != "" && !cmd.IsCancelled() {
+				// If the apply was cancelled, the projects that depend on a
+				// cancelled project are reported as cancelled too.
+				ctx.Log.Info("skipping apply of project %q because project %q wasn't applied successfully", cmd.ProjectName, dep)
+				depErr = DependencyFailedErr{Dependency: dep}
+			}
+			if depErr.Dependency != "" {
+				failed[cmd.ProjectName] = true
+				cmd.Job.Write(fmt.Sprintf("Skipped: %s", depErr))
+				cmd.Job.Finish(false)
+				results = append(results, models.ProjectResult{
+					Command:     models.ApplyCommand,
+					RepoRelDir:  cmd.RepoRelDir,
+					Workspace:   cmd.Workspace,
+					ProjectName: cmd.ProjectName,
+					Error:       depErr,
+				})
+				continue
+			}
+			toRun = append(toRun, cmd)
+		}
+		res := c.runProjectCmds(toRun, models.ApplyCommand)
+		for i, r := range res.ProjectResults {
+			if !r.IsSuccessful() {
+				failed[toRun[i].ProjectName] = true
+			}
 		}
-		results = append(results, res)
+		results = append(results, res.ProjectResults...)
 	}
 	return CommandResult{ProjectResults: results}
 }
 
+// unappliedProjects returns the names of the pull request's projects that have
+// a plan that hasn't been applied yet.
+func (c *DefaultCommandRunner) unappliedProjects(ctx *CommandContext) map[string]bool {
+	unapplied := make(map[string]bool)
+	pullStatus, err := c.DB.GetPullStatus(ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get pull status: %s", err)
+		return unapplied
+	}
+	if pullStatus == nil {
+		return unapplied
+	}
+	for _, p := range pullStatus.Projects {
+		switch p.Status {
+		case models.PlannedPlanStatus, models.ErroredApplyStatus, models.PassedPolicyCheckStatus, models.ErroredPolicyCheckStatus:
+			if p.ProjectName != "" {
+				unapplied[p.ProjectName] = true
+			}
+		}
+	}
+	return unapplied
+}
+
+// applyWaves splits cmds into waves where each project is in a later wave than
+// the projects it depends on. Dependencies that aren't being applied, ex.
+// because they've already been applied, are ignored. Within each wave, cmds
+// keep their original order.
+func applyWaves(cmds []models.ProjectCommandContext) [][]models.ProjectCommandContext {
+	beingApplied := make(map[string]bool)
+	for _, cmd := range cmds {
+		if cmd.ProjectName != "" {
+			beingApplied[cmd.ProjectName] = true
+		}
+	}
+
+	var waves [][]models.ProjectCommandContext
+	done := make(map[string]bool)
+	remaining := cmds
+	for len(remaining) > 0 {
+		var wave, next []models.ProjectCommandContext
+		for _, cmd := range remaining {
+			ready := true
+			for _, d := range cmd.DependsOn {
+				if beingApplied[d] && !done[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, cmd)
+			} else {
+				next = append(next, cmd)
+			}
+		}
+		if len(wave) == 0 {
+			// This can only happen if there's a cycle which we validate
+			// against when parsing the config, but rather than loop forever
+			// we apply the rest together.
+			wave, next = next, nil
+		}
+		for _, cmd := range wave {
+			done[cmd.ProjectName] = true
+		}
+		waves = append(waves, wave)
+		remaining = next
+	}
+	return waves
+}
+
+// failedDependency returns the name of the first project that cmd depends on
+// that's in projects or an empty string if there isn't one.
+func failedDependency(cmd models.ProjectCommandContext, projects map[string]bool) string {
+	for _, d := range cmd.DependsOn {
+		if projects[d] {
+			return d
+		}
+	}
+	return ""
+}
+
+// runProjectCmdsParallel runs cmds concurrently using at most
+// ParallelPoolSize goroutines. The results are returned in the same order as
+// cmds so that the comment we post back is deterministic.
+// Commands for projects in the same dir and workspace share a working dir
+// lock so they're run one after another in the same goroutine.
+func (c *DefaultCommandRunner) runProjectCmdsParallel(cmds []models.ProjectCommandContext, cmdName models.CommandName) CommandResult {
+	results := make([]models.ProjectResult, len(cmds))
+	sem := make(chan struct{}, c.ParallelPoolSize)
+	var wg sync.WaitGroup
+	for _, group := range groupByDirAndWorkspace(cmds) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(group []int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			for _, i := range group {
+				results[i] = c.runProjectCmd(cmds[i], cmdName)
+			}
+		}(group)
+	}
+	wg.Wait()
+	return CommandResult{ProjectResults: results}
+}
+
+// groupByDirAndWorkspace returns the indices of cmds grouped by their dir and
+// workspace. Groups are ordered by their first command and the indices in
+// each group stay in order.
+func groupByDirAndWorkspace(cmds []models.ProjectCommandContext) [][]int {
+	var groups [][]int
+	groupIdx := make(map[string]int)
+	for i, cmd := range cmds {
+		key := cmd.Workspace + "/" + cmd.RepoRelDir
+		if g, ok := groupIdx[key]; ok {
+			groups[g] = append(groups[g], i)
+			continue
+		}
+		groupIdx[key] = len(groups)
+		groups = append(groups, []int{i})
+	}
+	return groups
+}
+
+func (c *DefaultCommandRunner) runProjectCmd(cmd models.ProjectCommandContext, cmdName models.CommandName) models.ProjectResult {
+	if cmd.IsCancelled() {
+		cmd.Log.Info("not running %s because it was cancelled", cmdName.String())
+		err := errors.New("cancelled before this project started")
+		cmd.Job.Write(fmt.Sprintf("Cancelled: %s", err))
+		cmd.Job.Finish(false)
+		return models.ProjectResult{
+			Command:     cmdName,
+			RepoRelDir:  cmd.RepoRelDir,
+			Workspace:   cmd.Workspace,
+			ProjectName: cmd.ProjectName,
+			Error:       err,
+			Cancelled:   true,
+		}
+	}
+	checkRuns := c.GithubChecks && cmd.BaseRepo.VCSHost.Type == models.Github
+	if cmd.Job == nil && !checkRuns {
+		return c.doProjectCmd(cmd, cmdName)
+	}
+
+	// While the command runs, the project's commit status links to its job
+	// so its output can be followed.
+	url := ""
+	if cmd.Job != nil {
+		url = cmd.Job.URL
+	}
+	if err := c.CommitStatusUpdater.UpdateProject(cmd, cmdName, models.PendingCommitStatus, url); err != nil {
+		cmd.Log.Warn("unable to update commit status: %s", err)
+	}
+	res := c.doProjectCmd(cmd, cmdName)
+	if cmd.Job != nil {
+		if res.Failure != "" {
+			cmd.Job.Write(res.Failure)
+		}
+		cmd.Job.Finish(res.IsSuccessful())
+	}
+	if err := c.CommitStatusUpdater.UpdateProjectResult(cmd, cmdName, res); err != nil {
+		cmd.Log.Warn("unable to update commit status: %s", err)
+	}
+	return res
+}
+
+func (c *DefaultCommandRunner) doProjectCmd(cmd models.ProjectCommandContext, cmdName models.CommandName) models.ProjectResult {
+	switch cmdName {
+	case models.PlanCommand:
+		return c.ProjectCommandRunner.Plan(cmd)
+	case models.ApplyCommand:
+		return c.ProjectCommandRunner.Apply(cmd)
+	case models.PolicyCheckCommand:
+		return c.ProjectCommandRunner.PolicyCheck(cmd)
+	case models.ImportCommand:
+		return c.ProjectCommandRunner.Import(cmd)
+	case models.StateRmCommand:
+		return c.ProjectCommandRunner.StateRm(cmd)
+	}
+	return models.ProjectResult{}
+}
+
+// startJobs starts a job for each of cmds so that their output is streamed
+// to the web UI and comments on the pull request with links to the jobs'
+// pages.
+func (c *DefaultCommandRunner) startJobs(ctx *CommandContext, cmdName models.CommandName, cmds []models.ProjectCommandContext) {
+	if c.Jobs == nil || len(cmds) == 0 {
+		return
+	}
+	comment := fmt.Sprintf("Running %s. Follow the output of each project as it runs:\n", cmdName.String())
+	for i := range cmds {
+		cmds[i].Job = c.Jobs.Start(jobs.Info{
+			Command:      cmdName.String(),
+			RepoFullName: ctx.BaseRepo.FullName,
+			PullNum:      ctx.Pull.Num,
+			RepoRelDir:   cmds[i].RepoRelDir,
+			Workspace:    cmds[i].Workspace,
+			ProjectName:  cmds[i].ProjectName,
+		})
+		comment += "\n- "
+		if cmds[i].ProjectName != "" {
+			comment += fmt.Sprintf("project: `%s` ", cmds[i].ProjectName)
+		}
+		comment += fmt.Sprintf("dir: `%s` workspace: `%s`: %s", cmds[i].RepoRelDir, cmds[i].Workspace, cmds[i].Job.URL)
+	}
+	if c.Jobs.URLGenerator == nil {
+		return
+	}
+	if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, comment); err != nil {
+		ctx.Log.Warn("unable to comment with links to jobs: %s", err)
+	}
+}
+
+// finishJobs marks the jobs of cmds that haven't finished, ex. because they
+// were never run, as failed so that they don't look like they're running
+// forever.
+func finishJobs(cmds []models.ProjectCommandContext) {
+	for _, cmd := range cmds {
+		cmd.Job.Finish(false)
+	}
+}
+
+// parallelEnabled returns true if cmds should be run in parallel. Like
+// automerge, this is configured per repo so we check the first project.
+func (c *DefaultCommandRunner) parallelEnabled(cmds []models.ProjectCommandContext, cmdName models.CommandName) bool {
+	if c.ParallelPoolSize <= 1 || len(cmds) <= 1 {
+		return false
+	}
+	switch cmdName {
+	case models.PlanCommand, models.PolicyCheckCommand:
+		return cmds[0].ParallelPlanEnabled
+	case models.ApplyCommand:
+		return cmds[0].ParallelApplyEnabled
+	}
+	return false
+}
+
+// GetPull gets pull request pullNum of baseRepo from the VCS host. It also
+// returns the repo the pull request's head branch is in, which is different
+// for forks. Bitbucket isn't supported since we don't have a client for
+// getting pull requests from it.
+func (c *DefaultCommandRunner) GetPull(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
+	switch baseRepo.VCSHost.Type {
+	case models.Github:
+		return c.getGithubData(baseRepo, pullNum)
+	case models.Gitlab:
+		pull, err := c.getGitlabData(baseRepo, pullNum)
+		return pull, baseRepo, err
+	case models.AzureDevops:
+		return c.getAzureDevopsData(baseRepo, pullNum)
+	case models.Gitea:
+		return c.getGiteaData(baseRepo, pullNum)
+	}
+	return models.PullRequest{}, models.Repo{}, fmt.Errorf("getting pull requests from %s is not supported", baseRepo.VCSHost.Type.String())
+}
+
 func (c *DefaultCommandRunner) getGithubData(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
 	if c.GithubPullGetter == nil {
 		return models.PullRequest{}, models.Repo{}, errors.New("Atlantis not configured to support GitHub")
@@ -386,9 +878,37 @@ func (c *DefaultCommandRunner) getAzureDevopsData(baseRepo models.Repo, pullNum
 	return pull, headRepo, nil
 }
 
-func (c *DefaultCommandRunner) buildLogger(repoFullName string, pullNum int) *logging.SimpleLogger {
+func (c *DefaultCommandRunner) getGiteaData(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
+	if c.GiteaPullGetter == nil {
+		return models.PullRequest{}, models.Repo{}, errors.New("Atlantis not configured to support Gitea")
+	}
+	giteaPull, err := c.GiteaPullGetter.GetPullRequest(baseRepo, pullNum)
+	if err != nil {
+		return models.PullRequest{}, models.Repo{}, errors.Wrap(err, "making pull request API call to Gitea")
+	}
+	pull, _, headRepo, err := c.EventParser.ParseGiteaPull(giteaPull)
+	if err != nil {
+		return pull, headRepo, errors.Wrap(err, "extracting required fields from comment data")
+	}
+	return pull, headRepo, nil
+}
+
+// buildLogger returns a logger for running cmdName on a pull request. The
+// repo, pull request, command and request ID are included as fields when
+// logging as JSON so that all entries for one webhook can be correlated.
+func (c *DefaultCommandRunner) buildLogger(repoFullName string, pullNum int, cmdName models.CommandName, requestID string) *logging.SimpleLogger {
 	src := fmt.Sprintf("%s#%d", repoFullName, pullNum)
-	return c.Logger.NewLogger(src, true, c.Logger.GetLevel())
+	fields := map[string]interface{}{
+		"repo":    repoFullName,
+		"pull":    pullNum,
+		"command": cmdName.String(),
+	}
+	if requestID != "" {
+		fields["request_id"] = requestID
+	}
+	log := c.Logger.NewLogger(src, true, c.Logger.GetLevel())
+	log.SetFields(fields)
+	return log
 }
 
 func (c *DefaultCommandRunner) validateCtxAndComment(ctx *CommandContext) bool {
@@ -427,6 +947,179 @@ func (c *DefaultCommandRunner) updatePull(ctx *CommandContext, command PullComma
 	}
 }
 
+// unlock releases the locks held by the pull request for the projects that
+// match cmd's flags, deletes their plans and their statuses so that they have
+// to be planned again, and then comments with what was unlocked.
+func (c *DefaultCommandRunner) unlock(ctx *CommandContext, cmd *CommentCommand) {
+	comment, err := c.unlockProjects(ctx, cmd)
+	if err != nil {
+		ctx.Log.Err("unlocking: %s", err)
+		comment = fmt.Sprintf("**Unlock Error**\n```\n%s\n```", err)
+	}
+	if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, comment); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
+
+func (c *DefaultCommandRunner) unlockProjects(ctx *CommandContext, cmd *CommentCommand) (string, error) {
+	unlockFn, err := c.WorkingDirLocker.TryLockPull(ctx.BaseRepo.FullName, ctx.Pull.Num)
+	if err != nil {
+		return "", err
+	}
+	defer unlockFn()
+
+	// Locks don't store the project name so we look up which dirs and
+	// workspaces the project is in from the pull's status.
+	var namedProjects []models.ProjectStatus
+	if cmd.ProjectName != "" {
+		pullStatus, err := c.DB.GetPullStatus(ctx.Pull)
+		if err != nil {
+			return "", errors.Wrap(err, "getting pull status")
+		}
+		if pullStatus != nil {
+			for _, p := range pullStatus.Projects {
+				if p.ProjectName == cmd.ProjectName {
+					namedProjects = append(namedProjects, p)
+				}
+			}
+		}
+	}
+	matches := func(repoRelDir string, workspace string, projectName string) bool {
+		if cmd.ProjectName != "" {
+			if projectName == cmd.ProjectName {
+				return true
+			}
+			for _, p := range namedProjects {
+				if p.RepoRelDir == repoRelDir && p.Workspace == workspace {
+					return true
+				}
+			}
+			return false
+		}
+		return (cmd.RepoRelDir == "" || cmd.RepoRelDir == repoRelDir) &&
+			(cmd.Workspace == "" || cmd.Workspace == workspace)
+	}
+
+	var unlocked []models.ProjectLock
+	if cmd.IsForSpecificProject() {
+		locks, err := c.Locker.List()
+		if err != nil {
+			return "", errors.Wrap(err, "listing locks")
+		}
+		for key, lock := range locks {
+			if lock.Project.RepoFullName != ctx.BaseRepo.FullName || lock.Pull.Num != ctx.Pull.Num || !matches(lock.Project.Path, lock.Workspace, "") {
+				continue
+			}
+			if _, err := c.Locker.Unlock(key); err != nil {
+				return "", errors.Wrapf(err, "unlocking %s", key)
+			}
+			unlocked = append(unlocked, lock)
+		}
+	} else {
+		unlocked, err = c.Locker.UnlockByPull(ctx.BaseRepo.FullName, ctx.Pull.Num)
+		if err != nil {
+			return "", errors.Wrap(err, "unlocking")
+		}
+	}
+
+	var deletedPlans []PendingPlan
+	pullDir, err := c.WorkingDir.GetPullDir(ctx.BaseRepo, ctx.Pull)
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrap(err, "getting pull dir")
+	}
+	// If the pull dir doesn't exist then there aren't any plans to delete.
+	if err == nil {
+		plans, err := c.PendingPlanFinder.Find(pullDir)
+		if err != nil {
+			return "", errors.Wrap(err, "finding plans")
+		}
+		for _, plan := range plans {
+			if matches(plan.RepoRelDir, plan.Workspace, plan.ProjectName) {
+				deletedPlans = append(deletedPlans, plan)
+			}
+		}
+		if cmd.IsForSpecificProject() {
+			for _, plan := range deletedPlans {
+				if err := c.PendingPlanFinder.DeletePlan(plan); err != nil {
+					return "", errors.Wrap(err, "deleting plan")
+				}
+			}
+		} else if err := c.PendingPlanFinder.DeletePlans(pullDir); err != nil {
+			return "", errors.Wrap(err, "deleting plans")
+		}
+	}
+
+	// Build the unique set of dirs and workspaces we unlocked.
+	type dirWorkspace struct {
+		RepoRelDir string
+		Workspace  string
+	}
+	seen := make(map[dirWorkspace]bool)
+	for _, l := range unlocked {
+		seen[dirWorkspace{l.Project.Path, l.Workspace}] = true
+	}
+	for _, p := range deletedPlans {
+		seen[dirWorkspace{p.RepoRelDir, p.Workspace}] = true
+	}
+	for _, p := range namedProjects {
+		seen[dirWorkspace{p.RepoRelDir, p.Workspace}] = true
+	}
+	var projects []dirWorkspace
+	for p := range seen {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].RepoRelDir != projects[j].RepoRelDir {
+			return projects[i].RepoRelDir < projects[j].RepoRelDir
+		}
+		return projects[i].Workspace < projects[j].Workspace
+	})
+
+	if cmd.IsForSpecificProject() {
+		for _, p := range projects {
+			if err := c.DB.DeleteProjectStatus(ctx.Pull, p.Workspace, p.RepoRelDir); err != nil {
+				return "", errors.Wrap(err, "deleting project status")
+			}
+		}
+	} else if err := c.DB.DeletePullStatus(ctx.Pull); err != nil {
+		return "", errors.Wrap(err, "deleting pull status")
+	}
+
+	if len(projects) == 0 {
+		return "No locks or plans found to delete.", nil
+	}
+	comment := "Locks and plans deleted for:\n"
+	for _, p := range projects {
+		comment += fmt.Sprintf("\n- dir: `%s` workspace: `%s`", p.RepoRelDir, p.Workspace)
+	}
+	comment += "\n\nTo `apply` these projects you must run `plan` again."
+	return comment, nil
+}
+
+// cancel cancels the commands running for the pull request and comments with
+// how many there were. Their results are commented when they've stopped.
+func (c *DefaultCommandRunner) cancel(ctx *CommandContext) {
+	cancelled := c.Canceller.Cancel(ctx.BaseRepo.FullName, ctx.Pull.Num)
+	ctx.Log.Info("%s cancelled %d running command(s)", ctx.User.Username, cancelled)
+	comment := "No plans or applies are running for this pull request."
+	if cancelled > 0 {
+		comment = fmt.Sprintf("Cancelling %d running command(s). Terraform has been interrupted so that it can stop cleanly and release its state lock. Projects that haven't started won't be run.", cancelled)
+	}
+	if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, comment); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
+
+// trackCancellation makes cmds cancellable with atlantis cancel. The returned
+// function must be called once they've finished.
+func (c *DefaultCommandRunner) trackCancellation(ctx *CommandContext, cmds []models.ProjectCommandContext) func() {
+	cancelCtx, done := c.Canceller.Track(ctx.BaseRepo.FullName, ctx.Pull.Num)
+	for i := range cmds {
+		cmds[i].Context = cancelCtx
+	}
+	return done
+}
+
 // logPanics logs and creates a comment on the pull request for panics.
 func (c *DefaultCommandRunner) logPanics(baseRepo models.Repo, pullNum int, logger logging.SimpleLogging) {
 	if err := recover(); err != nil {
@@ -463,12 +1156,66 @@ func (c *DefaultCommandRunner) updateDB(ctx *CommandContext, pull models.PullReq
 			ctx.Log.Debug("ignoring error result from project at dir %q workspace %q because it is dir not exist error", r.RepoRelDir, r.Workspace)
 			continue
 		}
+		// Skipped projects weren't applied so their plans are still pending.
+		if _, ok := r.Error.(DependencyFailedErr); ok {
+			continue
+		}
 		filtered = append(filtered, r)
 	}
 	ctx.Log.Debug("updating DB with pull results")
 	return c.DB.UpdatePullWithResults(pull, filtered)
 }
 
+// recordHistory saves the output of running cmdName on each project so it
+// can be viewed after the comment has been posted. It also deletes any
+// history older than HistoryRetention.
+func (c *DefaultCommandRunner) recordHistory(ctx *CommandContext, cmdName models.CommandName, startedAt time.Time, results []models.ProjectResult) {
+	finishedAt := time.Now()
+	var runs []models.ProjectRun
+	for _, r := range results {
+		if _, ok := r.Error.(DependencyFailedErr); ok {
+			continue
+		}
+		runs = append(runs, models.ProjectRun{
+			Command:     cmdName,
+			RepoRelDir:  r.RepoRelDir,
+			Workspace:   r.Workspace,
+			ProjectName: r.ProjectName,
+			Status:      r.PlanStatus(),
+			Output:      projectRunOutput(r),
+			User:        ctx.User.Username,
+			HeadCommit:  ctx.Pull.HeadCommit,
+			StartedAt:   startedAt,
+			FinishedAt:  finishedAt,
+		})
+	}
+	if err := c.DB.AddProjectRuns(ctx.Pull, runs); err != nil {
+		ctx.Log.Err("unable to save history: %s", err)
+	}
+	if c.HistoryRetention > 0 {
+		deleted, err := c.DB.DeleteProjectRunsBefore(finishedAt.Add(-c.HistoryRetention))
+		if err != nil {
+			ctx.Log.Warn("unable to delete old history: %s", err)
+		} else if deleted > 0 {
+			ctx.Log.Debug("deleted %d runs older than %s from history", deleted, c.HistoryRetention)
+		}
+	}
+}
+
+// projectRunOutput returns the output to save in the history for r.
+func projectRunOutput(r models.ProjectResult) string {
+	switch {
+	case r.Error != nil:
+		return r.Error.Error()
+	case r.Failure != "":
+		return r.Failure
+	case r.PlanSuccess != nil:
+		return r.PlanSuccess.TerraformOutput
+	default:
+		return r.ApplySuccess
+	}
+}
+
 // automergeEnabled returns true if automerging is enabled in this context.
 func (c *DefaultCommandRunner) automergeEnabled(ctx *CommandContext, projectCmds []models.ProjectCommandContext) bool {
 	// If the global automerge is set, we always automerge.
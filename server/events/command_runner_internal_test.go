@@ -144,3 +144,6 @@ func (m *MockCSU) UpdateCombined(repo models.Repo, pull models.PullRequest, stat
 func (m *MockCSU) UpdateProject(ctx models.ProjectCommandContext, cmdName models.CommandName, status models.CommitStatus, url string) error {
 	return nil
 }
+func (m *MockCSU) UpdateProjectResult(ctx models.ProjectCommandContext, cmdName models.CommandName, res models.ProjectResult) error {
+	return nil
+}
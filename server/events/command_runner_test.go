@@ -14,11 +14,21 @@
 package events_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/logging"
 
 	"github.com/google/go-github/v28/github"
@@ -28,7 +38,11 @@ import (
 	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/fixtures"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	vcsmatchers "github.com/runatlantis/atlantis/server/events/vcs/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	"github.com/runatlantis/atlantis/server/jobs"
 	logmocks "github.com/runatlantis/atlantis/server/logging/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -38,6 +52,7 @@ var projectCommandRunner *mocks.MockProjectCommandRunner
 var eventParsing *mocks.MockEventParsing
 var azuredevopsGetter *mocks.MockAzureDevopsPullGetter
 var githubGetter *mocks.MockGithubPullGetter
+var giteaGetter *mocks.MockGiteaPullGetter
 var gitlabGetter *mocks.MockGitlabMergeRequestGetter
 var ch events.DefaultCommandRunner
 var pullLogger *logging.SimpleLogger
@@ -52,6 +67,7 @@ func setup(t *testing.T) *vcsmocks.MockClient {
 	githubGetter = mocks.NewMockGithubPullGetter()
 	gitlabGetter = mocks.NewMockGitlabMergeRequestGetter()
 	azuredevopsGetter = mocks.NewMockAzureDevopsPullGetter()
+	giteaGetter = mocks.NewMockGiteaPullGetter()
 	logger := logmocks.NewMockSimpleLogging()
 	pullLogger = logging.NewSimpleLogger("runatlantis/atlantis#1", true, logging.Info)
 	projectCommandRunner = mocks.NewMockProjectCommandRunner()
@@ -62,12 +78,13 @@ func setup(t *testing.T) *vcsmocks.MockClient {
 		ThenReturn(pullLogger)
 	ch = events.DefaultCommandRunner{
 		VCSClient:                vcsClient,
-		CommitStatusUpdater:      &events.DefaultCommitStatusUpdater{vcsClient, "atlantis"},
+		CommitStatusUpdater:      &events.DefaultCommitStatusUpdater{Client: vcsClient, StatusName: "atlantis"},
 		EventParser:              eventParsing,
 		MarkdownRenderer:         &events.MarkdownRenderer{},
 		GithubPullGetter:         githubGetter,
 		GitlabMergeRequestGetter: gitlabGetter,
 		AzureDevopsPullGetter:    azuredevopsGetter,
+		GiteaPullGetter:          giteaGetter,
 		Logger:                   logger,
 		AllowForkPRs:             false,
 		AllowForkPRsFlag:         "allow-fork-prs-flag",
@@ -84,7 +101,7 @@ func TestRunCommentCommand_LogPanics(t *testing.T) {
 	t.Log("if there is a panic it is commented back on the pull request")
 	vcsClient := setup(t)
 	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenPanic("OMG PANIC!!!")
-	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, 1, &events.CommentCommand{Name: models.PlanCommand})
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, 1, &events.CommentCommand{Name: models.PlanCommand}, "")
 	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
 	Assert(t, strings.Contains(comment, "Error: goroutine panic"), fmt.Sprintf("comment should be about a goroutine panic but was %q", comment))
 }
@@ -93,7 +110,7 @@ func TestRunCommentCommand_NoGithubPullGetter(t *testing.T) {
 	t.Log("if DefaultCommandRunner was constructed with a nil GithubPullGetter an error should be logged")
 	setup(t)
 	ch.GithubPullGetter = nil
-	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, 1, nil)
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, 1, &events.CommentCommand{Name: models.PlanCommand}, "")
 	Equals(t, "[EROR] Atlantis not configured to support GitHub\n", pullLogger.History.String())
 }
 
@@ -101,15 +118,33 @@ func TestRunCommentCommand_NoGitlabMergeGetter(t *testing.T) {
 	t.Log("if DefaultCommandRunner was constructed with a nil GitlabMergeRequestGetter an error should be logged")
 	setup(t)
 	ch.GitlabMergeRequestGetter = nil
-	ch.RunCommentCommand(fixtures.GitlabRepo, &fixtures.GitlabRepo, nil, fixtures.User, 1, nil)
+	ch.RunCommentCommand(fixtures.GitlabRepo, &fixtures.GitlabRepo, nil, fixtures.User, 1, &events.CommentCommand{Name: models.PlanCommand}, "")
 	Equals(t, "[EROR] Atlantis not configured to support GitLab\n", pullLogger.History.String())
 }
 
+func TestRunCommentCommand_LogsCorrelationFields(t *testing.T) {
+	t.Log("when logging as JSON the pull request and request ID should be included")
+	setup(t)
+	buf := &bytes.Buffer{}
+	pullLogger.Logger = log.New(buf, "", 0)
+	pullLogger.JSON = true
+	ch.GithubPullGetter = nil
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, 1, &events.CommentCommand{Name: models.ApplyCommand}, "X-Github-Delivery=123")
+
+	var entry map[string]interface{}
+	Ok(t, json.Unmarshal(buf.Bytes(), &entry))
+	Equals(t, "Atlantis not configured to support GitHub", entry["msg"])
+	Equals(t, fixtures.GithubRepo.FullName, entry["repo"])
+	Equals(t, float64(1), entry["pull"])
+	Equals(t, "apply", entry["command"])
+	Equals(t, "X-Github-Delivery=123", entry["request_id"])
+}
+
 func TestRunCommentCommand_GithubPullErr(t *testing.T) {
 	t.Log("if getting the github pull request fails an error should be logged")
 	vcsClient := setup(t)
 	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(nil, errors.New("err"))
-	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, nil)
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, fixtures.Pull.Num, "`Error: making pull request API call to GitHub: err`")
 }
 
@@ -117,10 +152,19 @@ func TestRunCommentCommand_GitlabMergeRequestErr(t *testing.T) {
 	t.Log("if getting the gitlab merge request fails an error should be logged")
 	vcsClient := setup(t)
 	When(gitlabGetter.GetMergeRequest(fixtures.GitlabRepo.FullName, fixtures.Pull.Num)).ThenReturn(nil, errors.New("err"))
-	ch.RunCommentCommand(fixtures.GitlabRepo, &fixtures.GitlabRepo, nil, fixtures.User, fixtures.Pull.Num, nil)
+	ch.RunCommentCommand(fixtures.GitlabRepo, &fixtures.GitlabRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GitlabRepo, fixtures.Pull.Num, "`Error: making merge request API call to GitLab: err`")
 }
 
+func TestRunCommentCommand_GiteaPullErr(t *testing.T) {
+	t.Log("if getting the gitea pull request fails an error should be logged")
+	vcsClient := setup(t)
+	giteaRepo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Gitea}}
+	When(giteaGetter.GetPullRequest(giteaRepo, fixtures.Pull.Num)).ThenReturn(nil, errors.New("err"))
+	ch.RunCommentCommand(giteaRepo, &giteaRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
+	vcsClient.VerifyWasCalledOnce().CreateComment(giteaRepo, fixtures.Pull.Num, "`Error: making pull request API call to Gitea: err`")
+}
+
 func TestRunCommentCommand_GithubPullParseErr(t *testing.T) {
 	t.Log("if parsing the returned github pull request fails an error should be logged")
 	vcsClient := setup(t)
@@ -128,7 +172,7 @@ func TestRunCommentCommand_GithubPullParseErr(t *testing.T) {
 	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(&pull, nil)
 	When(eventParsing.ParseGithubPull(&pull)).ThenReturn(fixtures.Pull, fixtures.GithubRepo, fixtures.GitlabRepo, errors.New("err"))
 
-	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, nil)
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, fixtures.Pull.Num, "`Error: extracting required fields from comment data: err`")
 }
 
@@ -148,7 +192,7 @@ func TestRunCommentCommand_ForkPRDisabled(t *testing.T) {
 	headRepo.Owner = "forkrepo"
 	When(eventParsing.ParseGithubPull(&pull)).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
 
-	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, nil)
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
 	commentMessage := fmt.Sprintf("Atlantis commands can't be run on fork pull requests. To enable, set --%s  or, to disable this message, set --%s", ch.AllowForkPRsFlag, ch.SilenceForkPRErrorsFlag)
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, modelPull.Num, commentMessage)
 }
@@ -167,7 +211,7 @@ func TestRunCommentCommand_ForkPRDisabled_SilenceEnabled(t *testing.T) {
 	headRepo.Owner = "forkrepo"
 	When(eventParsing.ParseGithubPull(&pull)).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
 
-	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, nil)
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
 	vcsClient.VerifyWasCalled(Never()).CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString())
 }
 
@@ -177,7 +221,7 @@ func TestRunCommentCommand_DisableApplyAllDisabled(t *testing.T) {
 	vcsClient := setup(t)
 	ch.DisableApplyAll = true
 	modelPull := models.PullRequest{State: models.OpenPullState}
-	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, modelPull.Num, &events.CommentCommand{Name: models.ApplyCommand})
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, modelPull.Num, &events.CommentCommand{Name: models.ApplyCommand}, "")
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, modelPull.Num, "**Error:** Running `atlantis apply` without flags is disabled. You must specify which project to apply via the `-d <dir>`, `-w <workspace>` or `-p <project name>` flags.")
 }
 
@@ -192,7 +236,7 @@ func TestRunCommentCommand_ClosedPull(t *testing.T) {
 	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
 	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, modelPull.BaseRepo, fixtures.GithubRepo, nil)
 
-	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, nil)
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand}, "")
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, modelPull.Num, "Atlantis commands can't be run on closed pull requests")
 }
 
@@ -231,6 +275,638 @@ func TestRunAutoplanCommand_DeletePlans(t *testing.T) {
 
 	When(workingDir.GetPullDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).
 		ThenReturn(tmp, nil)
-	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User)
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
 	pendingPlanFinder.VerifyWasCalledOnce().DeletePlans(tmp)
 }
+
+// Test that when parallel plans are enabled, projects are planned
+// concurrently up to the pool size and the results are still commented in the
+// same order as the projects.
+func TestRunAutoplanCommand_ParallelPlan(t *testing.T) {
+	vcsClient := setup(t)
+	ch.ParallelPoolSize = 2
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "dir1", Workspace: "default", ParallelPlanEnabled: true},
+			{RepoRelDir: "dir2", Workspace: "default", ParallelPlanEnabled: true},
+			{RepoRelDir: "dir3", Workspace: "default", ParallelPlanEnabled: true},
+		}, nil)
+	var running, maxRunning int32
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		curr := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			prev := atomic.LoadInt32(&maxRunning)
+			if curr <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, curr) {
+				break
+			}
+		}
+		// Wait until the pool is full so we know projects are running at the
+		// same time.
+		for start := time.Now(); atomic.LoadInt32(&maxRunning) < 2 && time.Since(start) < time.Second; {
+			time.Sleep(time.Millisecond)
+		}
+		// Make the first project finish last.
+		if ctx.RepoRelDir == "dir1" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return ReturnValues{
+			models.ProjectResult{
+				RepoRelDir:  ctx.RepoRelDir,
+				Workspace:   ctx.Workspace,
+				PlanSuccess: &models.PlanSuccess{TerraformOutput: "output-" + ctx.RepoRelDir},
+			},
+		}
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+	Equals(t, int32(2), maxRunning)
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	dir1 := strings.Index(comment, "output-dir1")
+	dir2 := strings.Index(comment, "output-dir2")
+	dir3 := strings.Index(comment, "output-dir3")
+	Assert(t, dir1 >= 0 && dir1 < dir2 && dir2 < dir3, "expected results in project order, got %q", comment)
+}
+
+// Test that when parallel plans are enabled, projects in the same dir and
+// workspace are planned one after another since they share a working dir lock.
+func TestRunAutoplanCommand_ParallelPlanSameDir(t *testing.T) {
+	vcsClient := setup(t)
+	ch.ParallelPoolSize = 3
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{ProjectName: "a", RepoRelDir: "dir", Workspace: "default", ParallelPlanEnabled: true},
+			{ProjectName: "b", RepoRelDir: "dir", Workspace: "default", ParallelPlanEnabled: true},
+			{ProjectName: "c", RepoRelDir: "dir", Workspace: "staging", ParallelPlanEnabled: true},
+		}, nil)
+	var mutex sync.Mutex
+	running := make(map[string]bool)
+	var collided bool
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		key := ctx.Workspace + "/" + ctx.RepoRelDir
+		mutex.Lock()
+		if running[key] {
+			collided = true
+		}
+		running[key] = true
+		mutex.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		running[key] = false
+		mutex.Unlock()
+		return ReturnValues{
+			models.ProjectResult{
+				RepoRelDir:  ctx.RepoRelDir,
+				Workspace:   ctx.Workspace,
+				ProjectName: ctx.ProjectName,
+				PlanSuccess: &models.PlanSuccess{TerraformOutput: "output-" + ctx.ProjectName},
+			},
+		}
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+	Assert(t, !collided, "expected projects in the same dir and workspace not to run concurrently")
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	a := strings.Index(comment, "output-a")
+	b := strings.Index(comment, "output-b")
+	c := strings.Index(comment, "output-c")
+	Assert(t, a >= 0 && a < b && b < c, "expected results in project order, got %q", comment)
+}
+
+// Test that after a successful plan, projects with policy sets have their
+// policies checked and the results are stored.
+func TestRunAutoplanCommand_PolicyCheck(t *testing.T) {
+	vcsClient := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+
+	policySets := []valid.PolicySet{{Name: "policies", Path: "/policies"}}
+	policyCheckSteps := []valid.Step{{StepName: "show"}, {StepName: "policy_check"}}
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "dir1", Workspace: "default", PolicySets: policySets, PolicyCheckSteps: policyCheckSteps},
+			{RepoRelDir: "dir2", Workspace: "default", PolicySets: policySets, PolicyCheckSteps: policyCheckSteps},
+			{RepoRelDir: "dir3", Workspace: "default"},
+		}, nil)
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		res := models.ProjectResult{
+			Command:     models.PlanCommand,
+			RepoRelDir:  ctx.RepoRelDir,
+			Workspace:   ctx.Workspace,
+			PlanSuccess: &models.PlanSuccess{},
+		}
+		// The plan for dir2 fails so its policies shouldn't be checked.
+		if ctx.RepoRelDir == "dir2" {
+			res.PlanSuccess = nil
+			res.Error = errors.New("plan failed")
+		}
+		return ReturnValues{res}
+	})
+	When(projectCommandRunner.PolicyCheck(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		return ReturnValues{
+			models.ProjectResult{
+				Command:    models.PolicyCheckCommand,
+				RepoRelDir: ctx.RepoRelDir,
+				Workspace:  ctx.Workspace,
+				Error:      errors.New("policy set(s) failed: policies"),
+			},
+		}
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+
+	policyCtx := projectCommandRunner.VerifyWasCalledOnce().PolicyCheck(matchers.AnyModelsProjectCommandContext()).GetCapturedArguments()
+	Equals(t, "dir1", policyCtx.RepoRelDir)
+	Equals(t, policyCheckSteps, policyCtx.Steps)
+
+	// We comment once for the plans and once for the policy checks.
+	_, _, bodies := vcsClient.VerifyWasCalled(Times(2)).CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetAllCapturedArguments()
+	Assert(t, strings.HasPrefix(bodies[1], "Ran Policy Check for dir: `dir1` workspace: `default`"), "got %q", bodies[1])
+
+	pullStatus, err := boltdb.GetPullStatus(fixtures.Pull)
+	Ok(t, err)
+	Equals(t, []models.ProjectStatus{
+		{RepoRelDir: "dir1", Workspace: "default", Status: models.ErroredPolicyCheckStatus},
+		{RepoRelDir: "dir2", Workspace: "default", Status: models.ErroredPlanStatus},
+		{RepoRelDir: "dir3", Workspace: "default", Status: models.PlannedPlanStatus},
+	}, pullStatus.Projects)
+}
+
+// Test that the output of each project's plan is saved in the history and that
+// runs older than the retention period are deleted.
+func TestRunAutoplanCommand_RecordsHistory(t *testing.T) {
+	setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+	ch.HistoryRetention = time.Hour
+
+	old := time.Now().Add(-2 * time.Hour)
+	Ok(t, boltdb.AddProjectRuns(fixtures.Pull, []models.ProjectRun{
+		{Command: models.PlanCommand, RepoRelDir: "old", StartedAt: old, FinishedAt: old},
+	}))
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "dir1", Workspace: "default"},
+			{RepoRelDir: "dir2", Workspace: "default"},
+		}, nil)
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		res := models.ProjectResult{
+			Command:     models.PlanCommand,
+			RepoRelDir:  ctx.RepoRelDir,
+			Workspace:   ctx.Workspace,
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "output-" + ctx.RepoRelDir},
+		}
+		if ctx.RepoRelDir == "dir2" {
+			res.PlanSuccess = nil
+			res.Error = errors.New("plan failed")
+		}
+		return ReturnValues{res}
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+
+	runs, err := boltdb.GetProjectRuns(fixtures.Pull)
+	Ok(t, err)
+	Equals(t, 2, len(runs))
+	Equals(t, "dir1", runs[0].RepoRelDir)
+	Equals(t, models.PlannedPlanStatus, runs[0].Status)
+	Equals(t, "output-dir1", runs[0].Output)
+	Equals(t, fixtures.User.Username, runs[0].User)
+	Equals(t, fixtures.Pull.HeadCommit, runs[0].HeadCommit)
+	Equals(t, "dir2", runs[1].RepoRelDir)
+	Equals(t, models.ErroredPlanStatus, runs[1].Status)
+	Equals(t, "plan failed", runs[1].Output)
+}
+
+type jobURLGenerator struct{}
+
+func (jobURLGenerator) GenerateJobURL(id string) string {
+	return "https://atlantis/jobs/" + id
+}
+
+// Test that when jobs are enabled, each project's output is streamed to a job
+// that's linked to from a comment and the project's commit status.
+func TestRunAutoplanCommand_StreamsJobs(t *testing.T) {
+	vcsClient := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+	ch.Jobs = jobs.NewRegistry(jobURLGenerator{})
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "dir1", Workspace: "default"},
+		}, nil)
+	var job *jobs.Job
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		job = ctx.Job
+		ctx.Job.Write("planning")
+		return ReturnValues{
+			models.ProjectResult{
+				RepoRelDir:  ctx.RepoRelDir,
+				Workspace:   ctx.Workspace,
+				PlanSuccess: &models.PlanSuccess{TerraformOutput: "output"},
+			},
+		}
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+	Assert(t, job != nil, "exp project to be run with a job")
+	Equals(t, jobs.SucceededStatus, job.Status())
+	lines, _, unsubscribe := job.Subscribe(0)
+	unsubscribe()
+	Equals(t, []string{"planning"}, lines)
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, fixtures.Pull.Num,
+		"Running plan. Follow the output of each project as it runs:\n\n- dir: `dir1` workspace: `default`: "+job.URL)
+	vcsClient.VerifyWasCalledOnce().UpdateStatus(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.EqModelsCommitStatus(models.PendingCommitStatus),
+		EqString("atlantis/plan: dir1/default"), AnyString(), EqString(job.URL))
+	vcsClient.VerifyWasCalledOnce().UpdateStatus(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.EqModelsCommitStatus(models.SuccessCommitStatus),
+		EqString("atlantis/plan: dir1/default"), AnyString(), EqString(job.URL))
+}
+
+// Test that when GitHub checks are enabled, each project gets a check run even
+// though jobs are disabled.
+func TestRunAutoplanCommand_GithubChecks(t *testing.T) {
+	setup(t)
+	checks := vcsmocks.NewMockChecksClient()
+	ch.CommitStatusUpdater = &events.DefaultCommitStatusUpdater{Client: vcsmocks.NewMockClient(), StatusName: "atlantis", Checks: checks, MarkdownRenderer: &events.MarkdownRenderer{}}
+	ch.GithubChecks = true
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{BaseRepo: fixtures.GithubRepo, Pull: fixtures.Pull, RepoRelDir: "dir1", Workspace: "default", RePlanCmd: "atlantis plan -d dir1"},
+		}, nil)
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).ThenReturn(models.ProjectResult{
+		RepoRelDir:  "dir1",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "output"},
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+	_, _, runs := checks.VerifyWasCalled(AtLeast(1)).UpdateCheckRun(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), vcsmatchers.AnyVcsCheckRun()).GetAllCapturedArguments()
+	var projectRuns []vcs.CheckRun
+	for _, run := range runs {
+		if run.Name == "atlantis/plan: dir1/default" {
+			projectRuns = append(projectRuns, run)
+		}
+	}
+	Equals(t, 2, len(projectRuns))
+	Equals(t, models.PendingCommitStatus, projectRuns[0].Status)
+	Equals(t, models.SuccessCommitStatus, projectRuns[1].Status)
+	Assert(t, strings.Contains(projectRuns[1].Summary, "output"), "exp summary to contain the plan output, got %q", projectRuns[1].Summary)
+	Equals(t, "1:atlantis plan -d dir1", projectRuns[1].ExternalID)
+}
+
+// Test that before applying, the project's status is loaded from the database
+// so that the policies_passed apply requirement can be checked.
+func TestRunCommentCommand_ApplySetsProjectPlanStatus(t *testing.T) {
+	setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+
+	pull := &github.PullRequest{}
+	modelPull := fixtures.Pull
+	modelPull.BaseRepo = fixtures.GithubRepo
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, fixtures.GithubRepo, fixtures.GithubRepo, nil)
+	_, err = boltdb.UpdatePullWithResults(modelPull, []models.ProjectResult{
+		{
+			Command:            models.PolicyCheckCommand,
+			RepoRelDir:         "dir1",
+			Workspace:          "default",
+			PolicyCheckSuccess: &models.PolicyCheckSuccess{},
+		},
+		{
+			Command:    models.PolicyCheckCommand,
+			RepoRelDir: "dir2",
+			Workspace:  "default",
+			Error:      errors.New("failed"),
+		},
+	})
+	Ok(t, err)
+
+	When(projectCommandBuilder.BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "dir1", Workspace: "default", ApplyRequirements: []string{"policies_passed"}},
+			{RepoRelDir: "dir2", Workspace: "default", ApplyRequirements: []string{"policies_passed"}},
+		}, nil)
+
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, modelPull.Num, &events.CommentCommand{Name: models.ApplyCommand}, "")
+
+	ctxs := projectCommandRunner.VerifyWasCalled(Times(2)).Apply(matchers.AnyModelsProjectCommandContext()).GetAllCapturedArguments()
+	Equals(t, models.PassedPolicyCheckStatus, ctxs[0].ProjectPlanStatus)
+	Equals(t, models.ErroredPolicyCheckStatus, ctxs[1].ProjectPlanStatus)
+}
+
+// Test that import is dispatched to the project command runner and that,
+// since it modifies state directly, no commit status is set.
+func TestRunCommentCommand_Import(t *testing.T) {
+	vcsClient := setup(t)
+	pull := &github.PullRequest{}
+	modelPull := fixtures.Pull
+	modelPull.BaseRepo = fixtures.GithubRepo
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, fixtures.GithubRepo, fixtures.GithubRepo, nil)
+	When(projectCommandBuilder.BuildImportCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{{RepoRelDir: "dir", Workspace: "default"}}, nil)
+	When(projectCommandRunner.Import(matchers.AnyModelsProjectCommandContext())).
+		ThenReturn(models.ProjectResult{
+			Command:       models.ImportCommand,
+			RepoRelDir:    "dir",
+			Workspace:     "default",
+			ImportSuccess: &models.ImportSuccess{Output: "imported"},
+		})
+
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, modelPull.Num, &events.CommentCommand{Name: models.ImportCommand, Args: []string{"aws_instance.a", "i-123"}}, "")
+
+	projectCommandRunner.VerifyWasCalledOnce().Import(matchers.AnyModelsProjectCommandContext())
+	vcsClient.VerifyWasCalled(Never()).UpdateStatus(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), matchers.AnyModelsCommitStatus(), AnyString(), AnyString(), AnyString())
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "imported"), "exp comment to contain output, got %q", comment)
+}
+
+// setupUnlock sets up a pull request that holds locks on dir1 and dir2 and
+// has plans for both, as well as another pull request that holds a lock on
+// dir3.
+func setupUnlock(t *testing.T) (*vcsmocks.MockClient, locking.Locker, models.PullRequest, func()) {
+	vcsClient := setup(t)
+	pull := &github.PullRequest{}
+	modelPull := fixtures.Pull
+	modelPull.BaseRepo = fixtures.GithubRepo
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, fixtures.GithubRepo, fixtures.GithubRepo, nil)
+
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	locker := locking.NewClient(boltdb)
+	ch.DB = boltdb
+	ch.Locker = locker
+	ch.WorkingDirLocker = events.NewDefaultWorkingDirLocker()
+
+	otherPull := modelPull
+	otherPull.Num = modelPull.Num + 1
+	for _, l := range []struct {
+		dir  string
+		pull models.PullRequest
+	}{{"dir1", modelPull}, {"dir2", modelPull}, {"dir3", otherPull}} {
+		_, err := locker.TryLock(models.NewProject(fixtures.GithubRepo.FullName, l.dir), "default", l.pull, fixtures.GithubRepo, fixtures.User)
+		Ok(t, err)
+	}
+	_, err = boltdb.UpdatePullWithResults(modelPull, []models.ProjectResult{
+		{RepoRelDir: "dir1", Workspace: "default", PlanSuccess: &models.PlanSuccess{}},
+		{RepoRelDir: "dir2", Workspace: "default", ProjectName: "two", PlanSuccess: &models.PlanSuccess{}},
+	})
+	Ok(t, err)
+
+	When(workingDir.GetPullDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn("/pulldir", nil)
+	When(pendingPlanFinder.Find("/pulldir")).ThenReturn([]events.PendingPlan{
+		{RepoDir: "/pulldir/default", RepoRelDir: "dir1", Workspace: "default"},
+		{RepoDir: "/pulldir/default", RepoRelDir: "dir2", Workspace: "default", ProjectName: "two"},
+	}, nil)
+	return vcsClient, locker, modelPull, cleanup
+}
+
+// Test that unlock without any flags releases all the pull's locks and
+// deletes all its plans.
+func TestRunCommentCommand_UnlockAll(t *testing.T) {
+	vcsClient, locker, modelPull, cleanup := setupUnlock(t)
+	defer cleanup()
+
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.UnlockCommand}, "")
+
+	locks, err := locker.List()
+	Ok(t, err)
+	Equals(t, 1, len(locks))
+	for _, l := range locks {
+		Equals(t, "dir3", l.Project.Path)
+	}
+	pendingPlanFinder.VerifyWasCalledOnce().DeletePlans("/pulldir")
+	status, err := ch.DB.GetPullStatus(modelPull)
+	Ok(t, err)
+	Assert(t, status == nil, "exp pull status to be deleted")
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	Equals(t, "Locks and plans deleted for:\n\n- dir: `dir1` workspace: `default`\n- dir: `dir2` workspace: `default`\n\nTo `apply` these projects you must run `plan` again.", comment)
+}
+
+// Test that the -d and -p flags only unlock the matching projects.
+func TestRunCommentCommand_UnlockProject(t *testing.T) {
+	cases := []struct {
+		description string
+		cmd         events.CommentCommand
+	}{
+		{"dir", events.CommentCommand{Name: models.UnlockCommand, RepoRelDir: "dir2"}},
+		{"project name", events.CommentCommand{Name: models.UnlockCommand, ProjectName: "two"}},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			vcsClient, locker, modelPull, cleanup := setupUnlock(t)
+			defer cleanup()
+
+			ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, &c.cmd, "")
+
+			locks, err := locker.List()
+			Ok(t, err)
+			var lockedDirs []string
+			for _, l := range locks {
+				lockedDirs = append(lockedDirs, l.Project.Path)
+			}
+			sort.Strings(lockedDirs)
+			Equals(t, []string{"dir1", "dir3"}, lockedDirs)
+			pendingPlanFinder.VerifyWasCalledOnce().DeletePlan(events.PendingPlan{RepoDir: "/pulldir/default", RepoRelDir: "dir2", Workspace: "default", ProjectName: "two"})
+			pendingPlanFinder.VerifyWasCalled(Never()).DeletePlans(AnyString())
+			status, err := ch.DB.GetPullStatus(modelPull)
+			Ok(t, err)
+			Equals(t, 1, len(status.Projects))
+			Equals(t, "dir1", status.Projects[0].RepoRelDir)
+			_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+			Equals(t, "Locks and plans deleted for:\n\n- dir: `dir2` workspace: `default`\n\nTo `apply` these projects you must run `plan` again.", comment)
+		})
+	}
+}
+
+// Test that cancel cancels the commands running for the pull request.
+func TestRunCommentCommand_Cancel(t *testing.T) {
+	vcsClient := setup(t)
+	pull := &github.PullRequest{}
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(fixtures.Pull, fixtures.GithubRepo, fixtures.GithubRepo, nil)
+	ch.Canceller = &events.CommandCanceller{}
+	running, done := ch.Canceller.Track(fixtures.GithubRepo.FullName, fixtures.Pull.Num)
+	defer done()
+	other, otherDone := ch.Canceller.Track(fixtures.GithubRepo.FullName, fixtures.Pull.Num+1)
+	defer otherDone()
+
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.CancelCommand}, "")
+	Assert(t, running.Err() == context.Canceled, "exp command to be cancelled")
+	Assert(t, other.Err() == nil, "exp other pull's command not to be cancelled")
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	Assert(t, strings.HasPrefix(comment, "Cancelling 1 running command(s)."), "got comment %q", comment)
+}
+
+// Test that when a plan is cancelled, the projects that haven't started are
+// reported as cancelled without being run.
+func TestRunAutoplanCommand_Cancelled(t *testing.T) {
+	vcsClient := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+	ch.Canceller = &events.CommandCanceller{}
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "dir1", Workspace: "default"},
+			{RepoRelDir: "dir2", Workspace: "default"},
+		}, nil)
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		// Cancel while the first project is running.
+		ch.Canceller.Cancel(fixtures.GithubRepo.FullName, fixtures.Pull.Num)
+		Assert(t, ctx.IsCancelled(), "exp project's context to be cancelled")
+		return ReturnValues{
+			models.ProjectResult{
+				Command:    models.PlanCommand,
+				RepoRelDir: ctx.RepoRelDir,
+				Workspace:  ctx.Workspace,
+				Error:      errors.New("interrupted"),
+				Cancelled:  true,
+			},
+		}
+	})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User, "")
+	projectCommandRunner.VerifyWasCalledOnce().Plan(matchers.AnyModelsProjectCommandContext())
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "**Plan Cancelled**\n```\ninterrupted\n```"), "exp first project to be cancelled, got %q", comment)
+	Assert(t, strings.Contains(comment, "**Plan Cancelled**\n```\ncancelled before this project started\n```"), "exp second project to be cancelled, got %q", comment)
+}
+
+// Test that projects are applied after the projects they depend on and that
+// they're skipped if one of those projects wasn't applied successfully.
+func TestRunCommentCommand_ApplyDependsOn(t *testing.T) {
+	vcsClient := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+
+	pull := &github.PullRequest{}
+	modelPull := fixtures.Pull
+	modelPull.BaseRepo = fixtures.GithubRepo
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, fixtures.GithubRepo, fixtures.GithubRepo, nil)
+	When(projectCommandBuilder.BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "apps", Workspace: "default", ProjectName: "apps", DependsOn: []string{"eks"}},
+			{RepoRelDir: "eks", Workspace: "default", ProjectName: "eks", DependsOn: []string{"network"}},
+			{RepoRelDir: "network", Workspace: "default", ProjectName: "network"},
+			{RepoRelDir: "dns", Workspace: "default", ProjectName: "dns", DependsOn: []string{"network", "unplanned"}},
+		}, nil)
+	When(projectCommandRunner.Apply(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		res := models.ProjectResult{
+			Command:     models.ApplyCommand,
+			RepoRelDir:  ctx.RepoRelDir,
+			Workspace:   ctx.Workspace,
+			ProjectName: ctx.ProjectName,
+		}
+		if ctx.ProjectName == "eks" {
+			res.Error = errors.New("apply failed")
+		} else {
+			res.ApplySuccess = "success"
+		}
+		return ReturnValues{res}
+	})
+
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, modelPull.Num, &events.CommentCommand{Name: models.ApplyCommand}, "")
+
+	ctxs := projectCommandRunner.VerifyWasCalled(Times(3)).Apply(matchers.AnyModelsProjectCommandContext()).GetAllCapturedArguments()
+	var applied []string
+	for _, ctx := range ctxs {
+		applied = append(applied, ctx.ProjectName)
+	}
+	Equals(t, []string{"network", "eks", "dns"}, applied)
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "**Apply Skipped**: this project depends on project \"eks\""), "exp apps to be skipped, got %q", comment)
+}
+
+// Test that projects aren't applied before a project they depend on that has
+// a plan but isn't part of the apply.
+func TestRunCommentCommand_ApplyDependsOnUnapplied(t *testing.T) {
+	vcsClient := setup(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	ch.DB = boltdb
+
+	pull := &github.PullRequest{}
+	modelPull := fixtures.Pull
+	modelPull.BaseRepo = fixtures.GithubRepo
+	_, err = boltdb.UpdatePullWithResults(modelPull, []models.ProjectResult{
+		{Command: models.PlanCommand, RepoRelDir: "network", Workspace: "default", ProjectName: "network", PlanSuccess: &models.PlanSuccess{}},
+		{Command: models.ApplyCommand, RepoRelDir: "vpc", Workspace: "default", ProjectName: "vpc", ApplySuccess: "success"},
+	})
+	Ok(t, err)
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, fixtures.GithubRepo, fixtures.GithubRepo, nil)
+	When(projectCommandBuilder.BuildApplyCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn([]models.ProjectCommandContext{
+			{RepoRelDir: "apps", Workspace: "default", ProjectName: "apps", DependsOn: []string{"eks"}},
+			{RepoRelDir: "eks", Workspace: "default", ProjectName: "eks", DependsOn: []string{"network"}},
+			// vpc has already been applied so dns can be applied.
+			{RepoRelDir: "dns", Workspace: "default", ProjectName: "dns", DependsOn: []string{"vpc"}},
+		}, nil)
+	When(projectCommandRunner.Apply(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(models.ProjectCommandContext)
+		return ReturnValues{models.ProjectResult{
+			Command:      models.ApplyCommand,
+			RepoRelDir:   ctx.RepoRelDir,
+			Workspace:    ctx.Workspace,
+			ProjectName:  ctx.ProjectName,
+			ApplySuccess: "success",
+		}}
+	})
+
+	ch.RunCommentCommand(fixtures.GithubRepo, nil, nil, fixtures.User, modelPull.Num, &events.CommentCommand{Name: models.ApplyCommand}, "")
+
+	ctx := projectCommandRunner.VerifyWasCalledOnce().Apply(matchers.AnyModelsProjectCommandContext()).GetCapturedArguments()
+	Equals(t, "dns", ctx.ProjectName)
+	_, _, comment := vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "**Apply Skipped**: this project depends on project \"network\" which has a plan that hasn't been applied"), "exp eks to be skipped, got %q", comment)
+	Assert(t, strings.Contains(comment, "**Apply Skipped**: this project depends on project \"eks\" which wasn't applied successfully"), "exp apps to be skipped, got %q", comment)
+}
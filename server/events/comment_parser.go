@@ -37,6 +37,8 @@ const (
 	verboseFlagLong    = "verbose"
 	verboseFlagShort   = ""
 	atlantisExecutable = "atlantis"
+	stateCommand       = "state"
+	stateRmSubcommand  = "rm"
 )
 
 // multiLineRegex is used to ignore multi-line comments since those aren't valid
@@ -69,6 +71,7 @@ type CommentParser struct {
 	GitlabUser      string
 	BitbucketUser   string
 	AzureDevopsUser string
+	GiteaUser       string
 }
 
 // CommentParseResult describes the result of parsing a comment as a command.
@@ -88,9 +91,12 @@ type CommentParseResult struct {
 // Valid commands contain:
 // - The initial "executable" name, 'run' or 'atlantis' or '@GithubUser'
 //   where GithubUser is the API user Atlantis is running as.
-// - Then a command, either 'plan', 'apply', or 'help'.
+// - Then a command, either 'plan', 'apply', 'import', 'state rm', 'unlock', 'cancel'
+//   or 'help'.
 // - Then optional flags, then an optional separator '--' followed by optional
 //   extra flags to be appended to the terraform plan/apply command.
+// - import and state rm also take the resource addresses (and ID for import)
+//   before the separator.
 //
 // Examples:
 // - atlantis help
@@ -98,6 +104,10 @@ type CommentParseResult struct {
 // - @GithubUser plan -w staging
 // - atlantis plan -w staging -d dir --verbose
 // - atlantis plan --verbose -- -key=value -key2 value2
+// - atlantis import -d dir aws_instance.example i-abcd1234
+// - atlantis state rm -p project aws_instance.example
+// - atlantis unlock -d dir
+// - atlantis cancel
 //
 func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) CommentParseResult {
 	if multiLineRegex.MatchString(comment) {
@@ -128,6 +138,8 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 		vcsUser = e.BitbucketUser
 	case models.AzureDevops:
 		vcsUser = e.AzureDevopsUser
+	case models.Gitea:
+		vcsUser = e.GiteaUser
 	}
 	executableNames := []string{"run", atlantisExecutable, "@" + vcsUser}
 	if !e.stringInSlice(args[0], executableNames) {
@@ -156,11 +168,21 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 		return CommentParseResult{CommentResponse: HelpComment}
 	}
 
-	// Need to have a plan or apply at this point.
-	if !e.stringInSlice(command, []string{models.PlanCommand.String(), models.ApplyCommand.String()}) {
+	// Need to have a plan, apply, import, state, unlock or cancel at this point.
+	if !e.stringInSlice(command, []string{models.PlanCommand.String(), models.ApplyCommand.String(), models.ImportCommand.String(), stateCommand, models.UnlockCommand.String(), models.CancelCommand.String()}) {
 		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError: unknown command %q.\nRun 'atlantis --help' for usage.\n```", command)}
 	}
 
+	// state has subcommands so the flags start one argument later.
+	flagArgs := args[2:]
+	if command == stateCommand {
+		if len(args) < 3 || args[2] != stateRmSubcommand {
+			return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError: %s requires a subcommand, only %q is supported.\nRun 'atlantis --help' for usage.\n```", stateCommand, stateRmSubcommand)}
+		}
+		command = fmt.Sprintf("%s %s", stateCommand, stateRmSubcommand)
+		flagArgs = args[3:]
+	}
+
 	var workspace string
 	var dir string
 	var project string
@@ -186,13 +208,39 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Apply the plan for this directory, relative to root of repo, ex. 'child/dir'.")
 		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Apply the plan for this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", yaml.AtlantisYAMLFilename))
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case models.ImportCommand.String():
+		name = models.ImportCommand
+		flagSet = pflag.NewFlagSet(models.ImportCommand.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(ioutil.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before importing.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run import in relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Which project to run import for. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", yaml.AtlantisYAMLFilename))
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case fmt.Sprintf("%s %s", stateCommand, stateRmSubcommand):
+		name = models.StateRmCommand
+		flagSet = pflag.NewFlagSet(command, pflag.ContinueOnError)
+		flagSet.SetOutput(ioutil.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before removing resources from state.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run state rm in relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Which project to run state rm for. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", yaml.AtlantisYAMLFilename))
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case models.UnlockCommand.String():
+		name = models.UnlockCommand
+		flagSet = pflag.NewFlagSet(models.UnlockCommand.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(ioutil.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Only unlock projects in this Terraform workspace.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Only unlock the project in this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Only unlock this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", yaml.AtlantisYAMLFilename))
+	case models.CancelCommand.String():
+		name = models.CancelCommand
+		flagSet = pflag.NewFlagSet(models.CancelCommand.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(ioutil.Discard)
 	default:
 		return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", command)}
 	}
 
 	// Now parse the flags.
-	// It's safe to use [2:] because we know there's at least 2 elements in args.
-	err = flagSet.Parse(args[2:])
+	err = flagSet.Parse(flagArgs)
 	if err == pflag.ErrHelp {
 		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nUsage of %s:\n%s\n```", command, flagSet.FlagUsagesWrapped(usagesCols))}
 	}
@@ -206,6 +254,21 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 	} else {
 		unusedArgs = flagSet.Args()[0:flagSet.ArgsLenAtDash()]
 	}
+
+	// import and state rm take positional arguments. The rest don't take any.
+	var positionalArgs []string
+	switch name {
+	case models.ImportCommand:
+		if len(unusedArgs) != 2 {
+			return CommentParseResult{CommentResponse: e.errMarkdown("import requires exactly two arguments: ADDRESS and ID", command, flagSet)}
+		}
+		positionalArgs, unusedArgs = unusedArgs, nil
+	case models.StateRmCommand:
+		if len(unusedArgs) == 0 {
+			return CommentParseResult{CommentResponse: e.errMarkdown("state rm requires at least one ADDRESS", command, flagSet)}
+		}
+		positionalArgs, unusedArgs = unusedArgs, nil
+	}
 	if len(unusedArgs) > 0 {
 		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("unknown argument(s) – %s", strings.Join(unusedArgs, " ")), command, flagSet)}
 	}
@@ -214,7 +277,11 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 	if flagSet.ArgsLenAtDash() != -1 {
 		extraArgs = flagSet.Args()[flagSet.ArgsLenAtDash():]
 	}
-
+	// unlock and cancel don't run Terraform so there's nothing to pass the
+	// extra args to.
+	if (name == models.UnlockCommand || name == models.CancelCommand) && len(extraArgs) > 0 {
+		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("%s doesn't take extra arguments", command), command, flagSet)}
+	}
 	dir, err = e.validateDir(dir)
 	if err != nil {
 		return CommentParseResult{CommentResponse: e.errMarkdown(err.Error(), command, flagSet)}
@@ -237,9 +304,9 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 		return CommentParseResult{CommentResponse: e.errMarkdown(err, command, flagSet)}
 	}
 
-	return CommentParseResult{
-		Command: NewCommentCommand(dir, extraArgs, name, verbose, workspace, project),
-	}
+	cmd := NewCommentCommand(dir, extraArgs, name, verbose, workspace, project)
+	cmd.Args = positionalArgs
+	return CommentParseResult{Command: cmd}
 }
 
 // BuildPlanComment builds a plan comment for the specified args.
@@ -341,12 +408,31 @@ Examples:
   # apply the plan for the root directory and staging workspace
   atlantis apply -d . -w staging
 
+  # import an existing resource into the root directory's state
+  atlantis import -d . aws_instance.example i-abcd1234
+
+  # remove a resource from the staging workspace's state
+  atlantis state rm -w staging aws_instance.example
+
+  # release this pull request's locks and discard its plans
+  atlantis unlock
+
+  # stop the plans and applies running for this pull request
+  atlantis cancel
+
 Commands:
-  plan   Runs 'terraform plan' for the changes in this pull request.
-         To plan a specific project, use the -d, -w and -p flags.
-  apply  Runs 'terraform apply' on all unapplied plans from this pull request.
-         To only apply a specific plan, use the -d, -w and -p flags.
-  help   View help.
+  plan      Runs 'terraform plan' for the changes in this pull request.
+            To plan a specific project, use the -d, -w and -p flags.
+  apply     Runs 'terraform apply' on all unapplied plans from this pull request.
+            To only apply a specific plan, use the -d, -w and -p flags.
+  import    Runs 'terraform import ADDRESS ID' for a single project.
+            Use the -d, -w and -p flags to select the project.
+  state rm  Runs 'terraform state rm ADDRESS...' for a single project.
+            Use the -d, -w and -p flags to select the project.
+  unlock    Releases all locks held by this pull request and discards its plans.
+            To only unlock specific projects, use the -d, -w and -p flags.
+  cancel    Cancels the plans and applies running for this pull request.
+  help      View help.
 
 Flags:
   -h, --help   help for atlantis
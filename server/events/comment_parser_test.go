@@ -169,6 +169,8 @@ func TestParse_SubcommandUsage(t *testing.T) {
 		"atlantis plan --help",
 		"atlantis apply -h",
 		"atlantis apply --help",
+		"atlantis import -h",
+		"atlantis state rm --help",
 	}
 	for _, c := range comments {
 		r := commentParser.Parse(c, models.Github)
@@ -180,6 +182,156 @@ func TestParse_SubcommandUsage(t *testing.T) {
 	}
 }
 
+func TestParse_ImportAndStateRm(t *testing.T) {
+	cases := []struct {
+		comment    string
+		expName    models.CommandName
+		expFlags   []string
+		expArgs    []string
+		expDir     string
+		expProject string
+		expErr     string
+	}{
+		{
+			comment: "atlantis import aws_instance.a i-123",
+			expName: models.ImportCommand,
+			expArgs: []string{"aws_instance.a", "i-123"},
+		},
+		{
+			comment:  "atlantis import -d dir 'aws_instance.a[\"key\"]' i-123 -- -var a=b",
+			expName:  models.ImportCommand,
+			expFlags: []string{"-var", "a=b"},
+			expArgs:  []string{"aws_instance.a[\"key\"]", "i-123"},
+			expDir:   "dir",
+		},
+		{
+			comment: "atlantis import aws_instance.a",
+			expErr:  "import requires exactly two arguments: ADDRESS and ID",
+		},
+		{
+			comment: "atlantis import aws_instance.a i-123 extra",
+			expErr:  "import requires exactly two arguments: ADDRESS and ID",
+		},
+		{
+			comment:    "atlantis state rm -p proj aws_instance.a aws_instance.b",
+			expName:    models.StateRmCommand,
+			expArgs:    []string{"aws_instance.a", "aws_instance.b"},
+			expProject: "proj",
+		},
+		{
+			comment: "atlantis state rm",
+			expErr:  "state rm requires at least one ADDRESS",
+		},
+		{
+			comment: "atlantis state list",
+			expErr:  "state requires a subcommand, only \"rm\" is supported",
+		},
+		{
+			comment: "atlantis state",
+			expErr:  "state requires a subcommand, only \"rm\" is supported",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			r := commentParser.Parse(c.comment, models.Github)
+			if c.expErr != "" {
+				Assert(t, strings.Contains(r.CommentResponse, c.expErr), "exp %q to contain %q", r.CommentResponse, c.expErr)
+				return
+			}
+			Equals(t, "", r.CommentResponse)
+			Equals(t, c.expName, r.Command.Name)
+			Equals(t, c.expFlags, r.Command.Flags)
+			Equals(t, c.expArgs, r.Command.Args)
+			Equals(t, c.expDir, r.Command.RepoRelDir)
+			Equals(t, c.expProject, r.Command.ProjectName)
+		})
+	}
+}
+
+func TestParse_Unlock(t *testing.T) {
+	cases := []struct {
+		comment      string
+		expDir       string
+		expWorkspace string
+		expProject   string
+		expErr       string
+	}{
+		{
+			comment: "atlantis unlock",
+		},
+		{
+			comment:      "atlantis unlock -d dir -w staging",
+			expDir:       "dir",
+			expWorkspace: "staging",
+		},
+		{
+			comment:    "atlantis unlock -p proj",
+			expProject: "proj",
+		},
+		{
+			comment: "atlantis unlock -p proj -d dir",
+			expErr:  "cannot use -p/--project at same time as -d/--dir or -w/--workspace",
+		},
+		{
+			comment: "atlantis unlock arg",
+			expErr:  "unknown argument(s) – arg",
+		},
+		{
+			comment: "atlantis unlock -- -lock=false",
+			expErr:  "unlock doesn't take extra arguments",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			r := commentParser.Parse(c.comment, models.Github)
+			if c.expErr != "" {
+				Assert(t, strings.Contains(r.CommentResponse, c.expErr), "exp %q to contain %q", r.CommentResponse, c.expErr)
+				return
+			}
+			Equals(t, "", r.CommentResponse)
+			Equals(t, models.UnlockCommand, r.Command.Name)
+			Equals(t, []string(nil), r.Command.Flags)
+			Equals(t, c.expDir, r.Command.RepoRelDir)
+			Equals(t, c.expWorkspace, r.Command.Workspace)
+			Equals(t, c.expProject, r.Command.ProjectName)
+		})
+	}
+}
+
+func TestParse_Cancel(t *testing.T) {
+	cases := []struct {
+		comment string
+		expErr  string
+	}{
+		{
+			comment: "atlantis cancel",
+		},
+		{
+			comment: "atlantis cancel -d dir",
+			expErr:  "unknown shorthand flag: 'd' in -d",
+		},
+		{
+			comment: "atlantis cancel arg",
+			expErr:  "unknown argument(s) – arg",
+		},
+		{
+			comment: "atlantis cancel -- -lock=false",
+			expErr:  "cancel doesn't take extra arguments",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			r := commentParser.Parse(c.comment, models.Github)
+			if c.expErr != "" {
+				Assert(t, strings.Contains(r.CommentResponse, c.expErr), "exp %q to contain %q", r.CommentResponse, c.expErr)
+				return
+			}
+			Equals(t, "", r.CommentResponse)
+			Equals(t, models.CancelCommand, r.Command.Name)
+		})
+	}
+}
+
 func TestParse_InvalidFlags(t *testing.T) {
 	t.Log("given a comment with a valid atlantis command but invalid" +
 		" flags, should return a warning and the proper usage")
@@ -15,8 +15,12 @@ package events
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 )
@@ -35,13 +39,26 @@ type CommitStatusUpdater interface {
 	// UpdateProject sets the commit status for the project represented by
 	// ctx.
 	UpdateProject(ctx models.ProjectCommandContext, cmdName models.CommandName, status models.CommitStatus, url string) error
+	// UpdateProjectResult sets the commit status for the project represented
+	// by ctx from res, the result of running cmdName.
+	UpdateProjectResult(ctx models.ProjectCommandContext, cmdName models.CommandName, res models.ProjectResult) error
 }
 
+// ReplanActionID is the identifier of the action on check runs that replans
+// the check run's projects.
+const ReplanActionID = "replan"
+
 // DefaultCommitStatusUpdater implements CommitStatusUpdater.
 type DefaultCommitStatusUpdater struct {
 	Client vcs.Client
 	// StatusName is the name used to identify Atlantis when creating PR statuses.
 	StatusName string
+	// Checks publishes check runs instead of commit statuses for GitHub
+	// repos. If nil, commit statuses are used.
+	Checks vcs.ChecksClient
+	// MarkdownRenderer renders the results of projects into the summary of
+	// their check runs.
+	MarkdownRenderer *MarkdownRenderer
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateCombined(repo models.Repo, pull models.PullRequest, status models.CommitStatus, command models.CommandName) error {
@@ -55,25 +72,31 @@ func (d *DefaultCommitStatusUpdater) UpdateCombined(repo models.Repo, pull model
 	case models.SuccessCommitStatus:
 		descripWords = "succeeded."
 	}
-	descrip := fmt.Sprintf("%s %s", strings.Title(command.String()), descripWords)
+	descrip := fmt.Sprintf("%s %s", command.TitleString(), descripWords)
+	if d.useChecks(repo) {
+		return d.updateCheckRun(repo, pull, src, status, descrip, "", fmt.Sprintf("%s %s", atlantisExecutable, models.PlanCommand.String()), "", nil)
+	}
 	return d.Client.UpdateStatus(repo, pull, status, src, descrip, "")
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateCombinedCount(repo models.Repo, pull models.PullRequest, status models.CommitStatus, command models.CommandName, numSuccess int, numTotal int) error {
 	src := fmt.Sprintf("%s/%s", d.StatusName, command.String())
 	cmdVerb := "planned"
-	if command == models.ApplyCommand {
+	switch command {
+	case models.ApplyCommand:
 		cmdVerb = "applied"
+	case models.PolicyCheckCommand:
+		cmdVerb = "policies checked"
 	}
-	return d.Client.UpdateStatus(repo, pull, status, src, fmt.Sprintf("%d/%d projects %s successfully.", numSuccess, numTotal, cmdVerb), "")
+	descrip := fmt.Sprintf("%d/%d projects %s successfully.", numSuccess, numTotal, cmdVerb)
+	if d.useChecks(repo) {
+		return d.updateCheckRun(repo, pull, src, status, descrip, "", fmt.Sprintf("%s %s", atlantisExecutable, models.PlanCommand.String()), "", nil)
+	}
+	return d.Client.UpdateStatus(repo, pull, status, src, descrip, "")
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateProject(ctx models.ProjectCommandContext, cmdName models.CommandName, status models.CommitStatus, url string) error {
-	projectID := ctx.ProjectName
-	if projectID == "" {
-		projectID = fmt.Sprintf("%s/%s", ctx.RepoRelDir, ctx.Workspace)
-	}
-	src := fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectID)
+	src := fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectStatusID(ctx))
 	var descripWords string
 	switch status {
 	case models.PendingCommitStatus:
@@ -83,6 +106,129 @@ func (d *DefaultCommitStatusUpdater) UpdateProject(ctx models.ProjectCommandCont
 	case models.SuccessCommitStatus:
 		descripWords = "succeeded."
 	}
-	descrip := fmt.Sprintf("%s %s", strings.Title(cmdName.String()), descripWords)
+	descrip := fmt.Sprintf("%s %s", cmdName.TitleString(), descripWords)
+	if d.useChecks(ctx.BaseRepo) {
+		return d.updateCheckRun(ctx.BaseRepo, ctx.Pull, src, status, descrip, url, ctx.RePlanCmd, "", nil)
+	}
 	return d.Client.UpdateStatus(ctx.BaseRepo, ctx.Pull, status, src, descrip, url)
 }
+
+// UpdateProjectResult sets the project's commit status to whether res was
+// successful. If check runs are used, the check run's summary is the
+// rendered result and the errors Terraform reported are annotated.
+func (d *DefaultCommitStatusUpdater) UpdateProjectResult(ctx models.ProjectCommandContext, cmdName models.CommandName, res models.ProjectResult) error {
+	status := models.SuccessCommitStatus
+	if !res.IsSuccessful() {
+		status = models.FailedCommitStatus
+	}
+	var url string
+	if ctx.Job != nil {
+		url = ctx.Job.URL
+	}
+	if !d.useChecks(ctx.BaseRepo) || d.MarkdownRenderer == nil {
+		return d.UpdateProject(ctx, cmdName, status, url)
+	}
+
+	src := fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectStatusID(ctx))
+	descrip := fmt.Sprintf("%s succeeded.", cmdName.TitleString())
+	var annotations []vcs.CheckRunAnnotation
+	if status == models.FailedCommitStatus {
+		descrip = fmt.Sprintf("%s failed.", cmdName.TitleString())
+		if res.Error != nil {
+			annotations = terraformErrorAnnotations(ctx.RepoRelDir, res.Error.Error())
+		}
+	}
+	summary := d.MarkdownRenderer.Render(CommandResult{ProjectResults: []models.ProjectResult{res}}, cmdName, "", false, models.Github)
+	return d.updateCheckRun(ctx.BaseRepo, ctx.Pull, src, status, descrip, url, ctx.RePlanCmd, summary, annotations)
+}
+
+// ParseCheckRunExternalID parses the external id of a check run created by
+// Atlantis into the number of its pull request and the comment that replans
+// it.
+func ParseCheckRunExternalID(id string) (pullNum int, replanComment string, err error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", fmt.Errorf("invalid check run external id %q", id)
+	}
+	pullNum, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "invalid pull request number in check run external id %q", id)
+	}
+	return pullNum, parts[1], nil
+}
+
+func (d *DefaultCommitStatusUpdater) useChecks(repo models.Repo) bool {
+	return d.Checks != nil && repo.VCSHost.Type == models.Github
+}
+
+// updateCheckRun updates the check run named src. Once it has finished, it
+// has an action that replans by running replanComment.
+func (d *DefaultCommitStatusUpdater) updateCheckRun(repo models.Repo, pull models.PullRequest, src string, status models.CommitStatus, descrip string, url string, replanComment string, summary string, annotations []vcs.CheckRunAnnotation) error {
+	if summary == "" {
+		summary = descrip
+	}
+	run := vcs.CheckRun{
+		Name:        src,
+		Status:      status,
+		Title:       descrip,
+		Summary:     summary,
+		DetailsURL:  url,
+		Annotations: annotations,
+	}
+	if replanComment != "" {
+		// The external id is sent back to us when the action is clicked.
+		run.ExternalID = fmt.Sprintf("%d:%s", pull.Num, replanComment)
+		if status != models.PendingCommitStatus {
+			run.Actions = []vcs.CheckRunAction{{
+				Label:       "Re-run",
+				Description: "Run plan again",
+				Identifier:  ReplanActionID,
+			}}
+		}
+	}
+	return d.Checks.UpdateCheckRun(repo, pull, run)
+}
+
+// terraformErrorLocationRegex matches where Terraform reports an error is,
+// ex. "on main.tf line 3, in resource "null_resource" "a":".
+var terraformErrorLocationRegex = regexp.MustCompile(`(?m)^\s+on (\S+) line (\d+)`)
+
+// terraformErrorAnnotations returns an annotation for each error in output,
+// Terraform's output, that says which file and line it's from. repoRelDir is
+// the directory Terraform ran in.
+func terraformErrorAnnotations(repoRelDir string, output string) []vcs.CheckRunAnnotation {
+	var annotations []vcs.CheckRunAnnotation
+	// Each error starts with a line beginning with "Error: ".
+	blocks := strings.Split(output, "Error: ")
+	for _, block := range blocks[1:] {
+		match := terraformErrorLocationRegex.FindStringSubmatch(block)
+		if match == nil {
+			continue
+		}
+		line, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		file := path.Join(repoRelDir, match[1])
+		// Errors in modules outside the repo can't be annotated.
+		if strings.HasPrefix(file, "../") {
+			continue
+		}
+		block = strings.TrimSpace(block)
+		annotations = append(annotations, vcs.CheckRunAnnotation{
+			Path:    file,
+			Line:    line,
+			Title:   strings.SplitN(block, "\n", 2)[0],
+			Message: block,
+		})
+	}
+	return annotations
+}
+
+// projectStatusID identifies the project in its status name.
+func projectStatusID(ctx models.ProjectCommandContext) string {
+	if ctx.ProjectName != "" {
+		return ctx.ProjectName
+	}
+	return fmt.Sprintf("%s/%s", ctx.RepoRelDir, ctx.Workspace)
+}
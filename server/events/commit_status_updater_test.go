@@ -14,12 +14,14 @@
 package events_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -126,6 +128,13 @@ func TestUpdateCombinedCount(t *testing.T) {
 			numTotal:   2,
 			expDescrip: "2/2 projects applied successfully.",
 		},
+		{
+			status:     models.FailedCommitStatus,
+			command:    models.PolicyCheckCommand,
+			numSuccess: 1,
+			numTotal:   2,
+			expDescrip: "1/2 projects policies checked successfully.",
+		},
 	}
 
 	for _, c := range cases {
@@ -257,3 +266,106 @@ func TestDefaultCommitStatusUpdater_UpdateProjectCustomStatusName(t *testing.T)
 	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{},
 		models.SuccessCommitStatus, "custom/apply: ./default", "Apply succeeded.", "url")
 }
+
+// Test that when checks are enabled, GitHub repos get check runs.
+func TestDefaultCommitStatusUpdater_UpdateCombinedChecks(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	checks := mocks.NewMockChecksClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", Checks: checks}
+	repo := models.Repo{VCSHost: models.VCSHost{Type: models.Github}}
+	pull := models.PullRequest{Num: 2}
+
+	Ok(t, s.UpdateCombined(repo, pull, models.PendingCommitStatus, models.PlanCommand))
+	Ok(t, s.UpdateCombinedCount(repo, pull, models.SuccessCommitStatus, models.PlanCommand, 1, 1))
+	checks.VerifyWasCalledOnce().UpdateCheckRun(repo, pull, vcs.CheckRun{
+		Name:       "atlantis/plan",
+		Status:     models.PendingCommitStatus,
+		Title:      "Plan in progress...",
+		Summary:    "Plan in progress...",
+		ExternalID: "2:atlantis plan",
+	})
+	checks.VerifyWasCalledOnce().UpdateCheckRun(repo, pull, vcs.CheckRun{
+		Name:       "atlantis/plan",
+		Status:     models.SuccessCommitStatus,
+		Title:      "1/1 projects planned successfully.",
+		Summary:    "1/1 projects planned successfully.",
+		ExternalID: "2:atlantis plan",
+		Actions:    []vcs.CheckRunAction{{Label: "Re-run", Description: "Run plan again", Identifier: "replan"}},
+	})
+
+	// Other VCS hosts still get commit statuses.
+	gitlabRepo := models.Repo{VCSHost: models.VCSHost{Type: models.Gitlab}}
+	Ok(t, s.UpdateCombined(gitlabRepo, pull, models.PendingCommitStatus, models.PlanCommand))
+	client.VerifyWasCalledOnce().UpdateStatus(gitlabRepo, pull, models.PendingCommitStatus, "atlantis/plan", "Plan in progress...", "")
+}
+
+// Test that project check runs have the rendered result as their summary and
+// annotate Terraform's errors, except those outside the repo or without a
+// location.
+func TestDefaultCommitStatusUpdater_UpdateProjectResultChecks(t *testing.T) {
+	RegisterMockTestingT(t)
+	checks := mocks.NewMockChecksClient()
+	renderer := &events.MarkdownRenderer{}
+	s := events.DefaultCommitStatusUpdater{Client: mocks.NewMockClient(), StatusName: "atlantis", Checks: checks, MarkdownRenderer: renderer}
+	ctx := models.ProjectCommandContext{
+		BaseRepo:   models.Repo{VCSHost: models.VCSHost{Type: models.Github}},
+		Pull:       models.PullRequest{Num: 2},
+		RepoRelDir: "dir",
+		Workspace:  "default",
+		RePlanCmd:  "atlantis plan -d dir",
+	}
+	tfErr := `exit status 1: running "terraform plan" in "/tmp/dir":
+
+Error: Unsupported argument
+
+  on main.tf line 3, in resource "null_resource" "a":
+   3:   foo = "bar"
+
+An argument named "foo" is not expected here.
+
+Error: Missing required argument
+
+  on ../modules/a/main.tf line 1:
+
+Error: Invalid reference
+
+  on ../../outside/main.tf line 1:
+
+Error: No configuration files
+`
+	res := models.ProjectResult{RepoRelDir: "dir", Workspace: "default", Error: errors.New(tfErr)}
+	Ok(t, s.UpdateProjectResult(ctx, models.PlanCommand, res))
+
+	checks.VerifyWasCalledOnce().UpdateCheckRun(ctx.BaseRepo, ctx.Pull, vcs.CheckRun{
+		Name:       "atlantis/plan: dir/default",
+		Status:     models.FailedCommitStatus,
+		Title:      "Plan failed.",
+		Summary:    renderer.Render(events.CommandResult{ProjectResults: []models.ProjectResult{res}}, models.PlanCommand, "", false, models.Github),
+		ExternalID: "2:atlantis plan -d dir",
+		Annotations: []vcs.CheckRunAnnotation{{
+			Path:    "dir/main.tf",
+			Line:    3,
+			Title:   "Unsupported argument",
+			Message: "Unsupported argument\n\n  on main.tf line 3, in resource \"null_resource\" \"a\":\n   3:   foo = \"bar\"\n\nAn argument named \"foo\" is not expected here.",
+		}, {
+			Path:    "modules/a/main.tf",
+			Line:    1,
+			Title:   "Missing required argument",
+			Message: "Missing required argument\n\n  on ../modules/a/main.tf line 1:",
+		}},
+		Actions: []vcs.CheckRunAction{{Label: "Re-run", Description: "Run plan again", Identifier: "replan"}},
+	})
+}
+
+func TestParseCheckRunExternalID(t *testing.T) {
+	pullNum, comment, err := events.ParseCheckRunExternalID("2:atlantis plan -d dir:a")
+	Ok(t, err)
+	Equals(t, 2, pullNum)
+	Equals(t, "atlantis plan -d dir:a", comment)
+
+	_, _, err = events.ParseCheckRunExternalID("atlantis plan")
+	ErrEquals(t, "invalid check run external id \"atlantis plan\"", err)
+	_, _, err = events.ParseCheckRunExternalID("a:atlantis plan")
+	ErrContains(t, "invalid pull request number in check run external id \"a:atlantis plan\"", err)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,15 +18,25 @@ import (
 
 // BoltDB is a database using BoltDB
 type BoltDB struct {
-	db              *bolt.DB
-	locksBucketName []byte
-	pullsBucketName []byte
+	db                   *bolt.DB
+	locksBucketName      []byte
+	pullsBucketName      []byte
+	driftBucketName      []byte
+	driftReposBucketName []byte
+	historyBucketName    []byte
+	queuesBucketName     []byte
+	eventsBucketName     []byte
 }
 
 const (
-	locksBucketName  = "runLocks"
-	pullsBucketName  = "pulls"
-	pullKeySeparator = "::"
+	locksBucketName      = "runLocks"
+	pullsBucketName      = "pulls"
+	driftBucketName      = "drift"
+	driftReposBucketName = "driftRepos"
+	historyBucketName    = "history"
+	queuesBucketName     = "lockQueues"
+	eventsBucketName     = "events"
+	pullKeySeparator     = "::"
 )
 
 // New returns a valid locker. We need to be able to write to dataDir
@@ -50,24 +61,41 @@ func New(dataDir string) (*BoltDB, error) {
 		if _, err = tx.CreateBucketIfNotExists([]byte(pullsBucketName)); err != nil {
 			return errors.Wrapf(err, "creating bucket %q", pullsBucketName)
 		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(driftBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", driftBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(driftReposBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", driftReposBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(historyBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", historyBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(queuesBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", queuesBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(eventsBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", eventsBucketName)
+		}
 		return nil
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "starting BoltDB")
 	}
-	// todo: close BoltDB when server is sigtermed
-	return &BoltDB{db: db, locksBucketName: []byte(locksBucketName), pullsBucketName: []byte(pullsBucketName)}, nil
+	return &BoltDB{db: db, locksBucketName: []byte(locksBucketName), pullsBucketName: []byte(pullsBucketName), driftBucketName: []byte(driftBucketName), driftReposBucketName: []byte(driftReposBucketName), historyBucketName: []byte(historyBucketName), queuesBucketName: []byte(queuesBucketName), eventsBucketName: []byte(eventsBucketName)}, nil
 }
 
 // NewWithDB is used for testing.
 func NewWithDB(db *bolt.DB, bucket string) (*BoltDB, error) {
-	return &BoltDB{db: db, locksBucketName: []byte(bucket), pullsBucketName: []byte(pullsBucketName)}, nil
+	return &BoltDB{db: db, locksBucketName: []byte(bucket), pullsBucketName: []byte(pullsBucketName), driftBucketName: []byte(driftBucketName), driftReposBucketName: []byte(driftReposBucketName), historyBucketName: []byte(historyBucketName), queuesBucketName: []byte(queuesBucketName), eventsBucketName: []byte(eventsBucketName)}, nil
 }
 
 // TryLock attempts to create a new lock. If the lock is
-// acquired, it will return true and the lock returned will be newLock.
+// acquired, it will return true and the lock returned will be newLock. The
+// lock's pull request is also removed from the lock's queue.
 // If the lock is not acquired, it will return false and the current
-// lock that is preventing this lock from being acquired.
+// lock that is preventing this lock from being acquired. If that lock is held
+// by newLock's pull request, its expiry is updated to newLock's since the
+// pull request is still using it.
 func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock, error) {
 	var lockAcquired bool
 	var currLock models.ProjectLock
@@ -83,7 +111,9 @@ func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 			bucket.Put([]byte(key), newLockSerialized) // nolint: errcheck
 			lockAcquired = true
 			currLock = newLock
-			return nil
+			return b.updateQueue(tx, key, func(queue []models.ProjectLock) []models.ProjectLock {
+				return removeQueuedPull(queue, newLock.Project.RepoFullName, newLock.Pull.Num)
+			})
 		}
 
 		// otherwise the lock fails, return to caller the run that's holding the lock
@@ -91,6 +121,12 @@ func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 			return errors.Wrap(err, "failed to deserialize current lock")
 		}
 		lockAcquired = false
+		if currLock.Pull.Num == newLock.Pull.Num && !currLock.ExpiresAt.Equal(newLock.ExpiresAt) {
+			currLock.ExpiresAt = newLock.ExpiresAt
+			refreshedLockSerialized, _ := json.Marshal(currLock)
+			// This will only error on readonly buckets, it's okay to ignore.
+			bucket.Put([]byte(key), refreshedLockSerialized) // nolint: errcheck
+		}
 		return nil
 	})
 
@@ -127,6 +163,34 @@ func (b *BoltDB) Unlock(p models.Project, workspace string) (*models.ProjectLock
 	return nil, err
 }
 
+// UnlockIfExpired deletes the lock for project and workspace if it has
+// expired by now. It returns the deleted lock or nil if there's no lock or it
+// hasn't expired.
+func (b *BoltDB) UnlockIfExpired(p models.Project, workspace string, now time.Time) (*models.ProjectLock, error) {
+	var deleted *models.ProjectLock
+	key := b.lockKey(p, workspace)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.locksBucketName)
+		serialized := bucket.Get([]byte(key))
+		if serialized == nil {
+			return nil
+		}
+		var lock models.ProjectLock
+		if err := json.Unmarshal(serialized, &lock); err != nil {
+			return errors.Wrap(err, "failed to deserialize lock")
+		}
+		if !lock.Expired(now) {
+			return nil
+		}
+		deleted = &lock
+		return bucket.Delete([]byte(key))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "DB transaction failed")
+	}
+	return deleted, nil
+}
+
 // List lists all current locks.
 func (b *BoltDB) List() ([]models.ProjectLock, error) {
 	var locks []models.ProjectLock
@@ -147,7 +211,7 @@ func (b *BoltDB) List() ([]models.ProjectLock, error) {
 	for k, v := range locksBytes {
 		var lock models.ProjectLock
 		if err := json.Unmarshal(v, &lock); err != nil {
-			return locks, errors.Wrap(err, fmt.Sprintf("failed to deserialize lock at key %q", string(k)))
+			return locks, errors.Wrapf(err, "failed to deserialize lock at index %d", k)
 		}
 		locks = append(locks, lock)
 	}
@@ -155,35 +219,163 @@ func (b *BoltDB) List() ([]models.ProjectLock, error) {
 	return locks, nil
 }
 
-// UnlockByPull deletes all locks associated with that pull request and returns them.
+// UnlockByPull deletes all locks associated with that pull request and returns
+// them. The pull request is also removed from the queue of every lock.
 func (b *BoltDB) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
 	var locks []models.ProjectLock
-	err := b.db.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket(b.locksBucketName).Cursor()
+	// The locks are found and deleted in one transaction so that we don't
+	// delete a lock that another pull request took in between.
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.locksBucketName)
+		c := bucket.Cursor()
 
 		// we can use the repoFullName as a prefix search since that's the first part of the key
+		var keys [][]byte
 		for k, v := c.Seek([]byte(repoFullName)); k != nil && bytes.HasPrefix(k, []byte(repoFullName)); k, v = c.Next() {
 			var lock models.ProjectLock
 			if err := json.Unmarshal(v, &lock); err != nil {
 				return errors.Wrapf(err, "deserializing lock at key %q", string(k))
 			}
-			if lock.Pull.Num == pullNum {
+			if lock.Project.RepoFullName == repoFullName && lock.Pull.Num == pullNum {
 				locks = append(locks, lock)
+				keys = append(keys, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
 			}
 		}
 		return nil
 	})
 	if err != nil {
-		return locks, err
+		return nil, errors.Wrap(err, "DB transaction failed")
 	}
 
-	// delete the locks
-	for _, lock := range locks {
-		if _, err = b.Unlock(lock.Project, lock.Workspace); err != nil {
-			return locks, errors.Wrapf(err, "unlocking repo %s, path %s, workspace %s", lock.Project.RepoFullName, lock.Project.Path, lock.Workspace)
+	// Remove the pull from the queues.
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.queuesBucketName).Cursor()
+		var keys [][]byte
+		for k, _ := c.Seek([]byte(repoFullName)); k != nil && bytes.HasPrefix(k, []byte(repoFullName)); k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
 		}
+		for _, k := range keys {
+			err := b.updateQueue(tx, string(k), func(queue []models.ProjectLock) []models.ProjectLock {
+				return removeQueuedPull(queue, repoFullName, pullNum)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return locks, errors.Wrap(err, "DB transaction failed")
+}
+
+// Enqueue adds lock's pull request to the end of the queue of pull requests
+// waiting for the lock for lock's project and workspace. If the pull request
+// is already queued, it keeps its place. It returns the pull request's
+// position in the queue, starting at 1.
+func (b *BoltDB) Enqueue(lock models.ProjectLock) (int, error) {
+	var position int
+	key := b.lockKey(lock.Project, lock.Workspace)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return b.updateQueue(tx, key, func(queue []models.ProjectLock) []models.ProjectLock {
+			for i, q := range queue {
+				if q.Pull.Num == lock.Pull.Num {
+					position = i + 1
+					return queue
+				}
+			}
+			position = len(queue) + 1
+			return append(queue, lock)
+		})
+	})
+	return position, errors.Wrap(err, "DB transaction failed")
+}
+
+// Dequeue removes the first pull request from the queue for the lock for
+// project and workspace and returns it. If the queue is empty, it returns a
+// nil pointer.
+func (b *BoltDB) Dequeue(p models.Project, workspace string) (*models.ProjectLock, error) {
+	var next *models.ProjectLock
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return b.updateQueue(tx, b.lockKey(p, workspace), func(queue []models.ProjectLock) []models.ProjectLock {
+			if len(queue) == 0 {
+				return queue
+			}
+			next = &queue[0]
+			return queue[1:]
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "DB transaction failed")
 	}
-	return locks, nil
+	if next != nil {
+		next.Time = next.Time.Local()
+	}
+	return next, nil
+}
+
+// GetQueue returns the pull requests waiting for the lock for project and
+// workspace, in the order they'll get it.
+func (b *BoltDB) GetQueue(p models.Project, workspace string) ([]models.ProjectLock, error) {
+	var queue []models.ProjectLock
+	err := b.db.View(func(tx *bolt.Tx) error {
+		var err error
+		queue, err = b.getQueue(tx, b.lockKey(p, workspace))
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "DB transaction failed")
+	}
+	for i := range queue {
+		queue[i].Time = queue[i].Time.Local()
+	}
+	return queue, nil
+}
+
+func (b *BoltDB) getQueue(tx *bolt.Tx, key string) ([]models.ProjectLock, error) {
+	serialized := tx.Bucket(b.queuesBucketName).Get([]byte(key))
+	if serialized == nil {
+		return nil, nil
+	}
+	var queue []models.ProjectLock
+	if err := json.Unmarshal(serialized, &queue); err != nil {
+		return nil, errors.Wrapf(err, "deserializing queue at key %q", key)
+	}
+	return queue, nil
+}
+
+// updateQueue replaces the queue at key with the result of calling fn with
+// the current queue. Empty queues are deleted.
+func (b *BoltDB) updateQueue(tx *bolt.Tx, key string, fn func([]models.ProjectLock) []models.ProjectLock) error {
+	queue, err := b.getQueue(tx, key)
+	if err != nil {
+		return err
+	}
+	queue = fn(queue)
+	bucket := tx.Bucket(b.queuesBucketName)
+	if len(queue) == 0 {
+		return bucket.Delete([]byte(key))
+	}
+	serialized, err := json.Marshal(queue)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	return bucket.Put([]byte(key), serialized)
+}
+
+// removeQueuedPull returns queue without the entries for pull pullNum of
+// repoFullName.
+func removeQueuedPull(queue []models.ProjectLock, repoFullName string, pullNum int) []models.ProjectLock {
+	var kept []models.ProjectLock
+	for _, q := range queue {
+		if q.Project.RepoFullName != repoFullName || q.Pull.Num != pullNum {
+			kept = append(kept, q)
+		}
+	}
+	return kept
 }
 
 // GetLock returns a pointer to the lock for that project and workspace.
@@ -260,6 +452,9 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []mod
 						res.ProjectName == proj.ProjectName {
 
 						proj.Status = res.PlanStatus()
+						if res.PlanSuccess != nil {
+							proj.PlanMetadata = res.PlanSuccess.Metadata
+						}
 						updatedExisting = true
 						break
 					}
@@ -344,6 +539,289 @@ func (b *BoltDB) DeleteProjectStatus(pull models.PullRequest, workspace string,
 	return errors.Wrap(err, "DB transaction failed")
 }
 
+// UpdateDriftStatus stores the result of checking a project for drift,
+// overwriting the previous result for that project.
+func (b *BoltDB) UpdateDriftStatus(status models.DriftStatus) error {
+	key, err := b.driftKey(status)
+	if err != nil {
+		return err
+	}
+	serialized, err := json.Marshal(status)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.driftBucketName)
+		return bucket.Put(key, serialized)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// GetDriftStatuses returns the latest drift status of every project that has
+// been checked for drift.
+func (b *BoltDB) GetDriftStatuses() ([]models.DriftStatus, error) {
+	var statuses []models.DriftStatus
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.driftBucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var s models.DriftStatus
+			if err := json.Unmarshal(v, &s); err != nil {
+				return errors.Wrapf(err, "deserializing drift status at key %q", string(k))
+			}
+			s.LastChecked = s.LastChecked.Local()
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, errors.Wrap(err, "DB transaction failed")
+}
+
+// UpdateDriftRepo stores repo so that it can be checked for drift,
+// overwriting the previously stored repo with the same ID.
+func (b *BoltDB) UpdateDriftRepo(repo models.Repo) error {
+	serialized, err := json.Marshal(repo)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.driftReposBucketName)
+		return bucket.Put([]byte(repo.ID()), serialized)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// GetDriftRepos returns the repos stored by UpdateDriftRepo.
+func (b *BoltDB) GetDriftRepos() ([]models.Repo, error) {
+	var repos []models.Repo
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.driftReposBucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var repo models.Repo
+			if err := json.Unmarshal(v, &repo); err != nil {
+				return errors.Wrapf(err, "deserializing drift repo at key %q", string(k))
+			}
+			repos = append(repos, repo)
+		}
+		return nil
+	})
+	return repos, errors.Wrap(err, "DB transaction failed")
+}
+
+// AddProjectRuns adds runs to the history of pull.
+func (b *BoltDB) AddProjectRuns(pull models.PullRequest, runs []models.ProjectRun) error {
+	prefix, err := b.pullKey(pull)
+	if err != nil {
+		return err
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.historyBucketName)
+		for _, run := range runs {
+			serialized, err := json.Marshal(run)
+			if err != nil {
+				return errors.Wrap(err, "serializing")
+			}
+			// The sequence keeps runs in the order they were added when we
+			// iterate over the pull's keys.
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			key := []byte(fmt.Sprintf("%s%s%020d", prefix, pullKeySeparator, seq))
+			if err := bucket.Put(key, serialized); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// GetProjectRuns returns the history of pull, oldest run first.
+func (b *BoltDB) GetProjectRuns(pull models.PullRequest) ([]models.ProjectRun, error) {
+	key, err := b.pullKey(pull)
+	if err != nil {
+		return nil, err
+	}
+	prefix := append(key, []byte(pullKeySeparator)...)
+
+	var runs []models.ProjectRun
+	err = b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.historyBucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var run models.ProjectRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				return errors.Wrapf(err, "deserializing run at key %q", string(k))
+			}
+			run.StartedAt = run.StartedAt.Local()
+			run.FinishedAt = run.FinishedAt.Local()
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	return runs, errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteProjectRunsBefore deletes the runs of every pull request that
+// finished before t. It returns how many runs were deleted.
+func (b *BoltDB) DeleteProjectRunsBefore(t time.Time) (int, error) {
+	var deleted int
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.historyBucketName)
+		var toDelete [][]byte
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var run models.ProjectRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				return errors.Wrapf(err, "deserializing run at key %q", string(k))
+			}
+			if run.FinishedAt.Before(t) {
+				toDelete = append(toDelete, append([]byte{}, k...))
+			}
+		}
+		// Keys can't be deleted while iterating with a cursor.
+		for _, k := range toDelete {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(toDelete)
+		return nil
+	})
+	return deleted, errors.Wrap(err, "DB transaction failed")
+}
+
+// EnqueueEvent adds event to the event queue. If an event with the same ID has
+// already been received, it isn't added and EnqueueEvent returns false.
+func (b *BoltDB) EnqueueEvent(event models.QueuedEvent) (bool, error) {
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return false, errors.Wrap(err, "serializing")
+	}
+	added := false
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.eventsBucketName)
+		if bucket.Get([]byte(event.ID)) != nil {
+			return nil
+		}
+		added = true
+		return bucket.Put([]byte(event.ID), serialized)
+	})
+	return added, errors.Wrap(err, "DB transaction failed")
+}
+
+// GetEvents returns the events in the event queue, oldest first.
+func (b *BoltDB) GetEvents() ([]models.QueuedEvent, error) {
+	var events []models.QueuedEvent
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.eventsBucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var event models.QueuedEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return errors.Wrapf(err, "deserializing event at key %q", string(k))
+			}
+			event.ReceivedAt = event.ReceivedAt.Local()
+			events = append(events, event)
+		}
+		return nil
+	})
+	// Events are keyed by ID so we need to sort them.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ReceivedAt.Before(events[j].ReceivedAt)
+	})
+	return events, errors.Wrap(err, "DB transaction failed")
+}
+
+// UpdateEvent replaces the event with the same ID as event.
+func (b *BoltDB) UpdateEvent(event models.QueuedEvent) error {
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.eventsBucketName).Put([]byte(event.ID), serialized)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// StartEvent marks the pending event with id as running, increments its
+// attempts and sets its heartbeat. It returns the updated event or nil if the
+// event isn't pending.
+func (b *BoltDB) StartEvent(id string) (*models.QueuedEvent, error) {
+	var event *models.QueuedEvent
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.eventsBucketName)
+		serialized := bucket.Get([]byte(id))
+		if serialized == nil {
+			return nil
+		}
+		var e models.QueuedEvent
+		if err := json.Unmarshal(serialized, &e); err != nil {
+			return errors.Wrapf(err, "deserializing event at key %q", id)
+		}
+		if e.Status != models.PendingEventStatus {
+			return nil
+		}
+		e.Status = models.RunningEventStatus
+		e.Attempts++
+		e.HeartbeatAt = time.Now()
+		serialized, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		if err := bucket.Put([]byte(id), serialized); err != nil {
+			return err
+		}
+		e.ReceivedAt = e.ReceivedAt.Local()
+		event = &e
+		return nil
+	})
+	return event, errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteEvent deletes the event with id from the event queue.
+func (b *BoltDB) DeleteEvent(id string) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.eventsBucketName).Delete([]byte(id))
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteProcessedEventsBefore deletes the processed events that were received
+// before t. It returns how many were deleted.
+func (b *BoltDB) DeleteProcessedEventsBefore(t time.Time) (int, error) {
+	var deleted int
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.eventsBucketName)
+		var toDelete [][]byte
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var event models.QueuedEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return errors.Wrapf(err, "deserializing event at key %q", string(k))
+			}
+			if event.Status == models.ProcessedEventStatus && event.ReceivedAt.Before(t) {
+				toDelete = append(toDelete, append([]byte{}, k...))
+			}
+		}
+		// Keys can't be deleted while iterating with a cursor.
+		for _, k := range toDelete {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(toDelete)
+		return nil
+	})
+	return deleted, errors.Wrap(err, "DB transaction failed")
+}
+
+// Close closes the database, waiting for any transactions in progress to
+// finish. It releases the lock on the database file so that another Atlantis
+// can open it.
+func (b *BoltDB) Close() error {
+	return b.db.Close()
+}
+
 func (b *BoltDB) pullKey(pull models.PullRequest) ([]byte, error) {
 	hostname := pull.BaseRepo.VCSHost.Hostname
 	if strings.Contains(hostname, pullKeySeparator) {
@@ -358,6 +836,15 @@ func (b *BoltDB) pullKey(pull models.PullRequest) ([]byte, error) {
 		nil
 }
 
+func (b *BoltDB) driftKey(status models.DriftStatus) ([]byte, error) {
+	for _, s := range []string{status.VCSHostname, status.RepoFullName, status.RepoRelDir, status.Workspace, status.ProjectName} {
+		if strings.Contains(s, pullKeySeparator) {
+			return nil, fmt.Errorf("%q contains illegal string %q", s, pullKeySeparator)
+		}
+	}
+	return []byte(fmt.Sprintf("%s::%s::%s::%s::%s", status.VCSHostname, status.RepoFullName, status.RepoRelDir, status.Workspace, status.ProjectName)), nil
+}
+
 func (b *BoltDB) lockKey(p models.Project, workspace string) string {
 	return fmt.Sprintf("%s/%s/%s", p.RepoFullName, p.Path, workspace)
 }
@@ -384,10 +871,14 @@ func (b *BoltDB) writePullToBucket(bucket *bolt.Bucket, key []byte, pull models.
 }
 
 func (b *BoltDB) projectResultToProject(p models.ProjectResult) models.ProjectStatus {
-	return models.ProjectStatus{
+	status := models.ProjectStatus{
 		Workspace:   p.Workspace,
 		RepoRelDir:  p.RepoRelDir,
 		ProjectName: p.ProjectName,
 		Status:      p.PlanStatus(),
 	}
+	if p.PlanSuccess != nil {
+		status.PlanMetadata = p.PlanSuccess.Metadata
+	}
+	return status
 }
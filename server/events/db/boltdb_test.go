@@ -168,6 +168,63 @@ func TestLockingExistingLock(t *testing.T) {
 	}
 }
 
+func TestLockingRefreshesExpiry(t *testing.T) {
+	t.Log("locking again from the pull request holding the lock should update its expiry")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	firstLock := lock
+	firstLock.ExpiresAt = time.Now().Add(time.Hour)
+	_, _, err := b.TryLock(firstLock)
+	Ok(t, err)
+
+	relock := firstLock
+	relock.Time = time.Now().Add(time.Minute)
+	relock.ExpiresAt = relock.Time.Add(time.Hour)
+	acquired, currLock, err := b.TryLock(relock)
+	Ok(t, err)
+	Equals(t, false, acquired)
+	Assert(t, currLock.ExpiresAt.Equal(relock.ExpiresAt), "exp expiry %s, got %s", relock.ExpiresAt, currLock.ExpiresAt)
+	Assert(t, currLock.Time.Equal(firstLock.Time), "exp lock time to be unchanged, got %s", currLock.Time)
+
+	t.Log("...but not from another pull request")
+	otherPull := relock
+	otherPull.Pull.Num = pullNum + 1
+	otherPull.ExpiresAt = relock.ExpiresAt.Add(time.Hour)
+	_, _, err = b.TryLock(otherPull)
+	Ok(t, err)
+	storedLock, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	Assert(t, storedLock.ExpiresAt.Equal(relock.ExpiresAt), "exp expiry %s, got %s", relock.ExpiresAt, storedLock.ExpiresAt)
+}
+
+func TestUnlockIfExpired(t *testing.T) {
+	t.Log("UnlockIfExpired should only delete the lock if it has expired")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	expiring := lock
+	expiring.ExpiresAt = time.Now().Add(time.Hour)
+	_, _, err := b.TryLock(expiring)
+	Ok(t, err)
+
+	deleted, err := b.UnlockIfExpired(project, workspace, time.Now())
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp lock that hasn't expired not to be deleted")
+	_, err = b.GetLock(project, workspace)
+	Ok(t, err)
+
+	deleted, err = b.UnlockIfExpired(project, workspace, expiring.ExpiresAt.Add(time.Second))
+	Ok(t, err)
+	Equals(t, pullNum, deleted.Pull.Num)
+	l, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	Assert(t, l == nil, "exp expired lock to be deleted")
+
+	t.Log("...and should succeed when there's no lock")
+	deleted, err = b.UnlockIfExpired(project, workspace, time.Now())
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp no lock to be deleted")
+}
+
 func TestUnlockingNoLocks(t *testing.T) {
 	t.Log("unlocking with no locks should succeed")
 	db, b := newTestDB()
@@ -328,6 +385,56 @@ func TestUnlockByPullMatching(t *testing.T) {
 	Equals(t, 0, len(ls))
 }
 
+func TestLockQueue(t *testing.T) {
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	queued := func(nums ...int) {
+		t.Helper()
+		queue, err := b.GetQueue(project, workspace)
+		Ok(t, err)
+		var actNums []int
+		for _, q := range queue {
+			actNums = append(actNums, q.Pull.Num)
+		}
+		Equals(t, nums, actNums)
+	}
+	withPull := func(num int) models.ProjectLock {
+		l := lock
+		l.Pull.Num = num
+		return l
+	}
+
+	// Pulls are queued in order and keep their place if queued again.
+	for _, num := range []int{2, 3, 2} {
+		_, err := b.Enqueue(withPull(num))
+		Ok(t, err)
+	}
+	pos, err := b.Enqueue(withPull(4))
+	Ok(t, err)
+	Equals(t, 3, pos)
+	queued(2, 3, 4)
+
+	// Dequeue returns the first pull.
+	next, err := b.Dequeue(project, workspace)
+	Ok(t, err)
+	Equals(t, 2, next.Pull.Num)
+	queued(3, 4)
+
+	// Acquiring the lock removes the pull from the queue.
+	acquired, _, err := b.TryLock(withPull(4))
+	Ok(t, err)
+	Assert(t, acquired, "exp lock to be acquired")
+	queued(3)
+
+	// Unlocking by pull removes it from the queue.
+	_, err = b.UnlockByPull(project.RepoFullName, 3)
+	Ok(t, err)
+	queued()
+	next, err = b.Dequeue(project, workspace)
+	Ok(t, err)
+	Equals(t, (*models.ProjectLock)(nil), next)
+}
+
 func TestGetLockNotThere(t *testing.T) {
 	t.Log("getting a lock that doesn't exist should return a nil pointer")
 	db, b := newTestDB()
@@ -623,6 +730,11 @@ func TestPullStatus_UpdateMerge(t *testing.T) {
 					LockURL:         "lock-url",
 					RePlanCmd:       "plan command",
 					ApplyCmd:        "apply command",
+					Metadata: &models.PlanMetadata{
+						HeadCommit: "sha",
+						Time:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+						User:       "lkysow",
+					},
 				},
 			},
 		})
@@ -675,6 +787,11 @@ func TestPullStatus_UpdateMerge(t *testing.T) {
 				RepoRelDir: "staythesame",
 				Workspace:  "default",
 				Status:     models.PlannedPlanStatus,
+				PlanMetadata: &models.PlanMetadata{
+					HeadCommit: "sha",
+					Time:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+					User:       "lkysow",
+				},
 			},
 			{
 				RepoRelDir: "newresult",
@@ -686,6 +803,247 @@ func TestPullStatus_UpdateMerge(t *testing.T) {
 }
 
 // newTestDB returns a TestDB using a temporary path.
+func TestDriftStatus_UpdateGet(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+
+	statuses, err := b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	status := models.DriftStatus{
+		RepoFullName: "runatlantis/atlantis",
+		VCSHostname:  "github.com",
+		RepoRelDir:   ".",
+		Workspace:    "default",
+		Drifted:      true,
+		LastChecked:  time.Now(),
+	}
+	Ok(t, b.UpdateDriftStatus(status))
+	other := status
+	other.RepoRelDir = "staging"
+	Ok(t, b.UpdateDriftStatus(other))
+
+	// Updating the same project again should overwrite the old status.
+	status.Drifted = false
+	status.Error = "err"
+	Ok(t, b.UpdateDriftStatus(status))
+
+	statuses, err = b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	Equals(t, ".", statuses[0].RepoRelDir)
+	Equals(t, false, statuses[0].Drifted)
+	Equals(t, "err", statuses[0].Error)
+	Assert(t, status.LastChecked.Equal(statuses[0].LastChecked), "exp %s got %s", status.LastChecked, statuses[0].LastChecked)
+	Equals(t, "staging", statuses[1].RepoRelDir)
+	Equals(t, true, statuses[1].Drifted)
+}
+
+func TestDriftStatus_InvalidKey(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+
+	err := b.UpdateDriftStatus(models.DriftStatus{
+		RepoFullName: "runatlantis/atlantis",
+		VCSHostname:  "github.com",
+		RepoRelDir:   "bad::dir",
+		Workspace:    "default",
+	})
+	ErrEquals(t, "\"bad::dir\" contains illegal string \"::\"", err)
+}
+
+func TestDriftRepos_UpdateGet(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+
+	repos, err := b.GetDriftRepos()
+	Ok(t, err)
+	Equals(t, 0, len(repos))
+
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+	Ok(t, b.UpdateDriftRepo(repo))
+	// Updating the same repo again should overwrite it.
+	updated, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "new-token")
+	Ok(t, err)
+	Ok(t, b.UpdateDriftRepo(updated))
+
+	repos, err = b.GetDriftRepos()
+	Ok(t, err)
+	Equals(t, []models.Repo{updated}, repos)
+}
+
+func TestProjectRuns_AddGetDelete(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num: 1,
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost:  models.VCSHost{Hostname: "github.com"},
+		},
+	}
+	otherPull := pull
+	otherPull.Num = 10
+
+	runs, err := b.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 0, len(runs))
+
+	old := time.Now().Add(-2 * time.Hour)
+	now := time.Now()
+	Ok(t, b.AddProjectRuns(pull, []models.ProjectRun{
+		{
+			Command:    models.PlanCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Status:     models.PlannedPlanStatus,
+			Output:     "plan output",
+			User:       "lkysow",
+			HeadCommit: "sha",
+			StartedAt:  old,
+			FinishedAt: old,
+		},
+	}))
+	Ok(t, b.AddProjectRuns(pull, []models.ProjectRun{
+		{
+			Command:    models.ApplyCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Status:     models.AppliedPlanStatus,
+			Output:     "apply output",
+			StartedAt:  now,
+			FinishedAt: now,
+		},
+	}))
+	Ok(t, b.AddProjectRuns(otherPull, []models.ProjectRun{
+		{
+			Command:    models.PlanCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			StartedAt:  old,
+			FinishedAt: old,
+		},
+	}))
+
+	runs, err = b.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 2, len(runs))
+	Equals(t, models.PlanCommand, runs[0].Command)
+	Equals(t, "plan output", runs[0].Output)
+	Equals(t, "lkysow", runs[0].User)
+	Equals(t, "sha", runs[0].HeadCommit)
+	Assert(t, old.Equal(runs[0].StartedAt), "exp %s got %s", old, runs[0].StartedAt)
+	Equals(t, models.ApplyCommand, runs[1].Command)
+	Equals(t, models.AppliedPlanStatus, runs[1].Status)
+
+	deleted, err := b.DeleteProjectRunsBefore(time.Now().Add(-time.Hour))
+	Ok(t, err)
+	Equals(t, 2, deleted)
+	runs, err = b.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 1, len(runs))
+	Equals(t, "apply output", runs[0].Output)
+	runs, err = b.GetProjectRuns(otherPull)
+	Ok(t, err)
+	Equals(t, 0, len(runs))
+}
+
+func TestEvents(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+
+	events, err := b.GetEvents()
+	Ok(t, err)
+	Equals(t, 0, len(events))
+
+	old := time.Now().Add(-2 * time.Hour)
+	first := models.QueuedEvent{
+		ID:         "X-Github-Delivery=1",
+		Status:     models.PendingEventStatus,
+		Autoplan:   true,
+		BaseRepo:   models.Repo{FullName: "runatlantis/atlantis"},
+		PullNum:    1,
+		ReceivedAt: old,
+	}
+	second := models.QueuedEvent{
+		ID:     "X-Github-Delivery=2",
+		Status: models.PendingEventStatus,
+		Comment: &models.QueuedComment{
+			Name:       models.ApplyCommand,
+			RepoRelDir: "dir",
+			Flags:      []string{"-target=resource"},
+		},
+		PullNum:    2,
+		ReceivedAt: time.Now(),
+	}
+	// The second event is added first to check that they're returned in the
+	// order they were received.
+	added, err := b.EnqueueEvent(second)
+	Ok(t, err)
+	Assert(t, added, "exp event to be added")
+	added, err = b.EnqueueEvent(first)
+	Ok(t, err)
+	Assert(t, added, "exp event to be added")
+
+	// Redeliveries are ignored.
+	redelivered := first
+	redelivered.PullNum = 10
+	added, err = b.EnqueueEvent(redelivered)
+	Ok(t, err)
+	Assert(t, !added, "exp redelivered event not to be added")
+
+	events, err = b.GetEvents()
+	Ok(t, err)
+	Equals(t, 2, len(events))
+	Equals(t, first.ID, events[0].ID)
+	Equals(t, 1, events[0].PullNum)
+	Assert(t, old.Equal(events[0].ReceivedAt), "exp %s got %s", old, events[0].ReceivedAt)
+	Equals(t, second.ID, events[1].ID)
+	Equals(t, *second.Comment, *events[1].Comment)
+
+	// Only pending events can be started.
+	started, err := b.StartEvent(first.ID)
+	Ok(t, err)
+	Equals(t, models.RunningEventStatus, started.Status)
+	Equals(t, 1, started.Attempts)
+	Assert(t, time.Since(started.HeartbeatAt) < time.Minute, "exp heartbeat to be set, got %s", started.HeartbeatAt)
+	started, err = b.StartEvent(first.ID)
+	Ok(t, err)
+	Assert(t, started == nil, "exp running event not to be started")
+	started, err = b.StartEvent("unknown")
+	Ok(t, err)
+	Assert(t, started == nil, "exp unknown event not to be started")
+
+	first.Status = models.ProcessedEventStatus
+	first.Attempts = 1
+	Ok(t, b.UpdateEvent(first))
+	second.Status = models.FailedEventStatus
+	second.Error = "err"
+	Ok(t, b.UpdateEvent(second))
+	events, err = b.GetEvents()
+	Ok(t, err)
+	Equals(t, models.ProcessedEventStatus, events[0].Status)
+	Equals(t, 1, events[0].Attempts)
+	Equals(t, models.FailedEventStatus, events[1].Status)
+	Equals(t, "err", events[1].Error)
+
+	// Only old processed events are deleted.
+	deleted, err := b.DeleteProcessedEventsBefore(time.Now().Add(-time.Hour))
+	Ok(t, err)
+	Equals(t, 1, deleted)
+	deleted, err = b.DeleteProcessedEventsBefore(time.Now())
+	Ok(t, err)
+	Equals(t, 0, deleted)
+
+	Ok(t, b.DeleteEvent(second.ID))
+	events, err = b.GetEvents()
+	Ok(t, err)
+	Equals(t, 0, len(events))
+}
+
 func newTestDB() (*bolt.DB, *db.BoltDB) {
 	// Retrieve a temporary path.
 	f, err := ioutil.TempFile("", "")
@@ -704,6 +1062,9 @@ func newTestDB() (*bolt.DB, *db.BoltDB) {
 		if _, err := tx.CreateBucketIfNotExists([]byte(lockBucket)); err != nil {
 			return errors.Wrap(err, "failed to create bucket")
 		}
+		if _, err := tx.CreateBucketIfNotExists([]byte("lockQueues")); err != nil {
+			return errors.Wrap(err, "failed to create bucket")
+		}
 		return nil
 	}); err != nil {
 		panic(errors.Wrap(err, "could not create bucket"))
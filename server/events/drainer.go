@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Drainer tracks the operations in progress, ex. running plans and applies, so
+// that Atlantis can wait for them to finish when it's shut down instead of
+// abandoning them halfway. Its zero value is ready to use.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	ops      int
+	// drained is closed when the last operation finishes while draining.
+	drained chan struct{}
+}
+
+// StartOp registers a new operation. It returns false if Atlantis is shutting
+// down, in which case the operation must not be started. Otherwise callers
+// must call OpDone once it has finished. If d is nil, operations aren't
+// tracked.
+func (d *Drainer) StartOp() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.ops++
+	return true
+}
+
+// OpDone marks an operation started with StartOp as finished.
+func (d *Drainer) OpDone() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ops--
+	if d.ops == 0 && d.drained != nil {
+		close(d.drained)
+		d.drained = nil
+	}
+}
+
+// Draining returns true once Drain has been called.
+func (d *Drainer) Draining() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Drain stops new operations from starting and waits until the ones in
+// progress have finished or ctx is done. It returns how many are still in
+// progress.
+func (d *Drainer) Drain(ctx context.Context) int {
+	d.mu.Lock()
+	d.draining = true
+	drained := make(chan struct{})
+	if d.ops == 0 {
+		close(drained)
+	} else {
+		d.drained = drained
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ops
+}
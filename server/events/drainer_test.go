@@ -0,0 +1,51 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDrainer_NoOps(t *testing.T) {
+	d := &events.Drainer{}
+	Equals(t, 0, d.Drain(context.Background()))
+	Assert(t, d.Draining(), "exp draining")
+	Assert(t, !d.StartOp(), "exp no new ops while draining")
+}
+
+// Test that Drain waits for the ops in progress.
+func TestDrainer_WaitsForOps(t *testing.T) {
+	d := &events.Drainer{}
+	Assert(t, d.StartOp(), "exp op to start")
+	Assert(t, d.StartOp(), "exp op to start")
+	d.OpDone()
+
+	drained := make(chan int)
+	go func() {
+		drained <- d.Drain(context.Background())
+	}()
+	// Wait until draining has started.
+	for !d.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	Assert(t, !d.StartOp(), "exp no new ops while draining")
+	select {
+	case <-drained:
+		t.Fatal("exp Drain to wait for the op in progress")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	d.OpDone()
+	Equals(t, 0, <-drained)
+}
+
+func TestDrainer_Timeout(t *testing.T) {
+	d := &events.Drainer{}
+	Assert(t, d.StartOp(), "exp op to start")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	Equals(t, 1, d.Drain(ctx))
+}
@@ -0,0 +1,310 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// driftExitCode is the exit code of `terraform plan -detailed-exitcode` when
+// the plan succeeded and there are changes.
+const driftExitCode = 2
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_drift_webhooks_sender.go DriftWebhooksSender
+
+// DriftWebhooksSender sends webhooks when drift is detected or resolved.
+type DriftWebhooksSender interface {
+	// SendDrift sends the webhook.
+	SendDrift(log *logging.SimpleLogger, res webhooks.DriftResult) error
+}
+
+// DriftDetector periodically plans the default branch of each repo that has
+// drift_detection enabled in the server-side repo config. A project has
+// drifted if its plan has changes, ex. because someone modified the
+// infrastructure outside of Atlantis.
+type DriftDetector struct {
+	// GlobalCfg is the server-side repo config. It determines which repos we
+	// check.
+	GlobalCfg valid.GlobalCfg
+	// VCSHostTypes are the VCS hosts Atlantis is configured for. They're used
+	// to look up the repos by their ID.
+	VCSHostTypes    []models.VCSHostType
+	EventParser     EventParsing
+	WorkingDir      WorkingDir
+	ProjectFinder   ProjectFinder
+	ParserValidator *yaml.ParserValidator
+	InitStepRunner  StepRunner
+	PlanStepRunner  StepRunner
+	RunStepRunner   CustomStepRunner
+	EnvStepRunner   EnvStepRunner
+	DB              locking.Backend
+	Webhooks        DriftWebhooksSender
+	Logger          *logging.SimpleLogger
+	// Interval is how long we wait between checks.
+	Interval time.Duration
+}
+
+// Run checks for drift immediately and then every Interval until stop is
+// closed.
+func (d *DriftDetector) Run(stop <-chan struct{}) {
+	d.Logger.Info("drift detection enabled, checking every %s", d.Interval)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		d.DetectDrift()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DetectDrift checks every project of each drift detection enabled repo once
+// and records the results.
+func (d *DriftDetector) DetectDrift() {
+	prevStatuses, err := d.DB.GetDriftStatuses()
+	if err != nil {
+		d.Logger.Err("unable to get previous drift statuses: %s", err)
+		return
+	}
+	prev := make(map[string]models.DriftStatus)
+	for _, s := range prevStatuses {
+		prev[d.statusKey(s)] = s
+	}
+
+	storedRepos, err := d.DB.GetDriftRepos()
+	if err != nil {
+		d.Logger.Err("unable to get repos to check for drift: %s", err)
+		return
+	}
+	stored := make(map[string]models.Repo)
+	for _, r := range storedRepos {
+		stored[r.ID()] = r
+	}
+
+	for _, repoID := range d.GlobalCfg.DriftDetectionRepoIDs() {
+		repo, err := d.repoFromID(repoID, stored)
+		if err != nil {
+			d.Logger.Warn("skipping drift detection for %s: %s", repoID, err)
+			continue
+		}
+		log := d.Logger.WithFields(map[string]interface{}{
+			"repo": repo.FullName,
+		})
+		if err := d.detectRepoDrift(log, repo, prev); err != nil {
+			log.Err("checking for drift: %s", err)
+		}
+	}
+}
+
+// RecordRepo stores repo if it has drift detection enabled. Unlike its ID in
+// the server-side config, the repo from a pull request event has everything
+// we need to clone it, whatever VCS host it's on.
+func (d *DriftDetector) RecordRepo(log *logging.SimpleLogger, repo models.Repo) {
+	for _, id := range d.GlobalCfg.DriftDetectionRepoIDs() {
+		if id == repo.ID() {
+			if err := d.DB.UpdateDriftRepo(repo); err != nil {
+				log.Warn("unable to store repo for drift detection: %s", err)
+			}
+			return
+		}
+	}
+}
+
+// Statuses returns the latest drift status of every project that has been
+// checked.
+func (d *DriftDetector) Statuses() ([]models.DriftStatus, error) {
+	return d.DB.GetDriftStatuses()
+}
+
+func (d *DriftDetector) detectRepoDrift(log *logging.SimpleLogger, repo models.Repo, prev map[string]models.DriftStatus) error {
+	repoDir, err := d.WorkingDir.CloneDefaultBranch(log, repo)
+	if err != nil {
+		return err
+	}
+	projCfgs, err := d.buildProjectCfgs(log, repo, repoDir)
+	if err != nil {
+		return err
+	}
+	log.Info("checking %d projects for drift", len(projCfgs))
+
+	for _, projCfg := range projCfgs {
+		status := d.checkProject(log, repo, repoDir, projCfg)
+		prevStatus, hadPrev := prev[d.statusKey(status)]
+		if status.Error != "" {
+			// We don't know whether the project has drifted so we keep the
+			// previous result to avoid sending webhooks for errors.
+			status.Drifted = hadPrev && prevStatus.Drifted
+		}
+		if err := d.DB.UpdateDriftStatus(status); err != nil {
+			log.Err("unable to update drift status: %s", err)
+		}
+		if status.Drifted != (hadPrev && prevStatus.Drifted) {
+			if err := d.Webhooks.SendDrift(log, webhooks.DriftResult{
+				Workspace:   status.Workspace,
+				Repo:        repo,
+				Directory:   status.RepoRelDir,
+				ProjectName: status.ProjectName,
+				Drifted:     status.Drifted,
+			}); err != nil {
+				log.Warn("unable to send drift webhook: %s", err)
+			}
+		}
+	}
+	return nil
+}
+
+// buildProjectCfgs returns the configs of every project in the repo. If the
+// repo has an atlantis.yaml file then these are the projects defined in it,
+// otherwise we use all the projects our algorithm finds.
+func (d *DriftDetector) buildProjectCfgs(log *logging.SimpleLogger, repo models.Repo, repoDir string) ([]valid.MergedProjectCfg, error) {
+	hasRepoCfg, err := d.ParserValidator.HasRepoCfg(repoDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "looking for %s file in %q", yaml.AtlantisYAMLFilename, repoDir)
+	}
+
+	var projCfgs []valid.MergedProjectCfg
+	if hasRepoCfg {
+		repoCfg, err := d.ParserValidator.ParseRepoCfg(repoDir, d.GlobalCfg, repo.ID())
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", yaml.AtlantisYAMLFilename)
+		}
+		for _, p := range repoCfg.Projects {
+			projCfgs = append(projCfgs, d.GlobalCfg.MergeProjectCfg(log, repo.ID(), p, repoCfg))
+		}
+		return projCfgs, nil
+	}
+
+	// Without a config file, we treat every file in the repo as modified so
+	// that all projects are found.
+	var files []string
+	err = filepath.Walk(repoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(repoDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, relPath)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing files in %q", repoDir)
+	}
+	for _, mp := range d.ProjectFinder.DetermineProjects(log, files, repo.FullName, repoDir) {
+		projCfgs = append(projCfgs, d.GlobalCfg.DefaultProjCfg(log, repo.ID(), mp.Path, DefaultWorkspace))
+	}
+	return projCfgs, nil
+}
+
+// checkProject runs the steps of the project's plan workflow and returns
+// whether the plan step had changes. Drift isn't detected by run steps, even
+// if they run terraform plan themselves.
+func (d *DriftDetector) checkProject(log *logging.SimpleLogger, repo models.Repo, repoDir string, projCfg valid.MergedProjectCfg) models.DriftStatus {
+	status := models.DriftStatus{
+		RepoFullName: repo.FullName,
+		VCSHostname:  repo.VCSHost.Hostname,
+		RepoRelDir:   projCfg.RepoRelDir,
+		Workspace:    projCfg.Workspace,
+		ProjectName:  projCfg.Name,
+		LastChecked:  time.Now(),
+	}
+	logFields := map[string]interface{}{
+		"dir":       projCfg.RepoRelDir,
+		"workspace": projCfg.Workspace,
+	}
+	if projCfg.Name != "" {
+		logFields["project"] = projCfg.Name
+	}
+	ctx := models.ProjectCommandContext{
+		BaseRepo:          repo,
+		HeadRepo:          repo,
+		Log:               log.WithFields(logFields),
+		ProjectName:       projCfg.Name,
+		RepoConfigVersion: projCfg.RepoCfgVersion,
+		RepoRelDir:        projCfg.RepoRelDir,
+		TerraformVersion:  projCfg.TerraformVersion,
+		Workspace:         projCfg.Workspace,
+	}
+
+	absPath := filepath.Join(repoDir, projCfg.RepoRelDir)
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		status.Error = DirNotExistErr{RepoRelDir: projCfg.RepoRelDir}.Error()
+		return status
+	}
+
+	envs := make(map[string]string)
+	for _, step := range projCfg.Workflow.Plan.Steps {
+		var out string
+		var err error
+		switch step.StepName {
+		case "init":
+			out, err = d.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "plan":
+			extraArgs := append(append([]string{}, step.ExtraArgs...), "-detailed-exitcode")
+			out, err = d.PlanStepRunner.Run(ctx, extraArgs, absPath, envs)
+			if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok && exitErr.ExitCode() == driftExitCode {
+				ctx.Log.Info("detected drift")
+				status.Drifted = true
+				err = nil
+			}
+		case "run":
+			out, err = d.RunStepRunner.Run(ctx, step.RunCommand, absPath, envs)
+		case "env":
+			out, err = d.EnvStepRunner.Run(ctx, step.RunCommand, step.EnvVarValue, absPath, envs)
+			envs[step.EnvVarName] = out
+			// The value is only used to set the environment variable.
+			out = ""
+		default:
+			ctx.Log.Debug("skipping %q step since it's not supported by drift detection", step.StepName)
+		}
+		if err != nil {
+			status.Error = fmt.Sprintf("%s: %s", err, strings.TrimSpace(out))
+			return status
+		}
+	}
+	return status
+}
+
+// repoFromID returns the repo for repoID, ex. github.com/runatlantis/atlantis,
+// by finding the configured VCS host it's on. Not every VCS host supports
+// that so otherwise we use the repo stored from its last pull request event.
+func (d *DriftDetector) repoFromID(repoID string, stored map[string]models.Repo) (models.Repo, error) {
+	parts := strings.SplitN(repoID, "/", 2)
+	if len(parts) != 2 {
+		return models.Repo{}, fmt.Errorf("invalid repo id %q", repoID)
+	}
+	for _, vcsHostType := range d.VCSHostTypes {
+		repo, err := d.EventParser.ParseAPIPlanRequest(vcsHostType, parts[1])
+		if err == nil && repo.ID() == repoID {
+			return repo, nil
+		}
+	}
+	if repo, ok := stored[repoID]; ok {
+		return repo, nil
+	}
+	return models.Repo{}, fmt.Errorf("repo isn't on any of the configured VCS hosts or hasn't had any pull request events yet")
+}
+
+func (d *DriftDetector) statusKey(s models.DriftStatus) string {
+	return strings.Join([]string{s.RepoID(), s.RepoRelDir, s.Workspace, s.ProjectName}, "::")
+}
@@ -0,0 +1,252 @@
+package events_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/runtime"
+	tmocks "github.com/runatlantis/atlantis/server/events/terraform/mocks"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const driftRepoID = "github.com/runatlantis/atlantis"
+
+func setupDriftDetector(t *testing.T, repoDir string) (*events.DriftDetector, *mocks.MockStepRunner, *mocks.MockDriftWebhooksSender, func()) {
+	RegisterMockTestingT(t)
+	dataDir, cleanup := TempDir(t)
+	database, err := db.New(dataDir)
+	Ok(t, err)
+
+	globalCfg := valid.NewGlobalCfg(false, false, false)
+	globalCfg.Repos = append(globalCfg.Repos,
+		valid.Repo{ID: driftRepoID, DriftDetection: true},
+		// This repo isn't on a configured VCS host so should be skipped.
+		valid.Repo{ID: "gitlab.com/runatlantis/atlantis", DriftDetection: true},
+	)
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.CloneDefaultBranch(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo())).ThenReturn(repoDir, nil)
+	planRunner := mocks.NewMockStepRunner()
+	webhooksSender := mocks.NewMockDriftWebhooksSender()
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	runStepRunner := &runtime.RunStepRunner{
+		TerraformExecutor: tmocks.NewMockClient(),
+		DefaultTFVersion:  tfVersion,
+	}
+	return &events.DriftDetector{
+		GlobalCfg:    globalCfg,
+		VCSHostTypes: []models.VCSHostType{models.Github},
+		EventParser: &events.EventParser{
+			GithubUser:     "user",
+			GithubToken:    "token",
+			GithubHostname: "github.com",
+		},
+		WorkingDir:      workingDir,
+		ProjectFinder:   &events.DefaultProjectFinder{},
+		ParserValidator: &yaml.ParserValidator{},
+		InitStepRunner:  mocks.NewMockStepRunner(),
+		PlanStepRunner:  planRunner,
+		RunStepRunner:   runStepRunner,
+		EnvStepRunner:   &runtime.EnvStepRunner{RunStepRunner: runStepRunner},
+		DB:              database,
+		Webhooks:        webhooksSender,
+		Logger:          logging.NewNoopLogger(),
+		Interval:        time.Hour,
+	}, planRunner, webhooksSender, cleanup
+}
+
+// exitErr returns the error the terraform client returns when terraform
+// exits with exitCode.
+func exitErr(t *testing.T, exitCode string) error {
+	err := exec.Command("sh", "-c", "exit "+exitCode).Run()
+	Assert(t, err != nil, "exp error")
+	return errors.Wrap(err, "running terraform plan")
+}
+
+func TestDriftDetector_DetectDrift(t *testing.T) {
+	repoDir, cleanup := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+	defer cleanup()
+	d, planRunner, webhooksSender, cleanup2 := setupDriftDetector(t, repoDir)
+	defer cleanup2()
+
+	// Plan has changes so we should detect drift and send a webhook.
+	When(planRunner.Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString())).
+		ThenReturn("changes", exitErr(t, "2"))
+	d.DetectDrift()
+
+	_, extraArgs, path, _ := planRunner.VerifyWasCalledOnce().Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString()).GetCapturedArguments()
+	Equals(t, []string{"-detailed-exitcode"}, extraArgs)
+	Equals(t, repoDir, path)
+
+	statuses, err := d.Statuses()
+	Ok(t, err)
+	Equals(t, 1, len(statuses))
+	Equals(t, "runatlantis/atlantis", statuses[0].RepoFullName)
+	Equals(t, "github.com", statuses[0].VCSHostname)
+	Equals(t, ".", statuses[0].RepoRelDir)
+	Equals(t, "default", statuses[0].Workspace)
+	Equals(t, true, statuses[0].Drifted)
+	Equals(t, "", statuses[0].Error)
+	_, res := webhooksSender.VerifyWasCalledOnce().SendDrift(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyWebhooksDriftResult()).GetCapturedArguments()
+	Equals(t, webhooks.DriftResult{
+		Workspace: "default",
+		Repo:      res.Repo,
+		Directory: ".",
+		Drifted:   true,
+	}, res)
+	Equals(t, driftRepoID, res.Repo.ID())
+
+	// If we're still drifted we shouldn't send another webhook.
+	d.DetectDrift()
+	webhooksSender.VerifyWasCalledOnce().SendDrift(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyWebhooksDriftResult())
+
+	// If the plan errors we don't know if we've drifted so we keep the last
+	// result and don't send a webhook.
+	When(planRunner.Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString())).
+		ThenReturn("Error: invalid credentials", exitErr(t, "1"))
+	d.DetectDrift()
+	statuses, err = d.Statuses()
+	Ok(t, err)
+	Equals(t, true, statuses[0].Drifted)
+	Equals(t, "running terraform plan: exit status 1: Error: invalid credentials", statuses[0].Error)
+	webhooksSender.VerifyWasCalledOnce().SendDrift(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyWebhooksDriftResult())
+
+	// Once the plan has no changes, the drift is resolved.
+	When(planRunner.Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString())).
+		ThenReturn("no changes", nil)
+	d.DetectDrift()
+	statuses, err = d.Statuses()
+	Ok(t, err)
+	Equals(t, false, statuses[0].Drifted)
+	Equals(t, "", statuses[0].Error)
+	_, results := webhooksSender.VerifyWasCalled(Times(2)).SendDrift(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyWebhooksDriftResult()).GetAllCapturedArguments()
+	Equals(t, false, results[1].Drifted)
+}
+
+// If the repo has an atlantis.yaml file we should check the projects defined
+// in it.
+func TestDriftDetector_DetectDriftRepoCfg(t *testing.T) {
+	repoDir, cleanup := DirStructure(t, map[string]interface{}{
+		"staging":    map[string]interface{}{"main.tf": nil},
+		"production": map[string]interface{}{"main.tf": nil},
+	})
+	defer cleanup()
+	repoCfg := `
+version: 3
+projects:
+- dir: staging
+- name: prod
+  dir: production
+  workspace: prod
+`
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "atlantis.yaml"), []byte(repoCfg), 0600))
+	d, planRunner, _, cleanup2 := setupDriftDetector(t, repoDir)
+	defer cleanup2()
+
+	When(planRunner.Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString())).
+		ThenReturn("no changes", nil)
+	d.DetectDrift()
+
+	ctxs, _, paths, _ := planRunner.VerifyWasCalled(Times(2)).Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString()).GetAllCapturedArguments()
+	Equals(t, []string{filepath.Join(repoDir, "staging"), filepath.Join(repoDir, "production")}, paths)
+	Equals(t, "prod", ctxs[1].ProjectName)
+	Equals(t, "prod", ctxs[1].Workspace)
+
+	statuses, err := d.Statuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	Equals(t, "production", statuses[0].RepoRelDir)
+	Equals(t, "prod", statuses[0].ProjectName)
+	Equals(t, "staging", statuses[1].RepoRelDir)
+}
+
+// Test that the project's whole plan workflow is run, not just its init and
+// plan steps.
+func TestDriftDetector_DetectDriftCustomWorkflow(t *testing.T) {
+	repoDir, cleanup := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+	defer cleanup()
+	d, planRunner, _, cleanup2 := setupDriftDetector(t, repoDir)
+	defer cleanup2()
+	for i := range d.GlobalCfg.Repos {
+		if d.GlobalCfg.Repos[i].ID == driftRepoID {
+			d.GlobalCfg.Repos[i].Workflow = &valid.Workflow{
+				Name: "custom",
+				Plan: valid.Stage{
+					Steps: []valid.Step{
+						{StepName: "env", EnvVarName: "TF_VAR_env", RunCommand: "echo staging"},
+						{StepName: "run", RunCommand: "touch ran"},
+						{StepName: "plan"},
+					},
+				},
+			}
+		}
+	}
+
+	When(planRunner.Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString())).
+		ThenReturn("no changes", nil)
+	d.DetectDrift()
+
+	_, _, _, envs := planRunner.VerifyWasCalledOnce().Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString()).GetCapturedArguments()
+	Equals(t, map[string]string{"TF_VAR_env": "staging"}, envs)
+	_, err := os.Stat(filepath.Join(repoDir, "ran"))
+	Ok(t, err)
+	statuses, err := d.Statuses()
+	Ok(t, err)
+	Equals(t, 1, len(statuses))
+	Equals(t, "", statuses[0].Error)
+}
+
+// Test that repos on VCS hosts that can't be looked up by ID, ex. Bitbucket,
+// are checked once they've been recorded from a pull request event.
+func TestDriftDetector_RecordRepo(t *testing.T) {
+	repoDir, cleanup := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+	defer cleanup()
+	d, planRunner, _, cleanup2 := setupDriftDetector(t, repoDir)
+	defer cleanup2()
+	d.GlobalCfg.Repos = append(d.GlobalCfg.Repos, valid.Repo{ID: "bitbucket.org/owner/repo", DriftDetection: true})
+	When(planRunner.Run(matchers.AnyModelsProjectCommandContext(), matchers.AnySliceOfString(), AnyString(), matchers.AnyMapOfStringToString())).
+		ThenReturn("no changes", nil)
+
+	bitbucketRepo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+	otherRepo, err := models.NewRepo(models.BitbucketCloud, "owner/other", "https://bitbucket.org/owner/other.git", "user", "token")
+	Ok(t, err)
+	d.RecordRepo(logging.NewNoopLogger(), bitbucketRepo)
+	// Repos without drift detection aren't stored.
+	d.RecordRepo(logging.NewNoopLogger(), otherRepo)
+	repos, err := d.DB.GetDriftRepos()
+	Ok(t, err)
+	Equals(t, []models.Repo{bitbucketRepo}, repos)
+
+	d.DetectDrift()
+	statuses, err := d.Statuses()
+	Ok(t, err)
+	var repoIDs []string
+	for _, s := range statuses {
+		repoIDs = append(repoIDs, s.RepoID())
+	}
+	Equals(t, []string{"bitbucket.org/owner/repo", driftRepoID}, repoIDs)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
 	"github.com/xanzy/go-gitlab"
 	"gopkg.in/go-playground/validator.v9"
 )
@@ -62,6 +63,30 @@ func (c AutoplanCommand) IsAutoplan() bool {
 	return true
 }
 
+// PolicyCheckCommand is a policy check that is automatically run after a
+// successful plan.
+type PolicyCheckCommand struct {
+	// Verbose is true if the plan that triggered this check was verbose.
+	Verbose bool
+	// Autoplan is true if the plan that triggered this check was an autoplan.
+	Autoplan bool
+}
+
+// CommandName is PolicyCheck.
+func (c PolicyCheckCommand) CommandName() models.CommandName {
+	return models.PolicyCheckCommand
+}
+
+// IsVerbose is true if the plan that triggered this check was verbose.
+func (c PolicyCheckCommand) IsVerbose() bool {
+	return c.Verbose
+}
+
+// IsAutoplan is true if the plan that triggered this check was an autoplan.
+func (c PolicyCheckCommand) IsAutoplan() bool {
+	return c.Autoplan
+}
+
 // CommentCommand is a command that was triggered by a pull request comment.
 type CommentCommand struct {
 	// RepoRelDir is the path relative to the repo root to run the command in.
@@ -81,6 +106,10 @@ type CommentCommand struct {
 	// project specified in an atlantis.yaml file.
 	// If empty then the comment specified no project.
 	ProjectName string
+	// Args are the positional arguments of import and state rm, ex. the
+	// address and id in atlantis import ADDRESS ID. They're kept separate
+	// from Flags because they can't be passed to plan or apply.
+	Args []string
 }
 
 // IsForSpecificProject returns true if the command is for a specific dir, workspace
@@ -258,19 +287,56 @@ type EventParsing interface {
 	// ParseAzureDevopsRepo parses the response from the Azure DevOps API endpoint that
 	// returns a repo into the Atlantis model.
 	ParseAzureDevopsRepo(adRepo *azuredevops.GitRepository) (models.Repo, error)
+
+	// ParseGiteaIssueCommentEvent parses Gitea pull request comment events.
+	// baseRepo is the repo that the pull request will be merged into.
+	// user is the user that made the comment.
+	// pullNum is the number of the pull request that triggered the webhook.
+	ParseGiteaIssueCommentEvent(event *gitea.IssueCommentEvent) (
+		baseRepo models.Repo, user models.User, pullNum int, err error)
+
+	// ParseGiteaPullEvent parses Gitea pull request events.
+	// pull is the parsed pull request.
+	// pullEventType is the type of event, for example opened/closed.
+	// baseRepo is the repo the pull request will be merged into.
+	// headRepo is the repo the pull request branch is from.
+	// user is the user that triggered the event.
+	ParseGiteaPullEvent(event *gitea.PullRequestEvent) (
+		pull models.PullRequest, pullEventType models.PullRequestEventType,
+		baseRepo models.Repo, headRepo models.Repo, user models.User, err error)
+
+	// ParseGiteaPull parses the response from the Gitea API endpoint (not
+	// from a webhook) that returns a pull request.
+	// pull is the parsed pull request.
+	// baseRepo is the repo the pull request will be merged into.
+	// headRepo is the repo the pull request branch is from.
+	ParseGiteaPull(pull *gitea.PullRequest) (
+		pullModel models.PullRequest, baseRepo models.Repo, headRepo models.Repo, err error)
+
+	// ParseAPIPlanRequest parses a repo from a request to the Atlantis API
+	// into the Atlantis model. Only GitHub, GitLab and Gitea are
+	// supported.
+	// vcsHostType is the VCS host the repo is on.
+	// repoFullName is the owner and name of the repo, ex. runatlantis/atlantis.
+	ParseAPIPlanRequest(vcsHostType models.VCSHostType, repoFullName string) (models.Repo, error)
 }
 
 // EventParser parses VCS events.
 type EventParser struct {
 	GithubUser         string
 	GithubToken        string
+	GithubHostname     string
 	GitlabUser         string
 	GitlabToken        string
+	GitlabHostname     string
 	BitbucketUser      string
 	BitbucketToken     string
 	BitbucketServerURL string
 	AzureDevopsToken   string
 	AzureDevopsUser    string
+	GiteaUser          string
+	GiteaToken         string
+	GiteaBaseURL       string
 }
 
 // GetBitbucketCloudPullEventType returns the type of the pull request
@@ -839,3 +905,108 @@ func (e *EventParser) ParseAzureDevopsRepo(adRepo *azuredevops.GitRepository) (m
 	fullName := fmt.Sprintf("%s/%s/%s", owner, project, repo)
 	return models.NewRepo(models.AzureDevops, fullName, cloneURL, e.AzureDevopsUser, e.AzureDevopsToken)
 }
+
+// ParseAPIPlanRequest parses a repo from a request to the Atlantis API. Since
+// API requests don't come from the VCS host, we construct the clone URL
+// ourselves from the configured hostname.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseAPIPlanRequest(vcsHostType models.VCSHostType, repoFullName string) (models.Repo, error) {
+	switch vcsHostType {
+	case models.Github:
+		return models.NewRepo(vcsHostType, repoFullName, e.apiCloneURL(e.GithubHostname, repoFullName), e.GithubUser, e.GithubToken)
+	case models.Gitlab:
+		return models.NewRepo(vcsHostType, repoFullName, e.apiCloneURL(e.GitlabHostname, repoFullName), e.GitlabUser, e.GitlabToken)
+	case models.Gitea:
+		return models.NewRepo(vcsHostType, repoFullName, e.apiCloneURL(e.GiteaBaseURL, repoFullName), e.GiteaUser, e.GiteaToken)
+	}
+	return models.Repo{}, fmt.Errorf("API requests are not supported for %s", vcsHostType.String())
+}
+
+// ParseGiteaIssueCommentEvent parses Gitea pull request comment events.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGiteaIssueCommentEvent(event *gitea.IssueCommentEvent) (baseRepo models.Repo, user models.User, pullNum int, err error) {
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrap(err, "webhook was missing fields")
+		return
+	}
+	baseRepo, err = e.parseGiteaRepo(event.Repository)
+	if err != nil {
+		return
+	}
+	user = models.User{Username: *event.Sender.Login}
+	pullNum = *event.Issue.Number
+	return
+}
+
+// ParseGiteaPullEvent parses Gitea pull request events.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGiteaPullEvent(event *gitea.PullRequestEvent) (pull models.PullRequest, pullEventType models.PullRequestEventType, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrap(err, "webhook was missing fields")
+		return
+	}
+	pull, baseRepo, headRepo, err = e.ParseGiteaPull(event.PullRequest)
+	if err != nil {
+		return
+	}
+	switch *event.Action {
+	case "opened", "reopened":
+		pullEventType = models.OpenedPullEvent
+	case "synchronized":
+		pullEventType = models.UpdatedPullEvent
+	case "closed":
+		pullEventType = models.ClosedPullEvent
+	default:
+		pullEventType = models.OtherPullEvent
+	}
+	user = models.User{Username: *event.Sender.Login}
+	return
+}
+
+// ParseGiteaPull parses the response from the Gitea API endpoint (not
+// from a webhook) that returns a pull request.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGiteaPull(pull *gitea.PullRequest) (pullModel models.PullRequest, baseRepo models.Repo, headRepo models.Repo, err error) {
+	if err = validator.New().Struct(pull); err != nil {
+		err = errors.Wrap(err, "pull request was missing fields")
+		return
+	}
+	baseRepo, err = e.parseGiteaRepo(pull.Base.Repository)
+	if err != nil {
+		return
+	}
+	headRepo, err = e.parseGiteaRepo(pull.Head.Repository)
+	if err != nil {
+		return
+	}
+
+	pullState := models.ClosedPullState
+	if *pull.State == "open" {
+		pullState = models.OpenPullState
+	}
+
+	pullModel = models.PullRequest{
+		Author:     *pull.User.Login,
+		HeadBranch: *pull.Head.Ref,
+		HeadCommit: *pull.Head.Sha,
+		URL:        *pull.HTMLURL,
+		Num:        *pull.Number,
+		State:      pullState,
+		BaseRepo:   baseRepo,
+		BaseBranch: *pull.Base.Ref,
+	}
+	return
+}
+
+func (e *EventParser) parseGiteaRepo(repo *gitea.Repository) (models.Repo, error) {
+	return models.NewRepo(models.Gitea, *repo.FullName, *repo.CloneURL, e.GiteaUser, e.GiteaToken)
+}
+
+// apiCloneURL returns the HTTPS clone URL for repoFullName on hostname. We
+// assume HTTPS if hostname doesn't specify a scheme.
+func (e *EventParser) apiCloneURL(hostname string, repoFullName string) string {
+	if !strings.HasPrefix(hostname, "http://") && !strings.HasPrefix(hostname, "https://") {
+		hostname = "https://" + hostname
+	}
+	return fmt.Sprintf("%s/%s.git", strings.TrimSuffix(hostname, "/"), repoFullName)
+}
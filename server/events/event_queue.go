@@ -0,0 +1,364 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/recovery"
+)
+
+const (
+	// defaultMaxEventAttempts is how many times an event is run before it's
+	// marked failed if EventQueue.MaxAttempts isn't set.
+	defaultMaxEventAttempts = 3
+	// eventQueuePollInterval is how often we check the queue for events that
+	// weren't added by this instance, ex. ones retried by an admin from
+	// another instance.
+	eventQueuePollInterval = 10 * time.Second
+	// processedEventRetention is how long processed events are kept so that
+	// redeliveries of their webhooks are ignored.
+	processedEventRetention = 72 * time.Hour
+	// eventHeartbeatInterval is how often the heartbeat of a running event
+	// is updated.
+	eventHeartbeatInterval = 15 * time.Second
+	// eventHeartbeatTimeout is how long after its last heartbeat a running
+	// event is treated as interrupted.
+	eventHeartbeatTimeout = time.Minute
+	// interruptedEventError is the error of events that were running when
+	// Atlantis stopped.
+	interruptedEventError = "Atlantis stopped while the event was running"
+)
+
+// EventQueue stores the commands from webhooks in the database and runs them
+// with a bounded number of workers. Since the events are stored, the ones that
+// haven't run when Atlantis stops are run when it starts again. An autoplan or
+// plan event that was running when Atlantis stopped, or that panicked, is
+// retried until it has been attempted MaxAttempts times, after which it's
+// marked failed so that an admin can retry or discard it. Other commands, ex.
+// apply, are marked failed straight away since they may have partly run.
+//
+// While an event runs, its heartbeat is updated. Running events whose
+// heartbeat stops are treated as interrupted so that, when instances share a
+// database, events running on other instances aren't mistaken for ones that
+// were interrupted.
+type EventQueue struct {
+	DB            locking.Backend
+	CommandRunner CommandRunner
+	// Drainer tracks the running events so that shutdown waits for them.
+	// Once it's draining, no more events are started.
+	Drainer *Drainer
+	Logger  logging.SimpleLogging
+	// Workers is how many events can run at once.
+	Workers int
+	// MaxAttempts is how many times an event is run before it's marked
+	// failed. Defaults to 3.
+	MaxAttempts int
+
+	mu      sync.Mutex
+	running int
+	wake    chan struct{}
+}
+
+// Enqueue adds event to the queue to be run. The event's ID is the delivery ID
+// in its RequestID if there is one so that redeliveries are deduplicated. It
+// returns false if the event was already received.
+func (q *EventQueue) Enqueue(event models.QueuedEvent) (bool, error) {
+	event.ID = q.eventID(event.RequestID)
+	event.Status = models.PendingEventStatus
+	event.ReceivedAt = time.Now()
+	added, err := q.DB.EnqueueEvent(event)
+	if err != nil {
+		return false, errors.Wrap(err, "adding event to queue")
+	}
+	if added {
+		q.notify()
+	}
+	return added, nil
+}
+
+// Run runs the queued events until stop is closed. It also periodically
+// checks for interrupted events.
+func (q *EventQueue) Run(stop <-chan struct{}) {
+	q.Logger.Info("running queued events with %d workers", q.Workers)
+	q.requeueInterrupted()
+	ticker := time.NewTicker(eventQueuePollInterval)
+	defer ticker.Stop()
+	for {
+		q.dispatch()
+		select {
+		case <-stop:
+			return
+		case <-q.wakeChan():
+		case <-ticker.C:
+			q.requeueInterrupted()
+		}
+	}
+}
+
+// Retry queues the failed event with id to be run again.
+func (q *EventQueue) Retry(id string) error {
+	event, err := q.getFailed(id)
+	if err != nil {
+		return err
+	}
+	event.Status = models.PendingEventStatus
+	event.Attempts = 0
+	event.Error = ""
+	if err := q.DB.UpdateEvent(event); err != nil {
+		return errors.Wrap(err, "updating event")
+	}
+	q.notify()
+	return nil
+}
+
+// Discard deletes the failed event with id without running it.
+func (q *EventQueue) Discard(id string) error {
+	if _, err := q.getFailed(id); err != nil {
+		return err
+	}
+	return errors.Wrap(q.DB.DeleteEvent(id), "deleting event")
+}
+
+// RunEvent runs event's command with runner. It returns an error if the event
+// is invalid or the command panicked.
+func RunEvent(runner CommandRunner, event models.QueuedEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PANIC: %s\n%s", r, recovery.Stack(3))
+		}
+	}()
+	if event.Autoplan {
+		if event.HeadRepo == nil || event.Pull == nil {
+			return errors.New("autoplan event is missing its pull request")
+		}
+		runner.RunAutoplanCommand(event.BaseRepo, *event.HeadRepo, *event.Pull, event.User, event.RequestID)
+		return nil
+	}
+	if event.Comment == nil {
+		return errors.New("comment event is missing its command")
+	}
+	cmd := &CommentCommand{
+		Name:        event.Comment.Name,
+		RepoRelDir:  event.Comment.RepoRelDir,
+		Workspace:   event.Comment.Workspace,
+		ProjectName: event.Comment.ProjectName,
+		Flags:       event.Comment.Flags,
+		Verbose:     event.Comment.Verbose,
+		Args:        event.Comment.Args,
+	}
+	runner.RunCommentCommand(event.BaseRepo, event.HeadRepo, event.Pull, event.User, event.PullNum, cmd, event.RequestID)
+	return nil
+}
+
+// NewQueuedComment returns cmd as it's stored in the event queue.
+func NewQueuedComment(cmd *CommentCommand) *models.QueuedComment {
+	return &models.QueuedComment{
+		Name:        cmd.Name,
+		RepoRelDir:  cmd.RepoRelDir,
+		Workspace:   cmd.Workspace,
+		ProjectName: cmd.ProjectName,
+		Flags:       cmd.Flags,
+		Verbose:     cmd.Verbose,
+		Args:        cmd.Args,
+	}
+}
+
+// dispatch starts the pending events, oldest first, while there are free
+// workers.
+func (q *EventQueue) dispatch() {
+	events, err := q.DB.GetEvents()
+	if err != nil {
+		q.Logger.Err("unable to get queued events: %s", err)
+		return
+	}
+	for _, event := range events {
+		if event.Status != models.PendingEventStatus {
+			continue
+		}
+		if !q.reserveWorker() {
+			return
+		}
+		if !q.Drainer.StartOp() {
+			q.releaseWorker()
+			return
+		}
+		started, err := q.DB.StartEvent(event.ID)
+		if err != nil || started == nil {
+			if err != nil {
+				q.Logger.Err("unable to start event %s: %s", event.ID, err)
+			}
+			q.Drainer.OpDone()
+			q.releaseWorker()
+			continue
+		}
+		go q.run(*started)
+	}
+}
+
+// run runs event, which has been started, and records the result.
+func (q *EventQueue) run(event models.QueuedEvent) {
+	defer func() {
+		q.Drainer.OpDone()
+		q.releaseWorker()
+		q.notify()
+	}()
+
+	q.Logger.Debug("running event %s, attempt %d", event.ID, event.Attempts)
+	stopHeartbeat := q.heartbeat(event)
+	err := RunEvent(q.CommandRunner, event)
+	stopHeartbeat()
+	if err != nil {
+		q.Logger.Err("event %s failed: %s", event.ID, err)
+		q.fail(&event, err.Error())
+	} else {
+		event.Status = models.ProcessedEventStatus
+		event.Error = ""
+	}
+	if err := q.DB.UpdateEvent(event); err != nil {
+		q.Logger.Err("unable to update event %s: %s", event.ID, err)
+	}
+
+	deleted, err := q.DB.DeleteProcessedEventsBefore(time.Now().Add(-processedEventRetention))
+	if err != nil {
+		q.Logger.Err("unable to delete old processed events: %s", err)
+	} else if deleted > 0 {
+		q.Logger.Debug("deleted %d processed events older than %s", deleted, processedEventRetention)
+	}
+}
+
+// heartbeat updates event's heartbeat until the returned func is called.
+func (q *EventQueue) heartbeat(event models.QueuedEvent) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(eventHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				event.HeartbeatAt = time.Now()
+				if err := q.DB.UpdateEvent(event); err != nil {
+					q.Logger.Err("unable to update heartbeat of event %s: %s", event.ID, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// requeueInterrupted retries the running events whose heartbeat has stopped
+// because the instance running them stopped.
+func (q *EventQueue) requeueInterrupted() {
+	events, err := q.DB.GetEvents()
+	if err != nil {
+		q.Logger.Err("unable to get queued events: %s", err)
+		return
+	}
+	for _, event := range events {
+		if event.Status != models.RunningEventStatus || time.Since(event.HeartbeatAt) < eventHeartbeatTimeout {
+			continue
+		}
+		q.fail(&event, interruptedEventError)
+		q.Logger.Warn("event %s was running when Atlantis stopped, marking it %s", event.ID, event.Status)
+		if err := q.DB.UpdateEvent(event); err != nil {
+			q.Logger.Err("unable to update event %s: %s", event.ID, err)
+		}
+	}
+}
+
+// fail records a failed attempt of event. It's retried if it's retryable and
+// hasn't been attempted MaxAttempts times.
+func (q *EventQueue) fail(event *models.QueuedEvent, reason string) {
+	event.Error = reason
+	maxAttempts := q.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultMaxEventAttempts
+	}
+	if event.Attempts >= maxAttempts || !retryable(*event) {
+		event.Status = models.FailedEventStatus
+	} else {
+		event.Status = models.PendingEventStatus
+	}
+}
+
+// retryable returns true if event can safely be run again after an attempt
+// that didn't finish. Only autoplan and plan are since other commands, ex.
+// apply or import, may have already changed the state.
+func retryable(event models.QueuedEvent) bool {
+	return event.Autoplan || (event.Comment != nil && event.Comment.Name == models.PlanCommand)
+}
+
+func (q *EventQueue) getFailed(id string) (models.QueuedEvent, error) {
+	events, err := q.DB.GetEvents()
+	if err != nil {
+		return models.QueuedEvent{}, errors.Wrap(err, "getting events")
+	}
+	for _, event := range events {
+		if event.ID != id {
+			continue
+		}
+		if event.Status != models.FailedEventStatus {
+			return models.QueuedEvent{}, fmt.Errorf("event %q is %s, only failed events can be retried or discarded", id, event.Status)
+		}
+		return event, nil
+	}
+	return models.QueuedEvent{}, fmt.Errorf("no event found with id %q", id)
+}
+
+// eventID returns the id for an event with requestID. Request IDs are of the
+// form <header>=<delivery id>, or just the delivery id for Bitbucket. If the
+// VCS host didn't send a delivery id, the id is random.
+func (q *EventQueue) eventID(requestID string) string {
+	if strings.HasSuffix(requestID, "=") || requestID == "" {
+		return uuid.New().String()
+	}
+	return requestID
+}
+
+func (q *EventQueue) reserveWorker() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running >= q.Workers {
+		return false
+	}
+	q.running++
+	return true
+}
+
+func (q *EventQueue) releaseWorker() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+}
+
+func (q *EventQueue) wakeChan() chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.wake == nil {
+		// It's buffered so that notifying never blocks and wake-ups that
+		// happen while we're dispatching aren't lost.
+		q.wake = make(chan struct{}, 1)
+	}
+	return q.wake
+}
+
+// notify wakes Run up to dispatch events.
+func (q *EventQueue) notify() {
+	select {
+	case q.wakeChan() <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,226 @@
+package events_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeCommandRunner records the commands it runs. If panics is set, it
+// panics instead.
+type fakeCommandRunner struct {
+	mu       sync.Mutex
+	panics   bool
+	autoplan []models.PullRequest
+	comments []events.CommentCommand
+}
+
+func (f *fakeCommandRunner) RunCommentCommand(_ models.Repo, _ *models.Repo, _ *models.PullRequest, _ models.User, _ int, cmd *events.CommentCommand, _ string) {
+	if f.panics {
+		panic("boom")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.comments = append(f.comments, *cmd)
+}
+
+func (f *fakeCommandRunner) RunAutoplanCommand(_ models.Repo, _ models.Repo, pull models.PullRequest, _ models.User, _ string) {
+	if f.panics {
+		panic("boom")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.autoplan = append(f.autoplan, pull)
+}
+
+func setupEventQueue(t *testing.T) (*events.EventQueue, *fakeCommandRunner, func()) {
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	runner := &fakeCommandRunner{}
+	q := &events.EventQueue{
+		DB:            boltdb,
+		CommandRunner: runner,
+		Logger:        logging.NewNoopLogger(),
+		Workers:       2,
+		MaxAttempts:   2,
+	}
+	return q, runner, cleanup
+}
+
+// runQueue runs q until every event is processed or failed.
+func runQueue(t *testing.T, q *events.EventQueue) []models.QueuedEvent {
+	stop := make(chan struct{})
+	defer close(stop)
+	go q.Run(stop)
+	for i := 0; i < 500; i++ {
+		queued, err := q.DB.GetEvents()
+		Ok(t, err)
+		done := true
+		for _, e := range queued {
+			if e.Status == models.PendingEventStatus || e.Status == models.RunningEventStatus {
+				done = false
+			}
+		}
+		if done {
+			return queued
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for events to run")
+	return nil
+}
+
+func TestEventQueue_Enqueue(t *testing.T) {
+	q, _, cleanup := setupEventQueue(t)
+	defer cleanup()
+
+	added, err := q.Enqueue(models.QueuedEvent{RequestID: "X-Github-Delivery=1", Autoplan: true})
+	Ok(t, err)
+	Assert(t, added, "exp event to be added")
+	added, err = q.Enqueue(models.QueuedEvent{RequestID: "X-Github-Delivery=1", Autoplan: true})
+	Ok(t, err)
+	Assert(t, !added, "exp redelivered event to be ignored")
+
+	// Events without a delivery id are never deduplicated.
+	for _, reqID := range []string{"X-Github-Delivery=", "X-Github-Delivery=", ""} {
+		added, err = q.Enqueue(models.QueuedEvent{RequestID: reqID, Autoplan: true})
+		Ok(t, err)
+		Assert(t, added, "exp event to be added")
+	}
+	// Bitbucket request ids are just the delivery id.
+	added, err = q.Enqueue(models.QueuedEvent{RequestID: "2", Autoplan: true})
+	Ok(t, err)
+	Assert(t, added, "exp event to be added")
+	added, err = q.Enqueue(models.QueuedEvent{RequestID: "2", Autoplan: true})
+	Ok(t, err)
+	Assert(t, !added, "exp redelivered event to be ignored")
+
+	queued, err := q.DB.GetEvents()
+	Ok(t, err)
+	Equals(t, 5, len(queued))
+	Equals(t, "X-Github-Delivery=1", queued[0].ID)
+	Equals(t, models.PendingEventStatus, queued[0].Status)
+	Assert(t, queued[1].ID != queued[2].ID, "exp random ids to differ")
+	Equals(t, "2", queued[4].ID)
+}
+
+func TestEventQueue_Run(t *testing.T) {
+	q, runner, cleanup := setupEventQueue(t)
+	defer cleanup()
+
+	pull := models.PullRequest{Num: 1}
+	_, err := q.Enqueue(models.QueuedEvent{RequestID: "id=1", Autoplan: true, HeadRepo: &models.Repo{}, Pull: &pull})
+	Ok(t, err)
+	_, err = q.Enqueue(models.QueuedEvent{RequestID: "id=2", Comment: &models.QueuedComment{Name: models.PlanCommand, RepoRelDir: "dir", Flags: []string{"-var=a"}}})
+	Ok(t, err)
+
+	queued := runQueue(t, q)
+	Equals(t, 2, len(queued))
+	for _, e := range queued {
+		Equals(t, models.ProcessedEventStatus, e.Status)
+		Equals(t, 1, e.Attempts)
+	}
+	Equals(t, []models.PullRequest{pull}, runner.autoplan)
+	Equals(t, []events.CommentCommand{{Name: models.PlanCommand, RepoRelDir: "dir", Flags: []string{"-var=a"}}}, runner.comments)
+}
+
+// Test that events that panic are retried and then marked failed and that
+// they can be retried and discarded.
+func TestEventQueue_Failed(t *testing.T) {
+	q, runner, cleanup := setupEventQueue(t)
+	defer cleanup()
+	runner.panics = true
+
+	_, err := q.Enqueue(models.QueuedEvent{RequestID: "id=1", Comment: &models.QueuedComment{Name: models.PlanCommand}})
+	Ok(t, err)
+	queued := runQueue(t, q)
+	Equals(t, models.FailedEventStatus, queued[0].Status)
+	Equals(t, 2, queued[0].Attempts)
+	Assert(t, strings.Contains(queued[0].Error, "PANIC: boom"), "exp panic in error, got %q", queued[0].Error)
+
+	runner.panics = false
+	Ok(t, q.Retry("id=1"))
+	queued = runQueue(t, q)
+	Equals(t, models.ProcessedEventStatus, queued[0].Status)
+	Equals(t, 1, queued[0].Attempts)
+	Equals(t, "", queued[0].Error)
+
+	ErrEquals(t, "event \"id=1\" is processed, only failed events can be retried or discarded", q.Discard("id=1"))
+	ErrEquals(t, "no event found with id \"other\"", q.Retry("other"))
+}
+
+// Test that plans that were running when Atlantis stopped are retried unless
+// they've been attempted too many times and that other commands aren't
+// retried.
+func TestEventQueue_Interrupted(t *testing.T) {
+	q, runner, cleanup := setupEventQueue(t)
+	defer cleanup()
+
+	for _, e := range []models.QueuedEvent{
+		{ID: "retried", Status: models.RunningEventStatus, Attempts: 1, Comment: &models.QueuedComment{Name: models.PlanCommand}, ReceivedAt: time.Now().Add(-2 * time.Minute)},
+		{ID: "apply", Status: models.RunningEventStatus, Attempts: 1, Comment: &models.QueuedComment{Name: models.ApplyCommand}, ReceivedAt: time.Now().Add(-time.Minute)},
+		{ID: "failed", Status: models.RunningEventStatus, Attempts: 2, Comment: &models.QueuedComment{Name: models.PlanCommand}, ReceivedAt: time.Now()},
+	} {
+		_, err := q.DB.EnqueueEvent(e)
+		Ok(t, err)
+	}
+
+	queued := runQueue(t, q)
+	Equals(t, "retried", queued[0].ID)
+	Equals(t, models.ProcessedEventStatus, queued[0].Status)
+	Equals(t, 2, queued[0].Attempts)
+	Equals(t, "apply", queued[1].ID)
+	Equals(t, models.FailedEventStatus, queued[1].Status)
+	Equals(t, 1, queued[1].Attempts)
+	Equals(t, "failed", queued[2].ID)
+	Equals(t, models.FailedEventStatus, queued[2].Status)
+	Equals(t, "Atlantis stopped while the event was running", queued[2].Error)
+	Equals(t, []events.CommentCommand{{Name: models.PlanCommand}}, runner.comments)
+
+	Ok(t, q.Discard("failed"))
+	queued, err := q.DB.GetEvents()
+	Ok(t, err)
+	Equals(t, 2, len(queued))
+}
+
+// Test that events with a recent heartbeat, ex. because another instance is
+// running them, aren't treated as interrupted.
+func TestEventQueue_RunningElsewhere(t *testing.T) {
+	q, runner, cleanup := setupEventQueue(t)
+	defer cleanup()
+
+	_, err := q.DB.EnqueueEvent(models.QueuedEvent{ID: "running", Status: models.RunningEventStatus, Attempts: 1, Comment: &models.QueuedComment{Name: models.PlanCommand}, HeartbeatAt: time.Now()})
+	Ok(t, err)
+
+	stop := make(chan struct{})
+	go q.Run(stop)
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+
+	queued, err := q.DB.GetEvents()
+	Ok(t, err)
+	Equals(t, models.RunningEventStatus, queued[0].Status)
+	Equals(t, 1, queued[0].Attempts)
+	Equals(t, 0, len(runner.comments))
+}
+
+// Test that panicking commands other than plan aren't retried.
+func TestEventQueue_FailedNotRetryable(t *testing.T) {
+	q, runner, cleanup := setupEventQueue(t)
+	defer cleanup()
+	runner.panics = true
+
+	_, err := q.Enqueue(models.QueuedEvent{RequestID: "id=1", Comment: &models.QueuedComment{Name: models.ApplyCommand}})
+	Ok(t, err)
+	queued := runQueue(t, q)
+	Equals(t, models.FailedEventStatus, queued[0].Status)
+	Equals(t, 1, queued[0].Attempts)
+}
@@ -3,23 +3,28 @@ package events
 import (
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
+// gitCredsFilename is the name of the file, in the home dir, that git reads
+// credentials from.
+const gitCredsFilename = ".git-credentials"
+
 // WriteGitCreds generates a .git-credentials file containing the username and token
 // used for authenticating with git over HTTPS
 // It will create the file in home/.git-credentials
 func WriteGitCreds(gitUser string, gitToken string, gitHostname string, home string, logger *logging.SimpleLogger) error {
-	const credsFilename = ".git-credentials"
-	credsFile := filepath.Join(home, credsFilename)
-	credsFileContents := `https://%s:%s@%s`
-	config := fmt.Sprintf(credsFileContents, gitUser, gitToken, gitHostname)
+	credsFile := filepath.Join(home, gitCredsFilename)
+	config := gitCredsContents(gitUser, gitToken, gitHostname)
 
 	// If there is already a .git-credentials file and its contents aren't exactly
 	// what we would have written to it, then we error out because we don't
@@ -38,11 +43,60 @@ func WriteGitCreds(gitUser string, gitToken string, gitHostname string, home str
 	}
 
 	if err := ioutil.WriteFile(credsFile, []byte(config), 0600); err != nil {
-		return errors.Wrapf(err, "writing generated %s file with user, token and hostname to %s", credsFilename, credsFile)
+		return errors.Wrapf(err, "writing generated %s file with user, token and hostname to %s", gitCredsFilename, credsFile)
 	}
 
 	logger.Info("wrote git credentials to %s", credsFile)
+	return configureGitCreds(gitUser, gitHostname, logger)
+}
+
+// githubAppTokenEnvVar is the env var that GithubAppGitEnv passes the GitHub
+// App installation token to git's credential helper in.
+const githubAppTokenEnvVar = "ATLANTIS_GH_APP_TOKEN"
+
+// GithubAppGitEnv returns env vars that make git authenticate as the GitHub
+// App's installation on repo's owner. Installation tokens expire and each
+// owner has its own so rather than writing a token to the .git-credentials
+// file, which every command shares, git is configured through
+// GIT_CONFIG_COUNT with a credential helper that reads the token from the
+// command's environment. This needs git 2.31 or later. It returns nil if repo
+// isn't on GitHub.
+func GithubAppGitEnv(tokens GithubAppTokens, repo models.Repo) (map[string]string, error) {
+	if repo.VCSHost.Type != models.Github {
+		return nil, nil
+	}
+	cloneURL, err := url.Parse(repo.CloneURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing clone URL of %s", repo.FullName)
+	}
+	token, err := tokens.Token(repo.Owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting GitHub App token for %s", repo.FullName)
+	}
+	credentialKey := fmt.Sprintf("credential.%s://%s.helper", cloneURL.Scheme, cloneURL.Host)
+	return map[string]string{
+		"GIT_CONFIG_COUNT": "3",
+		// An empty helper resets the list of helpers so that one configured
+		// by WriteGitCreds isn't asked first.
+		"GIT_CONFIG_KEY_0":   credentialKey,
+		"GIT_CONFIG_VALUE_0": "",
+		"GIT_CONFIG_KEY_1":   credentialKey,
+		"GIT_CONFIG_VALUE_1": fmt.Sprintf(`!f() { if test "$1" = get; then echo username=%s; echo "password=$%s"; fi; }; f`, vcs.GithubAppGitUser, githubAppTokenEnvVar),
+		// As with the helper, the user mustn't be in the URL.
+		"GIT_CONFIG_KEY_2":   fmt.Sprintf("url.https://%s/.insteadOf", cloneURL.Host),
+		"GIT_CONFIG_VALUE_2": fmt.Sprintf("ssh://git@%s/", cloneURL.Host),
+		githubAppTokenEnvVar: token,
+	}, nil
+}
+
+// gitCredsContents returns the .git-credentials file for gitUser.
+func gitCredsContents(gitUser string, gitToken string, gitHostname string) string {
+	return fmt.Sprintf("https://%s:%s@%s", gitUser, gitToken, gitHostname)
+}
 
+// configureGitCreds configures git to use the .git-credentials file and to
+// use HTTPS instead of SSH for gitHostname.
+func configureGitCreds(gitUser string, gitHostname string, logger *logging.SimpleLogger) error {
 	credentialCmd := exec.Command("git", "config", "--global", "credential.helper", "store")
 	if out, err := credentialCmd.CombinedOutput(); err != nil {
 		return errors.Wrapf(err, "There was an error running %s: %s", strings.Join(credentialCmd.Args, " "), string(out))
@@ -3,11 +3,14 @@ package events_test
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -110,3 +113,36 @@ func TestWriteGitCreds_ConfigureGitUrlOverride(t *testing.T) {
 	Ok(t, err)
 	Equals(t, expOutput+"\n", string(actOutput))
 }
+
+// Test that git gets the GitHub App token for the repo's owner from the env
+// and that other hosts and VCSs are left alone.
+func TestGithubAppGitEnv(t *testing.T) {
+	tokens := &fakeGithubAppTokens{}
+	repo, err := models.NewRepo(models.Github, "owner/repo", "https://github.com/owner/repo.git", "x-access-token", "")
+	Ok(t, err)
+	env, err := events.GithubAppGitEnv(tokens, repo)
+	Ok(t, err)
+	Equals(t, []string{"owner"}, tokens.owners)
+
+	credentialFill := func(host string) string {
+		cmd := exec.Command("git", "credential", "fill")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=")
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+		}
+		out, _ := cmd.Output()
+		return string(out)
+	}
+	out := credentialFill("github.com")
+	Assert(t, strings.Contains(out, "username=x-access-token\n"), "exp username in %q", out)
+	Assert(t, strings.Contains(out, "password=token-for-owner\n"), "exp password in %q", out)
+	out = credentialFill("example.com")
+	Assert(t, !strings.Contains(out, "token-for-owner"), "exp no token for other hosts in %q", out)
+
+	repo, err = models.NewRepo(models.Gitlab, "owner/repo", "https://gitlab.com/owner/repo.git", "user", "token")
+	Ok(t, err)
+	env, err = events.GithubAppGitEnv(tokens, repo)
+	Ok(t, err)
+	Assert(t, env == nil, "exp no env for GitLab")
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// LockQueueRunner plans the next pull request waiting for a lock once the lock
+// is released. It implements locking.QueueNotifier.
+type LockQueueRunner struct {
+	DB            locking.Backend
+	CommandRunner CommandRunner
+	VCSClient     vcs.Client
+	Logger        logging.SimpleLogging
+}
+
+// LockReleased removes the first pull request from the queue for the lock for
+// project and workspace and runs plan for that project in the pull request.
+// If the pull request can't get the lock, ex. because another pull request
+// got it first, it's added back to the end of the queue.
+func (l *LockQueueRunner) LockReleased(project models.Project, workspace string) {
+	next, err := l.DB.Dequeue(project, workspace)
+	if err != nil {
+		l.Logger.Err("unable to get next pull request waiting for lock for %s/%s/%s: %s", project.RepoFullName, project.Path, workspace, err)
+		return
+	}
+	if next == nil {
+		return
+	}
+
+	l.Logger.Info("lock for %s/%s/%s was released, planning pull request #%d which was waiting for it", project.RepoFullName, project.Path, workspace, next.Pull.Num)
+	comment := fmt.Sprintf("The lock for dir: `%s` workspace: `%s` has been released. Planning it again since this pull request was waiting for it.", project.Path, workspace)
+	if err := l.VCSClient.CreateComment(next.Pull.BaseRepo, next.Pull.Num, comment); err != nil {
+		l.Logger.Err("unable to comment on pull request #%d: %s", next.Pull.Num, err)
+	}
+	// Some VCS hosts need the pull request and head repo to be passed in
+	// since they can't be looked up.
+	l.CommandRunner.RunCommentCommand(next.Pull.BaseRepo, &next.HeadRepo, &next.Pull, next.User, next.Pull.Num, &CommentCommand{
+		Name:       models.PlanCommand,
+		RepoRelDir: project.Path,
+		Workspace:  workspace,
+	}, "")
+}
@@ -0,0 +1,65 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/fixtures"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that when a lock is released we comment on and plan the first pull
+// request waiting for it.
+func TestLockQueueRunner_LockReleased(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	commandRunner := mocks.NewMockCommandRunner()
+	vcsClient := vcsmocks.NewMockClient()
+	runner := events.LockQueueRunner{
+		DB:            boltDB,
+		CommandRunner: commandRunner,
+		VCSClient:     vcsClient,
+		Logger:        logging.NewNoopLogger(),
+	}
+
+	project := models.NewProject(fixtures.GithubRepo.FullName, "dir")
+	user := models.User{Username: "lkysow"}
+	headRepo := models.Repo{FullName: "fork/atlantis"}
+	var pulls []models.PullRequest
+	for _, num := range []int{2, 3} {
+		pull := fixtures.Pull
+		pull.Num = num
+		pull.BaseRepo = fixtures.GithubRepo
+		pulls = append(pulls, pull)
+		_, err := boltDB.Enqueue(models.ProjectLock{Project: project, Workspace: "default", Pull: pull, HeadRepo: headRepo, User: user})
+		Ok(t, err)
+	}
+
+	runner.LockReleased(project, "default")
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, 2, "The lock for dir: `dir` workspace: `default` has been released. Planning it again since this pull request was waiting for it.")
+	commandRunner.VerifyWasCalledOnce().RunCommentCommand(fixtures.GithubRepo, &headRepo, &pulls[0], user, 2, &events.CommentCommand{
+		Name:       models.PlanCommand,
+		RepoRelDir: "dir",
+		Workspace:  "default",
+	}, "")
+
+	// Pull #3 is next.
+	queue, err := boltDB.GetQueue(project, "default")
+	Ok(t, err)
+	Equals(t, 1, len(queue))
+	Equals(t, 3, queue[0].Pull.Num)
+
+	// If nothing is queued, nothing happens.
+	runner.LockReleased(models.NewProject(fixtures.GithubRepo.FullName, "other"), "default")
+	commandRunner.VerifyWasCalledOnce().RunCommentCommand(matchers.AnyModelsRepo(), matchers.AnyPtrToModelsRepo(), matchers.AnyPtrToModelsPullRequest(), matchers.AnyModelsUser(), AnyInt(), matchers.AnyPtrToEventsCommentCommand(), AnyString())
+}
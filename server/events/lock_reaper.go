@@ -0,0 +1,221 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// LockReaper periodically releases locks that should no longer be held. This
+// cleans up after pull requests whose closed webhook we never received.
+// It releases:
+// - all the locks of pull requests that have been closed or merged, using the
+//   same cleanup as when the pull request's closed webhook is received
+// - locks that have expired, see models.ProjectLock.ExpiresAt
+// It also deletes the working dirs of pull requests that don't hold any locks.
+type LockReaper struct {
+	Locker           locking.Locker
+	VCSClient        vcs.Client
+	PullCleaner      PullCleaner
+	WorkingDir       WorkingDir
+	WorkingDirLocker WorkingDirLocker
+	DB               locking.Backend
+	// DataDir is the directory that Atlantis stores its data in. Working
+	// dirs are cloned under it.
+	DataDir string
+	Logger  *logging.SimpleLogger
+	// Interval is how long we wait between checks.
+	Interval time.Duration
+}
+
+// Run reaps stale locks immediately and then every Interval until stop is
+// closed.
+func (r *LockReaper) Run(stop <-chan struct{}) {
+	r.Logger.Info("stale lock reaper enabled, checking every %s", r.Interval)
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		r.Reap()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reap releases stale locks and deletes orphaned working dirs once.
+func (r *LockReaper) Reap() {
+	locks, err := r.Locker.List()
+	if err != nil {
+		r.Logger.Err("unable to list locks: %s", err)
+		return
+	}
+
+	// Check each pull request only once even if it holds multiple locks.
+	closedPulls := make(map[string]bool)
+	for _, lock := range locks {
+		key := r.pullKey(lock.Project.RepoFullName, lock.Pull.Num)
+		// Locks created by older versions of Atlantis don't have the pull's
+		// repo so we can't look them up.
+		if _, checked := closedPulls[key]; checked || lock.Pull.BaseRepo == (models.Repo{}) {
+			continue
+		}
+		closed, err := r.VCSClient.PullIsClosed(lock.Pull.BaseRepo, lock.Pull)
+		if err != nil {
+			r.Logger.Warn("unable to check if pull request %s#%d is closed: %s", lock.Project.RepoFullName, lock.Pull.Num, err)
+			closedPulls[key] = false
+			continue
+		}
+		closedPulls[key] = closed
+		if closed {
+			r.Logger.Info("pull request %s#%d is closed, releasing its locks", lock.Project.RepoFullName, lock.Pull.Num)
+			if err := r.PullCleaner.CleanUpPull(lock.Pull.BaseRepo, lock.Pull); err != nil {
+				r.Logger.Err("unable to clean up pull request %s#%d: %s", lock.Project.RepoFullName, lock.Pull.Num, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	for key, lock := range locks {
+		if closedPulls[r.pullKey(lock.Project.RepoFullName, lock.Pull.Num)] || !lock.Expired(now) {
+			continue
+		}
+		r.releaseExpired(key, now)
+	}
+
+	r.deleteOrphanedWorkingDirs()
+}
+
+// releaseExpired releases lock because it expired, discards its plan and
+// comments on the pull request. Its pull request may have locked it again
+// since we listed it, which restarts its TTL, so it's only released if it's
+// still expired.
+func (r *LockReaper) releaseExpired(key string, now time.Time) {
+	lock, err := r.Locker.UnlockIfExpired(key, now)
+	if err != nil {
+		r.Logger.Err("unable to release expired lock %q: %s", key, err)
+		return
+	}
+	if lock == nil {
+		return
+	}
+	r.Logger.Info("lock %q expired at %s, released it", key, lock.ExpiresAt)
+	if lock.Pull.BaseRepo == (models.Repo{}) {
+		return
+	}
+
+	unlock, err := r.WorkingDirLocker.TryLock(lock.Pull.BaseRepo.FullName, lock.Pull.Num, lock.Workspace)
+	if err != nil {
+		r.Logger.Err("unable to obtain working dir lock when trying to delete old plans: %s", err)
+	} else {
+		if err := r.WorkingDir.DeleteForWorkspace(lock.Pull.BaseRepo, lock.Pull, lock.Workspace); err != nil {
+			r.Logger.Err("unable to delete workspace: %s", err)
+		}
+		unlock()
+	}
+	if err := r.DB.DeleteProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path); err != nil {
+		r.Logger.Err("unable to delete project status: %s", err)
+	}
+
+	comment := fmt.Sprintf("**Warning**: The lock for dir: `%s` workspace: `%s` **expired** so it was released and its plan was discarded.\n\n"+
+		"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace)
+	if err := r.VCSClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment); err != nil {
+		r.Logger.Err("unable to comment on pull request %s#%d: %s", lock.Project.RepoFullName, lock.Pull.Num, err)
+	}
+}
+
+// deleteOrphanedWorkingDirs deletes the working dirs of pull requests that
+// don't hold any locks. Without a lock there's no plan to apply so if the
+// pull request is still open, we'll just clone it again the next time it's
+// planned. Dirs used in the last Interval are kept since commands clone
+// before they lock their projects.
+func (r *LockReaper) deleteOrphanedWorkingDirs() {
+	// We list the locks again since we may have just released some.
+	locks, err := r.Locker.List()
+	if err != nil {
+		r.Logger.Err("unable to list locks: %s", err)
+		return
+	}
+	lockedPulls := make(map[string]bool)
+	for _, lock := range locks {
+		lockedPulls[r.pullKey(lock.Project.RepoFullName, lock.Pull.Num)] = true
+	}
+
+	reposDir := filepath.Join(r.DataDir, workingDirPrefix)
+	pullDirs, err := r.findPullDirs(reposDir)
+	if err != nil {
+		r.Logger.Err("unable to find working dirs in %q: %s", reposDir, err)
+		return
+	}
+	for _, pullDir := range pullDirs {
+		rel, err := filepath.Rel(reposDir, pullDir)
+		if err != nil {
+			continue
+		}
+		repoFullName := filepath.ToSlash(filepath.Dir(rel))
+		pullNum, err := strconv.Atoi(filepath.Base(rel))
+		if err != nil || lockedPulls[r.pullKey(repoFullName, pullNum)] {
+			continue
+		}
+		if info, err := os.Stat(pullDir); err != nil || time.Since(info.ModTime()) < r.Interval {
+			continue
+		}
+
+		// If we can't get the lock then a command is running for this pull
+		// request so it's not orphaned.
+		unlock, err := r.WorkingDirLocker.TryLockPull(repoFullName, pullNum)
+		if err != nil {
+			continue
+		}
+		r.Logger.Info("deleting orphaned working dir %q", pullDir)
+		if err := os.RemoveAll(pullDir); err != nil {
+			r.Logger.Err("unable to delete orphaned working dir %q: %s", pullDir, err)
+		}
+		unlock()
+	}
+}
+
+// findPullDirs returns the dirs under reposDir that have workspaces cloned in
+// them, i.e. reposDir/{repoFullName}/{pullNum}.
+func (r *LockReaper) findPullDirs(reposDir string) ([]string, error) {
+	seen := make(map[string]bool)
+	var pullDirs []string
+	err := filepath.Walk(reposDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		// The default branch clones used by drift detection aren't for a
+		// pull request.
+		if info.Name() == defaultBranchDirName {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			pullDir := filepath.Dir(path)
+			if !seen[pullDir] {
+				seen[pullDir] = true
+				pullDirs = append(pullDirs, pullDir)
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return pullDirs, err
+}
+
+func (r *LockReaper) pullKey(repoFullName string, pullNum int) string {
+	return fmt.Sprintf("%s#%d", repoFullName, pullNum)
+}
@@ -0,0 +1,127 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	lockmocks "github.com/runatlantis/atlantis/server/events/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/fixtures"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLockReaper_Reap(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltDB, err := db.New(tmp)
+	Ok(t, err)
+	locker := lockmocks.NewMockLocker()
+	vcsClient := vcsmocks.NewMockClient()
+	pullCleaner := mocks.NewMockPullCleaner()
+	workingDir := mocks.NewMockWorkingDir()
+	reaper := events.LockReaper{
+		Locker:           locker,
+		VCSClient:        vcsClient,
+		PullCleaner:      pullCleaner,
+		WorkingDir:       workingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		DB:               boltDB,
+		DataDir:          tmp,
+		Logger:           logging.NewNoopLogger(),
+	}
+
+	repo := fixtures.GithubRepo
+	newLock := func(num int, expiresAt time.Time) models.ProjectLock {
+		return models.ProjectLock{
+			Project:   models.NewProject(repo.FullName, "."),
+			Workspace: "default",
+			Pull:      models.PullRequest{Num: num, BaseRepo: repo},
+			ExpiresAt: expiresAt,
+		}
+	}
+	closedLock := newLock(1, time.Time{})
+	expiredLock := newLock(2, time.Now().Add(-time.Hour))
+	activeLock := newLock(3, time.Now().Add(time.Hour))
+	When(locker.List()).ThenReturn(map[string]models.ProjectLock{
+		"closed":  closedLock,
+		"expired": expiredLock,
+		"active":  activeLock,
+	}, nil)
+	When(vcsClient.PullIsClosed(repo, closedLock.Pull)).ThenReturn(true, nil)
+	When(locker.UnlockIfExpired(EqString("expired"), matchers.AnyTimeTime())).ThenReturn(&expiredLock, nil)
+
+	// Pull 4 doesn't hold any locks so its working dir is orphaned. The
+	// default branch clone isn't for a pull request so it's kept.
+	reposDir := filepath.Join(tmp, "repos", repo.FullName)
+	for _, dir := range []string{"3/default/.git", "4/default/.git", "4/staging/.git", "5/default/.git", "default-branch/.git"} {
+		Ok(t, os.MkdirAll(filepath.Join(reposDir, dir), 0700))
+	}
+	// Pull 5 was just cloned so its command may not have locked its projects
+	// yet.
+	reaper.Interval = time.Hour
+	old := time.Now().Add(-2 * time.Hour)
+	Ok(t, os.Chtimes(filepath.Join(reposDir, "4"), old, old))
+
+	reaper.Reap()
+
+	pullCleaner.VerifyWasCalledOnce().CleanUpPull(repo, closedLock.Pull)
+	locker.VerifyWasCalledOnce().UnlockIfExpired(EqString("expired"), matchers.AnyTimeTime())
+	locker.VerifyWasCalled(Never()).UnlockIfExpired(EqString("active"), matchers.AnyTimeTime())
+	locker.VerifyWasCalled(Never()).Unlock(AnyString())
+	workingDir.VerifyWasCalledOnce().DeleteForWorkspace(repo, expiredLock.Pull, "default")
+	vcsClient.VerifyWasCalledOnce().CreateComment(repo, 2, "**Warning**: The lock for dir: `.` workspace: `default` **expired** so it was released and its plan was discarded.\n\n"+
+		"To `apply` this plan you must run `plan` again.")
+	vcsClient.VerifyWasCalledOnce().CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString())
+
+	_, err = os.Stat(filepath.Join(reposDir, "3"))
+	Ok(t, err)
+	_, err = os.Stat(filepath.Join(reposDir, "4"))
+	Assert(t, os.IsNotExist(err), "expected orphaned working dir to be deleted but got %v", err)
+	_, err = os.Stat(filepath.Join(reposDir, "5"))
+	Ok(t, err)
+	_, err = os.Stat(filepath.Join(reposDir, "default-branch"))
+	Ok(t, err)
+}
+
+// Test that a lock that expired when it was listed but whose pull request has
+// locked it again since isn't released.
+func TestLockReaper_ReapRenewedLock(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	locker := lockmocks.NewMockLocker()
+	vcsClient := vcsmocks.NewMockClient()
+	workingDir := mocks.NewMockWorkingDir()
+	reaper := events.LockReaper{
+		Locker:           locker,
+		VCSClient:        vcsClient,
+		WorkingDir:       workingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		DataDir:          tmp,
+		Logger:           logging.NewNoopLogger(),
+	}
+	expiredLock := models.ProjectLock{
+		Project:   models.NewProject(fixtures.GithubRepo.FullName, "."),
+		Workspace: "default",
+		Pull:      models.PullRequest{Num: 2, BaseRepo: fixtures.GithubRepo},
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	When(locker.List()).ThenReturn(map[string]models.ProjectLock{"renewed": expiredLock}, nil)
+	When(locker.UnlockIfExpired(EqString("renewed"), matchers.AnyTimeTime())).ThenReturn(nil, nil)
+
+	reaper.Reap()
+
+	locker.VerifyWasCalledOnce().UnlockIfExpired(EqString("renewed"), matchers.AnyTimeTime())
+	workingDir.VerifyWasCalled(Never()).DeleteForWorkspace(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())
+	vcsClient.VerifyWasCalled(Never()).CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString())
+}
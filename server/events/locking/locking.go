@@ -17,21 +17,97 @@ package locking
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"time"
 
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_backend.go Backend
 
-// Backend is an implementation of the locking API we require.
+// Backend is an implementation of the locking API we require. It also stores
+// the status of each pull request since both need to be shared by all
+// Atlantis instances using the same backend.
 type Backend interface {
 	TryLock(lock models.ProjectLock) (bool, models.ProjectLock, error)
 	Unlock(project models.Project, workspace string) (*models.ProjectLock, error)
 	List() ([]models.ProjectLock, error)
 	GetLock(project models.Project, workspace string) (*models.ProjectLock, error)
 	UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error)
+	// UnlockIfExpired deletes the lock for project and workspace if it has
+	// expired by now. It returns the deleted lock or nil if there's no lock
+	// or it hasn't expired, ex. because its pull request locked it again.
+	UnlockIfExpired(project models.Project, workspace string, now time.Time) (*models.ProjectLock, error)
+
+	// Enqueue adds lock's pull request to the end of the queue of pull
+	// requests waiting for the lock for lock's project and workspace. It
+	// returns the pull request's position in the queue, starting at 1.
+	// Acquiring the lock or unlocking by pull removes a pull request from the
+	// queue.
+	Enqueue(lock models.ProjectLock) (int, error)
+	// Dequeue removes the first pull request from the queue for the lock
+	// for project and workspace and returns it. If the queue is empty, it
+	// returns a nil pointer.
+	Dequeue(project models.Project, workspace string) (*models.ProjectLock, error)
+	// GetQueue returns the pull requests waiting for the lock for project
+	// and workspace, in the order they'll get it.
+	GetQueue(project models.Project, workspace string) ([]models.ProjectLock, error)
+
+	// UpdatePullWithResults updates pull's status with the latest project
+	// results. It returns the new PullStatus object.
+	UpdatePullWithResults(pull models.PullRequest, newResults []models.ProjectResult) (models.PullStatus, error)
+	// GetPullStatus returns the status for pull.
+	// If there is no status, returns a nil pointer.
+	GetPullStatus(pull models.PullRequest) (*models.PullStatus, error)
+	// DeletePullStatus deletes the status for pull.
+	DeletePullStatus(pull models.PullRequest) error
+	// DeleteProjectStatus deletes all project statuses under pull that match
+	// workspace and repoRelDir.
+	DeleteProjectStatus(pull models.PullRequest, workspace string, repoRelDir string) error
+
+	// UpdateDriftStatus stores the result of checking a project for drift,
+	// overwriting the previous result for that project.
+	UpdateDriftStatus(status models.DriftStatus) error
+	// GetDriftStatuses returns the latest drift status of every project
+	// that has been checked for drift.
+	GetDriftStatuses() ([]models.DriftStatus, error)
+	// UpdateDriftRepo stores repo so that it can be checked for drift,
+	// overwriting the previously stored repo with the same ID.
+	UpdateDriftRepo(repo models.Repo) error
+	// GetDriftRepos returns the repos stored by UpdateDriftRepo.
+	GetDriftRepos() ([]models.Repo, error)
+
+	// AddProjectRuns adds runs to the history of pull.
+	AddProjectRuns(pull models.PullRequest, runs []models.ProjectRun) error
+	// GetProjectRuns returns the history of pull, oldest run first.
+	GetProjectRuns(pull models.PullRequest) ([]models.ProjectRun, error)
+	// DeleteProjectRunsBefore deletes the runs of every pull request that
+	// finished before t. It returns how many runs were deleted.
+	DeleteProjectRunsBefore(t time.Time) (int, error)
+
+	// EnqueueEvent adds event to the event queue. If an event with the same
+	// ID has already been received, it isn't added and EnqueueEvent returns
+	// false.
+	EnqueueEvent(event models.QueuedEvent) (bool, error)
+	// GetEvents returns the events in the event queue, oldest first.
+	GetEvents() ([]models.QueuedEvent, error)
+	// StartEvent marks the pending event with id as running, increments
+	// its attempts and sets its HeartbeatAt to now. It returns the updated
+	// event or nil if the event isn't pending, ex. because it was already
+	// started.
+	StartEvent(id string) (*models.QueuedEvent, error)
+	// UpdateEvent replaces the event with the same ID as event.
+	UpdateEvent(event models.QueuedEvent) error
+	// DeleteEvent deletes the event with id from the event queue.
+	DeleteEvent(id string) error
+	// DeleteProcessedEventsBefore deletes the processed events that were
+	// received before t. It returns how many were deleted.
+	DeleteProcessedEventsBefore(t time.Time) (int, error)
+
+	// Close closes the backend. It's called when Atlantis shuts down.
+	Close() error
 }
 
 // TryLockResponse results from an attempted lock.
@@ -42,21 +118,38 @@ type TryLockResponse struct {
 	CurrLock models.ProjectLock
 	// LockKey is an identified by which to lookup and delete this lock.
 	LockKey string
+	// QueuePosition is the position of the pull request in the queue of
+	// pull requests waiting for the lock, starting at 1. It's 0 if the lock
+	// was acquired or the pull request wasn't queued.
+	QueuePosition int
+}
+
+// QueueNotifier is notified when locks are released so that the pull requests
+// waiting for them can continue.
+type QueueNotifier interface {
+	// LockReleased is called after the lock for project and workspace is
+	// released.
+	LockReleased(project models.Project, workspace string)
 }
 
 // Client is used to perform locking actions.
 type Client struct {
-	backend Backend
+	backend  Backend
+	notifier QueueNotifier
+	ttl      time.Duration
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_locker.go Locker
 
 type Locker interface {
-	TryLock(p models.Project, workspace string, pull models.PullRequest, user models.User) (TryLockResponse, error)
+	TryLock(p models.Project, workspace string, pull models.PullRequest, headRepo models.Repo, user models.User) (TryLockResponse, error)
 	Unlock(key string) (*models.ProjectLock, error)
 	List() (map[string]models.ProjectLock, error)
 	UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error)
 	GetLock(key string) (*models.ProjectLock, error)
+	// UnlockIfExpired deletes the lock at key if it has expired by now. It
+	// returns the deleted lock or nil if the lock wasn't deleted.
+	UnlockIfExpired(key string, now time.Time) (*models.ProjectLock, error)
 }
 
 // NewClient returns a new locking client.
@@ -66,23 +159,55 @@ func NewClient(backend Backend) *Client {
 	}
 }
 
+// SetQueueNotifier sets the notifier that's called in the background each
+// time a lock is released.
+func (c *Client) SetQueueNotifier(n QueueNotifier) {
+	c.notifier = n
+}
+
+// SetLockTTL sets how long new locks are held for before they expire. If ttl
+// is 0, locks don't expire.
+func (c *Client) SetLockTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
 // keyRegex matches and captures {repoFullName}/{path}/{workspace} where path can have multiple /'s in it.
 var keyRegex = regexp.MustCompile(`^(.*?\/.*?)\/(.*)\/(.*)$`)
 
-// TryLock attempts to acquire a lock to a project and workspace.
-func (c *Client) TryLock(p models.Project, workspace string, pull models.PullRequest, user models.User) (TryLockResponse, error) {
+// TryLock attempts to acquire a lock to a project and workspace. If the lock
+// is held by another pull request, pull is added to the lock's queue along
+// with headRepo so that it can be planned once it gets the lock. If pull
+// already holds the lock, the lock's TTL starts again.
+// Requests that aren't for a pull request, i.e. pull.Num isn't positive,
+// aren't queued since we can't comment on them once the lock is released.
+func (c *Client) TryLock(p models.Project, workspace string, pull models.PullRequest, headRepo models.Repo, user models.User) (TryLockResponse, error) {
 	lock := models.ProjectLock{
 		Workspace: workspace,
 		Time:      time.Now().Local(),
 		Project:   p,
 		User:      user,
 		Pull:      pull,
+		HeadRepo:  headRepo,
+	}
+	if c.ttl > 0 {
+		lock.ExpiresAt = lock.Time.Add(c.ttl)
 	}
 	lockAcquired, currLock, err := c.backend.TryLock(lock)
 	if err != nil {
 		return TryLockResponse{}, err
 	}
-	return TryLockResponse{lockAcquired, currLock, c.key(p, workspace)}, nil
+	resp := TryLockResponse{
+		LockAcquired: lockAcquired,
+		CurrLock:     currLock,
+		LockKey:      c.key(p, workspace),
+	}
+	if !lockAcquired && currLock.Pull.Num != pull.Num && pull.Num > 0 {
+		resp.QueuePosition, err = c.backend.Enqueue(lock)
+		if err != nil {
+			return TryLockResponse{}, err
+		}
+	}
+	return resp, nil
 }
 
 // Unlock attempts to unlock a project and workspace. If successful,
@@ -94,7 +219,26 @@ func (c *Client) Unlock(key string) (*models.ProjectLock, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.backend.Unlock(project, workspace)
+	lock, err := c.backend.Unlock(project, workspace)
+	if lock != nil {
+		c.lockReleased(*lock)
+	}
+	return lock, err
+}
+
+// UnlockIfExpired deletes the lock at key if it has expired by now. The check
+// is done by the backend when deleting the lock so a lock whose TTL was just
+// restarted isn't deleted.
+func (c *Client) UnlockIfExpired(key string, now time.Time) (*models.ProjectLock, error) {
+	project, workspace, err := c.lockKeyToProjectWorkspace(key)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := c.backend.UnlockIfExpired(project, workspace, now)
+	if lock != nil {
+		c.lockReleased(*lock)
+	}
+	return lock, err
 }
 
 // List returns a map of all locks with their lock key as the map key.
@@ -113,7 +257,11 @@ func (c *Client) List() (map[string]models.ProjectLock, error) {
 
 // UnlockByPull deletes all locks associated with that pull request.
 func (c *Client) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
-	return c.backend.UnlockByPull(repoFullName, pullNum)
+	locks, err := c.backend.UnlockByPull(repoFullName, pullNum)
+	for _, lock := range locks {
+		c.lockReleased(lock)
+	}
+	return locks, err
 }
 
 // GetLock attempts to get the lock stored at key. If successful,
@@ -134,6 +282,15 @@ func (c *Client) GetLock(key string) (*models.ProjectLock, error) {
 	return projectLock, nil
 }
 
+// lockReleased notifies c.notifier, if set, that lock was released. It
+// doesn't wait for the notifier so that callers aren't slowed down by
+// whatever the notifier does with the next pull request.
+func (c *Client) lockReleased(lock models.ProjectLock) {
+	if c.notifier != nil {
+		go c.notifier.LockReleased(lock.Project, lock.Workspace)
+	}
+}
+
 func (c *Client) key(p models.Project, workspace string) string {
 	return fmt.Sprintf("%s/%s/%s", p.RepoFullName, p.Path, workspace)
 }
@@ -146,3 +303,28 @@ func (c *Client) lockKeyToProjectWorkspace(key string) (models.Project, string,
 
 	return models.Project{RepoFullName: matches[1], Path: matches[2]}, matches[3], nil
 }
+
+// RegisterMetrics registers gauges for the number of locks held and the age of
+// the oldest lock. They're computed from l each time the metrics are scraped.
+func RegisterMetrics(reg *metrics.Registry, l Locker) {
+	reg.NewGaugeFunc("locks", "Number of project locks currently held.", func() float64 {
+		locks, err := l.List()
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(len(locks))
+	})
+	reg.NewGaugeFunc("lock_oldest_age_seconds", "Age of the oldest project lock currently held.", func() float64 {
+		locks, err := l.List()
+		if err != nil {
+			return math.NaN()
+		}
+		var oldest float64
+		for _, lock := range locks {
+			if age := time.Since(lock.Time).Seconds(); age > oldest {
+				oldest = age
+			}
+		}
+		return oldest
+	})
+}
@@ -15,6 +15,7 @@ package locking_test
 
 import (
 	"errors"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -25,12 +26,14 @@ import (
 	"github.com/runatlantis/atlantis/server/events/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events/locking/mocks/matchers"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/metrics"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
 var project = models.NewProject("owner/repo", "path")
 var workspace = "workspace"
 var pull = models.PullRequest{}
+var headRepo = models.Repo{FullName: "owner/repo"}
 var user = models.User{}
 var errExpected = errors.New("err")
 var timeNow = time.Now().Local()
@@ -42,7 +45,7 @@ func TestTryLock_Err(t *testing.T) {
 	When(backend.TryLock(matchers.AnyModelsProjectLock())).ThenReturn(false, models.ProjectLock{}, errExpected)
 	t.Log("when the backend returns an error, TryLock should return that error")
 	l := locking.NewClient(backend)
-	_, err := l.TryLock(project, workspace, pull, user)
+	_, err := l.TryLock(project, workspace, pull, headRepo, user)
 	Equals(t, err, err)
 }
 
@@ -52,11 +55,47 @@ func TestTryLock_Success(t *testing.T) {
 	backend := mocks.NewMockBackend()
 	When(backend.TryLock(matchers.AnyModelsProjectLock())).ThenReturn(true, currLock, nil)
 	l := locking.NewClient(backend)
-	r, err := l.TryLock(project, workspace, pull, user)
+	r, err := l.TryLock(project, workspace, pull, headRepo, user)
 	Ok(t, err)
 	Equals(t, locking.TryLockResponse{LockAcquired: true, CurrLock: currLock, LockKey: "owner/repo/path/workspace"}, r)
 }
 
+func TestTryLock_TTL(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	When(backend.TryLock(matchers.AnyModelsProjectLock())).ThenReturn(true, models.ProjectLock{}, nil)
+	l := locking.NewClient(backend)
+	l.SetLockTTL(time.Hour)
+	_, err := l.TryLock(project, workspace, pull, headRepo, user)
+	Ok(t, err)
+	lock := backend.VerifyWasCalledOnce().TryLock(matchers.AnyModelsProjectLock()).GetCapturedArguments()
+	Equals(t, lock.Time.Add(time.Hour), lock.ExpiresAt)
+	Assert(t, !lock.Expired(lock.Time.Add(time.Minute)), "lock should not have expired yet")
+	Assert(t, lock.Expired(lock.Time.Add(2*time.Hour)), "lock should have expired")
+}
+
+func TestTryLock_Queued(t *testing.T) {
+	RegisterMockTestingT(t)
+	currLock := models.ProjectLock{Pull: models.PullRequest{Num: 1}}
+	queuedPull := models.PullRequest{Num: 2}
+	backend := mocks.NewMockBackend()
+	When(backend.TryLock(matchers.AnyModelsProjectLock())).ThenReturn(false, currLock, nil)
+	When(backend.Enqueue(matchers.AnyModelsProjectLock())).ThenReturn(2, nil)
+	l := locking.NewClient(backend)
+	r, err := l.TryLock(project, workspace, queuedPull, headRepo, user)
+	Ok(t, err)
+	Equals(t, locking.TryLockResponse{LockAcquired: false, CurrLock: currLock, LockKey: "owner/repo/path/workspace", QueuePosition: 2}, r)
+	queued := backend.VerifyWasCalledOnce().Enqueue(matchers.AnyModelsProjectLock()).GetCapturedArguments()
+	Equals(t, queuedPull, queued.Pull)
+	Equals(t, headRepo, queued.HeadRepo)
+
+	t.Log("requests that aren't for a pull request shouldn't be queued")
+	r, err = l.TryLock(project, workspace, models.PullRequest{}, headRepo, user)
+	Ok(t, err)
+	Equals(t, 0, r.QueuePosition)
+	backend.VerifyWasCalledOnce().Enqueue(matchers.AnyModelsProjectLock())
+}
+
 func TestUnlock_InvalidKey(t *testing.T) {
 	RegisterMockTestingT(t)
 	backend := mocks.NewMockBackend()
@@ -87,6 +126,52 @@ func TestUnlock(t *testing.T) {
 	Equals(t, &pl, lock)
 }
 
+// notifierFunc implements locking.QueueNotifier.
+type notifierFunc func(project models.Project, workspace string)
+
+func (n notifierFunc) LockReleased(project models.Project, workspace string) {
+	n(project, workspace)
+}
+
+func TestUnlock_NotifiesQueue(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	When(backend.Unlock(matchers.AnyModelsProject(), AnyString())).ThenReturn(&pl, nil)
+	When(backend.UnlockByPull("owner/repo", 1)).ThenReturn([]models.ProjectLock{pl}, nil)
+	When(backend.UnlockIfExpired(matchers.AnyModelsProject(), AnyString(), matchers.AnyTimeTime())).ThenReturn(&pl, nil)
+	released := make(chan string, 3)
+	l := locking.NewClient(backend)
+	l.SetQueueNotifier(notifierFunc(func(p models.Project, w string) {
+		released <- p.Path + "/" + w
+	}))
+
+	_, err := l.Unlock("owner/repo/path/workspace")
+	Ok(t, err)
+	_, err = l.UnlockByPull("owner/repo", 1)
+	Ok(t, err)
+	_, err = l.UnlockIfExpired("owner/repo/path/workspace", time.Now())
+	Ok(t, err)
+	for i := 0; i < 3; i++ {
+		select {
+		case r := <-released:
+			Equals(t, "path/workspace", r)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for notifier")
+		}
+	}
+}
+
+func TestUnlockIfExpired(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	now := time.Now()
+	When(backend.UnlockIfExpired(project, workspace, now)).ThenReturn(&pl, nil)
+	l := locking.NewClient(backend)
+	lock, err := l.UnlockIfExpired("owner/repo/path/workspace", now)
+	Ok(t, err)
+	Equals(t, &pl, lock)
+}
+
 func TestList_Err(t *testing.T) {
 	RegisterMockTestingT(t)
 	backend := mocks.NewMockBackend()
@@ -144,3 +229,20 @@ func TestGetLock(t *testing.T) {
 	Ok(t, err)
 	Equals(t, &pl, lock)
 }
+
+func TestRegisterMetrics(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	When(backend.List()).ThenReturn([]models.ProjectLock{
+		{Project: project, Workspace: workspace, Time: time.Now().Add(-time.Minute)},
+		{Project: project, Workspace: "other", Time: time.Now()},
+	}, nil)
+	reg := metrics.NewRegistry()
+	locking.RegisterMetrics(reg, locking.NewClient(backend))
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	Assert(t, strings.Contains(body, "atlantis_locks 2\n"), "got %s", body)
+	Assert(t, strings.Contains(body, "atlantis_lock_oldest_age_seconds 60."), "got %s", body)
+}
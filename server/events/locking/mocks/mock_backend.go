@@ -124,6 +124,392 @@ func (mock *MockBackend) UnlockByPull(repoFullName string, pullNum int) ([]model
 	return ret0, ret1
 }
 
+func (mock *MockBackend) UpdatePullWithResults(pull models.PullRequest, newResults []models.ProjectResult) (models.PullStatus, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{pull, newResults}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdatePullWithResults", params, []reflect.Type{reflect.TypeOf((*models.PullStatus)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.PullStatus
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.PullStatus)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) GetPullStatus(pull models.PullRequest) (*models.PullStatus, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{pull}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetPullStatus", params, []reflect.Type{reflect.TypeOf((**models.PullStatus)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *models.PullStatus
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*models.PullStatus)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) DeletePullStatus(pull models.PullRequest) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{pull}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeletePullStatus", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) DeleteProjectStatus(pull models.PullRequest, workspace string, repoRelDir string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{pull, workspace, repoRelDir}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteProjectStatus", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) UpdateDriftStatus(status models.DriftStatus) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{status}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateDriftStatus", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) GetDriftStatuses() ([]models.DriftStatus, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetDriftStatuses", params, []reflect.Type{reflect.TypeOf((*[]models.DriftStatus)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.DriftStatus
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.DriftStatus)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) AddProjectRuns(pull models.PullRequest, runs []models.ProjectRun) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{pull, runs}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("AddProjectRuns", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) GetProjectRuns(pull models.PullRequest) ([]models.ProjectRun, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{pull}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetProjectRuns", params, []reflect.Type{reflect.TypeOf((*[]models.ProjectRun)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.ProjectRun
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.ProjectRun)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) DeleteProjectRunsBefore(t time.Time) (int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{t}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteProjectRunsBefore", params, []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 int
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(int)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) Enqueue(lock models.ProjectLock) (int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{lock}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Enqueue", params, []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 int
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(int)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) Dequeue(project models.Project, workspace string) (*models.ProjectLock, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{project, workspace}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Dequeue", params, []reflect.Type{reflect.TypeOf((**models.ProjectLock)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *models.ProjectLock
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*models.ProjectLock)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) GetQueue(project models.Project, workspace string) ([]models.ProjectLock, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{project, workspace}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetQueue", params, []reflect.Type{reflect.TypeOf((*[]models.ProjectLock)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.ProjectLock
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.ProjectLock)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) Close() error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Close", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) EnqueueEvent(event models.QueuedEvent) (bool, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("EnqueueEvent", params, []reflect.Type{reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 bool
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(bool)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) GetEvents() ([]models.QueuedEvent, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetEvents", params, []reflect.Type{reflect.TypeOf((*[]models.QueuedEvent)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.QueuedEvent
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.QueuedEvent)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) StartEvent(id string) (*models.QueuedEvent, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{id}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("StartEvent", params, []reflect.Type{reflect.TypeOf((**models.QueuedEvent)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *models.QueuedEvent
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*models.QueuedEvent)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) UpdateEvent(event models.QueuedEvent) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateEvent", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) DeleteEvent(id string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{id}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteEvent", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) DeleteProcessedEventsBefore(t time.Time) (int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{t}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteProcessedEventsBefore", params, []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 int
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(int)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) UpdateDriftRepo(repo models.Repo) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{repo}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateDriftRepo", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) GetDriftRepos() ([]models.Repo, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetDriftRepos", params, []reflect.Type{reflect.TypeOf((*[]models.Repo)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.Repo
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) UnlockIfExpired(project models.Project, workspace string, now time.Time) (*models.ProjectLock, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{project, workspace, now}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UnlockIfExpired", params, []reflect.Type{reflect.TypeOf((**models.ProjectLock)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *models.ProjectLock
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*models.ProjectLock)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockBackend) VerifyWasCalledOnce() *VerifierMockBackend {
 	return &VerifierMockBackend{
 		mock:                   mock,
@@ -131,53 +517,439 @@ func (mock *MockBackend) VerifyWasCalledOnce() *VerifierMockBackend {
 	}
 }
 
-func (mock *MockBackend) VerifyWasCalled(invocationCountMatcher pegomock.Matcher) *VerifierMockBackend {
-	return &VerifierMockBackend{
-		mock:                   mock,
-		invocationCountMatcher: invocationCountMatcher,
+func (mock *MockBackend) VerifyWasCalled(invocationCountMatcher pegomock.Matcher) *VerifierMockBackend {
+	return &VerifierMockBackend{
+		mock:                   mock,
+		invocationCountMatcher: invocationCountMatcher,
+	}
+}
+
+func (mock *MockBackend) VerifyWasCalledInOrder(invocationCountMatcher pegomock.Matcher, inOrderContext *pegomock.InOrderContext) *VerifierMockBackend {
+	return &VerifierMockBackend{
+		mock:                   mock,
+		invocationCountMatcher: invocationCountMatcher,
+		inOrderContext:         inOrderContext,
+	}
+}
+
+func (mock *MockBackend) VerifyWasCalledEventually(invocationCountMatcher pegomock.Matcher, timeout time.Duration) *VerifierMockBackend {
+	return &VerifierMockBackend{
+		mock:                   mock,
+		invocationCountMatcher: invocationCountMatcher,
+		timeout:                timeout,
+	}
+}
+
+type VerifierMockBackend struct {
+	mock                   *MockBackend
+	invocationCountMatcher pegomock.Matcher
+	inOrderContext         *pegomock.InOrderContext
+	timeout                time.Duration
+}
+
+func (verifier *VerifierMockBackend) TryLock(lock models.ProjectLock) *MockBackend_TryLock_OngoingVerification {
+	params := []pegomock.Param{lock}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params, verifier.timeout)
+	return &MockBackend_TryLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_TryLock_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_TryLock_OngoingVerification) GetCapturedArguments() models.ProjectLock {
+	lock := c.GetAllCapturedArguments()
+	return lock[len(lock)-1]
+}
+
+func (c *MockBackend_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.ProjectLock) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.ProjectLock, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.ProjectLock)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) Unlock(project models.Project, workspace string) *MockBackend_Unlock_OngoingVerification {
+	params := []pegomock.Param{project, workspace}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Unlock", params, verifier.timeout)
+	return &MockBackend_Unlock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_Unlock_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_Unlock_OngoingVerification) GetCapturedArguments() (models.Project, string) {
+	project, workspace := c.GetAllCapturedArguments()
+	return project[len(project)-1], workspace[len(workspace)-1]
+}
+
+func (c *MockBackend_Unlock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Project, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Project)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) List() *MockBackend_List_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "List", params, verifier.timeout)
+	return &MockBackend_List_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_List_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_List_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_List_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) GetLock(project models.Project, workspace string) *MockBackend_GetLock_OngoingVerification {
+	params := []pegomock.Param{project, workspace}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetLock", params, verifier.timeout)
+	return &MockBackend_GetLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetLock_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetLock_OngoingVerification) GetCapturedArguments() (models.Project, string) {
+	project, workspace := c.GetAllCapturedArguments()
+	return project[len(project)-1], workspace[len(workspace)-1]
+}
+
+func (c *MockBackend_GetLock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Project, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Project)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) UnlockByPull(repoFullName string, pullNum int) *MockBackend_UnlockByPull_OngoingVerification {
+	params := []pegomock.Param{repoFullName, pullNum}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UnlockByPull", params, verifier.timeout)
+	return &MockBackend_UnlockByPull_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UnlockByPull_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UnlockByPull_OngoingVerification) GetCapturedArguments() (string, int) {
+	repoFullName, pullNum := c.GetAllCapturedArguments()
+	return repoFullName[len(repoFullName)-1], pullNum[len(pullNum)-1]
+}
+
+func (c *MockBackend_UnlockByPull_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []int) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]int, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(int)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) UpdatePullWithResults(pull models.PullRequest, newResults []models.ProjectResult) *MockBackend_UpdatePullWithResults_OngoingVerification {
+	params := []pegomock.Param{pull, newResults}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdatePullWithResults", params, verifier.timeout)
+	return &MockBackend_UpdatePullWithResults_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UpdatePullWithResults_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UpdatePullWithResults_OngoingVerification) GetCapturedArguments() (models.PullRequest, []models.ProjectResult) {
+	pull, newResults := c.GetAllCapturedArguments()
+	return pull[len(pull)-1], newResults[len(newResults)-1]
+}
+
+func (c *MockBackend_UpdatePullWithResults_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest, _param1 [][]models.ProjectResult) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullRequest)
+		}
+		_param1 = make([][]models.ProjectResult, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.([]models.ProjectResult)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) GetPullStatus(pull models.PullRequest) *MockBackend_GetPullStatus_OngoingVerification {
+	params := []pegomock.Param{pull}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetPullStatus", params, verifier.timeout)
+	return &MockBackend_GetPullStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetPullStatus_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetPullStatus_OngoingVerification) GetCapturedArguments() models.PullRequest {
+	pull := c.GetAllCapturedArguments()
+	return pull[len(pull)-1]
+}
+
+func (c *MockBackend_GetPullStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullRequest)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) DeletePullStatus(pull models.PullRequest) *MockBackend_DeletePullStatus_OngoingVerification {
+	params := []pegomock.Param{pull}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeletePullStatus", params, verifier.timeout)
+	return &MockBackend_DeletePullStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeletePullStatus_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeletePullStatus_OngoingVerification) GetCapturedArguments() models.PullRequest {
+	pull := c.GetAllCapturedArguments()
+	return pull[len(pull)-1]
+}
+
+func (c *MockBackend_DeletePullStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullRequest)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) DeleteProjectStatus(pull models.PullRequest, workspace string, repoRelDir string) *MockBackend_DeleteProjectStatus_OngoingVerification {
+	params := []pegomock.Param{pull, workspace, repoRelDir}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteProjectStatus", params, verifier.timeout)
+	return &MockBackend_DeleteProjectStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeleteProjectStatus_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteProjectStatus_OngoingVerification) GetCapturedArguments() (models.PullRequest, string, string) {
+	pull, workspace, repoRelDir := c.GetAllCapturedArguments()
+	return pull[len(pull)-1], workspace[len(workspace)-1], repoRelDir[len(repoRelDir)-1]
+}
+
+func (c *MockBackend_DeleteProjectStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest, _param1 []string, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullRequest)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) UpdateDriftStatus(status models.DriftStatus) *MockBackend_UpdateDriftStatus_OngoingVerification {
+	params := []pegomock.Param{status}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateDriftStatus", params, verifier.timeout)
+	return &MockBackend_UpdateDriftStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UpdateDriftStatus_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UpdateDriftStatus_OngoingVerification) GetCapturedArguments() models.DriftStatus {
+	status := c.GetAllCapturedArguments()
+	return status[len(status)-1]
+}
+
+func (c *MockBackend_UpdateDriftStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.DriftStatus) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.DriftStatus, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.DriftStatus)
+		}
 	}
+	return
 }
 
-func (mock *MockBackend) VerifyWasCalledInOrder(invocationCountMatcher pegomock.Matcher, inOrderContext *pegomock.InOrderContext) *VerifierMockBackend {
-	return &VerifierMockBackend{
-		mock:                   mock,
-		invocationCountMatcher: invocationCountMatcher,
-		inOrderContext:         inOrderContext,
+func (verifier *VerifierMockBackend) GetDriftStatuses() *MockBackend_GetDriftStatuses_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetDriftStatuses", params, verifier.timeout)
+	return &MockBackend_GetDriftStatuses_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetDriftStatuses_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetDriftStatuses_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_GetDriftStatuses_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) AddProjectRuns(pull models.PullRequest, runs []models.ProjectRun) *MockBackend_AddProjectRuns_OngoingVerification {
+	params := []pegomock.Param{pull, runs}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "AddProjectRuns", params, verifier.timeout)
+	return &MockBackend_AddProjectRuns_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_AddProjectRuns_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_AddProjectRuns_OngoingVerification) GetCapturedArguments() (models.PullRequest, []models.ProjectRun) {
+	pull, runs := c.GetAllCapturedArguments()
+	return pull[len(pull)-1], runs[len(runs)-1]
+}
+
+func (c *MockBackend_AddProjectRuns_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest, _param1 [][]models.ProjectRun) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullRequest)
+		}
+		_param1 = make([][]models.ProjectRun, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.([]models.ProjectRun)
+		}
 	}
+	return
 }
 
-func (mock *MockBackend) VerifyWasCalledEventually(invocationCountMatcher pegomock.Matcher, timeout time.Duration) *VerifierMockBackend {
-	return &VerifierMockBackend{
-		mock:                   mock,
-		invocationCountMatcher: invocationCountMatcher,
-		timeout:                timeout,
+func (verifier *VerifierMockBackend) GetProjectRuns(pull models.PullRequest) *MockBackend_GetProjectRuns_OngoingVerification {
+	params := []pegomock.Param{pull}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetProjectRuns", params, verifier.timeout)
+	return &MockBackend_GetProjectRuns_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetProjectRuns_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetProjectRuns_OngoingVerification) GetCapturedArguments() models.PullRequest {
+	pull := c.GetAllCapturedArguments()
+	return pull[len(pull)-1]
+}
+
+func (c *MockBackend_GetProjectRuns_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullRequest, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullRequest)
+		}
 	}
+	return
 }
 
-type VerifierMockBackend struct {
-	mock                   *MockBackend
-	invocationCountMatcher pegomock.Matcher
-	inOrderContext         *pegomock.InOrderContext
-	timeout                time.Duration
+func (verifier *VerifierMockBackend) DeleteProjectRunsBefore(t time.Time) *MockBackend_DeleteProjectRunsBefore_OngoingVerification {
+	params := []pegomock.Param{t}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteProjectRunsBefore", params, verifier.timeout)
+	return &MockBackend_DeleteProjectRunsBefore_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-func (verifier *VerifierMockBackend) TryLock(lock models.ProjectLock) *MockBackend_TryLock_OngoingVerification {
+type MockBackend_DeleteProjectRunsBefore_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteProjectRunsBefore_OngoingVerification) GetCapturedArguments() time.Time {
+	t := c.GetAllCapturedArguments()
+	return t[len(t)-1]
+}
+
+func (c *MockBackend_DeleteProjectRunsBefore_OngoingVerification) GetAllCapturedArguments() (_param0 []time.Time) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]time.Time, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(time.Time)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) Enqueue(lock models.ProjectLock) *MockBackend_Enqueue_OngoingVerification {
 	params := []pegomock.Param{lock}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params, verifier.timeout)
-	return &MockBackend_TryLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Enqueue", params, verifier.timeout)
+	return &MockBackend_Enqueue_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockBackend_TryLock_OngoingVerification struct {
+type MockBackend_Enqueue_OngoingVerification struct {
 	mock              *MockBackend
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockBackend_TryLock_OngoingVerification) GetCapturedArguments() models.ProjectLock {
+func (c *MockBackend_Enqueue_OngoingVerification) GetCapturedArguments() models.ProjectLock {
 	lock := c.GetAllCapturedArguments()
 	return lock[len(lock)-1]
 }
 
-func (c *MockBackend_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.ProjectLock) {
+func (c *MockBackend_Enqueue_OngoingVerification) GetAllCapturedArguments() (_param0 []models.ProjectLock) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]models.ProjectLock, len(params[0]))
@@ -188,23 +960,23 @@ func (c *MockBackend_TryLock_OngoingVerification) GetAllCapturedArguments() (_pa
 	return
 }
 
-func (verifier *VerifierMockBackend) Unlock(project models.Project, workspace string) *MockBackend_Unlock_OngoingVerification {
+func (verifier *VerifierMockBackend) Dequeue(project models.Project, workspace string) *MockBackend_Dequeue_OngoingVerification {
 	params := []pegomock.Param{project, workspace}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Unlock", params, verifier.timeout)
-	return &MockBackend_Unlock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Dequeue", params, verifier.timeout)
+	return &MockBackend_Dequeue_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockBackend_Unlock_OngoingVerification struct {
+type MockBackend_Dequeue_OngoingVerification struct {
 	mock              *MockBackend
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockBackend_Unlock_OngoingVerification) GetCapturedArguments() (models.Project, string) {
+func (c *MockBackend_Dequeue_OngoingVerification) GetCapturedArguments() (models.Project, string) {
 	project, workspace := c.GetAllCapturedArguments()
 	return project[len(project)-1], workspace[len(workspace)-1]
 }
 
-func (c *MockBackend_Unlock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string) {
+func (c *MockBackend_Dequeue_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]models.Project, len(params[0]))
@@ -219,80 +991,280 @@ func (c *MockBackend_Unlock_OngoingVerification) GetAllCapturedArguments() (_par
 	return
 }
 
-func (verifier *VerifierMockBackend) List() *MockBackend_List_OngoingVerification {
+func (verifier *VerifierMockBackend) GetQueue(project models.Project, workspace string) *MockBackend_GetQueue_OngoingVerification {
+	params := []pegomock.Param{project, workspace}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetQueue", params, verifier.timeout)
+	return &MockBackend_GetQueue_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetQueue_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetQueue_OngoingVerification) GetCapturedArguments() (models.Project, string) {
+	project, workspace := c.GetAllCapturedArguments()
+	return project[len(project)-1], workspace[len(workspace)-1]
+}
+
+func (c *MockBackend_GetQueue_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Project, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Project)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) Close() *MockBackend_Close_OngoingVerification {
 	params := []pegomock.Param{}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "List", params, verifier.timeout)
-	return &MockBackend_List_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Close", params, verifier.timeout)
+	return &MockBackend_Close_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockBackend_List_OngoingVerification struct {
+type MockBackend_Close_OngoingVerification struct {
 	mock              *MockBackend
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockBackend_List_OngoingVerification) GetCapturedArguments() {
+func (c *MockBackend_Close_OngoingVerification) GetCapturedArguments() {
 }
 
-func (c *MockBackend_List_OngoingVerification) GetAllCapturedArguments() {
+func (c *MockBackend_Close_OngoingVerification) GetAllCapturedArguments() {
 }
 
-func (verifier *VerifierMockBackend) GetLock(project models.Project, workspace string) *MockBackend_GetLock_OngoingVerification {
-	params := []pegomock.Param{project, workspace}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetLock", params, verifier.timeout)
-	return &MockBackend_GetLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+func (verifier *VerifierMockBackend) EnqueueEvent(event models.QueuedEvent) *MockBackend_EnqueueEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "EnqueueEvent", params, verifier.timeout)
+	return &MockBackend_EnqueueEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockBackend_GetLock_OngoingVerification struct {
+type MockBackend_EnqueueEvent_OngoingVerification struct {
 	mock              *MockBackend
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockBackend_GetLock_OngoingVerification) GetCapturedArguments() (models.Project, string) {
-	project, workspace := c.GetAllCapturedArguments()
-	return project[len(project)-1], workspace[len(workspace)-1]
+func (c *MockBackend_EnqueueEvent_OngoingVerification) GetCapturedArguments() models.QueuedEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
 }
 
-func (c *MockBackend_GetLock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string) {
+func (c *MockBackend_EnqueueEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []models.QueuedEvent) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
-		_param0 = make([]models.Project, len(params[0]))
+		_param0 = make([]models.QueuedEvent, len(params[0]))
 		for u, param := range params[0] {
-			_param0[u] = param.(models.Project)
+			_param0[u] = param.(models.QueuedEvent)
 		}
-		_param1 = make([]string, len(params[1]))
-		for u, param := range params[1] {
-			_param1[u] = param.(string)
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) GetEvents() *MockBackend_GetEvents_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetEvents", params, verifier.timeout)
+	return &MockBackend_GetEvents_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetEvents_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetEvents_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_GetEvents_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) StartEvent(id string) *MockBackend_StartEvent_OngoingVerification {
+	params := []pegomock.Param{id}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "StartEvent", params, verifier.timeout)
+	return &MockBackend_StartEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_StartEvent_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_StartEvent_OngoingVerification) GetCapturedArguments() string {
+	id := c.GetAllCapturedArguments()
+	return id[len(id)-1]
+}
+
+func (c *MockBackend_StartEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
 		}
 	}
 	return
 }
 
-func (verifier *VerifierMockBackend) UnlockByPull(repoFullName string, pullNum int) *MockBackend_UnlockByPull_OngoingVerification {
-	params := []pegomock.Param{repoFullName, pullNum}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UnlockByPull", params, verifier.timeout)
-	return &MockBackend_UnlockByPull_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+func (verifier *VerifierMockBackend) UpdateEvent(event models.QueuedEvent) *MockBackend_UpdateEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateEvent", params, verifier.timeout)
+	return &MockBackend_UpdateEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockBackend_UnlockByPull_OngoingVerification struct {
+type MockBackend_UpdateEvent_OngoingVerification struct {
 	mock              *MockBackend
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockBackend_UnlockByPull_OngoingVerification) GetCapturedArguments() (string, int) {
-	repoFullName, pullNum := c.GetAllCapturedArguments()
-	return repoFullName[len(repoFullName)-1], pullNum[len(pullNum)-1]
+func (c *MockBackend_UpdateEvent_OngoingVerification) GetCapturedArguments() models.QueuedEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
 }
 
-func (c *MockBackend_UnlockByPull_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []int) {
+func (c *MockBackend_UpdateEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []models.QueuedEvent) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.QueuedEvent, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.QueuedEvent)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) DeleteEvent(id string) *MockBackend_DeleteEvent_OngoingVerification {
+	params := []pegomock.Param{id}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteEvent", params, verifier.timeout)
+	return &MockBackend_DeleteEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeleteEvent_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteEvent_OngoingVerification) GetCapturedArguments() string {
+	id := c.GetAllCapturedArguments()
+	return id[len(id)-1]
+}
+
+func (c *MockBackend_DeleteEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]string, len(params[0]))
 		for u, param := range params[0] {
 			_param0[u] = param.(string)
 		}
-		_param1 = make([]int, len(params[1]))
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) DeleteProcessedEventsBefore(t time.Time) *MockBackend_DeleteProcessedEventsBefore_OngoingVerification {
+	params := []pegomock.Param{t}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteProcessedEventsBefore", params, verifier.timeout)
+	return &MockBackend_DeleteProcessedEventsBefore_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeleteProcessedEventsBefore_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteProcessedEventsBefore_OngoingVerification) GetCapturedArguments() time.Time {
+	t := c.GetAllCapturedArguments()
+	return t[len(t)-1]
+}
+
+func (c *MockBackend_DeleteProcessedEventsBefore_OngoingVerification) GetAllCapturedArguments() (_param0 []time.Time) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]time.Time, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(time.Time)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) UpdateDriftRepo(repo models.Repo) *MockBackend_UpdateDriftRepo_OngoingVerification {
+	params := []pegomock.Param{repo}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateDriftRepo", params, verifier.timeout)
+	return &MockBackend_UpdateDriftRepo_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UpdateDriftRepo_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UpdateDriftRepo_OngoingVerification) GetCapturedArguments() models.Repo {
+	repo := c.GetAllCapturedArguments()
+	return repo[len(repo)-1]
+}
+
+func (c *MockBackend_UpdateDriftRepo_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) GetDriftRepos() *MockBackend_GetDriftRepos_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetDriftRepos", params, verifier.timeout)
+	return &MockBackend_GetDriftRepos_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetDriftRepos_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetDriftRepos_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_GetDriftRepos_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) UnlockIfExpired(project models.Project, workspace string, now time.Time) *MockBackend_UnlockIfExpired_OngoingVerification {
+	params := []pegomock.Param{project, workspace, now}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UnlockIfExpired", params, verifier.timeout)
+	return &MockBackend_UnlockIfExpired_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UnlockIfExpired_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UnlockIfExpired_OngoingVerification) GetCapturedArguments() (models.Project, string, time.Time) {
+	project, workspace, now := c.GetAllCapturedArguments()
+	return project[len(project)-1], workspace[len(workspace)-1], now[len(now)-1]
+}
+
+func (c *MockBackend_UnlockIfExpired_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string, _param2 []time.Time) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Project, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Project)
+		}
+		_param1 = make([]string, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.(int)
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]time.Time, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(time.Time)
 		}
 	}
 	return
@@ -26,25 +26,6 @@ func NewMockLocker(options ...pegomock.Option) *MockLocker {
 func (mock *MockLocker) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockLocker) FailHandler() pegomock.FailHandler      { return mock.fail }
 
-func (mock *MockLocker) TryLock(p models.Project, workspace string, pull models.PullRequest, user models.User) (locking.TryLockResponse, error) {
-	if mock == nil {
-		panic("mock must not be nil. Use myMock := NewMockLocker().")
-	}
-	params := []pegomock.Param{p, workspace, pull, user}
-	result := pegomock.GetGenericMockFrom(mock).Invoke("TryLock", params, []reflect.Type{reflect.TypeOf((*locking.TryLockResponse)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
-	var ret0 locking.TryLockResponse
-	var ret1 error
-	if len(result) != 0 {
-		if result[0] != nil {
-			ret0 = result[0].(locking.TryLockResponse)
-		}
-		if result[1] != nil {
-			ret1 = result[1].(error)
-		}
-	}
-	return ret0, ret1
-}
-
 func (mock *MockLocker) Unlock(key string) (*models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockLocker().")
@@ -121,6 +102,44 @@ func (mock *MockLocker) GetLock(key string) (*models.ProjectLock, error) {
 	return ret0, ret1
 }
 
+func (mock *MockLocker) TryLock(p models.Project, workspace string, pull models.PullRequest, headRepo models.Repo, user models.User) (locking.TryLockResponse, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockLocker().")
+	}
+	params := []pegomock.Param{p, workspace, pull, headRepo, user}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("TryLock", params, []reflect.Type{reflect.TypeOf((*locking.TryLockResponse)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 locking.TryLockResponse
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(locking.TryLockResponse)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockLocker) UnlockIfExpired(key string, now time.Time) (*models.ProjectLock, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockLocker().")
+	}
+	params := []pegomock.Param{key, now}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UnlockIfExpired", params, []reflect.Type{reflect.TypeOf((**models.ProjectLock)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *models.ProjectLock
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*models.ProjectLock)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockLocker) VerifyWasCalledOnce() *VerifierMockLocker {
 	return &VerifierMockLocker{
 		mock:                   mock,
@@ -158,45 +177,6 @@ type VerifierMockLocker struct {
 	timeout                time.Duration
 }
 
-func (verifier *VerifierMockLocker) TryLock(p models.Project, workspace string, pull models.PullRequest, user models.User) *MockLocker_TryLock_OngoingVerification {
-	params := []pegomock.Param{p, workspace, pull, user}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params, verifier.timeout)
-	return &MockLocker_TryLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
-}
-
-type MockLocker_TryLock_OngoingVerification struct {
-	mock              *MockLocker
-	methodInvocations []pegomock.MethodInvocation
-}
-
-func (c *MockLocker_TryLock_OngoingVerification) GetCapturedArguments() (models.Project, string, models.PullRequest, models.User) {
-	p, workspace, pull, user := c.GetAllCapturedArguments()
-	return p[len(p)-1], workspace[len(workspace)-1], pull[len(pull)-1], user[len(user)-1]
-}
-
-func (c *MockLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string, _param2 []models.PullRequest, _param3 []models.User) {
-	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
-	if len(params) > 0 {
-		_param0 = make([]models.Project, len(params[0]))
-		for u, param := range params[0] {
-			_param0[u] = param.(models.Project)
-		}
-		_param1 = make([]string, len(params[1]))
-		for u, param := range params[1] {
-			_param1[u] = param.(string)
-		}
-		_param2 = make([]models.PullRequest, len(params[2]))
-		for u, param := range params[2] {
-			_param2[u] = param.(models.PullRequest)
-		}
-		_param3 = make([]models.User, len(params[3]))
-		for u, param := range params[3] {
-			_param3[u] = param.(models.User)
-		}
-	}
-	return
-}
-
 func (verifier *VerifierMockLocker) Unlock(key string) *MockLocker_Unlock_OngoingVerification {
 	params := []pegomock.Param{key}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Unlock", params, verifier.timeout)
@@ -298,3 +278,77 @@ func (c *MockLocker_GetLock_OngoingVerification) GetAllCapturedArguments() (_par
 	}
 	return
 }
+
+func (verifier *VerifierMockLocker) TryLock(p models.Project, workspace string, pull models.PullRequest, headRepo models.Repo, user models.User) *MockLocker_TryLock_OngoingVerification {
+	params := []pegomock.Param{p, workspace, pull, headRepo, user}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params, verifier.timeout)
+	return &MockLocker_TryLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockLocker_TryLock_OngoingVerification struct {
+	mock              *MockLocker
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockLocker_TryLock_OngoingVerification) GetCapturedArguments() (models.Project, string, models.PullRequest, models.Repo, models.User) {
+	p, workspace, pull, headRepo, user := c.GetAllCapturedArguments()
+	return p[len(p)-1], workspace[len(workspace)-1], pull[len(pull)-1], headRepo[len(headRepo)-1], user[len(user)-1]
+}
+
+func (c *MockLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Project, _param1 []string, _param2 []models.PullRequest, _param3 []models.Repo, _param4 []models.User) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Project, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Project)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]models.PullRequest, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(models.PullRequest)
+		}
+		_param3 = make([]models.Repo, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(models.Repo)
+		}
+		_param4 = make([]models.User, len(params[4]))
+		for u, param := range params[4] {
+			_param4[u] = param.(models.User)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockLocker) UnlockIfExpired(key string, now time.Time) *MockLocker_UnlockIfExpired_OngoingVerification {
+	params := []pegomock.Param{key, now}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UnlockIfExpired", params, verifier.timeout)
+	return &MockLocker_UnlockIfExpired_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockLocker_UnlockIfExpired_OngoingVerification struct {
+	mock              *MockLocker
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockLocker_UnlockIfExpired_OngoingVerification) GetCapturedArguments() (string, time.Time) {
+	key, now := c.GetAllCapturedArguments()
+	return key[len(key)-1], now[len(now)-1]
+}
+
+func (c *MockLocker_UnlockIfExpired_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []time.Time) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]time.Time, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(time.Time)
+		}
+	}
+	return
+}
@@ -24,8 +24,11 @@ import (
 )
 
 const (
-	planCommandTitle  = "Plan"
-	applyCommandTitle = "Apply"
+	planCommandTitle        = "Plan"
+	applyCommandTitle       = "Apply"
+	policyCheckCommandTitle = "Policy Check"
+	importCommandTitle      = "Import"
+	stateRmCommandTitle     = "State Rm"
 	// maxUnwrappedLines is the maximum number of lines the Terraform output
 	// can be before we wrap it in an expandable template.
 	maxUnwrappedLines = 12
@@ -82,7 +85,7 @@ type projectResultTmplData struct {
 // Render formats the data into a markdown string.
 // nolint: interfacer
 func (m *MarkdownRenderer) Render(res CommandResult, cmdName models.CommandName, log string, verbose bool, vcsHost models.VCSHostType) string {
-	commandStr := strings.Title(cmdName.String())
+	commandStr := cmdName.TitleString()
 	common := commonData{
 		Command:         commandStr,
 		Verbose:         verbose,
@@ -109,7 +112,27 @@ func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult,
 			RepoRelDir:  result.RepoRelDir,
 			ProjectName: result.ProjectName,
 		}
-		if result.Error != nil {
+		if result.Cancelled {
+			tmpl := cancelledUnwrappedTmpl
+			if m.shouldUseWrappedTmpl(vcsHost, result.Error.Error()) {
+				tmpl = cancelledWrappedTmpl
+			}
+			resultData.Rendered = m.renderTemplate(tmpl, struct {
+				Command string
+				Error   string
+			}{
+				Command: common.Command,
+				Error:   result.Error.Error(),
+			})
+		} else if _, ok := result.Error.(DependencyFailedErr); ok {
+			resultData.Rendered = m.renderTemplate(skippedTmpl, struct {
+				Command string
+				Error   string
+			}{
+				Command: common.Command,
+				Error:   result.Error.Error(),
+			})
+		} else if result.Error != nil {
 			tmpl := unwrappedErrTmpl
 			if m.shouldUseWrappedTmpl(vcsHost, result.Error.Error()) {
 				tmpl = wrappedErrTmpl
@@ -136,12 +159,30 @@ func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult,
 				resultData.Rendered = m.renderTemplate(planSuccessUnwrappedTmpl, planSuccessData{PlanSuccess: *result.PlanSuccess, PlanWasDeleted: common.PlansDeleted})
 			}
 			numPlanSuccesses++
+		} else if result.PolicyCheckSuccess != nil {
+			if m.shouldUseWrappedTmpl(vcsHost, result.PolicyCheckSuccess.PolicyCheckOutput) {
+				resultData.Rendered = m.renderTemplate(policyCheckSuccessWrappedTmpl, *result.PolicyCheckSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplate(policyCheckSuccessUnwrappedTmpl, *result.PolicyCheckSuccess)
+			}
 		} else if result.ApplySuccess != "" {
 			if m.shouldUseWrappedTmpl(vcsHost, result.ApplySuccess) {
 				resultData.Rendered = m.renderTemplate(applyWrappedSuccessTmpl, struct{ Output string }{result.ApplySuccess})
 			} else {
 				resultData.Rendered = m.renderTemplate(applyUnwrappedSuccessTmpl, struct{ Output string }{result.ApplySuccess})
 			}
+		} else if result.ImportSuccess != nil {
+			if m.shouldUseWrappedTmpl(vcsHost, result.ImportSuccess.Output) {
+				resultData.Rendered = m.renderTemplate(stateCmdSuccessWrappedTmpl, *result.ImportSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplate(stateCmdSuccessUnwrappedTmpl, *result.ImportSuccess)
+			}
+		} else if result.StateRmSuccess != nil {
+			if m.shouldUseWrappedTmpl(vcsHost, result.StateRmSuccess.Output) {
+				resultData.Rendered = m.renderTemplate(stateCmdSuccessWrappedTmpl, *result.StateRmSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplate(stateCmdSuccessUnwrappedTmpl, *result.StateRmSuccess)
+			}
 
 		} else {
 			resultData.Rendered = "Found no template. This is a bug!"
@@ -157,10 +198,16 @@ func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult,
 		tmpl = singleProjectPlanUnsuccessfulTmpl
 	case len(resultsTmplData) == 1 && common.Command == applyCommandTitle:
 		tmpl = singleProjectApplyTmpl
+	case len(resultsTmplData) == 1 && common.Command == policyCheckCommandTitle:
+		tmpl = singleProjectPolicyCheckTmpl
+	case len(resultsTmplData) == 1 && (common.Command == importCommandTitle || common.Command == stateRmCommandTitle):
+		tmpl = singleProjectApplyTmpl
 	case common.Command == planCommandTitle:
 		tmpl = multiProjectPlanTmpl
 	case common.Command == applyCommandTitle:
 		tmpl = multiProjectApplyTmpl
+	case common.Command == policyCheckCommandTitle:
+		tmpl = multiProjectPolicyCheckTmpl
 	default:
 		return "no template matched–this is a bug"
 	}
@@ -225,6 +272,18 @@ var multiProjectApplyTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMa
 		"{{$result.Rendered}}\n\n" +
 		"---\n{{end}}" +
 		logTmpl))
+var singleProjectPolicyCheckTmpl = template.Must(template.New("").Parse(
+	"{{$result := index .Results 0}}Ran {{.Command}} for {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n\n{{$result.Rendered}}\n" + logTmpl))
+var multiProjectPolicyCheckTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMap()).Parse(
+	"Ran {{.Command}} for {{ len .Results }} projects:\n\n" +
+		"{{ range $result := .Results }}" +
+		"1. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"{{end}}\n" +
+		"{{ range $i, $result := .Results }}" +
+		"### {{add $i 1}}. {{ if $result.ProjectName }}project: `{{$result.ProjectName}}` {{ end }}dir: `{{$result.RepoRelDir}}` workspace: `{{$result.Workspace}}`\n" +
+		"{{$result.Rendered}}\n\n" +
+		"---\n{{end}}" +
+		logTmpl))
 var planSuccessUnwrappedTmpl = template.Must(template.New("").Parse(
 	"```diff\n" +
 		"{{.TerraformOutput}}\n" +
@@ -247,6 +306,24 @@ var planNextSteps = "{{ if .PlanWasDeleted }}This plan was not saved because one
 	"* :put_litter_in_its_place: To **delete** this plan click [here]({{.LockURL}})\n" +
 	"* :repeat: To **plan** this project again, comment:\n" +
 	"    * `{{.RePlanCmd}}`{{end}}"
+var policyCheckSuccessUnwrappedTmpl = template.Must(template.New("").Parse(
+	"```diff\n" +
+		"{{.PolicyCheckOutput}}\n" +
+		"```\n\n" + policyCheckNextSteps))
+var policyCheckSuccessWrappedTmpl = template.Must(template.New("").Parse(
+	"<details><summary>Show Output</summary>\n\n" +
+		"```diff\n" +
+		"{{.PolicyCheckOutput}}\n" +
+		"```\n\n" +
+		policyCheckNextSteps + "\n" +
+		"</details>"))
+
+// policyCheckNextSteps are instructions appended after successful policy
+// checks as to what to do next.
+var policyCheckNextSteps = "* :arrow_forward: To **apply** this plan, comment:\n" +
+	"    * `{{.ApplyCmd}}`\n" +
+	"* :repeat: To re-run policies **plan** this project again by commenting:\n" +
+	"    * `{{.RePlanCmd}}`"
 var applyUnwrappedSuccessTmpl = template.Must(template.New("").Parse(
 	"```diff\n" +
 		"{{.Output}}\n" +
@@ -257,6 +334,23 @@ var applyWrappedSuccessTmpl = template.Must(template.New("").Parse(
 		"{{.Output}}\n" +
 		"```\n" +
 		"</details>"))
+var stateCmdSuccessUnwrappedTmpl = template.Must(template.New("").Parse(
+	"```diff\n" +
+		"{{.Output}}\n" +
+		"```\n\n" + stateCmdNextSteps))
+var stateCmdSuccessWrappedTmpl = template.Must(template.New("").Parse(
+	"<details><summary>Show Output</summary>\n\n" +
+		"```diff\n" +
+		"{{.Output}}\n" +
+		"```\n\n" +
+		stateCmdNextSteps + "\n" +
+		"</details>"))
+
+// stateCmdNextSteps are instructions appended after a successful import or
+// state rm. Any previous plan was deleted since it no longer matches the state.
+var stateCmdNextSteps = ":put_litter_in_its_place: Any existing plan for this project was deleted because the state has changed.\n\n" +
+	"* :repeat: To **plan** this project again, comment:\n" +
+	"    * `{{.RePlanCmd}}`"
 var unwrappedErrTmplText = "**{{.Command}} Error**\n" +
 	"```\n" +
 	"{{.Error}}\n" +
@@ -270,6 +364,18 @@ var unwrappedErrTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText
 var unwrappedErrWithLogTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText + logTmpl))
 var wrappedErrTmpl = template.Must(template.New("").Parse(wrappedErrTmplText))
 var failureTmplText = "**{{.Command}} Failed**: {{.Failure}}"
+var cancelledUnwrappedTmpl = template.Must(template.New("").Parse(
+	":stop_sign: **{{.Command}} Cancelled**\n" +
+		"```\n" +
+		"{{.Error}}\n" +
+		"```"))
+var cancelledWrappedTmpl = template.Must(template.New("").Parse(
+	":stop_sign: **{{.Command}} Cancelled**\n" +
+		"<details><summary>Show Output</summary>\n\n" +
+		"```\n" +
+		"{{.Error}}\n" +
+		"```\n</details>"))
+var skippedTmpl = template.Must(template.New("").Parse(":fast_forward: **{{.Command}} Skipped**: this project {{.Error}}."))
 var failureTmpl = template.Must(template.New("").Parse(failureTmplText))
 var failureWithLogTmpl = template.Must(template.New("").Parse(failureTmplText + logTmpl))
 var logTmpl = "{{if .Verbose}}\n<details><summary>Log</summary>\n  <p>\n\n```\n{{.Log}}```\n</p></details>{{end}}\n"
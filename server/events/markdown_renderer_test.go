@@ -556,6 +556,234 @@ $$$
 
 ---
 
+`,
+		},
+		{
+			"errored and skipped apply",
+			models.ApplyCommand,
+			[]models.ProjectResult{
+				{
+					Workspace:   "workspace",
+					RepoRelDir:  "network",
+					ProjectName: "network",
+					Error:       errors.New("error"),
+				},
+				{
+					Workspace:   "workspace",
+					RepoRelDir:  "eks",
+					ProjectName: "eks",
+					Error:       events.DependencyFailedErr{Dependency: "network"},
+				},
+			},
+			models.Github,
+			`Ran Apply for 2 projects:
+
+1. project: $network$ dir: $network$ workspace: $workspace$
+1. project: $eks$ dir: $eks$ workspace: $workspace$
+
+### 1. project: $network$ dir: $network$ workspace: $workspace$
+**Apply Error**
+$$$
+error
+$$$
+
+---
+### 2. project: $eks$ dir: $eks$ workspace: $workspace$
+:fast_forward: **Apply Skipped**: this project depends on project "network" which wasn't applied successfully.
+
+---
+
+`,
+		},
+		{
+			"cancelled apply",
+			models.ApplyCommand,
+			[]models.ProjectResult{
+				{
+					Workspace:   "workspace",
+					RepoRelDir:  "network",
+					ProjectName: "network",
+					Error:       errors.New("exit status 1: Interrupt received."),
+					Cancelled:   true,
+				},
+				{
+					Workspace:   "workspace",
+					RepoRelDir:  "eks",
+					ProjectName: "eks",
+					Error:       errors.New("cancelled before this project started"),
+					Cancelled:   true,
+				},
+			},
+			models.Github,
+			`Ran Apply for 2 projects:
+
+1. project: $network$ dir: $network$ workspace: $workspace$
+1. project: $eks$ dir: $eks$ workspace: $workspace$
+
+### 1. project: $network$ dir: $network$ workspace: $workspace$
+:stop_sign: **Apply Cancelled**
+$$$
+exit status 1: Interrupt received.
+$$$
+
+---
+### 2. project: $eks$ dir: $eks$ workspace: $workspace$
+:stop_sign: **Apply Cancelled**
+$$$
+cancelled before this project started
+$$$
+
+---
+
+`,
+		},
+		{
+			"single successful policy check",
+			models.PolicyCheckCommand,
+			[]models.ProjectResult{
+				{
+					PolicyCheckSuccess: &models.PolicyCheckSuccess{
+						PolicyCheckOutput: "4 tests, 4 passed",
+						RePlanCmd:         "atlantis plan -d path -w workspace",
+						ApplyCmd:          "atlantis apply -d path -w workspace",
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`Ran Policy Check for dir: $path$ workspace: $workspace$
+
+$$$diff
+4 tests, 4 passed
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :repeat: To re-run policies **plan** this project again by commenting:
+    * $atlantis plan -d path -w workspace$
+
+`,
+		},
+		{
+			"multiple policy checks with an error",
+			models.PolicyCheckCommand,
+			[]models.ProjectResult{
+				{
+					PolicyCheckSuccess: &models.PolicyCheckSuccess{
+						PolicyCheckOutput: "4 tests, 4 passed",
+						RePlanCmd:         "atlantis plan -d path -w workspace",
+						ApplyCmd:          "atlantis apply -d path -w workspace",
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+				{
+					Workspace:  "workspace",
+					RepoRelDir: "path2",
+					Error:      errors.New("policy set(s) failed: buckets"),
+				},
+			},
+			models.Github,
+			`Ran Policy Check for 2 projects:
+
+1. dir: $path$ workspace: $workspace$
+1. dir: $path2$ workspace: $workspace$
+
+### 1. dir: $path$ workspace: $workspace$
+$$$diff
+4 tests, 4 passed
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :repeat: To re-run policies **plan** this project again by commenting:
+    * $atlantis plan -d path -w workspace$
+
+---
+### 2. dir: $path2$ workspace: $workspace$
+**Policy Check Error**
+$$$
+policy set(s) failed: buckets
+$$$
+
+---
+
+`,
+		},
+		{
+			"single successful import",
+			models.ImportCommand,
+			[]models.ProjectResult{
+				{
+					ImportSuccess: &models.ImportSuccess{
+						Output:    "import-output",
+						RePlanCmd: "atlantis plan -d path -w workspace",
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`Ran Import for dir: $path$ workspace: $workspace$
+
+$$$diff
+import-output
+$$$
+
+:put_litter_in_its_place: Any existing plan for this project was deleted because the state has changed.
+
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
+`,
+		},
+		{
+			"single successful state rm",
+			models.StateRmCommand,
+			[]models.ProjectResult{
+				{
+					StateRmSuccess: &models.StateRmSuccess{
+						Output:    "state-rm-output",
+						RePlanCmd: "atlantis plan -d path -w workspace",
+					},
+					Workspace:   "workspace",
+					RepoRelDir:  "path",
+					ProjectName: "projectname",
+				},
+			},
+			models.Github,
+			`Ran State Rm for project: $projectname$ dir: $path$ workspace: $workspace$
+
+$$$diff
+state-rm-output
+$$$
+
+:put_litter_in_its_place: Any existing plan for this project was deleted because the state has changed.
+
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
+`,
+		},
+		{
+			"single errored import",
+			models.ImportCommand,
+			[]models.ProjectResult{
+				{
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+					Error:      errors.New("error"),
+				},
+			},
+			models.Github,
+			`Ran Import for dir: $path$ workspace: $workspace$
+
+**Import Error**
+$$$
+error
+$$$
+
 `,
 		},
 	}
@@ -70,6 +70,21 @@ func (mock *MockCommitStatusUpdater) UpdateProject(ctx models.ProjectCommandCont
 	return ret0
 }
 
+func (mock *MockCommitStatusUpdater) UpdateProjectResult(ctx models.ProjectCommandContext, cmdName models.CommandName, res models.ProjectResult) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommitStatusUpdater().")
+	}
+	params := []pegomock.Param{ctx, cmdName, res}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateProjectResult", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockCommitStatusUpdater) VerifyWasCalledOnce() *VerifierMockCommitStatusUpdater {
 	return &VerifierMockCommitStatusUpdater{
 		mock:                   mock,
@@ -231,3 +246,38 @@ func (c *MockCommitStatusUpdater_UpdateProject_OngoingVerification) GetAllCaptur
 	}
 	return
 }
+
+func (verifier *VerifierMockCommitStatusUpdater) UpdateProjectResult(ctx models.ProjectCommandContext, cmdName models.CommandName, res models.ProjectResult) *MockCommitStatusUpdater_UpdateProjectResult_OngoingVerification {
+	params := []pegomock.Param{ctx, cmdName, res}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateProjectResult", params, verifier.timeout)
+	return &MockCommitStatusUpdater_UpdateProjectResult_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommitStatusUpdater_UpdateProjectResult_OngoingVerification struct {
+	mock              *MockCommitStatusUpdater
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommitStatusUpdater_UpdateProjectResult_OngoingVerification) GetCapturedArguments() (models.ProjectCommandContext, models.CommandName, models.ProjectResult) {
+	ctx, cmdName, res := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1], cmdName[len(cmdName)-1], res[len(res)-1]
+}
+
+func (c *MockCommitStatusUpdater_UpdateProjectResult_OngoingVerification) GetAllCapturedArguments() (_param0 []models.ProjectCommandContext, _param1 []models.CommandName, _param2 []models.ProjectResult) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.ProjectCommandContext, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.ProjectCommandContext)
+		}
+		_param1 = make([]models.CommandName, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.CommandName)
+		}
+		_param2 = make([]models.ProjectResult, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(models.ProjectResult)
+		}
+	}
+	return
+}
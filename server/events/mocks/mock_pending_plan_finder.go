@@ -59,6 +59,21 @@ func (mock *MockPendingPlanFinder) DeletePlans(pullDir string) error {
 	return ret0
 }
 
+func (mock *MockPendingPlanFinder) DeletePlan(plan events.PendingPlan) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockPendingPlanFinder().")
+	}
+	params := []pegomock.Param{plan}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeletePlan", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockPendingPlanFinder) VerifyWasCalledOnce() *VerifierMockPendingPlanFinder {
 	return &VerifierMockPendingPlanFinder{
 		mock:                   mock,
@@ -149,3 +164,30 @@ func (c *MockPendingPlanFinder_DeletePlans_OngoingVerification) GetAllCapturedAr
 	}
 	return
 }
+
+func (verifier *VerifierMockPendingPlanFinder) DeletePlan(plan events.PendingPlan) *MockPendingPlanFinder_DeletePlan_OngoingVerification {
+	params := []pegomock.Param{plan}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeletePlan", params, verifier.timeout)
+	return &MockPendingPlanFinder_DeletePlan_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockPendingPlanFinder_DeletePlan_OngoingVerification struct {
+	mock              *MockPendingPlanFinder
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockPendingPlanFinder_DeletePlan_OngoingVerification) GetCapturedArguments() events.PendingPlan {
+	plan := c.GetAllCapturedArguments()
+	return plan[len(plan)-1]
+}
+
+func (c *MockPendingPlanFinder_DeletePlan_OngoingVerification) GetAllCapturedArguments() (_param0 []events.PendingPlan) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]events.PendingPlan, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(events.PendingPlan)
+		}
+	}
+	return
+}
@@ -27,11 +27,11 @@ func NewMockProjectLocker(options ...pegomock.Option) *MockProjectLocker {
 func (mock *MockProjectLocker) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockProjectLocker) FailHandler() pegomock.FailHandler      { return mock.fail }
 
-func (mock *MockProjectLocker) TryLock(log *logging.SimpleLogger, pull models.PullRequest, user models.User, workspace string, project models.Project) (*events.TryLockResponse, error) {
+func (mock *MockProjectLocker) TryLock(log *logging.SimpleLogger, pull models.PullRequest, headRepo models.Repo, user models.User, workspace string, project models.Project) (*events.TryLockResponse, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockProjectLocker().")
 	}
-	params := []pegomock.Param{log, pull, user, workspace, project}
+	params := []pegomock.Param{log, pull, headRepo, user, workspace, project}
 	result := pegomock.GetGenericMockFrom(mock).Invoke("TryLock", params, []reflect.Type{reflect.TypeOf((**events.TryLockResponse)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
 	var ret0 *events.TryLockResponse
 	var ret1 error
@@ -83,8 +83,8 @@ type VerifierMockProjectLocker struct {
 	timeout                time.Duration
 }
 
-func (verifier *VerifierMockProjectLocker) TryLock(log *logging.SimpleLogger, pull models.PullRequest, user models.User, workspace string, project models.Project) *MockProjectLocker_TryLock_OngoingVerification {
-	params := []pegomock.Param{log, pull, user, workspace, project}
+func (verifier *VerifierMockProjectLocker) TryLock(log *logging.SimpleLogger, pull models.PullRequest, headRepo models.Repo, user models.User, workspace string, project models.Project) *MockProjectLocker_TryLock_OngoingVerification {
+	params := []pegomock.Param{log, pull, headRepo, user, workspace, project}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params, verifier.timeout)
 	return &MockProjectLocker_TryLock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -94,12 +94,12 @@ type MockProjectLocker_TryLock_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockProjectLocker_TryLock_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, models.PullRequest, models.User, string, models.Project) {
-	log, pull, user, workspace, project := c.GetAllCapturedArguments()
-	return log[len(log)-1], pull[len(pull)-1], user[len(user)-1], workspace[len(workspace)-1], project[len(project)-1]
+func (c *MockProjectLocker_TryLock_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, models.PullRequest, models.Repo, models.User, string, models.Project) {
+	log, pull, headRepo, user, workspace, project := c.GetAllCapturedArguments()
+	return log[len(log)-1], pull[len(pull)-1], headRepo[len(headRepo)-1], user[len(user)-1], workspace[len(workspace)-1], project[len(project)-1]
 }
 
-func (c *MockProjectLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []models.PullRequest, _param2 []models.User, _param3 []string, _param4 []models.Project) {
+func (c *MockProjectLocker_TryLock_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []models.PullRequest, _param2 []models.Repo, _param3 []models.User, _param4 []string, _param5 []models.Project) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]*logging.SimpleLogger, len(params[0]))
@@ -110,17 +110,21 @@ func (c *MockProjectLocker_TryLock_OngoingVerification) GetAllCapturedArguments(
 		for u, param := range params[1] {
 			_param1[u] = param.(models.PullRequest)
 		}
-		_param2 = make([]models.User, len(params[2]))
+		_param2 = make([]models.Repo, len(params[2]))
 		for u, param := range params[2] {
-			_param2[u] = param.(models.User)
+			_param2[u] = param.(models.Repo)
 		}
-		_param3 = make([]string, len(params[3]))
+		_param3 = make([]models.User, len(params[3]))
 		for u, param := range params[3] {
-			_param3[u] = param.(string)
+			_param3[u] = param.(models.User)
 		}
-		_param4 = make([]models.Project, len(params[4]))
+		_param4 = make([]string, len(params[4]))
 		for u, param := range params[4] {
-			_param4[u] = param.(models.Project)
+			_param4[u] = param.(string)
+		}
+		_param5 = make([]models.Project, len(params[5]))
+		for u, param := range params[5] {
+			_param5[u] = param.(models.Project)
 		}
 	}
 	return
@@ -17,6 +17,8 @@
 package models
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	paths "path"
@@ -24,6 +26,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 
 	"github.com/pkg/errors"
@@ -211,11 +214,23 @@ type ProjectLock struct {
 	// User is the username of the user that ran the command
 	// that created this lock.
 	User User
+	// HeadRepo is the repo that Pull's branch is in. It's used to plan Pull
+	// when it's waiting in the lock's queue and the lock is released.
+	HeadRepo Repo
 	// Workspace is the Terraform workspace that this
 	// lock is being held against.
 	Workspace string
 	// Time is the time at which the lock was first created.
 	Time time.Time
+	// ExpiresAt is the time after which the lock is released by the stale
+	// lock reaper even if the pull request is still open. If it's zero, the
+	// lock doesn't expire.
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the lock has an expiry time and it's before now.
+func (p ProjectLock) Expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(now)
 }
 
 // Project represents a Terraform project. Since there may be multiple
@@ -278,6 +293,7 @@ const (
 	BitbucketCloud
 	BitbucketServer
 	AzureDevops
+	Gitea
 )
 
 func (h VCSHostType) String() string {
@@ -292,6 +308,8 @@ func (h VCSHostType) String() string {
 		return "BitbucketServer"
 	case AzureDevops:
 		return "AzureDevops"
+	case Gitea:
+		return "Gitea"
 	}
 	return "<missing String() implementation>"
 }
@@ -312,17 +330,47 @@ type ProjectCommandContext struct {
 	AutoplanEnabled bool
 	// BaseRepo is the repository that the pull request will be merged into.
 	BaseRepo Repo
+	// Context is cancelled when the command is cancelled, ex. with atlantis
+	// cancel. It's nil if the command can't be cancelled.
+	Context context.Context
+	// DependsOn are the names of the projects that must be applied before
+	// this project.
+	DependsOn []string
 	// EscapedCommentArgs are the extra arguments that were added to the atlantis
 	// command, ex. atlantis plan -- -target=resource. We then escape them
 	// by adding a \ before each character so that they can be used within
 	// sh -c safely, i.e. sh -c "terraform plan $(touch bad)".
 	EscapedCommentArgs []string
+	// EscapedPositionalArgs are the positional arguments of import and state
+	// rm, ex. the address and id to import, escaped like EscapedCommentArgs.
+	// They're passed to Terraform after EscapedCommentArgs since Terraform
+	// requires options to come first.
+	EscapedPositionalArgs []string
 	// HeadRepo is the repository that is getting merged into the BaseRepo.
 	// If the pull request branch is from the same repository then HeadRepo will
 	// be the same as BaseRepo.
 	HeadRepo Repo
+	// Job streams the output of this command to the web UI as it runs. It's
+	// nil if the output isn't being streamed.
+	Job *jobs.Job
 	// Log is a logger that's been set up for this context.
 	Log *logging.SimpleLogger
+	// ParallelApplyEnabled is true if parallel apply is enabled for the repo
+	// that this project is in.
+	ParallelApplyEnabled bool
+	// ParallelPlanEnabled is true if parallel plan is enabled for the repo
+	// that this project is in.
+	ParallelPlanEnabled bool
+	// PolicyCheckSteps are the steps we need to run to check this project's
+	// plan against PolicySets. They're only set for plan commands.
+	PolicyCheckSteps []valid.Step
+	// PolicySets are the policies this project's plan must pass. If empty, we
+	// don't run the policy check stage.
+	PolicySets []valid.PolicySet
+	// ProjectPlanStatus is the status of this project as stored in the
+	// database. It's only set for apply commands when the apply requirements
+	// depend on it.
+	ProjectPlanStatus ProjectPlanStatus
 	// PullMergeable is true if the pull request for this project is able to be merged.
 	PullMergeable bool
 	// Pull is the pull request we're responding to.
@@ -354,6 +402,11 @@ type ProjectCommandContext struct {
 	Workspace string
 }
 
+// IsCancelled returns true if the command has been cancelled.
+func (p ProjectCommandContext) IsCancelled() bool {
+	return p.Context != nil && p.Context.Err() != nil
+}
+
 // SplitRepoFullName splits a repo full name up into its owner and repo
 // name segments. If the repoFullName is malformed, may return empty
 // strings for owner or repo.
@@ -371,14 +424,42 @@ func SplitRepoFullName(repoFullName string) (owner string, repo string) {
 
 // ProjectResult is the result of executing a plan/apply for a specific project.
 type ProjectResult struct {
-	Command      CommandName
-	RepoRelDir   string
-	Workspace    string
-	Error        error
-	Failure      string
-	PlanSuccess  *PlanSuccess
-	ApplySuccess string
-	ProjectName  string
+	Command            CommandName
+	RepoRelDir         string
+	Workspace          string
+	Error              error
+	Failure            string
+	PlanSuccess        *PlanSuccess
+	PolicyCheckSuccess *PolicyCheckSuccess
+	ApplySuccess       string
+	ImportSuccess      *ImportSuccess
+	StateRmSuccess     *StateRmSuccess
+	ProjectName        string
+	// Cancelled is true if the command was cancelled before it finished, ex.
+	// with atlantis cancel. Error is also set so cancelled projects count as
+	// failed.
+	Cancelled bool
+}
+
+// MarshalJSON implements json.Marshaler. It's needed so that the command name
+// and error are rendered as strings. Without this, errors would be rendered as
+// empty objects.
+func (p ProjectResult) MarshalJSON() ([]byte, error) {
+	// alias has no methods so json.Marshal won't recurse back into MarshalJSON.
+	type alias ProjectResult
+	var errMsg string
+	if p.Error != nil {
+		errMsg = p.Error.Error()
+	}
+	return json.Marshal(struct {
+		alias
+		Command string
+		Error   string `json:",omitempty"`
+	}{
+		alias:   alias(p),
+		Command: p.Command.String(),
+		Error:   errMsg,
+	})
 }
 
 // CommitStatus returns the vcs commit status of this project result.
@@ -411,6 +492,14 @@ func (p ProjectResult) PlanStatus() ProjectPlanStatus {
 			return ErroredApplyStatus
 		}
 		return AppliedPlanStatus
+
+	case PolicyCheckCommand:
+		if p.Error != nil {
+			return ErroredPolicyCheckStatus
+		} else if p.Failure != "" {
+			return ErroredPolicyCheckStatus
+		}
+		return PassedPolicyCheckStatus
 	}
 
 	panic("PlanStatus() missing a combination")
@@ -418,7 +507,8 @@ func (p ProjectResult) PlanStatus() ProjectPlanStatus {
 
 // IsSuccessful returns true if this project result had no errors.
 func (p ProjectResult) IsSuccessful() bool {
-	return p.PlanSuccess != nil || p.ApplySuccess != ""
+	return p.PlanSuccess != nil || p.PolicyCheckSuccess != nil || p.ApplySuccess != "" ||
+		p.ImportSuccess != nil || p.StateRmSuccess != nil
 }
 
 // PlanSuccess is the result of a successful plan.
@@ -435,6 +525,52 @@ type PlanSuccess struct {
 	// branch we're merging into has been updated since we cloned and merged
 	// it.
 	HasDiverged bool
+	// Metadata describes what the plan was generated against.
+	Metadata *PlanMetadata
+}
+
+// PlanMetadata describes what a plan was generated against. It's stored next
+// to the planfile so that we can refuse to apply plans that are out of date.
+type PlanMetadata struct {
+	// HeadCommit is the pull request's head commit that was planned.
+	HeadCommit string
+	// BaseCommit is the commit of the base branch that the pull request was
+	// merged into before planning. It's only set when using the merge
+	// checkout strategy.
+	BaseCommit string
+	// Time is when the plan was generated.
+	Time time.Time
+	// User is the username of the user that ran plan.
+	User string
+}
+
+// PolicyCheckSuccess is the result of a plan that passed all policy checks.
+type PolicyCheckSuccess struct {
+	// PolicyCheckOutput is the output from the policy checks.
+	PolicyCheckOutput string
+	// RePlanCmd is the command that users should run to re-plan this project,
+	// which will also re-run the policy checks.
+	RePlanCmd string
+	// ApplyCmd is the command that users should run to apply this plan.
+	ApplyCmd string
+}
+
+// ImportSuccess is the result of a successful import.
+type ImportSuccess struct {
+	// Output is the output from Terraform of running import.
+	Output string
+	// RePlanCmd is the command that users should run to re-plan this project.
+	// Any existing plan is deleted after an import since it's now stale.
+	RePlanCmd string
+}
+
+// StateRmSuccess is the result of a successful state rm.
+type StateRmSuccess struct {
+	// Output is the output from Terraform of running state rm.
+	Output string
+	// RePlanCmd is the command that users should run to re-plan this project.
+	// Any existing plan is deleted after a state rm since it's now stale.
+	RePlanCmd string
 }
 
 // PullStatus is the current status of a pull request that is in progress.
@@ -463,6 +599,9 @@ type ProjectStatus struct {
 	ProjectName string
 	// Status is the status of where this project is at in the planning cycle.
 	Status ProjectPlanStatus
+	// PlanMetadata describes the project's latest plan. It's nil if the
+	// project hasn't been planned successfully.
+	PlanMetadata *PlanMetadata
 }
 
 // ProjectPlanStatus is the status of where this project is at in the planning
@@ -482,6 +621,15 @@ const (
 	// AppliedPlanStatus means that a plan has been generated and applied
 	// successfully.
 	AppliedPlanStatus
+	// ErroredPolicyCheckStatus means that a plan has been generated but it
+	// failed one or more of the policy checks.
+	ErroredPolicyCheckStatus
+	// PassedPolicyCheckStatus means that a plan has been generated and it
+	// passed all of the policy checks.
+	PassedPolicyCheckStatus
+	// DiscardedPlanStatus means that the plan was discarded because its lock
+	// was deleted. It's only used in the history.
+	DiscardedPlanStatus
 )
 
 // String returns a string representation of the status.
@@ -495,11 +643,141 @@ func (p ProjectPlanStatus) String() string {
 		return "apply_errored"
 	case AppliedPlanStatus:
 		return "applied"
+	case ErroredPolicyCheckStatus:
+		return "policy_check_errored"
+	case PassedPolicyCheckStatus:
+		return "policy_check_passed"
+	case DiscardedPlanStatus:
+		return "discarded"
 	default:
 		panic("missing String() impl for ProjectPlanStatus")
 	}
 }
 
+// DriftStatus is the result of the most recent drift detection run for a
+// project on the default branch of a repo.
+type DriftStatus struct {
+	// RepoFullName is the owner and repo name, ex. runatlantis/atlantis.
+	RepoFullName string
+	// VCSHostname is the hostname of the VCS provider the repo is on, ex.
+	// github.com.
+	VCSHostname string
+	RepoRelDir  string
+	Workspace   string
+	ProjectName string
+	// Drifted is true if the last plan found changes, i.e. the real
+	// infrastructure no longer matches the default branch.
+	Drifted bool
+	// Error is set if the last drift detection run for this project failed.
+	// In that case we don't know if the project has drifted.
+	Error string
+	// LastChecked is when the project was last checked for drift.
+	LastChecked time.Time
+}
+
+// RepoID returns the atlantis ID of the repo this status is for.
+// ID is in the form: {vcs hostname}/{repoFullName}.
+func (d DriftStatus) RepoID() string {
+	return fmt.Sprintf("%s/%s", d.VCSHostname, d.RepoFullName)
+}
+
+// ProjectRun is the record of running plan or apply on a project in a pull
+// request. Unlike ProjectStatus, it includes the full output so the run can be
+// viewed after the pull request comment has been posted.
+type ProjectRun struct {
+	Command     CommandName
+	RepoRelDir  string
+	Workspace   string
+	ProjectName string
+	// Status is the status of the project after the run.
+	Status ProjectPlanStatus
+	// Output is the Terraform output or the error if the run failed.
+	Output string
+	// User is the username of the user that triggered the run.
+	User string
+	// HeadCommit is the pull request's head commit at the time of the run.
+	HeadCommit string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// QueuedEvent is a command from a webhook that's stored in the event queue
+// until it's been run.
+type QueuedEvent struct {
+	// ID identifies the event. If the VCS host sent a delivery ID with the
+	// webhook, it's used so that redeliveries of the same webhook are
+	// ignored.
+	ID string
+	// RequestID is the VCS host's id for the webhook, if any, ex.
+	// X-Github-Delivery=<id>. It's used for logging.
+	RequestID string
+	Status    QueuedEventStatus
+	// Autoplan is true if the event is for a pull request being opened or
+	// updated. Otherwise it's for a comment and Comment is set.
+	Autoplan bool
+	Comment  *QueuedComment
+	BaseRepo Repo
+	// HeadRepo and Pull are nil for comments on VCS hosts whose webhooks
+	// don't include them. They're looked up when the command runs.
+	HeadRepo *Repo
+	Pull     *PullRequest
+	PullNum  int
+	User     User
+	// ReceivedAt is when the webhook was received.
+	ReceivedAt time.Time
+	// Attempts is how many times we've started running the event.
+	Attempts int
+	// HeartbeatAt is updated periodically by the instance running the event.
+	// If it stops being updated, that instance has stopped.
+	HeartbeatAt time.Time
+	// Error is why the last attempt failed, if it did.
+	Error string
+}
+
+// QueuedComment is the command parsed from a pull request comment.
+type QueuedComment struct {
+	Name        CommandName
+	RepoRelDir  string
+	Workspace   string
+	ProjectName string
+	Flags       []string
+	Verbose     bool
+	// Args are the positional arguments of import and state rm.
+	Args []string
+}
+
+// QueuedEventStatus is the status of an event in the event queue.
+type QueuedEventStatus int
+
+const (
+	// PendingEventStatus means the event is waiting to be run.
+	PendingEventStatus QueuedEventStatus = iota
+	// RunningEventStatus means the event is running.
+	RunningEventStatus
+	// ProcessedEventStatus means the event has been run. Processed events
+	// are kept for a while so that redeliveries are ignored.
+	ProcessedEventStatus
+	// FailedEventStatus means every attempt to run the event failed. It
+	// won't be retried unless an admin retries it.
+	FailedEventStatus
+)
+
+// String returns a string representation of the status.
+func (s QueuedEventStatus) String() string {
+	switch s {
+	case PendingEventStatus:
+		return "pending"
+	case RunningEventStatus:
+		return "running"
+	case ProcessedEventStatus:
+		return "processed"
+	case FailedEventStatus:
+		return "failed"
+	default:
+		panic("missing String() impl for QueuedEventStatus")
+	}
+}
+
 // CommandName is which command to run.
 type CommandName int
 
@@ -508,6 +786,19 @@ const (
 	ApplyCommand CommandName = iota
 	// PlanCommand is a command to run terraform plan.
 	PlanCommand
+	// PolicyCheckCommand is a command to check the plan against the
+	// configured policies. It runs automatically after a successful plan.
+	PolicyCheckCommand
+	// ImportCommand is a command to run terraform import.
+	ImportCommand
+	// StateRmCommand is a command to run terraform state rm.
+	StateRmCommand
+	// UnlockCommand is a command to release the locks held by a pull request
+	// and discard its plans.
+	UnlockCommand
+	// CancelCommand is a command to cancel the plans and applies running for
+	// a pull request.
+	CancelCommand
 	// Adding more? Don't forget to update String() below
 )
 
@@ -518,6 +809,22 @@ func (c CommandName) String() string {
 		return "apply"
 	case PlanCommand:
 		return "plan"
+	case PolicyCheckCommand:
+		return "policy_check"
+	case ImportCommand:
+		return "import"
+	case StateRmCommand:
+		return "state_rm"
+	case UnlockCommand:
+		return "unlock"
+	case CancelCommand:
+		return "cancel"
 	}
 	return ""
 }
+
+// TitleString returns the string representation of c in title case, ex.
+// "Policy Check".
+func (c CommandName) TitleString() string {
+	return strings.Title(strings.Replace(c.String(), "_", " ", -1))
+}
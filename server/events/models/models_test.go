@@ -14,6 +14,7 @@
 package models_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -343,6 +344,18 @@ func TestAzureDevopsSplitRepoFullName(t *testing.T) {
 	}
 }
 
+func TestProjectResult_MarshalJSON(t *testing.T) {
+	bytes, err := json.Marshal(models.ProjectResult{
+		Command:      models.ApplyCommand,
+		RepoRelDir:   "dir",
+		Workspace:    "default",
+		Error:        errors.New("err"),
+		ApplySuccess: "success",
+	})
+	Ok(t, err)
+	Equals(t, `{"RepoRelDir":"dir","Workspace":"default","Failure":"","PlanSuccess":null,"PolicyCheckSuccess":null,"ApplySuccess":"success","ImportSuccess":null,"StateRmSuccess":null,"ProjectName":"","Cancelled":false,"Command":"apply","Error":"err"}`, string(bytes))
+}
+
 func TestProjectResult_IsSuccessful(t *testing.T) {
 	cases := map[string]struct {
 		pr  models.ProjectResult
@@ -360,6 +373,24 @@ func TestProjectResult_IsSuccessful(t *testing.T) {
 			},
 			true,
 		},
+		"policy check success": {
+			models.ProjectResult{
+				PolicyCheckSuccess: &models.PolicyCheckSuccess{},
+			},
+			true,
+		},
+		"import success": {
+			models.ProjectResult{
+				ImportSuccess: &models.ImportSuccess{},
+			},
+			true,
+		},
+		"state rm success": {
+			models.ProjectResult{
+				StateRmSuccess: &models.StateRmSuccess{},
+			},
+			true,
+		},
 		"failure": {
 			models.ProjectResult{
 				Failure: "failure",
@@ -428,6 +459,20 @@ func TestProjectResult_PlanStatus(t *testing.T) {
 			},
 			expStatus: models.AppliedPlanStatus,
 		},
+		{
+			p: models.ProjectResult{
+				Command: models.PolicyCheckCommand,
+				Error:   errors.New("err"),
+			},
+			expStatus: models.ErroredPolicyCheckStatus,
+		},
+		{
+			p: models.ProjectResult{
+				Command:            models.PolicyCheckCommand,
+				PolicyCheckSuccess: &models.PolicyCheckSuccess{},
+			},
+			expStatus: models.PassedPolicyCheckStatus,
+		},
 	}
 
 	for _, c := range cases {
@@ -437,6 +482,14 @@ func TestProjectResult_PlanStatus(t *testing.T) {
 	}
 }
 
+func TestCommandName_TitleString(t *testing.T) {
+	Equals(t, "Plan", models.PlanCommand.TitleString())
+	Equals(t, "Apply", models.ApplyCommand.TitleString())
+	Equals(t, "Policy Check", models.PolicyCheckCommand.TitleString())
+	Equals(t, "Import", models.ImportCommand.TitleString())
+	Equals(t, "State Rm", models.StateRmCommand.TitleString())
+}
+
 func TestPullStatus_StatusCount(t *testing.T) {
 	ps := models.PullStatus{
 		Projects: []models.ProjectStatus{
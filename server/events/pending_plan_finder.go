@@ -16,6 +16,8 @@ import (
 type PendingPlanFinder interface {
 	Find(pullDir string) ([]PendingPlan, error)
 	DeletePlans(pullDir string) error
+	// DeletePlan deletes the planfile for plan.
+	DeletePlan(plan PendingPlan) error
 }
 
 // DefaultPendingPlanFinder finds unapplied plans.
@@ -99,3 +101,12 @@ func (p *DefaultPendingPlanFinder) DeletePlans(pullDir string) error {
 	}
 	return nil
 }
+
+// DeletePlan deletes the planfile for plan.
+func (p *DefaultPendingPlanFinder) DeletePlan(plan PendingPlan) error {
+	path := filepath.Join(plan.RepoDir, plan.RepoRelDir, runtime.GetPlanFilename(plan.Workspace, plan.ProjectName))
+	if err := os.Remove(path); err != nil {
+		return errors.Wrapf(err, "delete plan at %s", path)
+	}
+	return nil
+}
@@ -277,6 +277,46 @@ func TestPendingPlanFinder_DeletePlans(t *testing.T) {
 	Equals(t, 0, len(foundPlans))
 }
 
+func TestPendingPlanFinder_DeletePlan(t *testing.T) {
+	files := map[string]interface{}{
+		"default": map[string]interface{}{
+			"dir1": map[string]interface{}{
+				"default.tfplan":       nil,
+				"proj1-default.tfplan": nil,
+			},
+			"dir2": map[string]interface{}{
+				"default.tfplan": nil,
+			},
+		},
+	}
+	tmp, cleanup := DirStructure(t, files)
+	defer cleanup()
+	runCmd(t, filepath.Join(tmp, "default"), "git", "init")
+
+	pf := &events.DefaultPendingPlanFinder{}
+	Ok(t, pf.DeletePlan(events.PendingPlan{
+		RepoDir:     filepath.Join(tmp, "default"),
+		RepoRelDir:  "dir1",
+		Workspace:   "default",
+		ProjectName: "proj1",
+	}))
+
+	// Only that project's plan should be deleted.
+	foundPlans, err := pf.Find(tmp)
+	Ok(t, err)
+	Equals(t, 2, len(foundPlans))
+	for _, p := range foundPlans {
+		Equals(t, "", p.ProjectName)
+	}
+
+	err = pf.DeletePlan(events.PendingPlan{
+		RepoDir:    filepath.Join(tmp, "default"),
+		RepoRelDir: "dir3",
+		Workspace:  "default",
+	})
+	ErrContains(t, "no such file or directory", err)
+}
+
 func runCmd(t *testing.T, dir string, name string, args ...string) string {
 	t.Helper()
 	cpCmd := exec.Command(name, args...)
@@ -2,6 +2,8 @@ package events
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -14,6 +16,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/logging"
 )
 
 const (
@@ -25,6 +28,13 @@ const (
 	DefaultWorkspace = "default"
 	// DefaultAutomergeEnabled is the default for the automerge setting.
 	DefaultAutomergeEnabled = false
+	// DefaultParallelApplyEnabled is the default for the parallel apply setting.
+	DefaultParallelApplyEnabled = false
+	// DefaultParallelPlanEnabled is the default for the parallel plan setting.
+	DefaultParallelPlanEnabled = false
+	// TerraformVersionFilename is the name of the file used by tfenv to set
+	// the version of Terraform for a project.
+	TerraformVersionFilename = ".terraform-version"
 )
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_project_command_builder.go ProjectCommandBuilder
@@ -42,6 +52,14 @@ type ProjectCommandBuilder interface {
 	// comment doesn't specify one project then there may be multiple commands
 	// to be run.
 	BuildApplyCommands(ctx *CommandContext, comment *CommentCommand) ([]models.ProjectCommandContext, error)
+	// BuildImportCommands builds an import command for the single project
+	// identified by comment. If comment doesn't specify a project then the
+	// default dir and workspace are used.
+	BuildImportCommands(ctx *CommandContext, comment *CommentCommand) ([]models.ProjectCommandContext, error)
+	// BuildStateRmCommands builds a state rm command for the single project
+	// identified by comment. If comment doesn't specify a project then the
+	// default dir and workspace are used.
+	BuildStateRmCommands(ctx *CommandContext, comment *CommentCommand) ([]models.ProjectCommandContext, error)
 }
 
 // DefaultProjectCommandBuilder implements ProjectCommandBuilder.
@@ -56,6 +74,18 @@ type DefaultProjectCommandBuilder struct {
 	GlobalCfg         valid.GlobalCfg
 	PendingPlanFinder *DefaultPendingPlanFinder
 	CommentBuilder    CommentBuilder
+	// TerraformVersionLister is used to resolve required_version constraints
+	// to a specific version. If nil, only exact versions are used.
+	TerraformVersionLister TerraformVersionLister
+}
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_terraform_version_lister.go TerraformVersionLister
+
+// TerraformVersionLister lists the versions of Terraform that are available.
+type TerraformVersionLister interface {
+	// ListVersions returns the available versions sorted from oldest to
+	// newest.
+	ListVersions(log *logging.SimpleLogger) ([]*version.Version, error)
 }
 
 // See ProjectCommandBuilder.BuildAutoplanCommands.
@@ -80,7 +110,7 @@ func (p *DefaultProjectCommandBuilder) BuildPlanCommands(ctx *CommandContext, cm
 	if !cmd.IsForSpecificProject() {
 		return p.buildPlanAllCommands(ctx, cmd.Flags, cmd.Verbose)
 	}
-	pcc, err := p.buildProjectPlanCommand(ctx, cmd)
+	pcc, err := p.buildProjectCommand(ctx, models.PlanCommand, cmd)
 	return []models.ProjectCommandContext{pcc}, err
 }
 
@@ -93,6 +123,20 @@ func (p *DefaultProjectCommandBuilder) BuildApplyCommands(ctx *CommandContext, c
 	return []models.ProjectCommandContext{pac}, err
 }
 
+// See ProjectCommandBuilder.BuildImportCommands.
+func (p *DefaultProjectCommandBuilder) BuildImportCommands(ctx *CommandContext, cmd *CommentCommand) ([]models.ProjectCommandContext, error) {
+	pcc, err := p.buildProjectCommand(ctx, models.ImportCommand, cmd)
+	pcc.EscapedPositionalArgs = p.escapeArgs(cmd.Args)
+	return []models.ProjectCommandContext{pcc}, err
+}
+
+// See ProjectCommandBuilder.BuildStateRmCommands.
+func (p *DefaultProjectCommandBuilder) BuildStateRmCommands(ctx *CommandContext, cmd *CommentCommand) ([]models.ProjectCommandContext, error) {
+	pcc, err := p.buildProjectCommand(ctx, models.StateRmCommand, cmd)
+	pcc.EscapedPositionalArgs = p.escapeArgs(cmd.Args)
+	return []models.ProjectCommandContext{pcc}, err
+}
+
 // buildPlanAllCommands builds plan contexts for all projects we determine were
 // modified in this ctx.
 func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *CommandContext, commentFlags []string, verbose bool) ([]models.ProjectCommandContext, error) {
@@ -141,7 +185,7 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *CommandContext,
 		for _, mp := range matchingProjects {
 			ctx.Log.Debug("determining config for project at dir: %q workspace: %q", mp.Dir, mp.Workspace)
 			mergedCfg := p.GlobalCfg.MergeProjectCfg(ctx.Log, ctx.BaseRepo.ID(), mp, repoCfg)
-			projCtxs = append(projCtxs, p.buildCtx(ctx, models.PlanCommand, mergedCfg, commentFlags, repoCfg.Automerge, verbose, repoDir))
+			projCtxs = append(projCtxs, p.buildCtx(ctx, models.PlanCommand, mergedCfg, commentFlags, repoCfg.Automerge, repoCfg.ParallelApply, repoCfg.ParallelPlan, verbose, repoDir))
 		}
 	} else {
 		// If there is no config file, then we'll plan each project that
@@ -152,23 +196,23 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *CommandContext,
 		for _, mp := range modifiedProjects {
 			ctx.Log.Debug("determining config for project at dir: %q", mp.Path)
 			pCfg := p.GlobalCfg.DefaultProjCfg(ctx.Log, ctx.BaseRepo.ID(), mp.Path, DefaultWorkspace)
-			projCtxs = append(projCtxs, p.buildCtx(ctx, models.PlanCommand, pCfg, commentFlags, DefaultAutomergeEnabled, verbose, repoDir))
+			projCtxs = append(projCtxs, p.buildCtx(ctx, models.PlanCommand, pCfg, commentFlags, DefaultAutomergeEnabled, DefaultParallelApplyEnabled, DefaultParallelPlanEnabled, verbose, repoDir))
 		}
 	}
 
 	return projCtxs, nil
 }
 
-// buildProjectPlanCommand builds a plan context for a single project.
-// cmd must be for only one project.
-func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *CommandContext, cmd *CommentCommand) (models.ProjectCommandContext, error) {
+// buildProjectCommand builds a context for a single project for commands
+// that clone the repo, ex. plan. cmd must be for only one project.
+func (p *DefaultProjectCommandBuilder) buildProjectCommand(ctx *CommandContext, cmdName models.CommandName, cmd *CommentCommand) (models.ProjectCommandContext, error) {
 	workspace := DefaultWorkspace
 	if cmd.Workspace != "" {
 		workspace = cmd.Workspace
 	}
 
 	var pcc models.ProjectCommandContext
-	ctx.Log.Debug("building plan command")
+	ctx.Log.Debug("building %s command", cmdName.String())
 	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.BaseRepo.FullName, ctx.Pull.Num, workspace)
 	if err != nil {
 		return pcc, err
@@ -186,7 +230,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *CommandConte
 		repoRelDir = cmd.RepoRelDir
 	}
 
-	return p.buildProjectCommandCtx(ctx, models.PlanCommand, cmd.ProjectName, cmd.Flags, repoDir, repoRelDir, workspace, cmd.Verbose)
+	return p.buildProjectCommandCtx(ctx, cmdName, cmd.ProjectName, cmd.Flags, repoDir, repoRelDir, workspace, cmd.Verbose)
 }
 
 // buildApplyAllCommands builds apply contexts for every project that has
@@ -283,10 +327,14 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(
 	}
 
 	automerge := DefaultAutomergeEnabled
+	parallelApply := DefaultParallelApplyEnabled
+	parallelPlan := DefaultParallelPlanEnabled
 	if repoCfgPtr != nil {
 		automerge = repoCfgPtr.Automerge
+		parallelApply = repoCfgPtr.ParallelApply
+		parallelPlan = repoCfgPtr.ParallelPlan
 	}
-	return p.buildCtx(ctx, cmd, projCfg, commentFlags, automerge, verbose, repoDir), nil
+	return p.buildCtx(ctx, cmd, projCfg, commentFlags, automerge, parallelApply, parallelPlan, verbose, repoDir), nil
 }
 
 // getCfg returns the atlantis.yaml config (if it exists) for this project. If
@@ -375,15 +423,23 @@ func (p *DefaultProjectCommandBuilder) buildCtx(ctx *CommandContext,
 	projCfg valid.MergedProjectCfg,
 	commentArgs []string,
 	automergeEnabled bool,
+	parallelApplyEnabled bool,
+	parallelPlanEnabled bool,
 	verbose bool,
 	absRepoDir string) models.ProjectCommandContext {
 
 	var steps []valid.Step
+	var policyCheckSteps []valid.Step
 	switch cmd {
 	case models.PlanCommand:
 		steps = projCfg.Workflow.Plan.Steps
+		policyCheckSteps = projCfg.Workflow.PolicyCheck.Steps
 	case models.ApplyCommand:
 		steps = projCfg.Workflow.Apply.Steps
+	case models.ImportCommand:
+		steps = projCfg.Workflow.Import.Steps
+	case models.StateRmCommand:
+		steps = projCfg.Workflow.StateRm.Steps
 	}
 
 	// If TerraformVersion not defined in config file look for a
@@ -392,26 +448,40 @@ func (p *DefaultProjectCommandBuilder) buildCtx(ctx *CommandContext,
 		projCfg.TerraformVersion = p.getTfVersion(ctx, filepath.Join(absRepoDir, projCfg.RepoRelDir))
 	}
 
+	logFields := map[string]interface{}{
+		"command":   cmd.String(),
+		"dir":       projCfg.RepoRelDir,
+		"workspace": projCfg.Workspace,
+	}
+	if projCfg.Name != "" {
+		logFields["project"] = projCfg.Name
+	}
+
 	return models.ProjectCommandContext{
-		ApplyCmd:           p.CommentBuilder.BuildApplyComment(projCfg.RepoRelDir, projCfg.Workspace, projCfg.Name),
-		BaseRepo:           ctx.BaseRepo,
-		EscapedCommentArgs: p.escapeArgs(commentArgs),
-		AutomergeEnabled:   automergeEnabled,
-		AutoplanEnabled:    projCfg.AutoplanEnabled,
-		Steps:              steps,
-		HeadRepo:           ctx.HeadRepo,
-		Log:                ctx.Log,
-		PullMergeable:      ctx.PullMergeable,
-		Pull:               ctx.Pull,
-		ProjectName:        projCfg.Name,
-		ApplyRequirements:  projCfg.ApplyRequirements,
-		RePlanCmd:          p.CommentBuilder.BuildPlanComment(projCfg.RepoRelDir, projCfg.Workspace, projCfg.Name, commentArgs),
-		RepoRelDir:         projCfg.RepoRelDir,
-		RepoConfigVersion:  projCfg.RepoCfgVersion,
-		TerraformVersion:   projCfg.TerraformVersion,
-		User:               ctx.User,
-		Verbose:            verbose,
-		Workspace:          projCfg.Workspace,
+		ApplyCmd:             p.CommentBuilder.BuildApplyComment(projCfg.RepoRelDir, projCfg.Workspace, projCfg.Name),
+		BaseRepo:             ctx.BaseRepo,
+		DependsOn:            projCfg.DependsOn,
+		EscapedCommentArgs:   p.escapeArgs(commentArgs),
+		AutomergeEnabled:     automergeEnabled,
+		AutoplanEnabled:      projCfg.AutoplanEnabled,
+		Steps:                steps,
+		HeadRepo:             ctx.HeadRepo,
+		Log:                  ctx.Log.WithFields(logFields),
+		ParallelApplyEnabled: parallelApplyEnabled,
+		ParallelPlanEnabled:  parallelPlanEnabled,
+		PolicyCheckSteps:     policyCheckSteps,
+		PolicySets:           projCfg.PolicySets,
+		PullMergeable:        ctx.PullMergeable,
+		Pull:                 ctx.Pull,
+		ProjectName:          projCfg.Name,
+		ApplyRequirements:    projCfg.ApplyRequirements,
+		RePlanCmd:            p.CommentBuilder.BuildPlanComment(projCfg.RepoRelDir, projCfg.Workspace, projCfg.Name, commentArgs),
+		RepoRelDir:           projCfg.RepoRelDir,
+		RepoConfigVersion:    projCfg.RepoCfgVersion,
+		TerraformVersion:     projCfg.TerraformVersion,
+		User:                 ctx.User,
+		Verbose:              verbose,
+		Workspace:            projCfg.Workspace,
 	}
 }
 
@@ -427,35 +497,121 @@ func (p *DefaultProjectCommandBuilder) escapeArgs(args []string) []string {
 	return escaped
 }
 
-// Extracts required_version from Terraform configuration.
+// Extracts the version of Terraform to use from the project's
+// .terraform-version file or from the required_version setting in its
+// Terraform configuration.
 // Returns nil if unable to determine version from configuration.
 func (p *DefaultProjectCommandBuilder) getTfVersion(ctx *CommandContext, absProjDir string) *version.Version {
+	if v, found := p.getTfVersionFromFile(ctx, absProjDir); found {
+		return v
+	}
+
 	module, diags := tfconfig.LoadModule(absProjDir)
 	if diags.HasErrors() {
 		ctx.Log.Err("trying to detect required version: %s", diags.Error())
 		return nil
 	}
 
-	if len(module.RequiredCore) != 1 {
-		ctx.Log.Info("cannot determine which version to use from terraform configuration, detected %d possibilities.", len(module.RequiredCore))
+	if len(module.RequiredCore) == 0 {
+		ctx.Log.Info("cannot determine which version to use from terraform configuration, detected 0 possibilities.")
 		return nil
 	}
-	requiredVersionSetting := module.RequiredCore[0]
 
-	// We allow `= x.y.z`, `=x.y.z` or `x.y.z` where `x`, `y` and `z` are integers.
-	re := regexp.MustCompile(`^=?\s*([^\s]+)\s*$`)
-	matched := re.FindStringSubmatch(requiredVersionSetting)
-	if len(matched) == 0 {
-		ctx.Log.Debug("did not specify exact version in terraform configuration, found %q", requiredVersionSetting)
-		return nil
+	if len(module.RequiredCore) == 1 {
+		requiredVersionSetting := module.RequiredCore[0]
+		ctx.Log.Debug("found required_version setting of %q", requiredVersionSetting)
+
+		// We allow `= x.y.z`, `=x.y.z` or `x.y.z` where `x`, `y` and `z` are integers.
+		re := regexp.MustCompile(`^=?\s*([^\s]+)\s*$`)
+		matched := re.FindStringSubmatch(requiredVersionSetting)
+		if len(matched) != 0 {
+			if version, err := version.NewVersion(matched[1]); err == nil {
+				ctx.Log.Info("detected module requires version: %q", version.String())
+				return version
+			}
+		}
 	}
-	ctx.Log.Debug("found required_version setting of %q", requiredVersionSetting)
-	version, err := version.NewVersion(matched[1])
+
+	// Otherwise the settings are constraints, ex. `~> 0.12.20`, and all of
+	// them must be satisfied.
+	constraints, err := version.NewConstraint(strings.Join(module.RequiredCore, ","))
 	if err != nil {
-		ctx.Log.Debug(err.Error())
+		ctx.Log.Debug("could not parse required_version settings %q: %s", module.RequiredCore, err)
 		return nil
 	}
+	return p.resolveTfVersion(ctx, constraints)
+}
+
+// getTfVersionFromFile reads the .terraform-version file used by tfenv. It
+// returns false if there is no such file. The file can contain an exact
+// version, `latest` for the newest stable version or `latest:<regex>` for the
+// newest version matching the regex, which may be a pre-release.
+func (p *DefaultProjectCommandBuilder) getTfVersionFromFile(ctx *CommandContext, absProjDir string) (*version.Version, bool) {
+	contents, err := ioutil.ReadFile(filepath.Join(absProjDir, TerraformVersionFilename)) // nolint: gosec
+	if os.IsNotExist(err) {
+		return nil, false
+	}
+	if err != nil {
+		ctx.Log.Err("reading %s: %s", TerraformVersionFilename, err)
+		return nil, false
+	}
+	setting := strings.TrimSpace(string(contents))
+	ctx.Log.Debug("found %s file containing %q", TerraformVersionFilename, setting)
+
+	if setting == "latest" || strings.HasPrefix(setting, "latest:") {
+		re, err := regexp.Compile(strings.TrimPrefix(strings.TrimPrefix(setting, "latest"), ":"))
+		if err != nil {
+			ctx.Log.Warn("invalid regex in %s: %s", TerraformVersionFilename, err)
+			return nil, false
+		}
+		stableOnly := setting == "latest"
+		return p.latestTfVersion(ctx, func(v *version.Version) bool {
+			if stableOnly && v.Prerelease() != "" {
+				return false
+			}
+			return re.MatchString(v.String())
+		}), true
+	}
+
+	v, err := version.NewVersion(strings.TrimPrefix(setting, "v"))
+	if err != nil {
+		ctx.Log.Warn("invalid version in %s: %s", TerraformVersionFilename, err)
+		return nil, false
+	}
+	ctx.Log.Info("detected %s requires version: %q", TerraformVersionFilename, v.String())
+	return v, true
+}
+
+// resolveTfVersion returns the newest version of Terraform that satisfies
+// constraints or nil if there isn't one.
+func (p *DefaultProjectCommandBuilder) resolveTfVersion(ctx *CommandContext, constraints version.Constraints) *version.Version {
+	v := p.latestTfVersion(ctx, func(v *version.Version) bool {
+		// Pre-releases are only used if they're explicitly pinned.
+		return v.Prerelease() == "" && constraints.Check(v)
+	})
+	if v != nil {
+		ctx.Log.Info("resolved required_version %q to version: %q", constraints.String(), v.String())
+	}
+	return v
+}
 
-	ctx.Log.Info("detected module requires version: %q", version.String())
-	return version
+// latestTfVersion returns the newest available version of Terraform for which
+// match returns true or nil if there isn't one.
+func (p *DefaultProjectCommandBuilder) latestTfVersion(ctx *CommandContext, match func(v *version.Version) bool) *version.Version {
+	if p.TerraformVersionLister == nil {
+		ctx.Log.Debug("not resolving terraform version since we can't list the available versions")
+		return nil
+	}
+	versions, err := p.TerraformVersionLister.ListVersions(ctx.Log)
+	if err != nil {
+		ctx.Log.Warn("unable to list terraform versions, using default version: %s", err)
+		return nil
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if match(versions[i]) {
+			return versions[i]
+		}
+	}
+	ctx.Log.Warn("no available terraform version matches the project's requirements, using default version")
+	return nil
 }
@@ -605,6 +605,12 @@ projects:
 
 					// Init fields we couldn't in our cases map.
 					c.expCtx.Steps = expSteps
+					// None of the cases define a policy_check stage so plans
+					// always get the default.
+					c.expCtx.PolicyCheckSteps = nil
+					if cmd == models.PlanCommand {
+						c.expCtx.PolicyCheckSteps = valid.DefaultPolicyCheckStage.Steps
+					}
 
 					Equals(t, c.expCtx, ctx)
 					// Equals() doesn't compare TF version properly so have to
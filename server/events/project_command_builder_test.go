@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/matchers"
@@ -718,6 +719,41 @@ func TestDefaultProjectCommandBuilder_EscapeArgs(t *testing.T) {
 	}
 }
 
+// Test that the address and id of an import are kept out of the replan
+// command and the comment args.
+func TestDefaultProjectCommandBuilder_BuildImportCommands(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+	defer cleanup()
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyModelsRepo(), matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmpDir, nil)
+	When(workingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(tmpDir, nil)
+	builder := &events.DefaultProjectCommandBuilder{
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		WorkingDir:       workingDir,
+		ParserValidator:  &yaml.ParserValidator{},
+		ProjectFinder:    &events.DefaultProjectFinder{},
+		CommentBuilder:   &events.CommentParser{},
+		GlobalCfg:        valid.NewGlobalCfg(true, false, false),
+	}
+
+	ctxs, err := builder.BuildImportCommands(&events.CommandContext{Log: logging.NewNoopLogger()}, &events.CommentCommand{
+		RepoRelDir: ".",
+		Flags:      []string{"-var=a"},
+		Name:       models.ImportCommand,
+		Workspace:  "default",
+		Args:       []string{"aws_instance.a", "i-123"},
+	})
+	Ok(t, err)
+	Equals(t, 1, len(ctxs))
+	Equals(t, []string{`\-\v\a\r\=\a`}, ctxs[0].EscapedCommentArgs)
+	Equals(t, []string{`\a\w\s\_\i\n\s\t\a\n\c\e\.\a`, `\i\-\1\2\3`}, ctxs[0].EscapedPositionalArgs)
+	Equals(t, "atlantis plan -d . -- -var=a", ctxs[0].RePlanCmd)
+}
+
 // Test that terraform version is used when specified in terraform configuration
 func TestDefaultProjectCommandBuilder_TerraformVersion(t *testing.T) {
 	// For the following tests:
@@ -887,3 +923,102 @@ projects:
 		})
 	}
 }
+
+// Test that required_version constraints and .terraform-version files are
+// resolved to the newest matching version.
+func TestDefaultProjectCommandBuilder_TerraformVersionResolved(t *testing.T) {
+	cases := map[string]struct {
+		RequiredVersion string
+		VersionFile     string
+		Exp             string
+	}{
+		"pessimistic constraint": {
+			RequiredVersion: "~> 0.12.0",
+			Exp:             "0.12.20",
+		},
+		"range": {
+			RequiredVersion: ">= 0.12, < 0.13",
+			Exp:             "0.12.20",
+		},
+		"pre-releases are skipped": {
+			RequiredVersion: ">= 0.13.0-a",
+			Exp:             "0.13.1",
+		},
+		"no matching version": {
+			RequiredVersion: "> 1.0",
+			Exp:             "",
+		},
+		"version file": {
+			RequiredVersion: "~> 0.12.0",
+			VersionFile:     "0.11.14\n",
+			Exp:             "0.11.14",
+		},
+		"version file latest skips pre-releases": {
+			VersionFile: "latest",
+			Exp:         "0.13.1",
+		},
+		"version file latest regex": {
+			VersionFile: "latest:^0.12",
+			Exp:         "0.12.20",
+		},
+		"version file latest regex matching pre-release": {
+			VersionFile: "latest:-rc",
+			Exp:         "0.14.0-rc1",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			project := map[string]interface{}{
+				"main.tf": nil,
+			}
+			if c.RequiredVersion != "" {
+				project["main.tf"] = fmt.Sprintf("terraform {\n  required_version = %q\n}\n", c.RequiredVersion)
+			}
+			if c.VersionFile != "" {
+				project[events.TerraformVersionFilename] = c.VersionFile
+			}
+			tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+				"project1": project,
+			})
+			defer cleanup()
+
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(
+				matchers.AnyPtrToLoggingSimpleLogger(),
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsPullRequest(),
+				AnyString())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn([]string{"project1/main.tf"}, nil)
+			lister := mocks.NewMockTerraformVersionLister()
+			var versions []*version.Version
+			for _, v := range []string{"0.11.14", "0.12.3", "0.12.20", "0.13.0-beta1", "0.13.1", "0.14.0-rc1"} {
+				versions = append(versions, version.Must(version.NewVersion(v)))
+			}
+			When(lister.ListVersions(matchers.AnyPtrToLoggingSimpleLogger())).ThenReturn(versions, nil)
+
+			builder := &events.DefaultProjectCommandBuilder{
+				WorkingDirLocker:       events.NewDefaultWorkingDirLocker(),
+				WorkingDir:             workingDir,
+				VCSClient:              vcsClient,
+				ParserValidator:        &yaml.ParserValidator{},
+				ProjectFinder:          &events.DefaultProjectFinder{},
+				CommentBuilder:         &events.CommentParser{},
+				GlobalCfg:              valid.NewGlobalCfg(true, false, false),
+				TerraformVersionLister: lister,
+			}
+
+			actCtxs, err := builder.BuildAutoplanCommands(&events.CommandContext{})
+			Ok(t, err)
+			Equals(t, 1, len(actCtxs))
+			if c.Exp == "" {
+				Assert(t, actCtxs[0].TerraformVersion == nil, "exp nil version, got %s", actCtxs[0].TerraformVersion)
+			} else {
+				Assert(t, actCtxs[0].TerraformVersion != nil, "exp version %s, got nil", c.Exp)
+				Equals(t, c.Exp, actCtxs[0].TerraformVersion.String())
+			}
+		})
+	}
+}
@@ -14,10 +14,13 @@
 package events
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -26,6 +29,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/yaml/raw"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 // DirNotExistErr is an error caused by the directory not existing.
@@ -38,6 +42,24 @@ func (d DirNotExistErr) Error() string {
 	return fmt.Sprintf("dir %q does not exist", d.RepoRelDir)
 }
 
+// DependencyFailedErr is the error for a project whose apply was skipped
+// because a project it depends on wasn't applied successfully or has a plan
+// that isn't being applied with it.
+type DependencyFailedErr struct {
+	Dependency string
+	// Unapplied is true if the dependency wasn't part of the apply but has a
+	// plan that hasn't been applied yet.
+	Unapplied bool
+}
+
+// Error implements the error interface.
+func (d DependencyFailedErr) Error() string {
+	if d.Unapplied {
+		return fmt.Sprintf("depends on project %q which has a plan that hasn't been applied, apply it first or together with this project", d.Dependency)
+	}
+	return fmt.Sprintf("depends on project %q which wasn't applied successfully", d.Dependency)
+}
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_lock_url_generator.go LockURLGenerator
 
 // LockURLGenerator generates urls to locks.
@@ -87,27 +109,49 @@ type ProjectCommandRunner interface {
 	Plan(ctx models.ProjectCommandContext) models.ProjectResult
 	// Apply runs terraform apply for the project described by ctx.
 	Apply(ctx models.ProjectCommandContext) models.ProjectResult
+	// PolicyCheck checks the plan for the project described by ctx against
+	// its policies.
+	PolicyCheck(ctx models.ProjectCommandContext) models.ProjectResult
+	// Import runs terraform import for the project described by ctx.
+	Import(ctx models.ProjectCommandContext) models.ProjectResult
+	// StateRm runs terraform state rm for the project described by ctx.
+	StateRm(ctx models.ProjectCommandContext) models.ProjectResult
 }
 
 // DefaultProjectCommandRunner implements ProjectCommandRunner.
 type DefaultProjectCommandRunner struct {
-	Locker              ProjectLocker
-	LockURLGenerator    LockURLGenerator
-	InitStepRunner      StepRunner
-	PlanStepRunner      StepRunner
-	ApplyStepRunner     StepRunner
-	RunStepRunner       CustomStepRunner
-	EnvStepRunner       EnvStepRunner
-	PullApprovedChecker runtime.PullApprovedChecker
-	WorkingDir          WorkingDir
-	Webhooks            WebhooksSender
-	WorkingDirLocker    WorkingDirLocker
+	Locker                ProjectLocker
+	LockURLGenerator      LockURLGenerator
+	InitStepRunner        StepRunner
+	PlanStepRunner        StepRunner
+	ApplyStepRunner       StepRunner
+	ShowStepRunner        StepRunner
+	PolicyCheckStepRunner StepRunner
+	ImportStepRunner      StepRunner
+	StateRmStepRunner     StepRunner
+	RunStepRunner         CustomStepRunner
+	EnvStepRunner         EnvStepRunner
+	PullApprovedChecker   runtime.PullApprovedChecker
+	WorkingDir            WorkingDir
+	Webhooks              WebhooksSender
+	WorkingDirLocker      WorkingDirLocker
+	// MaxPlanAge is how old a plan can be before it can't be applied. If 0,
+	// plans don't expire.
+	MaxPlanAge time.Duration
+	// CommandDuration has the labels command and result, where result is one
+	// of success, failure, error or cancelled.
+	CommandDuration *metrics.Histogram
+	// GithubAppTokens, if set, is used to give each step's git commands, ex.
+	// terraform init fetching modules, the GitHub App's installation token
+	// for the base repo's owner. See GithubAppGitEnv.
+	GithubAppTokens GithubAppTokens
 }
 
 // Plan runs terraform plan for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Plan(ctx models.ProjectCommandContext) models.ProjectResult {
+	start := time.Now()
 	planSuccess, failure, err := p.doPlan(ctx)
-	return models.ProjectResult{
+	return p.observe(ctx, start, models.ProjectResult{
 		Command:     models.PlanCommand,
 		PlanSuccess: planSuccess,
 		Error:       err,
@@ -115,13 +159,14 @@ func (p *DefaultProjectCommandRunner) Plan(ctx models.ProjectCommandContext) mod
 		RepoRelDir:  ctx.RepoRelDir,
 		Workspace:   ctx.Workspace,
 		ProjectName: ctx.ProjectName,
-	}
+	})
 }
 
 // Apply runs terraform apply for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Apply(ctx models.ProjectCommandContext) models.ProjectResult {
+	start := time.Now()
 	applyOut, failure, err := p.doApply(ctx)
-	return models.ProjectResult{
+	return p.observe(ctx, start, models.ProjectResult{
 		Command:      models.ApplyCommand,
 		Failure:      failure,
 		Error:        err,
@@ -129,12 +174,83 @@ func (p *DefaultProjectCommandRunner) Apply(ctx models.ProjectCommandContext) mo
 		RepoRelDir:   ctx.RepoRelDir,
 		Workspace:    ctx.Workspace,
 		ProjectName:  ctx.ProjectName,
+	})
+}
+
+// PolicyCheck checks the plan for the project described by ctx against its
+// policies.
+func (p *DefaultProjectCommandRunner) PolicyCheck(ctx models.ProjectCommandContext) models.ProjectResult {
+	start := time.Now()
+	policySuccess, failure, err := p.doPolicyCheck(ctx)
+	return p.observe(ctx, start, models.ProjectResult{
+		Command:            models.PolicyCheckCommand,
+		PolicyCheckSuccess: policySuccess,
+		Error:              err,
+		Failure:            failure,
+		RepoRelDir:         ctx.RepoRelDir,
+		Workspace:          ctx.Workspace,
+		ProjectName:        ctx.ProjectName,
+	})
+}
+
+// Import runs terraform import for the project described by ctx.
+func (p *DefaultProjectCommandRunner) Import(ctx models.ProjectCommandContext) models.ProjectResult {
+	start := time.Now()
+	out, failure, err := p.doStateCommand(ctx, "import")
+	var importSuccess *models.ImportSuccess
+	if out != nil {
+		importSuccess = &models.ImportSuccess{Output: *out, RePlanCmd: ctx.RePlanCmd}
 	}
+	return p.observe(ctx, start, models.ProjectResult{
+		Command:       models.ImportCommand,
+		ImportSuccess: importSuccess,
+		Error:         err,
+		Failure:       failure,
+		RepoRelDir:    ctx.RepoRelDir,
+		Workspace:     ctx.Workspace,
+		ProjectName:   ctx.ProjectName,
+	})
+}
+
+// StateRm runs terraform state rm for the project described by ctx.
+func (p *DefaultProjectCommandRunner) StateRm(ctx models.ProjectCommandContext) models.ProjectResult {
+	start := time.Now()
+	out, failure, err := p.doStateCommand(ctx, "state rm")
+	var stateRmSuccess *models.StateRmSuccess
+	if out != nil {
+		stateRmSuccess = &models.StateRmSuccess{Output: *out, RePlanCmd: ctx.RePlanCmd}
+	}
+	return p.observe(ctx, start, models.ProjectResult{
+		Command:        models.StateRmCommand,
+		StateRmSuccess: stateRmSuccess,
+		Error:          err,
+		Failure:        failure,
+		RepoRelDir:     ctx.RepoRelDir,
+		Workspace:      ctx.Workspace,
+		ProjectName:    ctx.ProjectName,
+	})
+}
+
+// observe records how long the command that produced res took and its
+// outcome. If the command errored because it was cancelled, res is marked as
+// cancelled. It returns res so it can wrap the return statement.
+func (p *DefaultProjectCommandRunner) observe(ctx models.ProjectCommandContext, start time.Time, res models.ProjectResult) models.ProjectResult {
+	res.Cancelled = res.Error != nil && ctx.IsCancelled()
+	result := "error"
+	if res.IsSuccessful() {
+		result = "success"
+	} else if res.Failure != "" {
+		result = "failure"
+	} else if res.Cancelled {
+		result = "cancelled"
+	}
+	p.CommandDuration.ObserveSince(start, res.Command.String(), result)
+	return res
 }
 
 func (p *DefaultProjectCommandRunner) doPlan(ctx models.ProjectCommandContext) (*models.PlanSuccess, string, error) {
 	// Acquire Atlantis lock for this repo/dir/workspace.
-	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.BaseRepo.FullName, ctx.RepoRelDir))
+	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.HeadRepo, ctx.User, ctx.Workspace, models.NewProject(ctx.BaseRepo.FullName, ctx.RepoRelDir))
 	if err != nil {
 		return nil, "", errors.Wrap(err, "acquiring lock")
 	}
@@ -144,7 +260,7 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx models.ProjectCommandContext) (
 	ctx.Log.Debug("acquired lock for project")
 
 	// Acquire internal lock for the directory we're going to operate in.
-	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace)
+	unlockFn, err := p.WorkingDirLocker.TryLockPath(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
 	if err != nil {
 		return nil, "", err
 	}
@@ -171,19 +287,81 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx models.ProjectCommandContext) (
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
 
+	metadata := &models.PlanMetadata{
+		HeadCommit: ctx.Pull.HeadCommit,
+		Time:       time.Now(),
+		User:       ctx.User.Username,
+	}
+	// The base commit is informational so we don't fail the plan if we
+	// can't get it.
+	if metadata.BaseCommit, err = p.WorkingDir.GetBaseCommit(ctx.Pull, ctx.Workspace); err != nil {
+		ctx.Log.Warn("unable to get base commit: %s", err)
+	}
+	if err := writePlanMetadata(filepath.Join(projAbsPath, runtime.GetPlanMetadataFilename(ctx.Workspace, ctx.ProjectName)), metadata); err != nil {
+		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
+			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
+		}
+		return nil, "", err
+	}
+
 	return &models.PlanSuccess{
 		LockURL:         p.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey),
 		TerraformOutput: strings.Join(outputs, "\n"),
 		RePlanCmd:       ctx.RePlanCmd,
 		ApplyCmd:        ctx.ApplyCmd,
 		HasDiverged:     hasDiverged,
+		Metadata:        metadata,
+	}, "", nil
+}
+
+func (p *DefaultProjectCommandRunner) doPolicyCheck(ctx models.ProjectCommandContext) (*models.PolicyCheckSuccess, string, error) {
+	// The plan has already been generated so the repo must be cloned.
+	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", errors.New("project has not been cloned–did you run plan?")
+		}
+		return nil, "", err
+	}
+	absPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(absPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	// Acquire internal lock for the directory we're going to operate in.
+	unlockFn, err := p.WorkingDirLocker.TryLockPath(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+	}
+	return &models.PolicyCheckSuccess{
+		PolicyCheckOutput: strings.Join(outputs, "\n"),
+		RePlanCmd:         ctx.RePlanCmd,
+		ApplyCmd:          ctx.ApplyCmd,
 	}, "", nil
 }
 
 func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx models.ProjectCommandContext, absPath string) ([]string, error) {
 	var outputs []string
 	envs := make(map[string]string)
+	if p.GithubAppTokens != nil {
+		appEnv, err := GithubAppGitEnv(p.GithubAppTokens, ctx.BaseRepo)
+		if err != nil {
+			return outputs, err
+		}
+		for k, v := range appEnv {
+			envs[k] = v
+		}
+	}
 	for _, step := range steps {
+		if ctx.IsCancelled() {
+			return outputs, errors.New("cancelled")
+		}
 		var out string
 		var err error
 		switch step.StepName {
@@ -193,6 +371,14 @@ func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx models.Pr
 			out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
 		case "apply":
 			out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "show":
+			out, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "policy_check":
+			out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "import":
+			out, err = p.ImportStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "state_rm":
+			out, err = p.StateRmStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
 		case "run":
 			out, err = p.RunStepRunner.Run(ctx, step.RunCommand, absPath, envs)
 		case "env":
@@ -226,24 +412,16 @@ func (p *DefaultProjectCommandRunner) doApply(ctx models.ProjectCommandContext)
 		return "", "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
-	for _, req := range ctx.ApplyRequirements {
-		switch req {
-		case raw.ApprovedApplyRequirement:
-			approved, err := p.PullApprovedChecker.PullIsApproved(ctx.BaseRepo, ctx.Pull) // nolint: vetshadow
-			if err != nil {
-				return "", "", errors.Wrap(err, "checking if pull request was approved")
-			}
-			if !approved {
-				return "", "Pull request must be approved before running apply.", nil
-			}
-		case raw.MergeableApplyRequirement:
-			if !ctx.PullMergeable {
-				return "", "Pull request must be mergeable before running apply.", nil
-			}
-		}
+	failure, err = p.checkPlanMetadata(ctx, absPath)
+	if err != nil || failure != "" {
+		return "", failure, err
+	}
+	failure, err = p.checkApplyRequirements(ctx, "apply")
+	if err != nil || failure != "" {
+		return "", failure, err
 	}
 	// Acquire internal lock for the directory we're going to operate in.
-	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace)
+	unlockFn, err := p.WorkingDirLocker.TryLockPath(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
 	if err != nil {
 		return "", "", err
 	}
@@ -263,3 +441,151 @@ func (p *DefaultProjectCommandRunner) doApply(ctx models.ProjectCommandContext)
 	}
 	return strings.Join(outputs, "\n"), "", nil
 }
+
+// doStateCommand runs a command that modifies the Terraform state directly,
+// ex. import. Since these commands change infrastructure they must pass the
+// same apply requirements as apply and they take the project lock like plan.
+// On success any existing plan is stale so it's deleted by the step runners.
+func (p *DefaultProjectCommandRunner) doStateCommand(ctx models.ProjectCommandContext, command string) (*string, string, error) {
+	failure, err := p.checkApplyRequirements(ctx, command)
+	if err != nil || failure != "" {
+		return nil, failure, err
+	}
+
+	// Acquire Atlantis lock for this repo/dir/workspace.
+	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.HeadRepo, ctx.User, ctx.Workspace, models.NewProject(ctx.BaseRepo.FullName, ctx.RepoRelDir))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "acquiring lock")
+	}
+	if !lockAttempt.LockAcquired {
+		return nil, lockAttempt.LockFailureReason, nil
+	}
+	ctx.Log.Debug("acquired lock for project")
+
+	// Acquire internal lock for the directory we're going to operate in.
+	unlockFn, err := p.WorkingDirLocker.TryLockPath(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	// Clone is idempotent so okay to run even if the repo was already cloned.
+	repoDir, _, err := p.WorkingDir.Clone(ctx.Log, ctx.BaseRepo, ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+	if err != nil {
+		return nil, "", err
+	}
+	absPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(absPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+	}
+	out := strings.Join(outputs, "\n")
+	return &out, "", nil
+}
+
+// checkPlanMetadata returns a failure message if the plan in absPath is out of
+// date, i.e. the pull request has new commits since it was planned or it's
+// older than p.MaxPlanAge.
+func (p *DefaultProjectCommandRunner) checkPlanMetadata(ctx models.ProjectCommandContext, absPath string) (string, error) {
+	metadata, err := readPlanMetadata(filepath.Join(absPath, runtime.GetPlanMetadataFilename(ctx.Workspace, ctx.ProjectName)))
+	if os.IsNotExist(err) {
+		// Plans made before we recorded metadata can still be applied.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !sameCommit(metadata.HeadCommit, ctx.Pull.HeadCommit) {
+		return fmt.Sprintf("This plan was generated for commit %s but the pull request is now at commit %s. Run plan again before running apply.", commitOrUnknown(metadata.HeadCommit), commitOrUnknown(ctx.Pull.HeadCommit)), nil
+	}
+	if p.MaxPlanAge > 0 && time.Since(metadata.Time) > p.MaxPlanAge {
+		return fmt.Sprintf("This plan is older than %s so it has expired. Run plan again before running apply.", p.MaxPlanAge), nil
+	}
+	return "", nil
+}
+
+// sameCommit returns true if a and b are the same commit. We're prefix
+// matching because Bitbucket only gives us a 12 character prefix. If either
+// is empty we can't tell so it returns false.
+func sameCommit(a string, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+func commitOrUnknown(commit string) string {
+	if commit == "" {
+		return "unknown"
+	}
+	return commit
+}
+
+// writePlanMetadata writes metadata to path as JSON.
+func writePlanMetadata(path string, metadata *models.PlanMetadata) error {
+	serialized, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "serializing plan metadata")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, serialized, 0600), "writing plan metadata")
+}
+
+// readPlanMetadata reads the metadata written by writePlanMetadata. If the
+// file doesn't exist, the error satisfies os.IsNotExist.
+func readPlanMetadata(path string) (*models.PlanMetadata, error) {
+	serialized, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	var metadata models.PlanMetadata
+	if err := json.Unmarshal(serialized, &metadata); err != nil {
+		return nil, errors.Wrapf(err, "parsing plan metadata at %s", path)
+	}
+	return &metadata, nil
+}
+
+// checkApplyRequirements returns a failure message if ctx doesn't meet the
+// project's apply requirements. command is used in the failure message.
+func (p *DefaultProjectCommandRunner) checkApplyRequirements(ctx models.ProjectCommandContext, command string) (string, error) {
+	for _, req := range ctx.ApplyRequirements {
+		switch req {
+		case raw.ApprovedApplyRequirement:
+			approved, err := p.PullApprovedChecker.PullIsApproved(ctx.BaseRepo, ctx.Pull)
+			if err != nil {
+				return "", errors.Wrap(err, "checking if pull request was approved")
+			}
+			if !approved {
+				return fmt.Sprintf("Pull request must be approved before running %s.", command), nil
+			}
+		case raw.MergeableApplyRequirement:
+			if !ctx.PullMergeable {
+				return fmt.Sprintf("Pull request must be mergeable before running %s.", command), nil
+			}
+		case raw.PoliciesPassedApplyReq:
+			// Policies are checked against a plan so they only gate apply.
+			// Projects without policy sets are never checked so they have
+			// nothing to fail.
+			if command == "apply" && len(ctx.PolicySets) > 0 && ctx.ProjectPlanStatus != models.PassedPolicyCheckStatus {
+				return "All policies must pass for project before running apply.", nil
+			}
+		case raw.UndivergedApplyRequirement:
+			// The plan was made against the base branch we cloned so only
+			// apply is gated.
+			if command != "apply" {
+				continue
+			}
+			diverged, err := p.WorkingDir.HasDiverged(ctx.Log, ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+			if err != nil {
+				return "", errors.Wrap(err, "checking if base branch has diverged")
+			}
+			if diverged {
+				return fmt.Sprintf("The %s branch has new commits since this project was planned. Update the pull request's branch if needed and run plan again before running apply.", ctx.Pull.BaseBranch), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -14,8 +14,16 @@
 package events_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock"
@@ -28,6 +36,7 @@ import (
 	tmocks "github.com/runatlantis/atlantis/server/events/terraform/mocks"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -68,6 +77,7 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	When(mockLocker.TryLock(
 		matchers.AnyPtrToLoggingSimpleLogger(),
 		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
 		matchers.AnyModelsUser(),
 		AnyString(),
 		matchers.AnyModelsProject(),
@@ -129,6 +139,70 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	}
 }
 
+// Test that if the plan is cancelled while a step is running, the rest of the
+// steps aren't run and the result is marked as cancelled.
+func TestDefaultProjectCommandRunner_PlanCancelled(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		InitStepRunner:   mockInit,
+		PlanStepRunner:   mockPlan,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, nil)
+	unlocked := false
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+		UnlockFn: func() error {
+			unlocked = true
+			return nil
+		},
+	}, nil)
+
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := models.ProjectCommandContext{
+		Context:    cmdCtx,
+		Log:        logging.NewNoopLogger(),
+		Steps:      []valid.Step{{StepName: "init"}, {StepName: "plan"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	When(mockInit.Run(matchers.AnyModelsProjectCommandContext(), AnyStringSlice(), AnyString(), matchers.AnyMapOfStringToString())).Then(func(_ []Param) ReturnValues {
+		cancel()
+		return ReturnValues{"Interrupt received.", errors.New("exit status 1")}
+	})
+	res := runner.Plan(ctx)
+
+	Assert(t, res.Cancelled, "exp result to be cancelled")
+	ErrEquals(t, "exit status 1\nInterrupt received.", res.Error)
+	Assert(t, unlocked, "exp lock to be released")
+	mockPlan.VerifyWasCalled(Never()).Run(matchers.AnyModelsProjectCommandContext(), AnyStringSlice(), AnyString(), matchers.AnyMapOfStringToString())
+}
+
 // Test what happens if there's no working dir. This signals that the project
 // was never planned.
 func TestDefaultProjectCommandRunner_ApplyNotCloned(t *testing.T) {
@@ -185,6 +259,332 @@ func TestDefaultProjectCommandRunner_ApplyNotMergeable(t *testing.T) {
 	Equals(t, "Pull request must be mergeable before running apply.", res.Failure)
 }
 
+// Test that if passing policies is required and the policy checks didn't pass
+// we give an error.
+func TestDefaultProjectCommandRunner_ApplyPoliciesNotPassed(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	for _, status := range []models.ProjectPlanStatus{models.PlannedPlanStatus, models.ErroredPolicyCheckStatus} {
+		t.Run(status.String(), func(t *testing.T) {
+			ctx := models.ProjectCommandContext{
+				ProjectPlanStatus: status,
+				ApplyRequirements: []string{"policies_passed"},
+				PolicySets:        []valid.PolicySet{{Name: "policies", Path: "/policies"}},
+			}
+			When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, "All policies must pass for project before running apply.", res.Failure)
+		})
+	}
+}
+
+// Test that policies_passed doesn't block applying a project without any
+// policy sets since its plan is never checked.
+func TestDefaultProjectCommandRunner_ApplyPoliciesPassedNoPolicySets(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockApply := mocks.NewMockStepRunner()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		ApplyStepRunner:  mockApply,
+		Webhooks:         mocks.NewMockWebhooksSender(),
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	ctx := models.ProjectCommandContext{
+		Log:               logging.NewNoopLogger(),
+		ProjectPlanStatus: models.PlannedPlanStatus,
+		ApplyRequirements: []string{"policies_passed"},
+		Steps:             []valid.Step{{StepName: "apply"}},
+	}
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+	When(mockApply.Run(ctx, nil, tmp, map[string]string{})).ThenReturn("applied", nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+	Equals(t, "applied", res.ApplySuccess)
+}
+
+// Test that if undiverged is required and the base branch has new commits we
+// give an error.
+func TestDefaultProjectCommandRunner_ApplyDiverged(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+	ctx := models.ProjectCommandContext{
+		Pull:              models.PullRequest{BaseBranch: "master"},
+		ApplyRequirements: []string{"undiverged"},
+	}
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+	When(mockWorkingDir.HasDiverged(ctx.Log, ctx.HeadRepo, ctx.Pull, ctx.Workspace)).ThenReturn(true, nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "The master branch has new commits since this project was planned. Update the pull request's branch if needed and run plan again before running apply.", res.Failure)
+}
+
+// Test that we refuse to apply plans if the pull request has new commits since
+// it was planned or if the plan has expired.
+func TestDefaultProjectCommandRunner_ApplyStalePlan(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	mockPlan := mocks.NewMockStepRunner()
+	mockApply := mocks.NewMockStepRunner()
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		PlanStepRunner:   mockPlan,
+		ApplyStepRunner:  mockApply,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		MaxPlanAge:       time.Hour,
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, nil)
+	When(mockWorkingDir.GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())).ThenReturn(repoDir, nil)
+	When(mockWorkingDir.GetBaseCommit(matchers.AnyModelsPullRequest(), AnyString())).ThenReturn("base-sha", nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+	}, nil)
+
+	ctx := models.ProjectCommandContext{
+		Log:        logging.NewNoopLogger(),
+		Steps:      []valid.Step{{StepName: "plan"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		Pull:       models.PullRequest{HeadCommit: "head-sha"},
+		User:       models.User{Username: "lkysow"},
+	}
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "head-sha", res.PlanSuccess.Metadata.HeadCommit)
+	Equals(t, "base-sha", res.PlanSuccess.Metadata.BaseCommit)
+	Equals(t, "lkysow", res.PlanSuccess.Metadata.User)
+
+	// The pull request has a new commit.
+	ctx.Steps = []valid.Step{{StepName: "apply"}}
+	ctx.Pull.HeadCommit = "new-sha"
+	res = runner.Apply(ctx)
+	Equals(t, "This plan was generated for commit head-sha but the pull request is now at commit new-sha. Run plan again before running apply.", res.Failure)
+	mockApply.VerifyWasCalled(Never()).Run(ctx, nil, repoDir, map[string]string{})
+
+	// We don't know the pull request's commit.
+	ctx.Pull.HeadCommit = ""
+	res = runner.Apply(ctx)
+	Equals(t, "This plan was generated for commit head-sha but the pull request is now at commit unknown. Run plan again before running apply.", res.Failure)
+
+	// The plan has expired.
+	ctx.Pull.HeadCommit = "head-sha"
+	metadata, err := json.Marshal(models.PlanMetadata{
+		HeadCommit: "head-sha",
+		Time:       time.Now().Add(-2 * time.Hour),
+	})
+	Ok(t, err)
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, runtime.GetPlanMetadataFilename("default", "")), metadata, 0600))
+	res = runner.Apply(ctx)
+	Equals(t, "This plan is older than 1h0m0s so it has expired. Run plan again before running apply.", res.Failure)
+}
+
+// Test that it runs the policy check steps.
+func TestDefaultProjectCommandRunner_PolicyCheck(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockShow := mocks.NewMockStepRunner()
+	mockPolicyCheck := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	runner := &events.DefaultProjectCommandRunner{
+		ShowStepRunner:        mockShow,
+		PolicyCheckStepRunner: mockPolicyCheck,
+		WorkingDir:            mockWorkingDir,
+		WorkingDirLocker:      events.NewDefaultWorkingDirLocker(),
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	ctx := models.ProjectCommandContext{
+		Log: logging.NewNoopLogger(),
+		Steps: []valid.Step{
+			{
+				StepName: "show",
+			},
+			{
+				StepName: "policy_check",
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		RePlanCmd:  "atlantis plan -d .",
+		ApplyCmd:   "atlantis apply -d .",
+	}
+	When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(repoDir, nil)
+	When(mockShow.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("", nil)
+	When(mockPolicyCheck.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("policies passed", nil)
+
+	res := runner.PolicyCheck(ctx)
+	Ok(t, res.Error)
+	Equals(t, models.PolicyCheckCommand, res.Command)
+	Equals(t, &models.PolicyCheckSuccess{
+		PolicyCheckOutput: "policies passed",
+		RePlanCmd:         "atlantis plan -d .",
+		ApplyCmd:          "atlantis apply -d .",
+	}, res.PolicyCheckSuccess)
+	mockShow.VerifyWasCalledOnce().Run(ctx, nil, repoDir, map[string]string{})
+	mockPolicyCheck.VerifyWasCalledOnce().Run(ctx, nil, repoDir, map[string]string{})
+}
+
+// Test that import takes the project lock and runs the import steps.
+func TestDefaultProjectCommandRunner_Import(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockImport := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		InitStepRunner:   mockInit,
+		ImportStepRunner: mockImport,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	ctx := models.ProjectCommandContext{
+		Log: logging.NewNoopLogger(),
+		Steps: []valid.Step{
+			{
+				StepName: "init",
+			},
+			{
+				StepName: "import",
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+		RePlanCmd:  "atlantis plan -d .",
+	}
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+	}, nil)
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, nil)
+	When(mockInit.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("", nil)
+	When(mockImport.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("Import successful!", nil)
+
+	res := runner.Import(ctx)
+	Ok(t, res.Error)
+	Equals(t, models.ImportCommand, res.Command)
+	Equals(t, &models.ImportSuccess{
+		Output:    "Import successful!",
+		RePlanCmd: "atlantis plan -d .",
+	}, res.ImportSuccess)
+	mockImport.VerifyWasCalledOnce().Run(ctx, nil, repoDir, map[string]string{})
+}
+
+// Test that state rm doesn't run if the project is locked by another pull.
+func TestDefaultProjectCommandRunner_StateRmLocked(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockStateRm := mocks.NewMockStepRunner()
+	mockLocker := mocks.NewMockProjectLocker()
+	reg := metrics.NewRegistry()
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:            mockLocker,
+		StateRmStepRunner: mockStateRm,
+		WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+		CommandDuration:   reg.NewHistogram("project_command_duration_seconds", "", metrics.LongBuckets, "command", "result"),
+	}
+	ctx := models.ProjectCommandContext{
+		Log: logging.NewNoopLogger(),
+		Steps: []valid.Step{
+			{
+				StepName: "state_rm",
+			},
+		},
+	}
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired:      false,
+		LockFailureReason: "locked by pull #1",
+	}, nil)
+
+	res := runner.StateRm(ctx)
+	Equals(t, models.StateRmCommand, res.Command)
+	Equals(t, "locked by pull #1", res.Failure)
+	Assert(t, res.StateRmSuccess == nil, "exp no success")
+	mockStateRm.VerifyWasCalled(Never()).Run(matchers.AnyModelsProjectCommandContext(), AnyStringSlice(), AnyString(), matchers.AnyMapOfStringToString())
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	Assert(t, strings.Contains(w.Body.String(), `atlantis_project_command_duration_seconds_count{command="state_rm",result="failure"} 1`), "got %s", w.Body.String())
+}
+
+// Test that import and state rm are gated by the apply requirements.
+func TestDefaultProjectCommandRunner_StateCommandsApplyRequirements(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockApproved := mocks2.NewMockPullApprovedChecker()
+	runner := &events.DefaultProjectCommandRunner{
+		PullApprovedChecker: mockApproved,
+	}
+	ctx := models.ProjectCommandContext{
+		ApplyRequirements: []string{"approved", "mergeable"},
+	}
+	When(mockApproved.PullIsApproved(ctx.BaseRepo, ctx.Pull)).ThenReturn(false, nil)
+	Equals(t, "Pull request must be approved before running import.", runner.Import(ctx).Failure)
+	Equals(t, "Pull request must be approved before running state rm.", runner.StateRm(ctx).Failure)
+
+	When(mockApproved.PullIsApproved(ctx.BaseRepo, ctx.Pull)).ThenReturn(true, nil)
+	Equals(t, "Pull request must be mergeable before running import.", runner.Import(ctx).Failure)
+}
+
 // Test that it runs the expected apply steps.
 func TestDefaultProjectCommandRunner_Apply(t *testing.T) {
 	cases := []struct {
@@ -380,6 +780,7 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	When(mockLocker.TryLock(
 		matchers.AnyPtrToLoggingSimpleLogger(),
 		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
 		matchers.AnyModelsUser(),
 		AnyString(),
 		matchers.AnyModelsProject(),
@@ -433,6 +834,67 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	Equals(t, "var=\n\nvar=value\n\ndynamic_var=dynamic_value\n\ndynamic_var=overridden\n", res.PlanSuccess.TerraformOutput)
 }
 
+// Test that when we're a GitHub App, steps are given the installation token
+// for the base repo's owner.
+func TestDefaultProjectCommandRunner_GithubAppToken(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor: tmocks.NewMockClient(),
+		DefaultTFVersion:  tfVersion,
+	}
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:           mockLocker,
+		LockURLGenerator: mockURLGenerator{},
+		RunStepRunner:    &run,
+		WorkingDir:       mockWorkingDir,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		GithubAppTokens:  &fakeGithubAppTokens{},
+	}
+
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	When(mockWorkingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString(),
+	)).ThenReturn(repoDir, nil)
+	When(mockLocker.TryLock(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsPullRequest(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsUser(),
+		AnyString(),
+		matchers.AnyModelsProject(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+	}, nil)
+
+	baseRepo, err := models.NewRepo(models.Github, "owner/repo", "https://github.com/owner/repo.git", "x-access-token", "")
+	Ok(t, err)
+	ctx := models.ProjectCommandContext{
+		Log:      logging.NewNoopLogger(),
+		BaseRepo: baseRepo,
+		Steps: []valid.Step{
+			{
+				StepName:   "run",
+				RunCommand: "git credential fill <<EOF | grep password\nprotocol=https\nhost=github.com\n\nEOF",
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success, got %v", res.Error)
+	Equals(t, "password=token-for-owner\n", res.PlanSuccess.TerraformOutput)
+}
+
 type mockURLGenerator struct{}
 
 func (m mockURLGenerator) GenerateLockURL(lockID string) string {
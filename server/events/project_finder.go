@@ -17,11 +17,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -61,6 +63,11 @@ func (p *DefaultProjectFinder) DetermineProjects(log *logging.SimpleLogger, modi
 			dirs = append(dirs, projectDir)
 		}
 	}
+
+	// Projects that use a modified module, ex. from a shared top-level
+	// modules/ dir, also need to be planned.
+	_, dependents := p.moduleDependents(log, modifiedTerraformFiles, absRepoDir)
+	dirs = append(dirs, dependents...)
 	uniqueDirs := p.unique(dirs)
 
 	// The list of modified files will include files that were deleted. We still
@@ -79,9 +86,23 @@ func (p *DefaultProjectFinder) DetermineProjects(log *logging.SimpleLogger, modi
 
 // See ProjectFinder.DetermineProjectsViaConfig.
 func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log *logging.SimpleLogger, modifiedFiles []string, config valid.RepoCfg, absRepoDir string) ([]valid.Project, error) {
+	// Projects that use a modified local module are modified even if the
+	// module doesn't match their when_modified patterns.
+	var usesModifiedModule map[string]bool
+	if len(modifiedFiles) > 0 {
+		usesModifiedModule, _ = p.moduleDependents(log, modifiedFiles, absRepoDir)
+	}
+
 	var projects []valid.Project
 	for _, project := range config.Projects {
 		log.Debug("checking if project at dir %q workspace %q was modified", project.Dir, project.Workspace)
+		if usesModifiedModule[filepath.Clean(project.Dir)] {
+			if _, err := os.Stat(filepath.Join(absRepoDir, project.Dir)); err == nil {
+				log.Debug("project at dir %q uses a modified module", project.Dir)
+				projects = append(projects, project)
+				continue
+			}
+		}
 		var whenModifiedRelToRepoRoot []string
 		for _, wm := range project.Autoplan.WhenModified {
 			wm = strings.TrimSpace(wm)
@@ -192,11 +213,10 @@ func (p *DefaultProjectFinder) getProjectDir(modifiedFilePath string, repoDir st
 		//    main.tf # uses modules via ../modules
 		//  modules/
 		//    ...
-		// In this case, if we detect a change in modules/ we don't know which
-		// project was using this module so we can't suggest a project root, but we
-		// also detect that there's no main.tf in the parent folder of modules/
-		// so we won't suggest that as a project. So in this case we return nothing.
-		// The code below makes this happen.
+		// In this case, the modified module doesn't tell us the project root.
+		// We detect that there's no main.tf in the parent folder of modules/
+		// so we won't suggest that as a project and return nothing. The
+		// projects using the module are found by moduleDependents instead.
 
 		// Need to add a trailing slash before splitting on modules/ because if
 		// the input was modules/file.tf then path.Dir will be "modules" and so our
@@ -217,6 +237,106 @@ func (p *DefaultProjectFinder) getProjectDir(modifiedFilePath string, repoDir st
 	return dir
 }
 
+// moduleDependents finds the dirs that use a local module that contains one
+// of modifiedFiles, either directly or through other modules. It returns all
+// these dirs and, separately, those that aren't themselves used as modules,
+// i.e. the root modules that we can run plan in. Dirs are relative to
+// absRepoDir.
+func (p *DefaultProjectFinder) moduleDependents(log *logging.SimpleLogger, modifiedFiles []string, absRepoDir string) (map[string]bool, []string) {
+	callers, err := p.moduleCallers(absRepoDir)
+	if err != nil {
+		log.Warn("unable to determine which projects use local modules: %s", err)
+		return nil, nil
+	}
+	if len(callers) == 0 {
+		return nil, nil
+	}
+
+	// The modified modules are the closest dirs containing a modified file
+	// that are called as modules.
+	var queue []string
+	for _, f := range modifiedFiles {
+		for dir := path.Dir(f); ; dir = path.Dir(dir) {
+			if _, ok := callers[dir]; ok {
+				queue = append(queue, dir)
+				break
+			}
+			if dir == "." || dir == "/" {
+				break
+			}
+		}
+	}
+
+	dependents := make(map[string]bool)
+	for len(queue) > 0 {
+		module := queue[0]
+		queue = queue[1:]
+		for _, caller := range callers[module] {
+			if !dependents[caller] {
+				dependents[caller] = true
+				queue = append(queue, caller)
+			}
+		}
+	}
+
+	var roots []string
+	for dir := range dependents {
+		if _, isModule := callers[dir]; !isModule {
+			roots = append(roots, dir)
+		}
+	}
+	sort.Strings(roots)
+	if len(roots) > 0 {
+		log.Info("found %d project(s) using modified local modules: %v", len(roots), strings.Join(roots, ", "))
+	}
+	return dependents, roots
+}
+
+// moduleCallers parses the Terraform files in each dir of the repo and returns
+// a map from the dir of each local module, ex. modules/vpc, to the dirs that
+// call it, ex. project1 if project1 uses source = "../modules/vpc". Dirs are
+// relative to absRepoDir.
+func (p *DefaultProjectFinder) moduleCallers(absRepoDir string) (map[string][]string, error) {
+	callers := make(map[string][]string)
+	err := filepath.Walk(absRepoDir, func(absPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" || info.Name() == ".terraform" {
+			return filepath.SkipDir
+		}
+		if !tfconfig.IsModuleDir(absPath) {
+			return nil
+		}
+		relDir, err := filepath.Rel(absRepoDir, absPath)
+		if err != nil {
+			return err
+		}
+		// We ignore any errors since even if some of the config is invalid
+		// we still get the module calls that could be parsed.
+		module, _ := tfconfig.LoadModule(absPath)
+		if module == nil {
+			return nil
+		}
+		for _, call := range module.ModuleCalls {
+			if !strings.HasPrefix(call.Source, "./") && !strings.HasPrefix(call.Source, "../") {
+				// Only local modules can be modified in the same repo.
+				continue
+			}
+			moduleDir := filepath.Clean(filepath.Join(relDir, call.Source))
+			if moduleDir == ".." || strings.HasPrefix(moduleDir, "../") {
+				continue
+			}
+			callers[moduleDir] = append(callers[moduleDir], relDir)
+		}
+		return nil
+	})
+	return callers, errors.Wrapf(err, "finding modules in %q", absRepoDir)
+}
+
 // unique de-duplicates strs.
 func (p *DefaultProjectFinder) unique(strs []string) []string {
 	hash := make(map[string]bool)
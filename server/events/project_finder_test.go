@@ -458,3 +458,74 @@ func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 		})
 	}
 }
+
+// Test that projects using a modified local module, either directly or through
+// another module, are planned.
+func TestDetermineProjects_ModuleDependents(t *testing.T) {
+	repoDir, cleanup := DirStructure(t, map[string]interface{}{
+		"modules": map[string]interface{}{
+			"network": map[string]interface{}{
+				"main.tf": nil,
+			},
+			"cluster": map[string]interface{}{
+				"main.tf": `module "network" { source = "../network" }`,
+			},
+		},
+		"project1": map[string]interface{}{
+			"main.tf": `module "network" { source = "../modules/network" }`,
+		},
+		"project2": map[string]interface{}{
+			"main.tf": `module "cluster" { source = "../modules/cluster" }`,
+		},
+		"project3": map[string]interface{}{
+			"main.tf": `module "remote" { source = "terraform-aws-modules/vpc/aws" }`,
+		},
+	})
+	defer cleanup()
+
+	cases := []struct {
+		description     string
+		files           []string
+		expProjectPaths []string
+	}{
+		{
+			"direct and transitive dependents",
+			[]string{"modules/network/main.tf"},
+			[]string{"project1", "project2"},
+		},
+		{
+			"only dependents of the modified module",
+			[]string{"modules/cluster/main.tf"},
+			[]string{"project2"},
+		},
+		{
+			"modified project",
+			[]string{"project3/main.tf"},
+			[]string{"project3"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			projects := m.DetermineProjects(noopLogger, c.files, modifiedRepo, repoDir)
+			var paths []string
+			for _, project := range projects {
+				paths = append(paths, project.Path)
+			}
+			Equals(t, c.expProjectPaths, paths)
+		})
+	}
+
+	// Projects defined in an atlantis.yaml file should also be planned when a
+	// module they use is modified, even if it doesn't match when_modified.
+	config := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "project1", Autoplan: valid.Autoplan{Enabled: true, WhenModified: []string{"*.tf"}}},
+			{Dir: "project2", Autoplan: valid.Autoplan{Enabled: true, WhenModified: []string{"*.tf"}}},
+			{Dir: "project3", Autoplan: valid.Autoplan{Enabled: true, WhenModified: []string{"*.tf"}}},
+		},
+	}
+	projects, err := m.DetermineProjectsViaConfig(noopLogger, []string{"modules/cluster/main.tf"}, config, repoDir)
+	Ok(t, err)
+	Equals(t, 1, len(projects))
+	Equals(t, "project2", projects[0].Dir)
+}
@@ -32,7 +32,8 @@ type ProjectLocker interface {
 	// The third return value is a function that can be called to unlock the
 	// lock. It will only be set if the lock was acquired. Any errors will set
 	// error.
-	TryLock(log *logging.SimpleLogger, pull models.PullRequest, user models.User, workspace string, project models.Project) (*TryLockResponse, error)
+	// headRepo is stored with pull if it's queued for the lock.
+	TryLock(log *logging.SimpleLogger, pull models.PullRequest, headRepo models.Repo, user models.User, workspace string, project models.Project) (*TryLockResponse, error)
 }
 
 // DefaultProjectLocker implements ProjectLocker.
@@ -56,16 +57,21 @@ type TryLockResponse struct {
 }
 
 // TryLock implements ProjectLocker.TryLock.
-func (p *DefaultProjectLocker) TryLock(log *logging.SimpleLogger, pull models.PullRequest, user models.User, workspace string, project models.Project) (*TryLockResponse, error) {
-	lockAttempt, err := p.Locker.TryLock(project, workspace, pull, user)
+func (p *DefaultProjectLocker) TryLock(log *logging.SimpleLogger, pull models.PullRequest, headRepo models.Repo, user models.User, workspace string, project models.Project) (*TryLockResponse, error) {
+	lockAttempt, err := p.Locker.TryLock(project, workspace, pull, headRepo, user)
 	if err != nil {
 		return nil, err
 	}
 	if !lockAttempt.LockAcquired && lockAttempt.CurrLock.Pull.Num != pull.Num {
 		failureMsg := fmt.Sprintf(
-			"This project is currently locked by an unapplied plan from pull #%d. To continue, delete the lock from #%d or apply that plan and merge the pull request.\n\nOnce the lock is released, comment `atlantis plan` here to re-plan.",
+			"This project is currently locked by an unapplied plan from pull #%d. To continue, delete the lock from #%d or apply that plan and merge the pull request.\n\n",
 			lockAttempt.CurrLock.Pull.Num,
 			lockAttempt.CurrLock.Pull.Num)
+		if lockAttempt.QueuePosition > 0 {
+			failureMsg += fmt.Sprintf("This pull request is #%d in the queue for the lock. Once the lock is released, it will be planned again automatically.", lockAttempt.QueuePosition)
+		} else {
+			failureMsg += "Once the lock is released, comment `atlantis plan` here to re-plan."
+		}
 		return &TryLockResponse{
 			LockAcquired:      false,
 			LockFailureReason: failureMsg,
@@ -33,12 +33,13 @@ func TestDefaultProjectLocker_TryLockWhenLocked(t *testing.T) {
 	expProject := models.Project{}
 	expWorkspace := "default"
 	expPull := models.PullRequest{}
+	expHeadRepo := models.Repo{FullName: "owner/repo"}
 	expUser := models.User{}
 
 	lockingPull := models.PullRequest{
 		Num: 2,
 	}
-	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expUser)).ThenReturn(
+	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expHeadRepo, expUser)).ThenReturn(
 		locking.TryLockResponse{
 			LockAcquired: false,
 			CurrLock: models.ProjectLock{
@@ -48,7 +49,7 @@ func TestDefaultProjectLocker_TryLockWhenLocked(t *testing.T) {
 		},
 		nil,
 	)
-	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expUser, expWorkspace, expProject)
+	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expHeadRepo, expUser, expWorkspace, expProject)
 	Ok(t, err)
 	Equals(t, &events.TryLockResponse{
 		LockAcquired:      false,
@@ -56,6 +57,36 @@ func TestDefaultProjectLocker_TryLockWhenLocked(t *testing.T) {
 	}, res)
 }
 
+func TestDefaultProjectLocker_TryLockWhenLockedQueued(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockLocker := mocks.NewMockLocker()
+	locker := events.DefaultProjectLocker{
+		Locker: mockLocker,
+	}
+	expProject := models.Project{}
+	expWorkspace := "default"
+	expPull := models.PullRequest{Num: 3}
+	expHeadRepo := models.Repo{FullName: "owner/repo"}
+	expUser := models.User{}
+
+	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expHeadRepo, expUser)).ThenReturn(
+		locking.TryLockResponse{
+			LockAcquired: false,
+			CurrLock: models.ProjectLock{
+				Pull: models.PullRequest{Num: 2},
+			},
+			QueuePosition: 1,
+		},
+		nil,
+	)
+	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expHeadRepo, expUser, expWorkspace, expProject)
+	Ok(t, err)
+	Equals(t, &events.TryLockResponse{
+		LockAcquired:      false,
+		LockFailureReason: "This project is currently locked by an unapplied plan from pull #2. To continue, delete the lock from #2 or apply that plan and merge the pull request.\n\nThis pull request is #1 in the queue for the lock. Once the lock is released, it will be planned again automatically.",
+	}, res)
+}
+
 func TestDefaultProjectLocker_TryLockWhenLockedSamePull(t *testing.T) {
 	RegisterMockTestingT(t)
 	mockLocker := mocks.NewMockLocker()
@@ -65,13 +96,14 @@ func TestDefaultProjectLocker_TryLockWhenLockedSamePull(t *testing.T) {
 	expProject := models.Project{}
 	expWorkspace := "default"
 	expPull := models.PullRequest{Num: 2}
+	expHeadRepo := models.Repo{FullName: "owner/repo"}
 	expUser := models.User{}
 
 	lockingPull := models.PullRequest{
 		Num: 2,
 	}
 	lockKey := "key"
-	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expUser)).ThenReturn(
+	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expHeadRepo, expUser)).ThenReturn(
 		locking.TryLockResponse{
 			LockAcquired: false,
 			CurrLock: models.ProjectLock{
@@ -81,7 +113,7 @@ func TestDefaultProjectLocker_TryLockWhenLockedSamePull(t *testing.T) {
 		},
 		nil,
 	)
-	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expUser, expWorkspace, expProject)
+	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expHeadRepo, expUser, expWorkspace, expProject)
 	Ok(t, err)
 	Equals(t, true, res.LockAcquired)
 
@@ -101,13 +133,14 @@ func TestDefaultProjectLocker_TryLockUnlocked(t *testing.T) {
 	expProject := models.Project{}
 	expWorkspace := "default"
 	expPull := models.PullRequest{Num: 2}
+	expHeadRepo := models.Repo{FullName: "owner/repo"}
 	expUser := models.User{}
 
 	lockingPull := models.PullRequest{
 		Num: 2,
 	}
 	lockKey := "key"
-	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expUser)).ThenReturn(
+	When(mockLocker.TryLock(expProject, expWorkspace, expPull, expHeadRepo, expUser)).ThenReturn(
 		locking.TryLockResponse{
 			LockAcquired: true,
 			CurrLock: models.ProjectLock{
@@ -117,7 +150,7 @@ func TestDefaultProjectLocker_TryLockUnlocked(t *testing.T) {
 		},
 		nil,
 	)
-	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expUser, expWorkspace, expProject)
+	res, err := locker.TryLock(logging.NewNoopLogger(), expPull, expHeadRepo, expUser, expWorkspace, expProject)
 	Ok(t, err)
 	Equals(t, true, res.LockAcquired)
 
@@ -20,8 +20,6 @@ import (
 	"strings"
 	"text/template"
 
-	"github.com/runatlantis/atlantis/server/events/db"
-
 	"github.com/runatlantis/atlantis/server/logging"
 
 	"github.com/pkg/errors"
@@ -46,7 +44,7 @@ type PullClosedExecutor struct {
 	VCSClient  vcs.Client
 	WorkingDir WorkingDir
 	Logger     logging.SimpleLogging
-	DB         *db.BoltDB
+	DB         locking.Backend
 }
 
 type templatedProject struct {
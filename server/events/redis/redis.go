@@ -0,0 +1,877 @@
+// Package redis handles our remote database layer.
+package redis
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// RedisDB is a database using Redis. Unlike BoltDB, it can be shared by
+// multiple Atlantis instances.
+type RedisDB struct { // nolint: golint
+	client *redis.Client
+}
+
+const (
+	locksKeyPrefix      = "lock/"
+	pullsKeyPrefix      = "pr/"
+	driftKeyPrefix      = "drift/"
+	driftReposKeyPrefix = "drift_repo/"
+	historyKeyPrefix    = "history/"
+	queuesKeyPrefix     = "queue/"
+	eventsKeyPrefix     = "event/"
+	pullKeySeparator    = "::"
+	// maxTxRetries is how many times we retry a transaction if a key we're
+	// watching was modified by another instance while we were running.
+	maxTxRetries = 10
+)
+
+// getter is implemented by both redis.Client and redis.Tx so we can read keys
+// inside and outside of transactions.
+type getter interface {
+	Get(key string) *redis.StringCmd
+}
+
+// New returns a new RedisDB connected to the Redis instance at hostname:port.
+// It returns an error if the instance can't be reached.
+func New(hostname string, port int, password string, tlsEnabled bool, insecureSkipVerify bool, db int) (*RedisDB, error) {
+	var tlsConfig *tls.Config
+	if tlsEnabled {
+		tlsConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: insecureSkipVerify, // nolint: gosec
+		}
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:      fmt.Sprintf("%s:%d", hostname, port),
+		Password:  password,
+		DB:        db,
+		TLSConfig: tlsConfig,
+	})
+
+	// Check that the connection is valid.
+	if _, err := client.Ping().Result(); err != nil {
+		return nil, errors.Wrap(err, "connecting to Redis")
+	}
+	return &RedisDB{client: client}, nil
+}
+
+// TryLock attempts to create a new lock. If the lock is
+// acquired, it will return true and the lock returned will be newLock. The
+// lock's pull request is also removed from the lock's queue.
+// If the lock is not acquired, it will return false and the current
+// lock that is preventing this lock from being acquired. If that lock is held
+// by newLock's pull request, its expiry is updated to newLock's since the
+// pull request is still using it.
+func (r *RedisDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock, error) {
+	key := r.lockKey(newLock.Project, newLock.Workspace)
+	newLockSerialized, err := json.Marshal(newLock)
+	if err != nil {
+		return false, newLock, errors.Wrap(err, "serializing")
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		// SETNX is atomic so only one instance can ever acquire the lock.
+		acquired, err := r.client.SetNX(key, newLockSerialized, 0).Result()
+		if err != nil {
+			return false, newLock, errors.Wrap(err, "db transaction failed")
+		}
+		if acquired {
+			err := r.updateQueue(r.queueKey(newLock.Project, newLock.Workspace), func(queue []models.ProjectLock) []models.ProjectLock {
+				return removeQueuedPull(queue, newLock.Project.RepoFullName, newLock.Pull.Num)
+			})
+			return true, newLock, errors.Wrap(err, "db transaction failed")
+		}
+
+		// Someone else holds the lock so return it.
+		currLock, err := r.refreshLock(key, newLock)
+		if err != nil {
+			return false, newLock, err
+		}
+		// If the lock was deleted between our SETNX and GET we try again.
+		if currLock != nil {
+			return false, *currLock, nil
+		}
+	}
+	return false, newLock, fmt.Errorf("db transaction failed: lock at key %q kept changing", key)
+}
+
+// refreshLock returns the lock at key or nil if there isn't one. If the lock
+// is held by newLock's pull request, its expiry is updated to newLock's since
+// the pull request is still using it.
+func (r *RedisDB) refreshLock(key string, newLock models.ProjectLock) (*models.ProjectLock, error) {
+	var lock *models.ProjectLock
+	err := r.watch(key, func(tx *redis.Tx) error {
+		var err error
+		lock, err = r.getLock(tx, key)
+		if err != nil || lock == nil || lock.Pull.Num != newLock.Pull.Num || lock.ExpiresAt.Equal(newLock.ExpiresAt) {
+			return err
+		}
+		lock.ExpiresAt = newLock.ExpiresAt
+		serialized, err := json.Marshal(lock)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, serialized, 0)
+			return nil
+		})
+		return err
+	})
+	return lock, errors.Wrap(err, "db transaction failed")
+}
+
+// Unlock attempts to unlock the project and workspace.
+// If there is no lock, then it will return a nil pointer.
+// If there is a lock, then it will delete it, and then return a pointer
+// to the deleted lock.
+func (r *RedisDB) Unlock(p models.Project, workspace string) (*models.ProjectLock, error) {
+	return r.unlockIf(r.lockKey(p, workspace), func(models.ProjectLock) bool { return true })
+}
+
+// UnlockIfExpired deletes the lock for project and workspace if it has
+// expired by now. It returns the deleted lock or nil if there's no lock or it
+// hasn't expired.
+func (r *RedisDB) UnlockIfExpired(p models.Project, workspace string, now time.Time) (*models.ProjectLock, error) {
+	return r.unlockIf(r.lockKey(p, workspace), func(lock models.ProjectLock) bool { return lock.Expired(now) })
+}
+
+// unlockIf deletes the lock at key if shouldDelete returns true for it. The
+// check and delete are done in one transaction so that a lock that was
+// released and taken by someone else in the meantime isn't deleted. It
+// returns the deleted lock or nil if no lock was deleted.
+func (r *RedisDB) unlockIf(key string, shouldDelete func(lock models.ProjectLock) bool) (*models.ProjectLock, error) {
+	var deleted *models.ProjectLock
+	err := r.watch(key, func(tx *redis.Tx) error {
+		deleted = nil
+		lock, err := r.getLock(tx, key)
+		if err != nil || lock == nil || !shouldDelete(*lock) {
+			return err
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Del(key)
+			return nil
+		})
+		if err == nil {
+			deleted = lock
+		}
+		return err
+	})
+	return deleted, errors.Wrap(err, "db transaction failed")
+}
+
+// List lists all current locks.
+func (r *RedisDB) List() ([]models.ProjectLock, error) {
+	var locks []models.ProjectLock
+	iter := r.client.Scan(0, locksKeyPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		lock, err := r.getLock(r.client, iter.Val())
+		if err != nil {
+			return locks, err
+		}
+		// The lock may have been deleted since we scanned it.
+		if lock != nil {
+			locks = append(locks, *lock)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return locks, errors.Wrap(err, "db transaction failed")
+	}
+	return locks, nil
+}
+
+// UnlockByPull deletes all locks associated with that pull request and returns
+// them. The pull request is also removed from the queue of every lock.
+// Another instance may release one of the locks and another pull request may
+// take it after we've listed them so each lock is only deleted if it's still
+// held by this pull request.
+func (r *RedisDB) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
+	var locks []models.ProjectLock
+	allLocks, err := r.List()
+	if err != nil {
+		return locks, err
+	}
+	heldByPull := func(lock models.ProjectLock) bool {
+		return lock.Project.RepoFullName == repoFullName && lock.Pull.Num == pullNum
+	}
+	for _, lock := range allLocks {
+		if !heldByPull(lock) {
+			continue
+		}
+		deleted, err := r.unlockIf(r.lockKey(lock.Project, lock.Workspace), heldByPull)
+		if err != nil {
+			return locks, errors.Wrapf(err, "unlocking repo %s, path %s, workspace %s", lock.Project.RepoFullName, lock.Project.Path, lock.Workspace)
+		}
+		if deleted != nil {
+			locks = append(locks, *deleted)
+		}
+	}
+
+	// Remove the pull from the queues.
+	iter := r.client.Scan(0, queuesKeyPrefix+repoFullName+"/*", 0).Iterator()
+	for iter.Next() {
+		err := r.updateQueue(iter.Val(), func(queue []models.ProjectLock) []models.ProjectLock {
+			return removeQueuedPull(queue, repoFullName, pullNum)
+		})
+		if err != nil {
+			return locks, errors.Wrap(err, "db transaction failed")
+		}
+	}
+	return locks, errors.Wrap(iter.Err(), "db transaction failed")
+}
+
+// Enqueue adds lock's pull request to the end of the queue of pull requests
+// waiting for the lock for lock's project and workspace. If the pull request
+// is already queued, it keeps its place. It returns the pull request's
+// position in the queue, starting at 1.
+func (r *RedisDB) Enqueue(lock models.ProjectLock) (int, error) {
+	var position int
+	err := r.updateQueue(r.queueKey(lock.Project, lock.Workspace), func(queue []models.ProjectLock) []models.ProjectLock {
+		for i, q := range queue {
+			if q.Pull.Num == lock.Pull.Num {
+				position = i + 1
+				return queue
+			}
+		}
+		position = len(queue) + 1
+		return append(queue, lock)
+	})
+	return position, errors.Wrap(err, "db transaction failed")
+}
+
+// Dequeue removes the first pull request from the queue for the lock for
+// project and workspace and returns it. If the queue is empty, it returns a
+// nil pointer.
+func (r *RedisDB) Dequeue(p models.Project, workspace string) (*models.ProjectLock, error) {
+	var next *models.ProjectLock
+	err := r.updateQueue(r.queueKey(p, workspace), func(queue []models.ProjectLock) []models.ProjectLock {
+		if len(queue) == 0 {
+			return queue
+		}
+		next = &queue[0]
+		return queue[1:]
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	if next != nil {
+		next.Time = next.Time.Local()
+	}
+	return next, nil
+}
+
+// GetQueue returns the pull requests waiting for the lock for project and
+// workspace, in the order they'll get it.
+func (r *RedisDB) GetQueue(p models.Project, workspace string) ([]models.ProjectLock, error) {
+	queue, err := r.getQueue(r.client, r.queueKey(p, workspace))
+	if err != nil {
+		return nil, err
+	}
+	for i := range queue {
+		queue[i].Time = queue[i].Time.Local()
+	}
+	return queue, nil
+}
+
+// GetLock returns a pointer to the lock for that project and workspace.
+// If there is no lock, it returns a nil pointer.
+func (r *RedisDB) GetLock(p models.Project, workspace string) (*models.ProjectLock, error) {
+	return r.getLock(r.client, r.lockKey(p, workspace))
+}
+
+// UpdatePullWithResults updates pull's status with the latest project results.
+// It returns the new PullStatus object.
+func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []models.ProjectResult) (models.PullStatus, error) {
+	key, err := r.pullKey(pull)
+	if err != nil {
+		return models.PullStatus{}, err
+	}
+
+	var newStatus models.PullStatus
+	err = r.watch(key, func(tx *redis.Tx) error {
+		currStatus, err := r.getPull(tx, key)
+		if err != nil {
+			return err
+		}
+
+		// If there is no pull OR if the pull we have is out of date, we
+		// just write a new pull.
+		if currStatus == nil || currStatus.Pull.HeadCommit != pull.HeadCommit {
+			var statuses []models.ProjectStatus
+			for _, res := range newResults {
+				statuses = append(statuses, r.projectResultToProject(res))
+			}
+			newStatus = models.PullStatus{
+				Pull:     pull,
+				Projects: statuses,
+			}
+		} else {
+			// If there's an existing pull at the right commit then we have to
+			// merge our project results with the existing ones. We do a merge
+			// because it's possible a user is just applying a single project
+			// in this command and so we don't want to delete our data about
+			// other projects that aren't affected by this command.
+			newStatus = *currStatus
+			for _, res := range newResults {
+				// First, check if we should update any existing projects.
+				updatedExisting := false
+				for i := range newStatus.Projects {
+					// NOTE: We're using a reference here because we are
+					// in-place updating its Status field.
+					proj := &newStatus.Projects[i]
+					if res.Workspace == proj.Workspace &&
+						res.RepoRelDir == proj.RepoRelDir &&
+						res.ProjectName == proj.ProjectName {
+
+						proj.Status = res.PlanStatus()
+						if res.PlanSuccess != nil {
+							proj.PlanMetadata = res.PlanSuccess.Metadata
+						}
+						updatedExisting = true
+						break
+					}
+				}
+
+				if !updatedExisting {
+					// If we didn't update an existing project, then we need to
+					// add this because it's a new one.
+					newStatus.Projects = append(newStatus.Projects, r.projectResultToProject(res))
+				}
+			}
+		}
+
+		// Now, we overwrite the key with our new status.
+		return r.writePull(tx, key, newStatus)
+	})
+	return newStatus, errors.Wrap(err, "db transaction failed")
+}
+
+// GetPullStatus returns the status for pull.
+// If there is no status, returns a nil pointer.
+func (r *RedisDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, error) {
+	key, err := r.pullKey(pull)
+	if err != nil {
+		return nil, err
+	}
+	return r.getPull(r.client, key)
+}
+
+// DeletePullStatus deletes the status for pull.
+func (r *RedisDB) DeletePullStatus(pull models.PullRequest) error {
+	key, err := r.pullKey(pull)
+	if err != nil {
+		return err
+	}
+	err = r.client.Del(key).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// DeleteProjectStatus deletes all project statuses under pull that match
+// workspace and repoRelDir.
+func (r *RedisDB) DeleteProjectStatus(pull models.PullRequest, workspace string, repoRelDir string) error {
+	key, err := r.pullKey(pull)
+	if err != nil {
+		return err
+	}
+	err = r.watch(key, func(tx *redis.Tx) error {
+		currStatusPtr, err := r.getPull(tx, key)
+		if err != nil {
+			return err
+		}
+		if currStatusPtr == nil {
+			return nil
+		}
+		currStatus := *currStatusPtr
+
+		// Create a new projectStatuses array without the ones we want to
+		// delete.
+		var newProjects []models.ProjectStatus
+		for _, p := range currStatus.Projects {
+			if p.Workspace == workspace && p.RepoRelDir == repoRelDir {
+				continue
+			}
+			newProjects = append(newProjects, p)
+		}
+
+		// Overwrite the old pull status.
+		currStatus.Projects = newProjects
+		return r.writePull(tx, key, currStatus)
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// UpdateDriftStatus stores the result of checking a project for drift,
+// overwriting the previous result for that project.
+func (r *RedisDB) UpdateDriftStatus(status models.DriftStatus) error {
+	key, err := r.driftKey(status)
+	if err != nil {
+		return err
+	}
+	serialized, err := json.Marshal(status)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = r.client.Set(key, serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetDriftStatuses returns the latest drift status of every project that has
+// been checked for drift.
+func (r *RedisDB) GetDriftStatuses() ([]models.DriftStatus, error) {
+	var statuses []models.DriftStatus
+	iter := r.client.Scan(0, driftKeyPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		serialized, err := r.client.Get(key).Bytes()
+		// The status may have been deleted since we scanned it.
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return statuses, errors.Wrap(err, "getting drift status")
+		}
+		var s models.DriftStatus
+		if err := json.Unmarshal(serialized, &s); err != nil {
+			return statuses, errors.Wrapf(err, "deserializing drift status at key %q", key)
+		}
+		s.LastChecked = s.LastChecked.Local()
+		statuses = append(statuses, s)
+	}
+	if err := iter.Err(); err != nil {
+		return statuses, errors.Wrap(err, "db transaction failed")
+	}
+	return statuses, nil
+}
+
+// UpdateDriftRepo stores repo so that it can be checked for drift,
+// overwriting the previously stored repo with the same ID.
+func (r *RedisDB) UpdateDriftRepo(repo models.Repo) error {
+	serialized, err := json.Marshal(repo)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = r.client.Set(driftReposKeyPrefix+repo.ID(), serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetDriftRepos returns the repos stored by UpdateDriftRepo.
+func (r *RedisDB) GetDriftRepos() ([]models.Repo, error) {
+	var repos []models.Repo
+	iter := r.client.Scan(0, driftReposKeyPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		serialized, err := r.client.Get(key).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return repos, errors.Wrap(err, "getting drift repo")
+		}
+		var repo models.Repo
+		if err := json.Unmarshal(serialized, &repo); err != nil {
+			return repos, errors.Wrapf(err, "deserializing drift repo at key %q", key)
+		}
+		repos = append(repos, repo)
+	}
+	if err := iter.Err(); err != nil {
+		return repos, errors.Wrap(err, "db transaction failed")
+	}
+	return repos, nil
+}
+
+// AddProjectRuns adds runs to the history of pull.
+func (r *RedisDB) AddProjectRuns(pull models.PullRequest, runs []models.ProjectRun) error {
+	if len(runs) == 0 {
+		return nil
+	}
+	key, err := r.historyKey(pull)
+	if err != nil {
+		return err
+	}
+	var serialized []interface{}
+	for _, run := range runs {
+		s, err := json.Marshal(run)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		serialized = append(serialized, s)
+	}
+	// Each pull's history is a list so runs stay in the order they were
+	// added.
+	err = r.client.RPush(key, serialized...).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetProjectRuns returns the history of pull, oldest run first.
+func (r *RedisDB) GetProjectRuns(pull models.PullRequest) ([]models.ProjectRun, error) {
+	key, err := r.historyKey(pull)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := r.client.LRange(key, 0, -1).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	runs, err := r.deserializeRuns(key, vals)
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		runs[i].StartedAt = runs[i].StartedAt.Local()
+		runs[i].FinishedAt = runs[i].FinishedAt.Local()
+	}
+	return runs, nil
+}
+
+// DeleteProjectRunsBefore deletes the runs of every pull request that
+// finished before t. It returns how many runs were deleted.
+func (r *RedisDB) DeleteProjectRunsBefore(t time.Time) (int, error) {
+	var deleted int
+	iter := r.client.Scan(0, historyKeyPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		err := r.watch(key, func(tx *redis.Tx) error {
+			vals, err := tx.LRange(key, 0, -1).Result()
+			if err != nil {
+				return err
+			}
+			runs, err := r.deserializeRuns(key, vals)
+			if err != nil {
+				return err
+			}
+			// Runs are added in order so once we find one that's new enough,
+			// all the following ones are too.
+			n := 0
+			for n < len(runs) && runs[n].FinishedAt.Before(t) {
+				n++
+			}
+			if n == 0 {
+				return nil
+			}
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				pipe.LTrim(key, int64(n), -1)
+				return nil
+			})
+			if err == nil {
+				deleted += n
+			}
+			return err
+		})
+		if err != nil {
+			return deleted, errors.Wrap(err, "db transaction failed")
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, errors.Wrap(err, "db transaction failed")
+	}
+	return deleted, nil
+}
+
+// EnqueueEvent adds event to the event queue. If an event with the same ID has
+// already been received, it isn't added and EnqueueEvent returns false.
+func (r *RedisDB) EnqueueEvent(event models.QueuedEvent) (bool, error) {
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return false, errors.Wrap(err, "serializing")
+	}
+	added, err := r.client.SetNX(eventsKeyPrefix+event.ID, serialized, 0).Result()
+	return added, errors.Wrap(err, "db transaction failed")
+}
+
+// GetEvents returns the events in the event queue, oldest first.
+func (r *RedisDB) GetEvents() ([]models.QueuedEvent, error) {
+	var events []models.QueuedEvent
+	iter := r.client.Scan(0, eventsKeyPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		event, err := r.getEvent(r.client, key)
+		if err != nil {
+			return events, err
+		}
+		// The event may have been deleted since we scanned it.
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return events, errors.Wrap(err, "db transaction failed")
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ReceivedAt.Before(events[j].ReceivedAt)
+	})
+	return events, nil
+}
+
+// UpdateEvent replaces the event with the same ID as event.
+func (r *RedisDB) UpdateEvent(event models.QueuedEvent) error {
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = r.client.Set(eventsKeyPrefix+event.ID, serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// StartEvent marks the pending event with id as running, increments its
+// attempts and sets its heartbeat. It returns the updated event or nil if the event isn't pending,
+// ex. because another instance started it first.
+func (r *RedisDB) StartEvent(id string) (*models.QueuedEvent, error) {
+	key := eventsKeyPrefix + id
+	var started *models.QueuedEvent
+	err := r.watch(key, func(tx *redis.Tx) error {
+		started = nil
+		event, err := r.getEvent(tx, key)
+		if err != nil || event == nil || event.Status != models.PendingEventStatus {
+			return err
+		}
+		event.Status = models.RunningEventStatus
+		event.Attempts++
+		event.HeartbeatAt = time.Now()
+		serialized, err := json.Marshal(event)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, serialized, 0)
+			return nil
+		})
+		if err == nil {
+			started = event
+		}
+		return err
+	})
+	return started, errors.Wrap(err, "db transaction failed")
+}
+
+// DeleteEvent deletes the event with id from the event queue.
+func (r *RedisDB) DeleteEvent(id string) error {
+	err := r.client.Del(eventsKeyPrefix + id).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// DeleteProcessedEventsBefore deletes the processed events that were received
+// before t. It returns how many were deleted.
+func (r *RedisDB) DeleteProcessedEventsBefore(t time.Time) (int, error) {
+	events, err := r.GetEvents()
+	if err != nil {
+		return 0, err
+	}
+	var keys []string
+	for _, event := range events {
+		if event.Status == models.ProcessedEventStatus && event.ReceivedAt.Before(t) {
+			keys = append(keys, eventsKeyPrefix+event.ID)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	deleted, err := r.client.Del(keys...).Result()
+	return int(deleted), errors.Wrap(err, "db transaction failed")
+}
+
+// getEvent returns the event at key or nil if there isn't one.
+func (r *RedisDB) getEvent(g getter, key string) (*models.QueuedEvent, error) {
+	serialized, err := g.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting event")
+	}
+	var event models.QueuedEvent
+	if err := json.Unmarshal(serialized, &event); err != nil {
+		return nil, errors.Wrapf(err, "deserializing event at key %q", key)
+	}
+	event.ReceivedAt = event.ReceivedAt.Local()
+	return &event, nil
+}
+
+// Close closes the connection to Redis.
+func (r *RedisDB) Close() error {
+	return r.client.Close()
+}
+
+// watch runs fn in an optimistic transaction on key. If key is modified by
+// someone else before fn's writes are committed, fn is retried.
+func (r *RedisDB) watch(key string, fn func(tx *redis.Tx) error) error {
+	for i := 0; i < maxTxRetries; i++ {
+		err := r.client.Watch(fn, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return fmt.Errorf("key %q kept changing after %d attempts", key, maxTxRetries)
+}
+
+func (r *RedisDB) pullKey(pull models.PullRequest) (string, error) {
+	hostname := pull.BaseRepo.VCSHost.Hostname
+	if strings.Contains(hostname, pullKeySeparator) {
+		return "", fmt.Errorf("vcs hostname %q contains illegal string %q", hostname, pullKeySeparator)
+	}
+	repo := pull.BaseRepo.FullName
+	if strings.Contains(repo, pullKeySeparator) {
+		return "", fmt.Errorf("repo name %q contains illegal string %q", hostname, pullKeySeparator)
+	}
+
+	return fmt.Sprintf("%s%s::%s::%d", pullsKeyPrefix, hostname, repo, pull.Num), nil
+}
+
+func (r *RedisDB) historyKey(pull models.PullRequest) (string, error) {
+	key, err := r.pullKey(pull)
+	if err != nil {
+		return "", err
+	}
+	return historyKeyPrefix + strings.TrimPrefix(key, pullsKeyPrefix), nil
+}
+
+func (r *RedisDB) driftKey(status models.DriftStatus) (string, error) {
+	for _, s := range []string{status.VCSHostname, status.RepoFullName, status.RepoRelDir, status.Workspace, status.ProjectName} {
+		if strings.Contains(s, pullKeySeparator) {
+			return "", fmt.Errorf("%q contains illegal string %q", s, pullKeySeparator)
+		}
+	}
+	return fmt.Sprintf("%s%s::%s::%s::%s::%s", driftKeyPrefix, status.VCSHostname, status.RepoFullName, status.RepoRelDir, status.Workspace, status.ProjectName), nil
+}
+
+func (r *RedisDB) lockKey(p models.Project, workspace string) string {
+	return fmt.Sprintf("%s%s/%s/%s", locksKeyPrefix, p.RepoFullName, p.Path, workspace)
+}
+
+func (r *RedisDB) queueKey(p models.Project, workspace string) string {
+	return fmt.Sprintf("%s%s/%s/%s", queuesKeyPrefix, p.RepoFullName, p.Path, workspace)
+}
+
+func (r *RedisDB) getQueue(g getter, key string) ([]models.ProjectLock, error) {
+	serialized, err := g.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting queue data")
+	}
+	var queue []models.ProjectLock
+	if err := json.Unmarshal(serialized, &queue); err != nil {
+		return nil, errors.Wrapf(err, "deserializing queue at key %q", key)
+	}
+	return queue, nil
+}
+
+// updateQueue replaces the queue at key with the result of calling fn with
+// the current queue. Empty queues are deleted.
+func (r *RedisDB) updateQueue(key string, fn func([]models.ProjectLock) []models.ProjectLock) error {
+	return r.watch(key, func(tx *redis.Tx) error {
+		queue, err := r.getQueue(tx, key)
+		if err != nil {
+			return err
+		}
+		queue = fn(queue)
+		var serialized []byte
+		if len(queue) > 0 {
+			if serialized, err = json.Marshal(queue); err != nil {
+				return errors.Wrap(err, "serializing")
+			}
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			if len(queue) == 0 {
+				pipe.Del(key)
+			} else {
+				pipe.Set(key, serialized, 0)
+			}
+			return nil
+		})
+		return err
+	})
+}
+
+// removeQueuedPull returns queue without the entries for pull pullNum of
+// repoFullName.
+func removeQueuedPull(queue []models.ProjectLock, repoFullName string, pullNum int) []models.ProjectLock {
+	var kept []models.ProjectLock
+	for _, q := range queue {
+		if q.Project.RepoFullName != repoFullName || q.Pull.Num != pullNum {
+			kept = append(kept, q)
+		}
+	}
+	return kept
+}
+
+func (r *RedisDB) getLock(g getter, key string) (*models.ProjectLock, error) {
+	lockBytes, err := g.Get(key).Bytes()
+	// redis.Nil is returned if there was no data at that key.
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting lock data")
+	}
+
+	var lock models.ProjectLock
+	if err := json.Unmarshal(lockBytes, &lock); err != nil {
+		return nil, errors.Wrapf(err, "deserializing lock at key %q", key)
+	}
+
+	// need to set it to Local after deserialization due to https://github.com/golang/go/issues/19486
+	lock.Time = lock.Time.Local()
+	return &lock, nil
+}
+
+func (r *RedisDB) getPull(g getter, key string) (*models.PullStatus, error) {
+	serialized, err := g.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting pull data")
+	}
+
+	var p models.PullStatus
+	if err := json.Unmarshal(serialized, &p); err != nil {
+		return nil, errors.Wrapf(err, "deserializing pull at %q with contents %q", key, serialized)
+	}
+	return &p, nil
+}
+
+func (r *RedisDB) deserializeRuns(key string, vals []string) ([]models.ProjectRun, error) {
+	var runs []models.ProjectRun
+	for i, v := range vals {
+		var run models.ProjectRun
+		if err := json.Unmarshal([]byte(v), &run); err != nil {
+			return nil, errors.Wrapf(err, "deserializing run %d at key %q", i, key)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (r *RedisDB) writePull(tx *redis.Tx, key string, pull models.PullStatus) error {
+	serialized, err := json.Marshal(pull)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(key, serialized, 0)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisDB) projectResultToProject(p models.ProjectResult) models.ProjectStatus {
+	status := models.ProjectStatus{
+		Workspace:   p.Workspace,
+		RepoRelDir:  p.RepoRelDir,
+		ProjectName: p.ProjectName,
+		Status:      p.PlanStatus(),
+	}
+	if p.PlanSuccess != nil {
+		status.PlanMetadata = p.PlanSuccess.Metadata
+	}
+	return status
+}
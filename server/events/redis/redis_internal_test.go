@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that a lock is only deleted if it passes the check so that a lock
+// that was taken by another pull request since it was listed isn't deleted.
+func TestUnlockIf(t *testing.T) {
+	s, err := miniredis.Run()
+	Ok(t, err)
+	defer s.Close()
+	port, err := strconv.Atoi(s.Port())
+	Ok(t, err)
+	r, err := New(s.Host(), port, "", false, false, 0)
+	Ok(t, err)
+
+	project := models.NewProject("owner/repo", "path")
+	lock := models.ProjectLock{Project: project, Workspace: "default", Pull: models.PullRequest{Num: 2}}
+	_, _, err = r.TryLock(lock)
+	Ok(t, err)
+	heldByPull1 := func(l models.ProjectLock) bool { return l.Pull.Num == 1 }
+
+	deleted, err := r.unlockIf(r.lockKey(project, "default"), heldByPull1)
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp lock held by another pull not to be deleted")
+	locks, err := r.List()
+	Ok(t, err)
+	Equals(t, 1, len(locks))
+
+	deleted, err = r.unlockIf(r.lockKey(project, "default"), func(l models.ProjectLock) bool { return l.Pull.Num == 2 })
+	Ok(t, err)
+	Equals(t, 2, deleted.Pull.Num)
+	locks, err = r.List()
+	Ok(t, err)
+	Equals(t, 0, len(locks))
+}
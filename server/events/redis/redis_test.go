@@ -0,0 +1,1056 @@
+package redis_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/redis"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var project = models.NewProject("owner/repo", "parent/child")
+var workspace = "default"
+var pullNum = 1
+var lock = models.ProjectLock{
+	Pull: models.PullRequest{
+		Num: pullNum,
+	},
+	User: models.User{
+		Username: "lkysow",
+	},
+	Workspace: workspace,
+	Project:   project,
+	Time:      time.Now(),
+}
+
+func TestListNoLocks(t *testing.T) {
+	t.Log("listing locks when there are none should return an empty list")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 0, len(ls))
+}
+
+func TestListOneLock(t *testing.T) {
+	t.Log("listing locks when there is one should return it")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 1, len(ls))
+}
+
+func TestListMultipleLocks(t *testing.T) {
+	t.Log("listing locks when there are multiple should return them")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	// add multiple locks
+	repos := []string{
+		"owner/repo1",
+		"owner/repo2",
+		"owner/repo3",
+		"owner/repo4",
+	}
+
+	for _, r := range repos {
+		newLock := lock
+		newLock.Project = models.NewProject(r, "path")
+		_, _, err := b.TryLock(newLock)
+		Ok(t, err)
+	}
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 4, len(ls))
+	for _, r := range repos {
+		found := false
+		for _, l := range ls {
+			if l.Project.RepoFullName == r {
+				found = true
+			}
+		}
+		Assert(t, found, "expected %s in %v", r, ls)
+	}
+}
+
+func TestListAddRemove(t *testing.T) {
+	t.Log("listing after adding and removing should return none")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+	_, err = b.Unlock(project, workspace)
+	Ok(t, err)
+
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 0, len(ls))
+}
+
+func TestLockingNoLocks(t *testing.T) {
+	t.Log("with no locks yet, lock should succeed")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	acquired, currLock, err := b.TryLock(lock)
+	Ok(t, err)
+	Equals(t, true, acquired)
+	Equals(t, lock, currLock)
+}
+
+func TestLockingExistingLock(t *testing.T) {
+	t.Log("if there is an existing lock, lock should...")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+
+	t.Log("...succeed if the new project has a different path")
+	{
+		newLock := lock
+		newLock.Project = models.NewProject(project.RepoFullName, "different/path")
+		acquired, currLock, err := b.TryLock(newLock)
+		Ok(t, err)
+		Equals(t, true, acquired)
+		Equals(t, pullNum, currLock.Pull.Num)
+	}
+
+	t.Log("...succeed if the new project has a different workspace")
+	{
+		newLock := lock
+		newLock.Workspace = "different-workspace"
+		acquired, currLock, err := b.TryLock(newLock)
+		Ok(t, err)
+		Equals(t, true, acquired)
+		Equals(t, newLock, currLock)
+	}
+
+	t.Log("...succeed if the new project has a different repoName")
+	{
+		newLock := lock
+		newLock.Project = models.NewProject("different/repo", project.Path)
+		acquired, currLock, err := b.TryLock(newLock)
+		Ok(t, err)
+		Equals(t, true, acquired)
+		Equals(t, newLock, currLock)
+	}
+
+	t.Log("...not succeed if the new project only has a different pullNum")
+	{
+		newLock := lock
+		newLock.Pull.Num = lock.Pull.Num + 1
+		acquired, currLock, err := b.TryLock(newLock)
+		Ok(t, err)
+		Equals(t, false, acquired)
+		Equals(t, currLock.Pull.Num, pullNum)
+	}
+}
+
+func TestLockingConcurrent(t *testing.T) {
+	t.Log("if multiple instances try to lock at the same time only one should succeed")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	var acquiredCount int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			newLock := lock
+			newLock.Pull.Num = i
+			acquired, _, err := b.TryLock(newLock)
+			Ok(t, err)
+			if acquired {
+				atomic.AddInt32(&acquiredCount, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	Equals(t, int32(1), acquiredCount)
+}
+
+func TestLockingRefreshesExpiry(t *testing.T) {
+	t.Log("locking again from the pull request holding the lock should update its expiry")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	firstLock := lock
+	firstLock.ExpiresAt = time.Now().Add(time.Hour)
+	_, _, err := b.TryLock(firstLock)
+	Ok(t, err)
+
+	relock := firstLock
+	relock.Time = time.Now().Add(time.Minute)
+	relock.ExpiresAt = relock.Time.Add(time.Hour)
+	acquired, currLock, err := b.TryLock(relock)
+	Ok(t, err)
+	Equals(t, false, acquired)
+	Assert(t, currLock.ExpiresAt.Equal(relock.ExpiresAt), "exp expiry %s, got %s", relock.ExpiresAt, currLock.ExpiresAt)
+	Assert(t, currLock.Time.Equal(firstLock.Time), "exp lock time to be unchanged, got %s", currLock.Time)
+
+	t.Log("...but not from another pull request")
+	otherPull := relock
+	otherPull.Pull.Num = pullNum + 1
+	otherPull.ExpiresAt = relock.ExpiresAt.Add(time.Hour)
+	_, _, err = b.TryLock(otherPull)
+	Ok(t, err)
+	storedLock, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	Assert(t, storedLock.ExpiresAt.Equal(relock.ExpiresAt), "exp expiry %s, got %s", relock.ExpiresAt, storedLock.ExpiresAt)
+}
+
+func TestUnlockIfExpired(t *testing.T) {
+	t.Log("UnlockIfExpired should only delete the lock if it has expired")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	expiring := lock
+	expiring.ExpiresAt = time.Now().Add(time.Hour)
+	_, _, err := b.TryLock(expiring)
+	Ok(t, err)
+
+	deleted, err := b.UnlockIfExpired(project, workspace, time.Now())
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp lock that hasn't expired not to be deleted")
+	_, err = b.GetLock(project, workspace)
+	Ok(t, err)
+
+	deleted, err = b.UnlockIfExpired(project, workspace, expiring.ExpiresAt.Add(time.Second))
+	Ok(t, err)
+	Equals(t, pullNum, deleted.Pull.Num)
+	l, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	Assert(t, l == nil, "exp expired lock to be deleted")
+
+	t.Log("...and should succeed when there's no lock")
+	deleted, err = b.UnlockIfExpired(project, workspace, time.Now())
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp no lock to be deleted")
+}
+
+func TestUnlockingNoLocks(t *testing.T) {
+	t.Log("unlocking with no locks should succeed")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, err := b.Unlock(project, workspace)
+
+	Ok(t, err)
+}
+
+func TestUnlocking(t *testing.T) {
+	t.Log("unlocking with an existing lock should succeed")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+	_, err = b.Unlock(project, workspace)
+	Ok(t, err)
+
+	// should be no locks listed
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 0, len(ls))
+
+	// should be able to re-lock that repo with a new pull num
+	newLock := lock
+	newLock.Pull.Num = lock.Pull.Num + 1
+	acquired, currLock, err := b.TryLock(newLock)
+	Ok(t, err)
+	Equals(t, true, acquired)
+	Equals(t, newLock, currLock)
+}
+
+func TestUnlockingMultiple(t *testing.T) {
+	t.Log("unlocking and locking multiple locks should succeed")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+
+	new := lock
+	new.Project.RepoFullName = "new/repo"
+	_, _, err = b.TryLock(new)
+	Ok(t, err)
+
+	new2 := lock
+	new2.Project.Path = "new/path"
+	_, _, err = b.TryLock(new2)
+	Ok(t, err)
+
+	new3 := lock
+	new3.Workspace = "new-workspace"
+	_, _, err = b.TryLock(new3)
+	Ok(t, err)
+
+	// now try and unlock them
+	_, err = b.Unlock(new3.Project, new3.Workspace)
+	Ok(t, err)
+	_, err = b.Unlock(new2.Project, workspace)
+	Ok(t, err)
+	_, err = b.Unlock(new.Project, workspace)
+	Ok(t, err)
+	_, err = b.Unlock(project, workspace)
+	Ok(t, err)
+
+	// should be none left
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 0, len(ls))
+}
+
+func TestUnlockByPullNone(t *testing.T) {
+	t.Log("UnlockByPull should be successful when there are no locks")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	_, err := b.UnlockByPull("any/repo", 1)
+	Ok(t, err)
+}
+
+func TestUnlockByPullOne(t *testing.T) {
+	t.Log("with one lock, UnlockByPull should...")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+
+	t.Log("...delete nothing when its the same repo but a different pull")
+	{
+		_, err := b.UnlockByPull(project.RepoFullName, pullNum+1)
+		Ok(t, err)
+		ls, err := b.List()
+		Ok(t, err)
+		Equals(t, 1, len(ls))
+	}
+	t.Log("...delete nothing when its the same pull but a different repo")
+	{
+		_, err := b.UnlockByPull("different/repo", pullNum)
+		Ok(t, err)
+		ls, err := b.List()
+		Ok(t, err)
+		Equals(t, 1, len(ls))
+	}
+	t.Log("...delete the lock when its the same repo and pull")
+	{
+		_, err := b.UnlockByPull(project.RepoFullName, pullNum)
+		Ok(t, err)
+		ls, err := b.List()
+		Ok(t, err)
+		Equals(t, 0, len(ls))
+	}
+}
+
+func TestUnlockByPullAfterUnlock(t *testing.T) {
+	t.Log("after locking and unlocking, UnlockByPull should be successful")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+	_, err = b.Unlock(project, workspace)
+	Ok(t, err)
+
+	_, err = b.UnlockByPull(project.RepoFullName, pullNum)
+	Ok(t, err)
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 0, len(ls))
+}
+
+func TestUnlockByPullMatching(t *testing.T) {
+	t.Log("UnlockByPull should delete all locks in that repo and pull num")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+
+	// add additional locks with the same repo and pull num but different paths/workspaces
+	new := lock
+	new.Project.Path = "dif/path"
+	_, _, err = b.TryLock(new)
+	Ok(t, err)
+	new2 := lock
+	new2.Workspace = "new-workspace"
+	_, _, err = b.TryLock(new2)
+	Ok(t, err)
+
+	// there should now be 3
+	ls, err := b.List()
+	Ok(t, err)
+	Equals(t, 3, len(ls))
+
+	// should all be unlocked
+	_, err = b.UnlockByPull(project.RepoFullName, pullNum)
+	Ok(t, err)
+	ls, err = b.List()
+	Ok(t, err)
+	Equals(t, 0, len(ls))
+}
+
+func TestLockQueue(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	queued := func(nums ...int) {
+		t.Helper()
+		queue, err := b.GetQueue(project, workspace)
+		Ok(t, err)
+		var actNums []int
+		for _, q := range queue {
+			actNums = append(actNums, q.Pull.Num)
+		}
+		Equals(t, nums, actNums)
+	}
+	withPull := func(num int) models.ProjectLock {
+		l := lock
+		l.Pull.Num = num
+		return l
+	}
+
+	// Pulls are queued in order and keep their place if queued again.
+	for _, num := range []int{2, 3, 2} {
+		_, err := b.Enqueue(withPull(num))
+		Ok(t, err)
+	}
+	pos, err := b.Enqueue(withPull(4))
+	Ok(t, err)
+	Equals(t, 3, pos)
+	queued(2, 3, 4)
+
+	// Dequeue returns the first pull.
+	next, err := b.Dequeue(project, workspace)
+	Ok(t, err)
+	Equals(t, 2, next.Pull.Num)
+	queued(3, 4)
+
+	// Acquiring the lock removes the pull from the queue.
+	acquired, _, err := b.TryLock(withPull(4))
+	Ok(t, err)
+	Assert(t, acquired, "exp lock to be acquired")
+	queued(3)
+
+	// Unlocking by pull removes it from the queue.
+	_, err = b.UnlockByPull(project.RepoFullName, 3)
+	Ok(t, err)
+	queued()
+	next, err = b.Dequeue(project, workspace)
+	Ok(t, err)
+	Equals(t, (*models.ProjectLock)(nil), next)
+}
+
+func TestGetLockNotThere(t *testing.T) {
+	t.Log("getting a lock that doesn't exist should return a nil pointer")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	l, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	Equals(t, (*models.ProjectLock)(nil), l)
+}
+
+func TestGetLock(t *testing.T) {
+	t.Log("getting a lock should return the lock")
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+	_, _, err := b.TryLock(lock)
+	Ok(t, err)
+
+	l, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	// can't compare against time so doing each field
+	Equals(t, lock.Project, l.Project)
+	Equals(t, lock.Workspace, l.Workspace)
+	Equals(t, lock.Pull, l.Pull)
+	Equals(t, lock.User, l.User)
+}
+
+// Test we can create a status and then get it.
+func TestPullStatus_UpdateGet(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		URL:        "url",
+		HeadBranch: "head",
+		BaseBranch: "base",
+		Author:     "lkysow",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName:          "runatlantis/atlantis",
+			Owner:             "runatlantis",
+			Name:              "atlantis",
+			CloneURL:          "clone-url",
+			SanitizedCloneURL: "clone-url",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	status, err := b.UpdatePullWithResults(
+		pull,
+		[]models.ProjectResult{
+			{
+				Command:    models.PlanCommand,
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Failure:    "failure",
+			},
+		})
+	Ok(t, err)
+
+	maybeStatus, err := b.GetPullStatus(pull)
+	Ok(t, err)
+	Assert(t, maybeStatus != nil, "exp non-nil")
+	Equals(t, pull, maybeStatus.Pull)
+	Equals(t, []models.ProjectStatus{
+		{
+			Workspace:   "default",
+			RepoRelDir:  ".",
+			ProjectName: "",
+			Status:      models.ErroredPlanStatus,
+		},
+	}, status.Projects)
+}
+
+// Test we can create a status, delete it, and then we shouldn't be able to get
+// it.
+func TestPullStatus_UpdateDeleteGet(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		URL:        "url",
+		HeadBranch: "head",
+		BaseBranch: "base",
+		Author:     "lkysow",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName:          "runatlantis/atlantis",
+			Owner:             "runatlantis",
+			Name:              "atlantis",
+			CloneURL:          "clone-url",
+			SanitizedCloneURL: "clone-url",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	_, err := b.UpdatePullWithResults(
+		pull,
+		[]models.ProjectResult{
+			{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Failure:    "failure",
+			},
+		})
+	Ok(t, err)
+
+	err = b.DeletePullStatus(pull)
+	Ok(t, err)
+
+	maybeStatus, err := b.GetPullStatus(pull)
+	Ok(t, err)
+	Assert(t, maybeStatus == nil, "exp nil")
+}
+
+// Test we can create a status, delete a specific project's status within that
+// pull status, and when we get all the project statuses, that specific project
+// should not be there.
+func TestPullStatus_UpdateDeleteProject(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		URL:        "url",
+		HeadBranch: "head",
+		BaseBranch: "base",
+		Author:     "lkysow",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName:          "runatlantis/atlantis",
+			Owner:             "runatlantis",
+			Name:              "atlantis",
+			CloneURL:          "clone-url",
+			SanitizedCloneURL: "clone-url",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	_, err := b.UpdatePullWithResults(
+		pull,
+		[]models.ProjectResult{
+			{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Failure:    "failure",
+			},
+			{
+				RepoRelDir:   ".",
+				Workspace:    "staging",
+				ApplySuccess: "success!",
+			},
+		})
+	Ok(t, err)
+
+	err = b.DeleteProjectStatus(pull, "default", ".")
+	Ok(t, err)
+
+	status, err := b.GetPullStatus(pull)
+	Ok(t, err)
+	Assert(t, status != nil, "exp non-nil")
+	Equals(t, pull, status.Pull)
+	Equals(t, []models.ProjectStatus{
+		{
+			Workspace:   "staging",
+			RepoRelDir:  ".",
+			ProjectName: "",
+			Status:      models.AppliedPlanStatus,
+		},
+	}, status.Projects)
+}
+
+// Test that if we update an existing pull status and our new status is for a
+// different HeadSHA, that we just overwrite the old status.
+func TestPullStatus_UpdateNewCommit(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		URL:        "url",
+		HeadBranch: "head",
+		BaseBranch: "base",
+		Author:     "lkysow",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName:          "runatlantis/atlantis",
+			Owner:             "runatlantis",
+			Name:              "atlantis",
+			CloneURL:          "clone-url",
+			SanitizedCloneURL: "clone-url",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	_, err := b.UpdatePullWithResults(
+		pull,
+		[]models.ProjectResult{
+			{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Failure:    "failure",
+			},
+		})
+	Ok(t, err)
+
+	pull.HeadCommit = "newsha"
+	status, err := b.UpdatePullWithResults(pull,
+		[]models.ProjectResult{
+			{
+				RepoRelDir:   ".",
+				Workspace:    "staging",
+				ApplySuccess: "success!",
+			},
+		})
+
+	Ok(t, err)
+	Equals(t, 1, len(status.Projects))
+
+	maybeStatus, err := b.GetPullStatus(pull)
+	Ok(t, err)
+	Equals(t, pull, maybeStatus.Pull)
+	Equals(t, []models.ProjectStatus{
+		{
+			Workspace:   "staging",
+			RepoRelDir:  ".",
+			ProjectName: "",
+			Status:      models.AppliedPlanStatus,
+		},
+	}, maybeStatus.Projects)
+}
+
+// Test that if we update an existing pull status and our new status is for a
+// the same commit, that we merge the statuses.
+func TestPullStatus_UpdateMerge(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		URL:        "url",
+		HeadBranch: "head",
+		BaseBranch: "base",
+		Author:     "lkysow",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName:          "runatlantis/atlantis",
+			Owner:             "runatlantis",
+			Name:              "atlantis",
+			CloneURL:          "clone-url",
+			SanitizedCloneURL: "clone-url",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	_, err := b.UpdatePullWithResults(
+		pull,
+		[]models.ProjectResult{
+			{
+				Command:    models.PlanCommand,
+				RepoRelDir: "mergeme",
+				Workspace:  "default",
+				Failure:    "failure",
+			},
+			{
+				Command:     models.PlanCommand,
+				RepoRelDir:  "projectname",
+				Workspace:   "default",
+				ProjectName: "projectname",
+				Failure:     "failure",
+			},
+			{
+				Command:    models.PlanCommand,
+				RepoRelDir: "staythesame",
+				Workspace:  "default",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "tf out",
+					LockURL:         "lock-url",
+					RePlanCmd:       "plan command",
+					ApplyCmd:        "apply command",
+				},
+			},
+		})
+	Ok(t, err)
+
+	updateStatus, err := b.UpdatePullWithResults(pull,
+		[]models.ProjectResult{
+			{
+				Command:      models.ApplyCommand,
+				RepoRelDir:   "mergeme",
+				Workspace:    "default",
+				ApplySuccess: "applied!",
+			},
+			{
+				Command:     models.ApplyCommand,
+				RepoRelDir:  "projectname",
+				Workspace:   "default",
+				ProjectName: "projectname",
+				Error:       errors.New("apply error"),
+			},
+			{
+				Command:      models.ApplyCommand,
+				RepoRelDir:   "newresult",
+				Workspace:    "default",
+				ApplySuccess: "success!",
+			},
+		})
+	Ok(t, err)
+
+	getStatus, err := b.GetPullStatus(pull)
+	Ok(t, err)
+
+	// Test both the pull state returned from the update call *and* the get
+	// call.
+	for _, s := range []models.PullStatus{updateStatus, *getStatus} {
+		Equals(t, pull, s.Pull)
+		Equals(t, []models.ProjectStatus{
+			{
+				RepoRelDir: "mergeme",
+				Workspace:  "default",
+				Status:     models.AppliedPlanStatus,
+			},
+			{
+				RepoRelDir:  "projectname",
+				Workspace:   "default",
+				ProjectName: "projectname",
+				Status:      models.ErroredApplyStatus,
+			},
+			{
+				RepoRelDir: "staythesame",
+				Workspace:  "default",
+				Status:     models.PlannedPlanStatus,
+			},
+			{
+				RepoRelDir: "newresult",
+				Workspace:  "default",
+				Status:     models.AppliedPlanStatus,
+			},
+		}, updateStatus.Projects)
+	}
+}
+
+// newTestRedis starts an in-process Redis server and returns a RedisDB
+// connected to it.
+func TestDriftStatus_UpdateGet(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	statuses, err := b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	status := models.DriftStatus{
+		RepoFullName: "runatlantis/atlantis",
+		VCSHostname:  "github.com",
+		RepoRelDir:   ".",
+		Workspace:    "default",
+		Drifted:      true,
+		LastChecked:  time.Now(),
+	}
+	Ok(t, b.UpdateDriftStatus(status))
+	other := status
+	other.RepoRelDir = "staging"
+	Ok(t, b.UpdateDriftStatus(other))
+
+	// Updating the same project again should overwrite the old status.
+	status.Drifted = false
+	status.Error = "err"
+	Ok(t, b.UpdateDriftStatus(status))
+
+	statuses, err = b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	Equals(t, ".", statuses[0].RepoRelDir)
+	Equals(t, false, statuses[0].Drifted)
+	Equals(t, "err", statuses[0].Error)
+	Assert(t, status.LastChecked.Equal(statuses[0].LastChecked), "exp %s got %s", status.LastChecked, statuses[0].LastChecked)
+	Equals(t, "staging", statuses[1].RepoRelDir)
+	Equals(t, true, statuses[1].Drifted)
+}
+
+func TestDriftStatus_InvalidKey(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	err := b.UpdateDriftStatus(models.DriftStatus{
+		RepoFullName: "runatlantis/atlantis",
+		VCSHostname:  "github.com",
+		RepoRelDir:   "bad::dir",
+		Workspace:    "default",
+	})
+	ErrEquals(t, "\"bad::dir\" contains illegal string \"::\"", err)
+}
+
+func TestDriftRepos_UpdateGet(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	repos, err := b.GetDriftRepos()
+	Ok(t, err)
+	Equals(t, 0, len(repos))
+
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+	Ok(t, b.UpdateDriftRepo(repo))
+	// Updating the same repo again should overwrite it.
+	updated, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "new-token")
+	Ok(t, err)
+	Ok(t, b.UpdateDriftRepo(updated))
+
+	repos, err = b.GetDriftRepos()
+	Ok(t, err)
+	Equals(t, []models.Repo{updated}, repos)
+}
+
+func TestProjectRuns_AddGetDelete(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	pull := models.PullRequest{
+		Num: 1,
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost:  models.VCSHost{Hostname: "github.com"},
+		},
+	}
+	otherPull := pull
+	otherPull.Num = 10
+
+	runs, err := b.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 0, len(runs))
+
+	old := time.Now().Add(-2 * time.Hour)
+	now := time.Now()
+	Ok(t, b.AddProjectRuns(pull, []models.ProjectRun{
+		{
+			Command:    models.PlanCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Status:     models.PlannedPlanStatus,
+			Output:     "plan output",
+			User:       "lkysow",
+			HeadCommit: "sha",
+			StartedAt:  old,
+			FinishedAt: old,
+		},
+	}))
+	Ok(t, b.AddProjectRuns(pull, []models.ProjectRun{
+		{
+			Command:    models.ApplyCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Status:     models.AppliedPlanStatus,
+			Output:     "apply output",
+			StartedAt:  now,
+			FinishedAt: now,
+		},
+	}))
+	Ok(t, b.AddProjectRuns(otherPull, []models.ProjectRun{
+		{
+			Command:    models.PlanCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			StartedAt:  old,
+			FinishedAt: old,
+		},
+	}))
+
+	runs, err = b.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 2, len(runs))
+	Equals(t, models.PlanCommand, runs[0].Command)
+	Equals(t, "plan output", runs[0].Output)
+	Equals(t, "lkysow", runs[0].User)
+	Equals(t, "sha", runs[0].HeadCommit)
+	Assert(t, old.Equal(runs[0].StartedAt), "exp %s got %s", old, runs[0].StartedAt)
+	Equals(t, models.ApplyCommand, runs[1].Command)
+	Equals(t, models.AppliedPlanStatus, runs[1].Status)
+
+	deleted, err := b.DeleteProjectRunsBefore(time.Now().Add(-time.Hour))
+	Ok(t, err)
+	Equals(t, 2, deleted)
+	runs, err = b.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 1, len(runs))
+	Equals(t, "apply output", runs[0].Output)
+	runs, err = b.GetProjectRuns(otherPull)
+	Ok(t, err)
+	Equals(t, 0, len(runs))
+}
+
+func TestEvents(t *testing.T) {
+	b, cleanup := newTestRedis(t)
+	defer cleanup()
+
+	events, err := b.GetEvents()
+	Ok(t, err)
+	Equals(t, 0, len(events))
+
+	old := time.Now().Add(-2 * time.Hour)
+	first := models.QueuedEvent{
+		ID:         "X-Github-Delivery=1",
+		Status:     models.PendingEventStatus,
+		Autoplan:   true,
+		BaseRepo:   models.Repo{FullName: "runatlantis/atlantis"},
+		PullNum:    1,
+		ReceivedAt: old,
+	}
+	second := models.QueuedEvent{
+		ID:     "X-Github-Delivery=2",
+		Status: models.PendingEventStatus,
+		Comment: &models.QueuedComment{
+			Name:       models.ApplyCommand,
+			RepoRelDir: "dir",
+			Flags:      []string{"-target=resource"},
+		},
+		PullNum:    2,
+		ReceivedAt: time.Now(),
+	}
+	// The second event is added first to check that they're returned in the
+	// order they were received.
+	added, err := b.EnqueueEvent(second)
+	Ok(t, err)
+	Assert(t, added, "exp event to be added")
+	added, err = b.EnqueueEvent(first)
+	Ok(t, err)
+	Assert(t, added, "exp event to be added")
+
+	// Redeliveries are ignored.
+	redelivered := first
+	redelivered.PullNum = 10
+	added, err = b.EnqueueEvent(redelivered)
+	Ok(t, err)
+	Assert(t, !added, "exp redelivered event not to be added")
+
+	events, err = b.GetEvents()
+	Ok(t, err)
+	Equals(t, 2, len(events))
+	Equals(t, first.ID, events[0].ID)
+	Equals(t, 1, events[0].PullNum)
+	Assert(t, old.Equal(events[0].ReceivedAt), "exp %s got %s", old, events[0].ReceivedAt)
+	Equals(t, second.ID, events[1].ID)
+	Equals(t, *second.Comment, *events[1].Comment)
+
+	// Only pending events can be started.
+	started, err := b.StartEvent(first.ID)
+	Ok(t, err)
+	Equals(t, models.RunningEventStatus, started.Status)
+	Equals(t, 1, started.Attempts)
+	Assert(t, time.Since(started.HeartbeatAt) < time.Minute, "exp heartbeat to be set, got %s", started.HeartbeatAt)
+	started, err = b.StartEvent(first.ID)
+	Ok(t, err)
+	Assert(t, started == nil, "exp running event not to be started")
+	started, err = b.StartEvent("unknown")
+	Ok(t, err)
+	Assert(t, started == nil, "exp unknown event not to be started")
+
+	first.Status = models.ProcessedEventStatus
+	first.Attempts = 1
+	Ok(t, b.UpdateEvent(first))
+	second.Status = models.FailedEventStatus
+	second.Error = "err"
+	Ok(t, b.UpdateEvent(second))
+	events, err = b.GetEvents()
+	Ok(t, err)
+	Equals(t, models.ProcessedEventStatus, events[0].Status)
+	Equals(t, 1, events[0].Attempts)
+	Equals(t, models.FailedEventStatus, events[1].Status)
+	Equals(t, "err", events[1].Error)
+
+	// Only old processed events are deleted.
+	deleted, err := b.DeleteProcessedEventsBefore(time.Now().Add(-time.Hour))
+	Ok(t, err)
+	Equals(t, 1, deleted)
+	deleted, err = b.DeleteProcessedEventsBefore(time.Now())
+	Ok(t, err)
+	Equals(t, 0, deleted)
+
+	Ok(t, b.DeleteEvent(second.ID))
+	events, err = b.GetEvents()
+	Ok(t, err)
+	Equals(t, 0, len(events))
+}
+
+func newTestRedis(t *testing.T) (*redis.RedisDB, func()) {
+	s, err := miniredis.Run()
+	Ok(t, err)
+	port, err := strconv.Atoi(s.Port())
+	Ok(t, err)
+	r, err := redis.New(s.Host(), port, "", false, false, 0)
+	Ok(t, err)
+	return r, s.Close
+}
@@ -0,0 +1,58 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// ImportStepRunner runs `terraform import`.
+type ImportStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+// Run runs terraform import. The address and ID to import come from the
+// comment so they're in ctx.EscapedPositionalArgs.
+func (i *ImportStepRunner) Run(ctx models.ProjectCommandContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := i.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	importCmd := append(append([]string{"import", "-input=false", "-no-color"}, extraArgs...), ctx.EscapedCommentArgs...)
+	importCmd = append(importCmd, ctx.EscapedPositionalArgs...)
+	out, err := i.TerraformExecutor.RunCommandWithVersion(ctx.Context, ctx.Log, path, importCmd, workspaceEnvs(ctx, envs), tfVersion, ctx.Workspace, ctx.Job)
+	if err != nil {
+		return out, err
+	}
+
+	// The state has changed so any existing plan is now stale.
+	removeStalePlan(ctx, path)
+	return out, nil
+}
+
+// workspaceEnvs returns envs with TF_WORKSPACE set so that commands that
+// don't select the workspace themselves run against ctx.Workspace. The
+// workspace will have been created when the project was planned.
+func workspaceEnvs(ctx models.ProjectCommandContext, envs map[string]string) map[string]string {
+	if ctx.Workspace == defaultWorkspace {
+		return envs
+	}
+	withWorkspace := map[string]string{"TF_WORKSPACE": ctx.Workspace}
+	for k, v := range envs {
+		withWorkspace[k] = v
+	}
+	return withWorkspace
+}
+
+// removeStalePlan deletes the project's planfile, if any, since it was
+// generated against state that's since been modified.
+func removeStalePlan(ctx models.ProjectCommandContext, path string) {
+	planPath := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if err := os.Remove(planPath); err != nil && !os.IsNotExist(err) {
+		ctx.Log.Warn("failed to delete stale planfile: %s", err)
+	}
+}
@@ -0,0 +1,105 @@
+package runtime_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/runtime"
+	"github.com/runatlantis/atlantis/server/events/terraform/mocks"
+	matchers2 "github.com/runatlantis/atlantis/server/events/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestImportStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	planPath := filepath.Join(tmpDir, "default.tfplan")
+	Ok(t, ioutil.WriteFile(planPath, nil, 0600))
+
+	terraform := mocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.12.0")
+	s := runtime.ImportStepRunner{
+		TerraformExecutor: terraform,
+		DefaultTFVersion:  tfVersion,
+	}
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
+		ThenReturn("Import successful!", nil)
+
+	output, err := s.Run(models.ProjectCommandContext{
+		Log:                   logging.NewNoopLogger(),
+		Workspace:             "default",
+		RepoRelDir:            ".",
+		EscapedCommentArgs:    []string{"-lock=false"},
+		EscapedPositionalArgs: []string{"addr", "id"},
+	}, []string{"-var", "a=b"}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "Import successful!", output)
+
+	expArgs := []string{"import", "-input=false", "-no-color", "-var", "a=b", "-lock=false", "addr", "id"}
+	_, _, path, args, _, v, workspace, _ := terraform.VerifyWasCalledOnce().RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob()).GetCapturedArguments()
+	Equals(t, tmpDir, path)
+	Equals(t, expArgs, args)
+	Equals(t, tfVersion, v)
+	Equals(t, "default", workspace)
+
+	// The plan is stale so it should have been deleted.
+	_, err = os.Stat(planPath)
+	Assert(t, os.IsNotExist(err), "exp plan to be deleted")
+}
+
+func TestImportStepRunner_RunWorkspace(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := mocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.12.0")
+	s := runtime.ImportStepRunner{
+		TerraformExecutor: terraform,
+		DefaultTFVersion:  tfVersion,
+	}
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
+		ThenReturn("", nil)
+
+	_, err := s.Run(models.ProjectCommandContext{
+		Log:                   logging.NewNoopLogger(),
+		Workspace:             "staging",
+		RepoRelDir:            ".",
+		EscapedPositionalArgs: []string{"addr", "id"},
+	}, nil, "/path", map[string]string{"key": "val"})
+	Ok(t, err)
+
+	_, _, _, _, envs, _, _, _ := terraform.VerifyWasCalledOnce().RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob()).GetCapturedArguments()
+	Equals(t, map[string]string{"key": "val", "TF_WORKSPACE": "staging"}, envs)
+}
+
+// Should not delete the plan if the import fails.
+func TestImportStepRunner_RunError(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	planPath := filepath.Join(tmpDir, "default.tfplan")
+	Ok(t, ioutil.WriteFile(planPath, nil, 0600))
+
+	terraform := mocks.NewMockClient()
+	s := runtime.ImportStepRunner{
+		TerraformExecutor: terraform,
+	}
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
+		ThenReturn("output", errors.New("error"))
+
+	output, err := s.Run(models.ProjectCommandContext{
+		Log:       logging.NewNoopLogger(),
+		Workspace: "default",
+	}, nil, tmpDir, nil)
+	ErrEquals(t, "error", err)
+	Equals(t, "output", output)
+	_, err = os.Stat(planPath)
+	Ok(t, err)
+}
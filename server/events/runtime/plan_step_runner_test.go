@@ -1,6 +1,7 @@
 package runtime_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -19,6 +20,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/runtime"
 	"github.com/runatlantis/atlantis/server/events/terraform/mocks"
 	matchers2 "github.com/runatlantis/atlantis/server/events/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -36,9 +38,9 @@ func TestRun_NoWorkspaceIn08(t *testing.T) {
 		TerraformExecutor: terraform,
 	}
 
-	When(terraform.RunCommandWithVersion(matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())).
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
 		ThenReturn("output", nil)
-	output, err := s.Run(models.ProjectCommandContext{
+	ctx := models.ProjectCommandContext{
 		Log:                logger,
 		EscapedCommentArgs: []string{"comment", "args"},
 		Workspace:          workspace,
@@ -52,12 +54,14 @@ func TestRun_NoWorkspaceIn08(t *testing.T) {
 			Owner:    "owner",
 			Name:     "repo",
 		},
-	}, []string{"extra", "args"}, "/path", map[string]string(nil))
+	}
+	output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
 	Ok(t, err)
 
 	Equals(t, "output", output)
 	terraform.VerifyWasCalledOnce().RunCommandWithVersion(
-		logger,
+		ctx.Context,
+		ctx.Log,
 		"/path",
 		[]string{"plan",
 			"-input=false",
@@ -81,10 +85,11 @@ func TestRun_NoWorkspaceIn08(t *testing.T) {
 			"args"},
 		map[string]string(nil),
 		tfVersion,
-		workspace)
+		workspace,
+		ctx.Job)
 
 	// Verify that no env or workspace commands were run
-	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(logger,
+	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx.Context, ctx.Log,
 		"/path",
 		[]string{"env",
 			"select",
@@ -92,8 +97,9 @@ func TestRun_NoWorkspaceIn08(t *testing.T) {
 			"workspace"},
 		map[string]string(nil),
 		tfVersion,
-		workspace)
-	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(logger,
+		workspace,
+		ctx.Job)
+	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx.Context, ctx.Log,
 		"/path",
 		[]string{"workspace",
 			"select",
@@ -101,7 +107,8 @@ func TestRun_NoWorkspaceIn08(t *testing.T) {
 			"workspace"},
 		map[string]string(nil),
 		tfVersion,
-		workspace)
+		workspace,
+		ctx.Job)
 }
 
 func TestRun_ErrWorkspaceIn08(t *testing.T) {
@@ -118,7 +125,7 @@ func TestRun_ErrWorkspaceIn08(t *testing.T) {
 		DefaultTFVersion:  tfVersion,
 	}
 
-	When(terraform.RunCommandWithVersion(matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())).
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
 		ThenReturn("output", nil)
 	_, err := s.Run(models.ProjectCommandContext{
 		Log:        logger,
@@ -166,9 +173,9 @@ func TestRun_SwitchesWorkspace(t *testing.T) {
 				DefaultTFVersion:  tfVersion,
 			}
 
-			When(terraform.RunCommandWithVersion(matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString())).
+			When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
 				ThenReturn("output", nil)
-			output, err := s.Run(models.ProjectCommandContext{
+			ctx := models.ProjectCommandContext{
 				Log:                logger,
 				Workspace:          "workspace",
 				RepoRelDir:         ".",
@@ -182,12 +189,13 @@ func TestRun_SwitchesWorkspace(t *testing.T) {
 					Owner:    "owner",
 					Name:     "repo",
 				},
-			}, []string{"extra", "args"}, "/path", map[string]string(nil))
+			}
+			output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
 			Ok(t, err)
 
 			Equals(t, "output", output)
 			// Verify that env select was called as well as plan.
-			terraform.VerifyWasCalledOnce().RunCommandWithVersion(logger,
+			terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log,
 				"/path",
 				[]string{c.expWorkspaceCmd,
 					"select",
@@ -195,8 +203,9 @@ func TestRun_SwitchesWorkspace(t *testing.T) {
 					"workspace"},
 				map[string]string(nil),
 				tfVersion,
-				"workspace")
-			terraform.VerifyWasCalledOnce().RunCommandWithVersion(logger,
+				"workspace",
+				ctx.Job)
+			terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log,
 				"/path",
 				[]string{"plan",
 					"-input=false",
@@ -220,7 +229,8 @@ func TestRun_SwitchesWorkspace(t *testing.T) {
 					"args"},
 				map[string]string(nil),
 				tfVersion,
-				"workspace")
+				"workspace",
+				ctx.Job)
 		})
 	}
 }
@@ -261,12 +271,28 @@ func TestRun_CreatesWorkspace(t *testing.T) {
 				DefaultTFVersion:  tfVersion,
 			}
 
+			ctx := models.ProjectCommandContext{
+				Log:                logger,
+				Workspace:          "workspace",
+				RepoRelDir:         ".",
+				User:               models.User{Username: "username"},
+				EscapedCommentArgs: []string{"comment", "args"},
+				Pull: models.PullRequest{
+					Num: 2,
+				},
+				BaseRepo: models.Repo{
+					FullName: "owner/repo",
+					Owner:    "owner",
+					Name:     "repo",
+				},
+			}
+
 			// Ensure that we actually try to switch workspaces by making the
 			// output of `workspace show` to be a different name.
-			When(terraform.RunCommandWithVersion(logger, "/path", []string{"workspace", "show"}, map[string]string(nil), tfVersion, "workspace")).ThenReturn("diffworkspace\n", nil)
+			When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", []string{"workspace", "show"}, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("diffworkspace\n", nil)
 
 			expWorkspaceArgs := []string{c.expWorkspaceCommand, "select", "-no-color", "workspace"}
-			When(terraform.RunCommandWithVersion(logger, "/path", expWorkspaceArgs, map[string]string(nil), tfVersion, "workspace")).ThenReturn("", errors.New("workspace does not exist"))
+			When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expWorkspaceArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("", errors.New("workspace does not exist"))
 
 			expPlanArgs := []string{"plan",
 				"-input=false",
@@ -288,29 +314,15 @@ func TestRun_CreatesWorkspace(t *testing.T) {
 				"args",
 				"comment",
 				"args"}
-			When(terraform.RunCommandWithVersion(logger, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace")).ThenReturn("output", nil)
+			When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("output", nil)
 
-			output, err := s.Run(models.ProjectCommandContext{
-				Log:                logger,
-				Workspace:          "workspace",
-				RepoRelDir:         ".",
-				User:               models.User{Username: "username"},
-				EscapedCommentArgs: []string{"comment", "args"},
-				Pull: models.PullRequest{
-					Num: 2,
-				},
-				BaseRepo: models.Repo{
-					FullName: "owner/repo",
-					Owner:    "owner",
-					Name:     "repo",
-				},
-			}, []string{"extra", "args"}, "/path", map[string]string(nil))
+			output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
 			Ok(t, err)
 
 			Equals(t, "output", output)
 			// Verify that env select was called as well as plan.
-			terraform.VerifyWasCalledOnce().RunCommandWithVersion(logger, "/path", expWorkspaceArgs, map[string]string(nil), tfVersion, "workspace")
-			terraform.VerifyWasCalledOnce().RunCommandWithVersion(logger, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace")
+			terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expWorkspaceArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)
+			terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)
 		})
 	}
 }
@@ -326,7 +338,22 @@ func TestRun_NoWorkspaceSwitchIfNotNecessary(t *testing.T) {
 		TerraformExecutor: terraform,
 		DefaultTFVersion:  tfVersion,
 	}
-	When(terraform.RunCommandWithVersion(logger, "/path", []string{"workspace", "show"}, map[string]string(nil), tfVersion, "workspace")).ThenReturn("workspace\n", nil)
+	ctx := models.ProjectCommandContext{
+		Log:                logger,
+		Workspace:          "workspace",
+		RepoRelDir:         ".",
+		User:               models.User{Username: "username"},
+		EscapedCommentArgs: []string{"comment", "args"},
+		Pull: models.PullRequest{
+			Num: 2,
+		},
+		BaseRepo: models.Repo{
+			FullName: "owner/repo",
+			Owner:    "owner",
+			Name:     "repo",
+		},
+	}
+	When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", []string{"workspace", "show"}, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("workspace\n", nil)
 
 	expPlanArgs := []string{"plan",
 		"-input=false",
@@ -348,30 +375,16 @@ func TestRun_NoWorkspaceSwitchIfNotNecessary(t *testing.T) {
 		"args",
 		"comment",
 		"args"}
-	When(terraform.RunCommandWithVersion(logger, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace")).ThenReturn("output", nil)
+	When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("output", nil)
 
-	output, err := s.Run(models.ProjectCommandContext{
-		Log:                logger,
-		Workspace:          "workspace",
-		RepoRelDir:         ".",
-		User:               models.User{Username: "username"},
-		EscapedCommentArgs: []string{"comment", "args"},
-		Pull: models.PullRequest{
-			Num: 2,
-		},
-		BaseRepo: models.Repo{
-			FullName: "owner/repo",
-			Owner:    "owner",
-			Name:     "repo",
-		},
-	}, []string{"extra", "args"}, "/path", map[string]string(nil))
+	output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
 	Ok(t, err)
 
 	Equals(t, "output", output)
-	terraform.VerifyWasCalledOnce().RunCommandWithVersion(logger, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace")
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expPlanArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)
 
 	// Verify that workspace select was never called.
-	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(logger, "/path", []string{"workspace", "select", "-no-color", "workspace"}, map[string]string(nil), tfVersion, "workspace")
+	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx.Context, ctx.Log, "/path", []string{"workspace", "select", "-no-color", "workspace"}, map[string]string(nil), tfVersion, "workspace", ctx.Job)
 }
 
 func TestRun_AddsEnvVarFile(t *testing.T) {
@@ -419,9 +432,7 @@ func TestRun_AddsEnvVarFile(t *testing.T) {
 		"-var-file",
 		envVarsFile,
 	}
-	When(terraform.RunCommandWithVersion(logger, tmpDir, expPlanArgs, map[string]string(nil), tfVersion, "workspace")).ThenReturn("output", nil)
-
-	output, err := s.Run(models.ProjectCommandContext{
+	ctx := models.ProjectCommandContext{
 		Log:                logger,
 		Workspace:          "workspace",
 		RepoRelDir:         ".",
@@ -435,12 +446,15 @@ func TestRun_AddsEnvVarFile(t *testing.T) {
 			Owner:    "owner",
 			Name:     "repo",
 		},
-	}, []string{"extra", "args"}, tmpDir, map[string]string(nil))
+	}
+	When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, tmpDir, expPlanArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("output", nil)
+
+	output, err := s.Run(ctx, []string{"extra", "args"}, tmpDir, map[string]string(nil))
 	Ok(t, err)
 
 	// Verify that env select was never called since we're in version >= 0.10
-	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(logger, tmpDir, []string{"env", "select", "-no-color", "workspace"}, map[string]string(nil), tfVersion, "workspace")
-	terraform.VerifyWasCalledOnce().RunCommandWithVersion(logger, tmpDir, expPlanArgs, map[string]string(nil), tfVersion, "workspace")
+	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(ctx.Context, ctx.Log, tmpDir, []string{"env", "select", "-no-color", "workspace"}, map[string]string(nil), tfVersion, "workspace", ctx.Job)
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log, tmpDir, expPlanArgs, map[string]string(nil), tfVersion, "workspace", ctx.Job)
 	Equals(t, "output", output)
 }
 
@@ -455,7 +469,23 @@ func TestRun_UsesDiffPathForProject(t *testing.T) {
 		TerraformExecutor: terraform,
 		DefaultTFVersion:  tfVersion,
 	}
-	When(terraform.RunCommandWithVersion(logger, "/path", []string{"workspace", "show"}, map[string]string(nil), tfVersion, "workspace")).ThenReturn("workspace\n", nil)
+	ctx := models.ProjectCommandContext{
+		Log:                logger,
+		Workspace:          "default",
+		RepoRelDir:         ".",
+		User:               models.User{Username: "username"},
+		EscapedCommentArgs: []string{"comment", "args"},
+		ProjectName:        "projectname",
+		Pull: models.PullRequest{
+			Num: 2,
+		},
+		BaseRepo: models.Repo{
+			FullName: "owner/repo",
+			Owner:    "owner",
+			Name:     "repo",
+		},
+	}
+	When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", []string{"workspace", "show"}, map[string]string(nil), tfVersion, "workspace", ctx.Job)).ThenReturn("workspace\n", nil)
 
 	expPlanArgs := []string{"plan",
 		"-input=false",
@@ -478,24 +508,9 @@ func TestRun_UsesDiffPathForProject(t *testing.T) {
 		"comment",
 		"args",
 	}
-	When(terraform.RunCommandWithVersion(logger, "/path", expPlanArgs, map[string]string(nil), tfVersion, "default")).ThenReturn("output", nil)
+	When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expPlanArgs, map[string]string(nil), tfVersion, "default", ctx.Job)).ThenReturn("output", nil)
 
-	output, err := s.Run(models.ProjectCommandContext{
-		Log:                logger,
-		Workspace:          "default",
-		RepoRelDir:         ".",
-		User:               models.User{Username: "username"},
-		EscapedCommentArgs: []string{"comment", "args"},
-		ProjectName:        "projectname",
-		Pull: models.PullRequest{
-			Num: 2,
-		},
-		BaseRepo: models.Repo{
-			FullName: "owner/repo",
-			Owner:    "owner",
-			Name:     "repo",
-		},
-	}, []string{"extra", "args"}, "/path", map[string]string(nil))
+	output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
 	Ok(t, err)
 	Equals(t, "output", output)
 }
@@ -536,16 +551,18 @@ Terraform will perform the following actions:
 		DefaultTFVersion:  tfVersion,
 	}
 	When(terraform.RunCommandWithVersion(
+		matchers2.AnyContextContext(),
 		matchers.AnyPtrToLoggingSimpleLogger(),
 		AnyString(),
 		AnyStringSlice(),
 		matchers2.AnyMapOfStringToString(),
 		matchers2.AnyPtrToGoVersionVersion(),
-		AnyString())).
+		AnyString(),
+		matchers2.AnyPtrToJobsJob())).
 		Then(func(params []Param) ReturnValues {
 			// This code allows us to return different values depending on the
 			// tf command being run while still using the wildcard matchers above.
-			tfArgs := params[2].([]string)
+			tfArgs := params[3].([]string)
 			if stringSliceEquals(tfArgs, []string{"workspace", "show"}) {
 				return []ReturnValue{"default", nil}
 			} else if tfArgs[0] == "plan" {
@@ -590,16 +607,18 @@ func TestRun_OutputOnErr(t *testing.T) {
 	expOutput := "expected output"
 	expErrMsg := "error!"
 	When(terraform.RunCommandWithVersion(
+		matchers2.AnyContextContext(),
 		matchers.AnyPtrToLoggingSimpleLogger(),
 		AnyString(),
 		AnyStringSlice(),
 		matchers2.AnyMapOfStringToString(),
 		matchers2.AnyPtrToGoVersionVersion(),
-		AnyString())).
+		AnyString(),
+		matchers2.AnyPtrToJobsJob())).
 		Then(func(params []Param) ReturnValues {
 			// This code allows us to return different values depending on the
 			// tf command being run while still using the wildcard matchers above.
-			tfArgs := params[2].([]string)
+			tfArgs := params[3].([]string)
 			if stringSliceEquals(tfArgs, []string{"workspace", "show"}) {
 				return []ReturnValue{"default\n", nil}
 			} else if tfArgs[0] == "plan" {
@@ -627,14 +646,16 @@ func TestRun_NoOptionalVarsIn012(t *testing.T) {
 	}
 
 	When(terraform.RunCommandWithVersion(
+		matchers2.AnyContextContext(),
 		matchers.AnyPtrToLoggingSimpleLogger(),
 		AnyString(),
 		AnyStringSlice(),
 		matchers2.AnyMapOfStringToString(),
 		matchers2.AnyPtrToGoVersionVersion(),
-		AnyString())).ThenReturn("output", nil)
+		AnyString(),
+		matchers2.AnyPtrToJobsJob())).ThenReturn("output", nil)
 
-	output, err := s.Run(models.ProjectCommandContext{
+	ctx := models.ProjectCommandContext{
 		Workspace:          "default",
 		RepoRelDir:         ".",
 		User:               models.User{Username: "username"},
@@ -647,7 +668,8 @@ func TestRun_NoOptionalVarsIn012(t *testing.T) {
 			Owner:    "owner",
 			Name:     "repo",
 		},
-	}, []string{"extra", "args"}, "/path", map[string]string(nil))
+	}
+	output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
 	Ok(t, err)
 	Equals(t, "output", output)
 
@@ -662,7 +684,7 @@ func TestRun_NoOptionalVarsIn012(t *testing.T) {
 		"comment",
 		"args",
 	}
-	terraform.VerifyWasCalledOnce().RunCommandWithVersion(nil, "/path", expPlanArgs, map[string]string(nil), tfVersion, "default")
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log, "/path", expPlanArgs, map[string]string(nil), tfVersion, "default", ctx.Job)
 }
 
 // Test plans if using remote ops.
@@ -699,14 +721,31 @@ locally at this time.
 			absProjectPath, cleanup := TempDir(t)
 			defer cleanup()
 
+			ctx := models.ProjectCommandContext{
+				Workspace:          "default",
+				RepoRelDir:         ".",
+				User:               models.User{Username: "username"},
+				EscapedCommentArgs: []string{"comment", "args"},
+				Pull: models.PullRequest{
+					Num: 2,
+				},
+				BaseRepo: models.Repo{
+					FullName: "owner/repo",
+					Owner:    "owner",
+					Name:     "repo",
+				},
+			}
+
 			// First, terraform workspace gets run.
 			When(terraform.RunCommandWithVersion(
-				nil,
+				ctx.Context,
+				ctx.Log,
 				absProjectPath,
 				[]string{"workspace", "show"},
 				map[string]string(nil),
 				tfVersion,
-				"default")).ThenReturn("default\n", nil)
+				"default",
+				ctx.Job)).ThenReturn("default\n", nil)
 
 			// Then the first call to terraform plan should return the remote ops error.
 			expPlanArgs := []string{"plan",
@@ -734,24 +773,10 @@ locally at this time.
 			planErr := errors.New("exit status 1: err")
 			planOutput := "\n" + remoteOpsErr
 			asyncTf.LinesToSend = remotePlanOutput
-			When(terraform.RunCommandWithVersion(nil, absProjectPath, expPlanArgs, map[string]string(nil), tfVersion, "default")).
+			When(terraform.RunCommandWithVersion(ctx.Context, ctx.Log, absProjectPath, expPlanArgs, map[string]string(nil), tfVersion, "default", ctx.Job)).
 				ThenReturn(planOutput, planErr)
 
 			// Now that mocking is set up, we're ready to run the plan.
-			ctx := models.ProjectCommandContext{
-				Workspace:          "default",
-				RepoRelDir:         ".",
-				User:               models.User{Username: "username"},
-				EscapedCommentArgs: []string{"comment", "args"},
-				Pull: models.PullRequest{
-					Num: 2,
-				},
-				BaseRepo: models.Repo{
-					FullName: "owner/repo",
-					Owner:    "owner",
-					Name:     "repo",
-				},
-			}
 			output, err := s.Run(ctx, []string{"extra", "args"}, absProjectPath, map[string]string(nil))
 			Ok(t, err)
 			Equals(t, `
@@ -800,7 +825,7 @@ type remotePlanMock struct {
 	CalledArgs []string
 }
 
-func (r *remotePlanMock) RunCommandAsync(log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string) (chan<- string, <-chan terraform.Line) {
+func (r *remotePlanMock) RunCommandAsync(ctx context.Context, log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string, job *jobs.Job) (chan<- string, <-chan terraform.Line) {
 	r.CalledArgs = args
 	in := make(chan string)
 	out := make(chan terraform.Line)
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// DefaultConftestBinary is the conftest binary we run if none is configured.
+// It's looked up in $PATH.
+const DefaultConftestBinary = "conftest"
+
+// PolicyCheckStepRunner runs conftest against the output of the show step
+// for each of the project's policy sets.
+type PolicyCheckStepRunner struct {
+	// ConftestBinary is the path to the conftest binary. If it isn't an
+	// absolute path, it will be looked up in $PATH.
+	ConftestBinary string
+}
+
+func (p *PolicyCheckStepRunner) Run(ctx models.ProjectCommandContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	if len(ctx.PolicySets) == 0 {
+		return "", nil
+	}
+
+	showFile := filepath.Join(path, GetShowResultFilename(ctx.Workspace, ctx.ProjectName))
+	if _, err := os.Stat(showFile); err != nil {
+		return "", fmt.Errorf("unable to read terraform show output at %q, the show step must be run before the policy_check step: %s", showFile, err)
+	}
+
+	binary := p.ConftestBinary
+	if binary == "" {
+		binary = DefaultConftestBinary
+	}
+
+	finalEnvVars := os.Environ()
+	for key, val := range envs {
+		finalEnvVars = append(finalEnvVars, fmt.Sprintf("%s=%s", key, val))
+	}
+
+	// We run each policy set separately so that the output tells users which
+	// set failed.
+	var outputs []string
+	var failedSets []string
+	for _, set := range ctx.PolicySets {
+		args := append([]string{"test", "-p", set.Path}, extraArgs...)
+		args = append(args, showFile, "--no-color")
+		cmd := exec.Command(binary, args...) // #nosec
+		cmd.Dir = path
+		cmd.Env = finalEnvVars
+		out, err := cmd.CombinedOutput()
+		outputs = append(outputs, fmt.Sprintf("policy set: %s\n%s", set.Name, strings.TrimRight(string(out), "\n")))
+		if err != nil {
+			ctx.Log.Debug("policy set %q failed: %s", set.Name, err)
+			failedSets = append(failedSets, set.Name)
+		}
+	}
+
+	output := strings.Join(outputs, "\n\n")
+	if len(failedSets) > 0 {
+		return output, fmt.Errorf("policy set(s) failed: %s", strings.Join(failedSets, ", "))
+	}
+	ctx.Log.Info("all %d policy set(s) passed", len(ctx.PolicySets))
+	return output, nil
+}
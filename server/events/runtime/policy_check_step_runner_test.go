@@ -0,0 +1,98 @@
+package runtime_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/runtime"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeConftest is a script that pretends to be conftest. It prints its
+// arguments and fails if the policy path contains "fail".
+var fakeConftest = `#!/bin/sh
+echo "$@"
+case "$3" in
+  *fail*) exit 1 ;;
+esac
+`
+
+func TestPolicyCheckStepRunner_Run(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	conftest := filepath.Join(tmpDir, "conftest")
+	Ok(t, ioutil.WriteFile(conftest, []byte(fakeConftest), 0700)) // nolint: gosec
+	showFile := filepath.Join(tmpDir, "default.json")
+	Ok(t, ioutil.WriteFile(showFile, []byte("{}"), 0600))
+
+	cases := []struct {
+		description string
+		policySets  []valid.PolicySet
+		extraArgs   []string
+		expOut      string
+		expErr      string
+	}{
+		{
+			description: "no policy sets",
+			expOut:      "",
+		},
+		{
+			description: "passing policy sets",
+			policySets: []valid.PolicySet{
+				{Name: "first", Path: "/policies/first"},
+				{Name: "second", Path: "/policies/second"},
+			},
+			extraArgs: []string{"--all-namespaces"},
+			expOut: "policy set: first\ntest -p /policies/first --all-namespaces " + showFile + " --no-color\n\n" +
+				"policy set: second\ntest -p /policies/second --all-namespaces " + showFile + " --no-color",
+		},
+		{
+			description: "failing policy set",
+			policySets: []valid.PolicySet{
+				{Name: "first", Path: "/policies/fail"},
+				{Name: "second", Path: "/policies/second"},
+			},
+			expOut: "policy set: first\ntest -p /policies/fail " + showFile + " --no-color\n\n" +
+				"policy set: second\ntest -p /policies/second " + showFile + " --no-color",
+			expErr: "policy set(s) failed: first",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := runtime.PolicyCheckStepRunner{
+				ConftestBinary: conftest,
+			}
+			out, err := r.Run(models.ProjectCommandContext{
+				Log:        logging.NewNoopLogger(),
+				Workspace:  "default",
+				PolicySets: c.policySets,
+			}, c.extraArgs, tmpDir, map[string]string(nil))
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+			} else {
+				Ok(t, err)
+			}
+			Equals(t, c.expOut, out)
+		})
+	}
+}
+
+func TestPolicyCheckStepRunner_RunNoShowOutput(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	r := runtime.PolicyCheckStepRunner{}
+	_, err := r.Run(models.ProjectCommandContext{
+		Log:       logging.NewNoopLogger(),
+		Workspace: "default",
+		PolicySets: []valid.PolicySet{
+			{Name: "first", Path: "/policies/first"},
+		},
+	}, nil, tmpDir, map[string]string(nil))
+	ErrContains(t, "the show step must be run before the policy_check step", err)
+}
@@ -1,7 +1,9 @@
 package runtime
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/terraform"
+	"github.com/runatlantis/atlantis/server/jobs"
 )
 
 // RunStepRunner runs custom commands.
@@ -48,6 +52,7 @@ func (r *RunStepRunner) Run(ctx models.ProjectCommandContext, command string, pa
 		"HEAD_REPO_OWNER":            ctx.HeadRepo.Owner,
 		"PATH":                       fmt.Sprintf("%s:%s", os.Getenv("PATH"), r.TerraformBinDir),
 		"PLANFILE":                   filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName)),
+		"POSITIONAL_ARGS":            strings.Join(ctx.EscapedPositionalArgs, ","),
 		"PROJECT_NAME":               ctx.ProjectName,
 		"PULL_AUTHOR":                ctx.Pull.Author,
 		"PULL_NUM":                   fmt.Sprintf("%d", ctx.Pull.Num),
@@ -63,13 +68,21 @@ func (r *RunStepRunner) Run(ctx models.ProjectCommandContext, command string, pa
 		finalEnvVars = append(finalEnvVars, fmt.Sprintf("%s=%s", key, val))
 	}
 	cmd.Env = finalEnvVars
-	out, err := cmd.CombinedOutput()
+
+	// Like CombinedOutput but we also stream each line to the job.
+	var out bytes.Buffer
+	jobWriter := jobs.NewWriter(ctx.Job)
+	output := io.MultiWriter(&out, jobWriter)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err = terraform.RunCancellable(ctx.Context, cmd)
+	jobWriter.Flush()
 
 	if err != nil {
-		err = fmt.Errorf("%s: running %q in %q: \n%s", err, command, path, out)
+		err = fmt.Errorf("%s: running %q in %q: \n%s", err, command, path, out.String())
 		ctx.Log.Debug("error: %s", err)
 		return "", err
 	}
 	ctx.Log.Info("successfully ran %q in %q", command, path)
-	return string(out), nil
+	return out.String(), nil
 }
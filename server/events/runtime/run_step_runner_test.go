@@ -79,6 +79,10 @@ func TestRunStepRunner_Run(t *testing.T) {
 			Command: "echo args=$COMMENT_ARGS",
 			ExpOut:  "args=-target=resource1,-target=resource2\n",
 		},
+		{
+			Command: "echo positional_args=$POSITIONAL_ARGS",
+			ExpOut:  "positional_args=aws_instance.a,i-123\n",
+		},
 	}
 
 	for _, c := range cases {
@@ -130,12 +134,13 @@ func TestRunStepRunner_Run(t *testing.T) {
 				User: models.User{
 					Username: "acme-user",
 				},
-				Log:                logger,
-				Workspace:          "myworkspace",
-				RepoRelDir:         "mydir",
-				TerraformVersion:   projVersion,
-				ProjectName:        c.ProjectName,
-				EscapedCommentArgs: []string{"-target=resource1", "-target=resource2"},
+				Log:                   logger,
+				Workspace:             "myworkspace",
+				RepoRelDir:            "mydir",
+				TerraformVersion:      projVersion,
+				ProjectName:           c.ProjectName,
+				EscapedCommentArgs:    []string{"-target=resource1", "-target=resource2"},
+				EscapedPositionalArgs: []string{"aws_instance.a", "i-123"},
 			}
 			out, err := r.Run(ctx, c.Command, tmpDir, map[string]string{"test": "var"})
 			if c.ExpErr != "" {
@@ -3,6 +3,7 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/terraform"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -24,7 +26,7 @@ const (
 // TerraformExec brings the interface from TerraformClient into this package
 // without causing circular imports.
 type TerraformExec interface {
-	RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string) (string, error)
+	RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string, job *jobs.Job) (string, error)
 	EnsureVersion(log *logging.SimpleLogger, v *version.Version) error
 }
 
@@ -39,7 +41,7 @@ type AsyncTFExec interface {
 	// Callers can use the input channel to pass stdin input to the command.
 	// If any error is passed on the out channel, there will be no
 	// further output (so callers are free to exit).
-	RunCommandAsync(log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string) (chan<- string, <-chan terraform.Line)
+	RunCommandAsync(ctx context.Context, log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string, job *jobs.Job) (chan<- string, <-chan terraform.Line)
 }
 
 // StatusUpdater brings the interface from CommitStatusUpdater into this package
@@ -67,6 +69,20 @@ func GetPlanFilename(workspace string, projName string) string {
 	return fmt.Sprintf("%s-%s.tfplan", projName, workspace)
 }
 
+// GetShowResultFilename returns the filename (not the path) of the JSON
+// output of `terraform show` for the plan generated for workspace and
+// projName.
+func GetShowResultFilename(workspace string, projName string) string {
+	return strings.TrimSuffix(GetPlanFilename(workspace, projName), ".tfplan") + ".json"
+}
+
+// GetPlanMetadataFilename returns the filename (not the path) of the file
+// that describes what the plan for workspace and projName was generated
+// against.
+func GetPlanMetadataFilename(workspace string, projName string) string {
+	return GetPlanFilename(workspace, projName) + ".metadata.json"
+}
+
 // ProjectNameFromPlanfile returns the project name that a planfile with name
 // filename is for. If filename is for a project without a name then it will
 // return an empty string. workspace is the workspace this project is in.
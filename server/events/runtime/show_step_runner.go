@@ -0,0 +1,41 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// ShowStepRunner runs `terraform show -json` on the planfile and saves the
+// output so that it can be checked against policies.
+type ShowStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+func (s *ShowStepRunner) Run(ctx models.ProjectCommandContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := s.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	planFile := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	showFile := filepath.Join(path, GetShowResultFilename(ctx.Workspace, ctx.ProjectName))
+
+	output, err := s.TerraformExecutor.RunCommandWithVersion(ctx.Context, ctx.Log, path, []string{"show", "-no-color", "-json", filepath.Clean(planFile)}, envs, tfVersion, ctx.Workspace, ctx.Job)
+	if err != nil {
+		return output, errors.Wrap(err, "running terraform show")
+	}
+
+	if err := ioutil.WriteFile(showFile, []byte(output), os.FileMode(0600)); err != nil {
+		return "", errors.Wrap(err, "writing terraform show result")
+	}
+
+	// The JSON output is only used by the policy_check step so we don't
+	// return it, otherwise it would be commented on the pull request.
+	return "", nil
+}
@@ -0,0 +1,70 @@
+package runtime_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/runtime"
+	"github.com/runatlantis/atlantis/server/events/terraform/mocks"
+	matchers2 "github.com/runatlantis/atlantis/server/events/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestShowStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	terraform := mocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.12.0")
+	s := runtime.ShowStepRunner{
+		TerraformExecutor: terraform,
+		DefaultTFVersion:  tfVersion,
+	}
+	ctx := models.ProjectCommandContext{
+		Log:         logging.NewNoopLogger(),
+		Workspace:   "workspace",
+		ProjectName: "my/project",
+	}
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
+		ThenReturn(`{"format_version":"0.1"}`, nil)
+
+	output, err := s.Run(ctx, nil, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	// The JSON shouldn't be commented back to the pull request.
+	Equals(t, "", output)
+
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx.Context, ctx.Log, tmpDir, []string{"show", "-no-color", "-json", filepath.Join(tmpDir, "my::project-workspace.tfplan")}, map[string]string(nil), tfVersion, "workspace", ctx.Job)
+	showOutput, err := ioutil.ReadFile(filepath.Join(tmpDir, "my::project-workspace.json"))
+	Ok(t, err)
+	Equals(t, `{"format_version":"0.1"}`, string(showOutput))
+}
+
+func TestShowStepRunner_RunError(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	terraform := mocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.12.0")
+	s := runtime.ShowStepRunner{
+		TerraformExecutor: terraform,
+		DefaultTFVersion:  tfVersion,
+	}
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
+		ThenReturn("no plan file", errors.New("exit status 1"))
+
+	output, err := s.Run(models.ProjectCommandContext{
+		Log:       logging.NewNoopLogger(),
+		Workspace: "default",
+	}, nil, tmpDir, map[string]string(nil))
+	ErrEquals(t, "running terraform show: exit status 1", err)
+	Equals(t, "no plan file", output)
+}
@@ -0,0 +1,32 @@
+package runtime
+
+import (
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// StateRmStepRunner runs `terraform state rm`.
+type StateRmStepRunner struct {
+	TerraformExecutor TerraformExec
+	DefaultTFVersion  *version.Version
+}
+
+// Run runs terraform state rm. The addresses to remove come from the comment
+// so they're in ctx.EscapedPositionalArgs.
+func (s *StateRmStepRunner) Run(ctx models.ProjectCommandContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfVersion := s.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	stateRmCmd := append(append([]string{"state", "rm"}, extraArgs...), ctx.EscapedCommentArgs...)
+	stateRmCmd = append(stateRmCmd, ctx.EscapedPositionalArgs...)
+	out, err := s.TerraformExecutor.RunCommandWithVersion(ctx.Context, ctx.Log, path, stateRmCmd, workspaceEnvs(ctx, envs), tfVersion, ctx.Workspace, ctx.Job)
+	if err != nil {
+		return out, err
+	}
+
+	// The state has changed so any existing plan is now stale.
+	removeStalePlan(ctx, path)
+	return out, nil
+}
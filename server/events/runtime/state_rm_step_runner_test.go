@@ -0,0 +1,54 @@
+package runtime_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/runtime"
+	"github.com/runatlantis/atlantis/server/events/terraform/mocks"
+	matchers2 "github.com/runatlantis/atlantis/server/events/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStateRmStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	planPath := filepath.Join(tmpDir, "myproject-default.tfplan")
+	Ok(t, ioutil.WriteFile(planPath, nil, 0600))
+
+	terraform := mocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.12.0")
+	s := runtime.StateRmStepRunner{
+		TerraformExecutor: terraform,
+		DefaultTFVersion:  tfVersion,
+	}
+	When(terraform.RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob())).
+		ThenReturn("Removed addr", nil)
+
+	output, err := s.Run(models.ProjectCommandContext{
+		Log:                   logging.NewNoopLogger(),
+		Workspace:             "default",
+		ProjectName:           "myproject",
+		EscapedPositionalArgs: []string{"addr1", "addr2"},
+	}, []string{"-lock=false"}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "Removed addr", output)
+
+	expArgs := []string{"state", "rm", "-lock=false", "addr1", "addr2"}
+	_, _, path, args, _, v, workspace, _ := terraform.VerifyWasCalledOnce().RunCommandWithVersion(matchers2.AnyContextContext(), matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyMapOfStringToString(), matchers2.AnyPtrToGoVersionVersion(), AnyString(), matchers2.AnyPtrToJobsJob()).GetCapturedArguments()
+	Equals(t, tmpDir, path)
+	Equals(t, expArgs, args)
+	Equals(t, tfVersion, v)
+	Equals(t, "default", workspace)
+
+	_, err = os.Stat(planPath)
+	Assert(t, os.IsNotExist(err), "exp plan to be deleted")
+}
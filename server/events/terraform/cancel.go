@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// cancelGracePeriod is how long a command has to exit after it's interrupted
+// because it was cancelled before it's killed. Terraform needs this time to
+// stop cleanly and release its state lock. It's a variable so tests can
+// shorten it.
+var cancelGracePeriod = time.Minute
+
+// StartCancellable starts cmd in its own process group. If ctx is cancelled
+// before cmd exits, the process group is sent SIGINT so that Terraform stops
+// cleanly, and then SIGKILL if it hasn't exited after the grace period.
+// Signalling the whole group means commands run with sh -c and anything they
+// start are stopped too. Callers must call the returned stop function once
+// cmd.Wait has returned. If ctx is nil, cmd can't be cancelled.
+func StartCancellable(ctx context.Context, cmd *exec.Cmd) (stop func(), err error) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return func() {}, err
+	}
+	if ctx == nil {
+		return func() {}, nil
+	}
+
+	exited := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		}
+		// A negative pid signals the process group.
+		pgid := -cmd.Process.Pid
+		syscall.Kill(pgid, syscall.SIGINT) // nolint: errcheck
+		timer := time.NewTimer(cancelGracePeriod)
+		defer timer.Stop()
+		select {
+		case <-exited:
+		case <-timer.C:
+			syscall.Kill(pgid, syscall.SIGKILL) // nolint: errcheck
+		}
+	}()
+	return func() {
+		close(exited)
+		<-stopped
+	}, nil
+}
+
+// RunCancellable runs cmd like cmd.Run but stops it if ctx is cancelled. See
+// StartCancellable.
+func RunCancellable(ctx context.Context, cmd *exec.Cmd) error {
+	stop, err := StartCancellable(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer stop()
+	return cmd.Wait()
+}
@@ -4,8 +4,10 @@
 package mocks
 
 import (
+	context "context"
 	go_version "github.com/hashicorp/go-version"
 	pegomock "github.com/petergtz/pegomock"
+	jobs "github.com/runatlantis/atlantis/server/jobs"
 	logging "github.com/runatlantis/atlantis/server/logging"
 	"reflect"
 	"time"
@@ -26,38 +28,38 @@ func NewMockClient(options ...pegomock.Option) *MockClient {
 func (mock *MockClient) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockClient) FailHandler() pegomock.FailHandler      { return mock.fail }
 
-func (mock *MockClient) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *go_version.Version, workspace string) (string, error) {
+func (mock *MockClient) EnsureVersion(log *logging.SimpleLogger, v *go_version.Version) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockClient().")
 	}
-	params := []pegomock.Param{log, path, args, envs, v, workspace}
-	result := pegomock.GetGenericMockFrom(mock).Invoke("RunCommandWithVersion", params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
-	var ret0 string
-	var ret1 error
+	params := []pegomock.Param{log, v}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("EnsureVersion", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
 	if len(result) != 0 {
 		if result[0] != nil {
-			ret0 = result[0].(string)
-		}
-		if result[1] != nil {
-			ret1 = result[1].(error)
+			ret0 = result[0].(error)
 		}
 	}
-	return ret0, ret1
+	return ret0
 }
 
-func (mock *MockClient) EnsureVersion(log *logging.SimpleLogger, v *go_version.Version) error {
+func (mock *MockClient) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *go_version.Version, workspace string, job *jobs.Job) (string, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockClient().")
 	}
-	params := []pegomock.Param{log, v}
-	result := pegomock.GetGenericMockFrom(mock).Invoke("EnsureVersion", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
-	var ret0 error
+	params := []pegomock.Param{ctx, log, path, args, envs, v, workspace, job}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("RunCommandWithVersion", params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 string
+	var ret1 error
 	if len(result) != 0 {
 		if result[0] != nil {
-			ret0 = result[0].(error)
+			ret0 = result[0].(string)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
 		}
 	}
-	return ret0
+	return ret0, ret1
 }
 
 func (mock *MockClient) VerifyWasCalledOnce() *VerifierMockClient {
@@ -97,79 +99,89 @@ type VerifierMockClient struct {
 	timeout                time.Duration
 }
 
-func (verifier *VerifierMockClient) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *go_version.Version, workspace string) *MockClient_RunCommandWithVersion_OngoingVerification {
-	params := []pegomock.Param{log, path, args, envs, v, workspace}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RunCommandWithVersion", params, verifier.timeout)
-	return &MockClient_RunCommandWithVersion_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+func (verifier *VerifierMockClient) EnsureVersion(log *logging.SimpleLogger, v *go_version.Version) *MockClient_EnsureVersion_OngoingVerification {
+	params := []pegomock.Param{log, v}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "EnsureVersion", params, verifier.timeout)
+	return &MockClient_EnsureVersion_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockClient_RunCommandWithVersion_OngoingVerification struct {
+type MockClient_EnsureVersion_OngoingVerification struct {
 	mock              *MockClient
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockClient_RunCommandWithVersion_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, string, []string, map[string]string, *go_version.Version, string) {
-	log, path, args, envs, v, workspace := c.GetAllCapturedArguments()
-	return log[len(log)-1], path[len(path)-1], args[len(args)-1], envs[len(envs)-1], v[len(v)-1], workspace[len(workspace)-1]
+func (c *MockClient_EnsureVersion_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, *go_version.Version) {
+	log, v := c.GetAllCapturedArguments()
+	return log[len(log)-1], v[len(v)-1]
 }
 
-func (c *MockClient_RunCommandWithVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []string, _param2 [][]string, _param3 []map[string]string, _param4 []*go_version.Version, _param5 []string) {
+func (c *MockClient_EnsureVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []*go_version.Version) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
 		_param0 = make([]*logging.SimpleLogger, len(params[0]))
 		for u, param := range params[0] {
 			_param0[u] = param.(*logging.SimpleLogger)
 		}
-		_param1 = make([]string, len(params[1]))
+		_param1 = make([]*go_version.Version, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.(string)
-		}
-		_param2 = make([][]string, len(params[2]))
-		for u, param := range params[2] {
-			_param2[u] = param.([]string)
-		}
-		_param3 = make([]map[string]string, len(params[3]))
-		for u, param := range params[3] {
-			_param3[u] = param.(map[string]string)
-		}
-		_param4 = make([]*go_version.Version, len(params[4]))
-		for u, param := range params[4] {
-			_param4[u] = param.(*go_version.Version)
-		}
-		_param5 = make([]string, len(params[5]))
-		for u, param := range params[5] {
-			_param5[u] = param.(string)
+			_param1[u] = param.(*go_version.Version)
 		}
 	}
 	return
 }
 
-func (verifier *VerifierMockClient) EnsureVersion(log *logging.SimpleLogger, v *go_version.Version) *MockClient_EnsureVersion_OngoingVerification {
-	params := []pegomock.Param{log, v}
-	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "EnsureVersion", params, verifier.timeout)
-	return &MockClient_EnsureVersion_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+func (verifier *VerifierMockClient) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *go_version.Version, workspace string, job *jobs.Job) *MockClient_RunCommandWithVersion_OngoingVerification {
+	params := []pegomock.Param{ctx, log, path, args, envs, v, workspace, job}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RunCommandWithVersion", params, verifier.timeout)
+	return &MockClient_RunCommandWithVersion_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
-type MockClient_EnsureVersion_OngoingVerification struct {
+type MockClient_RunCommandWithVersion_OngoingVerification struct {
 	mock              *MockClient
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockClient_EnsureVersion_OngoingVerification) GetCapturedArguments() (*logging.SimpleLogger, *go_version.Version) {
-	log, v := c.GetAllCapturedArguments()
-	return log[len(log)-1], v[len(v)-1]
+func (c *MockClient_RunCommandWithVersion_OngoingVerification) GetCapturedArguments() (context.Context, *logging.SimpleLogger, string, []string, map[string]string, *go_version.Version, string, *jobs.Job) {
+	ctx, log, path, args, envs, v, workspace, job := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1], log[len(log)-1], path[len(path)-1], args[len(args)-1], envs[len(envs)-1], v[len(v)-1], workspace[len(workspace)-1], job[len(job)-1]
 }
 
-func (c *MockClient_EnsureVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []*logging.SimpleLogger, _param1 []*go_version.Version) {
+func (c *MockClient_RunCommandWithVersion_OngoingVerification) GetAllCapturedArguments() (_param0 []context.Context, _param1 []*logging.SimpleLogger, _param2 []string, _param3 [][]string, _param4 []map[string]string, _param5 []*go_version.Version, _param6 []string, _param7 []*jobs.Job) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
-		_param0 = make([]*logging.SimpleLogger, len(params[0]))
+		_param0 = make([]context.Context, len(params[0]))
 		for u, param := range params[0] {
-			_param0[u] = param.(*logging.SimpleLogger)
+			if param != nil {
+				_param0[u] = param.(context.Context)
+			}
 		}
-		_param1 = make([]*go_version.Version, len(params[1]))
+		_param1 = make([]*logging.SimpleLogger, len(params[1]))
 		for u, param := range params[1] {
-			_param1[u] = param.(*go_version.Version)
+			_param1[u] = param.(*logging.SimpleLogger)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([][]string, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.([]string)
+		}
+		_param4 = make([]map[string]string, len(params[4]))
+		for u, param := range params[4] {
+			_param4[u] = param.(map[string]string)
+		}
+		_param5 = make([]*go_version.Version, len(params[5]))
+		for u, param := range params[5] {
+			_param5[u] = param.(*go_version.Version)
+		}
+		_param6 = make([]string, len(params[6]))
+		for u, param := range params[6] {
+			_param6[u] = param.(string)
+		}
+		_param7 = make([]*jobs.Job, len(params[7]))
+		for u, param := range params[7] {
+			_param7[u] = param.(*jobs.Job)
 		}
 	}
 	return
@@ -16,6 +16,8 @@ package terraform
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,12 +28,15 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-getter"
 	"github.com/hashicorp/go-version"
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_terraform_client.go Client
@@ -39,8 +44,10 @@ import (
 type Client interface {
 	// RunCommandWithVersion executes terraform with args in path. If v is nil,
 	// it will use the default Terraform version. workspace is the Terraform
-	// workspace which should be set as an environment variable.
-	RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string) (string, error)
+	// workspace which should be set as an environment variable. The output is
+	// streamed to job as it's produced, unless job is nil. If ctx is
+	// cancelled, terraform is interrupted.
+	RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, path string, args []string, envs map[string]string, v *version.Version, workspace string, job *jobs.Job) (string, error)
 
 	// EnsureVersion makes sure that terraform version `v` is available to use
 	EnsureVersion(log *logging.SimpleLogger, v *version.Version) error
@@ -65,6 +72,12 @@ type DefaultClient struct {
 
 	// versionsLock is used to ensure versions isn't being concurrently written to.
 	versionsLock *sync.Mutex
+
+	// releases caches the versions in the releases index at downloadBaseURL.
+	// Use releasesLock to control access.
+	releases          []*version.Version
+	releasesFetchedAt time.Time
+	releasesLock      sync.Mutex
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_downloader.go Downloader
@@ -211,7 +224,7 @@ func (c *DefaultClient) EnsureVersion(log *logging.SimpleLogger, v *version.Vers
 }
 
 // See Client.RunCommandWithVersion.
-func (c *DefaultClient) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, customEnvVars map[string]string, v *version.Version, workspace string) (string, error) {
+func (c *DefaultClient) RunCommandWithVersion(ctx context.Context, log *logging.SimpleLogger, path string, args []string, customEnvVars map[string]string, v *version.Version, workspace string, job *jobs.Job) (string, error) {
 	tfCmd, cmd, err := c.prepCmd(log, v, workspace, path, args)
 	if err != nil {
 		return "", err
@@ -221,14 +234,24 @@ func (c *DefaultClient) RunCommandWithVersion(log *logging.SimpleLogger, path st
 		envVars = append(envVars, fmt.Sprintf("%s=%s", key, val))
 	}
 	cmd.Env = envVars
-	out, err := cmd.CombinedOutput()
+
+	// Like CombinedOutput but we also stream each line to the job. Since
+	// stdout and stderr are the same writer, only one of them is written at
+	// a time.
+	var out bytes.Buffer
+	jobWriter := jobs.NewWriter(job)
+	output := io.MultiWriter(&out, jobWriter)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err = RunCancellable(ctx, cmd)
+	jobWriter.Flush()
 	if err != nil {
 		err = errors.Wrapf(err, "running %q in %q", tfCmd, path)
 		log.Err(err.Error())
-		return string(out), err
+		return out.String(), err
 	}
 	log.Info("successfully ran %q in %q", tfCmd, path)
-	return string(out), nil
+	return out.String(), nil
 }
 
 // prepCmd builds a ready to execute command based on the version of terraform
@@ -287,8 +310,9 @@ type Line struct {
 // get the realtime output from the command.
 // Callers can use the input channel to pass stdin input to the command.
 // If any error is passed on the out channel, there will be no
-// further output (so callers are free to exit).
-func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string, args []string, customEnvVars map[string]string, v *version.Version, workspace string) (chan<- string, <-chan Line) {
+// further output (so callers are free to exit). The output is also streamed
+// to job and terraform is interrupted if ctx is cancelled.
+func (c *DefaultClient) RunCommandAsync(ctx context.Context, log *logging.SimpleLogger, path string, args []string, customEnvVars map[string]string, v *version.Version, workspace string, job *jobs.Job) (chan<- string, <-chan Line) {
 	outCh := make(chan Line)
 	inCh := make(chan string)
 
@@ -318,7 +342,7 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 		cmd.Env = envVars
 
 		log.Debug("starting %q in %q", tfCmd, path)
-		err = cmd.Start()
+		stop, err := StartCancellable(ctx, cmd)
 		if err != nil {
 			err = errors.Wrapf(err, "running %q in %q", tfCmd, path)
 			log.Err(err.Error())
@@ -346,6 +370,7 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 		go func() {
 			s := bufio.NewScanner(stdout)
 			for s.Scan() {
+				job.Write(s.Text())
 				outCh <- Line{Line: s.Text()}
 			}
 			wg.Done()
@@ -353,6 +378,7 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 		go func() {
 			s := bufio.NewScanner(stderr)
 			for s.Scan() {
+				job.Write(s.Text())
 				outCh <- Line{Line: s.Text()}
 			}
 			wg.Done()
@@ -364,6 +390,7 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 
 		// Wait for the command to complete.
 		err = cmd.Wait()
+		stop()
 
 		// We're done now. Send an error if there was one.
 		if err != nil {
@@ -482,3 +509,23 @@ type DefaultDownloader struct{}
 func (d *DefaultDownloader) GetFile(dst, src string, opts ...getter.ClientOption) error {
 	return getter.GetFile(dst, src, opts...)
 }
+
+// InstrumentedDownloader wraps a Downloader and records how long each
+// Terraform download took.
+type InstrumentedDownloader struct {
+	Downloader Downloader
+	// Duration has the label result which is either success or error.
+	Duration *metrics.Histogram
+}
+
+// See go-getter.GetFile.
+func (d *InstrumentedDownloader) GetFile(dst, src string, opts ...getter.ClientOption) error {
+	start := time.Now()
+	err := d.Downloader.GetFile(dst, src, opts...)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	d.Duration.ObserveSince(start, result)
+	return err
+}
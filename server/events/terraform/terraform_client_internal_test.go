@@ -1,14 +1,20 @@
 package terraform
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -103,7 +109,7 @@ func TestDefaultClient_RunCommandWithVersion_EnvVars(t *testing.T) {
 		"ATLANTIS_TERRAFORM_VERSION=$ATLANTIS_TERRAFORM_VERSION",
 		"DIR=$DIR",
 	}
-	out, err := client.RunCommandWithVersion(nil, tmp, args, map[string]string{}, nil, "workspace")
+	out, err := client.RunCommandWithVersion(context.Background(), nil, tmp, args, map[string]string{}, nil, "workspace", nil)
 	Ok(t, err)
 	exp := fmt.Sprintf("TF_IN_AUTOMATION=true TF_PLUGIN_CACHE_DIR=%s WORKSPACE=workspace ATLANTIS_TERRAFORM_VERSION=0.11.11 DIR=%s\n", tmp, tmp)
 	Equals(t, exp, out)
@@ -128,12 +134,88 @@ func TestDefaultClient_RunCommandWithVersion_Error(t *testing.T) {
 		"1",
 	}
 	log := logging.NewSimpleLogger("test", false, logging.Debug)
-	out, err := client.RunCommandWithVersion(log, tmp, args, map[string]string{}, nil, "workspace")
+	out, err := client.RunCommandWithVersion(context.Background(), log, tmp, args, map[string]string{}, nil, "workspace", nil)
 	ErrEquals(t, fmt.Sprintf(`running "echo dying && exit 1" in %q: exit status 1`, tmp), err)
 	// Test that we still get our output.
 	Equals(t, "dying\n", out)
 }
 
+// Test that the output is streamed to the job.
+func TestDefaultClient_RunCommandWithVersion_StreamsToJob(t *testing.T) {
+	v, err := version.NewVersion("0.11.11")
+	Ok(t, err)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	client := &DefaultClient{
+		defaultVersion:          v,
+		terraformPluginCacheDir: tmp,
+		overrideTF:              "echo",
+	}
+
+	job := jobs.NewRegistry(nil).Start(jobs.Info{Command: "plan"})
+	out, err := client.RunCommandWithVersion(context.Background(), nil, tmp, []string{"stdout", "&&", "printf", "stderr", ">&2"}, map[string]string{}, nil, "workspace", job)
+	Ok(t, err)
+	Equals(t, "stdout\nstderr", out)
+	lines, _, unsubscribe := job.Subscribe(0)
+	unsubscribe()
+	Equals(t, []string{"stdout", "stderr"}, lines)
+}
+
+// Test that when the context is cancelled the command is interrupted so it
+// can stop cleanly.
+func TestDefaultClient_RunCommandWithVersion_Cancel(t *testing.T) {
+	v, err := version.NewVersion("0.11.11")
+	Ok(t, err)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	client := &DefaultClient{
+		defaultVersion:          v,
+		terraformPluginCacheDir: tmp,
+		overrideTF:              "trap",
+	}
+
+	job := jobs.NewRegistry(nil).Start(jobs.Info{Command: "plan"})
+	_, ch, unsubscribe := job.Subscribe(0)
+	defer unsubscribe()
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Wait until the trap is set.
+		<-ch
+		cancel()
+	}()
+	args := []string{"'echo interrupted; exit 3'", "INT;", "echo", "started;", "while", "true;", "do", "sleep", "0.1;", "done"}
+	out, err := client.RunCommandWithVersion(cmdCtx, nil, tmp, args, map[string]string{}, nil, "workspace", job)
+	Assert(t, err != nil && strings.HasSuffix(err.Error(), "exit status 3"), "exp exit status 3, got %v", err)
+	Equals(t, "started\ninterrupted\n", out)
+}
+
+// Test that a command that ignores the interrupt is killed after the grace
+// period.
+func TestDefaultClient_RunCommandAsync_CancelKills(t *testing.T) {
+	defer func(orig time.Duration) { cancelGracePeriod = orig }(cancelGracePeriod)
+	cancelGracePeriod = 100 * time.Millisecond
+	v, err := version.NewVersion("0.11.11")
+	Ok(t, err)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	client := &DefaultClient{
+		defaultVersion:          v,
+		terraformPluginCacheDir: tmp,
+		overrideTF:              "trap",
+	}
+
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	log := logging.NewSimpleLogger("test", false, logging.Debug)
+	_, outCh := client.RunCommandAsync(cmdCtx, log, tmp, []string{"''", "INT;", "echo", "started;", "sleep", "60"}, map[string]string{}, nil, "workspace", nil)
+	line := <-outCh
+	Equals(t, "started", line.Line)
+	cancel()
+
+	out, err := waitCh(outCh)
+	Assert(t, err != nil && strings.HasSuffix(err.Error(), "signal: killed"), "exp to be killed, got %v", err)
+	Equals(t, "", out)
+}
+
 func TestDefaultClient_RunCommandAsync_Success(t *testing.T) {
 	v, err := version.NewVersion("0.11.11")
 	Ok(t, err)
@@ -152,7 +234,7 @@ func TestDefaultClient_RunCommandAsync_Success(t *testing.T) {
 		"ATLANTIS_TERRAFORM_VERSION=$ATLANTIS_TERRAFORM_VERSION",
 		"DIR=$DIR",
 	}
-	_, outCh := client.RunCommandAsync(nil, tmp, args, map[string]string{}, nil, "workspace")
+	_, outCh := client.RunCommandAsync(context.Background(), nil, tmp, args, map[string]string{}, nil, "workspace", nil)
 
 	out, err := waitCh(outCh)
 	Ok(t, err)
@@ -181,7 +263,7 @@ func TestDefaultClient_RunCommandAsync_BigOutput(t *testing.T) {
 		_, err = f.WriteString(s)
 		Ok(t, err)
 	}
-	_, outCh := client.RunCommandAsync(nil, tmp, []string{filename}, map[string]string{}, nil, "workspace")
+	_, outCh := client.RunCommandAsync(context.Background(), nil, tmp, []string{filename}, map[string]string{}, nil, "workspace", nil)
 
 	out, err := waitCh(outCh)
 	Ok(t, err)
@@ -199,7 +281,7 @@ func TestDefaultClient_RunCommandAsync_StderrOutput(t *testing.T) {
 		overrideTF:              "echo",
 	}
 	log := logging.NewSimpleLogger("test", false, logging.Debug)
-	_, outCh := client.RunCommandAsync(log, tmp, []string{"stderr", ">&2"}, map[string]string{}, nil, "workspace")
+	_, outCh := client.RunCommandAsync(context.Background(), log, tmp, []string{"stderr", ">&2"}, map[string]string{}, nil, "workspace", nil)
 
 	out, err := waitCh(outCh)
 	Ok(t, err)
@@ -217,7 +299,7 @@ func TestDefaultClient_RunCommandAsync_ExitOne(t *testing.T) {
 		overrideTF:              "echo",
 	}
 	log := logging.NewSimpleLogger("test", false, logging.Debug)
-	_, outCh := client.RunCommandAsync(log, tmp, []string{"dying", "&&", "exit", "1"}, map[string]string{}, nil, "workspace")
+	_, outCh := client.RunCommandAsync(context.Background(), log, tmp, []string{"dying", "&&", "exit", "1"}, map[string]string{}, nil, "workspace", nil)
 
 	out, err := waitCh(outCh)
 	ErrEquals(t, fmt.Sprintf(`running "echo dying && exit 1" in %q: exit status 1`, tmp), err)
@@ -236,7 +318,7 @@ func TestDefaultClient_RunCommandAsync_Input(t *testing.T) {
 		overrideTF:              "read",
 	}
 	log := logging.NewSimpleLogger("test", false, logging.Debug)
-	inCh, outCh := client.RunCommandAsync(log, tmp, []string{"a", "&&", "echo", "$a"}, map[string]string{}, nil, "workspace")
+	inCh, outCh := client.RunCommandAsync(context.Background(), log, tmp, []string{"a", "&&", "echo", "$a"}, map[string]string{}, nil, "workspace", nil)
 	inCh <- "echo me\n"
 
 	out, err := waitCh(outCh)
@@ -254,3 +336,52 @@ func waitCh(ch <-chan Line) (string, error) {
 	}
 	return strings.Join(ls, "\n"), nil
 }
+
+// Test that ListVersions returns the versions from the releases index and the
+// versions we have locally, and that it falls back to the local versions if
+// the index can't be fetched.
+func TestListVersions(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, "terraform0.11.14"), nil, 0700))
+
+	indexFetches := 0
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/terraform/index.json", r.URL.Path)
+		indexFetches++
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"name":"terraform","versions":{"0.12.20":{},"0.12.3":{},"0.13.0-beta1":{}}}`) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	client := &DefaultClient{
+		binDir:          tmp,
+		downloadBaseURL: server.URL,
+		versions:        map[string]string{"0.12.3": "/bin/terraform"},
+		versionsLock:    &sync.Mutex{},
+	}
+	versions, err := client.ListVersions(logging.NewNoopLogger())
+	Ok(t, err)
+	var strs []string
+	for _, v := range versions {
+		strs = append(strs, v.String())
+	}
+	Equals(t, []string{"0.11.14", "0.12.3", "0.12.20", "0.13.0-beta1"}, strs)
+
+	// The index should be cached.
+	_, err = client.ListVersions(logging.NewNoopLogger())
+	Ok(t, err)
+	Equals(t, 1, indexFetches)
+
+	// If we can't fetch the index, we use the local versions.
+	available = false
+	client.releases = nil
+	versions, err = client.ListVersions(logging.NewNoopLogger())
+	Ok(t, err)
+	Equals(t, 2, len(versions))
+	Equals(t, "0.12.3", versions[1].String())
+}
@@ -14,6 +14,7 @@
 package terraform_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -74,7 +75,7 @@ is 0.11.13. You can update by downloading from www.terraform.io/downloads.html
 	Ok(t, err)
 	Equals(t, "0.11.10", c.DefaultVersion().String())
 
-	output, err := c.RunCommandWithVersion(nil, tmp, nil, map[string]string{"test": "123"}, nil, "")
+	output, err := c.RunCommandWithVersion(context.Background(), nil, tmp, nil, map[string]string{"test": "123"}, nil, "", nil)
 	Ok(t, err)
 	Equals(t, fakeBinOut+"\n", output)
 }
@@ -102,7 +103,7 @@ is 0.11.13. You can update by downloading from www.terraform.io/downloads.html
 	Ok(t, err)
 	Equals(t, "0.11.10", c.DefaultVersion().String())
 
-	output, err := c.RunCommandWithVersion(nil, tmp, nil, map[string]string{}, nil, "")
+	output, err := c.RunCommandWithVersion(context.Background(), nil, tmp, nil, map[string]string{}, nil, "", nil)
 	Ok(t, err)
 	Equals(t, fakeBinOut+"\n", output)
 }
@@ -139,7 +140,7 @@ func TestNewClient_DefaultTFFlagInPath(t *testing.T) {
 	Ok(t, err)
 	Equals(t, "0.11.10", c.DefaultVersion().String())
 
-	output, err := c.RunCommandWithVersion(nil, tmp, nil, map[string]string{}, nil, "")
+	output, err := c.RunCommandWithVersion(context.Background(), nil, tmp, nil, map[string]string{}, nil, "", nil)
 	Ok(t, err)
 	Equals(t, fakeBinOut+"\n", output)
 }
@@ -163,7 +164,7 @@ func TestNewClient_DefaultTFFlagInBinDir(t *testing.T) {
 	Ok(t, err)
 	Equals(t, "0.11.10", c.DefaultVersion().String())
 
-	output, err := c.RunCommandWithVersion(nil, tmp, nil, map[string]string{}, nil, "")
+	output, err := c.RunCommandWithVersion(context.Background(), nil, tmp, nil, map[string]string{}, nil, "", nil)
 	Ok(t, err)
 	Equals(t, fakeBinOut+"\n", output)
 }
@@ -198,7 +199,7 @@ func TestNewClient_DefaultTFFlagDownload(t *testing.T) {
 
 	// Reset PATH so that it has sh.
 	Ok(t, os.Setenv("PATH", orig))
-	output, err := c.RunCommandWithVersion(nil, tmp, nil, map[string]string{}, nil, "")
+	output, err := c.RunCommandWithVersion(context.Background(), nil, tmp, nil, map[string]string{}, nil, "", nil)
 	Ok(t, err)
 	Equals(t, "\nTerraform v0.11.10\n\n", output)
 }
@@ -236,7 +237,7 @@ func TestRunCommandWithVersion_DLsTF(t *testing.T) {
 
 	v, err := version.NewVersion("99.99.99")
 	Ok(t, err)
-	output, err := c.RunCommandWithVersion(nil, tmp, nil, map[string]string{}, v, "")
+	output, err := c.RunCommandWithVersion(context.Background(), nil, tmp, nil, map[string]string{}, v, "", nil)
 	Assert(t, err == nil, "err: %s: %s", err, output)
 	Equals(t, "\nTerraform v99.99.99\n\n", output)
 }
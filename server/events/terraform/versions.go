@@ -0,0 +1,119 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// releasesIndexTTL is how long we cache the releases index for before
+// fetching it again.
+const releasesIndexTTL = time.Hour
+
+// releasesIndex is the subset of the releases index at
+// {download url}/terraform/index.json that we use.
+type releasesIndex struct {
+	Versions map[string]json.RawMessage `json:"versions"`
+}
+
+// ListVersions returns the versions of Terraform that can be used, sorted
+// from oldest to newest. These are the versions in the releases index at the
+// download URL as well as the versions we already have locally. If the index
+// can't be fetched, ex. because Atlantis is running without internet access,
+// only the local versions are returned.
+func (c *DefaultClient) ListVersions(log *logging.SimpleLogger) ([]*version.Version, error) {
+	found := make(map[string]*version.Version)
+	for _, v := range c.localVersions() {
+		found[v.String()] = v
+	}
+	released, err := c.releasedVersions()
+	if err != nil {
+		if len(found) == 0 {
+			return nil, err
+		}
+		log.Warn("unable to list terraform releases, only using versions available locally: %s", err)
+	}
+	for _, v := range released {
+		found[v.String()] = v
+	}
+
+	var versions []*version.Version
+	for _, v := range found {
+		versions = append(versions, v)
+	}
+	sort.Sort(version.Collection(versions))
+	return versions, nil
+}
+
+// localVersions returns the versions of Terraform that we've already found or
+// downloaded.
+func (c *DefaultClient) localVersions() []*version.Version {
+	c.versionsLock.Lock()
+	defer c.versionsLock.Unlock()
+	var versions []*version.Version
+	for s := range c.versions {
+		if v, err := version.NewVersion(s); err == nil {
+			versions = append(versions, v)
+		}
+	}
+
+	// Binaries downloaded before Atlantis was restarted are in our bin dir
+	// but not yet in c.versions.
+	files, err := ioutil.ReadDir(c.binDir)
+	if err != nil {
+		return versions
+	}
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), "terraform") {
+			continue
+		}
+		if v, err := version.NewVersion(strings.TrimPrefix(f.Name(), "terraform")); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// releasedVersions returns the versions in the releases index. The index is
+// cached for releasesIndexTTL.
+func (c *DefaultClient) releasedVersions() ([]*version.Version, error) {
+	c.releasesLock.Lock()
+	defer c.releasesLock.Unlock()
+	if c.releases != nil && time.Since(c.releasesFetchedAt) < releasesIndexTTL {
+		return c.releases, nil
+	}
+
+	indexURL := fmt.Sprintf("%s/terraform/index.json", c.downloadBaseURL)
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(indexURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching %s", indexURL)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: got status code %d", indexURL, resp.StatusCode)
+	}
+	var index releasesIndex
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", indexURL)
+	}
+
+	var versions []*version.Version
+	for s := range index.Versions {
+		// Skip any entries that aren't versions rather than failing.
+		if v, err := version.NewVersion(s); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	c.releases = versions
+	c.releasesFetchedAt = time.Now()
+	return versions, nil
+}
@@ -163,6 +163,15 @@ func (g *AzureDevopsClient) PullIsMergeable(repo models.Repo, pull models.PullRe
 	return false, nil
 }
 
+// PullIsClosed returns true if the pull request was completed or abandoned.
+func (g *AzureDevopsClient) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	adPull, err := g.GetPullRequest(repo, pull.Num)
+	if err != nil {
+		return false, errors.Wrap(err, "getting pull request")
+	}
+	return adPull.GetStatus() != azuredevops.PullActive.String(), nil
+}
+
 // GetPullRequest returns the pull request.
 func (g *AzureDevopsClient) GetPullRequest(repo models.Repo, num int) (*azuredevops.GitPullRequest, error) {
 	opts := azuredevops.PullRequestGetOptions{
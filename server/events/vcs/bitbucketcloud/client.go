@@ -156,6 +156,24 @@ func (b *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest) (boo
 	return true, nil
 }
 
+// PullIsClosed returns true if the pull request was merged, declined or
+// superseded.
+func (b *Client) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return false, err
+	}
+	var pullResp PullRequest
+	if err := json.Unmarshal(resp, &pullResp); err != nil {
+		return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(pullResp); err != nil {
+		return false, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+	return *pullResp.State != "OPEN", nil
+}
+
 // UpdateStatus updates the status of a commit.
 func (b *Client) UpdateStatus(repo models.Repo, pull models.PullRequest, status models.CommitStatus, src string, description string, url string) error {
 	bbState := "FAILED"
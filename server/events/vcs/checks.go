@@ -0,0 +1,54 @@
+package vcs
+
+import "github.com/runatlantis/atlantis/server/events/models"
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_checks_client.go ChecksClient
+
+// ChecksClient publishes check runs. Check runs are like commit statuses but
+// have markdown output, annotations on the files that failed and actions
+// users can click on. Only GitHub supports them.
+type ChecksClient interface {
+	// UpdateCheckRun creates or updates the check run named run.Name on the
+	// head commit of pull.
+	UpdateCheckRun(repo models.Repo, pull models.PullRequest, run CheckRun) error
+}
+
+// CheckRun is the state of a check run.
+type CheckRun struct {
+	// Name identifies the check run, ex. atlantis/plan: dir/default. It
+	// should be the same across runs.
+	Name   string
+	Status models.CommitStatus
+	// Title is a one-line description of the status.
+	Title string
+	// Summary is markdown describing the result.
+	Summary string
+	// DetailsURL is an optional link to more information, ex. the job page.
+	DetailsURL string
+	// ExternalID is sent back to Atlantis when a user clicks on an action.
+	ExternalID string
+	// Annotations point to the lines that caused a failure.
+	Annotations []CheckRunAnnotation
+	// Actions are the buttons shown on the check run. When clicked, GitHub
+	// sends a check_run webhook with the action's identifier.
+	Actions []CheckRunAction
+}
+
+// CheckRunAnnotation is a message about a line of a file in a check run.
+type CheckRunAnnotation struct {
+	// Path is relative to the repo root.
+	Path    string
+	Line    int
+	Title   string
+	Message string
+}
+
+// CheckRunAction is a button shown on a check run.
+type CheckRunAction struct {
+	// Label is at most 20 characters.
+	Label string
+	// Description is at most 40 characters.
+	Description string
+	// Identifier is at most 20 characters.
+	Identifier string
+}
@@ -0,0 +1,239 @@
+package gitea
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// filesPageSize is the number of changed files we ask for per page. Gitea
+// caps this at its MAX_RESPONSE_ITEMS setting which defaults to 50.
+const filesPageSize = 50
+
+type Client struct {
+	HTTPClient *http.Client
+	Token      string
+	BaseURL    string
+}
+
+// NewClient builds a Gitea client. Returns an error if the baseURL is
+// malformed. httpClient is the client to use to make the requests, token is
+// an access token for the Atlantis user and baseURL is the URL of the Gitea
+// installation, ex. https://gitea.corp.com. Don't include the API path, ex.
+// '/api/v1'.
+func NewClient(httpClient *http.Client, token string, baseURL string) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", baseURL)
+	}
+	if parsedURL.Scheme == "" {
+		return nil, fmt.Errorf("must have 'http://' or 'https://' in base url %q", baseURL)
+	}
+	return &Client{
+		HTTPClient: httpClient,
+		Token:      token,
+		BaseURL:    strings.TrimRight(parsedURL.String(), "/"),
+	}, nil
+}
+
+// GetModifiedFiles returns the names of files that were modified in the pull
+// request relative to the repo root, e.g. parent/child/file.txt.
+func (g *Client) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var files []string
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for page := 1; page <= maxLoops; page++ {
+		path := fmt.Sprintf("%s/api/v1/repos/%s/pulls/%d/files?page=%d&limit=%d", g.BaseURL, repo.FullName, pull.Num, page, filesPageSize)
+		resp, err := g.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+		var changed []ChangedFile
+		if err := json.Unmarshal(resp, &changed); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		for _, f := range changed {
+			if err := validator.New().Struct(f); err != nil {
+				return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+			}
+			files = append(files, *f.Filename)
+			// If the file was renamed, we'll want to run plan in the directory
+			// it was moved from as well.
+			if f.PreviousFilename != "" {
+				files = append(files, f.PreviousFilename)
+			}
+		}
+		if len(changed) < filesPageSize {
+			break
+		}
+	}
+
+	// Now ensure all files are unique.
+	hash := make(map[string]bool)
+	var unique []string
+	for _, f := range files {
+		if !hash[f] {
+			unique = append(unique, f)
+			hash[f] = true
+		}
+	}
+	return unique, nil
+}
+
+// CreateComment creates a comment on the pull request.
+func (g *Client) CreateComment(repo models.Repo, pullNum int, comment string) error {
+	bodyBytes, err := json.Marshal(map[string]string{"body": comment})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	// Pull requests are issues in Gitea so comments are created via the
+	// issues API.
+	path := fmt.Sprintf("%s/api/v1/repos/%s/issues/%d/comments", g.BaseURL, repo.FullName, pullNum)
+	_, err = g.makeRequest("POST", path, bytes.NewBuffer(bodyBytes))
+	return err
+}
+
+// PullIsApproved returns true if the pull request has an approving review
+// that hasn't been dismissed or made stale by new commits.
+func (g *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	path := fmt.Sprintf("%s/api/v1/repos/%s/pulls/%d/reviews", g.BaseURL, repo.FullName, pull.Num)
+	resp, err := g.makeRequest("GET", path, nil)
+	if err != nil {
+		return false, err
+	}
+	var reviews []Review
+	if err := json.Unmarshal(resp, &reviews); err != nil {
+		return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	for _, review := range reviews {
+		if err := validator.New().Struct(review); err != nil {
+			return false, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		if *review.State == "APPROVED" && !review.Stale && !review.Dismissed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PullIsMergeable returns true if the pull request has no conflicts and can
+// be merged.
+func (g *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
+	giteaPull, err := g.GetPullRequest(repo, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	return giteaPull.Mergeable, nil
+}
+
+// PullIsClosed returns true if the pull request was closed or merged.
+func (g *Client) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	giteaPull, err := g.GetPullRequest(repo, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	return *giteaPull.State == "closed", nil
+}
+
+// GetPullRequest returns the pull request.
+func (g *Client) GetPullRequest(repo models.Repo, pullNum int) (*PullRequest, error) {
+	path := fmt.Sprintf("%s/api/v1/repos/%s/pulls/%d", g.BaseURL, repo.FullName, pullNum)
+	resp, err := g.makeRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var pull PullRequest
+	if err := json.Unmarshal(resp, &pull); err != nil {
+		return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(pull); err != nil {
+		return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+	return &pull, nil
+}
+
+// UpdateStatus updates the status of a commit.
+func (g *Client) UpdateStatus(repo models.Repo, pull models.PullRequest, status models.CommitStatus, src string, description string, url string) error {
+	giteaState := "failure"
+	switch status {
+	case models.PendingCommitStatus:
+		giteaState = "pending"
+	case models.SuccessCommitStatus:
+		giteaState = "success"
+	case models.FailedCommitStatus:
+		giteaState = "failure"
+	}
+
+	bodyBytes, err := json.Marshal(map[string]string{
+		"context":     src,
+		"target_url":  url,
+		"state":       giteaState,
+		"description": description,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	path := fmt.Sprintf("%s/api/v1/repos/%s/statuses/%s", g.BaseURL, repo.FullName, pull.HeadCommit)
+	_, err = g.makeRequest("POST", path, bytes.NewBuffer(bodyBytes))
+	return err
+}
+
+// MergePull merges the pull request using a merge commit.
+func (g *Client) MergePull(pull models.PullRequest) error {
+	bodyBytes, err := json.Marshal(map[string]string{"Do": "merge"})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	path := fmt.Sprintf("%s/api/v1/repos/%s/pulls/%d/merge", g.BaseURL, pull.BaseRepo.FullName, pull.Num)
+	_, err = g.makeRequest("POST", path, bytes.NewBuffer(bodyBytes))
+	return err
+}
+
+// prepRequest adds auth and necessary headers.
+func (g *Client) prepRequest(method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "token "+g.Token)
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (g *Client) makeRequest(method string, path string, reqBody io.Reader) ([]byte, error) {
+	req, err := g.prepRequest(method, path, reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing request")
+	}
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	requestStr := fmt.Sprintf("%s %s", method, path)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("making request %q unexpected status code: %d, body: %s", requestStr, resp.StatusCode, string(respBody))
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading response from request %q", requestStr)
+	}
+	return respBody, nil
+}
@@ -0,0 +1,196 @@
+package gitea_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var repo = models.Repo{
+	FullName: "owner/repo",
+	Owner:    "owner",
+	Name:     "repo",
+	VCSHost: models.VCSHost{
+		Type:     models.Gitea,
+		Hostname: "gitea.example.com",
+	},
+}
+
+// fixtureServer returns a test server that responds to each path in routes
+// with the contents of the named file in testdata.
+func fixtureServer(t *testing.T, routes map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "token token", r.Header.Get("Authorization"))
+		fixture, ok := routes[r.Method+" "+r.RequestURI]
+		if !ok {
+			t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		body, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+		Ok(t, err)
+		w.Write(body) // nolint: errcheck
+	}))
+}
+
+func newClient(t *testing.T, url string) *gitea.Client {
+	client, err := gitea.NewClient(http.DefaultClient, "token", url)
+	Ok(t, err)
+	return client
+}
+
+func TestNewClient_InvalidURL(t *testing.T) {
+	_, err := gitea.NewClient(nil, "token", "gitea.example.com")
+	ErrEquals(t, "must have 'http://' or 'https://' in base url \"gitea.example.com\"", err)
+}
+
+func TestClient_GetModifiedFiles(t *testing.T) {
+	testServer := fixtureServer(t, map[string]string{
+		"GET /api/v1/repos/owner/repo/pulls/3/files?page=1&limit=50": "pull-files.json",
+	})
+	defer testServer.Close()
+
+	files, err := newClient(t, testServer.URL).GetModifiedFiles(repo, models.PullRequest{Num: 3})
+	Ok(t, err)
+	Equals(t, []string{"main.tf", "modules/new/vars.tf", "modules/old/vars.tf"}, files)
+}
+
+// Should request the next page if the first one was full.
+func TestClient_GetModifiedFilesPagination(t *testing.T) {
+	var firstPage []map[string]string
+	for i := 0; i < 50; i++ {
+		firstPage = append(firstPage, map[string]string{"filename": fmt.Sprintf("file%d.tf", i)})
+	}
+	firstResp, err := json.Marshal(firstPage)
+	Ok(t, err)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/api/v1/repos/owner/repo/pulls/3/files?page=1&limit=50":
+			w.Write(firstResp) // nolint: errcheck
+		case "/api/v1/repos/owner/repo/pulls/3/files?page=2&limit=50":
+			w.Write([]byte(`[{"filename": "file0.tf"}, {"filename": "last.tf"}]`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	files, err := newClient(t, testServer.URL).GetModifiedFiles(repo, models.PullRequest{Num: 3})
+	Ok(t, err)
+	Equals(t, 51, len(files))
+	Equals(t, "last.tf", files[50])
+}
+
+func TestClient_PullIsApproved(t *testing.T) {
+	cases := []struct {
+		fixture string
+		exp     bool
+	}{
+		{"pull-reviews-approved.json", true},
+		// A stale approval and a dismissed approval shouldn't count.
+		{"pull-reviews-stale.json", false},
+	}
+	for _, c := range cases {
+		t.Run(c.fixture, func(t *testing.T) {
+			testServer := fixtureServer(t, map[string]string{
+				"GET /api/v1/repos/owner/repo/pulls/3/reviews": c.fixture,
+			})
+			defer testServer.Close()
+
+			approved, err := newClient(t, testServer.URL).PullIsApproved(repo, models.PullRequest{Num: 3})
+			Ok(t, err)
+			Equals(t, c.exp, approved)
+		})
+	}
+}
+
+func TestClient_PullIsMergeable(t *testing.T) {
+	testServer := fixtureServer(t, map[string]string{
+		"GET /api/v1/repos/owner/repo/pulls/3": "pull-request.json",
+	})
+	defer testServer.Close()
+
+	mergeable, err := newClient(t, testServer.URL).PullIsMergeable(repo, models.PullRequest{Num: 3})
+	Ok(t, err)
+	Equals(t, true, mergeable)
+}
+
+func TestClient_GetPullRequest(t *testing.T) {
+	testServer := fixtureServer(t, map[string]string{
+		"GET /api/v1/repos/owner/repo/pulls/3": "pull-request.json",
+	})
+	defer testServer.Close()
+
+	pull, err := newClient(t, testServer.URL).GetPullRequest(repo, 3)
+	Ok(t, err)
+	Equals(t, 3, *pull.Number)
+	Equals(t, "add-main", *pull.Head.Ref)
+	Equals(t, "9d2f1a8c7b6e5d4c3b2a1f0e9d8c7b6a5f4e3d2c", *pull.Head.Sha)
+	Equals(t, "https://gitea.example.com/owner/repo.git", *pull.Base.Repository.CloneURL)
+}
+
+func TestClient_Posts(t *testing.T) {
+	cases := []struct {
+		description string
+		call        func(c *gitea.Client) error
+		expPath     string
+		expBody     string
+	}{
+		{
+			"create comment",
+			func(c *gitea.Client) error { return c.CreateComment(repo, 3, "comment") },
+			"/api/v1/repos/owner/repo/issues/3/comments",
+			`{"body":"comment"}`,
+		},
+		{
+			"update status",
+			func(c *gitea.Client) error {
+				return c.UpdateStatus(repo, models.PullRequest{Num: 3, HeadCommit: "sha"}, models.PendingCommitStatus, "atlantis/plan", "Plan in progress...", "https://atlantis.example.com")
+			},
+			"/api/v1/repos/owner/repo/statuses/sha",
+			`{"context":"atlantis/plan","description":"Plan in progress...","state":"pending","target_url":"https://atlantis.example.com"}`,
+		},
+		{
+			"merge pull",
+			func(c *gitea.Client) error { return c.MergePull(models.PullRequest{Num: 3, BaseRepo: repo}) },
+			"/api/v1/repos/owner/repo/pulls/3/merge",
+			`{"Do":"merge"}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Equals(t, "POST", r.Method)
+				Equals(t, c.expPath, r.RequestURI)
+				Equals(t, "application/json", r.Header.Get("Content-Type"))
+				body, err := ioutil.ReadAll(r.Body)
+				Ok(t, err)
+				Equals(t, c.expBody, string(body))
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer testServer.Close()
+			Ok(t, c.call(newClient(t, testServer.URL)))
+		})
+	}
+}
+
+func TestClient_ErrorStatusCode(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"token is required"}`, http.StatusUnauthorized)
+	}))
+	defer testServer.Close()
+
+	err := newClient(t, testServer.URL).CreateComment(repo, 3, "comment")
+	Assert(t, err != nil, "expected error")
+	Assert(t, strings.Contains(err.Error(), "unexpected status code: 401"), "got %q", err.Error())
+}
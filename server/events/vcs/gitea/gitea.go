@@ -0,0 +1,22 @@
+// Package gitea holds code for Gitea and Forgejo (a fork of Gitea that
+// supports the same API and webhooks).
+package gitea
+
+const (
+	// EventTypeHeader is the header Gitea sets to the type of webhook event.
+	// Gitea also sends X-GitHub-Event for compatibility so this must be
+	// checked before GitHub's header.
+	EventTypeHeader = "X-Gitea-Event"
+	// DeliveryHeader is the header Gitea sets to the unique ID of the webhook
+	// delivery.
+	DeliveryHeader = "X-Gitea-Delivery"
+	// SignatureHeader is the header Gitea sets to the HMAC SHA256 signature
+	// of the webhook body if a secret is configured.
+	SignatureHeader = "X-Gitea-Signature"
+
+	// PullRequestHeader and IssueCommentHeader are the values of
+	// EventTypeHeader for the events we handle. Gitea sends issue_comment for
+	// comments on pull requests as well as on issues.
+	PullRequestHeader  = "pull_request"
+	IssueCommentHeader = "issue_comment"
+)
@@ -0,0 +1,24 @@
+package gitea_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestValidateSignature(t *testing.T) {
+	body := `{"action":"opened","number":3}`
+	sig := "056719ac6819296394c8b25ad19212e16921e6b9fa42c3382c7d6edde9bfb45f"
+	Ok(t, gitea.ValidateSignature([]byte(body), sig, []byte("mysecret")))
+}
+
+func TestValidateSignature_Invalid(t *testing.T) {
+	body := `{"action":"opened","number":4}`
+	sig := "056719ac6819296394c8b25ad19212e16921e6b9fa42c3382c7d6edde9bfb45f"
+	ErrEquals(t, "payload signature check failed", gitea.ValidateSignature([]byte(body), sig, []byte("mysecret")))
+}
+
+func TestValidateSignature_Missing(t *testing.T) {
+	ErrEquals(t, "missing signature", gitea.ValidateSignature([]byte("{}"), "", []byte("mysecret")))
+}
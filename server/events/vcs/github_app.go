@@ -0,0 +1,253 @@
+package vcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/pkg/errors"
+)
+
+// GithubAppGitUser is the user that git authenticates as when using a GitHub
+// App installation token.
+const GithubAppGitUser = "x-access-token"
+
+const (
+	// githubAppJWTExpiry is how long the JWTs we sign are valid for. GitHub
+	// allows at most 10 minutes.
+	githubAppJWTExpiry = 9 * time.Minute
+	// githubAppJWTClockSkew is how far in the past the JWTs are issued to
+	// allow for our clock being ahead of GitHub's.
+	githubAppJWTClockSkew = time.Minute
+	// githubAppTokenRefresh is how long before an installation token expires
+	// that we get a new one. Tokens are valid for an hour.
+	githubAppTokenRefresh = 5 * time.Minute
+)
+
+// GithubAppCredentials authenticates as a GitHub App. It signs JWTs with the
+// App's private key and exchanges them for installation tokens, one for each
+// owner the App is installed on. The tokens are cached and replaced shortly
+// before they expire.
+type GithubAppCredentials struct {
+	appID      int64
+	key        *rsa.PrivateKey
+	appsClient *github.Client
+
+	mu sync.Mutex
+	// installations maps lowercased owners to their installation ids.
+	installations map[string]int64
+	// tokens maps lowercased owners to their installation tokens.
+	tokens map[string]githubAppToken
+}
+
+type githubAppToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewGithubAppCredentials returns credentials for the GitHub App with appID on
+// hostname. keyFile is the path to the App's PEM encoded private key.
+func NewGithubAppCredentials(hostname string, appID int64, keyFile string) (*GithubAppCredentials, error) {
+	keyPEM, err := ioutil.ReadFile(keyFile) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading GitHub App private key from %s", keyFile)
+	}
+	key, err := parseGithubAppKey(keyPEM)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing GitHub App private key from %s", keyFile)
+	}
+
+	creds := &GithubAppCredentials{
+		appID:         appID,
+		key:           key,
+		installations: make(map[string]int64),
+		tokens:        make(map[string]githubAppToken),
+	}
+	creds.appsClient, err = newGithubAPIClient(hostname, &http.Client{Transport: &githubAppJWTTransport{creds: creds}})
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// AppSlug returns the App's slug. Comments made by the App are from the
+// user <slug>[bot].
+func (c *GithubAppCredentials) AppSlug() (string, error) {
+	app, _, err := c.appsClient.Apps.Get(context.Background(), "")
+	if err != nil {
+		return "", errors.Wrap(err, "getting GitHub App")
+	}
+	// The App's URL is https://<hostname>/apps/<slug>. Our version of the
+	// client doesn't decode the slug field.
+	htmlURL, err := url.Parse(app.GetHTMLURL())
+	if err != nil || path.Base(htmlURL.Path) == "." || path.Base(htmlURL.Path) == "/" {
+		return "", fmt.Errorf("unable to get slug from GitHub App URL %q", app.GetHTMLURL())
+	}
+	return path.Base(htmlURL.Path), nil
+}
+
+// Token returns an installation token for the App's installation on owner.
+// The token can be used to call the API and, with GithubAppGitUser, to clone
+// the owner's repos over HTTPS.
+func (c *GithubAppCredentials) Token(owner string) (string, error) {
+	key := strings.ToLower(owner)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.tokens[key]; ok && time.Until(cached.expiresAt) > githubAppTokenRefresh {
+		return cached.token, nil
+	}
+
+	id, err := c.installationID(key)
+	if err != nil {
+		return "", err
+	}
+	token, _, err := c.appsClient.Apps.CreateInstallationToken(context.Background(), id, nil)
+	if err != nil {
+		// The App may have been uninstalled and reinstalled with a new id.
+		delete(c.installations, key)
+		return "", errors.Wrapf(err, "creating installation token for %s", owner)
+	}
+	c.tokens[key] = githubAppToken{
+		token:     token.GetToken(),
+		expiresAt: token.GetExpiresAt(),
+	}
+	return token.GetToken(), nil
+}
+
+// installationID returns the id of the App's installation on owner, which
+// must be lowercase. c.mu must be held.
+func (c *GithubAppCredentials) installationID(owner string) (int64, error) {
+	if id, ok := c.installations[owner]; ok {
+		return id, nil
+	}
+
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		installations, resp, err := c.appsClient.Apps.ListInstallations(context.Background(), &opts)
+		if err != nil {
+			return 0, errors.Wrap(err, "listing GitHub App installations")
+		}
+		for _, i := range installations {
+			c.installations[strings.ToLower(i.GetAccount().GetLogin())] = i.GetID()
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	id, ok := c.installations[owner]
+	if !ok {
+		return 0, fmt.Errorf("GitHub App %d isn't installed on %s", c.appID, owner)
+	}
+	return id, nil
+}
+
+// jwt returns a JWT signed with the App's key, used to authenticate as the
+// App itself.
+func (c *GithubAppCredentials) jwt() (string, error) {
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-githubAppJWTClockSkew).Unix(),
+		"exp": now.Add(githubAppJWTExpiry).Unix(),
+		"iss": c.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", errors.Wrap(err, "signing JWT")
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// parseGithubAppKey parses a PEM encoded RSA key. GitHub generates PKCS1 keys
+// but we also accept PKCS8 in case the key was converted.
+func parseGithubAppKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("key isn't an RSA key")
+	}
+	return key, nil
+}
+
+// githubAppJWTTransport authenticates requests as the App.
+type githubAppJWTTransport struct {
+	creds *GithubAppCredentials
+}
+
+func (t *githubAppJWTTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.creds.jwt()
+	if err != nil {
+		return nil, err
+	}
+	authed := req.Clone(req.Context())
+	authed.Header.Set("Authorization", "Bearer "+jwt)
+	return http.DefaultTransport.RoundTrip(authed)
+}
+
+// githubAppInstallationTransport authenticates requests with the installation
+// token for the owner of the repo in the request's path.
+type githubAppInstallationTransport struct {
+	creds *GithubAppCredentials
+}
+
+func (t *githubAppInstallationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	owner := repoOwnerFromPath(req.URL.Path)
+	if owner == "" {
+		return nil, fmt.Errorf("can't authenticate request to %s as a GitHub App installation because it isn't for a repo", req.URL.Path)
+	}
+	token, err := t.creds.Token(owner)
+	if err != nil {
+		return nil, err
+	}
+	authed := req.Clone(req.Context())
+	authed.Header.Set("Authorization", "token "+token)
+	return http.DefaultTransport.RoundTrip(authed)
+}
+
+// repoOwnerFromPath returns the owner in an API path of the form
+// .../repos/{owner}/{repo}/... or an empty string if path isn't for a repo.
+func repoOwnerFromPath(path string) string {
+	i := strings.Index(path, "/repos/")
+	if i == -1 {
+		return ""
+	}
+	parts := strings.SplitN(path[i+len("/repos/"):], "/", 2)
+	return parts[0]
+}
@@ -0,0 +1,177 @@
+package vcs_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// githubAppServer fakes the GitHub App endpoints. The installation tokens it
+// creates expire after tokenExpiry.
+type githubAppServer struct {
+	t           *testing.T
+	key         *rsa.PrivateKey
+	tokenExpiry time.Duration
+
+	mu            sync.Mutex
+	tokensCreated int
+}
+
+func (s *githubAppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.HasPrefix(r.URL.Path, "/api/v3/app") {
+		s.verifyJWT(r.Header.Get("Authorization"))
+	}
+	switch r.Method + " " + r.RequestURI {
+	case "GET /api/v3/app":
+		w.Write([]byte(`{"id": 1234, "html_url": "https://github.com/apps/atlantis-app"}`)) // nolint: errcheck
+	case "GET /api/v3/app/installations?per_page=100":
+		w.Write([]byte(`[{"id": 1, "account": {"login": "Owner"}}, {"id": 2, "account": {"login": "other"}}]`)) // nolint: errcheck
+	case "POST /api/v3/app/installations/1/access_tokens":
+		s.tokensCreated++
+		expiresAt := time.Now().Add(s.tokenExpiry).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"token": "token-%d", "expires_at": %q}`, s.tokensCreated, expiresAt)
+	case "GET /api/v3/repos/owner/repo/pulls/1":
+		if auth := r.Header.Get("Authorization"); auth != fmt.Sprintf("token token-%d", s.tokensCreated) {
+			s.t.Errorf("got unexpected authorization %q", auth)
+		}
+		w.Write([]byte(`{"number": 1}`)) // nolint: errcheck
+	default:
+		s.t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+// verifyJWT checks that auth is a JWT for app 1234 signed with s.key.
+func (s *githubAppServer) verifyJWT(auth string) {
+	parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+	if len(parts) != 3 {
+		s.t.Errorf("expected a JWT but got %q", auth)
+		return
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	Ok(s.t, err)
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	Ok(s.t, rsa.VerifyPKCS1v15(&s.key.PublicKey, crypto.SHA256, hash[:], sig))
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	Ok(s.t, err)
+	var claims map[string]int64
+	Ok(s.t, json.Unmarshal(claimsJSON, &claims))
+	Equals(s.t, int64(1234), claims["iss"])
+	Assert(s.t, claims["iat"] < time.Now().Unix(), "exp iat to be in the past")
+	Assert(s.t, claims["exp"] <= time.Now().Add(10*time.Minute).Unix(), "exp JWT to expire within 10 minutes")
+}
+
+func setupGithubApp(t *testing.T, tokenExpiry time.Duration) (*vcs.GithubAppCredentials, *githubAppServer, string, func()) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ok(t, err)
+	tmp, cleanupTmp := TempDir(t)
+	keyFile := filepath.Join(tmp, "key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	Ok(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	appServer := &githubAppServer{t: t, key: key, tokenExpiry: tokenExpiry}
+	testServer := httptest.NewTLSServer(appServer)
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	creds, err := vcs.NewGithubAppCredentials(testServerURL.Host, 1234, keyFile)
+	Ok(t, err)
+	enableSSLVerification := disableSSLVerification()
+	return creds, appServer, testServerURL.Host, func() {
+		enableSSLVerification()
+		testServer.Close()
+		cleanupTmp()
+	}
+}
+
+func TestNewGithubAppCredentials_InvalidKey(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	keyFile := filepath.Join(tmp, "key.pem")
+	Ok(t, ioutil.WriteFile(keyFile, []byte("not a key"), 0600))
+
+	_, err := vcs.NewGithubAppCredentials("github.com", 1234, keyFile)
+	ErrEquals(t, fmt.Sprintf("parsing GitHub App private key from %s: no PEM data found", keyFile), err)
+}
+
+func TestGithubAppCredentials_AppSlug(t *testing.T) {
+	creds, _, _, cleanup := setupGithubApp(t, time.Hour)
+	defer cleanup()
+
+	slug, err := creds.AppSlug()
+	Ok(t, err)
+	Equals(t, "atlantis-app", slug)
+}
+
+// Test that installation tokens are cached until they're about to expire.
+func TestGithubAppCredentials_Token(t *testing.T) {
+	cases := []struct {
+		description string
+		tokenExpiry time.Duration
+		expToken    string
+	}{
+		{
+			"cached",
+			time.Hour,
+			"token-1",
+		},
+		{
+			"refreshed before expiring",
+			2 * time.Minute,
+			"token-2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			creds, _, _, cleanup := setupGithubApp(t, c.tokenExpiry)
+			defer cleanup()
+
+			token, err := creds.Token("owner")
+			Ok(t, err)
+			Equals(t, "token-1", token)
+			token, err = creds.Token("OWNER")
+			Ok(t, err)
+			Equals(t, c.expToken, token)
+		})
+	}
+}
+
+func TestGithubAppCredentials_TokenNotInstalled(t *testing.T) {
+	creds, _, _, cleanup := setupGithubApp(t, time.Hour)
+	defer cleanup()
+
+	_, err := creds.Token("nope")
+	ErrEquals(t, "GitHub App 1234 isn't installed on nope", err)
+}
+
+func TestNewGithubAppClient(t *testing.T) {
+	creds, _, hostname, cleanup := setupGithubApp(t, time.Hour)
+	defer cleanup()
+
+	client, err := vcs.NewGithubAppClient(hostname, creds)
+	Ok(t, err)
+	pull, err := client.GetPullRequest(models.Repo{Owner: "owner", Name: "repo"}, 1)
+	Ok(t, err)
+	Equals(t, 1, pull.GetNumber())
+}
@@ -16,8 +16,10 @@ package vcs
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/vcs/common"
 
@@ -30,6 +32,14 @@ import (
 // by GitHub.
 const maxCommentLength = 65536
 
+// maxCheckRunSummaryLength is the maximum number of chars allowed in the
+// summary of a check run by GitHub.
+const maxCheckRunSummaryLength = 65535
+
+// maxCheckRunAnnotations is the maximum number of annotations GitHub accepts
+// in one request.
+const maxCheckRunAnnotations = 50
+
 // GithubClient is used to perform GitHub actions.
 type GithubClient struct {
 	client *github.Client
@@ -42,7 +52,33 @@ func NewGithubClient(hostname string, user string, pass string) (*GithubClient,
 		Username: strings.TrimSpace(user),
 		Password: strings.TrimSpace(pass),
 	}
-	client := github.NewClient(tp.Client())
+	client, err := newGithubAPIClient(hostname, tp.Client())
+	if err != nil {
+		return nil, err
+	}
+	return &GithubClient{
+		client: client,
+		ctx:    context.Background(),
+	}, nil
+}
+
+// NewGithubAppClient returns a GitHub client that authenticates as creds'
+// App, using the installation token for the owner of each repo.
+func NewGithubAppClient(hostname string, creds *GithubAppCredentials) (*GithubClient, error) {
+	client, err := newGithubAPIClient(hostname, &http.Client{Transport: &githubAppInstallationTransport{creds: creds}})
+	if err != nil {
+		return nil, err
+	}
+	return &GithubClient{
+		client: client,
+		ctx:    context.Background(),
+	}, nil
+}
+
+// newGithubAPIClient returns a go-github client for hostname that sends its
+// requests with httpClient.
+func newGithubAPIClient(hostname string, httpClient *http.Client) (*github.Client, error) {
+	client := github.NewClient(httpClient)
 	// If we're using github.com then we don't need to do any additional configuration
 	// for the client. It we're using Github Enterprise, then we need to manually
 	// set the base url for the API.
@@ -54,11 +90,7 @@ func NewGithubClient(hostname string, user string, pass string) (*GithubClient,
 		}
 		client.BaseURL = base
 	}
-
-	return &GithubClient{
-		client: client,
-		ctx:    context.Background(),
-	}, nil
+	return client, nil
 }
 
 // GetModifiedFiles returns the names of files that were modified in the pull request
@@ -162,6 +194,15 @@ func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest
 	return true, nil
 }
 
+// PullIsClosed returns true if the pull request was closed or merged.
+func (g *GithubClient) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	githubPR, err := g.GetPullRequest(repo, pull.Num)
+	if err != nil {
+		return false, errors.Wrap(err, "getting pull request")
+	}
+	return githubPR.GetState() == "closed", nil
+}
+
 // GetPullRequest returns the pull request.
 func (g *GithubClient) GetPullRequest(repo models.Repo, num int) (*github.PullRequest, error) {
 	pull, _, err := g.client.PullRequests.Get(g.ctx, repo.Owner, repo.Name, num)
@@ -191,6 +232,93 @@ func (g *GithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, s
 	return err
 }
 
+// UpdateCheckRun creates or updates the check run named run.Name on the head
+// commit of pull. If there's already a check run with that name on the commit
+// it's updated so that re-running a command doesn't add another check run.
+func (g *GithubClient) UpdateCheckRun(repo models.Repo, pull models.PullRequest, run CheckRun) error {
+	var status, conclusion *string
+	var completedAt *github.Timestamp
+	switch run.Status {
+	case models.PendingCommitStatus:
+		status = github.String("in_progress")
+	case models.SuccessCommitStatus, models.FailedCommitStatus:
+		status = github.String("completed")
+		conclusion = github.String("success")
+		if run.Status == models.FailedCommitStatus {
+			conclusion = github.String("failure")
+		}
+		completedAt = &github.Timestamp{Time: time.Now()}
+	}
+
+	summary := run.Summary
+	if len(summary) > maxCheckRunSummaryLength {
+		truncatedSuffix := "\n\n**Warning**: Output truncated. See the pull request comments for the full output."
+		summary = summary[:maxCheckRunSummaryLength-len(truncatedSuffix)] + truncatedSuffix
+	}
+	output := &github.CheckRunOutput{
+		Title:   github.String(run.Title),
+		Summary: github.String(summary),
+	}
+	for i, a := range run.Annotations {
+		if i == maxCheckRunAnnotations {
+			break
+		}
+		output.Annotations = append(output.Annotations, &github.CheckRunAnnotation{
+			Path:            github.String(a.Path),
+			StartLine:       github.Int(a.Line),
+			EndLine:         github.Int(a.Line),
+			AnnotationLevel: github.String("failure"),
+			Title:           github.String(a.Title),
+			Message:         github.String(a.Message),
+		})
+	}
+	var actions []*github.CheckRunAction
+	for _, a := range run.Actions {
+		actions = append(actions, &github.CheckRunAction{
+			Label:       a.Label,
+			Description: a.Description,
+			Identifier:  a.Identifier,
+		})
+	}
+	var detailsURL *string
+	if run.DetailsURL != "" {
+		detailsURL = github.String(run.DetailsURL)
+	}
+
+	existing, _, err := g.client.Checks.ListCheckRunsForRef(g.ctx, repo.Owner, repo.Name, pull.HeadCommit, &github.ListCheckRunsOptions{
+		CheckName: github.String(run.Name),
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing check runs")
+	}
+	if len(existing.CheckRuns) > 0 {
+		_, _, err = g.client.Checks.UpdateCheckRun(g.ctx, repo.Owner, repo.Name, existing.CheckRuns[0].GetID(), github.UpdateCheckRunOptions{
+			Name:        run.Name,
+			DetailsURL:  detailsURL,
+			ExternalID:  github.String(run.ExternalID),
+			Status:      status,
+			Conclusion:  conclusion,
+			CompletedAt: completedAt,
+			Output:      output,
+			Actions:     actions,
+		})
+		return errors.Wrap(err, "updating check run")
+	}
+	_, _, err = g.client.Checks.CreateCheckRun(g.ctx, repo.Owner, repo.Name, github.CreateCheckRunOptions{
+		Name:        run.Name,
+		HeadBranch:  pull.HeadBranch,
+		HeadSHA:     pull.HeadCommit,
+		DetailsURL:  detailsURL,
+		ExternalID:  github.String(run.ExternalID),
+		Status:      status,
+		Conclusion:  conclusion,
+		CompletedAt: completedAt,
+		Output:      output,
+		Actions:     actions,
+	})
+	return errors.Wrap(err, "creating check run")
+}
+
 // MergePull merges the pull request.
 func (g *GithubClient) MergePull(pull models.PullRequest) error {
 	// Users can set their repo to disallow certain types of merging.
@@ -195,6 +195,83 @@ func TestGithubClient_UpdateStatus(t *testing.T) {
 	}
 }
 
+// Test that check runs are created the first time and updated after that.
+func TestGithubClient_UpdateCheckRun(t *testing.T) {
+	cases := []struct {
+		description string
+		existing    string
+		expMethod   string
+		expURI      string
+	}{
+		{
+			"create",
+			`{"total_count": 0, "check_runs": []}`,
+			"POST",
+			"/api/v3/repos/owner/repo/check-runs",
+		},
+		{
+			"update",
+			`{"total_count": 1, "check_runs": [{"id": 5}]}`,
+			"PATCH",
+			"/api/v3/repos/owner/repo/check-runs/5",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			called := false
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/repos/owner/repo/commits/sha/check-runs?check_name=atlantis%2Fplan":
+						w.Write([]byte(c.existing)) // nolint: errcheck
+					case c.expURI:
+						Equals(t, c.expMethod, r.Method)
+						body, err := ioutil.ReadAll(r.Body)
+						Ok(t, err)
+						var run map[string]interface{}
+						Ok(t, json.Unmarshal(body, &run))
+						Equals(t, "atlantis/plan", run["name"])
+						Equals(t, "completed", run["status"])
+						Equals(t, "failure", run["conclusion"])
+						Equals(t, "2:atlantis plan", run["external_id"])
+						output := run["output"].(map[string]interface{})
+						Equals(t, "Plan failed.", output["title"])
+						Equals(t, "summary", output["summary"])
+						annotations := output["annotations"].([]interface{})
+						Equals(t, 1, len(annotations))
+						Equals(t, "dir/main.tf", annotations[0].(map[string]interface{})["path"])
+						actions := run["actions"].([]interface{})
+						Equals(t, "replan", actions[0].(map[string]interface{})["identifier"])
+						called = true
+						w.Write([]byte(`{"id": 5}`)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.UpdateCheckRun(models.Repo{Owner: "owner", Name: "repo"}, models.PullRequest{Num: 2, HeadCommit: "sha", HeadBranch: "branch"}, vcs.CheckRun{
+				Name:        "atlantis/plan",
+				Status:      models.FailedCommitStatus,
+				Title:       "Plan failed.",
+				Summary:     "summary",
+				ExternalID:  "2:atlantis plan",
+				Annotations: []vcs.CheckRunAnnotation{{Path: "dir/main.tf", Line: 1, Title: "title", Message: "message"}},
+				Actions:     []vcs.CheckRunAction{{Label: "Re-run", Description: "Run plan again", Identifier: "replan"}},
+			})
+			Ok(t, err)
+			Assert(t, called, "exp check run to be %sd", c.description)
+		})
+	}
+}
+
 func TestGithubClient_PullIsApproved(t *testing.T) {
 	respTemplate := `[
 		{
@@ -373,6 +450,49 @@ func TestGithubClient_PullIsMergeable(t *testing.T) {
 	}
 }
 
+func TestGithubClient_PullIsClosed(t *testing.T) {
+	jsBytes, err := ioutil.ReadFile("fixtures/github-pull-request.json")
+	Ok(t, err)
+	json := string(jsBytes)
+
+	for _, state := range []string{"open", "closed"} {
+		t.Run(state, func(t *testing.T) {
+			response := strings.Replace(json, `"state": "open"`, fmt.Sprintf(`"state": "%s"`, state), 1)
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/repos/owner/repo/pulls/1":
+						w.Write([]byte(response)) // nolint: errcheck
+						return
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+						return
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			closed, err := client.PullIsClosed(models.Repo{
+				FullName: "owner/repo",
+				Owner:    "owner",
+				Name:     "repo",
+				VCSHost: models.VCSHost{
+					Type:     models.Github,
+					Hostname: "github.com",
+				},
+			}, models.PullRequest{
+				Num: 1,
+			})
+			Ok(t, err)
+			Equals(t, state == "closed", closed)
+		})
+	}
+}
+
 func TestGithubClient_MergePullHandlesError(t *testing.T) {
 	cases := []struct {
 		code    int
@@ -175,6 +175,15 @@ func (g *GitlabClient) PullIsMergeable(repo models.Repo, pull models.PullRequest
 	return false, nil
 }
 
+// PullIsClosed returns true if the merge request was closed or merged.
+func (g *GitlabClient) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	mr, err := g.GetMergeRequest(repo.FullName, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	return mr.State == "closed" || mr.State == "merged", nil
+}
+
 // UpdateStatus updates the build status of a commit.
 func (g *GitlabClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
 	gitlabState := gitlab.Failed
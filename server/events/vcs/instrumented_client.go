@@ -0,0 +1,69 @@
+package vcs
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/metrics"
+)
+
+// InstrumentedClient wraps a Client and records the latency and errors of
+// each API call, labelled by VCS host and method.
+type InstrumentedClient struct {
+	Client Client
+	Host   models.VCSHostType
+	// Latency has the labels vcs and method.
+	Latency *metrics.Histogram
+	// Errors has the labels vcs and method.
+	Errors *metrics.Counter
+}
+
+func (i *InstrumentedClient) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	defer i.observe("GetModifiedFiles", time.Now())
+	files, err := i.Client.GetModifiedFiles(repo, pull)
+	return files, i.countErr("GetModifiedFiles", err)
+}
+
+func (i *InstrumentedClient) CreateComment(repo models.Repo, pullNum int, comment string) error {
+	defer i.observe("CreateComment", time.Now())
+	return i.countErr("CreateComment", i.Client.CreateComment(repo, pullNum, comment))
+}
+
+func (i *InstrumentedClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	defer i.observe("PullIsApproved", time.Now())
+	approved, err := i.Client.PullIsApproved(repo, pull)
+	return approved, i.countErr("PullIsApproved", err)
+}
+
+func (i *InstrumentedClient) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
+	defer i.observe("PullIsMergeable", time.Now())
+	mergeable, err := i.Client.PullIsMergeable(repo, pull)
+	return mergeable, i.countErr("PullIsMergeable", err)
+}
+
+func (i *InstrumentedClient) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	defer i.observe("PullIsClosed", time.Now())
+	closed, err := i.Client.PullIsClosed(repo, pull)
+	return closed, i.countErr("PullIsClosed", err)
+}
+
+func (i *InstrumentedClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
+	defer i.observe("UpdateStatus", time.Now())
+	return i.countErr("UpdateStatus", i.Client.UpdateStatus(repo, pull, state, src, description, url))
+}
+
+func (i *InstrumentedClient) MergePull(pull models.PullRequest) error {
+	defer i.observe("MergePull", time.Now())
+	return i.countErr("MergePull", i.Client.MergePull(pull))
+}
+
+func (i *InstrumentedClient) observe(method string, start time.Time) {
+	i.Latency.ObserveSince(start, i.Host.String(), method)
+}
+
+func (i *InstrumentedClient) countErr(method string, err error) error {
+	if err != nil {
+		i.Errors.Inc(i.Host.String(), method)
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package vcs_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestInstrumentedClient(t *testing.T) {
+	RegisterMockTestingT(t)
+	underlying := mocks.NewMockClient()
+	reg := metrics.NewRegistry()
+	client := &vcs.InstrumentedClient{
+		Client:  underlying,
+		Host:    models.Github,
+		Latency: reg.NewHistogram("vcs_api_duration_seconds", "", []float64{1}, "vcs", "method"),
+		Errors:  reg.NewCounter("vcs_api_errors_total", "", "vcs", "method"),
+	}
+	When(underlying.CreateComment(models.Repo{}, 1, "ok")).ThenReturn(nil)
+	When(underlying.CreateComment(models.Repo{}, 1, "err")).ThenReturn(errors.New("err"))
+
+	Ok(t, client.CreateComment(models.Repo{}, 1, "ok"))
+	ErrEquals(t, "err", client.CreateComment(models.Repo{}, 1, "err"))
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	Assert(t, strings.Contains(body, `atlantis_vcs_api_duration_seconds_count{method="CreateComment",vcs="Github"} 2`), "got %s", body)
+	Assert(t, strings.Contains(body, `atlantis_vcs_api_errors_total{method="CreateComment",vcs="Github"} 1`), "got %s", body)
+}
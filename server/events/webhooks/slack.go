@@ -56,3 +56,11 @@ func (s *SlackWebhook) Send(log *logging.SimpleLogger, applyResult ApplyResult)
 	}
 	return s.Client.PostMessage(s.Channel, applyResult)
 }
+
+// SendDrift sends the webhook to Slack if the workspace matches the regex.
+func (s *SlackWebhook) SendDrift(log *logging.SimpleLogger, driftResult DriftResult) error {
+	if !s.WorkspaceRegex.MatchString(driftResult.Workspace) {
+		return nil
+	}
+	return s.Client.PostDriftMessage(s.Channel, driftResult)
+}
@@ -32,6 +32,7 @@ type SlackClient interface {
 	TokenIsSet() bool
 	ChannelExists(channelName string) (bool, error)
 	PostMessage(channel string, applyResult ApplyResult) error
+	PostDriftMessage(channel string, driftResult DriftResult) error
 }
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_underlying_slack_client.go UnderlyingSlackClient
@@ -87,6 +88,15 @@ func (d *DefaultSlackClient) PostMessage(channel string, applyResult ApplyResult
 	return err
 }
 
+func (d *DefaultSlackClient) PostDriftMessage(channel string, driftResult DriftResult) error {
+	params := slack.NewPostMessageParameters()
+	params.Attachments = d.createDriftAttachments(driftResult)
+	params.AsUser = true
+	params.EscapeText = false
+	_, _, err := d.Slack.PostMessage(channel, "", params)
+	return err
+}
+
 func (d *DefaultSlackClient) createAttachments(applyResult ApplyResult) []slack.Attachment {
 	var colour string
 	var successWord string
@@ -128,3 +138,42 @@ func (d *DefaultSlackClient) createAttachments(applyResult ApplyResult) []slack.
 	}
 	return []slack.Attachment{attachment}
 }
+
+func (d *DefaultSlackClient) createDriftAttachments(driftResult DriftResult) []slack.Attachment {
+	colour := slackSuccessColour
+	text := fmt.Sprintf("Drift resolved for %s", driftResult.Repo.FullName)
+	if driftResult.Drifted {
+		colour = slackFailureColour
+		text = fmt.Sprintf("Drift detected for %s", driftResult.Repo.FullName)
+	}
+	directory := driftResult.Directory
+	// Since "." looks weird, replace it with "/" to make it clear this is the root.
+	if directory == "." {
+		directory = "/"
+	}
+
+	fields := []slack.AttachmentField{
+		{
+			Title: "Workspace",
+			Value: driftResult.Workspace,
+			Short: true,
+		},
+		{
+			Title: "Directory",
+			Value: directory,
+			Short: true,
+		},
+	}
+	if driftResult.ProjectName != "" {
+		fields = append(fields, slack.AttachmentField{
+			Title: "Project",
+			Value: driftResult.ProjectName,
+			Short: true,
+		})
+	}
+	return []slack.Attachment{{
+		Color:  colour,
+		Text:   text,
+		Fields: fields,
+	}}
+}
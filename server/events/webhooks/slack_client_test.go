@@ -170,6 +170,59 @@ func TestPostMessage_Error(t *testing.T) {
 	Assert(t, err != nil, "expected error")
 }
 
+func TestPostDriftMessage_Success(t *testing.T) {
+	t.Log("When drift is detected, function should succeed and indicate drift")
+	setup(t)
+
+	driftResult := webhooks.DriftResult{
+		Workspace: "production",
+		Repo: models.Repo{
+			FullName: "runatlantis/atlantis",
+		},
+		Directory: ".",
+		Drifted:   true,
+	}
+	expParams := slack.NewPostMessageParameters()
+	expParams.Attachments = []slack.Attachment{{
+		Color: "danger",
+		Text:  "Drift detected for runatlantis/atlantis",
+		Fields: []slack.AttachmentField{
+			{
+				Title: "Workspace",
+				Value: "production",
+				Short: true,
+			},
+			{
+				Title: "Directory",
+				Value: "/",
+				Short: true,
+			},
+		},
+	}}
+	expParams.AsUser = true
+	expParams.EscapeText = false
+
+	channel := "somechannel"
+	err := client.PostDriftMessage(channel, driftResult)
+	Ok(t, err)
+	underlying.VerifyWasCalledOnce().PostMessage(channel, "", expParams)
+
+	t.Log("When drift is resolved, function should succeed and include the project name")
+	driftResult.Drifted = false
+	driftResult.ProjectName = "myproject"
+	expParams.Attachments[0].Color = "good"
+	expParams.Attachments[0].Text = "Drift resolved for runatlantis/atlantis"
+	expParams.Attachments[0].Fields = append(expParams.Attachments[0].Fields, slack.AttachmentField{
+		Title: "Project",
+		Value: "myproject",
+		Short: true,
+	})
+
+	err = client.PostDriftMessage(channel, driftResult)
+	Ok(t, err)
+	underlying.VerifyWasCalledOnce().PostMessage(channel, "", expParams)
+}
+
 func setup(t *testing.T) {
 	RegisterMockTestingT(t)
 	underlying = mocks.NewMockUnderlyingSlackClient()
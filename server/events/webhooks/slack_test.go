@@ -66,3 +66,29 @@ func TestSend_NoopSuccess(t *testing.T) {
 	Ok(t, err)
 	client.VerifyWasCalled(Never()).PostMessage(channel, result)
 }
+
+func TestSendDrift_PostDriftMessage(t *testing.T) {
+	t.Log("Sending a drift hook should only call PostDriftMessage if the regex matches")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockSlackClient()
+	regex, err := regexp.Compile("prod.*")
+	Ok(t, err)
+
+	channel := "somechannel"
+	hook := webhooks.SlackWebhook{
+		Client:         client,
+		WorkspaceRegex: regex,
+		Channel:        channel,
+	}
+	result := webhooks.DriftResult{
+		Workspace: "production",
+		Drifted:   true,
+	}
+	_ = hook.SendDrift(logging.NewNoopLogger(), result)
+	client.VerifyWasCalledOnce().PostDriftMessage(channel, result)
+
+	result.Workspace = "staging"
+	err = hook.SendDrift(logging.NewNoopLogger(), result)
+	Ok(t, err)
+	client.VerifyWasCalled(Never()).PostDriftMessage(channel, result)
+}
@@ -25,6 +25,7 @@ import (
 
 const SlackKind = "slack"
 const ApplyEvent = "apply"
+const DriftEvent = "drift"
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_sender.go Sender
 
@@ -34,6 +35,14 @@ type Sender interface {
 	Send(log *logging.SimpleLogger, applyResult ApplyResult) error
 }
 
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_drift_sender.go DriftSender
+
+// DriftSender sends webhooks when drift detection finds a project has drifted.
+type DriftSender interface {
+	// SendDrift sends the webhook (if the implementation thinks it should).
+	SendDrift(log *logging.SimpleLogger, driftResult DriftResult) error
+}
+
 // ApplyResult is the result of a terraform apply.
 type ApplyResult struct {
 	Workspace string
@@ -44,9 +53,22 @@ type ApplyResult struct {
 	Directory string
 }
 
+// DriftResult is the result of checking a project on a repo's default branch
+// for drift.
+type DriftResult struct {
+	Workspace   string
+	Repo        models.Repo
+	Directory   string
+	ProjectName string
+	// Drifted is true if drift was detected. It's false if the project had
+	// previously drifted but now matches its configuration again.
+	Drifted bool
+}
+
 // MultiWebhookSender sends multiple webhooks for each one it's configured for.
 type MultiWebhookSender struct {
-	Webhooks []Sender
+	Webhooks      []Sender
+	DriftWebhooks []DriftSender
 }
 
 type Config struct {
@@ -58,6 +80,7 @@ type Config struct {
 
 func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookSender, error) {
 	var webhooks []Sender
+	var driftWebhooks []DriftSender
 	for _, c := range configs {
 		r, err := regexp.Compile(c.WorkspaceRegex)
 		if err != nil {
@@ -66,8 +89,8 @@ func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookS
 		if c.Kind == "" || c.Event == "" {
 			return nil, errors.New("must specify \"kind\" and \"event\" keys for webhooks")
 		}
-		if c.Event != ApplyEvent {
-			return nil, fmt.Errorf("\"event: %s\" not supported. Only \"event: %s\" is supported right now", c.Event, ApplyEvent)
+		if c.Event != ApplyEvent && c.Event != DriftEvent {
+			return nil, fmt.Errorf("\"event: %s\" not supported. Only \"event: %s\" and \"event: %s\" are supported right now", c.Event, ApplyEvent, DriftEvent)
 		}
 		switch c.Kind {
 		case SlackKind:
@@ -81,14 +104,19 @@ func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookS
 			if err != nil {
 				return nil, err
 			}
-			webhooks = append(webhooks, slack)
+			if c.Event == DriftEvent {
+				driftWebhooks = append(driftWebhooks, slack)
+			} else {
+				webhooks = append(webhooks, slack)
+			}
 		default:
 			return nil, fmt.Errorf("\"kind: %s\" not supported. Only \"kind: %s\" is supported right now", c.Kind, SlackKind)
 		}
 	}
 
 	return &MultiWebhookSender{
-		Webhooks: webhooks,
+		Webhooks:      webhooks,
+		DriftWebhooks: driftWebhooks,
 	}, nil
 }
 
@@ -101,3 +129,13 @@ func (w *MultiWebhookSender) Send(log *logging.SimpleLogger, result ApplyResult)
 	}
 	return nil
 }
+
+// SendDrift sends the webhook using its DriftWebhooks.
+func (w *MultiWebhookSender) SendDrift(log *logging.SimpleLogger, result DriftResult) error {
+	for _, w := range w.DriftWebhooks {
+		if err := w.SendDrift(log, result); err != nil {
+			log.Warn("error sending slack webhook: %s", err)
+		}
+	}
+	return nil
+}
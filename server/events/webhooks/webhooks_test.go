@@ -20,6 +20,7 @@ import (
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
 	"github.com/runatlantis/atlantis/server/events/webhooks/mocks"
+	"github.com/runatlantis/atlantis/server/events/webhooks/mocks/matchers"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -78,7 +79,7 @@ func TestNewWebhooksManager_UnsupportedEvent(t *testing.T) {
 	configs[0].Event = unsupportedEvent
 	_, err := webhooks.NewMultiWebhookSender(configs, client)
 	Assert(t, err != nil, "expected error")
-	Equals(t, "\"event: badevent\" not supported. Only \"event: apply\" is supported right now", err.Error())
+	Equals(t, "\"event: badevent\" not supported. Only \"event: apply\" and \"event: drift\" are supported right now", err.Error())
 }
 
 func TestNewWebhooksManager_NoKind(t *testing.T) {
@@ -154,6 +155,21 @@ func TestNewWebhooksManager_MultipleConfigSuccess(t *testing.T) {
 	Equals(t, nConfigs, len(m.Webhooks))
 }
 
+func TestNewWebhooksManager_DriftConfigSuccess(t *testing.T) {
+	t.Log("Drift webhooks should be kept separate from apply webhooks")
+	RegisterMockTestingT(t)
+	client := mocks.NewMockSlackClient()
+	When(client.TokenIsSet()).ThenReturn(true)
+	When(client.ChannelExists(validChannel)).ThenReturn(true, nil)
+
+	driftConfig := validConfig
+	driftConfig.Event = webhooks.DriftEvent
+	m, err := webhooks.NewMultiWebhookSender([]webhooks.Config{validConfig, driftConfig}, client)
+	Ok(t, err)
+	Equals(t, 1, len(m.Webhooks))
+	Equals(t, 1, len(m.DriftWebhooks))
+}
+
 func TestSend_SingleSuccess(t *testing.T) {
 	t.Log("Sending one webhook should succeed")
 	RegisterMockTestingT(t)
@@ -186,3 +202,25 @@ func TestSend_MultipleSuccess(t *testing.T) {
 		s.VerifyWasCalledOnce().Send(logger, result)
 	}
 }
+
+func TestSendDrift_MultipleSuccess(t *testing.T) {
+	t.Log("Sending multiple drift webhooks should succeed")
+	RegisterMockTestingT(t)
+	applySender := mocks.NewMockSender()
+	senders := []*mocks.MockDriftSender{
+		mocks.NewMockDriftSender(),
+		mocks.NewMockDriftSender(),
+	}
+	manager := webhooks.MultiWebhookSender{
+		Webhooks:      []webhooks.Sender{applySender},
+		DriftWebhooks: []webhooks.DriftSender{senders[0], senders[1]},
+	}
+	logger := logging.NewNoopLogger()
+	result := webhooks.DriftResult{Drifted: true}
+	err := manager.SendDrift(logger, result)
+	Ok(t, err)
+	for _, s := range senders {
+		s.VerifyWasCalledOnce().SendDrift(logger, result)
+	}
+	applySender.VerifyWasCalled(Never()).Send(matchers.AnyPtrToLoggingSimpleLogger(), matchers.AnyWebhooksApplyResult())
+}
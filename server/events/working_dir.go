@@ -15,11 +15,14 @@ package events
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -28,6 +31,15 @@ import (
 
 const workingDirPrefix = "repos"
 
+// defaultBranchDirName is the name of the dir, under the repo's dir, that the
+// repo's default branch is cloned into. It can't clash with a pull request's
+// dir because those are always numbers.
+const defaultBranchDirName = "default-branch"
+
+// hasDivergedFetchDepth is how many commits of the pull request's branch
+// HasDiverged fetches to look for the tip of the base branch in.
+const hasDivergedFetchDepth = 250
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_working_dir.go WorkingDir
 //go:generate pegomock generate -m --use-experimental-model-gen --package events WorkingDir
 
@@ -45,6 +57,27 @@ type WorkingDir interface {
 	// Delete deletes the workspace for this repo and pull.
 	Delete(r models.Repo, p models.PullRequest) error
 	DeleteForWorkspace(r models.Repo, p models.PullRequest, workspace string) error
+	// CloneDefaultBranch git clones the default branch of repo and returns
+	// the absolute path to the root of the cloned repo.
+	CloneDefaultBranch(log *logging.SimpleLogger, repo models.Repo) (string, error)
+	// HasDiverged returns true if the pull request's base branch has new
+	// commits since the workspace was cloned, i.e. the commit it was cloned
+	// against isn't the current tip of the base branch.
+	HasDiverged(log *logging.SimpleLogger, headRepo models.Repo, p models.PullRequest, workspace string) (bool, error)
+	// GetBaseCommit returns the commit of the base branch that the pull
+	// request was merged into when it was cloned. It returns an empty string
+	// if we're not using the merge checkout strategy.
+	GetBaseCommit(p models.PullRequest, workspace string) (string, error)
+	// ResolveRef returns the commit that the branch or tag ref of repo
+	// currently points to.
+	ResolveRef(log *logging.SimpleLogger, repo models.Repo, ref string) (string, error)
+}
+
+// GithubAppTokens returns GitHub App installation tokens. It's implemented by
+// vcs.GithubAppCredentials.
+type GithubAppTokens interface {
+	// Token returns an unexpired installation token for owner.
+	Token(owner string) (string, error)
 }
 
 // FileWorkspace implements WorkingDir with the file system.
@@ -61,6 +94,24 @@ type FileWorkspace struct {
 	// TestingOverrideBaseCloneURL can be used during testing to override the
 	// URL of the base repo to be cloned. If it's empty then we clone normally.
 	TestingOverrideBaseCloneURL string
+	// GithubAppTokens is set if we're authenticating with GitHub as a GitHub
+	// App. Installation tokens expire after an hour so each git command is
+	// given a current one through its environment, see gitEnv.
+	GithubAppTokens GithubAppTokens
+	// cloneDirLocksMu guards cloneDirLocks.
+	cloneDirLocksMu sync.Mutex
+	// cloneDirLocks maps clone dirs that are in use to their locks. When
+	// projects are run in parallel they may share the same workspace on disk
+	// and we must not re-clone or fetch into it while another project is
+	// cloning it. Clones of other dirs can run at the same time.
+	cloneDirLocks map[string]*cloneDirLock
+}
+
+// cloneDirLock is the lock for a clone dir. refs is the number of goroutines
+// holding or waiting for it so that it can be removed when it's unused.
+type cloneDirLock struct {
+	mu   sync.Mutex
+	refs int
 }
 
 // Clone git clones headRepo, checks out the branch and then returns the absolute
@@ -74,6 +125,8 @@ func (w *FileWorkspace) Clone(
 	p models.PullRequest,
 	workspace string) (string, bool, error) {
 	cloneDir := w.cloneDir(baseRepo, p, workspace)
+	defer w.lockCloneDir(cloneDir)()
+	defer w.markPullDirUsed(log, baseRepo, p)
 
 	// If the directory already exists, check if it's at the right commit.
 	// If so, then we do nothing.
@@ -102,7 +155,7 @@ func (w *FileWorkspace) Clone(
 		// commit, only a 12 character prefix.
 		if strings.HasPrefix(currCommit, p.HeadCommit) {
 			log.Debug("repo is at correct commit %q so will not re-clone", p.HeadCommit)
-			return cloneDir, w.warnDiverged(log, cloneDir), nil
+			return cloneDir, w.warnDiverged(log, cloneDir, p.BaseRepo), nil
 		}
 
 		log.Debug("repo was already cloned but is not at correct commit, wanted %q got %q", p.HeadCommit, currCommit)
@@ -120,7 +173,7 @@ func (w *FileWorkspace) Clone(
 // Then users won't be getting the merge functionality they expected.
 // If there are any errors we return false since we prefer things to succeed
 // vs. stopping the plan/apply.
-func (w *FileWorkspace) warnDiverged(log *logging.SimpleLogger, cloneDir string) bool {
+func (w *FileWorkspace) warnDiverged(log *logging.SimpleLogger, cloneDir string, baseRepo models.Repo) bool {
 	if !w.CheckoutMerge {
 		// It only makes sense to warn that master has diverged if we're using
 		// the checkout merge strategy. If we're just checking out the branch,
@@ -130,8 +183,14 @@ func (w *FileWorkspace) warnDiverged(log *logging.SimpleLogger, cloneDir string)
 	}
 
 	// Bring our remote refs up to date.
+	env, err := w.gitEnv(baseRepo)
+	if err != nil {
+		log.Warn("getting remote update failed: %s", err)
+		return false
+	}
 	remoteUpdateCmd := exec.Command("git", "remote", "update")
 	remoteUpdateCmd.Dir = cloneDir
+	remoteUpdateCmd.Env = env
 	outputRemoteUpdate, err := remoteUpdateCmd.CombinedOutput()
 	if err != nil {
 		log.Warn("getting remote update failed: %s", string(outputRemoteUpdate))
@@ -171,6 +230,11 @@ func (w *FileWorkspace) forceClone(log *logging.SimpleLogger,
 		return errors.Wrap(err, "creating new workspace")
 	}
 
+	p.BaseRepo, headRepo, err = w.withoutGithubAppUser(p.BaseRepo, headRepo)
+	if err != nil {
+		return err
+	}
+
 	// During testing, we mock some of this out.
 	headCloneURL := headRepo.CloneURL
 	if w.TestingOverrideHeadCloneURL != "" {
@@ -215,21 +279,229 @@ func (w *FileWorkspace) forceClone(log *logging.SimpleLogger,
 		}
 	}
 
+	return w.runGitCmds(log, cloneDir, cmds, p.BaseRepo, headRepo)
+}
+
+// CloneDefaultBranch git clones the default branch of repo and returns the
+// absolute path to the root of the cloned repo. Unlike Clone, it always
+// re-clones so that we're running off the latest commit.
+func (w *FileWorkspace) CloneDefaultBranch(log *logging.SimpleLogger, repo models.Repo) (string, error) {
+	cloneDir := filepath.Join(w.DataDir, workingDirPrefix, repo.FullName, defaultBranchDirName)
+	defer w.lockCloneDir(cloneDir)()
+	if err := os.RemoveAll(cloneDir); err != nil {
+		return "", errors.Wrapf(err, "deleting dir %q before cloning", cloneDir)
+	}
+	log.Info("creating dir %q", cloneDir)
+	if err := os.MkdirAll(cloneDir, 0700); err != nil {
+		return "", errors.Wrap(err, "creating new workspace")
+	}
+
+	repo, _, err := w.withoutGithubAppUser(repo, repo)
+	if err != nil {
+		return "", err
+	}
+
+	// During testing, we mock some of this out.
+	cloneURL := repo.CloneURL
+	if w.TestingOverrideHeadCloneURL != "" {
+		cloneURL = w.TestingOverrideHeadCloneURL
+	}
+
+	// Not specifying a branch means git will check out the default branch.
+	cmds := [][]string{
+		{
+			"git", "clone", "--depth=1", "--single-branch", cloneURL, cloneDir,
+		},
+	}
+	return cloneDir, w.runGitCmds(log, cloneDir, cmds, repo, repo)
+}
+
+// HasDiverged returns true if the base branch has new commits that weren't
+// in the base we cloned. With the merge checkout strategy that base is the
+// first parent of our merge commit. Otherwise it's the merge base of the
+// pull request's branch and the base branch.
+func (w *FileWorkspace) HasDiverged(log *logging.SimpleLogger, headRepo models.Repo, p models.PullRequest, workspace string) (bool, error) {
+	cloneDir := w.cloneDir(p.BaseRepo, p, workspace)
+	defer w.lockCloneDir(cloneDir)()
+	if _, err := os.Stat(cloneDir); err != nil {
+		return false, errors.Wrap(err, "checking if workspace exists")
+	}
+	var err error
+	p.BaseRepo, headRepo, err = w.withoutGithubAppUser(p.BaseRepo, headRepo)
+	if err != nil {
+		return false, err
+	}
+	// We use the repos' URLs rather than the clone's remotes since clones
+	// made by older versions of Atlantis have the credentials they were
+	// cloned with, which may have expired, in their remotes.
+	baseCloneURL := p.BaseRepo.CloneURL
+	if w.TestingOverrideBaseCloneURL != "" {
+		baseCloneURL = w.TestingOverrideBaseCloneURL
+	}
+	headCloneURL := headRepo.CloneURL
+	if w.TestingOverrideHeadCloneURL != "" {
+		headCloneURL = w.TestingOverrideHeadCloneURL
+	}
+
+	// Get the current tip of the base branch.
+	out, err := w.gitOutput(cloneDir, p.BaseRepo, headRepo, "git", "ls-remote", baseCloneURL, "refs/heads/"+p.BaseBranch)
+	if err != nil {
+		return false, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return false, fmt.Errorf("base branch %q not found", p.BaseBranch)
+	}
+	baseTip := fields[0]
+
+	if w.CheckoutMerge {
+		clonedBase, err := w.gitOutput(cloneDir, p.BaseRepo, headRepo, "git", "rev-parse", "HEAD^1")
+		if err != nil {
+			return false, err
+		}
+		clonedBase = strings.TrimSpace(clonedBase)
+		log.Debug("base branch tip is %s, cloned base is %s", baseTip, clonedBase)
+		return clonedBase != baseTip, nil
+	}
+
+	// We clone the pull request's branch without history so we need to fetch
+	// some of it, along with the tip of the base branch, to find out if the
+	// branch contains the tip of the base branch. If the tip is further back
+	// than we fetch, the branch is treated as having diverged.
+	depth := fmt.Sprintf("--depth=%d", hasDivergedFetchDepth)
+	if _, err := w.gitOutput(cloneDir, p.BaseRepo, headRepo, "git", "fetch", "-q", depth, headCloneURL, "refs/heads/"+p.HeadBranch); err != nil {
+		return false, err
+	}
+	if _, err := w.gitOutput(cloneDir, p.BaseRepo, headRepo, "git", "fetch", "-q", "--depth=1", baseCloneURL, "refs/heads/"+p.BaseBranch); err != nil {
+		return false, err
+	}
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", baseTip, "HEAD") // #nosec
+	cmd.Dir = cloneDir
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			log.Debug("base branch tip %s is not in the pull request's branch", baseTip)
+			return true, nil
+		}
+		return false, errors.Wrap(err, "running git merge-base")
+	}
+	return false, nil
+}
+
+// lockCloneDir waits for the lock on cloneDir and returns the function to
+// unlock it.
+func (w *FileWorkspace) lockCloneDir(cloneDir string) func() {
+	w.cloneDirLocksMu.Lock()
+	if w.cloneDirLocks == nil {
+		w.cloneDirLocks = make(map[string]*cloneDirLock)
+	}
+	l, ok := w.cloneDirLocks[cloneDir]
+	if !ok {
+		l = &cloneDirLock{}
+		w.cloneDirLocks[cloneDir] = l
+	}
+	l.refs++
+	w.cloneDirLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		w.cloneDirLocksMu.Lock()
+		defer w.cloneDirLocksMu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(w.cloneDirLocks, cloneDir)
+		}
+	}
+}
+
+// GetBaseCommit returns the first parent of our merge commit if we're using
+// the merge checkout strategy.
+func (w *FileWorkspace) GetBaseCommit(p models.PullRequest, workspace string) (string, error) {
+	if !w.CheckoutMerge {
+		return "", nil
+	}
+	cloneDir := w.cloneDir(p.BaseRepo, p, workspace)
+	out, err := w.gitOutput(cloneDir, p.BaseRepo, p.BaseRepo, "git", "rev-parse", "HEAD^1")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ResolveRef returns the commit that ref points to in repo. If ref is both a
+// branch and a tag, the branch is used since that's what git clone --branch
+// checks out.
+func (w *FileWorkspace) ResolveRef(log *logging.SimpleLogger, repo models.Repo, ref string) (string, error) {
+	repo, _, err := w.withoutGithubAppUser(repo, repo)
+	if err != nil {
+		return "", err
+	}
+	cloneURL := repo.CloneURL
+	if w.TestingOverrideHeadCloneURL != "" {
+		cloneURL = w.TestingOverrideHeadCloneURL
+	}
+
+	branch := "refs/heads/" + ref
+	tag := "refs/tags/" + ref
+	// Annotated tags point to a tag object, ref^{} is the commit it points to.
+	peeledTag := tag + "^{}"
+	out, err := w.gitOutput("", repo, repo, "git", "ls-remote", cloneURL, branch, tag, peeledTag)
+	if err != nil {
+		return "", err
+	}
+	commits := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			commits[fields[1]] = fields[0]
+		}
+	}
+	for _, name := range []string{branch, peeledTag, tag} {
+		if commit, ok := commits[name]; ok {
+			log.Debug("ref %q is at commit %s", ref, commit)
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("no branch or tag named %q in %s", ref, repo.FullName)
+}
+
+// gitOutput runs args in cloneDir and returns its output. Credentials for
+// baseRepo and headRepo are redacted from any errors.
+func (w *FileWorkspace) gitOutput(cloneDir string, baseRepo models.Repo, headRepo models.Repo, args ...string) (string, error) {
+	env, err := w.gitEnv(baseRepo)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(args[0], args[1:]...) // nolint: gosec
+	cmd.Dir = cloneDir
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		cmdStr := w.sanitizeGitCredentials(strings.Join(cmd.Args, " "), baseRepo, headRepo)
+		sanitizedOutput := w.sanitizeGitCredentials(string(output), baseRepo, headRepo)
+		sanitizedErrMsg := w.sanitizeGitCredentials(err.Error(), baseRepo, headRepo)
+		return "", fmt.Errorf("running %s: %s: %s", cmdStr, sanitizedOutput, sanitizedErrMsg)
+	}
+	return string(output), nil
+}
+
+// runGitCmds runs each of cmds in cloneDir, stopping at the first error.
+// Credentials for baseRepo and headRepo are redacted from any output.
+func (w *FileWorkspace) runGitCmds(log *logging.SimpleLogger, cloneDir string, cmds [][]string, baseRepo models.Repo, headRepo models.Repo) error {
+	env, err := w.gitEnv(baseRepo)
+	if err != nil {
+		return err
+	}
 	for _, args := range cmds {
 		cmd := exec.Command(args[0], args[1:]...) // nolint: gosec
 		cmd.Dir = cloneDir
-		// The git merge command requires these env vars are set.
-		cmd.Env = append(os.Environ(), []string{
-			"EMAIL=atlantis@runatlantis.io",
-			"GIT_AUTHOR_NAME=atlantis",
-			"GIT_COMMITTER_NAME=atlantis",
-		}...)
-
-		cmdStr := w.sanitizeGitCredentials(strings.Join(cmd.Args, " "), p.BaseRepo, headRepo)
+		cmd.Env = env
+
+		cmdStr := w.sanitizeGitCredentials(strings.Join(cmd.Args, " "), baseRepo, headRepo)
 		output, err := cmd.CombinedOutput()
-		sanitizedOutput := w.sanitizeGitCredentials(string(output), p.BaseRepo, headRepo)
+		sanitizedOutput := w.sanitizeGitCredentials(string(output), baseRepo, headRepo)
 		if err != nil {
-			sanitizedErrMsg := w.sanitizeGitCredentials(err.Error(), p.BaseRepo, headRepo)
+			sanitizedErrMsg := w.sanitizeGitCredentials(err.Error(), baseRepo, headRepo)
 			return fmt.Errorf("running %s: %s: %s", cmdStr, sanitizedOutput, sanitizedErrMsg)
 		}
 		log.Debug("ran: %s. Output: %s", cmdStr, strings.TrimSuffix(sanitizedOutput, "\n"))
@@ -266,6 +538,16 @@ func (w *FileWorkspace) DeleteForWorkspace(r models.Repo, p models.PullRequest,
 	return os.RemoveAll(w.cloneDir(r, p, workspace))
 }
 
+// markPullDirUsed sets the modification time of the pull request's dir to now.
+// The stale lock reaper doesn't delete dirs that were used recently since the
+// command that cloned them may not have locked its projects yet.
+func (w *FileWorkspace) markPullDirUsed(log *logging.SimpleLogger, r models.Repo, p models.PullRequest) {
+	now := time.Now()
+	if err := os.Chtimes(w.repoPullDir(r, p), now, now); err != nil && !os.IsNotExist(err) {
+		log.Warn("unable to update modification time of pull request dir: %s", err)
+	}
+}
+
 func (w *FileWorkspace) repoPullDir(r models.Repo, p models.PullRequest) string {
 	return filepath.Join(w.DataDir, workingDirPrefix, r.FullName, strconv.Itoa(p.Num))
 }
@@ -274,6 +556,49 @@ func (w *FileWorkspace) cloneDir(r models.Repo, p models.PullRequest, workspace
 	return filepath.Join(w.repoPullDir(r, p), workspace)
 }
 
+// withoutGithubAppUser returns baseRepo and headRepo without the GitHub App's
+// git user in their clone URLs if we're authenticating as a GitHub App. If a
+// URL has a user, git uses it with an empty password rather than asking the
+// credential helper set up by gitEnv for the token.
+func (w *FileWorkspace) withoutGithubAppUser(baseRepo models.Repo, headRepo models.Repo) (models.Repo, models.Repo, error) {
+	if w.GithubAppTokens == nil || baseRepo.VCSHost.Type != models.Github {
+		return baseRepo, headRepo, nil
+	}
+	for _, repo := range []*models.Repo{&baseRepo, &headRepo} {
+		cloneURL, err := url.Parse(repo.CloneURL)
+		if err != nil {
+			return baseRepo, headRepo, errors.Wrapf(err, "parsing clone URL of %s", repo.FullName)
+		}
+		cloneURL.User = nil
+		repo.CloneURL = cloneURL.String()
+	}
+	return baseRepo, headRepo, nil
+}
+
+// gitEnv returns the environment to run git commands for repo in. If we're
+// authenticating as a GitHub App it has a current installation token for
+// repo's owner. The token is also used for forks of repo since the App is
+// installed on the base repo's owner.
+func (w *FileWorkspace) gitEnv(repo models.Repo) ([]string, error) {
+	// The git merge command requires these env vars are set.
+	env := append(os.Environ(), []string{
+		"EMAIL=atlantis@runatlantis.io",
+		"GIT_AUTHOR_NAME=atlantis",
+		"GIT_COMMITTER_NAME=atlantis",
+	}...)
+	if w.GithubAppTokens == nil {
+		return env, nil
+	}
+	appEnv, err := GithubAppGitEnv(w.GithubAppTokens, repo)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range appEnv {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env, nil
+}
+
 // sanitizeGitCredentials replaces any git clone urls that contain credentials
 // in s with the sanitized versions.
 func (w *FileWorkspace) sanitizeGitCredentials(s string, base models.Repo, head models.Repo) string {
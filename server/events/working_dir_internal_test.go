@@ -0,0 +1,40 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Test that a clone dir's lock only blocks other users of the same dir and
+// that it's removed once it's unused.
+func TestLockCloneDir(t *testing.T) {
+	w := &FileWorkspace{}
+	unlockA := w.lockCloneDir("a")
+
+	// Another dir isn't blocked.
+	unlockB := w.lockCloneDir("b")
+	unlockB()
+
+	locked := make(chan struct{})
+	go func() {
+		w.lockCloneDir("a")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("exp lock on a to wait until it's unlocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlockA()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("exp lock on a to be acquired after it's unlocked")
+	}
+
+	w.cloneDirLocksMu.Lock()
+	defer w.cloneDirLocksMu.Unlock()
+	Equals(t, 0, len(w.cloneDirLocks))
+}
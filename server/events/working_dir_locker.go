@@ -32,6 +32,14 @@ type WorkingDirLocker interface {
 	// an error if the workspace is already locked. The error is expected to
 	// be printed to the pull request.
 	TryLock(repoFullName string, pullNum int, workspace string) (func(), error)
+	// TryLockPath tries to acquire a lock for a single project path within
+	// this repo, pull and workspace. It allows commands for different
+	// projects in the same workspace to run concurrently while still
+	// conflicting with a lock on the whole workspace or pull.
+	// It returns a function that should be used to unlock the path and
+	// an error if the path is already locked. The error is expected to
+	// be printed to the pull request.
+	TryLockPath(repoFullName string, pullNum int, workspace string, path string) (func(), error)
 	// TryLockPull tries to acquire a lock for all the workspaces in this repo
 	// and pull.
 	// It returns a function that should be used to unlock the workspace and
@@ -81,7 +89,9 @@ func (d *DefaultWorkingDirLocker) TryLock(repoFullName string, pullNum int, work
 	pullKey := d.pullKey(repoFullName, pullNum)
 	workspaceKey := d.workspaceKey(repoFullName, pullNum, workspace)
 	for _, l := range d.locks {
-		if l == pullKey || l == workspaceKey {
+		// A lock on the workspace also conflicts with any path locks held
+		// within that workspace.
+		if l == pullKey || l == workspaceKey || strings.HasPrefix(l, workspaceKey+"/") {
 			return func() {}, fmt.Errorf("the %s workspace is currently locked by another"+
 				" command that is running for this pull request–"+
 				"wait until the previous command is complete and try again", workspace)
@@ -93,6 +103,26 @@ func (d *DefaultWorkingDirLocker) TryLock(repoFullName string, pullNum int, work
 	}, nil
 }
 
+func (d *DefaultWorkingDirLocker) TryLockPath(repoFullName string, pullNum int, workspace string, path string) (func(), error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	pullKey := d.pullKey(repoFullName, pullNum)
+	workspaceKey := d.workspaceKey(repoFullName, pullNum, workspace)
+	pathKey := d.pathKey(repoFullName, pullNum, workspace, path)
+	for _, l := range d.locks {
+		if l == pullKey || l == workspaceKey || l == pathKey {
+			return func() {}, fmt.Errorf("the %s workspace at path %s is currently locked by another"+
+				" command that is running for this pull request–"+
+				"wait until the previous command is complete and try again", workspace, path)
+		}
+	}
+	d.locks = append(d.locks, pathKey)
+	return func() {
+		d.unlockPath(repoFullName, pullNum, workspace, path)
+	}, nil
+}
+
 // Unlock unlocks the workspace for this pull.
 func (d *DefaultWorkingDirLocker) unlock(repoFullName string, pullNum int, workspace string) {
 	d.mutex.Lock()
@@ -102,6 +132,15 @@ func (d *DefaultWorkingDirLocker) unlock(repoFullName string, pullNum int, works
 	d.removeLock(workspaceKey)
 }
 
+// unlockPath unlocks the path in the workspace for this pull.
+func (d *DefaultWorkingDirLocker) unlockPath(repoFullName string, pullNum int, workspace string, path string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	pathKey := d.pathKey(repoFullName, pullNum, workspace, path)
+	d.removeLock(pathKey)
+}
+
 // Unlock unlocks all workspaces for this pull.
 func (d *DefaultWorkingDirLocker) UnlockPull(repoFullName string, pullNum int) {
 	d.mutex.Lock()
@@ -125,6 +164,10 @@ func (d *DefaultWorkingDirLocker) workspaceKey(repo string, pull int, workspace
 	return fmt.Sprintf("%s/%s", d.pullKey(repo, pull), workspace)
 }
 
+func (d *DefaultWorkingDirLocker) pathKey(repo string, pull int, workspace string, path string) string {
+	return fmt.Sprintf("%s/%s", d.workspaceKey(repo, pull, workspace), path)
+}
+
 func (d *DefaultWorkingDirLocker) pullKey(repo string, pull int) string {
 	return fmt.Sprintf("%s/%d", repo, pull)
 }
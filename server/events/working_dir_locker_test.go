@@ -196,3 +196,45 @@ func TestLockPull_WorkspaceFirst(t *testing.T) {
 	_, err = locker.TryLockPull("owner/repo", 1)
 	Ok(t, err)
 }
+
+// Different paths in the same workspace should be lockable at the same time.
+func TestTryLockPath_DifferentPaths(t *testing.T) {
+	locker := events.NewDefaultWorkingDirLocker()
+	unlock1, err := locker.TryLockPath("owner/repo", 1, "workspace", "dir1")
+	Ok(t, err)
+	unlock2, err := locker.TryLockPath("owner/repo", 1, "workspace", "dir2")
+	Ok(t, err)
+
+	// The same path should fail.
+	_, err = locker.TryLockPath("owner/repo", 1, "workspace", "dir1")
+	Assert(t, err != nil, "exp err")
+
+	// After unlocking, we should be able to get it again.
+	unlock1()
+	_, err = locker.TryLockPath("owner/repo", 1, "workspace", "dir1")
+	Ok(t, err)
+	unlock2()
+}
+
+// Path locks should conflict with workspace and pull locks.
+func TestTryLockPath_WorkspaceAndPull(t *testing.T) {
+	locker := events.NewDefaultWorkingDirLocker()
+	unlock, err := locker.TryLockPath("owner/repo", 1, "workspace", "dir1")
+	Ok(t, err)
+
+	_, err = locker.TryLock("owner/repo", 1, "workspace")
+	Assert(t, err != nil, "exp err")
+	_, err = locker.TryLockPull("owner/repo", 1)
+	Assert(t, err != nil, "exp err")
+
+	// A different workspace shouldn't be affected.
+	_, err = locker.TryLock("owner/repo", 1, "workspace2")
+	Ok(t, err)
+
+	unlock()
+	unlockWorkspace, err := locker.TryLock("owner/repo", 1, "workspace")
+	Ok(t, err)
+	_, err = locker.TryLockPath("owner/repo", 1, "workspace", "dir1")
+	Assert(t, err != nil, "exp err")
+	unlockWorkspace()
+}
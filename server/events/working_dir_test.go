@@ -2,9 +2,16 @@ package events_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -37,6 +44,46 @@ func TestClone_NoneExisting(t *testing.T) {
 	Equals(t, expCommit, actCommit)
 }
 
+// Test that concurrent clones into the same and different dirs all succeed.
+func TestClone_Concurrent(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	expCommit := runCmd(t, repoDir, "git", "rev-parse", "branch")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	wd := &events.FileWorkspace{
+		DataDir:                     dataDir,
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for _, workspace := range []string{"default", "staging"} {
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(workspace string) {
+				defer wg.Done()
+				_, _, err := wd.Clone(nil, models.Repo{}, models.Repo{}, models.PullRequest{
+					HeadBranch: "branch",
+					HeadCommit: strings.TrimSpace(expCommit),
+				}, workspace)
+				errs <- err
+			}(workspace)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Ok(t, err)
+	}
+	for _, workspace := range []string{"default", "staging"} {
+		cloneDir, err := wd.GetWorkingDir(models.Repo{}, models.PullRequest{}, workspace)
+		Ok(t, err)
+		Equals(t, expCommit, runCmd(t, cloneDir, "git", "rev-parse", "HEAD"))
+	}
+}
+
 // Test that if we don't have any existing files, we check out the repo
 // successfully when we're using the merge method.
 func TestClone_CheckoutMergeNoneExisting(t *testing.T) {
@@ -249,6 +296,8 @@ func TestClone_NoReclone(t *testing.T) {
 	runCmd(t, dataDir, "mv", repoDir, "repos/0/default")
 	// Create a file that we can use later to check if the repo was recloned.
 	runCmd(t, dataDir, "touch", "repos/0/default/proof")
+	old := time.Now().Add(-time.Hour)
+	Ok(t, os.Chtimes(filepath.Join(dataDir, "repos/0"), old, old))
 
 	wd := &events.FileWorkspace{
 		DataDir:                     dataDir,
@@ -264,6 +313,12 @@ func TestClone_NoReclone(t *testing.T) {
 	// Check that our proof file is still there.
 	_, err = os.Stat(filepath.Join(cloneDir, "proof"))
 	Ok(t, err)
+
+	// Check that the pull dir is marked as used so the stale lock reaper
+	// doesn't delete it.
+	info, err := os.Stat(filepath.Join(dataDir, "repos/0"))
+	Ok(t, err)
+	Assert(t, info.ModTime().After(old), "exp pull dir's modification time to be updated")
 }
 
 // Test that if the repo is already cloned but is at the wrong commit, we
@@ -373,6 +428,292 @@ func TestClone_MasterHasDiverged(t *testing.T) {
 	Equals(t, hasDiverged, false)
 }
 
+// Test that HasDiverged returns true once the base branch has commits that
+// weren't there when we cloned, for both checkout strategies.
+func TestHasDiverged(t *testing.T) {
+	for _, checkoutMerge := range []bool{true, false} {
+		t.Run(fmt.Sprintf("checkout merge %t", checkoutMerge), func(t *testing.T) {
+			repoDir, cleanup := initRepo(t)
+			defer cleanup()
+			runCmd(t, repoDir, "git", "checkout", "branch")
+			runCmd(t, repoDir, "touch", "branch-file")
+			runCmd(t, repoDir, "git", "add", "branch-file")
+			runCmd(t, repoDir, "git", "commit", "-m", "branch-commit")
+
+			dataDir, cleanup2 := TempDir(t)
+			defer cleanup2()
+			overrideURL := fmt.Sprintf("file://%s", repoDir)
+			wd := &events.FileWorkspace{
+				DataDir:                     dataDir,
+				CheckoutMerge:               checkoutMerge,
+				TestingOverrideHeadCloneURL: overrideURL,
+				TestingOverrideBaseCloneURL: overrideURL,
+			}
+			pull := models.PullRequest{
+				HeadBranch: "branch",
+				BaseBranch: "master",
+			}
+			_, _, err := wd.Clone(nil, models.Repo{}, models.Repo{}, pull, "default")
+			Ok(t, err)
+
+			diverged, err := wd.HasDiverged(nil, models.Repo{}, pull, "default")
+			Ok(t, err)
+			Equals(t, false, diverged)
+
+			// Add a commit to master.
+			runCmd(t, repoDir, "git", "checkout", "master")
+			runCmd(t, repoDir, "touch", "master-file")
+			runCmd(t, repoDir, "git", "add", "master-file")
+			runCmd(t, repoDir, "git", "commit", "-m", "master-commit")
+
+			diverged, err = wd.HasDiverged(nil, models.Repo{}, pull, "default")
+			Ok(t, err)
+			Equals(t, true, diverged)
+		})
+	}
+}
+
+// Test that we clone the default branch and that we re-clone every time so
+// we pick up new commits.
+func TestCloneDefaultBranch(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+
+	// Add a commit to 'branch' that shouldn't be checked out.
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	runCmd(t, repoDir, "touch", "branch-file")
+	runCmd(t, repoDir, "git", "add", "branch-file")
+	runCmd(t, repoDir, "git", "commit", "-m", "branch-commit")
+	runCmd(t, repoDir, "git", "checkout", "-")
+	expCommit := runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+
+	wd := &events.FileWorkspace{
+		DataDir:                     dataDir,
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+	}
+	repo := models.Repo{FullName: "runatlantis/atlantis"}
+
+	cloneDir, err := wd.CloneDefaultBranch(nil, repo)
+	Ok(t, err)
+	Equals(t, filepath.Join(dataDir, "repos", "runatlantis/atlantis", "default-branch"), cloneDir)
+	Equals(t, expCommit, runCmd(t, cloneDir, "git", "rev-parse", "HEAD"))
+	_, err = os.Stat(filepath.Join(cloneDir, "branch-file"))
+	Assert(t, os.IsNotExist(err), "exp branch-file to not exist")
+
+	// Now advance the default branch and clone again.
+	runCmd(t, repoDir, "touch", "new-file")
+	runCmd(t, repoDir, "git", "add", "new-file")
+	runCmd(t, repoDir, "git", "commit", "-m", "new-commit")
+	expCommit = runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+
+	cloneDir, err = wd.CloneDefaultBranch(nil, repo)
+	Ok(t, err)
+	Equals(t, expCommit, runCmd(t, cloneDir, "git", "rev-parse", "HEAD"))
+}
+
+func TestResolveRef(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	masterCommit := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "branch-commit")
+	branchCommit := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+	runCmd(t, repoDir, "git", "tag", "-a", "v1", "-m", "annotated", masterCommit)
+	runCmd(t, repoDir, "git", "tag", "v2", branchCommit)
+
+	wd := &events.FileWorkspace{
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+	}
+	repo := models.Repo{FullName: "runatlantis/atlantis"}
+	cases := map[string]string{
+		"master": masterCommit,
+		"branch": branchCommit,
+		"v1":     masterCommit,
+		"v2":     branchCommit,
+	}
+	for ref, expCommit := range cases {
+		t.Run(ref, func(t *testing.T) {
+			commit, err := wd.ResolveRef(nil, repo, ref)
+			Ok(t, err)
+			Equals(t, expCommit, commit)
+		})
+	}
+
+	_, err := wd.ResolveRef(nil, repo, "nope")
+	ErrEquals(t, `no branch or tag named "nope" in runatlantis/atlantis`, err)
+}
+
+// fakeGithubAppTokens returns a token for each owner.
+type fakeGithubAppTokens struct {
+	owners []string
+}
+
+func (f *fakeGithubAppTokens) Token(owner string) (string, error) {
+	f.owners = append(f.owners, owner)
+	return "token-for-" + owner, nil
+}
+
+// Test that when we're a GitHub App, repos are cloned with the installation
+// token of the base repo's owner and that the token is redacted.
+func TestClone_GithubAppToken(t *testing.T) {
+	// The server asks for credentials and then fails the clone so that we can
+	// check what git sent.
+	var mu sync.Mutex
+	var gotUser, gotPass string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		gotUser, gotPass = user, pass
+		mu.Unlock()
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dataDir, cleanup := TempDir(t)
+	defer cleanup()
+
+	tokens := &fakeGithubAppTokens{}
+	wd := &events.FileWorkspace{
+		DataDir:         dataDir,
+		GithubAppTokens: tokens,
+	}
+	baseRepo, err := models.NewRepo(models.Github, "owner/repo", server.URL+"/owner/repo.git", "x-access-token", "")
+	Ok(t, err)
+	headRepo, err := models.NewRepo(models.Github, "fork/repo", server.URL+"/fork/repo.git", "x-access-token", "")
+	Ok(t, err)
+
+	_, _, err = wd.Clone(nil, baseRepo, headRepo, models.PullRequest{BaseRepo: baseRepo, HeadBranch: "branch"}, "default")
+	Assert(t, err != nil, "exp clone to fail")
+	Assert(t, !strings.Contains(err.Error(), "token-for-owner"), "exp token to be redacted, got %q", err.Error())
+	Equals(t, []string{"owner"}, tokens.owners)
+	mu.Lock()
+	defer mu.Unlock()
+	Equals(t, "x-access-token", gotUser)
+	Equals(t, "token-for-owner", gotPass)
+}
+
+// rotatingGithubAppTokens returns a new token each time one is requested, as
+// if the previous one had expired.
+type rotatingGithubAppTokens struct {
+	mu     sync.Mutex
+	issued int
+}
+
+func (r *rotatingGithubAppTokens) Token(owner string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.issued++
+	return fmt.Sprintf("token-%d", r.issued), nil
+}
+
+// latest returns the last token returned by Token.
+func (r *rotatingGithubAppTokens) latest() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fmt.Sprintf("token-%d", r.issued)
+}
+
+// gitHTTPServer serves the repo at repoDir over HTTP at /<owner>/repo.git for
+// any owner. Requests must use basic auth with a password that authorized
+// accepts.
+func gitHTTPServer(t *testing.T, repoDir string, authorized func(pass string) bool) *httptest.Server {
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	Ok(t, err)
+	backend := &cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + filepath.Dir(repoDir), "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, ok := r.BasicAuth(); !ok || !authorized(pass) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Serve every owner's repo.git from repoDir.
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+		if len(parts) != 3 || parts[1] != "repo.git" {
+			http.NotFound(w, r)
+			return
+		}
+		r.URL.Path = "/" + filepath.Base(repoDir) + "/" + parts[2]
+		backend.ServeHTTP(w, r)
+	}))
+}
+
+// Test that HasDiverged uses a current GitHub App token rather than the one
+// that the repo was cloned with, which may have expired.
+func TestHasDiverged_GithubAppTokenRefreshed(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "branch-commit")
+	runCmd(t, repoDir, "git", "checkout", "master")
+
+	tokens := &rotatingGithubAppTokens{}
+	server := gitHTTPServer(t, repoDir, func(pass string) bool { return pass == tokens.latest() })
+	defer server.Close()
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	wd := &events.FileWorkspace{
+		DataDir:         dataDir,
+		GithubAppTokens: tokens,
+	}
+	repo, err := models.NewRepo(models.Github, "owner/repo", server.URL+"/owner/repo.git", "x-access-token", "")
+	Ok(t, err)
+	pull := models.PullRequest{BaseRepo: repo, HeadBranch: "branch", BaseBranch: "master"}
+
+	_, _, err = wd.Clone(nil, repo, repo, pull, "default")
+	Ok(t, err)
+	diverged, err := wd.HasDiverged(nil, repo, pull, "default")
+	Ok(t, err)
+	Equals(t, false, diverged)
+}
+
+// Test that the GitHub App token isn't stored in the clone's remotes and that
+// checking if the base branch has diverged uses a current token.
+func TestClone_GithubAppTokenNotStored(t *testing.T) {
+	repoDir, cleanup := initRepo(t)
+	defer cleanup()
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "branch-commit")
+	headCommit := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+	runCmd(t, repoDir, "git", "checkout", "master")
+
+	tokens := &rotatingGithubAppTokens{}
+	server := gitHTTPServer(t, repoDir, func(pass string) bool { return pass == tokens.latest() })
+	defer server.Close()
+
+	dataDir, cleanup2 := TempDir(t)
+	defer cleanup2()
+	wd := &events.FileWorkspace{
+		DataDir:         dataDir,
+		CheckoutMerge:   true,
+		GithubAppTokens: tokens,
+	}
+	repo, err := models.NewRepo(models.Github, "owner/repo", server.URL+"/owner/repo.git", "x-access-token", "")
+	Ok(t, err)
+	pull := models.PullRequest{BaseRepo: repo, HeadBranch: "branch", HeadCommit: headCommit, BaseBranch: "master"}
+
+	cloneDir, _, err := wd.Clone(nil, repo, repo, pull, "default")
+	Ok(t, err)
+	remotes := runCmd(t, cloneDir, "git", "remote", "-v")
+	Assert(t, !strings.Contains(remotes, "token-"), "exp no token in remotes, got %q", remotes)
+
+	runCmd(t, repoDir, "git", "commit", "--allow-empty", "-m", "master-commit")
+	_, diverged, err := wd.Clone(nil, repo, repo, pull, "default")
+	Ok(t, err)
+	Equals(t, true, diverged)
+}
+
 func initRepo(t *testing.T) (string, func()) {
 	repoDir, cleanup := TempDir(t)
 	runCmd(t, repoDir, "git", "init")
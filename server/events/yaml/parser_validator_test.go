@@ -156,8 +156,11 @@ workflows:
 				Version: 2,
 				Workflows: map[string]valid.Workflow{
 					"custom": {
-						Name:  "custom",
-						Apply: valid.DefaultApplyStage,
+						Name:        "custom",
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Plan: valid.Stage{
 							Steps: []valid.Step{
 								{
@@ -333,9 +336,12 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"default": {
-						Name:  "default",
-						Plan:  valid.DefaultPlanStage,
-						Apply: valid.DefaultApplyStage,
+						Name:        "default",
+						Plan:        valid.DefaultPlanStage,
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -369,9 +375,12 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"myworkflow": {
-						Name:  "myworkflow",
-						Apply: valid.DefaultApplyStage,
-						Plan:  valid.DefaultPlanStage,
+						Name:        "myworkflow",
+						Apply:       valid.DefaultApplyStage,
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -407,9 +416,12 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"myworkflow": {
-						Name:  "myworkflow",
-						Apply: valid.DefaultApplyStage,
-						Plan:  valid.DefaultPlanStage,
+						Name:        "myworkflow",
+						Apply:       valid.DefaultApplyStage,
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -445,9 +457,12 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"myworkflow": {
-						Name:  "myworkflow",
-						Apply: valid.DefaultApplyStage,
-						Plan:  valid.DefaultPlanStage,
+						Name:        "myworkflow",
+						Apply:       valid.DefaultApplyStage,
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -483,9 +498,12 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"myworkflow": {
-						Name:  "myworkflow",
-						Apply: valid.DefaultApplyStage,
-						Plan:  valid.DefaultPlanStage,
+						Name:        "myworkflow",
+						Apply:       valid.DefaultApplyStage,
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -637,7 +655,10 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"default": {
-						Name: "default",
+						Name:        "default",
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Plan: valid.Stage{
 							Steps: []valid.Step{
 								{
@@ -699,7 +720,10 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"default": {
-						Name: "default",
+						Name:        "default",
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Plan: valid.Stage{
 							Steps: []valid.Step{
 								{
@@ -757,7 +781,10 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"default": {
-						Name: "default",
+						Name:        "default",
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Plan: valid.Stage{
 							Steps: []valid.Step{
 								{
@@ -811,7 +838,10 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"default": {
-						Name: "default",
+						Name:        "default",
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Plan: valid.Stage{
 							Steps: []valid.Step{
 								{
@@ -886,7 +916,10 @@ func TestParseGlobalCfg_NotExist(t *testing.T) {
 func TestParseGlobalCfg(t *testing.T) {
 	defaultCfg := valid.NewGlobalCfg(false, false, false)
 	customWorkflow1 := valid.Workflow{
-		Name: "custom1",
+		Name:        "custom1",
+		PolicyCheck: valid.DefaultPolicyCheckStage,
+		Import:      valid.DefaultImportStage,
+		StateRm:     valid.DefaultStateRmStage,
 		Plan: valid.Stage{
 			Steps: []valid.Step{
 				{
@@ -954,7 +987,13 @@ func TestParseGlobalCfg(t *testing.T) {
 			input: `repos:
 - id: /.*/
   apply_requirements: [invalid]`,
-			expErr: "repos: (0: (apply_requirements: \"invalid\" is not a valid apply_requirement, only \"approved\" and \"mergeable\" are supported.).).",
+			expErr: "repos: (0: (apply_requirements: \"invalid\" is not a valid apply_requirement, only \"approved\", \"mergeable\", \"policies_passed\" and \"undiverged\" are supported.).).",
+		},
+		"drift_detection with regex id": {
+			input: `repos:
+- id: /.*/
+  drift_detection: true`,
+			expErr: "repos: (0: (drift_detection: can only be enabled for repos with an exact id, not /.*/.).).",
 		},
 		"no workflows key": {
 			input: `repos: []`,
@@ -973,9 +1012,12 @@ workflows:
 				Workflows: map[string]valid.Workflow{
 					"default": defaultCfg.Workflows["default"],
 					"name": {
-						Name:  "name",
-						Apply: valid.DefaultApplyStage,
-						Plan:  valid.DefaultPlanStage,
+						Name:        "name",
+						Apply:       valid.DefaultApplyStage,
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -992,9 +1034,12 @@ workflows:
 				Workflows: map[string]valid.Workflow{
 					"default": defaultCfg.Workflows["default"],
 					"name": {
-						Name:  "name",
-						Apply: valid.DefaultApplyStage,
-						Plan:  valid.DefaultPlanStage,
+						Name:        "name",
+						Apply:       valid.DefaultApplyStage,
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -1012,9 +1057,12 @@ workflows:
 				Workflows: map[string]valid.Workflow{
 					"default": defaultCfg.Workflows["default"],
 					"name": {
-						Name:  "name",
-						Plan:  valid.DefaultPlanStage,
-						Apply: valid.DefaultApplyStage,
+						Name:        "name",
+						Plan:        valid.DefaultPlanStage,
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 					},
 				},
 			},
@@ -1027,6 +1075,7 @@ repos:
   workflow: custom1
   allowed_overrides: [apply_requirements, workflow]
   allow_custom_workflows: true
+  drift_detection: true
 - id: /.*/
 
 workflows:
@@ -1051,6 +1100,7 @@ workflows:
 						Workflow:             &customWorkflow1,
 						AllowedOverrides:     []string{"apply_requirements", "workflow"},
 						AllowCustomWorkflows: Bool(true),
+						DriftDetection:       true,
 					},
 					{
 						IDRegex: regexp.MustCompile(".*"),
@@ -1062,6 +1112,51 @@ workflows:
 				},
 			},
 		},
+		"policy sets": {
+			input: `
+policies:
+  policy_sets:
+  - name: prevent_public_buckets
+    path: /policies/buckets
+  - name: cost
+    path: /policies/cost.rego
+`,
+			exp: valid.GlobalCfg{
+				Repos: defaultCfg.Repos,
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+				},
+				PolicySets: []valid.PolicySet{
+					{
+						Name: "prevent_public_buckets",
+						Path: "/policies/buckets",
+					},
+					{
+						Name: "cost",
+						Path: "/policies/cost.rego",
+					},
+				},
+			},
+		},
+		"policy set without path": {
+			input: `
+policies:
+  policy_sets:
+  - name: cost
+`,
+			expErr: "policies: (policy_sets: (0: (path: cannot be blank.).).).",
+		},
+		"duplicate policy set names": {
+			input: `
+policies:
+  policy_sets:
+  - name: cost
+    path: /policies/a
+  - name: cost
+    path: /policies/b
+`,
+			expErr: "policies: (policy_sets: policy set name \"cost\" is defined more than once.).",
+		},
 		"id regex with trailing slash": {
 			input: `
 repos:
@@ -1114,7 +1209,10 @@ workflows:
 						IDRegex:           regexp.MustCompile(".*"),
 						ApplyRequirements: []string{},
 						Workflow: &valid.Workflow{
-							Name: "default",
+							Name:        "default",
+							PolicyCheck: valid.DefaultPolicyCheckStage,
+							Import:      valid.DefaultImportStage,
+							StateRm:     valid.DefaultStateRmStage,
 							Apply: valid.Stage{
 								Steps: nil,
 							},
@@ -1133,7 +1231,10 @@ workflows:
 				},
 				Workflows: map[string]valid.Workflow{
 					"default": {
-						Name: "default",
+						Name:        "default",
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Apply: valid.Stage{
 							Steps: nil,
 						},
@@ -1181,7 +1282,10 @@ workflows:
 // Test that if we pass in JSON strings everything should parse fine.
 func TestParserValidator_ParseGlobalCfgJSON(t *testing.T) {
 	customWorkflow := valid.Workflow{
-		Name: "custom",
+		Name:        "custom",
+		PolicyCheck: valid.DefaultPolicyCheckStage,
+		Import:      valid.DefaultImportStage,
+		StateRm:     valid.DefaultStateRmStage,
 		Plan: valid.Stage{
 			Steps: []valid.Step{
 				{
@@ -14,6 +14,7 @@ import (
 type GlobalCfg struct {
 	Repos     []Repo              `yaml:"repos" json:"repos"`
 	Workflows map[string]Workflow `yaml:"workflows" json:"workflows"`
+	Policies  Policies            `yaml:"policies" json:"policies"`
 }
 
 // Repo is the raw schema for repos in the server-side repo config.
@@ -23,12 +24,14 @@ type Repo struct {
 	Workflow             *string  `yaml:"workflow,omitempty" json:"workflow,omitempty"`
 	AllowedOverrides     []string `yaml:"allowed_overrides" json:"allowed_overrides"`
 	AllowCustomWorkflows *bool    `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
+	DriftDetection       *bool    `yaml:"drift_detection,omitempty" json:"drift_detection,omitempty"`
 }
 
 func (g GlobalCfg) Validate() error {
 	err := validation.ValidateStruct(&g,
 		validation.Field(&g.Repos),
-		validation.Field(&g.Workflows))
+		validation.Field(&g.Workflows),
+		validation.Field(&g.Policies))
 	if err != nil {
 		return err
 	}
@@ -82,8 +85,9 @@ func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
 	}
 	repos = append(defaultCfg.Repos, repos...)
 	return valid.GlobalCfg{
-		Repos:     repos,
-		Workflows: workflows,
+		Repos:      repos,
+		Workflows:  workflows,
+		PolicySets: g.Policies.ToValid(),
 	}
 }
 
@@ -113,6 +117,14 @@ func (r Repo) Validate() error {
 		return nil
 	}
 
+	driftDetectionValid := func(value interface{}) error {
+		enabled := value.(*bool)
+		if enabled != nil && *enabled && r.HasRegexID() {
+			return fmt.Errorf("can only be enabled for repos with an exact id, not %s", r.ID)
+		}
+		return nil
+	}
+
 	workflowExists := func(value interface{}) error {
 		// We validate workflows in ParserValidator.validateRepoWorkflows
 		// because we need the list of workflows to validate.
@@ -124,6 +136,7 @@ func (r Repo) Validate() error {
 		validation.Field(&r.AllowedOverrides, validation.By(overridesValid)),
 		validation.Field(&r.ApplyRequirements, validation.By(validApplyReq)),
 		validation.Field(&r.Workflow, validation.By(workflowExists)),
+		validation.Field(&r.DriftDetection, validation.By(driftDetectionValid)),
 	)
 }
 
@@ -153,5 +166,6 @@ func (r Repo) ToValid(workflows map[string]valid.Workflow) valid.Repo {
 		Workflow:             workflow,
 		AllowedOverrides:     r.AllowedOverrides,
 		AllowCustomWorkflows: r.AllowCustomWorkflows,
+		DriftDetection:       r.DriftDetection != nil && *r.DriftDetection,
 	}
 }
@@ -0,0 +1,59 @@
+package raw
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+)
+
+// Policies is the raw schema for the policies in the server-side repo config.
+type Policies struct {
+	PolicySets []PolicySet `yaml:"policy_sets" json:"policy_sets"`
+}
+
+// PolicySet is the raw schema for a single set of policies.
+type PolicySet struct {
+	Name string `yaml:"name" json:"name"`
+	Path string `yaml:"path" json:"path"`
+}
+
+func (p Policies) Validate() error {
+	uniqueNames := func(value interface{}) error {
+		sets := value.([]PolicySet)
+		seen := make(map[string]bool)
+		for _, s := range sets {
+			if seen[s.Name] {
+				return fmt.Errorf("policy set name %q is defined more than once", s.Name)
+			}
+			seen[s.Name] = true
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.PolicySets, validation.By(uniqueNames)),
+	)
+}
+
+func (p Policies) ToValid() []valid.PolicySet {
+	var sets []valid.PolicySet
+	for _, s := range p.PolicySets {
+		sets = append(sets, s.ToValid())
+	}
+	return sets
+}
+
+func (p PolicySet) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required),
+		validation.Field(&p.Path, validation.Required),
+	)
+}
+
+func (p PolicySet) ToValid() valid.PolicySet {
+	return valid.PolicySet{
+		Name: p.Name,
+		Path: p.Path,
+	}
+}
@@ -13,9 +13,11 @@ import (
 )
 
 const (
-	DefaultWorkspace          = "default"
-	ApprovedApplyRequirement  = "approved"
-	MergeableApplyRequirement = "mergeable"
+	DefaultWorkspace           = "default"
+	ApprovedApplyRequirement   = "approved"
+	MergeableApplyRequirement  = "mergeable"
+	PoliciesPassedApplyReq     = "policies_passed"
+	UndivergedApplyRequirement = "undiverged"
 )
 
 type Project struct {
@@ -26,6 +28,7 @@ type Project struct {
 	TerraformVersion  *string   `yaml:"terraform_version,omitempty"`
 	Autoplan          *Autoplan `yaml:"autoplan,omitempty"`
 	ApplyRequirements []string  `yaml:"apply_requirements,omitempty"`
+	DependsOn         []string  `yaml:"depends_on,omitempty"`
 }
 
 func (p Project) Validate() error {
@@ -93,6 +96,7 @@ func (p Project) ToValid() valid.Project {
 	v.ApplyRequirements = p.ApplyRequirements
 
 	v.Name = p.Name
+	v.DependsOn = p.DependsOn
 
 	return v
 }
@@ -109,8 +113,8 @@ func validProjectName(name string) bool {
 func validApplyReq(value interface{}) error {
 	reqs := value.([]string)
 	for _, r := range reqs {
-		if r != ApprovedApplyRequirement && r != MergeableApplyRequirement {
-			return fmt.Errorf("%q is not a valid apply_requirement, only %q and %q are supported", r, ApprovedApplyRequirement, MergeableApplyRequirement)
+		if r != ApprovedApplyRequirement && r != MergeableApplyRequirement && r != PoliciesPassedApplyReq && r != UndivergedApplyRequirement {
+			return fmt.Errorf("%q is not a valid apply_requirement, only %q, %q, %q and %q are supported", r, ApprovedApplyRequirement, MergeableApplyRequirement, PoliciesPassedApplyReq, UndivergedApplyRequirement)
 		}
 	}
 	return nil
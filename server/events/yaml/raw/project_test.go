@@ -101,7 +101,7 @@ func TestProject_Validate(t *testing.T) {
 				Dir:               String("."),
 				ApplyRequirements: []string{"unsupported"},
 			},
-			expErr: "apply_requirements: \"unsupported\" is not a valid apply_requirement, only \"approved\" and \"mergeable\" are supported.",
+			expErr: "apply_requirements: \"unsupported\" is not a valid apply_requirement, only \"approved\", \"mergeable\", \"policies_passed\" and \"undiverged\" are supported.",
 		},
 		{
 			description: "apply reqs with approved requirement",
@@ -119,6 +119,14 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			description: "apply reqs with policies_passed requirement",
+			input: raw.Project{
+				Dir:               String("."),
+				ApplyRequirements: []string{"policies_passed"},
+			},
+			expErr: "",
+		},
 		{
 			description: "apply reqs with mergeable and approved requirements",
 			input: raw.Project{
@@ -251,6 +259,7 @@ func TestProject_ToValid(t *testing.T) {
 				},
 				ApplyRequirements: []string{"approved"},
 				Name:              String("myname"),
+				DependsOn:         []string{"network"},
 			},
 			exp: valid.Project{
 				Dir:              ".",
@@ -263,6 +272,7 @@ func TestProject_ToValid(t *testing.T) {
 				},
 				ApplyRequirements: []string{"approved"},
 				Name:              String("myname"),
+				DependsOn:         []string{"network"},
 			},
 		},
 		{
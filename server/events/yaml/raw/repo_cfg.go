@@ -2,6 +2,8 @@ package raw
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
@@ -10,12 +12,20 @@ import (
 // DefaultAutomerge is the default setting for automerge.
 const DefaultAutomerge = false
 
+// DefaultParallelApply is the default setting for parallel apply.
+const DefaultParallelApply = false
+
+// DefaultParallelPlan is the default setting for parallel plan.
+const DefaultParallelPlan = false
+
 // RepoCfg is the raw schema for repo-level atlantis.yaml config.
 type RepoCfg struct {
-	Version   *int                `yaml:"version,omitempty"`
-	Projects  []Project           `yaml:"projects,omitempty"`
-	Workflows map[string]Workflow `yaml:"workflows,omitempty"`
-	Automerge *bool               `yaml:"automerge,omitempty"`
+	Version       *int                `yaml:"version,omitempty"`
+	Projects      []Project           `yaml:"projects,omitempty"`
+	Workflows     map[string]Workflow `yaml:"workflows,omitempty"`
+	Automerge     *bool               `yaml:"automerge,omitempty"`
+	ParallelApply *bool               `yaml:"parallel_apply,omitempty"`
+	ParallelPlan  *bool               `yaml:"parallel_plan,omitempty"`
 }
 
 func (r RepoCfg) Validate() error {
@@ -31,11 +41,77 @@ func (r RepoCfg) Validate() error {
 	}
 	return validation.ValidateStruct(&r,
 		validation.Field(&r.Version, validation.By(equals2)),
-		validation.Field(&r.Projects),
+		validation.Field(&r.Projects, validation.By(validDependsOn)),
 		validation.Field(&r.Workflows),
 	)
 }
 
+// validDependsOn checks that projects only depend on other named projects and
+// that there are no cycles.
+func validDependsOn(value interface{}) error {
+	projects := value.([]Project)
+	deps := make(map[string][]string)
+	for _, p := range projects {
+		if p.Name != nil {
+			deps[*p.Name] = p.DependsOn
+		}
+	}
+	for _, p := range projects {
+		for _, d := range p.DependsOn {
+			if _, ok := deps[d]; !ok {
+				return fmt.Errorf("depends_on: %q is not the name of a project", d)
+			}
+			if p.Name != nil && *p.Name == d {
+				return fmt.Errorf("depends_on: project %q can't depend on itself", d)
+			}
+		}
+	}
+
+	// Check for cycles with a depth first search from each project. visiting
+	// holds the projects on the current path and done the projects we've
+	// already fully checked.
+	visiting := make(map[string]bool)
+	done := make(map[string]bool)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		if visiting[name] {
+			// Only include the projects that are part of the cycle.
+			start := 0
+			for i, p := range path {
+				if p == name {
+					start = i
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("depends_on: found a cycle: %s", strings.Join(cycle, " -> "))
+		}
+		visiting[name] = true
+		path = append(path, name)
+		for _, d := range deps[name] {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		visiting[name] = false
+		done[name] = true
+		return nil
+	}
+	for _, p := range projects {
+		if p.Name == nil {
+			continue
+		}
+		if err := visit(*p.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r RepoCfg) ToValid() valid.RepoCfg {
 	validWorkflows := make(map[string]valid.Workflow)
 	for k, v := range r.Workflows {
@@ -52,10 +128,22 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 		automerge = *r.Automerge
 	}
 
+	parallelApply := DefaultParallelApply
+	if r.ParallelApply != nil {
+		parallelApply = *r.ParallelApply
+	}
+
+	parallelPlan := DefaultParallelPlan
+	if r.ParallelPlan != nil {
+		parallelPlan = *r.ParallelPlan
+	}
+
 	return valid.RepoCfg{
-		Version:   *r.Version,
-		Projects:  validProjects,
-		Workflows: validWorkflows,
-		Automerge: automerge,
+		Version:       *r.Version,
+		Projects:      validProjects,
+		Workflows:     validWorkflows,
+		Automerge:     automerge,
+		ParallelApply: parallelApply,
+		ParallelPlan:  parallelPlan,
 	}
 }
@@ -111,11 +111,23 @@ func TestConfig_UnmarshalYAML(t *testing.T) {
 			},
 			expErr: "yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `notabool` into bool",
 		},
+		{
+			description: "parallel_plan not a boolean",
+			input:       "version: 3\nparallel_plan: notabool",
+			exp: raw.RepoCfg{
+				Version:   nil,
+				Projects:  nil,
+				Workflows: nil,
+			},
+			expErr: "yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `notabool` into bool",
+		},
 		{
 			description: "should use values if set",
 			input: `
 version: 3
 automerge: true
+parallel_apply: true
+parallel_plan: false
 projects:
 - dir: mydir
   workspace: myworkspace
@@ -132,8 +144,10 @@ workflows:
     apply:
      steps: []`,
 			exp: raw.RepoCfg{
-				Version:   Int(3),
-				Automerge: Bool(true),
+				Version:       Int(3),
+				Automerge:     Bool(true),
+				ParallelApply: Bool(true),
+				ParallelPlan:  Bool(false),
 				Projects: []raw.Project{
 					{
 						Dir:              String("mydir"),
@@ -194,6 +208,49 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expErr: "version: only versions 2 and 3 are supported.",
 		},
+		{
+			description: "depends_on valid",
+			input: raw.RepoCfg{
+				Version: Int(3),
+				Projects: []raw.Project{
+					{Name: String("network"), Dir: String("network")},
+					{Name: String("eks"), Dir: String("eks"), DependsOn: []string{"network"}},
+					{Dir: String("apps"), DependsOn: []string{"eks", "network"}},
+				},
+			},
+		},
+		{
+			description: "depends_on unknown project",
+			input: raw.RepoCfg{
+				Version: Int(3),
+				Projects: []raw.Project{
+					{Name: String("eks"), Dir: String("eks"), DependsOn: []string{"network"}},
+				},
+			},
+			expErr: "projects: depends_on: \"network\" is not the name of a project.",
+		},
+		{
+			description: "depends_on itself",
+			input: raw.RepoCfg{
+				Version: Int(3),
+				Projects: []raw.Project{
+					{Name: String("eks"), Dir: String("eks"), DependsOn: []string{"eks"}},
+				},
+			},
+			expErr: "projects: depends_on: project \"eks\" can't depend on itself.",
+		},
+		{
+			description: "depends_on cycle",
+			input: raw.RepoCfg{
+				Version: Int(3),
+				Projects: []raw.Project{
+					{Name: String("apps"), Dir: String("apps"), DependsOn: []string{"eks"}},
+					{Name: String("eks"), Dir: String("eks"), DependsOn: []string{"network"}},
+					{Name: String("network"), Dir: String("network"), DependsOn: []string{"eks"}},
+				},
+			},
+			expErr: "projects: depends_on: found a cycle: eks -> network -> eks.",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -270,6 +327,32 @@ func TestConfig_ToValid(t *testing.T) {
 				Workflows: map[string]valid.Workflow{},
 			},
 		},
+		{
+			description: "parallel apply and plan omitted",
+			input: raw.RepoCfg{
+				Version: Int(2),
+			},
+			exp: valid.RepoCfg{
+				Version:       2,
+				ParallelApply: false,
+				ParallelPlan:  false,
+				Workflows:     map[string]valid.Workflow{},
+			},
+		},
+		{
+			description: "parallel apply and plan true",
+			input: raw.RepoCfg{
+				Version:       Int(2),
+				ParallelApply: Bool(true),
+				ParallelPlan:  Bool(true),
+			},
+			exp: valid.RepoCfg{
+				Version:       2,
+				ParallelApply: true,
+				ParallelPlan:  true,
+				Workflows:     map[string]valid.Workflow{},
+			},
+		},
 		{
 			description: "only plan stage set",
 			input: raw.RepoCfg{
@@ -286,8 +369,11 @@ func TestConfig_ToValid(t *testing.T) {
 				Automerge: false,
 				Workflows: map[string]valid.Workflow{
 					"myworkflow": {
-						Name: "myworkflow",
-						Plan: valid.DefaultPlanStage,
+						Name:        "myworkflow",
+						Plan:        valid.DefaultPlanStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Apply: valid.Stage{
 							Steps: []valid.Step{
 								{
@@ -333,7 +419,10 @@ func TestConfig_ToValid(t *testing.T) {
 				Automerge: true,
 				Workflows: map[string]valid.Workflow{
 					"myworkflow": {
-						Name: "myworkflow",
+						Name:        "myworkflow",
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
 						Apply: valid.Stage{
 							Steps: []valid.Step{
 								{
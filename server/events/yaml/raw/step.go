@@ -21,6 +21,12 @@ const (
 	ApplyStepName = "apply"
 	InitStepName  = "init"
 	EnvStepName   = "env"
+	ShowStepName  = "show"
+	// PolicyCheckStepName is the step that runs conftest against the output
+	// of the show step.
+	PolicyCheckStepName = "policy_check"
+	ImportStepName      = "import"
+	StateRmStepName     = "state_rm"
 )
 
 // Step represents a single action/command to perform. In YAML, it can be set as
@@ -63,7 +69,8 @@ func (s *Step) UnmarshalJSON(data []byte) error {
 func (s Step) Validate() error {
 	validStep := func(value interface{}) error {
 		str := *value.(*string)
-		if str != InitStepName && str != PlanStepName && str != ApplyStepName && str != EnvStepName {
+		if str != InitStepName && str != PlanStepName && str != ApplyStepName && str != EnvStepName && str != ShowStepName && str != PolicyCheckStepName &&
+			str != ImportStepName && str != StateRmStepName {
 			return fmt.Errorf("%q is not a valid step type, maybe you omitted the 'run' key", str)
 		}
 		return nil
@@ -83,7 +90,8 @@ func (s Step) Validate() error {
 				len(keys), strings.Join(keys, ","))
 		}
 		for stepName, args := range elem {
-			if stepName != InitStepName && stepName != PlanStepName && stepName != ApplyStepName {
+			if stepName != InitStepName && stepName != PlanStepName && stepName != ApplyStepName && stepName != PolicyCheckStepName &&
+				stepName != ImportStepName && stepName != StateRmStepName {
 				return fmt.Errorf("%q is not a valid step type", stepName)
 			}
 			var argKeys []string
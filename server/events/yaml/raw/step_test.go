@@ -194,6 +194,49 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			description: "show step",
+			input: raw.Step{
+				Key: String("show"),
+			},
+			expErr: "",
+		},
+		{
+			description: "policy_check step",
+			input: raw.Step{
+				Key: String("policy_check"),
+			},
+			expErr: "",
+		},
+		{
+			description: "import step",
+			input: raw.Step{
+				Key: String("import"),
+			},
+			expErr: "",
+		},
+		{
+			description: "state_rm extra_args",
+			input: raw.Step{
+				Map: MapType{
+					"state_rm": {
+						"extra_args": []string{"-lock=false"},
+					},
+				},
+			},
+			expErr: "",
+		},
+		{
+			description: "policy_check extra_args",
+			input: raw.Step{
+				Map: MapType{
+					"policy_check": {
+						"extra_args": []string{"--all-namespaces"},
+					},
+				},
+			},
+			expErr: "",
+		},
 		{
 			description: "init extra_args",
 			input: raw.Step{
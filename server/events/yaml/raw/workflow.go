@@ -6,14 +6,20 @@ import (
 )
 
 type Workflow struct {
-	Apply *Stage `yaml:"apply,omitempty" json:"apply,omitempty"`
-	Plan  *Stage `yaml:"plan,omitempty" json:"plan,omitempty"`
+	Apply       *Stage `yaml:"apply,omitempty" json:"apply,omitempty"`
+	Plan        *Stage `yaml:"plan,omitempty" json:"plan,omitempty"`
+	PolicyCheck *Stage `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
+	Import      *Stage `yaml:"import,omitempty" json:"import,omitempty"`
+	StateRm     *Stage `yaml:"state_rm,omitempty" json:"state_rm,omitempty"`
 }
 
 func (w Workflow) Validate() error {
 	return validation.ValidateStruct(&w,
 		validation.Field(&w.Apply),
 		validation.Field(&w.Plan),
+		validation.Field(&w.PolicyCheck),
+		validation.Field(&w.Import),
+		validation.Field(&w.StateRm),
 	)
 }
 
@@ -31,5 +37,20 @@ func (w Workflow) ToValid(name string) valid.Workflow {
 	} else {
 		v.Plan = w.Plan.ToValid()
 	}
+	if w.PolicyCheck == nil || w.PolicyCheck.Steps == nil {
+		v.PolicyCheck = valid.DefaultPolicyCheckStage
+	} else {
+		v.PolicyCheck = w.PolicyCheck.ToValid()
+	}
+	if w.Import == nil || w.Import.Steps == nil {
+		v.Import = valid.DefaultImportStage
+	} else {
+		v.Import = w.Import.ToValid()
+	}
+	if w.StateRm == nil || w.StateRm.Steps == nil {
+		v.StateRm = valid.DefaultStateRmStage
+	} else {
+		v.StateRm = w.StateRm.ToValid()
+	}
 	return v
 }
@@ -76,6 +76,54 @@ apply:
 				},
 			},
 		},
+		{
+			description: "policy_check set",
+			input: `
+policy_check:
+  steps: [show, policy_check]`,
+			exp: raw.Workflow{
+				PolicyCheck: &raw.Stage{
+					Steps: []raw.Step{
+						{
+							Key: String("show"),
+						},
+						{
+							Key: String("policy_check"),
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "import and state_rm set",
+			input: `
+import:
+  steps: [init, import]
+state_rm:
+  steps: [init, state_rm]`,
+			exp: raw.Workflow{
+				Import: &raw.Stage{
+					Steps: []raw.Step{
+						{
+							Key: String("init"),
+						},
+						{
+							Key: String("import"),
+						},
+					},
+				},
+				StateRm: &raw.Stage{
+					Steps: []raw.Step{
+						{
+							Key: String("init"),
+						},
+						{
+							Key: String("state_rm"),
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -120,8 +168,11 @@ func TestWorkflow_ToValid(t *testing.T) {
 			description: "nothing set",
 			input:       raw.Workflow{},
 			exp: valid.Workflow{
-				Apply: valid.DefaultApplyStage,
-				Plan:  valid.DefaultPlanStage,
+				Apply:       valid.DefaultApplyStage,
+				Plan:        valid.DefaultPlanStage,
+				PolicyCheck: valid.DefaultPolicyCheckStage,
+				Import:      valid.DefaultImportStage,
+				StateRm:     valid.DefaultStateRmStage,
 			},
 		},
 		{
@@ -141,6 +192,27 @@ func TestWorkflow_ToValid(t *testing.T) {
 						},
 					},
 				},
+				PolicyCheck: &raw.Stage{
+					Steps: []raw.Step{
+						{
+							Key: String("policy_check"),
+						},
+					},
+				},
+				Import: &raw.Stage{
+					Steps: []raw.Step{
+						{
+							Key: String("import"),
+						},
+					},
+				},
+				StateRm: &raw.Stage{
+					Steps: []raw.Step{
+						{
+							Key: String("state_rm"),
+						},
+					},
+				},
 			},
 			exp: valid.Workflow{
 				Apply: valid.Stage{
@@ -157,6 +229,27 @@ func TestWorkflow_ToValid(t *testing.T) {
 						},
 					},
 				},
+				PolicyCheck: valid.Stage{
+					Steps: []valid.Step{
+						{
+							StepName: "policy_check",
+						},
+					},
+				},
+				Import: valid.Stage{
+					Steps: []valid.Step{
+						{
+							StepName: "import",
+						},
+					},
+				},
+				StateRm: valid.Stage{
+					Steps: []valid.Step{
+						{
+							StepName: "state_rm",
+						},
+					},
+				},
 			},
 		},
 	}
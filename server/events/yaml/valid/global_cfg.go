@@ -11,6 +11,7 @@ import (
 
 const MergeableApplyReq = "mergeable"
 const ApprovedApplyReq = "approved"
+const PoliciesPassedApplyReq = "policies_passed"
 const ApplyRequirementsKey = "apply_requirements"
 const WorkflowKey = "workflow"
 const AllowedOverridesKey = "allowed_overrides"
@@ -19,8 +20,9 @@ const DefaultWorkflowName = "default"
 
 // GlobalCfg is the final parsed version of server-side repo config.
 type GlobalCfg struct {
-	Repos     []Repo
-	Workflows map[string]Workflow
+	Repos      []Repo
+	Workflows  map[string]Workflow
+	PolicySets []PolicySet
 }
 
 // Repo is the final parsed version of server-side repo config.
@@ -35,6 +37,10 @@ type Repo struct {
 	Workflow             *Workflow
 	AllowedOverrides     []string
 	AllowCustomWorkflows *bool
+	// DriftDetection is true if the default branch of this repo should be
+	// periodically planned to detect drift. It can only be set for repos
+	// with an exact match ID.
+	DriftDetection bool
 }
 
 type MergedProjectCfg struct {
@@ -46,6 +52,8 @@ type MergedProjectCfg struct {
 	AutoplanEnabled   bool
 	TerraformVersion  *version.Version
 	RepoCfgVersion    int
+	PolicySets        []PolicySet
+	DependsOn         []string
 }
 
 // DefaultApplyStage is the Atlantis default apply stage.
@@ -69,6 +77,42 @@ var DefaultPlanStage = Stage{
 	},
 }
 
+// DefaultPolicyCheckStage is the Atlantis default policy check stage.
+var DefaultPolicyCheckStage = Stage{
+	Steps: []Step{
+		{
+			StepName: "show",
+		},
+		{
+			StepName: "policy_check",
+		},
+	},
+}
+
+// DefaultImportStage is the Atlantis default import stage.
+var DefaultImportStage = Stage{
+	Steps: []Step{
+		{
+			StepName: "init",
+		},
+		{
+			StepName: "import",
+		},
+	},
+}
+
+// DefaultStateRmStage is the Atlantis default state rm stage.
+var DefaultStateRmStage = Stage{
+	Steps: []Step{
+		{
+			StepName: "init",
+		},
+		{
+			StepName: "state_rm",
+		},
+	},
+}
+
 // NewGlobalCfg returns a global config that respects the parameters.
 // allowRepoCfg is true if users want to allow repos full config functionality.
 // mergeableReq is true if users want to set the mergeable apply requirement
@@ -77,9 +121,12 @@ var DefaultPlanStage = Stage{
 // for all repos.
 func NewGlobalCfg(allowRepoCfg bool, mergeableReq bool, approvedReq bool) GlobalCfg {
 	defaultWorkflow := Workflow{
-		Name:  DefaultWorkflowName,
-		Apply: DefaultApplyStage,
-		Plan:  DefaultPlanStage,
+		Name:        DefaultWorkflowName,
+		Apply:       DefaultApplyStage,
+		Plan:        DefaultPlanStage,
+		PolicyCheck: DefaultPolicyCheckStage,
+		Import:      DefaultImportStage,
+		StateRm:     DefaultStateRmStage,
 	}
 	// Must construct slices here instead of using a `var` declaration because
 	// we treat nil slices differently.
@@ -130,6 +177,18 @@ func (r Repo) IDString() string {
 	return "/" + r.IDRegex.String() + "/"
 }
 
+// DriftDetectionRepoIDs returns the IDs of the repos that have drift detection
+// enabled.
+func (g GlobalCfg) DriftDetectionRepoIDs() []string {
+	var ids []string
+	for _, repo := range g.Repos {
+		if repo.DriftDetection && repo.ID != "" {
+			ids = append(ids, repo.ID)
+		}
+	}
+	return ids
+}
+
 // MergeProjectCfg merges proj and rCfg with the global config to return a
 // final config. It assumes that all configs have been validated.
 func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, proj Project, rCfg RepoCfg) MergedProjectCfg {
@@ -180,6 +239,8 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		AutoplanEnabled:   proj.Autoplan.Enabled,
 		TerraformVersion:  proj.TerraformVersion,
 		RepoCfgVersion:    rCfg.Version,
+		PolicySets:        g.PolicySets,
+		DependsOn:         proj.DependsOn,
 	}
 }
 
@@ -196,6 +257,7 @@ func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repo
 		Name:              "",
 		AutoplanEnabled:   DefaultAutoPlanEnabled,
 		TerraformVersion:  nil,
+		PolicySets:        g.PolicySets,
 	}
 }
 
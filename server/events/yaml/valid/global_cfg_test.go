@@ -16,7 +16,10 @@ import (
 
 func TestNewGlobalCfg(t *testing.T) {
 	expDefaultWorkflow := valid.Workflow{
-		Name: "default",
+		Name:        "default",
+		PolicyCheck: valid.DefaultPolicyCheckStage,
+		Import:      valid.DefaultImportStage,
+		StateRm:     valid.DefaultStateRmStage,
 		Apply: valid.Stage{
 			Steps: []valid.Step{
 				{
@@ -277,8 +280,11 @@ workflows:
 			exp: valid.MergedProjectCfg{
 				ApplyRequirements: []string{},
 				Workflow: valid.Workflow{
-					Name:  "custom",
-					Apply: valid.DefaultApplyStage,
+					Name:        "custom",
+					Apply:       valid.DefaultApplyStage,
+					PolicyCheck: valid.DefaultPolicyCheckStage,
+					Import:      valid.DefaultImportStage,
+					StateRm:     valid.DefaultStateRmStage,
 					Plan: valid.Stage{
 						Steps: []valid.Step{
 							{
@@ -310,9 +316,12 @@ repos:
 			exp: valid.MergedProjectCfg{
 				ApplyRequirements: []string{"mergeable"},
 				Workflow: valid.Workflow{
-					Name:  "default",
-					Apply: valid.DefaultApplyStage,
-					Plan:  valid.DefaultPlanStage,
+					Name:        "default",
+					Apply:       valid.DefaultApplyStage,
+					Plan:        valid.DefaultPlanStage,
+					PolicyCheck: valid.DefaultPolicyCheckStage,
+					Import:      valid.DefaultImportStage,
+					StateRm:     valid.DefaultStateRmStage,
 				},
 				RepoRelDir:      ".",
 				Workspace:       "default",
@@ -340,9 +349,12 @@ repos:
 			exp: valid.MergedProjectCfg{
 				ApplyRequirements: []string{"approved", "mergeable"},
 				Workflow: valid.Workflow{
-					Name:  "default",
-					Apply: valid.DefaultApplyStage,
-					Plan:  valid.DefaultPlanStage,
+					Name:        "default",
+					Apply:       valid.DefaultApplyStage,
+					Plan:        valid.DefaultPlanStage,
+					PolicyCheck: valid.DefaultPolicyCheckStage,
+					Import:      valid.DefaultImportStage,
+					StateRm:     valid.DefaultStateRmStage,
 				},
 				RepoRelDir:      "mydir",
 				Workspace:       "myworkspace",
@@ -366,9 +378,12 @@ repos:
 			exp: valid.MergedProjectCfg{
 				ApplyRequirements: []string{},
 				Workflow: valid.Workflow{
-					Name:  "default",
-					Apply: valid.DefaultApplyStage,
-					Plan:  valid.DefaultPlanStage,
+					Name:        "default",
+					Apply:       valid.DefaultApplyStage,
+					Plan:        valid.DefaultPlanStage,
+					PolicyCheck: valid.DefaultPolicyCheckStage,
+					Import:      valid.DefaultImportStage,
+					StateRm:     valid.DefaultStateRmStage,
 				},
 				RepoRelDir:      "mydir",
 				Workspace:       "myworkspace",
@@ -415,6 +430,18 @@ func TestRepo_IDString(t *testing.T) {
 	Equals(t, "/regex.*/", (valid.Repo{IDRegex: regexp.MustCompile("regex.*")}).IDString())
 }
 
+func TestGlobalCfg_DriftDetectionRepoIDs(t *testing.T) {
+	global := valid.NewGlobalCfg(false, false, false)
+	Equals(t, 0, len(global.DriftDetectionRepoIDs()))
+
+	global.Repos = append(global.Repos,
+		valid.Repo{ID: "github.com/owner/enabled", DriftDetection: true},
+		valid.Repo{ID: "github.com/owner/disabled"},
+		valid.Repo{ID: "github.com/owner/enabled2", DriftDetection: true},
+	)
+	Equals(t, []string{"github.com/owner/enabled", "github.com/owner/enabled2"}, global.DriftDetectionRepoIDs())
+}
+
 // String is a helper routine that allocates a new string value
 // to store v and returns a pointer to it.
 func String(v string) *string { return &v }
@@ -0,0 +1,10 @@
+package valid
+
+// PolicySet is a set of policies that plans are checked against during the
+// policy_check stage.
+type PolicySet struct {
+	// Name identifies the policy set in logs and comments.
+	Name string
+	// Path is the path to the directory or file containing the policies.
+	Path string
+}
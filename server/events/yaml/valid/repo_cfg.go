@@ -11,6 +11,12 @@ type RepoCfg struct {
 	Projects  []Project
 	Workflows map[string]Workflow
 	Automerge bool
+	// ParallelApply is true if apply commands for this repo's projects
+	// should run concurrently.
+	ParallelApply bool
+	// ParallelPlan is true if plan commands for this repo's projects
+	// should run concurrently.
+	ParallelPlan bool
 }
 
 func (r RepoCfg) FindProjectsByDirWorkspace(repoRelDir string, workspace string) []Project {
@@ -51,6 +57,9 @@ type Project struct {
 	TerraformVersion  *version.Version
 	Autoplan          Autoplan
 	ApplyRequirements []string
+	// DependsOn are the names of the projects that must be applied before
+	// this project.
+	DependsOn []string
 }
 
 // GetName returns the name of the project or an empty string if there is no
@@ -85,7 +94,10 @@ type Step struct {
 }
 
 type Workflow struct {
-	Name  string
-	Apply Stage
-	Plan  Stage
+	Name        string
+	Apply       Stage
+	Plan        Stage
+	PolicyCheck Stage
+	Import      Stage
+	StateRm     Stage
 }
@@ -14,6 +14,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -28,7 +29,9 @@ import (
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 	gitlab "github.com/xanzy/go-gitlab"
 )
 
@@ -83,16 +86,48 @@ type EventsController struct {
 	// Azure DevOps Team Project. If empty, no request validation is done.
 	AzureDevopsWebhookBasicPassword []byte
 	AzureDevopsRequestValidator     AzureDevopsRequestValidator
+	// GiteaWebhookSecret is the secret added to this webhook via the Gitea
+	// UI that identifies this call as coming from Gitea. If empty, no
+	// request validation is done.
+	GiteaWebhookSecret []byte
+	// WebhooksReceived counts webhooks by the labels vcs and event.
+	WebhooksReceived *metrics.Counter
+	// Drainer tracks the commands we start so that shutdown can wait for
+	// them. Once it's draining, webhooks are rejected.
+	Drainer *events.Drainer
+	// EventQueue stores the commands from webhooks until they've run. If
+	// nil, commands are run straight away.
+	EventQueue *events.EventQueue
+	// GithubAppID is the ID of the GitHub App Atlantis authenticates as. Only
+	// check runs created by this App are replanned. If 0, check run events
+	// are ignored.
+	GithubAppID int64
 }
 
 // Post handles POST webhook requests.
 func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get(githubHeader) != "" {
+	if e.Drainer.Draining() {
+		e.respondShuttingDown(w)
+		return
+	}
+	// Gitea also sends the GitHub event header for compatibility so we need
+	// to check for it first.
+	if r.Header.Get(gitea.EventTypeHeader) != "" {
+		if !e.supportsHost(models.Gitea) {
+			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support Gitea")
+			return
+		}
+		e.Logger.Debug("handling Gitea post")
+		e.WebhooksReceived.Inc(models.Gitea.String(), r.Header.Get(gitea.EventTypeHeader))
+		e.handleGiteaPost(w, r)
+		return
+	} else if r.Header.Get(githubHeader) != "" {
 		if !e.supportsHost(models.Github) {
 			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support GitHub")
 			return
 		}
 		e.Logger.Debug("handling GitHub post")
+		e.WebhooksReceived.Inc(models.Github.String(), r.Header.Get(githubHeader))
 		e.handleGithubPost(w, r)
 		return
 	} else if r.Header.Get(gitlabHeader) != "" {
@@ -101,6 +136,7 @@ func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		e.Logger.Debug("handling GitLab post")
+		e.WebhooksReceived.Inc(models.Gitlab.String(), r.Header.Get(gitlabHeader))
 		e.handleGitlabPost(w, r)
 		return
 	} else if r.Header.Get(bitbucketEventTypeHeader) != "" {
@@ -112,6 +148,7 @@ func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			e.Logger.Debug("handling Bitbucket Cloud post")
+			e.WebhooksReceived.Inc(models.BitbucketCloud.String(), r.Header.Get(bitbucketEventTypeHeader))
 			e.handleBitbucketCloudPost(w, r)
 			return
 		} else if r.Header.Get(bitbucketServerRequestIDHeader) != "" {
@@ -120,6 +157,7 @@ func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			e.Logger.Debug("handling Bitbucket Server post")
+			e.WebhooksReceived.Inc(models.BitbucketServer.String(), r.Header.Get(bitbucketEventTypeHeader))
 			e.handleBitbucketServerPost(w, r)
 			return
 		}
@@ -153,6 +191,9 @@ func (e *EventsController) handleGithubPost(w http.ResponseWriter, r *http.Reque
 	case *github.PullRequestEvent:
 		e.Logger.Debug("handling as pull request event")
 		e.HandleGithubPullRequestEvent(w, event, githubReqID)
+	case *github.CheckRunEvent:
+		e.Logger.Debug("handling as check run event")
+		e.HandleGithubCheckRunEvent(w, event, githubReqID)
 	default:
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event %s", githubReqID)
 	}
@@ -232,6 +273,7 @@ func (e *EventsController) handleAzureDevopsPost(w http.ResponseWriter, r *http.
 		e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing webhook: %v %s", err, azuredevopsReqID)
 		return
 	}
+	e.WebhooksReceived.Inc(models.AzureDevops.String(), event.EventType)
 	switch event.PayloadType {
 	case azuredevops.PullRequestCommentedEvent:
 		e.Logger.Debug("handling as pull request commented event")
@@ -244,6 +286,46 @@ func (e *EventsController) handleAzureDevopsPost(w http.ResponseWriter, r *http.
 	}
 }
 
+func (e *EventsController) handleGiteaPost(w http.ResponseWriter, r *http.Request) {
+	eventType := r.Header.Get(gitea.EventTypeHeader)
+	reqID := gitea.DeliveryHeader + "=" + r.Header.Get(gitea.DeliveryHeader)
+	sig := r.Header.Get(gitea.SignatureHeader)
+	defer r.Body.Close() // nolint: errcheck
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s %s", err, reqID)
+		return
+	}
+	if len(e.GiteaWebhookSecret) > 0 {
+		if err := gitea.ValidateSignature(body, sig, e.GiteaWebhookSecret); err != nil {
+			e.respond(w, logging.Warn, http.StatusBadRequest, errors.Wrap(err, "request did not pass validation").Error())
+			return
+		}
+	}
+	e.Logger.Debug("request valid")
+
+	switch eventType {
+	case gitea.IssueCommentHeader:
+		var event gitea.IssueCommentEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing webhook: %s %s", err, reqID)
+			return
+		}
+		e.Logger.Debug("handling as comment event")
+		e.HandleGiteaCommentEvent(w, &event, reqID)
+	case gitea.PullRequestHeader:
+		var event gitea.PullRequestEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing webhook: %s %s", err, reqID)
+			return
+		}
+		e.Logger.Debug("handling as pull request event")
+		e.HandleGiteaPullRequestEvent(w, &event, reqID)
+	default:
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event type %s %s", eventType, reqID)
+	}
+}
+
 // HandleGithubCommentEvent handles comment events from GitHub where Atlantis
 // commands can come from. It's exported to make testing easier.
 func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event *github.IssueCommentEvent, githubReqID string) {
@@ -260,7 +342,42 @@ func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event
 
 	// We pass in nil for maybeHeadRepo because the head repo data isn't
 	// available in the GithubIssueComment event.
-	e.handleCommentEvent(w, baseRepo, nil, nil, user, pullNum, event.Comment.GetBody(), models.Github)
+	e.handleCommentEvent(w, baseRepo, nil, nil, user, pullNum, event.Comment.GetBody(), models.Github, githubReqID)
+}
+
+// HandleGithubCheckRunEvent replans the projects of a check run created by
+// Atlantis when a user clicks on its Re-run action or re-runs it from the
+// checks tab. It's handled like a comment with the replan command so it goes
+// through the same checks. Other Apps can create check runs on the same
+// commits with any external id so we ignore check runs that aren't ours and
+// only run plan commands.
+func (e *EventsController) HandleGithubCheckRunEvent(w http.ResponseWriter, event *github.CheckRunEvent, githubReqID string) {
+	replan := event.GetAction() == "rerequested" ||
+		(event.GetAction() == "requested_action" && event.GetRequestedAction() != nil && event.GetRequestedAction().Identifier == events.ReplanActionID)
+	if !replan {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring check run event since action was %s %s", event.GetAction(), githubReqID)
+		return
+	}
+	if appID := event.GetCheckRun().GetApp().GetID(); e.GithubAppID == 0 || appID != e.GithubAppID {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring check run event since check run was created by App %d %s", appID, githubReqID)
+		return
+	}
+	pullNum, comment, err := events.ParseCheckRunExternalID(event.GetCheckRun().GetExternalID())
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Failed parsing event: %v %s", err, githubReqID)
+		return
+	}
+	if parsed := e.CommentParser.Parse(comment, models.Github); parsed.Command == nil || parsed.Command.Name != models.PlanCommand {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Failed parsing event: check run external id %q isn't a plan command %s", event.GetCheckRun().GetExternalID(), githubReqID)
+		return
+	}
+	baseRepo, err := e.Parser.ParseGithubRepo(event.Repo)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing event: %v %s", err, githubReqID)
+		return
+	}
+	user := models.User{Username: event.GetSender().GetLogin()}
+	e.handleCommentEvent(w, baseRepo, nil, nil, user, pullNum, comment, models.Github, githubReqID)
 }
 
 // HandleBitbucketCloudCommentEvent handles comment events from Bitbucket.
@@ -270,7 +387,7 @@ func (e *EventsController) HandleBitbucketCloudCommentEvent(w http.ResponseWrite
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	e.handleCommentEvent(w, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud)
+	e.handleCommentEvent(w, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud, bitbucketCloudRequestIDHeader+"="+reqID)
 }
 
 // HandleBitbucketServerCommentEvent handles comment events from Bitbucket.
@@ -280,7 +397,7 @@ func (e *EventsController) HandleBitbucketServerCommentEvent(w http.ResponseWrit
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	e.handleCommentEvent(w, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud)
+	e.handleCommentEvent(w, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud, bitbucketServerRequestIDHeader+"="+reqID)
 }
 
 func (e *EventsController) handleBitbucketCloudPullRequestEvent(w http.ResponseWriter, eventType string, body []byte, reqID string) {
@@ -291,7 +408,7 @@ func (e *EventsController) handleBitbucketCloudPullRequestEvent(w http.ResponseW
 	}
 	pullEventType := e.Parser.GetBitbucketCloudPullEventType(eventType)
 	e.Logger.Info("identified event as type %q", pullEventType.String())
-	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType, bitbucketCloudRequestIDHeader+"="+reqID)
 }
 
 func (e *EventsController) handleBitbucketServerPullRequestEvent(w http.ResponseWriter, eventType string, body []byte, reqID string) {
@@ -302,7 +419,7 @@ func (e *EventsController) handleBitbucketServerPullRequestEvent(w http.Response
 	}
 	pullEventType := e.Parser.GetBitbucketServerPullEventType(eventType)
 	e.Logger.Info("identified event as type %q", pullEventType.String())
-	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType, bitbucketServerRequestIDHeader+"="+reqID)
 }
 
 // HandleGithubPullRequestEvent will delete any locks associated with the pull
@@ -315,10 +432,10 @@ func (e *EventsController) HandleGithubPullRequestEvent(w http.ResponseWriter, p
 		return
 	}
 	e.Logger.Info("identified event as type %q", pullEventType.String())
-	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType, githubReqID)
 }
 
-func (e *EventsController) handlePullRequestEvent(w http.ResponseWriter, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, eventType models.PullRequestEventType) {
+func (e *EventsController) handlePullRequestEvent(w http.ResponseWriter, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, eventType models.PullRequestEventType, reqID string) {
 	if !e.RepoWhitelistChecker.IsWhitelisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
 		// If the repo isn't whitelisted and we receive an opened pull request
 		// event we comment back on the pull request that the repo isn't
@@ -336,19 +453,16 @@ func (e *EventsController) handlePullRequestEvent(w http.ResponseWriter, baseRep
 	switch eventType {
 	case models.OpenedPullEvent, models.UpdatedPullEvent:
 		// If the pull request was opened or updated, we will try to autoplan.
-
-		// Respond with success and then actually execute the command asynchronously.
-		// We use a goroutine so that this function returns and the connection is
-		// closed.
-		fmt.Fprintln(w, "Processing...")
-
 		e.Logger.Info("executing autoplan")
-		if !e.TestingMode {
-			go e.CommandRunner.RunAutoplanCommand(baseRepo, headRepo, pull, user)
-		} else {
-			// When testing we want to wait for everything to complete.
-			e.CommandRunner.RunAutoplanCommand(baseRepo, headRepo, pull, user)
-		}
+		e.runCommand(w, models.QueuedEvent{
+			RequestID: reqID,
+			Autoplan:  true,
+			BaseRepo:  baseRepo,
+			HeadRepo:  &headRepo,
+			Pull:      &pull,
+			PullNum:   pull.Num,
+			User:      user,
+		})
 		return
 	case models.ClosedPullEvent:
 		// If the pull request was closed, we delete locks.
@@ -373,14 +487,15 @@ func (e *EventsController) handleGitlabPost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	e.Logger.Debug("request valid")
+	gitlabReqID := "X-Gitlab-Event-UUID=" + r.Header.Get("X-Gitlab-Event-UUID")
 
 	switch event := event.(type) {
 	case gitlab.MergeCommentEvent:
 		e.Logger.Debug("handling as comment event")
-		e.HandleGitlabCommentEvent(w, event)
+		e.HandleGitlabCommentEvent(w, event, gitlabReqID)
 	case gitlab.MergeEvent:
 		e.Logger.Debug("handling as pull request event")
-		e.HandleGitlabMergeRequestEvent(w, event)
+		e.HandleGitlabMergeRequestEvent(w, event, gitlabReqID)
 	case gitlab.CommitCommentEvent:
 		e.Logger.Debug("comments on commits are not supported, only comments on merge requests")
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment on commit event")
@@ -392,17 +507,17 @@ func (e *EventsController) handleGitlabPost(w http.ResponseWriter, r *http.Reque
 
 // HandleGitlabCommentEvent handles comment events from GitLab where Atlantis
 // commands can come from. It's exported to make testing easier.
-func (e *EventsController) HandleGitlabCommentEvent(w http.ResponseWriter, event gitlab.MergeCommentEvent) {
+func (e *EventsController) HandleGitlabCommentEvent(w http.ResponseWriter, event gitlab.MergeCommentEvent, gitlabReqID string) {
 	// todo: can gitlab return the pull request here too?
 	baseRepo, headRepo, user, err := e.Parser.ParseGitlabMergeRequestCommentEvent(event)
 	if err != nil {
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing webhook: %s", err)
 		return
 	}
-	e.handleCommentEvent(w, baseRepo, &headRepo, nil, user, event.MergeRequest.IID, event.ObjectAttributes.Note, models.Gitlab)
+	e.handleCommentEvent(w, baseRepo, &headRepo, nil, user, event.MergeRequest.IID, event.ObjectAttributes.Note, models.Gitlab, gitlabReqID)
 }
 
-func (e *EventsController) handleCommentEvent(w http.ResponseWriter, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string, vcsHost models.VCSHostType) {
+func (e *EventsController) handleCommentEvent(w http.ResponseWriter, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string, vcsHost models.VCSHostType, reqID string) {
 	parseResult := e.CommentParser.Parse(comment, vcsHost)
 	if parseResult.Ignore {
 		truncated := comment
@@ -436,29 +551,85 @@ func (e *EventsController) handleCommentEvent(w http.ResponseWriter, baseRepo mo
 	}
 
 	e.Logger.Debug("executing command")
+	e.runCommand(w, models.QueuedEvent{
+		RequestID: reqID,
+		Comment:   events.NewQueuedComment(parseResult.Command),
+		BaseRepo:  baseRepo,
+		HeadRepo:  maybeHeadRepo,
+		Pull:      maybePull,
+		PullNum:   pullNum,
+		User:      user,
+	})
+}
+
+// runCommand runs the command for a webhook. If there's an event queue, the
+// event is added to it and run by its workers. Otherwise, or if it's a cancel
+// or unlock, the command is run straight away, tracked by e.Drainer so that
+// shutdown waits for it. If Atlantis has started shutting down, the command
+// isn't run and we respond with an error so that the VCS host retries the
+// webhook.
+func (e *EventsController) runCommand(w http.ResponseWriter, event models.QueuedEvent) {
+	if e.EventQueue != nil && !skipsEventQueue(event) {
+		added, err := e.EventQueue.Enqueue(event)
+		if err != nil {
+			e.respond(w, logging.Error, http.StatusInternalServerError, "Failed queueing event: %s", err)
+			return
+		}
+		if !added {
+			e.respond(w, logging.Info, http.StatusOK, "Ignoring event %s since it was already received", event.RequestID)
+			return
+		}
+		fmt.Fprintln(w, "Processing...")
+		return
+	}
+
+	if !e.Drainer.StartOp() {
+		e.respondShuttingDown(w)
+		return
+	}
 	fmt.Fprintln(w, "Processing...")
-	if !e.TestingMode {
-		// Respond with success and then actually execute the command asynchronously.
-		// We use a goroutine so that this function returns and the connection is
-		// closed.
-		go e.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, maybePull, user, pullNum, parseResult.Command)
-	} else {
+	run := func() {
+		defer e.Drainer.OpDone()
+		if err := events.RunEvent(e.CommandRunner, event); err != nil {
+			e.Logger.Err("running command: %s", err)
+		}
+	}
+	if e.TestingMode {
 		// When testing we want to wait for everything to complete.
-		e.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, maybePull, user, pullNum, parseResult.Command)
+		run()
+		return
 	}
+	// Respond with success and then actually execute the command asynchronously.
+	// We use a goroutine so that this function returns and the connection is
+	// closed.
+	go run()
+}
+
+// skipsEventQueue returns true if event is a cancel or unlock. They're quick
+// and are often for the commands taking up the workers, so they mustn't wait
+// behind them in the queue.
+func skipsEventQueue(event models.QueuedEvent) bool {
+	if event.Comment == nil {
+		return false
+	}
+	return event.Comment.Name == models.CancelCommand || event.Comment.Name == models.UnlockCommand
+}
+
+func (e *EventsController) respondShuttingDown(w http.ResponseWriter) {
+	e.respond(w, logging.Warn, http.StatusServiceUnavailable, "Atlantis is shutting down, try again later")
 }
 
 // HandleGitlabMergeRequestEvent will delete any locks associated with the pull
 // request if the event is a merge request closed event. It's exported to make
 // testing easier.
-func (e *EventsController) HandleGitlabMergeRequestEvent(w http.ResponseWriter, event gitlab.MergeEvent) {
+func (e *EventsController) HandleGitlabMergeRequestEvent(w http.ResponseWriter, event gitlab.MergeEvent, gitlabReqID string) {
 	pull, pullEventType, baseRepo, headRepo, user, err := e.Parser.ParseGitlabMergeRequestEvent(event)
 	if err != nil {
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing webhook: %s", err)
 		return
 	}
 	e.Logger.Info("identified event as type %q", pullEventType.String())
-	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType, gitlabReqID)
 }
 
 // HandleAzureDevopsPullRequestCommentedEvent handles comment events from Azure DevOps where Atlantis
@@ -490,7 +661,7 @@ func (e *EventsController) HandleAzureDevopsPullRequestCommentedEvent(w http.Res
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull request repository field: %s; %s", err, azuredevopsReqID)
 		return
 	}
-	e.handleCommentEvent(w, baseRepo, nil, nil, user, resource.PullRequest.GetPullRequestID(), string(strippedComment), models.AzureDevops)
+	e.handleCommentEvent(w, baseRepo, nil, nil, user, resource.PullRequest.GetPullRequestID(), string(strippedComment), models.AzureDevops, azuredevopsReqID)
 }
 
 // HandleAzureDevopsPullRequestEvent will delete any locks associated with the pull
@@ -509,7 +680,44 @@ func (e *EventsController) HandleAzureDevopsPullRequestEvent(w http.ResponseWrit
 		return
 	}
 	e.Logger.Info("identified event as type %q", pullEventType.String())
-	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType, azuredevopsReqID)
+}
+
+// HandleGiteaCommentEvent handles comment events from Gitea where Atlantis
+// commands can come from. It's exported to make testing easier.
+func (e *EventsController) HandleGiteaCommentEvent(w http.ResponseWriter, event *gitea.IssueCommentEvent, giteaReqID string) {
+	if event.Action == nil || *event.Action != "created" {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment event since action was not created %s", giteaReqID)
+		return
+	}
+	// Gitea sends the same event for comments on issues and pull requests.
+	if !event.IsPull {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment event since it was not on a pull request %s", giteaReqID)
+		return
+	}
+
+	baseRepo, user, pullNum, err := e.Parser.ParseGiteaIssueCommentEvent(event)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Failed parsing event: %v %s", err, giteaReqID)
+		return
+	}
+
+	// We pass in nil for maybeHeadRepo because the head repo data isn't
+	// available in the comment event.
+	e.handleCommentEvent(w, baseRepo, nil, nil, user, pullNum, *event.Comment.Body, models.Gitea, giteaReqID)
+}
+
+// HandleGiteaPullRequestEvent will delete any locks associated with the pull
+// request if the event is a pull request closed event. It's exported to make
+// testing easier.
+func (e *EventsController) HandleGiteaPullRequestEvent(w http.ResponseWriter, pullEvent *gitea.PullRequestEvent, giteaReqID string) {
+	pull, pullEventType, baseRepo, headRepo, user, err := e.Parser.ParseGiteaPullEvent(pullEvent)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s", err, giteaReqID)
+		return
+	}
+	e.Logger.Info("identified event as type %q", pullEventType.String())
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType, giteaReqID)
 }
 
 // supportsHost returns true if h is in e.SupportedVCSHosts and false otherwise.
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// historyTimeFormat is how times are rendered on the history view.
+const historyTimeFormat = "02-01-2006 15:04:05"
+
+// HistoryController handles requests for the plan and apply history of pull
+// requests.
+type HistoryController struct {
+	AtlantisVersion string
+	AtlantisURL     *url.URL
+	DB              locking.Backend
+	Logger          *logging.SimpleLogger
+	HistoryTemplate TemplateWriter
+}
+
+// GetHistory is the GET /history route. It renders the history of the pull
+// request given by the repo and pull query params, ex.
+// /history?repo=github.com/runatlantis/atlantis&pull=1.
+func (h *HistoryController) GetHistory(w http.ResponseWriter, r *http.Request) {
+	repoID := r.URL.Query().Get("repo")
+	parts := strings.SplitN(repoID, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		h.respond(w, logging.Warn, http.StatusBadRequest, "Invalid repo %q, must be of the form {vcs hostname}/{owner}/{repo}", repoID)
+		return
+	}
+	pullNum, err := strconv.Atoi(r.URL.Query().Get("pull"))
+	if err != nil || pullNum <= 0 {
+		h.respond(w, logging.Warn, http.StatusBadRequest, "Invalid pull request number %q", r.URL.Query().Get("pull"))
+		return
+	}
+
+	pull := models.PullRequest{
+		Num: pullNum,
+		BaseRepo: models.Repo{
+			FullName: parts[1],
+			VCSHost:  models.VCSHost{Hostname: parts[0]},
+		},
+	}
+	runs, err := h.DB.GetProjectRuns(pull)
+	if err != nil {
+		h.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting history: %s", err)
+		return
+	}
+
+	// Show the newest runs first.
+	var runData []HistoryRunData
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		runData = append(runData, HistoryRunData{
+			Command:           run.Command.String(),
+			Path:              run.RepoRelDir,
+			Workspace:         run.Workspace,
+			ProjectName:       run.ProjectName,
+			Status:            run.Status.String(),
+			Output:            run.Output,
+			User:              run.User,
+			HeadCommit:        run.HeadCommit,
+			StartedFormatted:  run.StartedAt.Format(historyTimeFormat),
+			FinishedFormatted: run.FinishedAt.Format(historyTimeFormat),
+		})
+	}
+
+	err = h.HistoryTemplate.Execute(w, HistoryData{
+		RepoFullName:    pull.BaseRepo.FullName,
+		PullNum:         pull.Num,
+		Runs:            runData,
+		AtlantisVersion: h.AtlantisVersion,
+		CleanedBasePath: h.AtlantisURL.Path,
+	})
+	if err != nil {
+		h.Logger.Err(err.Error())
+	}
+}
+
+// HistoryPath returns the path of the history view for pull, relative to the
+// Atlantis URL.
+func HistoryPath(pull models.PullRequest) string {
+	return fmt.Sprintf("/history?repo=%s&pull=%d", url.QueryEscape(pull.BaseRepo.ID()), pull.Num)
+}
+
+func (h *HistoryController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	h.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
@@ -0,0 +1,142 @@
+package server_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	sMocks "github.com/runatlantis/atlantis/server/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGetHistory_InvalidParams(t *testing.T) {
+	cases := []struct {
+		query  string
+		expErr string
+	}{
+		{
+			"",
+			"Invalid repo \"\", must be of the form {vcs hostname}/{owner}/{repo}",
+		},
+		{
+			"repo=github.com",
+			"Invalid repo \"github.com\", must be of the form {vcs hostname}/{owner}/{repo}",
+		},
+		{
+			"repo=github.com/owner/repo",
+			"Invalid pull request number \"\"",
+		},
+		{
+			"repo=github.com/owner/repo&pull=abc",
+			"Invalid pull request number \"abc\"",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			hc := server.HistoryController{
+				Logger: logging.NewNoopLogger(),
+			}
+			req, _ := http.NewRequest("GET", "/history?"+c.query, bytes.NewBuffer(nil))
+			w := httptest.NewRecorder()
+			hc.GetHistory(w, req)
+			responseContains(t, w, http.StatusBadRequest, c.expErr)
+		})
+	}
+}
+
+func TestGetHistory_Success(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+
+	pull := models.PullRequest{
+		Num: 1,
+		BaseRepo: models.Repo{
+			FullName: "owner/repo",
+			VCSHost:  models.VCSHost{Hostname: "github.com"},
+		},
+	}
+	planned := time.Now().Add(-time.Minute)
+	applied := time.Now()
+	Ok(t, boltdb.AddProjectRuns(pull, []models.ProjectRun{
+		{
+			Command:    models.PlanCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Status:     models.PlannedPlanStatus,
+			Output:     "plan output",
+			User:       "lkysow",
+			HeadCommit: "sha",
+			StartedAt:  planned,
+			FinishedAt: planned,
+		},
+		{
+			Command:    models.ApplyCommand,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Status:     models.AppliedPlanStatus,
+			Output:     "apply output",
+			User:       "lkysow",
+			HeadCommit: "sha",
+			StartedAt:  applied,
+			FinishedAt: applied,
+		},
+	}))
+
+	tmpl := sMocks.NewMockTemplateWriter()
+	atlantisURL, err := url.Parse("https://example.com/basepath")
+	Ok(t, err)
+	hc := server.HistoryController{
+		AtlantisVersion: "1300135",
+		AtlantisURL:     atlantisURL,
+		DB:              boltdb,
+		Logger:          logging.NewNoopLogger(),
+		HistoryTemplate: tmpl,
+	}
+	req, _ := http.NewRequest("GET", server.HistoryPath(pull), bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	hc.GetHistory(w, req)
+
+	// The newest runs should be first.
+	tmpl.VerifyWasCalledOnce().Execute(w, server.HistoryData{
+		RepoFullName: "owner/repo",
+		PullNum:      1,
+		Runs: []server.HistoryRunData{
+			{
+				Command:           "apply",
+				Path:              ".",
+				Workspace:         "default",
+				Status:            "applied",
+				Output:            "apply output",
+				User:              "lkysow",
+				HeadCommit:        "sha",
+				StartedFormatted:  applied.Format("02-01-2006 15:04:05"),
+				FinishedFormatted: applied.Format("02-01-2006 15:04:05"),
+			},
+			{
+				Command:           "plan",
+				Path:              ".",
+				Workspace:         "default",
+				Status:            "planned",
+				Output:            "plan output",
+				User:              "lkysow",
+				HeadCommit:        "sha",
+				StartedFormatted:  planned.Format("02-01-2006 15:04:05"),
+				FinishedFormatted: planned.Format("02-01-2006 15:04:05"),
+			},
+		},
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "/basepath",
+	})
+	responseContains(t, w, http.StatusOK, "")
+}
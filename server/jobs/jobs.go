@@ -0,0 +1,229 @@
+// Package jobs keeps the output of project commands in memory while they run
+// so that it can be streamed to the web UI.
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// finishedJobRetention is how long jobs are kept after they finish. Their
+// output is in the pull request comment by then so we only need to keep them
+// long enough for anyone watching to see the end.
+const finishedJobRetention = time.Hour
+
+// subscriberBuffer is how many lines can be waiting to be sent to a
+// subscriber before it's dropped for being too slow.
+const subscriberBuffer = 1000
+
+// Status is the status of a job.
+type Status int
+
+const (
+	// RunningStatus means the job's command is still running or hasn't
+	// started yet.
+	RunningStatus Status = iota
+	// SucceededStatus means the job's command succeeded.
+	SucceededStatus
+	// FailedStatus means the job's command failed or errored.
+	FailedStatus
+)
+
+// String returns the status as a string.
+func (s Status) String() string {
+	switch s {
+	case RunningStatus:
+		return "running"
+	case SucceededStatus:
+		return "succeeded"
+	case FailedStatus:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Info describes the project command a job is running.
+type Info struct {
+	// Command is the name of the command, ex. plan.
+	Command      string
+	RepoFullName string
+	PullNum      int
+	RepoRelDir   string
+	Workspace    string
+	ProjectName  string
+}
+
+// Job is the output of a single project command. All its methods are safe to
+// call on a nil Job, in which case they do nothing, so callers don't need to
+// check whether the output is being streamed.
+type Job struct {
+	Info
+	// ID uniquely identifies the job.
+	ID string
+	// URL is the URL of the job's page in the web UI.
+	URL       string
+	StartedAt time.Time
+
+	mu          sync.Mutex
+	lines       []string
+	status      Status
+	finishedAt  time.Time
+	subscribers map[chan string]struct{}
+}
+
+// Write appends line to the job's output and sends it to subscribers. Lines
+// written after the job finishes are dropped.
+func (j *Job) Write(line string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != RunningStatus {
+		return
+	}
+	j.lines = append(j.lines, line)
+	for ch := range j.subscribers {
+		select {
+		case ch <- line:
+		default:
+			// The subscriber isn't keeping up. We close its channel so that it
+			// can resubscribe from where it got to rather than block the
+			// command.
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Finish marks the job as succeeded or failed and closes the channels of its
+// subscribers. Only the first call has any effect.
+func (j *Job) Finish(success bool) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != RunningStatus {
+		return
+	}
+	j.status = FailedStatus
+	if success {
+		j.status = SucceededStatus
+	}
+	j.finishedAt = time.Now()
+	for ch := range j.subscribers {
+		close(ch)
+	}
+	j.subscribers = nil
+}
+
+// Status returns the status of the job.
+func (j *Job) Status() Status {
+	if j == nil {
+		return RunningStatus
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// Subscribe returns the lines of output after the first from lines and a
+// channel that receives each new line. The channel is closed when the job
+// finishes or if the subscriber falls too far behind, in which case it should
+// call Subscribe again with the number of lines it has received. Callers
+// must call unsubscribe when they're done.
+func (j *Job) Subscribe(from int) (lines []string, ch <-chan string, unsubscribe func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if from < 0 || from > len(j.lines) {
+		from = len(j.lines)
+	}
+	lines = append([]string(nil), j.lines[from:]...)
+	c := make(chan string, subscriberBuffer)
+	if j.status != RunningStatus {
+		close(c)
+		return lines, c, func() {}
+	}
+	if j.subscribers == nil {
+		j.subscribers = make(map[chan string]struct{})
+	}
+	j.subscribers[c] = struct{}{}
+	return lines, c, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subscribers[c]; ok {
+			delete(j.subscribers, c)
+			close(c)
+		}
+	}
+}
+
+// Registry holds the jobs that are running or finished recently.
+type Registry struct {
+	// URLGenerator generates the URLs of the jobs' pages. If nil, jobs
+	// won't have URLs.
+	URLGenerator URLGenerator
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// URLGenerator generates URLs to job pages.
+type URLGenerator interface {
+	// GenerateJobURL returns the full URL to the page for the job with id.
+	GenerateJobURL(id string) string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry(urlGenerator URLGenerator) *Registry {
+	return &Registry{
+		URLGenerator: urlGenerator,
+		jobs:         make(map[string]*Job),
+	}
+}
+
+// Start creates a new running job for the command described by info. It
+// returns nil if r is nil so that the job's methods do nothing.
+func (r *Registry) Start(info Info) *Job {
+	if r == nil {
+		return nil
+	}
+	job := &Job{
+		Info:      info,
+		ID:        uuid.New().String(),
+		StartedAt: time.Now(),
+	}
+	if r.URLGenerator != nil {
+		job.URL = r.URLGenerator.GenerateJobURL(job.ID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(job.StartedAt)
+	r.jobs[job.ID] = job
+	return job
+}
+
+// Get returns the job with id.
+func (r *Registry) Get(id string) (*Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	return job, ok
+}
+
+// prune deletes the jobs that finished more than finishedJobRetention before
+// now. r.mu must be held.
+func (r *Registry) prune(now time.Time) {
+	for id, job := range r.jobs {
+		job.mu.Lock()
+		expired := job.status != RunningStatus && now.Sub(job.finishedAt) > finishedJobRetention
+		job.mu.Unlock()
+		if expired {
+			delete(r.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package jobs_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/jobs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type urlGenerator struct{}
+
+func (urlGenerator) GenerateJobURL(id string) string {
+	return "https://atlantis/jobs/" + id
+}
+
+func TestRegistry_Start(t *testing.T) {
+	r := jobs.NewRegistry(urlGenerator{})
+	job := r.Start(jobs.Info{Command: "plan", RepoFullName: "owner/repo"})
+	Assert(t, job.ID != "", "exp id to be set")
+	Equals(t, "https://atlantis/jobs/"+job.ID, job.URL)
+	Equals(t, jobs.RunningStatus, job.Status())
+
+	got, ok := r.Get(job.ID)
+	Assert(t, ok, "exp job to be found")
+	Equals(t, job, got)
+	_, ok = r.Get("other")
+	Assert(t, !ok, "exp job not to be found")
+}
+
+// Test that the job methods can be called on the nil job returned when
+// there's no registry.
+func TestRegistry_StartNil(t *testing.T) {
+	var r *jobs.Registry
+	job := r.Start(jobs.Info{})
+	Assert(t, job == nil, "exp nil job")
+	job.Write("line")
+	job.Finish(true)
+	Equals(t, jobs.RunningStatus, job.Status())
+}
+
+func TestJob_Subscribe(t *testing.T) {
+	job := jobs.NewRegistry(nil).Start(jobs.Info{})
+	job.Write("1")
+	job.Write("2")
+
+	lines, ch, unsubscribe := job.Subscribe(1)
+	defer unsubscribe()
+	Equals(t, []string{"2"}, lines)
+
+	job.Write("3")
+	Equals(t, "3", <-ch)
+	job.Finish(false)
+	_, ok := <-ch
+	Assert(t, !ok, "exp channel to be closed when the job finishes")
+	Equals(t, jobs.FailedStatus, job.Status())
+
+	// Lines written after the job finishes are dropped.
+	job.Write("4")
+	lines, ch, unsubscribe = job.Subscribe(0)
+	defer unsubscribe()
+	Equals(t, []string{"1", "2", "3"}, lines)
+	_, ok = <-ch
+	Assert(t, !ok, "exp channel of finished job to be closed")
+}
+
+func TestJob_FinishOnce(t *testing.T) {
+	job := jobs.NewRegistry(nil).Start(jobs.Info{})
+	job.Finish(true)
+	job.Finish(false)
+	Equals(t, jobs.SucceededStatus, job.Status())
+}
+
+// Test that a subscriber that doesn't read its channel is dropped rather than
+// blocking writes.
+func TestJob_SlowSubscriber(t *testing.T) {
+	job := jobs.NewRegistry(nil).Start(jobs.Info{})
+	_, ch, unsubscribe := job.Subscribe(0)
+	defer unsubscribe()
+	for i := 0; i < 2000; i++ {
+		job.Write("line")
+	}
+	received := 0
+	for range ch {
+		received++
+	}
+	Assert(t, received < 2000, "exp subscriber to be dropped, got all lines")
+
+	// It can resubscribe from where it got to.
+	lines, _, unsubscribe2 := job.Subscribe(received)
+	defer unsubscribe2()
+	Equals(t, 2000-received, len(lines))
+}
+
+func TestWriter(t *testing.T) {
+	job := jobs.NewRegistry(nil).Start(jobs.Info{})
+	w := jobs.NewWriter(job)
+	_, err := w.Write([]byte("one\r\ntw"))
+	Ok(t, err)
+	_, err = w.Write([]byte("o\nthree"))
+	Ok(t, err)
+	lines, _, unsubscribe := job.Subscribe(0)
+	unsubscribe()
+	Equals(t, []string{"one", "two"}, lines)
+
+	w.Flush()
+	lines, _, unsubscribe = job.Subscribe(0)
+	unsubscribe()
+	Equals(t, []string{"one", "two", "three"}, lines)
+}
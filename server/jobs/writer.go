@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// Writer is an io.Writer that splits what's written to it into lines and
+// writes them to a job. It's safe for concurrent use so it can be used for
+// both the stdout and stderr of a command.
+type Writer struct {
+	job *Job
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter returns a Writer for job. If job is nil, the Writer discards
+// everything.
+func NewWriter(job *Job) *Writer {
+	return &Writer{job: job}
+}
+
+// Write writes each complete line in p to the job. Partial lines are held
+// until they're completed or Flush is called.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.job == nil {
+		return len(p), nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.job.Write(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any partial line that's left to the job.
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.job.Write(strings.TrimSuffix(string(w.buf), "\r"))
+		w.buf = nil
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// jobKeepaliveInterval is how often we send a comment on an idle job output
+// stream so that proxies don't close it.
+const jobKeepaliveInterval = 30 * time.Second
+
+// JobsController handles requests for the output of plans and applies while
+// they run.
+type JobsController struct {
+	AtlantisVersion string
+	AtlantisURL     *url.URL
+	Jobs            *jobs.Registry
+	Canceller       *events.CommandCanceller
+	Logger          *logging.SimpleLogger
+	JobTemplate     TemplateWriter
+}
+
+// GetJob is the GET /jobs/{id} route. It renders the job view, which follows
+// the job's output as it runs.
+func (j *JobsController) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := j.getJob(w, r)
+	if !ok {
+		return
+	}
+	err := j.JobTemplate.Execute(w, JobData{
+		ID:               job.ID,
+		Command:          job.Command,
+		RepoFullName:     job.RepoFullName,
+		PullNum:          job.PullNum,
+		Path:             job.RepoRelDir,
+		Workspace:        job.Workspace,
+		ProjectName:      job.ProjectName,
+		StartedFormatted: job.StartedAt.Format("02-01-2006 15:04:05"),
+		AtlantisVersion:  j.AtlantisVersion,
+		CleanedBasePath:  j.AtlantisURL.Path,
+	})
+	if err != nil {
+		j.Logger.Err(err.Error())
+	}
+}
+
+// GetJobOutput is the GET /jobs/{id}/output route. It streams the job's
+// output as server-sent events. Each line is a message whose id is the number
+// of lines sent so far so that browsers resume from where they got to when
+// they reconnect. When the job finishes, a "done" event with its status is
+// sent and the stream ends.
+func (j *JobsController) GetJobOutput(w http.ResponseWriter, r *http.Request) {
+	job, ok := j.getJob(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		j.respond(w, logging.Error, http.StatusInternalServerError, "Streaming isn't supported")
+		return
+	}
+	// sent is the number of lines the browser has already received.
+	sent, err := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if err != nil || sent < 0 {
+		sent = 0
+	}
+
+	lines, ch, unsubscribe := job.Subscribe(sent)
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stops nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range lines {
+		sent++
+		writeJobLine(w, sent, line)
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(jobKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				// If the job is still running we fell behind so we end the
+				// stream and let the browser reconnect from where it got to.
+				if status := job.Status(); status != jobs.RunningStatus {
+					fmt.Fprintf(w, "event: done\ndata: %s\n\n", status)
+					flusher.Flush()
+				}
+				return
+			}
+			sent++
+			writeJobLine(w, sent, line)
+			flusher.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// CancelJob is the POST /jobs/{id}/cancel route. Like commenting atlantis
+// cancel, it cancels all the commands running for the job's pull request.
+func (j *JobsController) CancelJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := j.getJob(w, r)
+	if !ok {
+		return
+	}
+	if job.Status() != jobs.RunningStatus {
+		j.respond(w, logging.Info, http.StatusBadRequest, "Job %q has already finished", job.ID)
+		return
+	}
+	cancelled := j.Canceller.Cancel(job.RepoFullName, job.PullNum)
+	// If auth is disabled we don't know who cancelled the job.
+	cancelledBy := ""
+	if id, ok := auth.FromContext(r.Context()); ok {
+		cancelledBy = fmt.Sprintf(" by %q", id.Username)
+	}
+	j.respond(w, logging.Info, http.StatusOK, "Cancelled %d running command(s) for %s#%d%s", cancelled, job.RepoFullName, job.PullNum, cancelledBy)
+}
+
+// writeJobLine writes line as the server-sent event with id.
+func writeJobLine(w http.ResponseWriter, id int, line string) {
+	fmt.Fprintf(w, "id: %d\n", id)
+	// A line can hold a multi-line message, ex. why the command failed, and
+	// events need a data field per line. Carriage returns also end lines in
+	// event streams so we drop them.
+	line = strings.Replace(line, "\r", "", -1)
+	for _, l := range strings.Split(line, "\n") {
+		fmt.Fprintf(w, "data: %s\n", l)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+func (j *JobsController) getJob(w http.ResponseWriter, r *http.Request) (*jobs.Job, bool) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok || id == "" {
+		j.respond(w, logging.Warn, http.StatusBadRequest, "No job id in request")
+		return nil, false
+	}
+	job, ok := j.Jobs.Get(id)
+	if !ok {
+		j.respond(w, logging.Info, http.StatusNotFound, "No job found with id %q. Jobs are only kept for a short time after they finish. Their output is in the pull request comments.", id)
+		return nil, false
+	}
+	return job, true
+}
+
+func (j *JobsController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	j.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
@@ -0,0 +1,125 @@
+package server_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	sMocks "github.com/runatlantis/atlantis/server/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGetJob_NotFound(t *testing.T) {
+	jc := server.JobsController{
+		Jobs:   jobs.NewRegistry(nil),
+		Logger: logging.NewNoopLogger(),
+	}
+	req, _ := http.NewRequest("GET", "/jobs/abc", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "abc"})
+	w := httptest.NewRecorder()
+	jc.GetJob(w, req)
+	responseContains(t, w, http.StatusNotFound, `No job found with id "abc"`)
+}
+
+func TestGetJob_Success(t *testing.T) {
+	RegisterMockTestingT(t)
+	registry := jobs.NewRegistry(nil)
+	job := registry.Start(jobs.Info{
+		Command:      "plan",
+		RepoFullName: "owner/repo",
+		PullNum:      1,
+		RepoRelDir:   "dir",
+		Workspace:    "default",
+	})
+	tmpl := sMocks.NewMockTemplateWriter()
+	atlantisURL, _ := url.Parse("https://example.com/basepath")
+	jc := server.JobsController{
+		AtlantisVersion: "1300135",
+		AtlantisURL:     atlantisURL,
+		Jobs:            registry,
+		Logger:          logging.NewNoopLogger(),
+		JobTemplate:     tmpl,
+	}
+	req, _ := http.NewRequest("GET", "/jobs/"+job.ID, bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": job.ID})
+	w := httptest.NewRecorder()
+	jc.GetJob(w, req)
+	tmpl.VerifyWasCalledOnce().Execute(w, server.JobData{
+		ID:               job.ID,
+		Command:          "plan",
+		RepoFullName:     "owner/repo",
+		PullNum:          1,
+		Path:             "dir",
+		Workspace:        "default",
+		StartedFormatted: job.StartedAt.Format("02-01-2006 15:04:05"),
+		AtlantisVersion:  "1300135",
+		CleanedBasePath:  "/basepath",
+	})
+}
+
+// Test that the output of a finished job is streamed followed by its status
+// and that the stream resumes after the Last-Event-ID.
+func TestGetJobOutput_Finished(t *testing.T) {
+	registry := jobs.NewRegistry(nil)
+	job := registry.Start(jobs.Info{Command: "plan"})
+	job.Write("line 1")
+	job.Write("line 2\nline 3")
+	job.Finish(true)
+	jc := server.JobsController{
+		Jobs:   registry,
+		Logger: logging.NewNoopLogger(),
+	}
+
+	t.Run("from start", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/jobs/"+job.ID+"/output", bytes.NewBuffer(nil))
+		req = mux.SetURLVars(req, map[string]string{"id": job.ID})
+		w := httptest.NewRecorder()
+		jc.GetJobOutput(w, req)
+		Equals(t, http.StatusOK, w.Code)
+		Equals(t, "text/event-stream", w.Header().Get("Content-Type"))
+		Equals(t, "id: 1\ndata: line 1\n\nid: 2\ndata: line 2\ndata: line 3\n\nevent: done\ndata: succeeded\n\n", w.Body.String())
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/jobs/"+job.ID+"/output", bytes.NewBuffer(nil))
+		req = mux.SetURLVars(req, map[string]string{"id": job.ID})
+		req.Header.Set("Last-Event-ID", "1")
+		w := httptest.NewRecorder()
+		jc.GetJobOutput(w, req)
+		Equals(t, "id: 2\ndata: line 2\ndata: line 3\n\nevent: done\ndata: succeeded\n\n", w.Body.String())
+	})
+}
+
+func TestCancelJob(t *testing.T) {
+	registry := jobs.NewRegistry(nil)
+	job := registry.Start(jobs.Info{Command: "apply", RepoFullName: "owner/repo", PullNum: 1})
+	canceller := &events.CommandCanceller{}
+	cmdCtx, done := canceller.Track("owner/repo", 1)
+	defer done()
+	jc := server.JobsController{
+		Jobs:      registry,
+		Canceller: canceller,
+		Logger:    logging.NewNoopLogger(),
+	}
+
+	req, _ := http.NewRequest("POST", "/jobs/"+job.ID+"/cancel", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": job.ID})
+	w := httptest.NewRecorder()
+	jc.CancelJob(w, req)
+	responseContains(t, w, http.StatusOK, "Cancelled 1 running command(s) for owner/repo#1")
+	Assert(t, cmdCtx.Err() != nil, "exp command to be cancelled")
+
+	// Finished jobs can't be cancelled.
+	job.Finish(false)
+	w = httptest.NewRecorder()
+	jc.CancelJob(w, req)
+	responseContains(t, w, http.StatusBadRequest, "has already finished")
+}
@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-
-	"github.com/runatlantis/atlantis/server/events/db"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -25,7 +25,7 @@ type LocksController struct {
 	LockDetailTemplate TemplateWriter
 	WorkingDir         events.WorkingDir
 	WorkingDirLocker   events.WorkingDirLocker
-	DB                 *db.BoltDB
+	DB                 locking.Backend
 }
 
 // GetLock is the GET /locks/{id} route. It renders the lock detail view.
@@ -63,6 +63,22 @@ func (l *LocksController) GetLock(w http.ResponseWriter, r *http.Request) {
 		RepoOwner:       owner,
 		RepoName:        repo,
 	}
+	// Locks created by older versions of Atlantis don't have the pull's repo.
+	if lock.Pull.BaseRepo != (models.Repo{}) {
+		viewData.HistoryPath = HistoryPath(lock.Pull)
+	}
+	queue, err := l.DB.GetQueue(lock.Project, lock.Workspace)
+	if err != nil {
+		l.Logger.Err("unable to get queue for lock %q: %s", idUnencoded, err)
+	}
+	for _, q := range queue {
+		viewData.Queue = append(viewData.Queue, LockQueueEntry{
+			PullRequestLink: q.Pull.URL,
+			PullNum:         q.Pull.Num,
+			User:            q.User.Username,
+			TimeFormatted:   q.Time.Format("02-01-2006 15:04:05"),
+		})
+	}
 
 	err = l.LockDetailTemplate.Execute(w, viewData)
 	if err != nil {
@@ -71,7 +87,8 @@ func (l *LocksController) GetLock(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteLock handles deleting the lock at id and commenting back on the
-// pull request that the lock has been deleted.
+// pull request that the lock has been deleted. If the user is logged in, who
+// deleted it is recorded in the comment and the pull request's history.
 func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 	id, ok := mux.Vars(r)["id"]
 	if !ok || id == "" {
@@ -93,6 +110,13 @@ func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 		l.respond(w, logging.Info, http.StatusNotFound, "No lock found at id %q", idUnencoded)
 		return
 	}
+	// If auth is disabled we don't know who deleted the lock.
+	var user, discardedBy string
+	if id, ok := auth.FromContext(r.Context()); ok {
+		user = id.Username
+		discardedBy = fmt.Sprintf(" by `%s`", user)
+		l.Logger.Info("lock %q was deleted by %q", idUnencoded, user)
+	}
 
 	// NOTE: Because BaseRepo was added to the PullRequest model later, previous
 	// installations of Atlantis will have locks in their DB that do not have
@@ -111,10 +135,11 @@ func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 		if err := l.DB.DeleteProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path); err != nil {
 			l.Logger.Err("unable to delete project status: %s", err)
 		}
+		l.recordDiscarded(*lock, user, discardedBy)
 
 		// Once the lock has been deleted, comment back on the pull request.
-		comment := fmt.Sprintf("**Warning**: The plan for dir: `%s` workspace: `%s` was **discarded** via the Atlantis UI.\n\n"+
-			"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace)
+		comment := fmt.Sprintf("**Warning**: The plan for dir: `%s` workspace: `%s` was **discarded** via the Atlantis UI%s.\n\n"+
+			"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace, discardedBy)
 		err = l.VCSClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment)
 		if err != nil {
 			l.respond(w, logging.Error, http.StatusInternalServerError, "Failed commenting on pull request: %s", err)
@@ -126,6 +151,25 @@ func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 	l.respond(w, logging.Info, http.StatusOK, "Deleted lock id %q", id)
 }
 
+// recordDiscarded adds the deletion of lock to its pull request's history.
+func (l *LocksController) recordDiscarded(lock models.ProjectLock, user string, discardedBy string) {
+	now := time.Now()
+	run := models.ProjectRun{
+		Command:    models.UnlockCommand,
+		RepoRelDir: lock.Project.Path,
+		Workspace:  lock.Workspace,
+		Status:     models.DiscardedPlanStatus,
+		Output:     fmt.Sprintf("The lock was deleted and the plan was discarded via the Atlantis UI%s.", discardedBy),
+		User:       user,
+		HeadCommit: lock.Pull.HeadCommit,
+		StartedAt:  now,
+		FinishedAt: now,
+	}
+	if err := l.DB.AddProjectRuns(lock.Pull, []models.ProjectRun{run}); err != nil {
+		l.Logger.Err("unable to save history: %s", err)
+	}
+}
+
 // respond is a helper function to respond and log the response. lvl is the log
 // level to log at, code is the HTTP response code.
 func (l *LocksController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
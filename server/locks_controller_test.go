@@ -8,12 +8,14 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/db"
 
 	"github.com/gorilla/mux"
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/locking/mocks"
 	mocks2 "github.com/runatlantis/atlantis/server/events/mocks"
@@ -93,6 +95,19 @@ func TestGetLock_Success(t *testing.T) {
 		Pull:      models.PullRequest{URL: "url", Author: "lkysow"},
 		Workspace: "workspace",
 	}, nil)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	db, err := db.New(tmp)
+	Ok(t, err)
+	queuedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = db.Enqueue(models.ProjectLock{
+		Project:   models.Project{RepoFullName: "owner/repo", Path: "path"},
+		Pull:      models.PullRequest{URL: "url2", Num: 2},
+		User:      models.User{Username: "queued"},
+		Workspace: "workspace",
+		Time:      queuedAt,
+	})
+	Ok(t, err)
 	tmpl := sMocks.NewMockTemplateWriter()
 	atlantisURL, err := url.Parse("https://example.com/basepath")
 	Ok(t, err)
@@ -102,6 +117,7 @@ func TestGetLock_Success(t *testing.T) {
 		LockDetailTemplate: tmpl,
 		AtlantisVersion:    "1300135",
 		AtlantisURL:        atlantisURL,
+		DB:                 db,
 	}
 	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
 	req = mux.SetURLVars(req, map[string]string{"id": "id"})
@@ -117,6 +133,14 @@ func TestGetLock_Success(t *testing.T) {
 		Workspace:       "workspace",
 		AtlantisVersion: "1300135",
 		CleanedBasePath: "/basepath",
+		Queue: []server.LockQueueEntry{
+			{
+				PullRequestLink: "url2",
+				PullNum:         2,
+				User:            "queued",
+				TimeFormatted:   queuedAt.Format("02-01-2006 15:04:05"),
+			},
+		},
 	})
 	responseContains(t, w, http.StatusOK, "")
 }
@@ -266,3 +290,52 @@ func TestDeleteLock_CommentSuccess(t *testing.T) {
 			"To `apply` this plan you must run `plan` again.")
 	workingDir.VerifyWasCalledOnce().DeleteForWorkspace(pull.BaseRepo, pull, "workspace")
 }
+
+func TestDeleteLock_RecordsUser(t *testing.T) {
+	t.Log("If the user is logged in, who deleted the lock should be recorded")
+	RegisterMockTestingT(t)
+
+	cp := vcsmocks.NewMockClient()
+	l := mocks.NewMockLocker()
+	pull := models.PullRequest{
+		Num:      1,
+		BaseRepo: models.Repo{FullName: "owner/repo"},
+	}
+	When(l.Unlock("id")).ThenReturn(&models.ProjectLock{
+		Pull:      pull,
+		Workspace: "workspace",
+		Project: models.Project{
+			Path:         "path",
+			RepoFullName: "owner/repo",
+		},
+	}, nil)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	db, err := db.New(tmp)
+	Ok(t, err)
+	lc := server.LocksController{
+		Locker:           l,
+		Logger:           logging.NewNoopLogger(),
+		VCSClient:        cp,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		WorkingDir:       mocks2.NewMockWorkingDir(),
+		DB:               db,
+	}
+	req, _ := http.NewRequest("DELETE", "", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "id"})
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Identity{Username: "alice"}))
+	w := httptest.NewRecorder()
+	lc.DeleteLock(w, req)
+	responseContains(t, w, http.StatusOK, "Deleted lock id \"id\"")
+	cp.VerifyWasCalled(Once()).CreateComment(pull.BaseRepo, pull.Num,
+		"**Warning**: The plan for dir: `path` workspace: `workspace` was **discarded** via the Atlantis UI by `alice`.\n\n"+
+			"To `apply` this plan you must run `plan` again.")
+
+	runs, err := db.GetProjectRuns(pull)
+	Ok(t, err)
+	Equals(t, 1, len(runs))
+	Equals(t, models.UnlockCommand, runs[0].Command)
+	Equals(t, models.DiscardedPlanStatus, runs[0].Status)
+	Equals(t, "alice", runs[0].User)
+	Equals(t, "The lock was deleted and the plan was discarded via the Atlantis UI by `alice`.", runs[0].Output)
+}
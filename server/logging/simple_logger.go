@@ -16,11 +16,13 @@ package logging
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 	"unicode"
 )
@@ -57,6 +59,18 @@ type SimpleLogger struct {
 	Logger      *log.Logger
 	KeepHistory bool
 	Level       LogLevel
+	// JSON controls whether log entries are written as JSON objects, one per
+	// line, instead of as text.
+	JSON bool
+	// historyMutex guards writes to History since the same logger can be
+	// used by projects that are running in parallel.
+	historyMutex sync.Mutex
+	// fields are added to each JSON log entry. They're set by WithFields.
+	fields map[string]interface{}
+	// historyParent is the logger whose History entries are saved to. It's
+	// set for loggers created by WithFields so that their entries end up in
+	// the same history as their parent's.
+	historyParent *SimpleLogger
 }
 
 type LogLevel int
@@ -107,6 +121,37 @@ func (l *SimpleLogger) NewLogger(source string, keepHistory bool, lvl LogLevel)
 		Level:       lvl,
 		Logger:      l.Underlying(),
 		KeepHistory: keepHistory,
+		JSON:        l.JSON,
+	}
+}
+
+// WithFields returns a logger that adds fields to each JSON log entry in
+// addition to any fields already set on l, ex. the pull request number. The
+// returned logger writes to the same underlying logger as l and its entries
+// are saved to l's History rather than its own.
+func (l *SimpleLogger) WithFields(fields map[string]interface{}) *SimpleLogger {
+	if l == nil {
+		return nil
+	}
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	historyParent := l
+	if l.historyParent != nil {
+		historyParent = l.historyParent
+	}
+	return &SimpleLogger{
+		Source:        l.Source,
+		Level:         l.Level,
+		Logger:        l.Logger,
+		KeepHistory:   l.KeepHistory,
+		JSON:          l.JSON,
+		fields:        merged,
+		historyParent: historyParent,
 	}
 }
 
@@ -117,6 +162,27 @@ func (l *SimpleLogger) SetLevel(lvl LogLevel) {
 	}
 }
 
+// SetFields adds fields to each JSON log entry written by this logger,
+// overwriting any existing fields with the same keys.
+func (l *SimpleLogger) SetFields(fields map[string]interface{}) {
+	if l == nil {
+		return
+	}
+	if l.fields == nil {
+		l.fields = make(map[string]interface{}, len(fields))
+	}
+	for k, v := range fields {
+		l.fields[k] = v
+	}
+}
+
+// SetJSON changes whether this logger writes entries as JSON.
+func (l *SimpleLogger) SetJSON(enabled bool) {
+	if l != nil {
+		l.JSON = enabled
+	}
+}
+
 // Debug logs at debug level.
 func (l *SimpleLogger) Debug(format string, a ...interface{}) {
 	if l != nil {
@@ -153,13 +219,17 @@ func (l *SimpleLogger) Log(level LogLevel, format string, a ...interface{}) {
 
 		// Only log this message if configured to log at this level.
 		if l.Level <= level {
-			datetime := time.Now().Format("2006/01/02 15:04:05-0700")
-			var caller string
-			if l.Level <= Debug {
-				file, line := l.callSite(3)
-				caller = fmt.Sprintf(" %s:%d", file, line)
+			if l.JSON {
+				l.logJSON(level, msg)
+			} else {
+				datetime := time.Now().Format("2006/01/02 15:04:05-0700")
+				var caller string
+				if l.Level <= Debug {
+					file, line := l.callSite(3)
+					caller = fmt.Sprintf(" %s:%d", file, line)
+				}
+				l.Logger.Printf("%s [%s]%s %s: %s\n", datetime, levelStr, caller, l.Source, msg) // noline: errcheck
 			}
-			l.Logger.Printf("%s [%s]%s %s: %s\n", datetime, levelStr, caller, l.Source, msg) // noline: errcheck
 		}
 
 		// Keep history at all log levels.
@@ -179,7 +249,34 @@ func (l *SimpleLogger) GetLevel() LogLevel {
 	return l.Level
 }
 
+// logJSON writes msg as a single line JSON object. The caller is always
+// included since it's cheap relative to the cost of parsing it back out of
+// text logs.
+func (l *SimpleLogger) logJSON(level LogLevel, msg string) {
+	entry := make(map[string]interface{}, len(l.fields)+5)
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	file, line := l.callSite(4)
+	entry["time"] = time.Now().Format(time.RFC3339)
+	entry["level"] = l.levelToJSONString(level)
+	entry["caller"] = fmt.Sprintf("%s:%d", file, line)
+	entry["source"] = l.Source
+	entry["msg"] = msg
+	out, err := json.Marshal(entry)
+	if err != nil {
+		out = []byte(fmt.Sprintf(`{"level":"error","msg":%q}`, "unable to marshal log entry: "+err.Error()))
+	}
+	l.Logger.Println(string(out))
+}
+
 func (l *SimpleLogger) saveToHistory(level string, msg string) {
+	if l.historyParent != nil {
+		l.historyParent.saveToHistory(level, msg)
+		return
+	}
+	l.historyMutex.Lock()
+	defer l.historyMutex.Unlock()
 	l.History.WriteString(fmt.Sprintf("[%s] %s\n", level, msg))
 }
 
@@ -205,6 +302,22 @@ func (l *SimpleLogger) levelToString(level LogLevel) string {
 	return "????"
 }
 
+// levelToJSONString returns the logging level as the lowercase strings most
+// log pipelines expect.
+func (l *SimpleLogger) levelToJSONString(level LogLevel) string {
+	switch level {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Warn:
+		return "warn"
+	case Error:
+		return "error"
+	}
+	return "unknown"
+}
+
 // callSite returns the location of the caller of this function via its
 // filename and line number. skip is the number of stack frames to skip.
 func (l *SimpleLogger) callSite(skip int) (string, int) {
@@ -0,0 +1,49 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestSimpleLogger_JSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := &logging.SimpleLogger{
+		Source: "runatlantis/atlantis#1",
+		Logger: log.New(buf, "", 0),
+		Level:  logging.Info,
+		JSON:   true,
+	}
+	l.WithFields(map[string]interface{}{"pull": 1}).
+		WithFields(map[string]interface{}{"project": "dir/default"}).
+		Warn("a %s", "message")
+	l.Debug("not logged")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	Equals(t, 1, len(lines))
+	var entry map[string]interface{}
+	Ok(t, json.Unmarshal([]byte(lines[0]), &entry))
+	Equals(t, "warn", entry["level"])
+	Equals(t, "A message", entry["msg"])
+	Equals(t, "runatlantis/atlantis#1", entry["source"])
+	Equals(t, float64(1), entry["pull"])
+	Equals(t, "dir/default", entry["project"])
+	Assert(t, strings.HasPrefix(entry["caller"].(string), "simple_logger_test.go:"), "exp caller to be this file, got %q", entry["caller"])
+	Assert(t, entry["time"] != "", "exp time to be set")
+}
+
+func TestSimpleLogger_WithFieldsSharesHistory(t *testing.T) {
+	l := &logging.SimpleLogger{
+		Logger:      log.New(&bytes.Buffer{}, "", 0),
+		Level:       logging.Info,
+		KeepHistory: true,
+	}
+	l.Info("parent")
+	l.WithFields(map[string]interface{}{"project": "dir/default"}).Info("child")
+	Equals(t, "[INFO] Parent\n[INFO] Child\n", l.History.String())
+}
@@ -0,0 +1,114 @@
+// Package metrics holds the Prometheus metrics that Atlantis exposes. It's a
+// thin wrapper around the Prometheus client that names every metric with the
+// Atlantis namespace.
+//
+// All metric methods are safe to call on a nil receiver so that components can
+// be constructed without metrics, ex. in tests.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace is prefixed to the names of all Atlantis metrics.
+const Namespace = "atlantis"
+
+// DefaultBuckets are histogram buckets, in seconds, suited to fast operations
+// like API calls.
+var DefaultBuckets = prometheus.DefBuckets
+
+// LongBuckets are histogram buckets, in seconds, suited to slow operations
+// like running Terraform.
+var LongBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+// Registry holds metrics and serves them over HTTP.
+type Registry struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	registry := prometheus.NewRegistry()
+	return &Registry{
+		registry: registry,
+		handler:  promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// NewCounter registers and returns a counter. labelNames are the names of
+// the labels whose values must be passed to Inc, in order.
+func (r *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+	}, labelNames)
+	r.registry.MustRegister(vec)
+	return &Counter{vec: vec}
+}
+
+// NewHistogram registers and returns a histogram with the given upper
+// bounds. labelNames are the names of the labels whose values must be passed
+// to Observe, in order.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}, labelNames)
+	r.registry.MustRegister(vec)
+	return &Histogram{vec: vec}
+}
+
+// NewGaugeFunc registers a gauge whose value is computed by calling fn each
+// time the metrics are scraped.
+func (r *Registry) NewGaugeFunc(name string, help string, fn func() float64) {
+	r.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// ServeHTTP writes all registered metrics in the Prometheus exposition
+// format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.handler.ServeHTTP(w, req)
+}
+
+// Counter is a cumulative metric that only goes up.
+type Counter struct {
+	vec *prometheus.CounterVec
+}
+
+// Inc increments the counter for labelValues by 1.
+func (c *Counter) Inc(labelValues ...string) {
+	if c == nil {
+		return
+	}
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+// Histogram samples observations, ex. request durations, into buckets.
+type Histogram struct {
+	vec *prometheus.HistogramVec
+}
+
+// Observe adds v to the histogram for labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	h.vec.WithLabelValues(labelValues...).Observe(v)
+}
+
+// ObserveSince observes the number of seconds since start.
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
@@ -0,0 +1,49 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounter("webhooks_total", "Number of webhooks.", "vcs", "event")
+	h := r.NewHistogram("duration_seconds", "How long it took.", []float64{1, 5})
+	r.NewGaugeFunc("locks", "Number of locks.", func() float64 { return 2 })
+
+	c.Inc("Github", "pull_request")
+	c.Inc("Github", "pull_request")
+	c.Inc("Gitlab", `quote"d`)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	Equals(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	Equals(t, `# HELP atlantis_duration_seconds How long it took.
+# TYPE atlantis_duration_seconds histogram
+atlantis_duration_seconds_bucket{le="1"} 1
+atlantis_duration_seconds_bucket{le="5"} 2
+atlantis_duration_seconds_bucket{le="+Inf"} 2
+atlantis_duration_seconds_sum 3.5
+atlantis_duration_seconds_count 2
+# HELP atlantis_locks Number of locks.
+# TYPE atlantis_locks gauge
+atlantis_locks 2
+# HELP atlantis_webhooks_total Number of webhooks.
+# TYPE atlantis_webhooks_total counter
+atlantis_webhooks_total{event="pull_request",vcs="Github"} 2
+atlantis_webhooks_total{event="quote\"d",vcs="Gitlab"} 1
+`, w.Body.String())
+}
+
+func TestNilMetrics(t *testing.T) {
+	// Should not panic.
+	var c *metrics.Counter
+	c.Inc("a")
+	var h *metrics.Histogram
+	h.Observe(1, "a")
+}
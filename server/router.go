@@ -18,6 +18,10 @@ type Router struct {
 	// LockViewRouteIDQueryParam is the query parameter needed to construct the
 	// lock view: underlying.Get(LockViewRouteName).URL(LockViewRouteIDQueryParam, "my id").
 	LockViewRouteIDQueryParam string
+	// JobViewRouteName is the named route for the job view that can be Get'd
+	// from the Underlying router. Its path has an id variable for the job's
+	// ID.
+	JobViewRouteName string
 	// AtlantisURL is the fully qualified URL that Atlantis is
 	// accessible from externally.
 	AtlantisURL *url.URL
@@ -33,3 +37,9 @@ func (r *Router) GenerateLockURL(lockID string) string {
 	// golang likes to double escape the lockURL path when using url.Parse().
 	return r.AtlantisURL.String() + lockURL.String()
 }
+
+// GenerateJobURL returns a fully qualified URL to view the job with id.
+func (r *Router) GenerateJobURL(id string) string {
+	jobURL, _ := r.Underlying.Get(r.JobViewRouteName).URL("id", id)
+	return r.AtlantisURL.String() + jobURL.String()
+}
@@ -60,3 +60,37 @@ func TestRouter_GenerateLockURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_GenerateJobURL(t *testing.T) {
+	cases := []struct {
+		AtlantisURL string
+		ExpURL      string
+	}{
+		{
+			"http://localhost:4141",
+			"http://localhost:4141/jobs/1234",
+		},
+		{
+			"https://example.com/basepath/",
+			"https://example.com/basepath/jobs/1234",
+		},
+	}
+
+	routeName := "routename"
+	underlyingRouter := mux.NewRouter()
+	underlyingRouter.HandleFunc("/jobs/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods("GET").Name(routeName)
+
+	for _, c := range cases {
+		t.Run(c.AtlantisURL, func(t *testing.T) {
+			atlantisURL, err := server.ParseAtlantisURL(c.AtlantisURL)
+			Ok(t, err)
+
+			router := &server.Router{
+				AtlantisURL:      atlantisURL,
+				JobViewRouteName: routeName,
+				Underlying:       underlyingRouter,
+			}
+			Equals(t, c.ExpURL, router.GenerateJobURL("1234"))
+		})
+	}
+}
@@ -37,17 +37,22 @@ import (
 	assetfs "github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/redis"
 	"github.com/runatlantis/atlantis/server/events/runtime"
 	"github.com/runatlantis/atlantis/server/events/terraform"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
 	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 	"github.com/runatlantis/atlantis/server/static"
 	"github.com/urfave/cli"
 	"github.com/urfave/negroni"
@@ -62,23 +67,50 @@ const (
 	// route. ex:
 	//   mux.Router.Get(LockViewRouteName).URL(LockViewRouteIDQueryParam, "my id")
 	LockViewRouteIDQueryParam = "id"
+	// JobViewRouteName is the named route in mux.Router for the job view.
+	// Its URL is built from the job's id, ex:
+	//   mux.Router.Get(JobViewRouteName).URL("id", "my id")
+	JobViewRouteName = "job-detail"
+	// cancelledCommandsGracePeriod is how long we wait for the commands we
+	// interrupt at shutdown to stop. Terraform is killed if it hasn't exited
+	// a minute after it's interrupted so this leaves time to comment the
+	// results.
+	cancelledCommandsGracePeriod = 90 * time.Second
 )
 
 // Server runs the Atlantis web server.
 type Server struct {
-	AtlantisVersion    string
-	AtlantisURL        *url.URL
-	Router             *mux.Router
-	Port               int
-	CommandRunner      *events.DefaultCommandRunner
-	Logger             *logging.SimpleLogger
-	Locker             locking.Locker
-	EventsController   *EventsController
-	LocksController    *LocksController
-	IndexTemplate      TemplateWriter
-	LockDetailTemplate TemplateWriter
-	SSLCertFile        string
-	SSLKeyFile         string
+	AtlantisVersion   string
+	AtlantisURL       *url.URL
+	Router            *mux.Router
+	Port              int
+	CommandRunner     *events.DefaultCommandRunner
+	Logger            *logging.SimpleLogger
+	Locker            locking.Locker
+	EventsController  *EventsController
+	LocksController   *LocksController
+	APIController     *APIController
+	HistoryController *HistoryController
+	JobsController    *JobsController
+	// EventQueueController handles the admin view of the event queue.
+	EventQueueController *EventQueueController
+	Metrics              *metrics.Registry
+	IndexTemplate        TemplateWriter
+	LockDetailTemplate   TemplateWriter
+	DriftDetector        *events.DriftDetector
+	LockReaper           *events.LockReaper
+	EventQueue           *events.EventQueue
+	WebAuth              *auth.Middleware
+	Drainer              *events.Drainer
+	// DrainTimeout is how long we wait for running commands to finish when
+	// shutting down.
+	DrainTimeout time.Duration
+	// Canceller interrupts the commands that are still running once
+	// DrainTimeout has passed.
+	Canceller   *events.CommandCanceller
+	DB          locking.Backend
+	SSLCertFile string
+	SSLKeyFile  string
 }
 
 // Config holds config for server that isn't passed in by the user.
@@ -111,12 +143,18 @@ type WebhookConfig struct {
 // for the server CLI command because it injects all the dependencies.
 func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	logger := logging.NewSimpleLogger("server", false, userConfig.ToLogLevel())
+	logger.SetJSON(userConfig.LogFormat == "json")
 	var supportedVCSHosts []models.VCSHostType
 	var githubClient *vcs.GithubClient
 	var gitlabClient *vcs.GitlabClient
 	var bitbucketCloudClient *bitbucketcloud.Client
 	var bitbucketServerClient *bitbucketserver.Client
 	var azuredevopsClient *vcs.AzureDevopsClient
+	var giteaClient *gitea.Client
+	var githubAppCreds *vcs.GithubAppCredentials
+	// githubUser and githubToken are used in the clone urls of GitHub repos
+	// and githubCommentUser is the user that can be @mentioned in comments.
+	githubUser, githubToken, githubCommentUser := userConfig.GithubUser, userConfig.GithubToken, userConfig.GithubUser
 	if userConfig.GithubUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Github)
 		var err error
@@ -125,6 +163,26 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			return nil, err
 		}
 	}
+	if userConfig.GithubAppID != 0 {
+		supportedVCSHosts = append(supportedVCSHosts, models.Github)
+		var err error
+		githubAppCreds, err = vcs.NewGithubAppCredentials(userConfig.GithubHostname, int64(userConfig.GithubAppID), userConfig.GithubAppKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		githubClient, err = vcs.NewGithubAppClient(userConfig.GithubHostname, githubAppCreds)
+		if err != nil {
+			return nil, err
+		}
+		// Getting the App's slug also checks that the App's id and key are
+		// valid.
+		githubCommentUser, err = githubAppCreds.AppSlug()
+		if err != nil {
+			return nil, err
+		}
+		// The token is added when cloning since installation tokens expire.
+		githubUser, githubToken = vcs.GithubAppGitUser, ""
+	}
 	if userConfig.GitlabUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Gitlab)
 		var err error
@@ -163,6 +221,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			return nil, err
 		}
 	}
+	if userConfig.GiteaUser != "" {
+		supportedVCSHosts = append(supportedVCSHosts, models.Gitea)
+		var err error
+		giteaClient, err = gitea.NewClient(http.DefaultClient, userConfig.GiteaToken, userConfig.GiteaBaseURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "setting up Gitea client")
+		}
+	}
 
 	if userConfig.WriteGitCreds {
 		home, err := homedir.Dir()
@@ -195,6 +261,15 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				return nil, err
 			}
 		}
+		if userConfig.GiteaUser != "" {
+			giteaURL, err := url.Parse(userConfig.GiteaBaseURL)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing Gitea base URL %q", userConfig.GiteaBaseURL)
+			}
+			if err := events.WriteGitCreds(userConfig.GiteaUser, userConfig.GiteaToken, giteaURL.Host, home, logger); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	var webhooksConfig []webhooks.Config
@@ -211,8 +286,19 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing webhooks")
 	}
-	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient)
-	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient, StatusName: userConfig.VCSStatusName}
+	metricsRegistry := metrics.NewRegistry()
+	vcsLatency := metricsRegistry.NewHistogram("vcs_api_duration_seconds", "Latency of VCS API calls.", metrics.DefaultBuckets, "vcs", "method")
+	vcsErrors := metricsRegistry.NewCounter("vcs_api_errors_total", "Number of VCS API calls that errored.", "vcs", "method")
+	instrumentVCS := func(host models.VCSHostType, client vcs.Client) vcs.Client {
+		return &vcs.InstrumentedClient{Client: client, Host: host, Latency: vcsLatency, Errors: vcsErrors}
+	}
+	vcsClient := vcs.NewClientProxy(
+		instrumentVCS(models.Github, githubClient),
+		instrumentVCS(models.Gitlab, gitlabClient),
+		instrumentVCS(models.BitbucketCloud, bitbucketCloudClient),
+		instrumentVCS(models.BitbucketServer, bitbucketServerClient),
+		instrumentVCS(models.AzureDevops, azuredevopsClient),
+		instrumentVCS(models.Gitea, giteaClient))
 	terraformClient, err := terraform.NewClient(
 		logger,
 		userConfig.DataDir,
@@ -221,7 +307,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.DefaultTFVersion,
 		config.DefaultTFVersionFlag,
 		userConfig.TFDownloadURL,
-		&terraform.DefaultDownloader{})
+		&terraform.InstrumentedDownloader{
+			Downloader: &terraform.DefaultDownloader{},
+			Duration:   metricsRegistry.NewHistogram("terraform_download_duration_seconds", "Time taken to download Terraform binaries.", metrics.LongBuckets, "result"),
+		})
 	// The flag.Lookup call is to detect if we're running in a unit test. If we
 	// are, then we don't error out because we don't have/want terraform
 	// installed on our CI system where the unit tests run.
@@ -232,16 +321,43 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		GitlabSupportsCommonMark: gitlabClient.SupportsCommonMark(),
 		DisableApplyAll:          userConfig.DisableApplyAll,
 	}
-	boltdb, err := db.New(userConfig.DataDir)
+	commitStatusUpdater := &events.DefaultCommitStatusUpdater{
+		Client:           vcsClient,
+		StatusName:       userConfig.VCSStatusName,
+		MarkdownRenderer: markdownRenderer,
+	}
+	if userConfig.GithubChecks && githubAppCreds != nil {
+		commitStatusUpdater.Checks = githubClient
+	}
+	var backend locking.Backend
+	switch userConfig.LockingDBType {
+	case "redis":
+		logger.Info("using Redis at %s:%d as the locking database", userConfig.RedisHost, userConfig.RedisPort)
+		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
+	default:
+		backend, err = db.New(userConfig.DataDir)
+	}
 	if err != nil {
 		return nil, err
 	}
-	lockingClient := locking.NewClient(boltdb)
+	lockingClient := locking.NewClient(backend)
+	if userConfig.LockTTL != "" {
+		// The TTL was validated when parsing the flags.
+		lockTTL, err := time.ParseDuration(userConfig.LockTTL)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing lock ttl")
+		}
+		lockingClient.SetLockTTL(lockTTL)
+	}
+	locking.RegisterMetrics(metricsRegistry, lockingClient)
 	workingDirLocker := events.NewDefaultWorkingDirLocker()
 	workingDir := &events.FileWorkspace{
 		DataDir:       userConfig.DataDir,
 		CheckoutMerge: userConfig.CheckoutStrategy == "merge",
 	}
+	if githubAppCreds != nil {
+		workingDir.GithubAppTokens = githubAppCreds
+	}
 	projectLocker := &events.DefaultProjectLocker{
 		Locker: lockingClient,
 	}
@@ -270,6 +386,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AtlantisURL:               parsedURL,
 		LockViewRouteIDQueryParam: LockViewRouteIDQueryParam,
 		LockViewRouteName:         LockViewRouteName,
+		JobViewRouteName:          JobViewRouteName,
 		Underlying:                underlyingRouter,
 	}
 	pullClosedExecutor := &events.PullClosedExecutor{
@@ -277,24 +394,30 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Locker:     lockingClient,
 		WorkingDir: workingDir,
 		Logger:     logger,
-		DB:         boltdb,
+		DB:         backend,
 	}
 	eventParser := &events.EventParser{
-		GithubUser:         userConfig.GithubUser,
-		GithubToken:        userConfig.GithubToken,
+		GithubUser:         githubUser,
+		GithubToken:        githubToken,
+		GithubHostname:     userConfig.GithubHostname,
 		GitlabUser:         userConfig.GitlabUser,
 		GitlabToken:        userConfig.GitlabToken,
+		GitlabHostname:     userConfig.GitlabHostname,
 		BitbucketUser:      userConfig.BitbucketUser,
 		BitbucketToken:     userConfig.BitbucketToken,
 		BitbucketServerURL: userConfig.BitbucketBaseURL,
 		AzureDevopsUser:    userConfig.AzureDevopsUser,
 		AzureDevopsToken:   userConfig.AzureDevopsToken,
+		GiteaUser:          userConfig.GiteaUser,
+		GiteaToken:         userConfig.GiteaToken,
+		GiteaBaseURL:       userConfig.GiteaBaseURL,
 	}
 	commentParser := &events.CommentParser{
-		GithubUser:      userConfig.GithubUser,
+		GithubUser:      githubCommentUser,
 		GitlabUser:      userConfig.GitlabUser,
 		BitbucketUser:   userConfig.BitbucketUser,
 		AzureDevopsUser: userConfig.AzureDevopsUser,
+		GiteaUser:       userConfig.GiteaUser,
 	}
 	defaultTfVersion := terraformClient.DefaultVersion()
 	pendingPlanFinder := &events.DefaultPendingPlanFinder{}
@@ -303,11 +426,92 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		DefaultTFVersion:  defaultTfVersion,
 		TerraformBinDir:   terraformClient.TerraformBinDir(),
 	}
+	projectCommandBuilder := &events.DefaultProjectCommandBuilder{
+		ParserValidator:        validator,
+		ProjectFinder:          &events.DefaultProjectFinder{},
+		VCSClient:              vcsClient,
+		WorkingDir:             workingDir,
+		WorkingDirLocker:       workingDirLocker,
+		GlobalCfg:              globalCfg,
+		PendingPlanFinder:      pendingPlanFinder,
+		CommentBuilder:         commentParser,
+		TerraformVersionLister: terraformClient,
+	}
+	initStepRunner := &runtime.InitStepRunner{
+		TerraformExecutor: terraformClient,
+		DefaultTFVersion:  defaultTfVersion,
+	}
+	planStepRunner := &runtime.PlanStepRunner{
+		TerraformExecutor:   terraformClient,
+		DefaultTFVersion:    defaultTfVersion,
+		CommitStatusUpdater: commitStatusUpdater,
+		AsyncTFExec:         terraformClient,
+	}
+	var maxPlanAge time.Duration
+	if userConfig.MaxPlanAge != "" {
+		// The age was validated when parsing the flags.
+		maxPlanAge, err = time.ParseDuration(userConfig.MaxPlanAge)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing max plan age")
+		}
+	}
+	projectCommandRunner := &events.DefaultProjectCommandRunner{
+		Locker:           projectLocker,
+		LockURLGenerator: router,
+		InitStepRunner:   initStepRunner,
+		PlanStepRunner:   planStepRunner,
+		ApplyStepRunner: &runtime.ApplyStepRunner{
+			TerraformExecutor:   terraformClient,
+			CommitStatusUpdater: commitStatusUpdater,
+			AsyncTFExec:         terraformClient,
+		},
+		ShowStepRunner: &runtime.ShowStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		PolicyCheckStepRunner: &runtime.PolicyCheckStepRunner{
+			ConftestBinary: runtime.DefaultConftestBinary,
+		},
+		ImportStepRunner: &runtime.ImportStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		StateRmStepRunner: &runtime.StateRmStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		RunStepRunner: runStepRunner,
+		EnvStepRunner: &runtime.EnvStepRunner{
+			RunStepRunner: runStepRunner,
+		},
+		PullApprovedChecker: vcsClient,
+		WorkingDir:          workingDir,
+		Webhooks:            webhooksManager,
+		WorkingDirLocker:    workingDirLocker,
+		MaxPlanAge:          maxPlanAge,
+		CommandDuration:     metricsRegistry.NewHistogram("project_command_duration_seconds", "Time taken to run a command for a project.", metrics.LongBuckets, "command", "result"),
+	}
+	if githubAppCreds != nil && userConfig.WriteGitCreds {
+		// Installation tokens expire so instead of writing them to
+		// ~/.git-credentials, each step is given a current one.
+		projectCommandRunner.GithubAppTokens = githubAppCreds
+	}
+	var historyRetention time.Duration
+	if userConfig.HistoryRetention != "" {
+		// The retention was validated when parsing the flags.
+		historyRetention, err = time.ParseDuration(userConfig.HistoryRetention)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing history retention")
+		}
+	}
+	jobRegistry := jobs.NewRegistry(router)
+	canceller := &events.CommandCanceller{}
 	commandRunner := &events.DefaultCommandRunner{
 		VCSClient:                vcsClient,
 		GithubPullGetter:         githubClient,
 		GitlabMergeRequestGetter: gitlabClient,
 		AzureDevopsPullGetter:    azuredevopsClient,
+		GiteaPullGetter:          giteaClient,
 		CommitStatusUpdater:      commitStatusUpdater,
 		EventParser:              eventParser,
 		MarkdownRenderer:         markdownRenderer,
@@ -317,47 +521,98 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		SilenceForkPRErrors:      userConfig.SilenceForkPRErrors,
 		SilenceForkPRErrorsFlag:  config.SilenceForkPRErrorsFlag,
 		DisableApplyAll:          userConfig.DisableApplyAll,
-		ProjectCommandBuilder: &events.DefaultProjectCommandBuilder{
-			ParserValidator:   validator,
-			ProjectFinder:     &events.DefaultProjectFinder{},
-			VCSClient:         vcsClient,
-			WorkingDir:        workingDir,
-			WorkingDirLocker:  workingDirLocker,
-			GlobalCfg:         globalCfg,
-			PendingPlanFinder: pendingPlanFinder,
-			CommentBuilder:    commentParser,
-		},
-		ProjectCommandRunner: &events.DefaultProjectCommandRunner{
-			Locker:           projectLocker,
-			LockURLGenerator: router,
-			InitStepRunner: &runtime.InitStepRunner{
-				TerraformExecutor: terraformClient,
-				DefaultTFVersion:  defaultTfVersion,
-			},
-			PlanStepRunner: &runtime.PlanStepRunner{
-				TerraformExecutor:   terraformClient,
-				DefaultTFVersion:    defaultTfVersion,
-				CommitStatusUpdater: commitStatusUpdater,
-				AsyncTFExec:         terraformClient,
-			},
-			ApplyStepRunner: &runtime.ApplyStepRunner{
-				TerraformExecutor:   terraformClient,
-				CommitStatusUpdater: commitStatusUpdater,
-				AsyncTFExec:         terraformClient,
-			},
-			RunStepRunner: runStepRunner,
+		ProjectCommandBuilder:    projectCommandBuilder,
+		ProjectCommandRunner:     projectCommandRunner,
+		WorkingDir:               workingDir,
+		WorkingDirLocker:         workingDirLocker,
+		PendingPlanFinder:        pendingPlanFinder,
+		Locker:                   lockingClient,
+		DB:                       backend,
+		GlobalAutomerge:          userConfig.Automerge,
+		ParallelPoolSize:         userConfig.ParallelPoolSize,
+		HistoryRetention:         historyRetention,
+		Jobs:                     jobRegistry,
+		Canceller:                canceller,
+		GithubChecks:             commitStatusUpdater.Checks != nil,
+	}
+	// The lock queue needs the command runner which needs the locking client
+	// so we set it afterwards.
+	lockingClient.SetQueueNotifier(&events.LockQueueRunner{
+		DB:            backend,
+		CommandRunner: commandRunner,
+		VCSClient:     vcsClient,
+		Logger:        logger,
+	})
+	var driftDetector *events.DriftDetector
+	if userConfig.DriftDetectionInterval != "" {
+		// The interval was validated when parsing the flags.
+		interval, err := time.ParseDuration(userConfig.DriftDetectionInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing drift detection interval")
+		}
+		if len(globalCfg.DriftDetectionRepoIDs()) == 0 {
+			logger.Warn("drift detection is enabled but no repos have drift_detection enabled in the server-side repo config")
+		}
+		driftDetector = &events.DriftDetector{
+			GlobalCfg:       globalCfg,
+			VCSHostTypes:    supportedVCSHosts,
+			EventParser:     eventParser,
+			WorkingDir:      workingDir,
+			ProjectFinder:   &events.DefaultProjectFinder{},
+			ParserValidator: validator,
+			InitStepRunner:  initStepRunner,
+			PlanStepRunner:  planStepRunner,
+			RunStepRunner:   runStepRunner,
 			EnvStepRunner: &runtime.EnvStepRunner{
 				RunStepRunner: runStepRunner,
 			},
-			PullApprovedChecker: vcsClient,
-			WorkingDir:          workingDir,
-			Webhooks:            webhooksManager,
-			WorkingDirLocker:    workingDirLocker,
-		},
-		WorkingDir:        workingDir,
-		PendingPlanFinder: pendingPlanFinder,
-		DB:                boltdb,
-		GlobalAutomerge:   userConfig.Automerge,
+			DB:       backend,
+			Webhooks: webhooksManager,
+			Logger:   logger,
+			Interval: interval,
+		}
+		commandRunner.DriftDetector = driftDetector
+	}
+	var lockReaper *events.LockReaper
+	if userConfig.LockReaperInterval != "" {
+		// The interval was validated when parsing the flags.
+		interval, err := time.ParseDuration(userConfig.LockReaperInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing lock reaper interval")
+		}
+		lockReaper = &events.LockReaper{
+			Locker:           lockingClient,
+			VCSClient:        vcsClient,
+			PullCleaner:      pullClosedExecutor,
+			WorkingDir:       workingDir,
+			WorkingDirLocker: workingDirLocker,
+			DB:               backend,
+			DataDir:          userConfig.DataDir,
+			Logger:           logger,
+			Interval:         interval,
+		}
+	}
+	webAuth := &auth.Middleware{
+		Roles:  auth.NewRoles(userConfig.WebViewers, userConfig.WebAdmins),
+		Logger: logger,
+	}
+	switch userConfig.WebAuth {
+	case "basic":
+		webAuth.Authenticator, err = auth.NewBasicAuthenticator(userConfig.WebBasicAuthUsers)
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing web basic auth")
+		}
+	case "oidc":
+		webAuth.Authenticator, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{
+			IssuerURL:    userConfig.WebOIDCIssuerURL,
+			ClientID:     userConfig.WebOIDCClientID,
+			ClientSecret: userConfig.WebOIDCClientSecret,
+			AtlantisURL:  userConfig.AtlantisURL,
+			GroupsClaim:  userConfig.WebOIDCGroupsClaim,
+		}, logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing web OIDC auth")
+		}
 	}
 	repoWhitelist, err := events.NewRepoWhitelistChecker(userConfig.RepoWhitelist)
 	if err != nil {
@@ -372,7 +627,49 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		LockDetailTemplate: lockTemplate,
 		WorkingDir:         workingDir,
 		WorkingDirLocker:   workingDirLocker,
-		DB:                 boltdb,
+		DB:                 backend,
+	}
+	historyController := &HistoryController{
+		AtlantisVersion: config.AtlantisVersion,
+		AtlantisURL:     parsedURL,
+		DB:              backend,
+		Logger:          logger,
+		HistoryTemplate: historyTemplate,
+	}
+	jobsController := &JobsController{
+		AtlantisVersion: config.AtlantisVersion,
+		AtlantisURL:     parsedURL,
+		Jobs:            jobRegistry,
+		Canceller:       canceller,
+		Logger:          logger,
+		JobTemplate:     jobTemplate,
+	}
+	// drainer tracks the commands started by webhooks and API requests so
+	// that we can wait for them when shutting down.
+	drainer := &events.Drainer{}
+	eventQueue := &events.EventQueue{
+		DB:            backend,
+		CommandRunner: commandRunner,
+		Drainer:       drainer,
+		Logger:        logger,
+		Workers:       userConfig.EventWorkers,
+	}
+	eventQueueController := &EventQueueController{
+		AtlantisVersion:    config.AtlantisVersion,
+		AtlantisURL:        parsedURL,
+		EventQueue:         eventQueue,
+		Logger:             logger,
+		EventQueueTemplate: eventQueueTemplate,
+	}
+	apiController := &APIController{
+		APISecret:             []byte(userConfig.APISecret),
+		Locker:                lockingClient,
+		Logger:                logger,
+		Parser:                eventParser,
+		ProjectCommandBuilder: projectCommandBuilder,
+		CommandRunner:         commandRunner,
+		WorkingDir:            workingDir,
+		Drainer:               drainer,
 	}
 	eventsController := &EventsController{
 		CommandRunner:                   commandRunner,
@@ -392,35 +689,76 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AzureDevopsWebhookBasicUser:     []byte(userConfig.AzureDevopsWebhookUser),
 		AzureDevopsWebhookBasicPassword: []byte(userConfig.AzureDevopsWebhookPassword),
 		AzureDevopsRequestValidator:     &DefaultAzureDevopsRequestValidator{},
+		GiteaWebhookSecret:              []byte(userConfig.GiteaWebhookSecret),
+		WebhooksReceived:                metricsRegistry.NewCounter("webhooks_received_total", "Number of webhooks received.", "vcs", "event"),
+		Drainer:                         drainer,
+		EventQueue:                      eventQueue,
+		GithubAppID:                     int64(userConfig.GithubAppID),
+	}
+	var drainTimeout time.Duration
+	if userConfig.DrainTimeout != "" {
+		// The drain timeout was validated when parsing the flags.
+		drainTimeout, err = time.ParseDuration(userConfig.DrainTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing drain timeout")
+		}
 	}
 	return &Server{
-		AtlantisVersion:    config.AtlantisVersion,
-		AtlantisURL:        parsedURL,
-		Router:             underlyingRouter,
-		Port:               userConfig.Port,
-		CommandRunner:      commandRunner,
-		Logger:             logger,
-		Locker:             lockingClient,
-		EventsController:   eventsController,
-		LocksController:    locksController,
-		IndexTemplate:      indexTemplate,
-		LockDetailTemplate: lockTemplate,
-		SSLKeyFile:         userConfig.SSLKeyFile,
-		SSLCertFile:        userConfig.SSLCertFile,
+		AtlantisVersion:      config.AtlantisVersion,
+		AtlantisURL:          parsedURL,
+		Router:               underlyingRouter,
+		Port:                 userConfig.Port,
+		CommandRunner:        commandRunner,
+		Logger:               logger,
+		Locker:               lockingClient,
+		EventsController:     eventsController,
+		LocksController:      locksController,
+		APIController:        apiController,
+		HistoryController:    historyController,
+		JobsController:       jobsController,
+		EventQueueController: eventQueueController,
+		Metrics:              metricsRegistry,
+		IndexTemplate:        indexTemplate,
+		LockDetailTemplate:   lockTemplate,
+		DriftDetector:        driftDetector,
+		LockReaper:           lockReaper,
+		EventQueue:           eventQueue,
+		WebAuth:              webAuth,
+		Drainer:              drainer,
+		DrainTimeout:         drainTimeout,
+		Canceller:            canceller,
+		DB:                   backend,
+		SSLKeyFile:           userConfig.SSLKeyFile,
+		SSLCertFile:          userConfig.SSLCertFile,
 	}, nil
 }
 
 // Start creates the routes and starts serving traffic.
 func (s *Server) Start() error {
-	s.Router.HandleFunc("/", s.Index).Methods("GET").MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
+	// The UI routes require users to log in if web auth is enabled. Webhooks,
+	// the API (which uses the API secret) and health checks don't.
+	s.Router.HandleFunc("/", s.WebAuth.Require(auth.ViewPermission, s.Index)).Methods("GET").MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
 		return r.URL.Path == "/" || r.URL.Path == "/index.html"
 	})
 	s.Router.HandleFunc("/healthz", s.Healthz).Methods("GET")
+	s.Router.Handle("/metrics", s.Metrics).Methods("GET")
 	s.Router.PathPrefix("/static/").Handler(http.FileServer(&assetfs.AssetFS{Asset: static.Asset, AssetDir: static.AssetDir, AssetInfo: static.AssetInfo}))
 	s.Router.HandleFunc("/events", s.EventsController.Post).Methods("POST")
-	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
-	s.Router.HandleFunc("/lock", s.LocksController.GetLock).Methods("GET").
+	s.Router.HandleFunc("/locks", s.WebAuth.Require(auth.DeleteLocksPermission, s.LocksController.DeleteLock)).Methods("DELETE").Queries("id", "{id:.*}")
+	s.Router.HandleFunc("/history", s.WebAuth.Require(auth.ViewPermission, s.HistoryController.GetHistory)).Methods("GET")
+	s.Router.HandleFunc("/api/plan", s.APIController.Plan).Methods("POST")
+	s.Router.HandleFunc("/api/apply", s.APIController.Apply).Methods("POST")
+	s.Router.HandleFunc("/lock", s.WebAuth.Require(auth.ViewPermission, s.LocksController.GetLock)).Methods("GET").
 		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
+	s.Router.HandleFunc("/jobs/{id}", s.WebAuth.Require(auth.ViewPermission, s.JobsController.GetJob)).Methods("GET").Name(JobViewRouteName)
+	s.Router.HandleFunc("/jobs/{id}/output", s.WebAuth.Require(auth.ViewPermission, s.JobsController.GetJobOutput)).Methods("GET")
+	s.Router.HandleFunc("/jobs/{id}/cancel", s.WebAuth.Require(auth.CancelPermission, s.JobsController.CancelJob)).Methods("POST")
+	s.Router.HandleFunc("/event-queue", s.WebAuth.Require(auth.EventQueuePermission, s.EventQueueController.GetEventQueue)).Methods("GET")
+	s.Router.HandleFunc("/event-queue/{id}/retry", s.WebAuth.Require(auth.EventQueuePermission, s.EventQueueController.RetryEvent)).Methods("POST")
+	s.Router.HandleFunc("/event-queue/{id}", s.WebAuth.Require(auth.EventQueuePermission, s.EventQueueController.DiscardEvent)).Methods("DELETE")
+	for path, handler := range s.WebAuth.Routes() {
+		s.Router.HandleFunc(path, handler).Methods("GET")
+	}
 	n := negroni.New(&negroni.Recovery{
 		Logger:     log.New(os.Stdout, "", log.LstdFlags),
 		PrintStack: false,
@@ -449,13 +787,48 @@ func (s *Server) Start() error {
 			s.Logger.Err(err.Error())
 		}
 	}()
+
+	driftStop := make(chan struct{})
+	if s.DriftDetector != nil {
+		go s.DriftDetector.Run(driftStop)
+	}
+	reaperStop := make(chan struct{})
+	if s.LockReaper != nil {
+		go s.LockReaper.Run(reaperStop)
+	}
+	queueStop := make(chan struct{})
+	go s.EventQueue.Run(queueStop)
 	<-stop
 
 	s.Logger.Warn("Received interrupt. Safely shutting down")
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) // nolint: vet
+	close(driftStop)
+	close(reaperStop)
+	close(queueStop)
+
+	// We keep serving while we wait for the running commands so that
+	// webhooks get an error response telling the VCS host to retry them.
+	s.Logger.Info("waiting up to %s for running commands to finish", s.DrainTimeout)
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), s.DrainTimeout)
+	defer cancelDrain()
+	if running := s.Drainer.Drain(drainCtx); running > 0 {
+		// Interrupt the commands rather than abandon them so that terraform
+		// can release its state locks and save what it's done.
+		s.Logger.Warn("interrupting %d command(s) that are still running and waiting up to %s for them to stop", s.Canceller.CancelAll(), cancelledCommandsGracePeriod)
+		graceCtx, cancelGrace := context.WithTimeout(context.Background(), cancelledCommandsGracePeriod)
+		defer cancelGrace()
+		if running := s.Drainer.Drain(graceCtx); running > 0 {
+			s.Logger.Warn("shutting down with %d command(s) still running, their output will be lost", running)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
 		return cli.NewExitError(fmt.Sprintf("while shutting down: %s", err), 1)
 	}
+	if err := s.DB.Close(); err != nil {
+		return cli.NewExitError(fmt.Sprintf("while closing the database: %s", err), 1)
+	}
 	return nil
 }
 
@@ -487,8 +860,31 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	//Sort by date - newest to oldest.
 	sort.SliceStable(lockResults, func(i, j int) bool { return lockResults[i].Time.After(lockResults[j].Time) })
 
+	var driftResults []DriftIndexData
+	if s.DriftDetector != nil {
+		statuses, err := s.DriftDetector.Statuses()
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "Could not retrieve drift statuses: %s", err)
+			return
+		}
+		for _, d := range statuses {
+			driftResults = append(driftResults, DriftIndexData{
+				RepoFullName:         d.RepoFullName,
+				Path:                 d.RepoRelDir,
+				Workspace:            d.Workspace,
+				ProjectName:          d.ProjectName,
+				Drifted:              d.Drifted,
+				Error:                d.Error,
+				LastChecked:          d.LastChecked,
+				LastCheckedFormatted: d.LastChecked.Format("02-01-2006 15:04:05"),
+			})
+		}
+	}
+
 	err = s.IndexTemplate.Execute(w, IndexData{
 		Locks:           lockResults,
+		DriftStatuses:   driftResults,
 		AtlantisVersion: s.AtlantisVersion,
 		CleanedBasePath: s.AtlantisURL.Path,
 	})
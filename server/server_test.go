@@ -156,6 +156,53 @@ func TestIndex_Success(t *testing.T) {
 	responseContains(t, w, http.StatusOK, "")
 }
 
+// If drift detection is enabled, the drift statuses should be rendered.
+func TestIndex_DriftStatuses(t *testing.T) {
+	RegisterMockTestingT(t)
+	l := mocks.NewMockLocker()
+	When(l.List()).ThenReturn(map[string]models.ProjectLock{}, nil)
+	backend := mocks.NewMockBackend()
+	now := time.Now()
+	When(backend.GetDriftStatuses()).ThenReturn([]models.DriftStatus{
+		{
+			RepoFullName: "lkysow/atlantis-example",
+			VCSHostname:  "github.com",
+			RepoRelDir:   ".",
+			Workspace:    "default",
+			Drifted:      true,
+			LastChecked:  now,
+		},
+	}, nil)
+	it := sMocks.NewMockTemplateWriter()
+	u, err := url.Parse("https://example.com")
+	Ok(t, err)
+	s := server.Server{
+		Locker:          l,
+		IndexTemplate:   it,
+		Router:          mux.NewRouter(),
+		AtlantisVersion: "0.3.1",
+		AtlantisURL:     u,
+		DriftDetector:   &events.DriftDetector{DB: backend},
+	}
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.Index(w, req)
+	it.VerifyWasCalledOnce().Execute(w, server.IndexData{
+		DriftStatuses: []server.DriftIndexData{
+			{
+				RepoFullName:         "lkysow/atlantis-example",
+				Path:                 ".",
+				Workspace:            "default",
+				Drifted:              true,
+				LastChecked:          now,
+				LastCheckedFormatted: now.Format("02-01-2006 15:04:05"),
+			},
+		},
+		AtlantisVersion: "0.3.1",
+	})
+	responseContains(t, w, http.StatusOK, "")
+}
+
 func TestHealthz(t *testing.T) {
 	s := server.Server{}
 	req, _ := http.NewRequest("GET", "/healthz", bytes.NewBuffer(nil))
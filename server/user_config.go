@@ -8,6 +8,7 @@ import "github.com/runatlantis/atlantis/server/logging"
 type UserConfig struct {
 	AllowForkPRs               bool   `mapstructure:"allow-fork-prs"`
 	AllowRepoConfig            bool   `mapstructure:"allow-repo-config"`
+	APISecret                  string `mapstructure:"api-secret"`
 	AtlantisURL                string `mapstructure:"atlantis-url"`
 	Automerge                  bool   `mapstructure:"automerge"`
 	AzureDevopsToken           string `mapstructure:"azuredevops-token"`
@@ -21,6 +22,16 @@ type UserConfig struct {
 	CheckoutStrategy           string `mapstructure:"checkout-strategy"`
 	DataDir                    string `mapstructure:"data-dir"`
 	DisableApplyAll            bool   `mapstructure:"disable-apply-all"`
+	DrainTimeout               string `mapstructure:"drain-timeout"`
+	DriftDetectionInterval     string `mapstructure:"drift-detection-interval"`
+	EventWorkers               int    `mapstructure:"event-workers"`
+	GiteaBaseURL               string `mapstructure:"gitea-base-url"`
+	GiteaToken                 string `mapstructure:"gitea-token"`
+	GiteaUser                  string `mapstructure:"gitea-user"`
+	GiteaWebhookSecret         string `mapstructure:"gitea-webhook-secret"`
+	GithubAppID                int    `mapstructure:"gh-app-id"`
+	GithubAppKeyFile           string `mapstructure:"gh-app-key-file"`
+	GithubChecks               bool   `mapstructure:"gh-checks"`
 	GithubHostname             string `mapstructure:"gh-hostname"`
 	GithubToken                string `mapstructure:"gh-token"`
 	GithubUser                 string `mapstructure:"gh-user"`
@@ -29,8 +40,21 @@ type UserConfig struct {
 	GitlabToken                string `mapstructure:"gitlab-token"`
 	GitlabUser                 string `mapstructure:"gitlab-user"`
 	GitlabWebhookSecret        string `mapstructure:"gitlab-webhook-secret"`
+	HistoryRetention           string `mapstructure:"history-retention"`
+	LockReaperInterval         string `mapstructure:"lock-reaper-interval"`
+	LockTTL                    string `mapstructure:"lock-ttl"`
+	LockingDBType              string `mapstructure:"locking-db-type"`
+	LogFormat                  string `mapstructure:"log-format"`
 	LogLevel                   string `mapstructure:"log-level"`
+	MaxPlanAge                 string `mapstructure:"max-plan-age"`
+	ParallelPoolSize           int    `mapstructure:"parallel-pool-size"`
 	Port                       int    `mapstructure:"port"`
+	RedisDB                    int    `mapstructure:"redis-db"`
+	RedisHost                  string `mapstructure:"redis-host"`
+	RedisInsecureSkipVerify    bool   `mapstructure:"redis-insecure-skip-verify"`
+	RedisPassword              string `mapstructure:"redis-password"`
+	RedisPort                  int    `mapstructure:"redis-port"`
+	RedisTLSEnabled            bool   `mapstructure:"redis-tls-enabled"`
 	RepoConfig                 string `mapstructure:"repo-config"`
 	RepoConfigJSON             string `mapstructure:"repo-config-json"`
 	RepoWhitelist              string `mapstructure:"repo-whitelist"`
@@ -50,6 +74,14 @@ type UserConfig struct {
 	TFEToken               string          `mapstructure:"tfe-token"`
 	VCSStatusName          string          `mapstructure:"vcs-status-name"`
 	DefaultTFVersion       string          `mapstructure:"default-tf-version"`
+	WebAdmins              string          `mapstructure:"web-admins"`
+	WebAuth                string          `mapstructure:"web-auth"`
+	WebBasicAuthUsers      string          `mapstructure:"web-basic-auth-users"`
+	WebOIDCClientID        string          `mapstructure:"web-oidc-client-id"`
+	WebOIDCClientSecret    string          `mapstructure:"web-oidc-client-secret"`
+	WebOIDCGroupsClaim     string          `mapstructure:"web-oidc-groups-claim"`
+	WebOIDCIssuerURL       string          `mapstructure:"web-oidc-issuer-url"`
+	WebViewers             string          `mapstructure:"web-viewers"`
 	Webhooks               []WebhookConfig `mapstructure:"webhooks"`
 	WriteGitCreds          bool            `mapstructure:"write-git-creds"`
 }
@@ -40,9 +40,23 @@ type LockIndexData struct {
 	TimeFormatted string
 }
 
+// DriftIndexData holds the fields needed to display the drift status of a
+// project on the index view.
+type DriftIndexData struct {
+	RepoFullName         string
+	Path                 string
+	Workspace            string
+	ProjectName          string
+	Drifted              bool
+	Error                string
+	LastChecked          time.Time
+	LastCheckedFormatted string
+}
+
 // IndexData holds the data for rendering the index page
 type IndexData struct {
 	Locks           []LockIndexData
+	DriftStatuses   []DriftIndexData
 	AtlantisVersion string
 	// CleanedBasePath is the path Atlantis is accessible at externally. If
 	// not using a path-based proxy, this will be an empty string. Never ends
@@ -103,6 +117,19 @@ var indexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
     <p class="placeholder">No locks found.</p>
     {{ end }}
   </section>
+  {{ if .DriftStatuses }}
+  <br>
+  <section>
+    <p class="title-heading small"><strong>Drift</strong></p>
+    {{ range .DriftStatuses }}
+      <div class="twelve columns button content lock-row">
+      <div class="list-title">{{.RepoFullName}} {{ if .ProjectName }}<span class="heading-font-size">{{.ProjectName}}</span> {{ end }}<code>{{.Path}}</code> <code>{{.Workspace}}</code></div>
+      <div class="list-status">{{ if .Error }}<code title="{{.Error}}">Error</code>{{ else if .Drifted }}<code>Drifted</code>{{ else }}<code>In Sync</code>{{ end }}</div>
+      <div class="list-timestamp"><span class="heading-font-size">{{.LastCheckedFormatted}}</span></div>
+      </div>
+    {{ end }}
+  </section>
+  {{ end }}
 </div>
 <footer>
 v{{ .AtlantisVersion }}
@@ -120,6 +147,12 @@ type LockDetailData struct {
 	PullRequestLink string
 	LockedBy        string
 	Workspace       string
+	// HistoryPath is the path to the history of the pull request that holds
+	// the lock. It's empty if the lock doesn't have the pull's repo.
+	HistoryPath string
+	// Queue is the pull requests waiting for the lock, in the order they'll
+	// get it.
+	Queue           []LockQueueEntry
 	Time            time.Time
 	AtlantisVersion string
 	// CleanedBasePath is the path Atlantis is accessible at externally. If
@@ -128,6 +161,14 @@ type LockDetailData struct {
 	CleanedBasePath string
 }
 
+// LockQueueEntry is a pull request waiting for a lock.
+type LockQueueEntry struct {
+	PullRequestLink string
+	PullNum         int
+	User            string
+	TimeFormatted   string
+}
+
 var lockTemplate = template.Must(template.New("lock.html.tmpl").Parse(`
 <!DOCTYPE html>
 <html lang="en">
@@ -159,6 +200,17 @@ var lockTemplate = template.Must(template.New("lock.html.tmpl").Parse(`
         <h6><code>Pull Request Link</code>: <a href="{{.PullRequestLink}}" target="_blank"><strong>{{.PullRequestLink}}</strong></a></h6>
         <h6><code>Locked By</code>: <strong>{{.LockedBy}}</strong></h6>
         <h6><code>Workspace</code>: <strong>{{.Workspace}}</strong></h6>
+        {{ if .HistoryPath }}
+        <h6><a href="{{ .CleanedBasePath }}{{ .HistoryPath }}"><strong>Plan &amp; Apply History</strong></a></h6>
+        {{ end }}
+        {{ if .Queue }}
+        <h6><code>Queue</code>:</h6>
+        <ol>
+          {{ range .Queue }}
+          <li><a href="{{ .PullRequestLink }}" target="_blank"><strong>#{{ .PullNum }}</strong></a> by {{ .User }} since {{ .TimeFormatted }}</li>
+          {{ end }}
+        </ol>
+        {{ end }}
         <br>
       </div>
       <div class="four columns">
@@ -229,3 +281,272 @@ v{{ .AtlantisVersion }}
 </body>
 </html>
 `))
+
+// HistoryRunData holds the fields needed to display one run on the history
+// view.
+type HistoryRunData struct {
+	Command           string
+	Path              string
+	Workspace         string
+	ProjectName       string
+	Status            string
+	Output            string
+	User              string
+	HeadCommit        string
+	StartedFormatted  string
+	FinishedFormatted string
+}
+
+// HistoryData holds the fields needed to display the plan and apply history
+// of a pull request.
+type HistoryData struct {
+	RepoFullName    string
+	PullNum         int
+	Runs            []HistoryRunData
+	AtlantisVersion string
+	// CleanedBasePath is the path Atlantis is accessible at externally. If
+	// not using a path-based proxy, this will be an empty string. Never ends
+	// in a '/' (hence "cleaned").
+	CleanedBasePath string
+}
+
+var historyTemplate = template.Must(template.New("history.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/normalize.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/skeleton.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/custom.css">
+  <link rel="icon" type="image/png" href="{{ .CleanedBasePath }}/static/images/atlantis-icon.png">
+</head>
+<body>
+<div class="container">
+  <section class="header">
+    <a title="atlantis" href="{{ .CleanedBasePath }}/"><img class="hero" src="{{ .CleanedBasePath }}/static/images/atlantis-icon_512.png"/></a>
+    <p class="title-heading">atlantis</p>
+    <p class="title-heading"><strong>{{.RepoFullName}} #{{.PullNum}}</strong></p>
+  </section>
+  <div class="navbar-spacer"></div>
+  <br>
+  <section>
+    <p class="title-heading small"><strong>History</strong></p>
+    {{ if .Runs }}
+    {{ range .Runs }}
+      <details>
+        <summary>
+          <strong>{{.Command}}</strong> {{ if .ProjectName }}<span class="heading-font-size">{{.ProjectName}}</span> {{ end }}<code>{{.Path}}</code> <code>{{.Workspace}}</code> <code>{{.Status}}</code>
+          <span class="heading-font-size">{{.StartedFormatted}} - {{.FinishedFormatted}}{{ if .User }} by {{.User}}{{ end }}{{ if .HeadCommit }} at {{.HeadCommit}}{{ end }}</span>
+        </summary>
+        <pre><code>{{.Output}}</code></pre>
+      </details>
+    {{ end }}
+    {{ else }}
+    <p class="placeholder">No history found.</p>
+    {{ end }}
+  </section>
+</div>
+<footer>
+v{{ .AtlantisVersion }}
+</footer>
+</body>
+</html>
+`))
+
+// JobData holds the fields needed to display the job view.
+type JobData struct {
+	ID               string
+	Command          string
+	RepoFullName     string
+	PullNum          int
+	Path             string
+	Workspace        string
+	ProjectName      string
+	StartedFormatted string
+	AtlantisVersion  string
+	// CleanedBasePath is the path Atlantis is accessible at externally. If
+	// not using a path-based proxy, this will be an empty string. Never ends
+	// in a '/' (hence "cleaned").
+	CleanedBasePath string
+}
+
+var jobTemplate = template.Must(template.New("job.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/normalize.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/skeleton.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/custom.css">
+  <link rel="icon" type="image/png" href="{{ .CleanedBasePath }}/static/images/atlantis-icon.png">
+</head>
+<body>
+<div class="container">
+  <section class="header">
+    <a title="atlantis" href="{{ .CleanedBasePath }}/"><img class="hero" src="{{ .CleanedBasePath }}/static/images/atlantis-icon_512.png"/></a>
+    <p class="title-heading">atlantis</p>
+    <p class="title-heading"><strong>{{.RepoFullName}} #{{.PullNum}}</strong></p>
+  </section>
+  <div class="navbar-spacer"></div>
+  <br>
+  <section>
+    <p class="title-heading small">
+      <strong>{{.Command}}</strong> {{ if .ProjectName }}<span class="heading-font-size">{{.ProjectName}}</span> {{ end }}<code>{{.Path}}</code> <code>{{.Workspace}}</code> <code id="status">running</code>
+      <span class="heading-font-size">started {{.StartedFormatted}}</span>
+    </p>
+    <a class="button button-default" id="cancel">Cancel</a>
+    <pre><code id="output"></code></pre>
+  </section>
+</div>
+<footer>
+v{{ .AtlantisVersion }}
+</footer>
+<script>
+  var output = document.getElementById("output");
+  var jobStatus = document.getElementById("status");
+  var cancelBtn = document.getElementById("cancel");
+  var source = new EventSource("{{ .CleanedBasePath }}/jobs/{{ .ID }}/output");
+  source.onmessage = function(event) {
+    // Only follow the output if the user hasn't scrolled up.
+    var following = window.innerHeight + window.pageYOffset >= document.body.offsetHeight - 10;
+    output.appendChild(document.createTextNode(event.data + "\n"));
+    if (following) {
+      window.scrollTo(0, document.body.scrollHeight);
+    }
+  };
+  source.addEventListener("done", function(event) {
+    jobStatus.textContent = event.data;
+    cancelBtn.style.display = "none";
+    source.close();
+  });
+
+  cancelBtn.onclick = function() {
+    if (!window.confirm("Are you sure you want to cancel the plans and applies running for this pull request?")) {
+      return;
+    }
+    var req = new XMLHttpRequest();
+    req.open("POST", "{{ .CleanedBasePath }}/jobs/{{ .ID }}/cancel");
+    req.onload = function() {
+      if (req.status === 200) {
+        cancelBtn.textContent = "Cancelling...";
+      } else {
+        window.alert(req.responseText);
+      }
+    };
+    req.send();
+  };
+</script>
+</body>
+</html>
+`))
+
+// QueuedEventData holds the fields needed to display one event on the event
+// queue view.
+type QueuedEventData struct {
+	ID                string
+	Status            string
+	Failed            bool
+	Command           string
+	RepoFullName      string
+	PullNum           int
+	User              string
+	Attempts          int
+	Error             string
+	ReceivedFormatted string
+}
+
+// EventQueueData holds the fields needed to display the event queue view.
+type EventQueueData struct {
+	Events          []QueuedEventData
+	AtlantisVersion string
+	// CleanedBasePath is the path Atlantis is accessible at externally. If
+	// not using a path-based proxy, this will be an empty string. Never ends
+	// in a '/' (hence "cleaned").
+	CleanedBasePath string
+}
+
+var eventQueueTemplate = template.Must(template.New("event-queue.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/normalize.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/skeleton.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/custom.css">
+  <link rel="icon" type="image/png" href="{{ .CleanedBasePath }}/static/images/atlantis-icon.png">
+</head>
+<body>
+<div class="container">
+  <section class="header">
+    <a title="atlantis" href="{{ .CleanedBasePath }}/"><img class="hero" src="{{ .CleanedBasePath }}/static/images/atlantis-icon_512.png"/></a>
+    <p class="title-heading">atlantis</p>
+    <p class="title-heading"><strong>Event Queue</strong></p>
+  </section>
+  <div class="navbar-spacer"></div>
+  <br>
+  <section>
+    {{ if .Events }}
+    {{ range .Events }}
+      <details>
+        <summary>
+          <strong>{{.Command}}</strong> {{.RepoFullName}} #{{.PullNum}} <code>{{.Status}}</code>
+          <span class="heading-font-size">received {{.ReceivedFormatted}}{{ if .User }} from {{.User}}{{ end }}, {{.Attempts}} attempt(s)</span>
+          {{ if .Failed }}
+          <a class="button button-default retry" data-id="{{.ID}}">Retry</a>
+          <a class="button button-default discard" data-id="{{.ID}}">Discard</a>
+          {{ end }}
+        </summary>
+        <p><code>{{.ID}}</code></p>
+        {{ if .Error }}<pre><code>{{.Error}}</code></pre>{{ end }}
+      </details>
+    {{ end }}
+    {{ else }}
+    <p class="placeholder">No pending or failed events.</p>
+    {{ end }}
+  </section>
+</div>
+<footer>
+v{{ .AtlantisVersion }}
+</footer>
+<script>
+  function send(method, path) {
+    var req = new XMLHttpRequest();
+    req.open(method, "{{ .CleanedBasePath }}/event-queue/" + path);
+    req.onload = function() {
+      if (req.status === 200) {
+        window.location.reload();
+      } else {
+        window.alert(req.responseText);
+      }
+    };
+    req.send();
+  }
+  Array.prototype.forEach.call(document.getElementsByClassName("retry"), function(btn) {
+    btn.onclick = function() {
+      send("POST", encodeURIComponent(btn.getAttribute("data-id")) + "/retry");
+    };
+  });
+  Array.prototype.forEach.call(document.getElementsByClassName("discard"), function(btn) {
+    btn.onclick = function() {
+      if (!window.confirm("Are you sure you want to discard this event? Its command won't be run.")) {
+        return;
+      }
+      send("DELETE", encodeURIComponent(btn.getAttribute("data-id")));
+    };
+  });
+</script>
+</body>
+</html>
+`))